@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/joncrangle/podcasts-sync/internal"
+)
+
+// runAnalyze prints throughput percentiles and stalls for a progress recording.
+// With no file argument the most recent recording is analyzed.
+func runAnalyze(args []string) error {
+	fs := flag.NewFlagSet("analyze", flag.ContinueOnError)
+	stall := fs.Duration("stall", internal.DefaultStallThreshold, "Minimum duration without progress reported as a stall")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: podcasts-sync analyze [--stall 2s] [recording.jsonl]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+
+	path := fs.Arg(0)
+	if path == "" {
+		latest, err := internal.LatestRecording(internal.DefaultRecordingsDir())
+		if err != nil {
+			return err
+		}
+		path = latest
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	a, err := internal.AnalyzeRecording(file, *stall)
+	if err != nil {
+		return err
+	}
+
+	mbps := func(bytesPerSec float64) string {
+		return fmt.Sprintf("%.1f MB/s", bytesPerSec/1024/1024)
+	}
+
+	fmt.Printf("Recording: %s\n", path)
+	fmt.Printf("Samples:   %d over %s\n", a.Samples, a.Duration.Round(100*time.Millisecond))
+	fmt.Printf("Bytes:     %s (average %s)\n", internal.FormatBytes(a.Bytes), mbps(a.Average))
+	fmt.Printf("Speed:     min %s | p50 %s | p90 %s | p99 %s | max %s\n",
+		mbps(a.Min), mbps(a.P50), mbps(a.P90), mbps(a.P99), mbps(a.Max))
+
+	if len(a.Stalls) == 0 {
+		fmt.Printf("Stalls:    none longer than %s\n", *stall)
+		return nil
+	}
+	fmt.Printf("Stalls:    %d longer than %s\n", len(a.Stalls), *stall)
+	for _, s := range a.Stalls {
+		fmt.Printf("  %8s - %8s  (%s)  %s\n",
+			s.Start.Round(100*time.Millisecond), s.End.Round(100*time.Millisecond), (s.End - s.Start).Round(100*time.Millisecond), s.File)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joncrangle/podcasts-sync/internal"
+)
+
+// runBench times the scan, match and copy pipelines on a synthetic tree.
+// With --dir on a mounted drive it measures that drive rather than the local disk.
+func runBench(args []string) error {
+	fs := flag.NewFlagSet("bench", flag.ContinueOnError)
+	dir := fs.String("dir", "", "Directory to write the synthetic library and drive in (default: system temp dir)")
+	files := fs.Int("files", 2000, "Number of episodes")
+	sizeKB := fs.Int64("size", 256, "Approximate episode size in KB")
+	buffers := fs.String("buffers", "32,256,1024", "Comma-separated copy buffer sizes in KB")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: podcasts-sync bench [--dir path] [--files 2000] [--size 256] [--buffers 32,256,1024]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if *files < 1 || *sizeKB < 1 {
+		return errors.New("--files and --size must be positive")
+	}
+
+	opts := internal.BenchOptions{Dir: *dir, Files: *files, FileSize: *sizeKB * 1024}
+	for _, field := range strings.Split(*buffers, ",") {
+		kb, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || kb < 1 {
+			return fmt.Errorf("invalid buffer size %q", field)
+		}
+		opts.BufferSizes = append(opts.BufferSizes, kb*1024)
+	}
+
+	fmt.Printf("Writing %d episodes of about %s...\n", opts.Files, internal.FormatBytes(opts.FileSize))
+	results, err := internal.RunBench(opts)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range results {
+		line := fmt.Sprintf("%-22s %8s  %10.0f files/s", r.Stage, r.Elapsed.Round(time.Millisecond), r.FilesPerSecond())
+		if r.Bytes > 0 {
+			line += fmt.Sprintf("  %8.1f MB/s", r.BytesPerSecond()/1024/1024)
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/joncrangle/podcasts-sync/internal"
+)
+
+// runChecksums writes a SHA256SUMS file listing every file in a drive's podcasts folder
+func runChecksums(args []string) error {
+	fs := flag.NewFlagSet("checksums", flag.ContinueOnError)
+	driveName := fs.String("drive", "", "Drive whose podcasts folder to list; the default is the only mounted drive")
+	dir := fs.String("dir", "", "Folder to list instead of a drive")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: podcasts-sync checksums [--drive NAME | --dir PATH]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+
+	podcastDir, speed, err := checksumFolder(*driveName, *dir)
+	if err != nil {
+		return err
+	}
+	n, err := internal.WriteChecksums(podcastDir, speed, hashProgress())
+	if err != nil {
+		return err
+	}
+	fmt.Printf("listed %d file(s) in %s\n", n, filepath.Join(podcastDir, internal.ChecksumsFile))
+	return nil
+}
+
+// runVerify checks a drive's podcasts folder against a SHA256SUMS file, by default the one in the folder
+func runVerify(args []string) error {
+	fs := flag.NewFlagSet("verify", flag.ContinueOnError)
+	driveName := fs.String("drive", "", "Drive whose podcasts folder to verify; the default is the only mounted drive")
+	dir := fs.String("dir", "", "Folder to verify instead of a drive")
+	sums := fs.String("sums", "", "Checksum file to verify against; the default is SHA256SUMS in the folder")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: podcasts-sync verify [--drive NAME | --dir PATH] [--sums FILE]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+
+	podcastDir, speed, err := checksumFolder(*driveName, *dir)
+	if err != nil {
+		return err
+	}
+	sumsPath := *sums
+	if sumsPath == "" {
+		sumsPath = filepath.Join(podcastDir, internal.ChecksumsFile)
+	}
+	report, err := internal.VerifyChecksums(podcastDir, sumsPath, speed, hashProgress())
+	if err != nil {
+		return err
+	}
+
+	for _, group := range []struct {
+		label string
+		files []string
+	}{{"FAILED", report.Mismatched}, {"MISSING", report.Missing}, {"NOT LISTED", report.Unlisted}} {
+		for _, rel := range group.files {
+			fmt.Printf("%s: %s\n", rel, group.label)
+		}
+	}
+	fmt.Printf("%d file(s) verified", report.Verified)
+	var problems []string
+	if n := len(report.Mismatched); n > 0 {
+		problems = append(problems, fmt.Sprintf("%d failed", n))
+	}
+	if n := len(report.Missing); n > 0 {
+		problems = append(problems, fmt.Sprintf("%d missing", n))
+	}
+	if n := len(report.Unlisted); n > 0 {
+		problems = append(problems, fmt.Sprintf("%d not listed", n))
+	}
+	if len(problems) > 0 {
+		fmt.Printf(", %s", strings.Join(problems, ", "))
+	}
+	fmt.Println()
+	if !report.OK() {
+		return errors.New("the folder doesn't match its checksums")
+	}
+	return nil
+}
+
+// checksumFolder returns the folder to hash, with the measured speed of its drive to size the hash pool
+func checksumFolder(driveName, dir string) (string, internal.DriveSpeed, error) {
+	if dir == "" {
+		drive, err := connectedDrive(driveName)
+		if err != nil {
+			return "", internal.DriveSpeed{}, err
+		}
+		return filepath.Join(drive.MountPath, drive.Folder), drive.Profile.Speed, nil
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return "", internal.DriveSpeed{}, fmt.Errorf("%s is not a folder", dir)
+	}
+	return dir, internal.DriveSpeed{}, nil
+}
+
+// hashProgress reports the files and bytes hashed so far on a line of stderr that is rewritten in
+// place, when stderr is a terminal; the line is cleared once every file is hashed
+func hashProgress() func(internal.HashProgress) {
+	if info, err := os.Stderr.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return nil
+	}
+	return func(p internal.HashProgress) {
+		fmt.Fprintf(os.Stderr, "\r\033[Khashed %d/%d file(s), %s of %s", p.Files, p.TotalFiles, internal.FormatBytes(p.Bytes), internal.FormatBytes(p.TotalBytes))
+		if p.Files == p.TotalFiles {
+			fmt.Fprint(os.Stderr, "\r\033[K")
+		}
+	}
+}
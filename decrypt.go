@@ -0,0 +1,84 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/joncrangle/podcasts-sync/internal"
+)
+
+// runDecrypt extracts the episodes in a drive's encrypted folder, for reading them without podcasts-sync's mirror
+func runDecrypt(args []string) error {
+	fs := flag.NewFlagSet("decrypt", flag.ContinueOnError)
+	driveName := fs.String("drive", "", "Drive whose encrypted folder to extract")
+	dir := fs.String("dir", "", "Encrypted folder to extract instead of a drive's")
+	out := fs.String("out", "", "Folder to write the decrypted episodes to")
+	passphraseFile := fs.String("passphrase-file", "", "File holding the passphrase; the default is the drive profile's, or "+internal.PassphraseEnv)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: podcasts-sync decrypt (--drive NAME | --dir PATH) --out DIR [--passphrase-file FILE]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if *out == "" || (*driveName == "") == (*dir == "") {
+		fs.Usage()
+		return errors.New("--out and one of --drive or --dir are required")
+	}
+
+	settings := internal.EncryptSettings{PassphraseFile: *passphraseFile}
+	folder := *dir
+	if *driveName != "" {
+		drive, err := encryptedDrive(*driveName)
+		if err != nil {
+			return err
+		}
+		folder = drive.EncryptedFolder()
+		if settings.PassphraseFile == "" {
+			settings = *drive.Profile.Encrypt
+		}
+	}
+	passphrase, err := settings.Passphrase()
+	if err != nil {
+		return err
+	}
+
+	n, err := internal.DecryptFolder(folder, passphrase, *out)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("decrypted %d file(s) to %s\n", n, *out)
+	return nil
+}
+
+// encryptedDrive returns the named drive, which has to be mounted and encrypted by its profile
+func encryptedDrive(name string) (internal.USBDrive, error) {
+	cfg, err := internal.LoadConfig(internal.DefaultConfigPath())
+	if err != nil {
+		return internal.USBDrive{}, err
+	}
+	drives := internal.NewDriveManager("/Volumes", internal.DirectoryTemplate{})
+	drives.SetProfiles(cfg.Drives)
+	drives.SetMirrors(internal.DefaultMirrorsDir())
+	mounted, err := drives.DetectDrives()
+	if err != nil {
+		return internal.USBDrive{}, err
+	}
+	i := slices.IndexFunc(mounted, func(d internal.USBDrive) bool { return d.Name == name })
+	switch {
+	case i < 0:
+		return internal.USBDrive{}, fmt.Errorf("drive %q is not connected", name)
+	case mounted[i].EncryptedFolder() == "":
+		return internal.USBDrive{}, fmt.Errorf("drive %q has no encrypt setting in its profile", name)
+	}
+	if _, err := os.Stat(mounted[i].EncryptedFolder()); err != nil {
+		return internal.USBDrive{}, fmt.Errorf("drive %q has no encrypted folder yet: %w", name, err)
+	}
+	return mounted[i], nil
+}
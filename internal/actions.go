@@ -0,0 +1,157 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ActionKind names the high-level actions the history remembers the last of
+type ActionKind string
+
+const (
+	ActionSync   ActionKind = "sync"
+	ActionDelete ActionKind = "delete"
+	ActionFilter ActionKind = "filter"
+)
+
+// Action is a user action on the library or a drive, kept so it can be repeated
+type Action struct {
+	Kind  ActionKind
+	Drive string
+	// Shows are the shows of the episodes the action touched, sorted
+	Shows    []string
+	Episodes int
+	// Filter is the quick list an ActionFilter applied
+	Filter string
+	At     time.Time
+}
+
+// NewAction describes kind applied to episodes on drive
+func NewAction(kind ActionKind, drive string, episodes []PodcastEpisode) Action {
+	a := Action{Kind: kind, Drive: drive, Episodes: len(episodes)}
+	for _, e := range episodes {
+		if !slices.Contains(a.Shows, e.ShowName) {
+			a.Shows = append(a.Shows, e.ShowName)
+		}
+	}
+	slices.Sort(a.Shows)
+	return a
+}
+
+// RecordAction stores a as the last action of its kind, stamping it with the current time if it has none
+func (h *History) RecordAction(a Action) error {
+	if h == nil {
+		return nil
+	}
+
+	db, err := h.open()
+	if err != nil {
+		return err
+	}
+
+	if a.At.IsZero() {
+		a.At = time.Now()
+	}
+	shows, err := json.Marshal(a.Shows)
+	if err != nil {
+		return fmt.Errorf("failed to encode action: %w", err)
+	}
+	_, err = db.Exec(
+		`INSERT OR REPLACE INTO actions (kind, drive, shows, episodes, filter, at) VALUES (?, ?, ?, ?, ?, ?)`,
+		string(a.Kind), a.Drive, string(shows), a.Episodes, a.Filter, a.At.Unix(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record action: %w", err)
+	}
+	return nil
+}
+
+// LastActions returns the last recorded action of each kind
+func (h *History) LastActions() (map[ActionKind]Action, error) {
+	actions := map[ActionKind]Action{}
+	if h == nil {
+		return actions, nil
+	}
+
+	db, err := h.open()
+	if err != nil {
+		return actions, err
+	}
+
+	rows, err := db.Query(`SELECT kind, drive, shows, episodes, filter, at FROM actions`)
+	if err != nil {
+		return actions, fmt.Errorf("failed to query actions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			a           Action
+			kind, shows string
+			at          int64
+		)
+		if err := rows.Scan(&kind, &a.Drive, &shows, &a.Episodes, &a.Filter, &at); err != nil {
+			return actions, fmt.Errorf("failed to read actions: %w", err)
+		}
+		if err := json.Unmarshal([]byte(shows), &a.Shows); err != nil {
+			return actions, fmt.Errorf("failed to read actions: %w", err)
+		}
+		a.Kind = ActionKind(kind)
+		a.At = time.Unix(at, 0)
+		actions[a.Kind] = a
+	}
+	return actions, rows.Err()
+}
+
+// SelectRepeat selects the episodes a repeat of the sync a would copy: the downloaded episodes of
+// its shows that aren't on the drive yet, skipping shows whose policy is never to sync.
+// Returns the number of episodes selected.
+func SelectRepeat(episodes []PodcastEpisode, a Action, cfg *Config) int {
+	var n int
+	for i := range episodes {
+		e := &episodes[i]
+		if !slices.Contains(a.Shows, e.ShowName) || e.Missing || e.Downloading || e.OnDrive {
+			continue
+		}
+		if cfg.PolicyFor(e.ShowName).Sync == SyncNever {
+			continue
+		}
+		e.Selected = true
+		n++
+	}
+	return n
+}
+
+// SelectShows selects the downloaded episodes of the named shows that aren't on the drive yet, or those
+// of every show whose policy isn't never to sync when no show is named. Show names ignore case.
+// Returns the number of episodes selected, or an error naming a show that isn't in the library.
+func SelectShows(episodes []PodcastEpisode, shows []string, cfg *Config) (int, error) {
+	named := func(show string) bool {
+		return slices.ContainsFunc(shows, func(s string) bool { return strings.EqualFold(s, show) })
+	}
+	for _, show := range shows {
+		if !slices.ContainsFunc(episodes, func(e PodcastEpisode) bool { return strings.EqualFold(e.ShowName, show) }) {
+			return 0, fmt.Errorf("no show named %q in the library", show)
+		}
+	}
+
+	var n int
+	for i := range episodes {
+		e := &episodes[i]
+		if e.Missing || e.Downloading || e.OnDrive {
+			continue
+		}
+		if len(shows) > 0 && !named(e.ShowName) {
+			continue
+		}
+		if len(shows) == 0 && cfg.PolicyFor(e.ShowName).Sync == SyncNever {
+			continue
+		}
+		e.Selected = true
+		n++
+	}
+	return n, nil
+}
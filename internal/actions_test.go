@@ -0,0 +1,105 @@
+package internal
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestHistory_LastActions(t *testing.T) {
+	h := NewHistory(filepath.Join(t.TempDir(), "history.db"))
+	defer h.Close()
+
+	episodes := []PodcastEpisode{{ZTitle: "B1", ShowName: "B"}, {ZTitle: "A1", ShowName: "A"}, {ZTitle: "B2", ShowName: "B"}}
+	first := NewAction(ActionSync, "STICK", episodes[:1])
+	second := NewAction(ActionSync, "CAR", episodes)
+	for _, a := range []Action{first, second, {Kind: ActionFilter, Filter: "Favorites"}} {
+		if err := h.RecordAction(a); err != nil {
+			t.Fatalf("RecordAction failed: %v", err)
+		}
+	}
+
+	actions, err := h.LastActions()
+	if err != nil {
+		t.Fatalf("LastActions failed: %v", err)
+	}
+	sync := actions[ActionSync]
+	if sync.Drive != "CAR" || sync.Episodes != 3 || !slices.Equal(sync.Shows, []string{"A", "B"}) || sync.At.IsZero() {
+		t.Errorf("Expected the second sync to be the last, got %+v", sync)
+	}
+	if actions[ActionFilter].Filter != "Favorites" {
+		t.Errorf("Expected the quick list to be remembered, got %+v", actions[ActionFilter])
+	}
+	if _, ok := actions[ActionDelete]; ok {
+		t.Error("Expected no delete to be recorded")
+	}
+
+	var nilHistory *History
+	if err := nilHistory.RecordAction(first); err != nil {
+		t.Errorf("Expected a nil history to record nothing, got %v", err)
+	}
+}
+
+func TestSelectRepeat(t *testing.T) {
+	episodes := []PodcastEpisode{
+		{ZTitle: "New", ShowName: "Weekly", Published: time.Now()},
+		{ZTitle: "Synced", ShowName: "Weekly", OnDrive: true},
+		{ZTitle: "Streamed", ShowName: "Weekly", Missing: true},
+		{ZTitle: "Blocked", ShowName: "Muted"},
+		{ZTitle: "Other", ShowName: "Daily"},
+	}
+	cfg := &Config{Shows: map[string]ShowPolicy{"Muted": {Sync: SyncNever}}}
+	last := Action{Kind: ActionSync, Shows: []string{"Muted", "Weekly"}}
+
+	if n := SelectRepeat(episodes, last, cfg); n != 1 {
+		t.Errorf("Expected 1 episode to be selected, got %d", n)
+	}
+	for _, e := range episodes {
+		if e.Selected != (e.ZTitle == "New") {
+			t.Errorf("Unexpected selection of %q: %v", e.ZTitle, e.Selected)
+		}
+	}
+}
+
+func TestSelectShows(t *testing.T) {
+	library := func() []PodcastEpisode {
+		return []PodcastEpisode{
+			{ZTitle: "New", ShowName: "Weekly"},
+			{ZTitle: "Synced", ShowName: "Weekly", OnDrive: true},
+			{ZTitle: "Streamed", ShowName: "Weekly", Missing: true},
+			{ZTitle: "Blocked", ShowName: "Muted"},
+			{ZTitle: "Other", ShowName: "Daily"},
+		}
+	}
+	cfg := &Config{Shows: map[string]ShowPolicy{"Muted": {Sync: SyncNever}}}
+	selected := func(episodes []PodcastEpisode) []string {
+		var titles []string
+		for _, e := range episodes {
+			if e.Selected {
+				titles = append(titles, e.ZTitle)
+			}
+		}
+		return titles
+	}
+
+	episodes := library()
+	if n, err := SelectShows(episodes, []string{"weekly", "Muted"}, cfg); err != nil || n != 2 {
+		t.Errorf("Expected 2 episodes of the named shows, got %d (%v)", n, err)
+	}
+	if got := selected(episodes); !slices.Equal(got, []string{"New", "Blocked"}) {
+		t.Errorf("Expected the new episodes of the named shows, even one never synced by policy, got %v", got)
+	}
+
+	episodes = library()
+	if n, err := SelectShows(episodes, nil, cfg); err != nil || n != 2 {
+		t.Errorf("Expected 2 episodes of every show, got %d (%v)", n, err)
+	}
+	if got := selected(episodes); !slices.Equal(got, []string{"New", "Other"}) {
+		t.Errorf("Expected every show but the one never synced, got %v", got)
+	}
+
+	if _, err := SelectShows(library(), []string{"Unknown"}, cfg); err == nil {
+		t.Error("Expected an error for a show that isn't in the library")
+	}
+}
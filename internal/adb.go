@@ -0,0 +1,185 @@
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// DefaultADBFolder is where episodes go on Android devices whose profile sets no adbFolder
+const DefaultADBFolder = "/sdcard/Podcasts"
+
+// adbBatch caps the paths passed to one adb shell command
+const adbBatch = 50
+
+// adbCommand builds an adb invocation; tests replace it
+var adbCommand = func(args ...string) *exec.Cmd {
+	return exec.Command("adb", args...)
+}
+
+// ADBFolder returns the folder episodes are pushed to on an Android device with this profile
+func (p DriveProfile) ADBFolder() string {
+	if !strings.HasPrefix(p.ADBDeviceFolder, "/") {
+		return DefaultADBFolder
+	}
+	return path.Clean(p.ADBDeviceFolder)
+}
+
+// detectADBDevices returns the Android devices adb reports as ready. Without adb there are none.
+func (dm *DriveManager) detectADBDevices() []USBDrive {
+	out, err := adbCommand("devices", "-l").Output()
+	if err != nil {
+		return nil
+	}
+	var drives []USBDrive
+	for _, device := range parseADBDevices(out) {
+		mirror := filepath.Join(dm.mirrors, "adb", device.serial)
+		if err := os.MkdirAll(mirror, 0o755); err != nil {
+			continue
+		}
+		drives = append(drives, USBDrive{
+			Name:      device.name,
+			MountPath: mirror,
+			Serial:    device.serial,
+			Profile:   dm.profile(device.name),
+		})
+	}
+	return drives
+}
+
+type adbDevice struct {
+	serial string
+	name   string
+}
+
+// parseADBDevices reads the output of adb devices -l. Devices are named after their model,
+// with the serial added when two share one.
+func parseADBDevices(out []byte) []adbDevice {
+	var devices []adbDevice
+	models := make(map[string]int)
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		// Offline and unauthorized devices can't be pushed to
+		if len(fields) < 2 || fields[1] != "device" {
+			continue
+		}
+		device := adbDevice{serial: fields[0], name: fields[0]}
+		for _, field := range fields[2:] {
+			if model, ok := strings.CutPrefix(field, "model:"); ok {
+				device.name = strings.ReplaceAll(model, "_", " ")
+			}
+		}
+		models[device.name]++
+		devices = append(devices, device)
+	}
+	for i, device := range devices {
+		if models[device.name] > 1 {
+			devices[i].name = fmt.Sprintf("%s (%s)", device.name, device.serial)
+		}
+	}
+	return devices
+}
+
+// shellQuote quotes s for the device's shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// adbShell runs script on the device and returns its output
+func adbShell(serial, script string, stdin io.Reader) ([]byte, error) {
+	cmd := adbCommand("-s", serial, "shell", script)
+	cmd.Stdin = stdin
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return out, fmt.Errorf("adb: %s: %w", msg, err)
+		}
+		return out, fmt.Errorf("adb: %w", err)
+	}
+	return out, nil
+}
+
+// listADBFolder returns the size of each file below folder on the device, keyed by slash-separated path
+func listADBFolder(serial, folder string) (map[string]int64, error) {
+	q := shellQuote(folder)
+	out, err := adbShell(serial, fmt.Sprintf("if [ -d %s ]; then find %s -type f -exec stat -c '%%s %%n' {} +; fi", q, q), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s on the device: %w", folder, err)
+	}
+	return parseADBListing(out, folder), nil
+}
+
+// parseADBListing reads "size path" lines, keeping the files below folder
+func parseADBListing(out []byte, folder string) map[string]int64 {
+	files := make(map[string]int64)
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		sizeField, name, ok := strings.Cut(strings.TrimRight(scanner.Text(), "\r"), " ")
+		size, err := strconv.ParseInt(sizeField, 10, 64)
+		if !ok || err != nil {
+			continue
+		}
+		if rel, ok := strings.CutPrefix(name, folder+"/"); ok && rel != "" {
+			files[rel] = size
+		}
+	}
+	return files
+}
+
+// adbDestination pushes to a folder on an Android device through adb shell, which needs Android 7 or later
+// to pass file contents through unchanged
+type adbDestination struct {
+	serial string
+	folder string
+}
+
+func (a adbDestination) String() string { return "adb " + a.serial + ":" + a.folder }
+
+// Tuning pushes one file at a time: adb shares one USB connection
+func (a adbDestination) Tuning() PushTuning { return PushTuning{Workers: 1, Attempts: 1} }
+
+func (a adbDestination) List() (map[string]int64, error) {
+	return listADBFolder(a.serial, a.folder)
+}
+
+// Put streams r into the file through a partial file renamed once it has all size bytes.
+// A stream cut short, e.g. by cancelling, still ends cat cleanly, so the size is what tells.
+func (a adbDestination) Put(rel string, r io.Reader, size int64) error {
+	dst := path.Join(a.folder, rel)
+	partial := shellQuote(dst + partialSuffix)
+	script := fmt.Sprintf("mkdir -p %s && cat > %s && [ \"$(stat -c %%s %s)\" = %d ] && mv %s %s",
+		shellQuote(path.Dir(dst)), partial, partial, size, partial, shellQuote(dst))
+	if _, err := adbShell(a.serial, script, r); err != nil {
+		_, _ = adbShell(a.serial, "rm -f "+partial, nil)
+		return err
+	}
+	return nil
+}
+
+func (a adbDestination) Remove(rels []string) error {
+	var errs []error
+	for batch := range slices.Chunk(rels, adbBatch) {
+		quoted := make([]string, len(batch))
+		for i, rel := range batch {
+			quoted[i] = shellQuote(path.Join(a.folder, rel))
+		}
+		if _, err := adbShell(a.serial, "rm -f "+strings.Join(quoted, " "), nil); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	// rmdir leaves the folders still holding files
+	_, _ = adbShell(a.serial, fmt.Sprintf("find %s -mindepth 1 -depth -type d -exec rmdir {} + 2>/dev/null; true", shellQuote(a.folder)), nil)
+	return errors.Join(errs...)
+}
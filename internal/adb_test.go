@@ -0,0 +1,83 @@
+package internal
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestParseADBDevices(t *testing.T) {
+	out := []byte(`List of devices attached
+R58M12345      device usb:1-1 product:beyond1 model:SM_G973F device:beyond1 transport_id:2
+emulator-5554  offline
+0A1B2C         unauthorized usb:1-2 transport_id:3
+PIX1           device usb:1-3 product:panther model:Pixel_7 device:panther transport_id:4
+PIX2           device usb:1-4 product:panther model:Pixel_7 device:panther transport_id:5
+`)
+	got := parseADBDevices(out)
+	want := []adbDevice{
+		{serial: "R58M12345", name: "SM G973F"},
+		{serial: "PIX1", name: "Pixel 7 (PIX1)"},
+		{serial: "PIX2", name: "Pixel 7 (PIX2)"},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("Expected ready devices named after their model, got %+v", got)
+	}
+}
+
+// fakeADB runs adb shell scripts on this machine, so a temporary folder stands in for the device
+func fakeADB(t *testing.T) {
+	t.Helper()
+	if exec.Command("stat", "-c", "%s", ".").Run() != nil {
+		t.Skip("needs a stat supporting -c, as on Android")
+	}
+	orig := adbCommand
+	adbCommand = func(args ...string) *exec.Cmd {
+		return exec.Command("sh", "-c", args[len(args)-1])
+	}
+	t.Cleanup(func() { adbCommand = orig })
+}
+
+func TestPushMirror_ADB(t *testing.T) {
+	fakeADB(t)
+	mirror := t.TempDir()
+	device := filepath.Join(t.TempDir(), "Podcasts")
+	writeDriveFiles(t, mirror, "Show/One.mp3", "Show/It's two.mp3", ".podcasts-sync.json", "Show/Three.mp3.part")
+	writeDriveFiles(t, device, "Mine/song.mp3")
+
+	if err := pushMirror(adbDestination{serial: "serial", folder: device}, mirror, nil); err != nil {
+		t.Fatalf("pushMirror failed: %v", err)
+	}
+	for _, name := range []string{"Show/One.mp3", "Show/It's two.mp3", "Mine/song.mp3"} {
+		if _, err := os.Stat(filepath.Join(device, filepath.FromSlash(name))); err != nil {
+			t.Errorf("Expected %s on the device: %v", name, err)
+		}
+	}
+	for _, name := range []string{".podcasts-sync.json", "Show/Three.mp3.part", "Show/One.mp3.part"} {
+		if _, err := os.Stat(filepath.Join(device, filepath.FromSlash(name))); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to stay off the device, got %v", name, err)
+		}
+	}
+
+	// One episode is deleted in the app, the other from the mirror
+	if err := os.Remove(filepath.Join(device, "Show", "One.mp3")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(mirror, "Show", "It's two.mp3")); err != nil {
+		t.Fatal(err)
+	}
+	if err := pushMirror(adbDestination{serial: "serial", folder: device}, mirror, nil); err != nil {
+		t.Fatalf("pushMirror failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(mirror, "Show", "One.mp3")); !os.IsNotExist(err) {
+		t.Errorf("Expected the episode deleted on the device to leave the mirror, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(device, "Show")); !os.IsNotExist(err) {
+		t.Errorf("Expected the emptied show folder to be removed from the device, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(device, "Mine", "song.mp3")); err != nil {
+		t.Errorf("Expected files podcasts-sync didn't push to be kept: %v", err)
+	}
+}
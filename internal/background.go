@@ -0,0 +1,37 @@
+package internal
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Copies in background mode rest for backgroundPause after every backgroundChunk, leaving the
+// drive and the disk to other apps in between; tests shorten the pause
+const backgroundChunk = 8 << 20
+
+var backgroundPause = 100 * time.Millisecond
+
+var background atomic.Bool
+
+// SetBackground lowers the process's CPU and IO priority and paces copies with short pauses, so a
+// large sync doesn't make the rest of the computer sluggish. Running syncs slow down or speed up at once.
+func SetBackground(on bool) error {
+	background.Store(on)
+	return setBackgroundPriority(on)
+}
+
+// Background reports whether background mode is on
+func Background() bool {
+	return background.Load()
+}
+
+// pace rests after every backgroundChunk copied while background mode is on
+func (tm *TransferManager) pace(n int64) {
+	if tm.unpaced || !background.Load() {
+		return
+	}
+	if tm.paced.Add(n) >= backgroundChunk {
+		tm.paced.Store(0)
+		time.Sleep(backgroundPause)
+	}
+}
@@ -0,0 +1,18 @@
+package internal
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+)
+
+// setBackgroundPriority applies or removes the background task policy, which lowers both the CPU
+// and the IO priority of the process. Like holdWakeAssertion it runs a tool rather than linking
+// libSystem through cgo.
+func setBackgroundPriority(on bool) error {
+	flag := "-B"
+	if on {
+		flag = "-b"
+	}
+	return exec.Command("/usr/sbin/taskpolicy", flag, "-p", strconv.Itoa(os.Getpid())).Run()
+}
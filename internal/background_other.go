@@ -0,0 +1,8 @@
+//go:build !darwin
+
+package internal
+
+// setBackgroundPriority leaves the priority alone where there's no task policy; copies are still paced
+func setBackgroundPriority(on bool) error {
+	return nil
+}
@@ -0,0 +1,39 @@
+package internal
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPace(t *testing.T) {
+	orig := backgroundPause
+	backgroundPause = 50 * time.Millisecond
+	t.Cleanup(func() {
+		backgroundPause = orig
+		_ = SetBackground(false)
+	})
+
+	copyChunk := func(tm *TransferManager) time.Duration {
+		start := time.Now()
+		if _, err := tm.Write(make([]byte, backgroundChunk)); err != nil {
+			t.Fatal(err)
+		}
+		return time.Since(start)
+	}
+	tm := NewTransferManager(4*backgroundChunk, 1, nil)
+	defer tm.Stop()
+
+	if d := copyChunk(tm); d >= backgroundPause {
+		t.Errorf("Expected no pause outside background mode, took %s", d)
+	}
+	if err := SetBackground(true); err != nil {
+		t.Fatal(err)
+	}
+	if d := copyChunk(tm); d < backgroundPause {
+		t.Errorf("Expected a pause after a chunk in background mode, took %s", d)
+	}
+	tm.unpaced = true
+	if d := copyChunk(tm); d >= backgroundPause {
+		t.Errorf("Expected no pause for a benchmark, took %s", d)
+	}
+}
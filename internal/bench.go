@@ -0,0 +1,221 @@
+package internal
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// BenchOptions sizes a synthetic run of the scan, match and copy pipelines
+type BenchOptions struct {
+	// Dir holds the synthetic library, drive and copies; pointing it at a real drive
+	// measures that drive. It is created if needed and the benchmark files are removed afterwards.
+	Dir         string
+	Files       int
+	FileSize    int64
+	BufferSizes []int // copy buffer sizes in bytes, one copy stage each
+}
+
+// BenchResult is the timing of one pipeline stage
+type BenchResult struct {
+	Stage   string
+	Files   int
+	Bytes   int64
+	Elapsed time.Duration
+}
+
+// FilesPerSecond returns the stage's file throughput
+func (r BenchResult) FilesPerSecond() float64 {
+	return float64(r.Files) / r.Elapsed.Seconds()
+}
+
+// BytesPerSecond returns the stage's byte throughput
+func (r BenchResult) BytesPerSecond() float64 {
+	return float64(r.Bytes) / r.Elapsed.Seconds()
+}
+
+// benchDriveName is the volume name of the drive written by WriteBenchTree
+const benchDriveName = "BENCH"
+
+// WriteBenchTree writes a library of files episodes of roughly size bytes under dir, and a drive
+// holding a copy of each. Every fourth drive copy is renamed so matching falls back to file size.
+func WriteBenchTree(dir string, files int, size int64) ([]PodcastEpisode, USBDrive, error) {
+	drive := USBDrive{Name: benchDriveName, MountPath: filepath.Join(dir, "Volumes", benchDriveName), Folder: "podcasts"}
+	libraryDir := filepath.Join(dir, "Library")
+	if err := os.MkdirAll(libraryDir, 0o755); err != nil {
+		return nil, drive, fmt.Errorf("failed to create benchmark library: %w", err)
+	}
+
+	const frameSize = 417 // see demoAudio
+	newest := time.Date(2024, time.March, 28, 12, 0, 0, 0, time.UTC)
+	library := make([]PodcastEpisode, 0, files)
+	for i := range files {
+		path := filepath.Join(libraryDir, fmt.Sprintf("episode-%05d.mp3", i))
+		// One more frame per episode keeps sizes unique, as they mostly are in real libraries
+		data := demoAudio(max(1, int(size/frameSize)) + i)
+		episode := PodcastEpisode{
+			ZTitle:    fmt.Sprintf("Episode %d", i),
+			ShowName:  fmt.Sprintf("Show %d", i%40),
+			FilePath:  (&url.URL{Scheme: "file", Path: path}).String(),
+			FileSize:  int64(len(data)),
+			Published: newest.Add(-time.Duration(i) * time.Hour),
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			return nil, drive, fmt.Errorf("failed to write benchmark episode: %w", err)
+		}
+
+		drivePath := filepath.Join(drive.MountPath, drive.Folder, LayoutShow.RelPath(episode))
+		if i%4 == 3 {
+			drivePath = filepath.Join(drive.MountPath, drive.Folder, "Unsorted", fmt.Sprintf("track %05d.mp3", i))
+		}
+		if err := os.MkdirAll(filepath.Dir(drivePath), 0o755); err != nil {
+			return nil, drive, fmt.Errorf("failed to create benchmark show folder: %w", err)
+		}
+		if err := os.WriteFile(drivePath, data, 0o644); err != nil {
+			return nil, drive, fmt.Errorf("failed to write benchmark drive episode: %w", err)
+		}
+		library = append(library, episode)
+	}
+	return library, drive, nil
+}
+
+// RunBench writes a benchmark tree and times scanning the drive, matching the scan against the
+// library, and copying the library once per buffer size, plus once in the kernel where supported
+func RunBench(opts BenchOptions) ([]BenchResult, error) {
+	dir, err := os.MkdirTemp(opts.Dir, "podcasts-sync-bench-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create benchmark directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	library, drive, err := WriteBenchTree(dir, opts.Files, opts.FileSize)
+	if err != nil {
+		return nil, err
+	}
+	var libraryBytes int64
+	bySize := make(map[int64][]*PodcastEpisode)
+	for i := range library {
+		libraryBytes += library[i].FileSize
+		bySize[library[i].FileSize] = append(bySize[library[i].FileSize], &library[i])
+	}
+
+	var results []BenchResult
+
+	start := time.Now()
+	scanned, err := NewPodcastScanner(DirectoryTemplate{}).ScanDrive(drive, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan benchmark drive: %w", err)
+	}
+	results = append(results, BenchResult{Stage: "scan", Files: len(scanned), Elapsed: time.Since(start)})
+
+	start = time.Now()
+	matcher := NewPodcastMatcher(bySize, LayoutShow)
+	matched := 0
+	for i := range scanned {
+		if err := matcher.Match(&scanned[i]); err != nil {
+			return nil, fmt.Errorf("failed to match %s: %w", scanned[i].FilePath, err)
+		}
+		if scanned[i].OnDrive {
+			matched++
+		}
+	}
+	if matched != len(library) {
+		return nil, fmt.Errorf("matched %d of %d benchmark episodes", matched, len(library))
+	}
+	results = append(results, BenchResult{Stage: "match", Files: len(scanned), Elapsed: time.Since(start)})
+
+	methods := opts.BufferSizes
+	if kernelCopy {
+		methods = append(slices.Clone(methods), 0)
+	}
+	for _, bufSize := range methods {
+		stage := "copy " + FormatBytes(int64(bufSize)) + " buffer"
+		if bufSize == 0 {
+			stage = "copy in kernel"
+		}
+		elapsed, err := benchCopy(library, filepath.Join(dir, fmt.Sprintf("copy-%d", bufSize)), bufSize)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, BenchResult{
+			Stage:   stage,
+			Files:   len(library),
+			Bytes:   libraryBytes,
+			Elapsed: elapsed,
+		})
+	}
+	return results, nil
+}
+
+// benchCopy copies the library into dest the way a sync does, progress tracking included.
+// A bufSize of 0 copies in the kernel.
+func benchCopy(library []PodcastEpisode, dest string, bufSize int) (time.Duration, error) {
+	if err := os.MkdirAll(dest, 0o755); err != nil {
+		return 0, fmt.Errorf("failed to create benchmark copy folder: %w", err)
+	}
+	defer os.RemoveAll(dest)
+
+	var total int64
+	for _, episode := range library {
+		total += episode.FileSize
+	}
+	ch := make(chan FileOp, 100)
+	drained := make(chan struct{})
+	go func() {
+		for range ch {
+		}
+		close(drained)
+	}()
+	tm := NewTransferManager(total, len(library), ChanSink(ch))
+	// The benchmark measures the drive, not background mode's pauses
+	tm.unpaced = true
+	defer func() {
+		tm.Stop()
+		close(ch)
+		<-drained
+	}()
+
+	start := time.Now()
+	for _, episode := range library {
+		if err := benchCopyFile(tm, episode, dest, bufSize); err != nil {
+			return 0, err
+		}
+	}
+	return time.Since(start), nil
+}
+
+func benchCopyFile(tm *TransferManager, episode PodcastEpisode, dest string, bufSize int) error {
+	srcPath, err := convertFileURIToPath(episode.FilePath)
+	if err != nil {
+		return err
+	}
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.Create(filepath.Join(dest, filepath.Base(srcPath)))
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	file := tm.BeginFile(episode.ZTitle)
+	if bufSize == 0 {
+		err = copyKernel(dst, src, file)
+	} else {
+		err = copySynced(dst, src, file, bufSize)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to copy %s: %w", srcPath, err)
+	}
+	if err := dst.Sync(); err != nil {
+		return err
+	}
+	file.Complete(episode.FileSize)
+	return dst.Close()
+}
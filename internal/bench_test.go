@@ -0,0 +1,136 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunBench(t *testing.T) {
+	results, err := RunBench(BenchOptions{Dir: t.TempDir(), Files: 12, FileSize: 4096, BufferSizes: []int{1024, 64 * 1024}})
+	if err != nil {
+		t.Fatalf("RunBench() error = %v", err)
+	}
+
+	stages := []string{"scan", "match", "copy 1.0 KB buffer", "copy 64.0 KB buffer"}
+	if kernelCopy {
+		stages = append(stages, "copy in kernel")
+	}
+	if len(results) != len(stages) {
+		t.Fatalf("got %d results, want %d: %+v", len(results), len(stages), results)
+	}
+	for i, r := range results {
+		if r.Stage != stages[i] || r.Files != 12 {
+			t.Errorf("result %d = %+v, want stage %q over 12 files", i, r, stages[i])
+		}
+	}
+}
+
+func TestCopySynced(t *testing.T) {
+	dir := t.TempDir()
+	data := demoAudio(50)
+	src := filepath.Join(dir, "src.mp3")
+	if err := os.WriteFile(src, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer in.Close()
+	out, err := os.Create(filepath.Join(dir, "dst.mp3"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+
+	var progress countingWriter
+	if err := copySynced(out, in, &progress, 1000); err != nil {
+		t.Fatalf("copySynced() error = %v", err)
+	}
+	got, _ := os.ReadFile(out.Name())
+	if string(got) != string(data) || int(progress) != len(data) {
+		t.Errorf("copied %d bytes with %d reported, want %d", len(got), progress, len(data))
+	}
+}
+
+type countingWriter int
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	*w += countingWriter(len(p))
+	return len(p), nil
+}
+
+// benchTree is shared by the benchmarks since writing thousands of files dominates otherwise
+func benchTree(b *testing.B, files int) ([]PodcastEpisode, USBDrive) {
+	b.Helper()
+	library, drive, err := WriteBenchTree(b.TempDir(), files, 16*1024)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return library, drive
+}
+
+func BenchmarkScanDrive(b *testing.B) {
+	for _, files := range []int{1000, 5000} {
+		b.Run(fmt.Sprintf("files=%d", files), func(b *testing.B) {
+			_, drive := benchTree(b, files)
+			scanner := NewPodcastScanner(DirectoryTemplate{})
+			for b.Loop() {
+				if _, err := scanner.ScanDrive(drive, nil); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkMatch(b *testing.B) {
+	library, drive := benchTree(b, 2000)
+	scanned, err := NewPodcastScanner(DirectoryTemplate{}).ScanDrive(drive, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	bySize := make(map[int64][]*PodcastEpisode)
+	for i := range library {
+		bySize[library[i].FileSize] = append(bySize[library[i].FileSize], &library[i])
+	}
+
+	for b.Loop() {
+		matcher := NewPodcastMatcher(bySize, LayoutShow)
+		for _, episode := range scanned {
+			if err := matcher.Match(&episode); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+	b.ReportMetric(float64(len(scanned)*b.N)/b.Elapsed().Seconds(), "files/s")
+}
+
+func BenchmarkCopy(b *testing.B) {
+	library, _ := benchTree(b, 50)
+	var total int64
+	for _, episode := range library {
+		total += episode.FileSize
+	}
+
+	for _, bufSize := range []int{32 * 1024, 256 * 1024, 1024 * 1024, 0} {
+		name := "buffer=" + FormatBytes(int64(bufSize))
+		if bufSize == 0 {
+			if !kernelCopy {
+				continue
+			}
+			name = "kernel"
+		}
+		b.Run(name, func(b *testing.B) {
+			b.SetBytes(total)
+			dest := b.TempDir()
+			for b.Loop() {
+				if _, err := benchCopy(library, dest, bufSize); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
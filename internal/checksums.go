@@ -0,0 +1,150 @@
+package internal
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// ChecksumsFile is the name of the checksum list written into a podcasts folder, in the format of
+// sha256sum, so `sha256sum -c SHA256SUMS` or `shasum -a 256 -c SHA256SUMS` verifies it from that folder
+const ChecksumsFile = "SHA256SUMS"
+
+// WriteChecksums lists the SHA-256 of every file in podcastDir in its ChecksumsFile and returns how many
+// files it lists. podcasts-sync's own bookkeeping and unfinished copies are left out, as when pushing.
+// Files are hashed concurrently, as many at once as suits a drive of the given speed; progress, if not
+// nil, follows the hashing.
+func WriteChecksums(podcastDir string, speed DriveSpeed, progress func(HashProgress)) (int, error) {
+	files, err := checksummedFiles(podcastDir)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list %s: %w", podcastDir, err)
+	}
+	sums, errs := hashFiles(folderPaths(podcastDir, files), hashWorkers(speed), progress)
+	var b strings.Builder
+	for _, rel := range files {
+		path := filepath.Join(podcastDir, filepath.FromSlash(rel))
+		if err := errs[path]; err != nil {
+			return 0, fmt.Errorf("failed to hash %s: %w", rel, err)
+		}
+		fmt.Fprintf(&b, "%s  %s\n", sums[path], rel)
+	}
+	out := filepath.Join(podcastDir, ChecksumsFile)
+	tmp := out + ".tmp"
+	if err := os.WriteFile(tmp, []byte(b.String()), 0o644); err != nil {
+		return 0, fmt.Errorf("failed to write %s: %w", ChecksumsFile, err)
+	}
+	if err := os.Rename(tmp, out); err != nil {
+		os.Remove(tmp)
+		return 0, fmt.Errorf("failed to write %s: %w", ChecksumsFile, err)
+	}
+	return len(files), nil
+}
+
+func checksummedFiles(podcastDir string) ([]string, error) {
+	files, err := mirrorFiles(podcastDir)
+	if err != nil {
+		return nil, err
+	}
+	delete(files, ChecksumsFile)
+	return slices.Sorted(maps.Keys(files)), nil
+}
+
+// folderPaths turns paths relative to dir, with forward slashes, into full paths
+func folderPaths(dir string, rels []string) []string {
+	paths := make([]string, len(rels))
+	for i, rel := range rels {
+		paths[i] = filepath.Join(dir, filepath.FromSlash(rel))
+	}
+	return paths
+}
+
+// ChecksumReport is the outcome of verifying a folder against a checksum list
+type ChecksumReport struct {
+	Verified int
+	// Mismatched files differ from the list, Missing ones are listed but gone,
+	// and Unlisted ones are in the folder but not in the list
+	Mismatched, Missing, Unlisted []string
+}
+
+// OK reports whether every listed file is present and intact
+func (r ChecksumReport) OK() bool {
+	return len(r.Mismatched) == 0 && len(r.Missing) == 0
+}
+
+// VerifyChecksums checks the files in podcastDir against the checksum list at sumsPath, as written by
+// WriteChecksums or by sha256sum run from the podcasts folder. Files are hashed as by WriteChecksums.
+func VerifyChecksums(podcastDir, sumsPath string, speed DriveSpeed, progress func(HashProgress)) (ChecksumReport, error) {
+	want, err := readChecksums(sumsPath)
+	if err != nil {
+		return ChecksumReport{}, err
+	}
+
+	var report ChecksumReport
+	listed := slices.Sorted(maps.Keys(want))
+	sums, errs := hashFiles(folderPaths(podcastDir, listed), hashWorkers(speed), progress)
+	for _, rel := range listed {
+		path := filepath.Join(podcastDir, filepath.FromSlash(rel))
+		sum, err := sums[path], errs[path]
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			report.Missing = append(report.Missing, rel)
+		case err != nil:
+			return report, fmt.Errorf("failed to hash %s: %w", rel, err)
+		case sum != want[rel]:
+			report.Mismatched = append(report.Mismatched, rel)
+		default:
+			report.Verified++
+		}
+	}
+
+	files, err := checksummedFiles(podcastDir)
+	if err != nil {
+		return report, fmt.Errorf("failed to list %s: %w", podcastDir, err)
+	}
+	for _, rel := range files {
+		if _, listed := want[rel]; !listed {
+			report.Unlisted = append(report.Unlisted, rel)
+		}
+	}
+	return report, nil
+}
+
+// readChecksums parses sha256sum output: a hash, a space, then a space or * for binary mode, then the path
+func readChecksums(sumsPath string) (map[string]string, error) {
+	file, err := os.Open(sumsPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checksums: %w", err)
+	}
+	defer file.Close()
+
+	sums := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSuffix(scanner.Text(), "\r")
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		if len(text) < 67 || text[64] != ' ' || (text[65] != ' ' && text[65] != '*') {
+			return nil, fmt.Errorf("invalid line %d in %s: expected a SHA-256 and a path", line, sumsPath)
+		}
+		sum := strings.ToLower(text[:64])
+		if strings.Trim(sum, "0123456789abcdef") != "" {
+			return nil, fmt.Errorf("invalid line %d in %s: expected a SHA-256 and a path", line, sumsPath)
+		}
+		rel := path.Clean(strings.TrimPrefix(filepath.ToSlash(text[66:]), "./"))
+		if rel == ".." || strings.HasPrefix(rel, "../") || path.IsAbs(rel) {
+			return nil, fmt.Errorf("invalid line %d in %s: %s is outside the folder", line, sumsPath, rel)
+		}
+		sums[rel] = sum
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read checksums: %w", err)
+	}
+	return sums, nil
+}
@@ -0,0 +1,103 @@
+package internal
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestChecksums(t *testing.T) {
+	dir := t.TempDir()
+	write := func(rel, data string) {
+		path := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("Show A/One.mp3", "one")
+	write("Show A/Two.mp3", "two")
+	write("Show B/Three.m4a", "three")
+	write("Show B/Four.m4a.part", "unfinished")
+	write(".podcasts-sync.json", "{}")
+
+	n, err := WriteChecksums(dir, DriveSpeed{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Errorf("Expected 3 files listed, got %d", n)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, ChecksumsFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256([]byte("one"))
+	if want := hex.EncodeToString(sum[:]) + "  Show A/One.mp3\n"; !strings.HasPrefix(string(data), want) {
+		t.Errorf("Expected sha256sum lines, got %q", data)
+	}
+
+	sumsPath := filepath.Join(dir, ChecksumsFile)
+	report, err := VerifyChecksums(dir, sumsPath, DriveSpeed{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.OK() || report.Verified != 3 || len(report.Unlisted) > 0 {
+		t.Errorf("Expected an untouched folder to verify, got %+v", report)
+	}
+
+	write("Show A/Two.mp3", "tampered")
+	os.Remove(filepath.Join(dir, "Show B", "Three.m4a"))
+	write("Show B/Five.m4a", "five")
+	report, err = VerifyChecksums(dir, sumsPath, DriveSpeed{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.OK() || report.Verified != 1 {
+		t.Errorf("Expected the check to fail with one file verified, got %+v", report)
+	}
+	if !slices.Equal(report.Mismatched, []string{"Show A/Two.mp3"}) {
+		t.Errorf("Expected the changed file to mismatch, got %v", report.Mismatched)
+	}
+	if !slices.Equal(report.Missing, []string{"Show B/Three.m4a"}) {
+		t.Errorf("Expected the deleted file to be missing, got %v", report.Missing)
+	}
+	if !slices.Equal(report.Unlisted, []string{"Show B/Five.m4a"}) {
+		t.Errorf("Expected the new file to be unlisted, got %v", report.Unlisted)
+	}
+}
+
+func TestVerifyChecksums_SHA256SumFormat(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "One.mp3"), []byte("one"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256([]byte("one"))
+	sums := filepath.Join(t.TempDir(), "sums.txt")
+	list := "# made by sha256sum -b\n\n" + strings.ToUpper(hex.EncodeToString(sum[:])) + " *./One.mp3\r\n"
+	if err := os.WriteFile(sums, []byte(list), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	report, err := VerifyChecksums(dir, sums, DriveSpeed{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.OK() || report.Verified != 1 {
+		t.Errorf("Expected a binary mode line to verify, got %+v", report)
+	}
+
+	for _, line := range []string{"not a checksum line", hex.EncodeToString(sum[:]) + "  ../outside.mp3"} {
+		if err := os.WriteFile(sums, []byte(line+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := VerifyChecksums(dir, sums, DriveSpeed{}, nil); err == nil {
+			t.Errorf("Expected %q to be rejected", line)
+		}
+	}
+}
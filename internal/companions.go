@@ -0,0 +1,160 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"path/filepath"
+	"strings"
+
+	"github.com/bogem/id3v2/v2"
+)
+
+// Companion file suffixes written next to a synced episode for players that can render them
+const (
+	chaptersSuffix  = ".chapters.json"
+	shownotesSuffix = ".html"
+)
+
+var artworkExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/jpg":  ".jpg",
+	"image/png":  ".png",
+}
+
+// chaptersFile follows the podcast namespace JSON chapters format
+type chaptersFile struct {
+	Version  string    `json:"version"`
+	Chapters []chapter `json:"chapters"`
+}
+
+type chapter struct {
+	StartTime float64 `json:"startTime"`
+	EndTime   float64 `json:"endTime,omitempty"`
+	Title     string  `json:"title,omitempty"`
+	Img       string  `json:"img,omitempty"`
+}
+
+// ExportCompanions writes the chapters, shownotes and transcript files enabled in profile next to destPath.
+// Chapters are read from the ID3 CHAP frames of the source file; the tag library does not
+// expose per-chapter pictures, so the episode artwork is exported and used as every chapter's image.
+func ExportCompanions(srcPath, destPath string, episode PodcastEpisode, profile DriveProfile) error {
+	base := strings.TrimSuffix(destPath, filepath.Ext(destPath))
+
+	if profile.ExportShownotes && episode.ShowNotes != "" {
+		if err := writeShownotes(base+shownotesSuffix, episode, profile.Stable); err != nil {
+			return err
+		}
+	}
+
+	if profile.ExportChapters {
+		if err := writeChapters(srcPath, base, profile.Stable); err != nil {
+			return err
+		}
+	}
+
+	if profile.ExportTranscripts && episode.TranscriptPath != "" {
+		if err := writeTranscript(episode.TranscriptPath, base, profile.Stable); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeShownotes(path string, episode PodcastEpisode, stable bool) error {
+	title := html.EscapeString(episode.ZTitle)
+	doc := fmt.Sprintf("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n</head>\n<body>\n<h1>%s</h1>\n<p>%s</p>\n%s\n</body>\n</html>\n",
+		title, title, html.EscapeString(episode.ShowName), episode.ShowNotes)
+
+	if err := writeCompanion(path, []byte(doc), stable); err != nil {
+		return fmt.Errorf("failed to write shownotes: %w", err)
+	}
+	return nil
+}
+
+func writeChapters(srcPath, base string, stable bool) error {
+	if strings.ToLower(filepath.Ext(srcPath)) != ".mp3" {
+		return nil
+	}
+
+	tag, err := id3v2.Open(srcPath, id3v2.Options{Parse: true})
+	if err != nil {
+		return fmt.Errorf("failed to read chapters: %w", err)
+	}
+	defer tag.Close()
+
+	frames := tag.GetFrames("CHAP")
+	if len(frames) == 0 {
+		return nil
+	}
+
+	img, err := writeArtwork(tag, base, stable)
+	if err != nil {
+		return err
+	}
+
+	file := chaptersFile{Version: "1.2.0"}
+	for _, f := range frames {
+		cf, ok := f.(id3v2.ChapterFrame)
+		if !ok {
+			continue
+		}
+		ch := chapter{
+			StartTime: cf.StartTime.Seconds(),
+			EndTime:   cf.EndTime.Seconds(),
+			Img:       img,
+		}
+		if cf.Title != nil {
+			ch.Title = cf.Title.Text
+		}
+		file.Chapters = append(file.Chapters, ch)
+	}
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode chapters: %w", err)
+	}
+	if err := writeCompanion(base+chaptersSuffix, data, stable); err != nil {
+		return fmt.Errorf("failed to write chapters: %w", err)
+	}
+	return nil
+}
+
+// writeArtwork exports the first attached picture and returns its file name relative to the episode
+func writeArtwork(tag *id3v2.Tag, base string, stable bool) (string, error) {
+	for _, f := range tag.GetFrames(tag.CommonID("Attached picture")) {
+		pic, ok := f.(id3v2.PictureFrame)
+		if !ok || len(pic.Picture) == 0 {
+			continue
+		}
+		ext, ok := artworkExtensions[strings.ToLower(pic.MimeType)]
+		if !ok {
+			continue
+		}
+		if err := writeCompanion(base+ext, pic.Picture, stable); err != nil {
+			return "", fmt.Errorf("failed to write artwork: %w", err)
+		}
+		return filepath.Base(base + ext), nil
+	}
+	return "", nil
+}
+
+// companionPaths lists every companion file that may have been written for an episode at audioPath,
+// followed by the AppleDouble files macOS may have paired with the episode and each companion,
+// so they're moved and deleted along with it
+func companionPaths(audioPath string) []string {
+	if strings.HasSuffix(audioPath, partialSuffix) {
+		// An unfinished copy has only its sidecar; the episode's companions belong to the finished file
+		return []string{audioPath + ".json", appleDoublePath(audioPath)}
+	}
+	base := strings.TrimSuffix(audioPath, filepath.Ext(audioPath))
+	paths := []string{
+		base + chaptersSuffix, base + shownotesSuffix, base + ".jpg", base + ".png",
+		base + transcriptTextSuffix, base + transcriptSRTSuffix,
+	}
+	for _, path := range append([]string{audioPath}, paths...) {
+		paths = append(paths, appleDoublePath(path))
+	}
+	return paths
+}
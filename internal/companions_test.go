@@ -0,0 +1,146 @@
+package internal
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bogem/id3v2/v2"
+)
+
+func TestExportCompanions(t *testing.T) {
+	tempDir := t.TempDir()
+	srcPath := filepath.Join(tempDir, "source.mp3")
+	createTestMP3(t, srcPath)
+
+	tag, err := id3v2.Open(srcPath, id3v2.Options{Parse: true})
+	if err != nil {
+		t.Fatalf("Failed to open test MP3: %v", err)
+	}
+	tag.AddChapterFrame(id3v2.ChapterFrame{
+		ElementID:   "ch0",
+		StartTime:   0,
+		EndTime:     90 * time.Second,
+		StartOffset: id3v2.IgnoredOffset,
+		EndOffset:   id3v2.IgnoredOffset,
+		Title:       &id3v2.TextFrame{Encoding: id3v2.EncodingUTF8, Text: "Intro"},
+	})
+	tag.AddAttachedPicture(id3v2.PictureFrame{
+		Encoding:    id3v2.EncodingUTF8,
+		MimeType:    "image/jpeg",
+		PictureType: id3v2.PTFrontCover,
+		Picture:     []byte{0xFF, 0xD8, 0xFF},
+	})
+	if err := tag.Save(); err != nil {
+		t.Fatalf("Failed to save test tags: %v", err)
+	}
+	tag.Close()
+
+	destPath := filepath.Join(tempDir, "2024-01-01 - Episode.mp3")
+	episode := PodcastEpisode{ZTitle: "Episode <1>", ShowName: "Show", ShowNotes: "<p>Notes</p>"}
+
+	t.Run("disabled profile writes nothing", func(t *testing.T) {
+		if err := ExportCompanions(srcPath, destPath, episode, DriveProfile{}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		for _, path := range companionPaths(destPath) {
+			if _, err := os.Stat(path); err == nil {
+				t.Errorf("Expected %s not to be written", path)
+			}
+		}
+	})
+
+	t.Run("enabled profile writes chapters, artwork and shownotes", func(t *testing.T) {
+		profile := DriveProfile{ExportChapters: true, ExportShownotes: true}
+		if err := ExportCompanions(srcPath, destPath, episode, profile); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		base := strings.TrimSuffix(destPath, ".mp3")
+		notes, err := os.ReadFile(base + shownotesSuffix)
+		if err != nil {
+			t.Fatalf("Expected shownotes file: %v", err)
+		}
+		if !strings.Contains(string(notes), "<p>Notes</p>") || !strings.Contains(string(notes), "Episode &lt;1&gt;") {
+			t.Errorf("Unexpected shownotes content: %s", notes)
+		}
+
+		data, err := os.ReadFile(base + chaptersSuffix)
+		if err != nil {
+			t.Fatalf("Expected chapters file: %v", err)
+		}
+		var chapters chaptersFile
+		if err := json.Unmarshal(data, &chapters); err != nil {
+			t.Fatalf("Failed to parse chapters: %v", err)
+		}
+		if len(chapters.Chapters) != 1 || chapters.Chapters[0].Title != "Intro" || chapters.Chapters[0].EndTime != 90 {
+			t.Errorf("Unexpected chapters: %+v", chapters)
+		}
+		if chapters.Chapters[0].Img != filepath.Base(base+".jpg") {
+			t.Errorf("Expected chapter image to reference artwork, got %q", chapters.Chapters[0].Img)
+		}
+		if _, err := os.Stat(base + ".jpg"); err != nil {
+			t.Errorf("Expected artwork file: %v", err)
+		}
+	})
+}
+
+func TestDeleteSelected_RemovesCompanions(t *testing.T) {
+	showDir := filepath.Join(t.TempDir(), "Show")
+	if err := os.MkdirAll(showDir, 0o755); err != nil {
+		t.Fatalf("Failed to create show directory: %v", err)
+	}
+
+	audio := filepath.Join(showDir, "episode.mp3")
+	files := append([]string{audio}, companionPaths(audio)...)
+	for _, f := range files {
+		if err := os.WriteFile(f, []byte("x"), 0o644); err != nil {
+			t.Fatalf("Failed to create %s: %v", f, err)
+		}
+	}
+
+	ps := NewPodcastSync()
+	ps.SetDrive(USBDrive{MountPath: filepath.Dir(showDir)})
+	result := ps.DeleteSelected([]PodcastEpisode{{FilePath: audio, Selected: true}})
+	if result.Error != nil {
+		t.Fatalf("Expected no error, got %v", result.Error)
+	}
+	if _, err := os.Stat(showDir); !os.IsNotExist(err) {
+		t.Error("Expected show directory to be removed along with companions")
+	}
+}
+
+func TestDeleteSelected_RemovesAppleDouble(t *testing.T) {
+	root := t.TempDir()
+	showDir := filepath.Join(root, "Show")
+	if err := os.MkdirAll(showDir, 0o755); err != nil {
+		t.Fatalf("Failed to create show directory: %v", err)
+	}
+	audio := filepath.Join(showDir, "Episode.mp3")
+	other := filepath.Join(showDir, "Other.mp3")
+	for _, f := range []string{audio, appleDoublePath(audio), other, appleDoublePath(other)} {
+		if err := os.WriteFile(f, []byte("x"), 0o644); err != nil {
+			t.Fatalf("Failed to create %s: %v", f, err)
+		}
+	}
+
+	episodes, err := NewPodcastScanner(DirectoryTemplate{}).ScanDrive(USBDrive{MountPath: root}, nil)
+	if err != nil || len(episodes) != 2 {
+		t.Fatalf("Expected AppleDouble files to be left out of the listing, got %d episodes, %v", len(episodes), err)
+	}
+
+	ps := NewPodcastSync()
+	ps.SetDrive(USBDrive{MountPath: root})
+	if result := ps.DeleteSelected([]PodcastEpisode{{FilePath: audio, Selected: true}}); result.Error != nil {
+		t.Fatalf("Expected no error, got %v", result.Error)
+	}
+	if _, err := os.Stat(appleDoublePath(audio)); !os.IsNotExist(err) {
+		t.Error("Expected the AppleDouble file to go with its episode")
+	}
+	if _, err := os.Stat(appleDoublePath(other)); err != nil {
+		t.Errorf("Expected the AppleDouble file of the remaining episode to be kept: %v", err)
+	}
+}
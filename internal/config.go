@@ -0,0 +1,334 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// configMigrations upgrades older config files; see migrateJSON
+var configMigrations = []jsonMigration{
+	// 0 → 1: the schema version itself was introduced
+	func(map[string]json.RawMessage) error { return nil },
+}
+
+// Config holds user settings persisted between runs.
+type Config struct {
+	Schema       int                     `json:"schema"`
+	Drives       map[string]DriveProfile `json:"drives,omitempty"`
+	PartialFiles PartialFilePolicy       `json:"partialFiles,omitempty"`
+	// KeepFavorites exempts starred episodes from bulk deletes on the drive
+	KeepFavorites bool `json:"keepFavorites,omitempty"`
+	// Shows holds per-show sync policies keyed by show name
+	Shows map[string]ShowPolicy `json:"shows,omitempty"`
+	// StallSeconds is how long a transfer may write nothing before the user is alerted; 0 uses DefaultStallTimeout
+	StallSeconds int `json:"stallSeconds,omitempty"`
+	// Watch schedules the automatic syncs of watch mode
+	Watch WatchSettings `json:"watch,omitzero"`
+	// MQTT publishes the sync events of watch mode to a broker
+	MQTT *MQTTSettings `json:"mqtt,omitempty"`
+	// SafeMode asks for the drive's name to be typed before delete all and pruning to keep limits
+	SafeMode bool `json:"safeMode,omitempty"`
+	// Language translates the UI and dates in episode descriptions; file names always use ISO dates
+	Language Language `json:"language,omitempty"`
+	// DrivePriority lists drive UUIDs or names, most preferred first, to pick between several connected drives
+	DrivePriority []string `json:"drivePriority,omitempty"`
+
+	loadErr error
+}
+
+// PartialFilePolicy decides what happens to the file being copied when a sync is cancelled
+type PartialFilePolicy string
+
+const (
+	PartialAsk    PartialFilePolicy = ""       // ask when cancelling
+	PartialDelete PartialFilePolicy = "delete" // remove the partial file
+	PartialKeep   PartialFilePolicy = "keep"   // keep it so the next sync resumes the copy
+)
+
+// DriveProfile holds settings for a single drive, keyed in Config by volume name, or by volume UUID to
+// tell apart drives that share a name.
+type DriveProfile struct {
+	ExportChapters    bool `json:"exportChapters,omitempty"`
+	ExportShownotes   bool `json:"exportShownotes,omitempty"`
+	ExportTranscripts bool `json:"exportTranscripts,omitempty"`
+	// Layout arranges the drive's podcasts folder; the default is a folder per show
+	Layout FolderLayout `json:"layout,omitempty"`
+	// Template overrides the formats the layout names show folders and episode files after
+	Template *DirectoryTemplate `json:"template,omitempty"`
+	// Folder is the folder below the drive's root that episodes go in; the default is "podcasts"
+	Folder string `json:"folder,omitempty"`
+	// Playlist orders the playlist written to the drive after each change; empty writes none
+	Playlist PlaylistOrder `json:"playlist,omitempty"`
+	// CustomOrder lists episodes by path below the podcasts folder for the custom playlist order
+	CustomOrder []string `json:"customOrder,omitempty"`
+	// IndexPrefix numbers the file names of episodes in the custom order after their position
+	IndexPrefix bool `json:"indexPrefix,omitempty"`
+	// Split cuts episodes beyond a size or length into parts
+	Split SplitSettings `json:"split,omitzero"`
+	// IPod keeps episodes in the iPod's music folder and lists them in its iTunesDB after each change
+	IPod bool `json:"ipod,omitempty"`
+	// ADBDeviceFolder is the folder episodes are pushed to on an Android device; the default is DefaultADBFolder
+	ADBDeviceFolder string `json:"adbFolder,omitempty"`
+	// LocalFolder syncs to a folder on this Mac, e.g. one Syncthing or Dropbox keeps in sync, instead
+	// of a mounted volume
+	LocalFolder string `json:"localFolder,omitempty"`
+	// Stable leaves unchanged playlists and companion files untouched and gives copied episodes the
+	// modification time of their source, so rsync or Syncthing only transfer new episodes
+	Stable bool `json:"stable,omitempty"`
+	// WebDAV syncs the drive to a WebDAV share through a local mirror instead of a mounted volume
+	WebDAV *WebDAVSettings `json:"webdav,omitempty"`
+	// Encrypt keeps the drive's episodes in an encrypted folder on it, synced through a local mirror
+	Encrypt *EncryptSettings `json:"encrypt,omitempty"`
+	// Verify reads copied episodes back from the drive, in full or sampled
+	Verify VerifySettings `json:"verify,omitzero"`
+	// Notify sends a summary of the drive's unattended syncs to a webhook or by email
+	Notify *NotifySettings `json:"notify,omitempty"`
+	// IOErrors counts the I/O errors hit on the drive since it was last repaired
+	IOErrors DriveIOErrors `json:"ioErrors,omitzero"`
+	// Speed is the last measured throughput, used for ETAs before a sync has its own samples
+	Speed DriveSpeed `json:"speed,omitzero"`
+	// Concurrency is how many episodes are copied at once; the default copies one at a time
+	Concurrency int `json:"concurrency,omitempty"`
+	// AutoSync syncs the drive without a keystroke when it is plugged in while the app runs
+	AutoSync AutoSyncMode `json:"autoSync,omitempty"`
+	// LastSync is when the app last synced the drive, recorded for drives that share a name
+	LastSync time.Time `json:"lastSync,omitzero"`
+}
+
+// DefaultConfigPath returns the location of the config file in the user's config directory
+func DefaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = filepath.Join(os.Getenv("HOME"), ".config")
+	}
+	return filepath.Join(dir, "podcasts-sync", "config.json")
+}
+
+// LoadConfig reads the config file at path.
+// A missing file is not an error and yields an empty config. A config that fails to load
+// is still returned so the app can run on defaults, but it refuses to be saved over the file.
+func LoadConfig(path string) (*Config, error) {
+	cfg := &Config{Drives: map[string]DriveProfile{}}
+	err := cfg.load(path)
+	cfg.loadErr = err
+	return cfg, err
+}
+
+func (c *Config) load(path string) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+
+	data, err = migrateJSON(data, configMigrations, "config", path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, c); err != nil {
+		return fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+	if c.Drives == nil {
+		c.Drives = map[string]DriveProfile{}
+	}
+	switch c.PartialFiles {
+	case PartialAsk, PartialDelete, PartialKeep:
+	default:
+		return fmt.Errorf("invalid partialFiles %q in %s: must be \"delete\" or \"keep\"", c.PartialFiles, path)
+	}
+	if err := c.Language.validate(); err != nil {
+		return fmt.Errorf("%w in %s", err, path)
+	}
+	if c.StallSeconds < 0 {
+		return fmt.Errorf("invalid stallSeconds %d in %s: must not be negative", c.StallSeconds, path)
+	}
+	for name, profile := range c.Drives {
+		if err := profile.Layout.validate(); err != nil {
+			return fmt.Errorf("%w for drive %q in %s", err, name, path)
+		}
+		if err := profile.Template.validate(); err != nil {
+			return fmt.Errorf("%w for drive %q in %s", err, name, path)
+		}
+		if err := profile.validFolder(); err != nil {
+			return fmt.Errorf("%w for drive %q in %s", err, name, path)
+		}
+		if err := profile.Playlist.validate(); err != nil {
+			return fmt.Errorf("%w for drive %q in %s", err, name, path)
+		}
+		if err := profile.Split.validate(); err != nil {
+			return fmt.Errorf("%w for drive %q in %s", err, name, path)
+		}
+		if err := profile.validLocalFolder(); err != nil {
+			return fmt.Errorf("%w for drive %q in %s", err, name, path)
+		}
+		if err := profile.WebDAV.validate(); err != nil {
+			return fmt.Errorf("%w for drive %q in %s", err, name, path)
+		}
+		if err := profile.Encrypt.validate(); err != nil {
+			return fmt.Errorf("%w for drive %q in %s", err, name, path)
+		}
+		if profile.Encrypt != nil && (profile.WebDAV != nil || profile.IPod) {
+			return fmt.Errorf("invalid encrypt for drive %q in %s: WebDAV shares and iPods can't be encrypted", name, path)
+		}
+		if err := profile.Verify.validate(); err != nil {
+			return fmt.Errorf("%w for drive %q in %s", err, name, path)
+		}
+		if err := profile.Notify.validate(); err != nil {
+			return fmt.Errorf("%w for drive %q in %s", err, name, path)
+		}
+		if profile.Concurrency < 0 || profile.Concurrency > maxConcurrency {
+			return fmt.Errorf("invalid concurrency %d for drive %q in %s: must not be negative or more than %d", profile.Concurrency, name, path, maxConcurrency)
+		}
+		switch profile.AutoSync {
+		case AutoSyncOff, AutoSyncPolicies, AutoSyncUnsynced:
+		default:
+			return fmt.Errorf("invalid autoSync %q for drive %q in %s: must be \"policies\" or \"unsynced\"", profile.AutoSync, name, path)
+		}
+	}
+	if _, err := ParseSyncWindow(c.Watch.Window); err != nil {
+		return fmt.Errorf("%w in %s", err, path)
+	}
+	if err := c.MQTT.validate(); err != nil {
+		return fmt.Errorf("%w in %s", err, path)
+	}
+	for show, policy := range c.Shows {
+		if err := policy.validate(show); err != nil {
+			return fmt.Errorf("%w in %s", err, path)
+		}
+	}
+
+	return nil
+}
+
+// Save writes the config to path, creating the parent directory if needed
+func (c *Config) Save(path string) error {
+	if c.loadErr != nil {
+		return fmt.Errorf("not overwriting a config that failed to load: %w", c.loadErr)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	c.Schema = len(configMigrations)
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+
+	return os.WriteFile(path, data, 0o644)
+}
+
+// StallTimeout returns how long a transfer may write nothing before it counts as stalled
+func (c *Config) StallTimeout() time.Duration {
+	if c == nil || c.StallSeconds == 0 {
+		return DefaultStallTimeout
+	}
+	return time.Duration(c.StallSeconds) * time.Second
+}
+
+// Retains reports whether episode must be kept on the drive by bulk deletes: it is pinned,
+// or starred while keepFavorites is set
+func (c *Config) Retains(episode PodcastEpisode) bool {
+	return episode.Pinned || (c != nil && c.KeepFavorites && episode.Favorite)
+}
+
+// PolicyFor returns the policy for the named show, or the zero policy if none is configured
+func (c *Config) PolicyFor(show string) ShowPolicy {
+	if c == nil {
+		return ShowPolicy{}
+	}
+	return c.Shows[show]
+}
+
+// SetPolicy stores the policy for the named show, dropping it when it is the zero policy
+func (c *Config) SetPolicy(show string, policy ShowPolicy) {
+	if policy == (ShowPolicy{}) {
+		delete(c.Shows, show)
+		return
+	}
+	if c.Shows == nil {
+		c.Shows = map[string]ShowPolicy{}
+	}
+	c.Shows[show] = policy
+}
+
+// PreferredDrive returns the index of the connected drive that comes first in DrivePriority,
+// matching its UUID before its name, or 0 when none of the drives is listed
+func (c *Config) PreferredDrive(drives []USBDrive) int {
+	if c == nil {
+		return 0
+	}
+	for _, want := range c.DrivePriority {
+		for i, d := range drives {
+			if d.UUID != "" && strings.EqualFold(d.UUID, want) {
+				return i
+			}
+		}
+		for i, d := range drives {
+			if d.Name == want {
+				return i
+			}
+		}
+	}
+	return 0
+}
+
+// ProfileFor returns the profile for the named drive, or the zero profile if none is configured
+func (c *Config) ProfileFor(name string) DriveProfile {
+	if c == nil {
+		return DriveProfile{}
+	}
+	return c.Drives[name]
+}
+
+// SetCustomOrder saves order as the named drive's custom playlist order and switches its playlist to it
+func (c *Config) SetCustomOrder(name string, order []string) {
+	if c.Drives == nil {
+		c.Drives = map[string]DriveProfile{}
+	}
+	profile := c.Drives[name]
+	profile.CustomOrder = order
+	profile.Playlist = PlaylistCustom
+	c.Drives[name] = profile
+}
+
+// SetDriveSpeed records the measured speed in the named drive's profile
+func (c *Config) SetDriveSpeed(name string, speed DriveSpeed) {
+	if c.Drives == nil {
+		c.Drives = map[string]DriveProfile{}
+	}
+	profile := c.Drives[name]
+	profile.Speed = speed
+	c.Drives[name] = profile
+}
+
+// KeyProfile returns the drive's profile key, first copying the profile it was detected with under
+// that key when the key has none yet, so a shared name's settings carry over to its UUID
+func (c *Config) KeyProfile(d USBDrive) string {
+	key := d.ProfileKey()
+	if _, ok := c.Drives[key]; !ok && key != d.Name {
+		if c.Drives == nil {
+			c.Drives = map[string]DriveProfile{}
+		}
+		if profile, ok := c.Drives[d.Name]; ok {
+			c.Drives[key] = profile
+		}
+	}
+	return key
+}
+
+// SetLastSync records when the named drive was last synced
+func (c *Config) SetLastSync(name string, at time.Time) {
+	if c.Drives == nil {
+		c.Drives = map[string]DriveProfile{}
+	}
+	profile := c.Drives[name]
+	profile.LastSync = at
+	c.Drives[name] = profile
+}
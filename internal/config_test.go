@@ -0,0 +1,164 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLoadConfig(t *testing.T) {
+	t.Run("missing file yields empty config", func(t *testing.T) {
+		cfg, err := LoadConfig(filepath.Join(t.TempDir(), "config.json"))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if cfg.Drives == nil || len(cfg.Drives) != 0 {
+			t.Errorf("Expected empty drives map, got %v", cfg.Drives)
+		}
+	})
+
+	t.Run("invalid json returns error", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.json")
+		if err := os.WriteFile(path, []byte("{not json"), 0o644); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		if _, err := LoadConfig(path); err == nil {
+			t.Error("Expected error for invalid config")
+		}
+	})
+
+	t.Run("save and reload round trip", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "nested", "config.json")
+		cfg := &Config{Drives: map[string]DriveProfile{
+			"CAR": {ExportChapters: true, ExportShownotes: true},
+		}}
+		if err := cfg.Save(path); err != nil {
+			t.Fatalf("Failed to save config: %v", err)
+		}
+
+		loaded, err := LoadConfig(path)
+		if err != nil {
+			t.Fatalf("Failed to load config: %v", err)
+		}
+		if profile := loaded.ProfileFor("CAR"); !profile.ExportChapters || !profile.ExportShownotes {
+			t.Errorf("Expected CAR profile to round trip, got %+v", profile)
+		}
+		if profile := loaded.ProfileFor("OTHER"); !reflect.DeepEqual(profile, DriveProfile{}) {
+			t.Errorf("Expected zero profile for unknown drive, got %+v", profile)
+		}
+	})
+}
+
+func TestLoadConfig_InvalidPartialFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"partialFiles": "shred"}`), 0o644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if _, err := LoadConfig(path); err == nil {
+		t.Error("Expected an error for an unknown partial file policy")
+	}
+}
+
+func TestLoadConfig_InvalidConcurrency(t *testing.T) {
+	for _, concurrency := range []int{-1, maxConcurrency + 1} {
+		path := filepath.Join(t.TempDir(), "config.json")
+		if err := os.WriteFile(path, fmt.Appendf(nil, `{"drives": {"SSD": {"concurrency": %d}}}`, concurrency), 0o644); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		if _, err := LoadConfig(path); err == nil {
+			t.Errorf("Expected an error for concurrency %d", concurrency)
+		}
+	}
+}
+
+func TestLoadConfig_InvalidLocalFolder(t *testing.T) {
+	for _, profile := range []string{`{"localFolder": "Dropbox/Podcasts"}`, `{"localFolder": "/Users/me/Dropbox", "ipod": true}`} {
+		path := filepath.Join(t.TempDir(), "config.json")
+		if err := os.WriteFile(path, fmt.Appendf(nil, `{"drives": {"Dropbox": %s}}`, profile), 0o644); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		if _, err := LoadConfig(path); err == nil {
+			t.Errorf("Expected an error for %s", profile)
+		}
+	}
+}
+
+func TestConfig_StallTimeout(t *testing.T) {
+	var nilConfig *Config
+	if got := nilConfig.StallTimeout(); got != DefaultStallTimeout {
+		t.Errorf("Expected the default timeout, got %v", got)
+	}
+	if got := (&Config{StallSeconds: 40}).StallTimeout(); got != 40*time.Second {
+		t.Errorf("Expected 40s, got %v", got)
+	}
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"stallSeconds": -1}`), 0o644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if _, err := LoadConfig(path); err == nil {
+		t.Error("Expected an error for a negative stall timeout")
+	}
+}
+
+func TestConfig_Retains(t *testing.T) {
+	favorite := PodcastEpisode{ZTitle: "Keep me", Favorite: true}
+
+	var nilConfig *Config
+	if nilConfig.Retains(favorite) {
+		t.Error("Expected nil config to retain nothing")
+	}
+	if (&Config{}).Retains(favorite) {
+		t.Error("Expected favorites to be deletable unless keepFavorites is set")
+	}
+	cfg := &Config{KeepFavorites: true}
+	if !cfg.Retains(favorite) {
+		t.Error("Expected keepFavorites to retain starred episodes")
+	}
+	if cfg.Retains(PodcastEpisode{ZTitle: "Other"}) {
+		t.Error("Expected unstarred episodes not to be retained")
+	}
+}
+
+func TestConfig_PreferredDrive(t *testing.T) {
+	drives := []USBDrive{
+		{Name: "GYM", MountPath: "/Volumes/GYM"},
+		{Name: "CAR", MountPath: "/Volumes/CAR", UUID: "1234-ABCD"},
+		{Name: "SPARE", MountPath: "/Volumes/SPARE"},
+	}
+
+	var nilConfig *Config
+	if got := nilConfig.PreferredDrive(drives); got != 0 {
+		t.Errorf("Expected nil config to pick the first drive, got %d", got)
+	}
+	if got := (&Config{DrivePriority: []string{"SPARE", "CAR"}}).PreferredDrive(drives); got != 2 {
+		t.Errorf("Expected the first listed drive to win, got %d", got)
+	}
+	if got := (&Config{DrivePriority: []string{"missing", "1234-abcd"}}).PreferredDrive(drives); got != 1 {
+		t.Errorf("Expected a UUID to match regardless of case, got %d", got)
+	}
+	if got := (&Config{DrivePriority: []string{"missing"}}).PreferredDrive(drives); got != 0 {
+		t.Errorf("Expected the first drive when none is listed, got %d", got)
+	}
+}
+
+func TestConfig_KeyProfile(t *testing.T) {
+	cfg := &Config{Drives: map[string]DriveProfile{"USB DRIVE": {Folder: "Shared"}}}
+	drive := USBDrive{Name: "USB DRIVE", UUID: "0A1B2C3D-4E5F-6071-8293-A4B5C6D7E8F9", Shared: true}
+
+	key := cfg.KeyProfile(drive)
+	if key != drive.UUID || cfg.Drives[key].Folder != "Shared" {
+		t.Errorf("Expected the name's profile copied under the UUID, got %q: %+v", key, cfg.Drives[key])
+	}
+	at := time.Date(2024, time.March, 5, 9, 0, 0, 0, time.UTC)
+	cfg.SetLastSync(key, at)
+	if !cfg.Drives[key].LastSync.Equal(at) || !cfg.Drives["USB DRIVE"].LastSync.IsZero() {
+		t.Errorf("Expected the last sync recorded for the UUID only, got %+v", cfg.Drives)
+	}
+	if key := cfg.KeyProfile(USBDrive{Name: "CAR"}); key != "CAR" || len(cfg.Drives) != 2 {
+		t.Errorf("Expected a drive keyed by name to be left alone, got %q: %+v", key, cfg.Drives)
+	}
+}
@@ -0,0 +1,45 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// crashLogTail is how many of the most recent log entries a crash report includes
+const crashLogTail = 50
+
+// DefaultCrashesDir returns the directory where crash reports are written
+func DefaultCrashesDir() string {
+	return filepath.Join(filepath.Dir(DefaultConfigPath()), "crashes")
+}
+
+// WriteCrashReport writes the panic value, its stack and the last entries of log to a new timestamped
+// file in dir, and returns the file's path
+func WriteCrashReport(dir, version string, value any, stack []byte, log []Debug) (string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create crashes directory: %w", err)
+	}
+
+	now := time.Now()
+	var b strings.Builder
+	fmt.Fprintf(&b, "podcasts-sync %s crashed at %s\n", version, now.Format(time.RFC3339))
+	fmt.Fprintf(&b, "%s %s/%s\n\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(&b, "panic: %v\n\n%s\n", value, stack)
+	if len(log) > crashLogTail {
+		log = log[len(log)-crashLogTail:]
+	}
+	fmt.Fprintf(&b, "Recent log (%d entries):\n", len(log))
+	for _, entry := range log {
+		b.WriteString(entry.String() + "\n")
+	}
+
+	path := filepath.Join(dir, "crash-"+now.Format("20060102-150405")+".txt")
+	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		return "", fmt.Errorf("failed to write crash report: %w", err)
+	}
+	return path, nil
+}
@@ -0,0 +1,34 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestWriteCrashReport(t *testing.T) {
+	dir := t.TempDir()
+	var log []Debug
+	for i := range crashLogTail + 5 {
+		log = append(log, Debug{DTitle: fmt.Sprintf("entry %d", i), Level: DebugInfo})
+	}
+
+	path, err := WriteCrashReport(dir, "1.2.3", "boom", []byte("goroutine 1 [running]:"), log)
+	if err != nil {
+		t.Fatalf("WriteCrashReport failed: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read crash report: %v", err)
+	}
+	report := string(data)
+	for _, want := range []string{"podcasts-sync 1.2.3 crashed", "panic: boom", "goroutine 1 [running]:", fmt.Sprintf("entry %d", crashLogTail+4)} {
+		if !strings.Contains(report, want) {
+			t.Errorf("Expected the report to contain %q, got:\n%s", want, report)
+		}
+	}
+	if strings.Contains(report, "entry 4:") {
+		t.Error("Expected only the most recent log entries")
+	}
+}
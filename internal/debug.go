@@ -1,8 +1,34 @@
 package internal
 
+import (
+	"fmt"
+	"time"
+)
+
+// DebugLevel is how serious a debug entry is
+type DebugLevel int
+
+const (
+	DebugInfo DebugLevel = iota
+	DebugWarn
+	DebugError
+)
+
+func (l DebugLevel) String() string {
+	switch l {
+	case DebugWarn:
+		return "warn"
+	case DebugError:
+		return "error"
+	}
+	return "info"
+}
+
 type Debug struct {
 	DTitle       string
 	DDescription string
+	Level        DebugLevel
+	Time         time.Time
 }
 
 func (d Debug) Title() string { return d.DTitle }
@@ -10,3 +36,8 @@ func (d Debug) Title() string { return d.DTitle }
 func (d Debug) Description() string { return d.DDescription }
 
 func (d Debug) FilterValue() string { return "" }
+
+// String formats the entry as a log line, e.g. for attaching to an issue
+func (d Debug) String() string {
+	return fmt.Sprintf("%s [%s] %s: %s", d.Time.Format("15:04:05.000"), d.Level, d.DTitle, d.DDescription)
+}
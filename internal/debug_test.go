@@ -0,0 +1,21 @@
+package internal
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDebug_String(t *testing.T) {
+	d := Debug{
+		DTitle:       "FileOpMsg",
+		DDescription: "Operation: sync, Error: disk full",
+		Level:        DebugError,
+		Time:         time.Date(2024, 1, 15, 9, 30, 5, 250_000_000, time.Local),
+	}
+	if want := "09:30:05.250 [error] FileOpMsg: Operation: sync, Error: disk full"; d.String() != want {
+		t.Errorf("String() = %q, want %q", d.String(), want)
+	}
+	if DebugLevel(0).String() != "info" || DebugWarn.String() != "warn" {
+		t.Errorf("Expected info and warn levels, got %s and %s", DebugLevel(0), DebugWarn)
+	}
+}
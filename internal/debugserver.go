@@ -0,0 +1,76 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"time"
+)
+
+// StateFunc returns a snapshot of application state for the debug server
+type StateFunc func() map[string]any
+
+// StartDebugServer serves pprof profiles under /debug/pprof/ and a JSON state dump
+// at /debug/state on addr. The server runs until the process exits.
+// Only loopback addresses are accepted, since the endpoints are unauthenticated.
+func StartDebugServer(addr string, state StateFunc) (string, error) {
+	if err := checkLoopback(addr); err != nil {
+		return "", err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.HandleFunc("/debug/state", func(w http.ResponseWriter, r *http.Request) {
+		snapshot := map[string]any{
+			"goroutines":            runtime.NumGoroutine(),
+			"activeProgressWriters": ActiveProgressWriters(),
+			"progressSends":         GetChannelStats(),
+			"time":                  time.Now().Format(time.RFC3339),
+		}
+		if state != nil {
+			for k, v := range state() {
+				snapshot[k] = v
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(snapshot)
+	})
+
+	// Listen synchronously so a bad address is reported before the TUI starts
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return "", fmt.Errorf("failed to start debug server: %w", err)
+	}
+
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		_ = server.Serve(listener)
+	}()
+
+	return listener.Addr().String(), nil
+}
+
+// checkLoopback refuses addresses that would expose the server beyond this machine, such as ":6060"
+func checkLoopback(addr string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid debug address %q: %w", addr, err)
+	}
+	if host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+		return fmt.Errorf("debug address %q is not a loopback address; use e.g. 127.0.0.1:6060", addr)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package internal
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestStartDebugServer(t *testing.T) {
+	addr, err := StartDebugServer("127.0.0.1:0", func() map[string]any {
+		return map[string]any{"state": "normal"}
+	})
+	if err != nil {
+		t.Fatalf("Failed to start debug server: %v", err)
+	}
+
+	resp, err := http.Get("http://" + addr + "/debug/state")
+	if err != nil {
+		t.Fatalf("Failed to fetch state: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var state map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&state); err != nil {
+		t.Fatalf("Failed to decode state: %v", err)
+	}
+	if state["state"] != "normal" {
+		t.Errorf("Expected state from StateFunc, got %v", state["state"])
+	}
+	if _, ok := state["goroutines"]; !ok {
+		t.Error("Expected goroutine count in state dump")
+	}
+
+	pprofResp, err := http.Get("http://" + addr + "/debug/pprof/")
+	if err != nil {
+		t.Fatalf("Failed to fetch pprof index: %v", err)
+	}
+	pprofResp.Body.Close()
+	if pprofResp.StatusCode != http.StatusOK {
+		t.Errorf("Expected pprof index to be served, got %d", pprofResp.StatusCode)
+	}
+
+	if _, err := StartDebugServer(addr, nil); err == nil {
+		t.Error("Expected error when the address is already in use")
+	}
+
+	for _, addr := range []string{":0", "0.0.0.0:0", "192.0.2.1:0", "6060"} {
+		if _, err := StartDebugServer(addr, nil); err == nil {
+			t.Errorf("Expected %q to be refused", addr)
+		}
+	}
+	if _, err := StartDebugServer("localhost:0", nil); err != nil {
+		t.Errorf("Expected localhost to be accepted, got %v", err)
+	}
+}
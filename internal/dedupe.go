@@ -0,0 +1,79 @@
+package internal
+
+import (
+	"maps"
+	"slices"
+)
+
+// MarkDuplicates sets SameAudio on episodes whose file is in the library under another show too,
+// as with feeds that republish an episode. Files are only hashed when another show has one of the same
+// size, and then all at once.
+func MarkDuplicates(episodes []PodcastEpisode) {
+	bySize := make(map[int64][]int)
+	for i := range episodes {
+		episodes[i].SameAudio = ""
+		if episodes[i].FileSize > 0 && !episodes[i].Missing {
+			bySize[episodes[i].FileSize] = append(bySize[episodes[i].FileSize], i)
+		}
+	}
+
+	var groups [][]int
+	paths := make(map[int]string)
+	for _, group := range bySize {
+		if !spansShows(episodes, group) {
+			continue
+		}
+		groups = append(groups, group)
+		for _, i := range group {
+			if path, err := convertFileURIToPath(episodes[i].FilePath); err == nil {
+				paths[i] = path
+			}
+		}
+	}
+	sums, _ := hashFiles(slices.Collect(maps.Values(paths)), libraryHashWorkers(), nil)
+
+	for _, group := range groups {
+		byHash := make(map[string][]int)
+		for _, i := range group {
+			if hash, ok := sums[paths[i]]; ok && paths[i] != "" {
+				byHash[hash] = append(byHash[hash], i)
+			}
+		}
+		for hash, same := range byHash {
+			if spansShows(episodes, same) {
+				for _, i := range same {
+					episodes[i].SameAudio = hash
+				}
+			}
+		}
+	}
+}
+
+// spansShows reports whether the episodes at indexes belong to more than one show
+func spansShows(episodes []PodcastEpisode, indexes []int) bool {
+	for _, i := range indexes[1:] {
+		if episodes[i].ShowName != episodes[indexes[0]].ShowName {
+			return true
+		}
+	}
+	return false
+}
+
+// dropDuplicateSelections deselects all but the first selected episode of each audio selected
+// under several shows, so the same file isn't copied twice, and returns the deselected ones
+func dropDuplicateSelections(episodes []PodcastEpisode) []PodcastEpisode {
+	var dropped []PodcastEpisode
+	seen := make(map[string]bool)
+	for i, e := range episodes {
+		if !e.Selected || e.SameAudio == "" {
+			continue
+		}
+		if seen[e.SameAudio] {
+			episodes[i].Selected = false
+			dropped = append(dropped, e)
+			continue
+		}
+		seen[e.SameAudio] = true
+	}
+	return dropped
+}
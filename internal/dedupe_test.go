@@ -0,0 +1,76 @@
+package internal
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMarkDuplicates(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, data []byte) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return "file://" + path
+	}
+	audio := bytes.Repeat([]byte("a"), 4096)
+	other := bytes.Repeat([]byte("b"), 4096)
+	episodes := []PodcastEpisode{
+		{ZTitle: "Interview", ShowName: "Network Feed", FilePath: write("one.mp3", audio), FileSize: 4096},
+		{ZTitle: "Interview", ShowName: "Guest Show", FilePath: write("two.mp3", audio), FileSize: 4096},
+		{ZTitle: "Same size", ShowName: "Third Show", FilePath: write("three.mp3", other), FileSize: 4096},
+		{ZTitle: "Rerun", ShowName: "Solo", FilePath: write("four.mp3", other[:100]), FileSize: 100},
+		{ZTitle: "Rerun again", ShowName: "Solo", FilePath: write("five.mp3", other[:100]), FileSize: 100},
+	}
+	MarkDuplicates(episodes)
+
+	if episodes[0].SameAudio == "" || episodes[0].SameAudio != episodes[1].SameAudio {
+		t.Errorf("Expected the same audio under two shows to be marked, got %+v", episodes[:2])
+	}
+	if episodes[2].SameAudio != "" {
+		t.Error("Expected a file of the same size but other audio not to be marked")
+	}
+	if episodes[3].SameAudio != "" || episodes[4].SameAudio != "" {
+		t.Error("Expected reruns within one show not to be marked")
+	}
+	if got := episodes[1].Title(); got != "⧉ Interview" {
+		t.Errorf("Expected the duplicate glyph in the title, got %q", got)
+	}
+
+	episodes[0].Selected, episodes[1].Selected, episodes[2].Selected = true, true, true
+	dropped := dropDuplicateSelections(episodes)
+	if len(dropped) != 1 || dropped[0].ShowName != "Guest Show" || episodes[1].Selected || !episodes[0].Selected || !episodes[2].Selected {
+		t.Errorf("Expected only the second copy to be deselected, got %+v", dropped)
+	}
+}
+
+func TestSyncAndWait_CopiesDuplicateOnce(t *testing.T) {
+	tempDir := t.TempDir()
+	audio := bytes.Repeat([]byte("a"), 2048)
+	var episodes []PodcastEpisode
+	for _, show := range []string{"Network Feed", "Guest Show"} {
+		path := filepath.Join(tempDir, show+".mp3")
+		if err := os.WriteFile(path, audio, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		episodes = append(episodes, PodcastEpisode{ZTitle: "Interview", ShowName: show, FilePath: "file://" + path, FileSize: 2048, Selected: true})
+	}
+	mount := filepath.Join(tempDir, "STICK")
+	if err := os.MkdirAll(mount, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	progress, err := SyncAndWait(episodes, USBDrive{Name: "STICK", MountPath: mount, Folder: "podcasts"}, nil)
+	if err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if progress.FilesDone != 1 || len(progress.Skipped) != 1 {
+		t.Errorf("Expected one copy and the duplicate skipped, got %d copied and %d skipped", progress.FilesDone, len(progress.Skipped))
+	}
+	if _, err := os.Stat(filepath.Join(mount, "podcasts", "Guest Show")); !os.IsNotExist(err) {
+		t.Errorf("Expected the second show's copy not to be written, got %v", err)
+	}
+}
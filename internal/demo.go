@@ -0,0 +1,118 @@
+package internal
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DemoDriveName is the volume name of the drive created by NewDemo
+const DemoDriveName = "DEMO STICK"
+
+var demoShows = []struct {
+	name     string
+	titles   []string
+	duration time.Duration
+}{
+	{"The Daily Byte", []string{"Compilers at Dawn", "The Garbage Collector", "Race Conditions", "Monads Explained", "Tabs vs Spaces"}, 25 * time.Minute},
+	{"Long Walks", []string{"Coastal Path", "Mountain Pass", "Old Town", "River Delta"}, 72 * time.Minute},
+	{"History Hour", []string{"The Printing Press", "Canals", "The Telegraph", "Railways", "Radio", "Transistors"}, 58 * time.Minute},
+	{"Kitchen Science", []string{"Bread", "Fermentation", "Caramel"}, 34 * time.Minute},
+}
+
+// Demo is a synthetic library and drive for exploring the app without Apple Podcasts or USB hardware.
+// Everything lives in a temporary directory that Close removes.
+type Demo struct {
+	Root        string
+	VolumesPath string
+	Library     []PodcastEpisode
+}
+
+// NewDemo writes a deterministic library of small MP3 files and a drive that already holds a few
+// of them, so both lists, matching and syncing have something to show
+func NewDemo() (*Demo, error) {
+	root, err := os.MkdirTemp("", "podcasts-sync-demo-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create demo directory: %w", err)
+	}
+	d := &Demo{Root: root, VolumesPath: filepath.Join(root, "Volumes")}
+	if err := d.populate(); err != nil {
+		d.Close()
+		return nil, err
+	}
+	return d, nil
+}
+
+func (d *Demo) populate() error {
+	libraryDir := filepath.Join(d.Root, "Library")
+	podcastDir := filepath.Join(d.VolumesPath, DemoDriveName, "podcasts")
+	if err := os.MkdirAll(libraryDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create demo library: %w", err)
+	}
+	if err := os.MkdirAll(podcastDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create demo drive: %w", err)
+	}
+
+	// Noon avoids date shifts when formatting file names in any time zone
+	newest := time.Date(2024, time.March, 28, 12, 0, 0, 0, time.UTC)
+	n := 0
+	for s, show := range demoShows {
+		for i, title := range show.titles {
+			n++
+			path := filepath.Join(libraryDir, fmt.Sprintf("episode-%02d.mp3", n))
+			episode := PodcastEpisode{
+				ZTitle:    title,
+				ShowName:  show.name,
+				FilePath:  (&url.URL{Scheme: "file", Path: path}).String(),
+				Published: newest.AddDate(0, 0, -(i*7 + s)),
+				Duration:  show.duration + time.Duration(i)*time.Minute,
+				ShowNotes: fmt.Sprintf("<p>%s: an episode of %s about %s.</p>", title, show.name, title),
+				// All but the two newest of each show have been listened to
+				Played: i >= 2,
+			}
+
+			// One deleted download shows how missing files are handled
+			if s == 2 && i == len(show.titles)-1 {
+				d.Library = append(d.Library, episode)
+				continue
+			}
+
+			// Sizes must differ between episodes since the drive scan matches on size
+			data := demoAudio(200 + n*7)
+			if err := os.WriteFile(path, data, 0o644); err != nil {
+				return fmt.Errorf("failed to write demo episode: %w", err)
+			}
+			episode.FileSize = int64(len(data))
+
+			// The two newest episodes of the first show are already on the drive
+			if s == 0 && i < 2 {
+				showDir := filepath.Join(podcastDir, sanitizeName(show.name))
+				if err := os.MkdirAll(showDir, 0o755); err != nil {
+					return fmt.Errorf("failed to create demo show folder: %w", err)
+				}
+				if err := os.WriteFile(filepath.Join(showDir, formatEpisodeName(episode)), data, 0o644); err != nil {
+					return fmt.Errorf("failed to write demo drive episode: %w", err)
+				}
+			}
+			d.Library = append(d.Library, episode)
+		}
+	}
+	return nil
+}
+
+// demoAudio returns frames of 128 kbps, 44.1 kHz MPEG-1 Layer III silence
+func demoAudio(frames int) []byte {
+	const frameSize = 417
+	data := make([]byte, frames*frameSize)
+	for i := 0; i < len(data); i += frameSize {
+		copy(data[i:], []byte{0xFF, 0xFB, 0x90, 0x00})
+	}
+	return data
+}
+
+// Close removes the demo library and drive
+func (d *Demo) Close() error {
+	return os.RemoveAll(d.Root)
+}
@@ -0,0 +1,55 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewDemo(t *testing.T) {
+	d, err := NewDemo()
+	if err != nil {
+		t.Fatalf("NewDemo failed: %v", err)
+	}
+
+	library, _ := LoadLocalPodcasts(d.Library)
+	missing := 0
+	for _, episode := range library {
+		if episode.Missing {
+			missing++
+		}
+	}
+	if len(library) != 18 || missing != 1 {
+		t.Errorf("Expected 18 episodes with one deleted download, got %d with %d missing", len(library), missing)
+	}
+
+	drives, err := NewDriveManager(d.VolumesPath, DirectoryTemplate{}).DetectDrives()
+	if err != nil || len(drives) != 1 || drives[0].Name != DemoDriveName {
+		t.Fatalf("Expected the demo drive to be detected, got %v, %v", drives, err)
+	}
+
+	bySize := map[int64][]*PodcastEpisode{}
+	for i := range library {
+		bySize[library[i].FileSize] = append(bySize[library[i].FileSize], &library[i])
+	}
+	onDrive, err := NewPodcastScanner(DirectoryTemplate{}).ScanDrive(drives[0], bySize)
+	if err != nil {
+		t.Fatalf("ScanDrive failed: %v", err)
+	}
+	matched := 0
+	for _, episode := range library {
+		if episode.OnDrive {
+			matched++
+		}
+	}
+	if len(onDrive) != 2 || matched != 2 {
+		t.Errorf("Expected two episodes on the drive matched to the library, got %d on drive and %d matched", len(onDrive), matched)
+	}
+
+	if err := d.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(d.Root)); !os.IsNotExist(err) {
+		t.Error("Expected Close to remove the demo directory")
+	}
+}
@@ -0,0 +1,292 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Destination is a folder podcasts-sync can't write directly, such as an Android device, a WebDAV share
+// or an encrypted folder.
+// Drives with one are synced into a local mirror that is pushed to the destination after each change.
+type Destination interface {
+	// String describes the destination in the drive selector
+	String() string
+	// List returns the size of each file below the destination, keyed by slash-separated path
+	List() (map[string]int64, error)
+	// Put writes the file at rel from size bytes of r, creating its folders
+	Put(rel string, r io.Reader, size int64) error
+	// Remove deletes the files at rels and the folders they leave empty
+	Remove(rels []string) error
+	// Tuning says how pushes to the destination run
+	Tuning() PushTuning
+}
+
+// PushTuning is how many files a push puts at once, and how often it tries each
+type PushTuning struct {
+	Workers  int
+	Attempts int
+}
+
+// pushedFile lists, in a mirror, the files pushed to its destination. Only those are ever removed from it.
+const pushedFile = ".podcasts-sync-pushed.json"
+
+// pushRetryDelay is the wait before the second attempt at a file, growing with each further one; tests shorten it
+var pushRetryDelay = 2 * time.Second
+
+// DefaultMirrorsDir returns the folder holding the local mirrors of destinations
+func DefaultMirrorsDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = filepath.Join(os.Getenv("HOME"), ".cache")
+	}
+	return filepath.Join(dir, "podcasts-sync")
+}
+
+// SetMirrors lists Android devices and the WebDAV shares of profiles among the drives, each synced
+// through a mirror folder below dir
+func (dm *DriveManager) SetMirrors(dir string) {
+	dm.mirrors = dir
+}
+
+// destination returns where the drive's mirror is pushed, or nil for drives written directly
+func (d USBDrive) destination() Destination {
+	switch {
+	case d.Serial != "":
+		return adbDestination{serial: d.Serial, folder: d.Profile.ADBFolder()}
+	case d.Profile.WebDAV != nil:
+		return newWebDAVDestination(*d.Profile.WebDAV)
+	case d.EncryptedFolder() != "":
+		dir := d.EncryptedFolder()
+		return newEncryptedDestination(folderDestination{dir: dir}, dir, *d.Profile.Encrypt)
+	}
+	return nil
+}
+
+// pushToDestination pushes the mirror at podcastDir to the destination of the drive being synced, if it has one
+func (ps *PodcastSync) pushToDestination(podcastDir string, tm *TransferManager) error {
+	if ps.remote == nil {
+		return nil
+	}
+	return pushMirror(ps.remote, podcastDir, tm)
+}
+
+// mirrorFiles returns the size of each file in the mirror to push, keyed by slash-separated path.
+// podcasts-sync's own bookkeeping and unfinished copies stay on the computer.
+func mirrorFiles(mirror string) (map[string]int64, error) {
+	files := make(map[string]int64)
+	err := filepath.WalkDir(mirror, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name := d.Name()
+		if p != mirror && strings.HasPrefix(name, ".podcasts-sync") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || isPartialFile(name) || strings.HasSuffix(name, ".tmp") {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(mirror, p)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = info.Size()
+		return nil
+	})
+	return files, err
+}
+
+// pushPlan is what a push changes: files to put, files to remove from the destination because they
+// left the mirror, and files removed at the destination that are to leave the mirror too
+type pushPlan struct {
+	put    []string
+	remove []string
+	gone   []string
+}
+
+// planPush compares the mirror with the destination. A pushed file missing from the destination was
+// deleted there, so it isn't pushed again. Files podcasts-sync never pushed are never removed.
+func planPush(local, remote map[string]int64, pushed []string) pushPlan {
+	var plan pushPlan
+	for _, rel := range slices.Sorted(maps.Keys(local)) {
+		size, there := remote[rel]
+		switch {
+		case !there && slices.Contains(pushed, rel):
+			plan.gone = append(plan.gone, rel)
+		case !there || size != local[rel]:
+			plan.put = append(plan.put, rel)
+		}
+	}
+	for _, rel := range pushed {
+		if _, inMirror := local[rel]; !inMirror {
+			if _, there := remote[rel]; there {
+				plan.remove = append(plan.remove, rel)
+			}
+		}
+	}
+	return plan
+}
+
+// pushMirror makes the destination match the mirror. tm, when set, grows by the bytes to put and shows their progress.
+func pushMirror(dest Destination, mirror string, tm *TransferManager) error {
+	local, err := mirrorFiles(mirror)
+	if err != nil {
+		return fmt.Errorf("failed to read the mirror of %s: %w", dest, err)
+	}
+	remote, err := dest.List()
+	if err != nil {
+		return err
+	}
+	plan := planPush(local, remote, loadPushed(mirror))
+
+	// Files deleted at the destination leave the mirror rather than coming back
+	for _, rel := range plan.gone {
+		_ = os.Remove(filepath.Join(mirror, filepath.FromSlash(rel)))
+		delete(local, rel)
+	}
+
+	var progress io.Writer = io.Discard
+	if tm != nil {
+		var total int64
+		for _, rel := range plan.put {
+			total += local[rel]
+		}
+		tm.AddTotals(total, 0)
+		progress = tm
+	}
+
+	tuning := dest.Tuning()
+	var (
+		mu   sync.Mutex
+		errs []error
+		done = make(map[string]bool)
+		wg   sync.WaitGroup
+		jobs = make(chan string)
+	)
+	for range max(tuning.Workers, 1) {
+		wg.Go(func() {
+			for rel := range jobs {
+				err := putWithRetry(dest, filepath.Join(mirror, filepath.FromSlash(rel)), rel, local[rel], tuning.Attempts, progress)
+				mu.Lock()
+				if err != nil {
+					errs = append(errs, err)
+				} else {
+					done[rel] = true
+				}
+				mu.Unlock()
+			}
+		})
+	}
+	for _, rel := range plan.put {
+		if tm != nil && tm.IsStopped() {
+			break
+		}
+		jobs <- rel
+	}
+	close(jobs)
+	wg.Wait()
+	if tm != nil && tm.IsStopped() {
+		errs = []error{ErrTransferStopped}
+	}
+
+	if len(plan.remove) > 0 {
+		if err := dest.Remove(plan.remove); err != nil {
+			errs = append(errs, fmt.Errorf("failed to remove files from %s: %w", dest, err))
+		}
+	}
+
+	// Whatever is at the destination and in the mirror now was pushed, apart from files that failed
+	var nowPushed []string
+	for rel := range local {
+		if _, there := remote[rel]; (there && !slices.Contains(plan.put, rel)) || done[rel] {
+			nowPushed = append(nowPushed, rel)
+		}
+	}
+	slices.Sort(nowPushed)
+	if err := savePushed(mirror, nowPushed); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// putWithRetry puts the file at src, trying up to attempts times. Bytes resent by a retry aren't counted twice.
+func putWithRetry(dest Destination, src, rel string, size int64, attempts int, progress io.Writer) error {
+	counted := &highWaterWriter{w: progress}
+	var err error
+	for attempt := range max(attempts, 1) {
+		if attempt > 0 {
+			time.Sleep(time.Duration(attempt) * pushRetryDelay)
+		}
+		var file *os.File
+		if file, err = os.Open(src); err != nil {
+			return err
+		}
+		counted.n = 0
+		err = dest.Put(rel, io.TeeReader(file, counted), size)
+		file.Close()
+		if err == nil || errors.Is(err, ErrTransferStopped) || errors.Is(err, ErrFileAborted) {
+			break
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to push %s: %w", path.Base(rel), err)
+	}
+	return nil
+}
+
+// highWaterWriter passes on only bytes beyond the furthest any attempt got
+type highWaterWriter struct {
+	w        io.Writer
+	n, total int64
+}
+
+func (h *highWaterWriter) Write(p []byte) (int, error) {
+	h.n += int64(len(p))
+	if extra := h.n - h.total; extra > 0 {
+		h.total = h.n
+		if _, err := h.w.Write(p[int64(len(p))-min(extra, int64(len(p))):]); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+func loadPushed(mirror string) []string {
+	data, err := os.ReadFile(filepath.Join(mirror, pushedFile))
+	if err != nil {
+		return nil
+	}
+	var pushed []string
+	if err := json.Unmarshal(data, &pushed); err != nil {
+		return nil
+	}
+	return pushed
+}
+
+func savePushed(mirror string, pushed []string) error {
+	data, err := json.MarshalIndent(pushed, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(mirror, pushedFile), data, 0o644); err != nil {
+		return fmt.Errorf("failed to record pushed files: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+package internal
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPlanPush(t *testing.T) {
+	local := map[string]int64{"A/new.mp3": 10, "A/changed.mp3": 20, "A/same.mp3": 30, "A/deleted on phone.mp3": 40}
+	remote := map[string]int64{"A/changed.mp3": 21, "A/same.mp3": 30, "A/deleted here.mp3": 50, "Music/song.mp3": 60}
+	pushed := []string{"A/changed.mp3", "A/same.mp3", "A/deleted on phone.mp3", "A/deleted here.mp3"}
+
+	plan := planPush(local, remote, pushed)
+	if want := []string{"A/changed.mp3", "A/new.mp3"}; !slices.Equal(plan.put, want) {
+		t.Errorf("Expected to push %q, got %q", want, plan.put)
+	}
+	if want := []string{"A/deleted here.mp3"}; !slices.Equal(plan.remove, want) {
+		t.Errorf("Expected to remove only pushed files that left the mirror %q, got %q", want, plan.remove)
+	}
+	if want := []string{"A/deleted on phone.mp3"}; !slices.Equal(plan.gone, want) {
+		t.Errorf("Expected files deleted on the device to leave the mirror %q, got %q", want, plan.gone)
+	}
+}
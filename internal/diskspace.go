@@ -0,0 +1,7 @@
+package internal
+
+// freeSpace returns the bytes available to this user on the volume holding path
+func freeSpace(path string) (int64, error) {
+	_, free, err := volumeSpace(path)
+	return free, err
+}
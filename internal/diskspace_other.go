@@ -0,0 +1,9 @@
+//go:build !unix
+
+package internal
+
+import "errors"
+
+func volumeSpace(path string) (capacity, free int64, err error) {
+	return 0, 0, errors.ErrUnsupported
+}
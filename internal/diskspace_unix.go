@@ -0,0 +1,14 @@
+//go:build unix
+
+package internal
+
+import "golang.org/x/sys/unix"
+
+// volumeSpace returns the size of the volume holding path and the bytes available to this user on it
+func volumeSpace(path string) (capacity, free int64, err error) {
+	var st unix.Statfs_t
+	if err := unix.Statfs(path, &st); err != nil {
+		return 0, 0, err
+	}
+	return int64(st.Blocks) * int64(st.Bsize), int64(st.Bavail) * int64(st.Bsize), nil
+}
@@ -1,9 +1,16 @@
 package internal
 
 import (
+	"errors"
+	"fmt"
 	"io"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -11,32 +18,98 @@ type USBDrive struct {
 	Name      string
 	MountPath string
 	Folder    string
+	// Serial is the adb serial of an Android device, whose MountPath is a local mirror pushed to it
+	Serial string
+	// Volume is the mount point of a drive kept in an encrypted folder, whose MountPath is a local mirror
+	Volume string
+	// UUID is the volume UUID of a drive whose profile is keyed by it rather than by its name
+	UUID string
+	// Shared is set when another mounted drive has the same name; such drives are keyed by UUID
+	Shared  bool
+	Profile DriveProfile
+	// Capacity and Free are the size of the drive's volume and the space left on it, zero when unknown
+	Capacity, Free int64
 }
 
-func (d USBDrive) Title() string { return d.Name }
+// Title is the drive's name, followed by its size and last sync when another drive shares the name
+func (d USBDrive) Title() string {
+	if !d.Shared {
+		return d.Name
+	}
+	synced := "never synced"
+	if !d.Profile.LastSync.IsZero() {
+		synced = "synced " + d.Profile.LastSync.Format("Jan 2 2006")
+	}
+	if d.Capacity > 0 {
+		return fmt.Sprintf("%s (%s, %s)", d.Name, FormatBytes(d.Capacity), synced)
+	}
+	return fmt.Sprintf("%s (%s)", d.Name, synced)
+}
 
-func (d USBDrive) Description() string { return d.MountPath }
+// SameDrive reports whether d and other are the same drive: the same volume when both UUIDs are
+// known, otherwise the same name at the same mount path
+func (d USBDrive) SameDrive(other USBDrive) bool {
+	if d.UUID != "" && other.UUID != "" {
+		return d.UUID == other.UUID && d.MountPath == other.MountPath
+	}
+	return d.Name == other.Name && d.MountPath == other.MountPath
+}
+
+func (d USBDrive) Description() string {
+	location := d.MountPath
+	if dest := d.destination(); dest != nil {
+		location = dest.String()
+	}
+	if space := d.Space(); space != "" {
+		location += " · " + space
+	}
+	if d.Profile.IOErrors.Count > 0 {
+		location += " · " + d.Profile.IOErrors.String()
+	}
+	if d.Profile.Speed.MeasuredAt.IsZero() {
+		return location
+	}
+	return location + " · " + d.Profile.Speed.String()
+}
 
 func (d USBDrive) FilterValue() string { return d.Name }
 
+// Space describes the free space on the drive, e.g. "12.3 GB free of 29.8 GB", or is empty when unknown
+func (d USBDrive) Space() string {
+	if d.Capacity <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s free of %s", FormatBytes(d.Free), FormatBytes(d.Capacity))
+}
+
+// DirectoryTemplate names the show folders and episode files on a drive. Drive profiles can set their
+// own formats; the placeholders are {show}, {title} and {date}, the date formatted as a Go layout.
 type DirectoryTemplate struct {
-	ShowNameFormat string
-	EpisodeFormat  string
-	DateFormat     string
-	SanitizeNames  bool
-	CreateIndex    bool
+	ShowNameFormat string `json:"showFormat,omitempty"`
+	EpisodeFormat  string `json:"episodeFormat,omitempty"`
+	DateFormat     string `json:"dateFormat,omitempty"`
+	SanitizeNames  bool   `json:"-"`
+	CreateIndex    bool   `json:"-"`
 }
 
 var defaultDirTemplate = DirectoryTemplate{
 	ShowNameFormat: "{show}",
 	EpisodeFormat:  "{date} - {title}",
-	DateFormat:     "2006-01-02",
+	DateFormat:     canonicalDateFormat,
 	SanitizeNames:  true,
 }
 
 type DriveManager struct {
 	volumesPath string
 	template    DirectoryTemplate
+	mirrors     string
+
+	// mu guards the profiles and the UUID cache, since drives can be detected from several goroutines
+	mu       sync.Mutex
+	profiles map[string]DriveProfile
+	// keyedByUUID is set while some profile is keyed by a volume UUID; uuids caches them by mount path
+	keyedByUUID bool
+	uuids       map[string]string
 }
 
 // NewDriveManager creates a new DriveManager instance
@@ -47,6 +120,19 @@ func NewDriveManager(volumesPath string, template DirectoryTemplate) *DriveManag
 	return &DriveManager{
 		volumesPath: volumesPath,
 		template:    template,
+		uuids:       map[string]string{},
+	}
+}
+
+// SetProfiles sets the per-drive profiles applied to detected drives, keyed by volume name or UUID
+func (dm *DriveManager) SetProfiles(profiles map[string]DriveProfile) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	// Copied, since the caller goes on editing its config while drives are detected
+	dm.profiles = maps.Clone(profiles)
+	dm.keyedByUUID = false
+	for key := range profiles {
+		dm.keyedByUUID = dm.keyedByUUID || volumeUUIDPattern.MatchString(key)
 	}
 }
 
@@ -58,19 +144,47 @@ func (dm *DriveManager) DetectDrives() ([]USBDrive, error) {
 	}
 
 	var drives []USBDrive
+	mounted := make(map[string]bool)
+	defer dm.forgetUnmounted(mounted)
+	var readable []string
 	for _, entry := range entries {
-		if entry.Name() == "Macintosh HD" {
+		if entry.Name() == "Macintosh HD" || !isReadableDrive(filepath.Join(dm.volumesPath, entry.Name())) {
 			continue
 		}
-
-		mountPath := filepath.Join(dm.volumesPath, entry.Name())
-		if isReadableDrive(mountPath) {
-			drives = append(drives, USBDrive{
-				Name:      entry.Name(),
-				MountPath: mountPath,
-				Folder:    "podcasts",
-			})
+		readable = append(readable, entry.Name())
+	}
+	names := volumeNames(readable)
+	shared := map[string]int{}
+	for _, name := range names {
+		shared[name]++
+	}
+	for _, entry := range readable {
+		mountPath := filepath.Join(dm.volumesPath, entry)
+		mounted[mountPath] = true
+		name := names[entry]
+		profile, uuid := dm.profileFor(name, mountPath, shared[name] > 1)
+		drive := USBDrive{
+			Name:      name,
+			MountPath: mountPath,
+			Folder:    driveFolder(profile),
+			UUID:      uuid,
+			Shared:    shared[name] > 1,
+			Profile:   profile,
+		}
+		// Drives that can't be measured show no space
+		drive.Capacity, drive.Free, _ = volumeSpace(mountPath)
+		if drive.Profile.Encrypt != nil {
+			var ok bool
+			if drive, ok = dm.encryptedDrive(drive); !ok {
+				continue
+			}
 		}
+		drives = append(drives, drive)
+	}
+	drives = append(drives, dm.localFolderDrives()...)
+	if dm.mirrors != "" {
+		drives = append(drives, dm.detectADBDevices()...)
+		drives = append(drives, dm.webDAVDrives()...)
 	}
 
 	return drives, nil
@@ -101,10 +215,19 @@ func (ps *PodcastScanner) ScanDrive(drive USBDrive, podcastsBySize map[int64][]*
 		}
 	}()
 
+	var scanned []PodcastEpisode
+	for podcast := range podcastsChan {
+		scanned = append(scanned, podcast)
+	}
+
+	// Durations let the matcher break size ties and give drive-only files a length to display
+	ExtractDurations(scanned)
+
 	var episodes []PodcastEpisode
-	matcher := NewPodcastMatcher(podcastsBySize)
+	matcher := newProfileMatcher(podcastsBySize, drive.Profile)
+	matcher.hashWorkers = hashWorkers(drive.Profile.Speed)
 
-	for podcast := range podcastsChan {
+	for _, podcast := range scanned {
 		if err := matcher.Match(&podcast); err != nil {
 			continue
 		}
@@ -119,19 +242,76 @@ func (ps *PodcastScanner) ScanDrive(drive USBDrive, podcastsBySize map[int64][]*
 	default:
 	}
 
+	if root, err := podcastsRoot(drive); err == nil {
+		markPinned(root, episodes)
+	}
 	return episodes, nil
 }
 
+// ErrSyncNotRunning is returned when appending to a sync that has already finished
+var ErrSyncNotRunning = errors.New("no sync is running")
+
+// ErrNotEnoughSpace is returned by a sync that wouldn't fit on the drive
+var ErrNotEnoughSpace = errors.New("not enough space on the drive")
+
+// driveFreeSpace reads the space available on a drive; tests replace it
+var driveFreeSpace = freeSpace
+
+// checkFreeSpace returns ErrNotEnoughSpace, with how much more is needed, when the volume holding
+// podcastDir can't take totalBytes. A volume whose free space can't be read is let through.
+func checkFreeSpace(drive USBDrive, podcastDir string, totalBytes int64) error {
+	if totalBytes <= 0 {
+		return nil
+	}
+	free, err := driveFreeSpace(podcastDir)
+	if err != nil || free >= totalBytes {
+		return nil
+	}
+	return fmt.Errorf("%w %s: %s more is needed to copy %s, and only %s is free",
+		ErrNotEnoughSpace, drive.Name, FormatBytes(totalBytes-free), FormatBytes(totalBytes), FormatBytes(free))
+}
+
 type PodcastSync struct {
 	tm             *TransferManager
+	queueMu        sync.Mutex
+	queue          []PodcastEpisode
+	queued         map[string]bool // FilePaths already part of the running sync
+	podcastDir     string
+	running        bool
+	partialPolicy  PartialFilePolicy
+	profile        DriveProfile
+	driveName      string
+	remote         Destination
+	runID          int64
+	history        *History
+	recordDir      string
+	recorder       *ProgressRecorder
+	allowSleep     bool
+	manifest       *Manifest
+	journal        *JournalRun
 	taggingQueue   chan taggingJob
 	taggingDone    chan struct{}
 	taggingStopped bool
+	// Files copied so far in this sync, for sampling which ones are verified in full
+	copied atomic.Int64
+	// How long the sync was estimated to take when it started, recorded next to how long it took
+	predicted time.Duration
+	// Each queued source as it was when planned, guarded by queueMu
+	planned map[string]sourceSnapshot
+}
+
+// sourceSnapshot is a source file's size and modification time when its copy was planned
+type sourceSnapshot struct {
+	size    int64
+	modTime time.Time
 }
 
 type taggingJob struct {
+	srcPath  string
 	filePath string
 	episode  PodcastEpisode
+	// laterPart marks the second and later parts of a split episode, which get no companion files
+	laterPart bool
 }
 
 // NewPodcastSync creates a new PodcastSync instance
@@ -142,10 +322,44 @@ func NewPodcastSync() *PodcastSync {
 	}
 }
 
+// SetHistory enables recording of sync and delete events in h
+func (ps *PodcastSync) SetHistory(h *History) {
+	ps.history = h
+}
+
+// SetRecordDir enables recording of raw progress samples for each sync into dir
+func (ps *PodcastSync) SetRecordDir(dir string) {
+	ps.recordDir = dir
+}
+
+// SetDrive sets the drive DeleteSelected works on; StartSync takes it from its arguments
+func (ps *PodcastSync) SetDrive(drive USBDrive) {
+	ps.profile = drive.Profile
+	ps.driveName = drive.Name
+	ps.remote = drive.destination()
+	ps.queueMu.Lock()
+	ps.podcastDir, _ = podcastsRoot(drive)
+	ps.queueMu.Unlock()
+}
+
+// SetAllowSleep lets the computer sleep during a sync instead of holding a power assertion
+func (ps *PodcastSync) SetAllowSleep(allow bool) {
+	ps.allowSleep = allow
+}
+
+// preventSleep keeps the computer awake until release is called; tests replace it
+var preventSleep = holdWakeAssertion
+
 // StartSync begins the podcast synchronization process
-func (ps *PodcastSync) StartSync(episodes []PodcastEpisode, drive USBDrive, ch chan<- FileOp) *TransferManager {
+func (ps *PodcastSync) StartSync(episodes []PodcastEpisode, drive USBDrive, sink ProgressSink) *TransferManager {
+	// Work on a copy - sizes and selection are adjusted below and the caller keeps reading its slice
+	episodes = slices.Clone(episodes)
+
+	// Examining a big library on a slow drive takes a while, so it is reported once it does
+	prep := newPrepareReporter(sink, len(episodes)+countSelected(episodes))
+
 	// Ensure FileSize is set for all episodes before calculating totalBytes
-	updatedEpisodes, err := LoadLocalPodcasts(episodes)
+	updatedEpisodes, err := loadLocalPodcasts(episodes, prep)
 	if err == nil {
 		episodes = updatedEpisodes
 	}
@@ -161,19 +375,57 @@ func (ps *PodcastSync) StartSync(episodes []PodcastEpisode, drive USBDrive, ch c
 		}
 	}
 
-	podcastDir := filepath.Join(drive.MountPath, drive.Folder)
-	if err := os.MkdirAll(podcastDir, 0o755); err != nil {
-		ch <- newFileOp(TransferProgress{}, false, err)
-		close(ch)
+	ps.profile = drive.Profile
+	ps.driveName = drive.Name
+	ps.remote = drive.destination()
+	ps.runID = time.Now().UnixNano()
+	ps.copied.Store(0)
+
+	podcastDir, err := podcastsRoot(drive)
+	if err == nil {
+		err = os.MkdirAll(podcastDir, 0o755)
+	}
+	if err != nil {
+		sink.Send(newFileOp(TransferProgress{}, false, err))
+		sink.Close()
 		return nil
 	}
+	// A manifest that can't be read, e.g. from a newer release, would lose its entries on save
+	ps.manifest, err = LoadManifest(podcastDir)
+	if err != nil {
+		sink.Send(newFileOp(TransferProgress{}, false, err))
+		sink.Close()
+		return nil
+	}
+	// A sync frees the space the last cleanup kept for undoing it; best-effort, like the history
+	_ = emptyTrash(podcastDir)
+	ps.journal = newJournalRun(podcastDir)
+	if err := RenumberEpisodes(podcastDir, drive.Profile, ps.manifest, ps.journal); err != nil {
+		sink.Send(newFileOp(TransferProgress{}, false, err))
+		sink.Close()
+		return nil
+	}
+
+	// The same audio selected under several shows is copied once
+	duplicates := dropDuplicateSelections(episodes)
+	prep.skip(len(duplicates))
 
 	// Calculate actual totals based on files that need to be transferred
-	actualTotalBytes, actualTotalFiles := ps.calculateActualTotals(episodes, podcastDir)
+	actualTotalBytes, actualTotalFiles, missing, skipped := ps.calculateActualTotals(episodes, podcastDir, prep)
+	skipped = append(skipped, duplicates...)
+
+	// A drive that can't fit the sync is reported now rather than by a write failing halfway
+	if err := checkFreeSpace(drive, podcastDir, actualTotalBytes); err != nil {
+		sink.Send(newFileOp(TransferProgress{}, false, err))
+		sink.Close()
+		return nil
+	}
 
 	// Send initial progress with actual totals
 	progress := initializeProgress(actualTotalBytes, actualTotalFiles)
-	ch <- newFileOp(progress, false, nil)
+	progress.Missing = missing
+	progress.Skipped = skipped
+	sink.Send(newFileOp(progress, false, nil))
 
 	// Stop any existing TransferManager before creating a new one
 	// This ensures the old senderLoop goroutine is fully stopped
@@ -182,19 +434,139 @@ func (ps *PodcastSync) StartSync(episodes []PodcastEpisode, drive USBDrive, ch c
 		ps.tm = nil
 	}
 
-	ps.tm = NewTransferManager(actualTotalBytes, actualTotalFiles, ch)
+	ps.predicted = 0
+	if actualTotalFiles > 0 {
+		// Like the history, the estimate is best-effort
+		if estimate, err := EstimateSync(ps.history, drive, actualTotalBytes, actualTotalFiles); err == nil {
+			ps.predicted = estimate.Duration
+		}
+	}
+
+	ps.tm = NewTransferManager(actualTotalBytes, actualTotalFiles, sink)
+	ps.tm.SetMissing(missing)
+	ps.tm.SetSkipped(skipped)
+	for _, episode := range episodes {
+		if !episode.Selected {
+			continue
+		}
+		if exists, _ := fileExists(filepath.Join(podcastDir, ps.profile.syncedPath(episode))); !exists {
+			ps.tm.SetFileState(episode.FilePath, FileQueued)
+		}
+	}
+
+	ps.recorder = nil
+	if ps.recordDir != "" {
+		// Recording is diagnostic only - a sync proceeds without it
+		if rec, err := NewProgressRecorder(ps.recordDir); err == nil {
+			ps.tm.SetRecorder(rec)
+			ps.recorder = rec
+		}
+	}
+
+	ps.queueMu.Lock()
+	ps.queue = episodes
+	ps.queued = make(map[string]bool)
+	for _, episode := range episodes {
+		if episode.Selected {
+			ps.queued[episode.FilePath] = true
+		}
+	}
+	ps.podcastDir = podcastDir
+	ps.running = true
+	ps.queueMu.Unlock()
+
+	// Keep the computer awake until the last file is written; a sync that can't still runs
+	release := func() {}
+	if !ps.allowSleep && actualTotalFiles > 0 {
+		if r, err := preventSleep(); err == nil {
+			release = r
+		}
+	}
 
 	// Start background tagging goroutine
 	go ps.taggingWorker()
 
-	go ps.syncEpisodes(episodes, podcastDir, ch)
+	go ps.syncEpisodes(podcastDir, sink, release)
 
 	return ps.tm
 }
 
-// DeleteSelected removes selected episodes from the drive
+// Append adds selected episodes to the running sync and grows its totals.
+// Episodes already queued or already on the drive are ignored.
+// Returns the number of files added.
+func (ps *PodcastSync) Append(episodes []PodcastEpisode) (int, error) {
+	ps.queueMu.Lock()
+	defer ps.queueMu.Unlock()
+
+	if !ps.running || ps.tm == nil {
+		return 0, ErrSyncNotRunning
+	}
+
+	var (
+		added      []PodcastEpisode
+		totalBytes int64
+	)
+	for _, episode := range episodes {
+		if !episode.Selected || ps.queued[episode.FilePath] {
+			continue
+		}
+		if episode.FileSize == 0 {
+			if filePath, err := convertFileURIToPath(episode.FilePath); err == nil {
+				if stat, err := os.Stat(filePath); err == nil {
+					episode.FileSize = stat.Size()
+				}
+			}
+		}
+		if exists, _ := fileExists(filepath.Join(ps.podcastDir, ps.profile.syncedPath(episode))); exists || sourceMissing(episode) || episode.Downloading {
+			continue
+		}
+		ps.snapshotSource(episode)
+		added = append(added, episode)
+		totalBytes += episode.FileSize
+	}
+
+	if len(added) == 0 {
+		return 0, nil
+	}
+	if !ps.tm.AddTotals(totalBytes, len(added)) {
+		return 0, ErrSyncNotRunning
+	}
+
+	for _, episode := range added {
+		ps.queued[episode.FilePath] = true
+		ps.tm.SetFileState(episode.FilePath, FileQueued)
+	}
+	ps.queue = append(ps.queue, added...)
+	return len(added), nil
+}
+
+// nextEpisode pops the next queued episode, marking the sync as no longer
+// accepting appends once the queue is empty
+func (ps *PodcastSync) nextEpisode() (PodcastEpisode, bool) {
+	ps.queueMu.Lock()
+	defer ps.queueMu.Unlock()
+
+	if len(ps.queue) == 0 {
+		ps.running = false
+		return PodcastEpisode{}, false
+	}
+	episode := ps.queue[0]
+	ps.queue = ps.queue[1:]
+	return episode, true
+}
+
+// DeleteSelected removes selected episodes from the drive set with SetDrive. Episodes on drives with a
+// manifest are moved to the trash and journaled, so the cleanup can be undone until the next sync or cleanup.
+// Files outside the drive's podcasts folder are refused with ErrOutsidePodcastsFolder.
 func (ps *PodcastSync) DeleteSelected(episodes []PodcastEpisode) FileOp {
+	ps.queueMu.Lock()
+	root := ps.podcastDir
+	ps.queueMu.Unlock()
+
 	visitedDirs := make(map[string]bool)
+	manifests := make(map[string]*Manifest)
+	journals := make(map[string]*JournalRun)
+	unreadable := make(map[string]bool)
 	var errors []error
 
 	// Delete files - continue even if some deletions fail
@@ -203,17 +575,82 @@ func (ps *PodcastSync) DeleteSelected(episodes []PodcastEpisode) FileOp {
 			continue
 		}
 
+		if err := checkInside(root, episode.FilePath); err != nil {
+			errors = append(errors, err)
+			continue
+		}
 		dir := filepath.Dir(episode.FilePath)
 		visitedDirs[dir] = true
 
-		if err := os.Remove(episode.FilePath); err != nil {
+		// A manifest above the podcasts folder doesn't make its trash and folders ours to change
+		manifestDir, tracked := findManifestDir(episode.FilePath)
+		tracked = tracked && (manifestDir == root || within(root, manifestDir))
+		if tracked && unreadable[manifestDir] {
+			continue
+		}
+		if tracked && manifests[manifestDir] == nil {
+			manifest, err := LoadManifest(manifestDir)
+			if err != nil {
+				// Files are left alone rather than removed behind a manifest we can't update
+				errors = append(errors, err)
+				unreadable[manifestDir] = true
+				continue
+			}
+			manifests[manifestDir] = manifest
+			// Only the last cleanup can be undone
+			if err := emptyTrash(manifestDir); err != nil {
+				errors = append(errors, err)
+			}
+			journals[manifestDir] = newJournalRun(manifestDir)
+		}
+
+		var err error
+		if tracked {
+			var entry *ManifestEntry
+			if e, ok := manifests[manifestDir].Entry(episode.FilePath); ok {
+				entry = &e
+			}
+			err = journals[manifestDir].trash(episode.FilePath, entry)
+		} else {
+			err = os.Remove(episode.FilePath)
+			// Companion files (chapters, shownotes, artwork) go with their episode
+			for _, companion := range companionPaths(episode.FilePath) {
+				_ = os.Remove(companion)
+			}
+		}
+		if err != nil {
 			// Collect all errors instead of stopping at first one
 			errors = append(errors, err)
+			continue
+		}
+
+		ps.record(HistoryRemoved, episode, nil)
+		if tracked {
+			manifests[manifestDir].Remove(episode.FilePath)
+			// Nested layouts leave the show folder above the emptied year or show folder
+			for parent := filepath.Dir(dir); strings.HasPrefix(parent, manifestDir+string(filepath.Separator)); parent = filepath.Dir(parent) {
+				visitedDirs[parent] = true
+			}
+		}
+	}
+
+	for dir, manifest := range manifests {
+		if err := manifest.Save(); err != nil {
+			errors = append(errors, fmt.Errorf("failed to update drive manifest: %w", err))
+		}
+		if err := saveJournalRun(dir, UndoCleanup, journals[dir]); err != nil {
+			errors = append(errors, err)
+		}
+		if err := writeDriveIndexes(dir, ps.profile); err != nil {
+			errors = append(errors, err)
 		}
 	}
 
 	// Clean up empty directories (including hidden system files)
-	ps.cleanupEmptyDirs(visitedDirs, &errors)
+	ps.cleanupEmptyDirs(root, visitedDirs, &errors)
+	if err := ps.pushToDestination(root, nil); err != nil {
+		errors = append(errors, err)
+	}
 
 	// Return first error if any occurred
 	var finalError error
@@ -251,65 +688,159 @@ func (ps *PodcastScanner) scanDirectory(drive USBDrive, results chan<- PodcastEp
 		return nil
 	}
 
-	return filepath.Walk(podcastDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil || info.IsDir() || !isAudioFile(path) {
-			return err
-		}
-
-		episode, err := parseEpisodeFromPath(path, ps.template)
+	return walkAudioFiles(podcastDir, func(path string, info os.FileInfo) error {
+		episode, err := parseEpisodeFromPath(path, drive.Profile.withTemplate(ps.template), drive.Profile.Layout)
 		if err != nil {
 			return err
 		}
 
 		episode.FileSize = info.Size()
+		episode.Downloaded = info.ModTime()
 		results <- episode
 		return nil
 	})
 }
 
-func (ps *PodcastSync) syncEpisodes(episodes []PodcastEpisode, podcastDir string, ch chan<- FileOp) {
+// maxConcurrency caps how many episodes a drive profile can copy at once
+const maxConcurrency = 16
+
+// workers is how many episodes the profile copies at once
+func (p DriveProfile) workers() int {
+	return max(p.Concurrency, 1)
+}
+
+// syncEpisodes copies the queued episodes, calling release once the sync has finished or been cancelled
+func (ps *PodcastSync) syncEpisodes(podcastDir string, sink ProgressSink, release func()) {
 	// Capture the current TransferManager in a local variable
 	// This prevents issues if ps.tm is overwritten by a new StartSync() call
 	tm := ps.tm
+	recorder := ps.recorder
+	manifest := ps.manifest
+	journal := ps.journal
+	runID, predicted, start := ps.runID, ps.predicted, time.Now()
+	finished := false
+
+	// Includes episodes appended while running, for the final cleanup pass
+	var processed []PodcastEpisode
 
 	defer func() {
-		// Close tagging queue to signal no more jobs
-		if !ps.taggingStopped {
-			close(ps.taggingQueue)
-			ps.taggingStopped = true
+		ps.queueMu.Lock()
+		ps.running = false
+		ps.queue = nil
+		ps.queueMu.Unlock()
+
+		ps.finishTagging()
+		if finished && tm != nil {
+			// Tagging counts toward the time taken, so it is recorded once tagging is done
+			ps.recordTiming(runID, podcastDir, tm.Snapshot(), predicted, time.Since(start))
 		}
 
-		// Wait for all pending tagging jobs to complete
-		<-ps.taggingDone
-
 		// Final cleanup pass: Remove any orphaned ID3 temp files
 		// This ensures no duplicate files remain after sync completion
-		ps.cleanupAllID3TempFiles(episodes, podcastDir)
+		ps.cleanupAllID3TempFiles(processed, podcastDir)
+		// Journal whatever was copied, including before a failure or cancel, so it can be undone
+		_ = saveJournalRun(podcastDir, UndoSync, journal)
 
 		// Stop the TransferManager first to shut down ProgressWriter
 		if tm != nil {
 			tm.Stop()
 		}
-		// Now safe to close the channel
-		safeClose(ch)
+		if recorder != nil {
+			_ = recorder.Close()
+		}
+		release()
+		// Nothing is sent after this, so the sink learns the sync has ended
+		sink.Close()
 	}()
 
-	for _, episode := range episodes {
-		if tm != nil && tm.IsStopped() {
-			break
-		}
+	// Each worker copies the next queued episode until the queue runs out, the transfer is stopped or
+	// an episode fails; the files being copied when another fails still finish
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex // guards processed and failure
+		failure error
+	)
+	for range ps.profile.workers() {
+		wg.Go(func() {
+			for {
+				mu.Lock()
+				failed := failure != nil
+				mu.Unlock()
+				if failed {
+					return
+				}
+				episode, ok := ps.nextEpisode()
+				if !ok {
+					return
+				}
+				if tm != nil && tm.IsStopped() {
+					return
+				}
 
-		if !episode.Selected {
-			continue
-		}
+				if !episode.Selected {
+					continue
+				}
+				mu.Lock()
+				processed = append(processed, episode)
+				mu.Unlock()
+
+				if err := ps.syncEpisode(episode, podcastDir); err != nil {
+					if tm != nil {
+						tm.SetFileState(episode.FilePath, FileFailed)
+					}
+					ps.record(HistoryFailed, episode, err)
+					mu.Lock()
+					if failure == nil {
+						failure = err
+					}
+					mu.Unlock()
+					return
+				}
+			}
+		})
+	}
+	wg.Wait()
+	if failure != nil {
+		// Keep the episodes copied so far in the manifest; the copy error is the one to report
+		_ = manifest.Save()
+		sink.Send(newFileOp(TransferProgress{}, false, failure))
+		return
+	}
 
-		if err := ps.syncEpisode(episode, podcastDir); err != nil {
-			safeSend(ch, newFileOp(TransferProgress{}, false, err))
+	if err := manifest.Save(); err != nil {
+		sink.Send(newFileOp(TransferProgress{}, false, fmt.Errorf("failed to update drive manifest: %w", err)))
+		return
+	}
+	if ps.profile.Playlist != PlaylistNone || ps.profile.IPod || ps.remote != nil {
+		// The playlist, iTunesDB and push read the tagged files, so tagging has to finish first
+		ps.finishTagging()
+		if err := writeDriveIndexes(podcastDir, ps.profile); err != nil {
+			sink.Send(newFileOp(TransferProgress{}, false, err))
+			return
+		}
+		if err := ps.pushToDestination(podcastDir, tm); err != nil {
+			sink.Send(newFileOp(TransferProgress{}, false, err))
 			return
 		}
 	}
+	finished = !tm.IsStopped()
+	sink.Send(newFileOp(tm.Snapshot(), true, nil))
+}
 
-	safeSend(ch, newFileOp(*ps.tm.progress, true, nil))
+// recordTiming stores how long a finished sync took, to refine the estimates of later syncs
+func (ps *PodcastSync) recordTiming(runID int64, podcastDir string, progress TransferProgress, predicted, actual time.Duration) {
+	if progress.FilesDone == 0 {
+		return
+	}
+	_ = ps.history.RecordTiming(SyncTiming{
+		RunID:      runID,
+		Drive:      ps.driveName,
+		FileSystem: fileSystemName(podcastDir),
+		Files:      progress.FilesDone,
+		Bytes:      progress.BytesTransferred,
+		Predicted:  predicted,
+		Actual:     actual,
+	})
 }
 
 func (ps *PodcastSync) syncEpisode(episode PodcastEpisode, podcastDir string) error {
@@ -318,102 +849,267 @@ func (ps *PodcastSync) syncEpisode(episode PodcastEpisode, podcastDir string) er
 		return err
 	}
 
-	showDir := filepath.Join(podcastDir, sanitizeName(episode.ShowName))
-	if err := os.MkdirAll(showDir, 0o755); err != nil {
+	destPath := filepath.Join(podcastDir, ps.profile.EpisodePath(episode))
+	if err := os.MkdirAll(filepath.Dir(destPath), 0o755); err != nil {
 		return err
 	}
 
-	destPath := filepath.Join(showDir, formatEpisodeName(episode))
-	if exists, _ := fileExists(destPath); exists {
+	if exists, _ := fileExists(filepath.Join(podcastDir, ps.profile.syncedPath(episode))); exists {
 		// File exists - skip it entirely since it's not counted in totals
+		ps.tm.SetFileState(episode.FilePath, FileSkipped)
 		return nil
 	}
 
+	// Podcasts.app may have re-downloaded or deleted the source since the sync was planned
+	episode, ok := ps.replan(episode, filePath)
+	if !ok {
+		return nil
+	}
+
+	if ps.profile.ExportShownotes && episode.ShowNotes == "" {
+		episode.ShowNotes = lazyShowNotes([]PodcastEpisode{episode})[episode.FilePath]
+	}
+
+	if parts := ps.profile.Split.Parts(episode); parts > 1 {
+		return ps.copyParts(episode, filePath, destPath, parts)
+	}
 	return ps.copyEpisode(episode, filePath, destPath)
 }
 
 func (ps *PodcastSync) copyEpisode(episode PodcastEpisode, srcPath, destPath string) error {
-	ps.tm.StartFile(episode.ZTitle)
+	file := ps.tm.BeginFile(episode.ZTitle)
+	ps.tm.SetFileState(episode.FilePath, FileCopying)
+
+	// Copy into a partial file that only takes the final name once complete,
+	// so an interrupted copy is never mistaken for a synced episode
+	partialPath := destPath + partialSuffix
+	adoptLegacyPartial(partialPath)
+	if err := preparePartial(srcPath, partialPath); err != nil {
+		return err
+	}
+	for {
+		completed, err := ps.copyToPartial(file, episode, srcPath, partialPath)
+		if errors.Is(err, ErrFileAborted) {
+			switch file.takeAction() {
+			case FileSkip:
+				ps.skipEpisode(file, episode, partialPath)
+				return nil
+			case FileRetry:
+				// The partial file is kept, so the retry resumes where the copy stalled
+				file.Restart(episode.ZTitle)
+				continue
+			}
+		}
+		if err != nil {
+			// The partial file is kept for the next sync to resume
+			_ = keepPartial(srcPath, partialPath)
+			return err
+		}
+		if !completed {
+			return nil
+		}
+		break
+	}
+	// Verify before tagging rewrites the copy; a bad copy is removed so the next sync copies it again
+	if err := ps.verifyCopy(srcPath, partialPath); err != nil {
+		ps.cleanupPartial(partialPath)
+		return fmt.Errorf("failed to verify %s: %w", filepath.Base(destPath), err)
+	}
+	if err := os.Rename(partialPath, destPath); err != nil {
+		return fmt.Errorf("failed to finalize %s: %w", filepath.Base(destPath), err)
+	}
+	_ = os.Remove(partialPath + ".json")
+
+	// Mark file as completed
+	file.Complete(episode.FileSize)
+	ps.tm.SetFileState(episode.FilePath, FileDone)
+	ps.record(HistorySynced, episode, nil)
+	ps.manifest.Set(destPath, ManifestEntry{
+		Show:     episode.ShowName,
+		Title:    episode.ZTitle,
+		Size:     episode.FileSize,
+		SyncedAt: time.Now(),
+	})
+	ps.journal.record(JournalCopied, destPath, "", nil)
+
+	ps.queueTagging(taggingJob{srcPath: srcPath, filePath: destPath, episode: episode})
+	return nil
+}
+
+// queueTagging tags a copied file asynchronously, so the next file can start transferring immediately
+func (ps *PodcastSync) queueTagging(job taggingJob) {
+	select {
+	case ps.taggingQueue <- job:
+		// Job queued successfully
+	default:
+		// Queue is full, tag synchronously (rare case)
+		ps.tag(job)
+	}
+}
+
+// skipEpisode gives up on an episode the user skipped mid-copy and takes it out of the totals
+func (ps *PodcastSync) skipEpisode(file *FileTransfer, episode PodcastEpisode, partialPath string) {
+	ps.cleanupPartial(partialPath)
+	file.Drop(episode.FileSize)
+	ps.tm.SetFileState(episode.FilePath, FileFailed)
+	ps.record(HistoryFailed, episode, errFileSkipped)
+}
+
+// errFileSkipped is recorded in the history for episodes skipped during a sync
+var errFileSkipped = errors.New("skipped during sync")
+
+// record stores a history entry for episode; history is best-effort and never fails a sync
+func (ps *PodcastSync) record(action HistoryAction, episode PodcastEpisode, err error) {
+	entry := HistoryEntry{
+		RunID:    ps.runID,
+		Action:   action,
+		Title:    episode.ZTitle,
+		ShowName: episode.ShowName,
+		Source:   episode.FilePath,
+		Drive:    ps.driveName,
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	_ = ps.history.Record(entry)
+}
+
+// copyToPartial fills partialPath with the contents of srcPath, resuming an earlier partial copy.
+// Returns false without an error if the transfer was stopped midway.
+func (ps *PodcastSync) copyToPartial(file *FileTransfer, episode PodcastEpisode, srcPath, partialPath string) (bool, error) {
+	// Destinations on the source's volume can share its blocks instead of copying them
+	if exists, _ := fileExists(partialPath); !exists && cloneFile(srcPath, partialPath) == nil {
+		file.Advance(episode.FileSize)
+		return true, nil
+	}
 
 	srcFile, err := os.Open(srcPath)
 	if err != nil {
-		return err
+		return false, err
 	}
 	defer srcFile.Close()
 
-	destFile, err := os.Create(destPath)
+	destFile, err := ps.openPartial(file, srcFile, partialPath)
 	if err != nil {
-		return err
+		return false, err
 	}
 	defer destFile.Close()
 
-	// Copy with periodic syncs for progress visibility
-	// Using MultiWriter for atomic writes to both file and progress tracker
-	const bufSize = 256 * 1024           // 256KB buffer
+	// Tags and companions are written after the copy, so no byte has to pass through the
+	// process and the kernel can copy directly where the platform allows it
+	if kernelCopy {
+		err = copyKernel(destFile, srcFile, file)
+	} else {
+		err = copySynced(destFile, srcFile, file, copyBufferFor(ps.profile))
+	}
+	if err != nil {
+		if ps.tm.IsStopped() {
+			ps.cancelPartial(destFile, srcPath, partialPath)
+			return false, nil
+		}
+		return false, err
+	}
+
+	// Final sync to ensure all data is written
+	if err := destFile.Sync(); err != nil {
+		return false, err
+	}
+	return true, destFile.Close()
+}
+
+// copyBufferSize is the chunk size episodes are copied in
+const copyBufferSize = 256 * 1024
+
+// copySynced copies src to dst in bufSize chunks, writing each chunk to progress as well.
+// dst is synced periodically so progress stays visible on slow USB drives.
+func copySynced(dst *os.File, src io.Reader, progress io.Writer, bufSize int) error {
 	const syncInterval = 8 * 1024 * 1024 // Sync every 8MB for balance of performance and responsiveness
 
 	buf := make([]byte, bufSize)
-	writer := io.MultiWriter(destFile, ps.tm)
+	// MultiWriter keeps the file and the progress tracker in step
+	writer := io.MultiWriter(dst, progress)
 
 	var bytesWrittenSinceSync int64
-
 	for {
-		nr, er := srcFile.Read(buf)
+		nr, er := src.Read(buf)
 		if nr > 0 {
 			nw, ew := writer.Write(buf[0:nr])
 			if ew != nil {
-				if ps.tm.IsStopped() {
-					ps.cleanup(destPath, filepath.Dir(destPath))
-					return nil
-				}
 				return ew
 			}
 			if nr != nw {
 				return io.ErrShortWrite
 			}
 
+			// Less frequent syncs reduce blocking I/O overhead
 			bytesWrittenSinceSync += int64(nw)
-
-			// Sync periodically to ensure progress is visible on slow USB drives
-			// Less frequent syncs (16MB) reduce blocking I/O overhead
 			if bytesWrittenSinceSync >= syncInterval {
-				if err := destFile.Sync(); err != nil {
+				if err := dst.Sync(); err != nil {
 					return err
 				}
 				bytesWrittenSinceSync = 0
 			}
 		}
+		if er == io.EOF {
+			return nil
+		}
 		if er != nil {
-			if er != io.EOF {
-				if ps.tm.IsStopped() {
-					ps.cleanup(destPath, filepath.Dir(destPath))
-					return nil
-				}
-				return er
-			}
-			break
+			return er
 		}
 	}
+}
 
-	// Final sync to ensure all data is written
-	if err := destFile.Sync(); err != nil {
-		return err
+// openPartial opens the partial file for an episode. A partial file kept from a
+// cancelled sync is resumed from where it stopped; anything else starts over.
+func (ps *PodcastSync) openPartial(file *FileTransfer, srcFile *os.File, partialPath string) (*os.File, error) {
+	srcInfo, err := srcFile.Stat()
+	if err != nil {
+		return nil, err
 	}
 
-	// Mark file as completed
-	ps.tm.CompleteFile(episode.FileSize)
+	if info, err := os.Stat(partialPath); err == nil && info.Size() > 0 && info.Size() < srcInfo.Size() {
+		destFile, err := os.OpenFile(partialPath, os.O_WRONLY|os.O_APPEND, 0o644)
+		if err == nil {
+			if _, err = srcFile.Seek(info.Size(), io.SeekStart); err == nil {
+				file.Advance(info.Size())
+				return destFile, nil
+			}
+			destFile.Close()
+		}
+	}
 
-	// Queue ID3 tagging to happen asynchronously
-	// This allows the next file to start transferring immediately
-	select {
-	case ps.taggingQueue <- taggingJob{filePath: destPath, episode: episode}:
-		// Job queued successfully
-	default:
-		// Queue is full, tag synchronously (rare case)
-		_ = AddID3Tags(destPath, episode)
+	return os.Create(partialPath)
+}
+
+// cancelPartial applies the partial file policy to an interrupted copy of srcPath
+func (ps *PodcastSync) cancelPartial(destFile *os.File, srcPath, partialPath string) {
+	if ps.PartialPolicy() == PartialKeep {
+		_ = destFile.Sync()
+		_ = keepPartial(srcPath, partialPath)
+		return
 	}
+	ps.cleanupPartial(partialPath)
+}
 
-	return nil
+// SetPartialPolicy sets what happens to the file being copied if the sync is cancelled.
+// Call before stopping the transfer; anything but PartialKeep deletes the partial file.
+func (ps *PodcastSync) SetPartialPolicy(policy PartialFilePolicy) {
+	ps.queueMu.Lock()
+	defer ps.queueMu.Unlock()
+	ps.partialPolicy = policy
+}
+
+// PartialPolicy returns the policy applied when the sync is cancelled
+func (ps *PodcastSync) PartialPolicy() PartialFilePolicy {
+	ps.queueMu.Lock()
+	defer ps.queueMu.Unlock()
+	return ps.partialPolicy
+}
+
+// cleanupPartial removes a partial file with its sidecar, and its folder if that is left empty
+func (ps *PodcastSync) cleanupPartial(partialPath string) {
+	_ = os.Remove(partialPath + ".json")
+	ps.cleanup(partialPath, filepath.Dir(partialPath))
 }
 
 func (ps *PodcastSync) cleanup(filePath, dirPath string) {
@@ -423,8 +1119,23 @@ func (ps *PodcastSync) cleanup(filePath, dirPath string) {
 	}
 }
 
-func (ps *PodcastSync) cleanupEmptyDirs(dirs map[string]bool, errors *[]error) {
-	for dir := range dirs {
+// cleanupEmptyDirs removes the dirs left empty, never touching root itself or anything outside it
+func (ps *PodcastSync) cleanupEmptyDirs(root string, dirs map[string]bool, errors *[]error) {
+	// Deepest first, so a folder emptied by removing its subfolder is removed too
+	sorted := slices.SortedFunc(maps.Keys(dirs), func(a, b string) int {
+		return len(b) - len(a)
+	})
+	for _, dir := range sorted {
+		if dir == root {
+			continue
+		}
+		if err := checkInside(root, dir); err != nil {
+			if _, statErr := os.Stat(dir); statErr == nil {
+				*errors = append(*errors, err)
+			}
+			continue
+		}
+
 		// First, try to clean up any hidden system files
 		cleanupSystemHiddenFiles(dir)
 
@@ -440,27 +1151,101 @@ func (ps *PodcastSync) cleanupEmptyDirs(dirs map[string]bool, errors *[]error) {
 	}
 }
 
-// calculateActualTotals checks which files need to be transferred and returns actual totals
-func (ps *PodcastSync) calculateActualTotals(episodes []PodcastEpisode, podcastDir string) (int64, int) {
+// calculateActualTotals checks which files need to be transferred and returns actual totals,
+// along with the selected episodes skipped because they are already on the drive.
+// Episodes whose source file has disappeared since loading (e.g. removed by Podcasts.app) are
+// deselected and returned as missing instead of failing the sync when they are reached. Each selected
+// episode examined is counted with prep.
+func (ps *PodcastSync) calculateActualTotals(episodes []PodcastEpisode, podcastDir string, prep *prepareReporter) (int64, int, []PodcastEpisode, []PodcastEpisode) {
 	var totalBytes int64
 	var totalFiles int
+	var missing, skipped []PodcastEpisode
+	ps.planned = make(map[string]sourceSnapshot)
 
-	for _, episode := range episodes {
+	for i, episode := range episodes {
 		if !episode.Selected {
 			continue
 		}
-
-		showDir := filepath.Join(podcastDir, sanitizeName(episode.ShowName))
-		destPath := filepath.Join(showDir, formatEpisodeName(episode))
+		prep.examined()
 
 		// Only count files that don't already exist
-		if exists, _ := fileExists(destPath); !exists {
-			totalBytes += episode.FileSize
-			totalFiles++
+		if exists, _ := fileExists(filepath.Join(podcastDir, ps.profile.syncedPath(episode))); exists {
+			skipped = append(skipped, episode)
+			continue
+		}
+		if sourceMissing(episode) || episode.Downloading {
+			// A partial download would be copied as a truncated episode
+			episodes[i].Selected = false
+			missing = append(missing, episode)
+			continue
 		}
+		ps.snapshotSource(episode)
+		totalBytes += episode.FileSize
+		totalFiles++
 	}
 
-	return totalBytes, totalFiles
+	return totalBytes, totalFiles, missing, skipped
+}
+
+// snapshotSource records the episode's source as it is now, so a change before it is copied can be noticed
+func (ps *PodcastSync) snapshotSource(episode PodcastEpisode) {
+	filePath, err := convertFileURIToPath(episode.FilePath)
+	if err != nil {
+		return
+	}
+	if info, err := os.Stat(filePath); err == nil {
+		if ps.planned == nil {
+			ps.planned = make(map[string]sourceSnapshot)
+		}
+		ps.planned[episode.FilePath] = sourceSnapshot{size: info.Size(), modTime: info.ModTime()}
+	}
+}
+
+// replan checks the episode's source against its snapshot from when the sync was planned.
+// A source deleted or still being re-downloaded since is dropped from the transfer as missing;
+// one replaced by a finished download is copied as it is now, with the totals adjusted to its size.
+func (ps *PodcastSync) replan(episode PodcastEpisode, srcPath string) (PodcastEpisode, bool) {
+	ps.queueMu.Lock()
+	planned, ok := ps.planned[episode.FilePath]
+	ps.queueMu.Unlock()
+	if !ok {
+		return episode, true
+	}
+
+	info, err := os.Stat(srcPath)
+	if err != nil && !os.IsNotExist(err) {
+		// Left for the copy to report
+		return episode, true
+	}
+	if err == nil && info.Size() == planned.size && info.ModTime().Equal(planned.modTime) {
+		return episode, true
+	}
+	if err != nil || stillDownloading(planned.size, info) {
+		ps.tm.DropMissing(episode, episode.FileSize)
+		return episode, false
+	}
+	ps.tm.AddTotals(info.Size()-episode.FileSize, 0)
+	episode.FileSize = info.Size()
+	return episode, true
+}
+
+// sourceMissing reports whether the episode's local file no longer exists
+func sourceMissing(episode PodcastEpisode) bool {
+	filePath, err := convertFileURIToPath(episode.FilePath)
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(filePath)
+	return os.IsNotExist(err)
+}
+
+// finishTagging stops accepting tagging jobs and waits for the queued ones. Safe to call more than once.
+func (ps *PodcastSync) finishTagging() {
+	if !ps.taggingStopped {
+		close(ps.taggingQueue)
+		ps.taggingStopped = true
+	}
+	<-ps.taggingDone
 }
 
 // taggingWorker processes ID3 tagging jobs in the background
@@ -468,9 +1253,19 @@ func (ps *PodcastSync) taggingWorker() {
 	defer close(ps.taggingDone)
 
 	for job := range ps.taggingQueue {
-		// Best-effort tagging - don't fail if tagging fails
-		// The AddID3Tags function includes retry logic and cleanup of temp files
-		_ = AddID3Tags(job.filePath, job.episode)
+		ps.tag(job)
+	}
+}
+
+func (ps *PodcastSync) tag(job taggingJob) {
+	// Best-effort tagging - don't fail if tagging fails
+	// The AddID3Tags function includes retry logic and cleanup of temp files
+	_ = AddID3Tags(job.filePath, job.episode)
+	if ps.profile.Stable {
+		_ = keepSourceTime(job.srcPath, job.filePath)
+	}
+	if !job.laterPart {
+		_ = ExportCompanions(job.srcPath, job.filePath, job.episode, ps.profile)
 	}
 }
 
@@ -483,10 +1278,13 @@ func (ps *PodcastSync) cleanupAllID3TempFiles(episodes []PodcastEpisode, podcast
 			continue
 		}
 
-		showDir := filepath.Join(podcastDir, sanitizeName(episode.ShowName))
-		destPath := filepath.Join(showDir, formatEpisodeName(episode))
+		destPath := filepath.Join(podcastDir, ps.profile.EpisodePath(episode))
 
 		// Best-effort cleanup - ignore errors as this is a safety measure
 		_ = CleanupID3TempFiles(destPath)
+		parts := ps.profile.Split.Parts(episode)
+		for part := 1; parts > 1 && part <= parts; part++ {
+			_ = CleanupID3TempFiles(partPath(destPath, part, parts))
+		}
 	}
 }
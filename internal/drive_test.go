@@ -1,9 +1,14 @@
 package internal
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestUSBDrive_Methods(t *testing.T) {
@@ -24,6 +29,11 @@ func TestUSBDrive_Methods(t *testing.T) {
 	if drive.FilterValue() != "Test Drive" {
 		t.Errorf("Expected FilterValue() to return 'Test Drive', got %s", drive.FilterValue())
 	}
+
+	drive.Capacity, drive.Free = 32<<30, 12<<30
+	if want := "/Volumes/TestDrive · 12.0 GB free of 32.0 GB"; drive.Description() != want {
+		t.Errorf("Expected Description() to return %q, got %s", want, drive.Description())
+	}
 }
 
 func TestNewDriveManager(t *testing.T) {
@@ -106,6 +116,10 @@ func TestDriveManager_DetectDrives(t *testing.T) {
 		if drive.MountPath != expectedPath {
 			t.Errorf("Expected mount path to be %s, got %s", expectedPath, drive.MountPath)
 		}
+
+		if drive.Capacity <= 0 || drive.Free < 0 || drive.Free > drive.Capacity {
+			t.Errorf("Expected the volume's capacity and free space, got %d free of %d", drive.Free, drive.Capacity)
+		}
 	}
 }
 
@@ -256,6 +270,7 @@ func TestPodcastSync_DeleteSelected(t *testing.T) {
 		}
 
 		ps := NewPodcastSync()
+		ps.SetDrive(USBDrive{MountPath: tempDir})
 		result := ps.DeleteSelected(episodes)
 
 		if result.Error != nil {
@@ -313,6 +328,7 @@ func TestPodcastSync_DeleteSelected(t *testing.T) {
 		}
 
 		ps := NewPodcastSync()
+		ps.SetDrive(USBDrive{MountPath: tempDir})
 		result := ps.DeleteSelected(episodes)
 
 		if result.Error != nil {
@@ -375,6 +391,7 @@ func TestPodcastSync_DeleteSelected(t *testing.T) {
 		}
 
 		ps := NewPodcastSync()
+		ps.SetDrive(USBDrive{MountPath: tempDir})
 		result := ps.DeleteSelected(episodes)
 
 		if result.Error != nil {
@@ -453,7 +470,7 @@ func TestPodcastSync_StartSync_NoFilesNeeded(t *testing.T) {
 		ch := make(chan FileOp, 10)
 
 		ps := NewPodcastSync()
-		tm := ps.StartSync(episodes, drive, ch)
+		tm := ps.StartSync(episodes, drive, ChanSink(ch))
 
 		if tm == nil {
 			t.Fatal("Expected non-nil TransferManager")
@@ -480,3 +497,465 @@ func TestPodcastSync_StartSync_NoFilesNeeded(t *testing.T) {
 		}
 	})
 }
+
+func TestPodcastSync_StartSync_Concurrency(t *testing.T) {
+	tempDir := t.TempDir()
+	drive := USBDrive{Name: "DRIVE", MountPath: filepath.Join(tempDir, "drive"), Profile: DriveProfile{Concurrency: 4}}
+	var (
+		episodes []PodcastEpisode
+		total    int64
+	)
+	for i := range 12 {
+		title := fmt.Sprintf("Episode %02d", i)
+		content := bytes.Repeat([]byte{byte(i)}, 1000+i)
+		source := filepath.Join(tempDir, title+".mp3")
+		if err := os.WriteFile(source, content, 0o644); err != nil {
+			t.Fatalf("Failed to create source file: %v", err)
+		}
+		episodes = append(episodes, PodcastEpisode{ZTitle: title, ShowName: "Show", FilePath: "file://" + source, Selected: true})
+		total += int64(len(content))
+	}
+
+	ch := make(chan FileOp, 1000)
+	NewPodcastSync().StartSync(episodes, drive, ChanSink(ch))
+	var last FileOp
+	for op := range ch {
+		if op.Error != nil {
+			t.Fatalf("Sync failed: %v", op.Error)
+		}
+		last = op
+	}
+	if !last.Complete || last.Progress.FilesDone != len(episodes) || last.Progress.BytesTransferred != total {
+		t.Errorf("Expected all %d episodes (%d bytes) copied, got %+v", len(episodes), total, last.Progress)
+	}
+	for i, episode := range episodes {
+		// Tagging puts an ID3 tag in front of the audio
+		got, err := os.ReadFile(filepath.Join(drive.MountPath, drive.Profile.EpisodePath(episode)))
+		if err != nil || !bytes.HasSuffix(got, bytes.Repeat([]byte{byte(i)}, 1000+i)) {
+			t.Errorf("Expected %s copied intact, got %d bytes, %v", episode.ZTitle, len(got), err)
+		}
+	}
+}
+
+func TestPodcastSync_StartSync_NotEnoughSpace(t *testing.T) {
+	original := driveFreeSpace
+	driveFreeSpace = func(string) (int64, error) { return 1500, nil }
+	defer func() { driveFreeSpace = original }()
+
+	tempDir := t.TempDir()
+	drive := USBDrive{Name: "DRIVE", MountPath: filepath.Join(tempDir, "drive")}
+	var episodes []PodcastEpisode
+	for _, title := range []string{"First", "Second"} {
+		source := filepath.Join(tempDir, title+".mp3")
+		if err := os.WriteFile(source, make([]byte, 1000), 0o644); err != nil {
+			t.Fatalf("Failed to create source file: %v", err)
+		}
+		episodes = append(episodes, PodcastEpisode{ZTitle: title, ShowName: "Show", FilePath: "file://" + source, Selected: true})
+	}
+
+	ch := make(chan FileOp, 100)
+	if tm := NewPodcastSync().StartSync(episodes, drive, ChanSink(ch)); tm != nil {
+		t.Error("Expected no transfer for a sync that doesn't fit")
+	}
+	var failure error
+	for op := range ch {
+		if op.Error != nil {
+			failure = op.Error
+		}
+	}
+	if !errors.Is(failure, ErrNotEnoughSpace) || !strings.Contains(failure.Error(), "500 B more") {
+		t.Errorf("Expected ErrNotEnoughSpace with the shortfall, got %v", failure)
+	}
+	for _, episode := range episodes {
+		if _, err := os.Stat(filepath.Join(drive.MountPath, drive.Profile.EpisodePath(episode))); err == nil {
+			t.Errorf("Expected %s not copied", episode.ZTitle)
+		}
+	}
+}
+
+func TestPodcastSync_StartSync_LeavesOutDownloads(t *testing.T) {
+	tempDir := t.TempDir()
+	drive := USBDrive{Name: "DRIVE", MountPath: filepath.Join(tempDir, "drive")}
+	var episodes []PodcastEpisode
+	for _, title := range []string{"Downloaded", "Downloading"} {
+		source := filepath.Join(tempDir, title+".mp3")
+		if err := os.WriteFile(source, make([]byte, 2000), 0o644); err != nil {
+			t.Fatalf("Failed to create source file: %v", err)
+		}
+		episodes = append(episodes, PodcastEpisode{ZTitle: title, ShowName: "Show", FilePath: "file://" + source, Selected: true})
+	}
+	// The second file grew since the library was loaded
+	episodes[1].FileSize = 1000
+
+	ch := make(chan FileOp, 100)
+	NewPodcastSync().StartSync(episodes, drive, ChanSink(ch))
+	var ops []FileOp
+	for op := range ch {
+		if op.Error != nil {
+			t.Fatalf("Sync failed: %v", op.Error)
+		}
+		ops = append(ops, op)
+	}
+	missing := ops[0].Progress.Missing
+	if ops[0].Progress.TotalFiles != 1 || len(missing) != 1 || !missing[0].Downloading {
+		t.Errorf("Expected the download left out, got %d to copy and missing %+v", ops[0].Progress.TotalFiles, missing)
+	}
+	if _, err := os.Stat(filepath.Join(drive.MountPath, drive.Profile.EpisodePath(episodes[1]))); err == nil {
+		t.Error("Expected the partial download not copied")
+	}
+}
+
+func TestPodcastSync_StartSync_ReportsSkipped(t *testing.T) {
+	tempDir := t.TempDir()
+	drive := USBDrive{Name: "DRIVE", MountPath: filepath.Join(tempDir, "drive")}
+	var episodes []PodcastEpisode
+	for _, title := range []string{"Synced", "New"} {
+		source := filepath.Join(tempDir, title+".mp3")
+		if err := os.WriteFile(source, []byte(title), 0o644); err != nil {
+			t.Fatalf("Failed to create source file: %v", err)
+		}
+		episodes = append(episodes, PodcastEpisode{ZTitle: title, ShowName: "Show", FilePath: "file://" + source, Selected: true})
+	}
+	existing := filepath.Join(drive.MountPath, drive.Profile.EpisodePath(episodes[0]))
+	if err := os.MkdirAll(filepath.Dir(existing), 0o755); err != nil {
+		t.Fatalf("Failed to create show directory: %v", err)
+	}
+	if err := os.WriteFile(existing, []byte("Synced"), 0o644); err != nil {
+		t.Fatalf("Failed to create dest file: %v", err)
+	}
+
+	ch := make(chan FileOp, 100)
+	NewPodcastSync().StartSync(episodes, drive, ChanSink(ch))
+	var ops []FileOp
+	for op := range ch {
+		ops = append(ops, op)
+	}
+
+	first, last := ops[0].Progress, ops[len(ops)-1]
+	if first.TotalFiles != 1 || len(first.Skipped) != 1 || first.Skipped[0].ZTitle != "Synced" {
+		t.Errorf("Expected the first progress to count 1 to copy and 1 skipped, got %d and %v", first.TotalFiles, first.Skipped)
+	}
+	if !last.Complete || len(last.Progress.Skipped) != 1 {
+		t.Errorf("Expected the completed sync to still report the skipped episode, got %+v", last)
+	}
+}
+
+func TestPodcastSync_StartSync_PreventsSleep(t *testing.T) {
+	var held, released int
+	original := preventSleep
+	preventSleep = func() (func(), error) {
+		held++
+		return func() { released++ }, nil
+	}
+	t.Cleanup(func() { preventSleep = original })
+
+	runSync := func(t *testing.T, allowSleep bool) {
+		tempDir := t.TempDir()
+		source := filepath.Join(tempDir, "episode.mp3")
+		if err := os.WriteFile(source, make([]byte, 64), 0o644); err != nil {
+			t.Fatalf("Failed to create source file: %v", err)
+		}
+		episodes := []PodcastEpisode{{ZTitle: "Episode", ShowName: "Show", FilePath: "file://" + source, Selected: true, FileSize: 64}}
+
+		ps := NewPodcastSync()
+		ps.SetAllowSleep(allowSleep)
+		ch := make(chan FileOp, 10)
+		ps.StartSync(episodes, USBDrive{Name: "DRIVE", MountPath: filepath.Join(tempDir, "drive")}, ChanSink(ch))
+		for range ch {
+		}
+	}
+
+	runSync(t, false)
+	if held != 1 || released != 1 {
+		t.Errorf("Expected the assertion to be held for the sync and released after it, got %d held, %d released", held, released)
+	}
+
+	runSync(t, true)
+	if held != 1 {
+		t.Errorf("Expected no assertion when sleep is allowed, got %d", held)
+	}
+}
+
+func TestPodcastSync_Append(t *testing.T) {
+	tempDir := t.TempDir()
+
+	// An episode already on the drive is never appended
+	existing := PodcastEpisode{ZTitle: "Existing", ShowName: "Show", FilePath: "file:///src/existing.mp3", Selected: true, FileSize: 5}
+	destPath := filepath.Join(tempDir, sanitizeName(existing.ShowName), formatEpisodeName(existing))
+	if err := os.MkdirAll(filepath.Dir(destPath), 0o755); err != nil {
+		t.Fatalf("Failed to create show directory: %v", err)
+	}
+	if err := os.WriteFile(destPath, []byte("audio"), 0o644); err != nil {
+		t.Fatalf("Failed to create dest file: %v", err)
+	}
+
+	newSource := filepath.Join(tempDir, "new.mp3")
+	if err := os.WriteFile(newSource, make([]byte, 20), 0o644); err != nil {
+		t.Fatalf("Failed to create source file: %v", err)
+	}
+
+	running := PodcastEpisode{ZTitle: "Running", ShowName: "Show", FilePath: "file:///src/running.mp3", Selected: true, FileSize: 10}
+	ps := NewPodcastSync()
+	ps.tm = NewTransferManager(10, 1, ChanSink(make(chan FileOp, 10)))
+	defer ps.tm.Stop()
+	ps.queue = []PodcastEpisode{running}
+	ps.queued = map[string]bool{running.FilePath: true}
+	ps.podcastDir = tempDir
+	ps.running = true
+
+	added, err := ps.Append([]PodcastEpisode{
+		running,
+		existing,
+		{ZTitle: "New", ShowName: "Show", FilePath: "file://" + newSource, Selected: true, FileSize: 20},
+		{ZTitle: "Gone", ShowName: "Show", FilePath: "file:///src/gone.mp3", Selected: true, FileSize: 30},
+		{ZTitle: "Unselected", ShowName: "Show", FilePath: "file:///src/unselected.mp3", FileSize: 40},
+	})
+	if err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	if added != 1 {
+		t.Errorf("Expected 1 episode added, got %d", added)
+	}
+	if ps.tm.progress.TotalBytes != 30 || ps.tm.progress.TotalFiles != 2 {
+		t.Errorf("Expected totals to grow to 30 bytes / 2 files, got %d / %d", ps.tm.progress.TotalBytes, ps.tm.progress.TotalFiles)
+	}
+	if states, _ := ps.tm.FileStates(); states["file://"+newSource] != FileQueued {
+		t.Errorf("Expected appended episode to be queued, got %v", states)
+	}
+
+	for range 2 {
+		if _, ok := ps.nextEpisode(); !ok {
+			t.Fatal("Expected queued episode")
+		}
+	}
+	if _, ok := ps.nextEpisode(); ok {
+		t.Fatal("Expected queue to be drained")
+	}
+	if _, err := ps.Append([]PodcastEpisode{{ZTitle: "Late", FilePath: "file:///src/late.mp3", Selected: true}}); err != ErrSyncNotRunning {
+		t.Errorf("Expected ErrSyncNotRunning after the queue drained, got %v", err)
+	}
+}
+
+func TestPodcastSync_CopyEpisode_PartialFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	content := make([]byte, 600*1024)
+	for i := range content {
+		content[i] = byte(i % 251)
+	}
+	srcPath := filepath.Join(tempDir, "source.mp3")
+	if err := os.WriteFile(srcPath, content, 0o644); err != nil {
+		t.Fatalf("Failed to create source: %v", err)
+	}
+	episode := PodcastEpisode{ZTitle: "Episode", FileSize: int64(len(content))}
+
+	newSync := func() *PodcastSync {
+		ps := NewPodcastSync()
+		ps.tm = NewTransferManager(episode.FileSize, 1, ChanSink(make(chan FileOp, 10)))
+		t.Cleanup(ps.tm.Stop)
+		return ps
+	}
+
+	t.Run("cancel keeps partial file", func(t *testing.T) {
+		destPath := filepath.Join(tempDir, "keep", "episode.mp3")
+		_ = os.MkdirAll(filepath.Dir(destPath), 0o755)
+		ps := newSync()
+		ps.SetPartialPolicy(PartialKeep)
+		ps.tm.Stop()
+
+		if err := ps.copyEpisode(episode, srcPath, destPath); err != nil {
+			t.Fatalf("copyEpisode failed: %v", err)
+		}
+		if _, err := os.Stat(destPath + partialSuffix); err != nil {
+			t.Errorf("Expected partial file to be kept: %v", err)
+		}
+		info, err := ReadPartialInfo(destPath + partialSuffix)
+		if err != nil {
+			t.Fatalf("Expected a sidecar beside the partial file: %v", err)
+		}
+		if info.Source != srcPath || info.Size != int64(len(content)) || info.SHA256 == "" {
+			t.Errorf("Unexpected partial file info: %+v", info)
+		}
+		if exists, _ := fileExists(destPath); exists {
+			t.Error("Expected cancelled copy not to take the final name")
+		}
+	})
+
+	t.Run("cancel deletes partial file", func(t *testing.T) {
+		destPath := filepath.Join(tempDir, "delete", "episode.mp3")
+		_ = os.MkdirAll(filepath.Dir(destPath), 0o755)
+		ps := newSync()
+		ps.SetPartialPolicy(PartialDelete)
+		ps.tm.Stop()
+
+		if err := ps.copyEpisode(episode, srcPath, destPath); err != nil {
+			t.Fatalf("copyEpisode failed: %v", err)
+		}
+		if _, err := os.Stat(destPath + partialSuffix); !os.IsNotExist(err) {
+			t.Errorf("Expected partial file to be removed, got %v", err)
+		}
+	})
+
+	t.Run("next sync resumes kept partial file", func(t *testing.T) {
+		destPath := filepath.Join(tempDir, "resume", "episode.mp3")
+		_ = os.MkdirAll(filepath.Dir(destPath), 0o755)
+		if err := os.WriteFile(destPath+partialSuffix, content[:1000], 0o644); err != nil {
+			t.Fatalf("Failed to create partial file: %v", err)
+		}
+		ps := newSync()
+
+		if err := ps.copyEpisode(episode, srcPath, destPath); err != nil {
+			t.Fatalf("copyEpisode failed: %v", err)
+		}
+		got, err := os.ReadFile(destPath)
+		if err != nil {
+			t.Fatalf("Expected completed file: %v", err)
+		}
+		if string(got) != string(content) {
+			t.Error("Expected resumed copy to match the source")
+		}
+		if ps.tm.Snapshot().BytesTransferred != episode.FileSize {
+			t.Errorf("Expected resumed bytes to count toward progress, got %d", ps.tm.Snapshot().BytesTransferred)
+		}
+	})
+}
+
+func TestPodcastSync_SkipEpisode(t *testing.T) {
+	destPath := filepath.Join(t.TempDir(), "Show", "episode.mp3")
+	_ = os.MkdirAll(filepath.Dir(destPath), 0o755)
+	if err := os.WriteFile(destPath+partialSuffix, make([]byte, 1000), 0o644); err != nil {
+		t.Fatalf("Failed to create partial file: %v", err)
+	}
+	episode := PodcastEpisode{ZTitle: "Episode", FilePath: "file:///episode.mp3", FileSize: 4000}
+
+	ps := NewPodcastSync()
+	ps.tm = NewTransferManager(episode.FileSize+500, 2, ChanSink(make(chan FileOp, 10)))
+	defer ps.tm.Stop()
+	file := ps.tm.BeginFile(episode.ZTitle)
+	file.Advance(1000)
+
+	ps.skipEpisode(file, episode, destPath+partialSuffix)
+
+	if _, err := os.Stat(filepath.Dir(destPath)); !os.IsNotExist(err) {
+		t.Errorf("Expected the partial file and its empty show folder to be removed, got %v", err)
+	}
+	progress := ps.tm.Snapshot()
+	if progress.TotalBytes != 500 || progress.TotalFiles != 1 || progress.BytesTransferred != 0 {
+		t.Errorf("Expected the skipped episode out of the totals, got %+v", progress)
+	}
+	if states, _ := ps.tm.FileStates(); states[episode.FilePath] != FileFailed {
+		t.Errorf("Expected skipped episode to be marked failed, got %v", states[episode.FilePath])
+	}
+}
+
+func TestPodcastSync_CalculateActualTotals_DropsMissingSources(t *testing.T) {
+	tempDir := t.TempDir()
+	present := filepath.Join(tempDir, "present.mp3")
+	if err := os.WriteFile(present, make([]byte, 10), 0o644); err != nil {
+		t.Fatalf("Failed to create source file: %v", err)
+	}
+
+	episodes := []PodcastEpisode{
+		{ZTitle: "Present", ShowName: "Show", FilePath: "file://" + present, Selected: true, FileSize: 10},
+		{ZTitle: "Gone", ShowName: "Show", FilePath: "file://" + filepath.Join(tempDir, "gone.mp3"), Selected: true, FileSize: 99},
+	}
+
+	ps := NewPodcastSync()
+	totalBytes, totalFiles, missing, _ := ps.calculateActualTotals(episodes, filepath.Join(tempDir, "drive"), nil)
+	if totalBytes != 10 || totalFiles != 1 {
+		t.Errorf("Expected only the present file in totals, got %d bytes / %d files", totalBytes, totalFiles)
+	}
+	if len(missing) != 1 || missing[0].ZTitle != "Gone" {
+		t.Errorf("Expected the deleted episode to be reported missing, got %v", missing)
+	}
+	if episodes[1].Selected {
+		t.Error("Expected the missing episode to be deselected so the sync skips it")
+	}
+}
+
+func TestPodcastSync_Replan(t *testing.T) {
+	tempDir := t.TempDir()
+	write := func(name string, size int, modTime time.Time) (PodcastEpisode, string) {
+		source := filepath.Join(tempDir, name+".mp3")
+		if err := os.WriteFile(source, make([]byte, size), 0o644); err != nil {
+			t.Fatalf("Failed to create source file: %v", err)
+		}
+		if err := os.Chtimes(source, modTime, modTime); err != nil {
+			t.Fatalf("Failed to set source time: %v", err)
+		}
+		return PodcastEpisode{ZTitle: name, ShowName: "Show", FilePath: "file://" + source, Selected: true, FileSize: int64(size)}, source
+	}
+	old := time.Now().Add(-time.Hour)
+	unchanged, unchangedPath := write("Unchanged", 100, old)
+	replaced, replacedPath := write("Replaced", 100, old)
+	deleted, deletedPath := write("Deleted", 100, old)
+	redownloading, redownloadingPath := write("Redownloading", 100, old)
+
+	ps := NewPodcastSync()
+	ps.tm = NewTransferManager(400, 4, ChanSink(make(chan FileOp, 10)))
+	defer ps.tm.Stop()
+	for _, episode := range []PodcastEpisode{unchanged, replaced, deleted, redownloading} {
+		ps.snapshotSource(episode)
+	}
+
+	// Podcasts.app finished re-downloading one, deleted one and is still writing another
+	write("Replaced", 150, time.Now().Add(-time.Minute))
+	if err := os.Remove(deletedPath); err != nil {
+		t.Fatalf("Failed to delete source: %v", err)
+	}
+	write("Redownloading", 40, time.Now())
+
+	if episode, ok := ps.replan(unchanged, unchangedPath); !ok || episode.FileSize != 100 {
+		t.Errorf("Expected the unchanged source copied as planned, got %v with %d bytes", ok, episode.FileSize)
+	}
+	if episode, ok := ps.replan(replaced, replacedPath); !ok || episode.FileSize != 150 {
+		t.Errorf("Expected the replaced source copied at its new size, got %v with %d bytes", ok, episode.FileSize)
+	}
+	if _, ok := ps.replan(deleted, deletedPath); ok {
+		t.Error("Expected the deleted source dropped")
+	}
+	if _, ok := ps.replan(redownloading, redownloadingPath); ok {
+		t.Error("Expected the source being re-downloaded dropped")
+	}
+
+	progress := ps.tm.Snapshot()
+	if progress.TotalBytes != 250 || progress.TotalFiles != 2 {
+		t.Errorf("Expected totals re-planned to 250 bytes / 2 files, got %d / %d", progress.TotalBytes, progress.TotalFiles)
+	}
+	if len(progress.Missing) != 2 {
+		t.Errorf("Expected the dropped episodes reported missing, got %+v", progress.Missing)
+	}
+	states, _ := ps.tm.FileStates()
+	if states[deleted.FilePath] != FileMissing || states[redownloading.FilePath] != FileMissing {
+		t.Errorf("Expected the dropped episodes marked missing, got %v", states)
+	}
+}
+
+func TestDriveManager_DetectDrives_LocalFolders(t *testing.T) {
+	volumes := t.TempDir()
+	synced := t.TempDir()
+	cfg := &Config{}
+	name, err := cfg.AddLocalFolder(synced)
+	if err != nil {
+		t.Fatalf("AddLocalFolder() failed: %v", err)
+	}
+	if again, _ := cfg.AddLocalFolder(synced); again != name {
+		t.Errorf("Expected the same folder to keep its profile, got %q and %q", name, again)
+	}
+	cfg.Drives["Gone"] = DriveProfile{LocalFolder: filepath.Join(synced, "missing")}
+	if _, err := cfg.AddLocalFolder("relative/folder"); err == nil {
+		t.Error("Expected a relative folder to be refused")
+	}
+
+	dm := NewDriveManager(volumes, DirectoryTemplate{})
+	dm.SetProfiles(cfg.Drives)
+	drives, err := dm.DetectDrives()
+	if err != nil {
+		t.Fatalf("DetectDrives() failed: %v", err)
+	}
+	if len(drives) != 1 || drives[0].Name != name || drives[0].MountPath != synced || drives[0].Folder != "podcasts" {
+		t.Fatalf("Expected only the existing folder as a drive, got %+v", drives)
+	}
+	if _, err := CheckDriveHealth(drives[0]); err == nil {
+		t.Error("Expected no health check for a local folder")
+	}
+}
@@ -0,0 +1,322 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bogem/id3v2/v2"
+)
+
+// durationWorkers bounds concurrent header reads, which are slow on USB drives
+const durationWorkers = 4
+
+// mp3ScanLimit is how far past the ID3 tag to look for the first MPEG frame
+const mp3ScanLimit = 64 * 1024
+
+var errNoAudioFrame = errors.New("no MPEG audio frame found")
+
+// Bitrates in kbps indexed by [MPEG-1 / MPEG-2(.5)][layer III, II, I][bitrate index]
+var mpegBitrates = [2][3][16]int{
+	{
+		{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 0},
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384, 0},
+		{0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448, 0},
+	},
+	{
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0},
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256, 0},
+	},
+}
+
+// Sample rates indexed by MPEG version bits (2.5, reserved, 2, 1) and sample rate index
+var mpegSampleRates = [4][3]int{
+	{11025, 12000, 8000},
+	{0, 0, 0},
+	{22050, 24000, 16000},
+	{44100, 48000, 32000},
+}
+
+// ExtractDurations fills in the duration of episodes that have none by reading their audio headers.
+// Files that can't be read keep a zero duration.
+func ExtractDurations(episodes []PodcastEpisode) {
+	jobs := make(chan *PodcastEpisode)
+	var wg sync.WaitGroup
+
+	for range durationWorkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for episode := range jobs {
+				path := episode.FilePath
+				if strings.HasPrefix(path, "file://") {
+					var err error
+					if path, err = convertFileURIToPath(path); err != nil {
+						continue
+					}
+				}
+				if d, err := ReadDuration(path); err == nil {
+					episode.Duration = d
+				}
+			}
+		}()
+	}
+
+	for i := range episodes {
+		if episodes[i].Duration == 0 {
+			jobs <- &episodes[i]
+		}
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// ReadDuration returns the playing time of an MP3 or MP4/M4A file.
+// Other formats return a zero duration.
+func ReadDuration(path string) (time.Duration, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".mp3":
+		return mp3Duration(path)
+	case ".m4a", ".m4b", ".mp4":
+		return mp4Duration(path)
+	default:
+		return 0, nil
+	}
+}
+
+// mp3Duration prefers the ID3 TLEN frame, then a Xing/Info or VBRI frame count,
+// and finally estimates from the first frame's bitrate assuming constant bitrate
+func mp3Duration(path string) (time.Duration, error) {
+	if tag, err := id3v2.Open(path, id3v2.Options{Parse: true, ParseFrames: []string{"TLEN"}}); err == nil {
+		ms, convErr := strconv.ParseInt(strings.TrimSpace(tag.GetTextFrame("TLEN").Text), 10, 64)
+		tag.Close()
+		if convErr == nil && ms > 0 {
+			return time.Duration(ms) * time.Millisecond, nil
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	audioStart, err := id3v2Size(f)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := f.Seek(audioStart, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	buf := make([]byte, mp3ScanLimit)
+	n, err := io.ReadFull(f, buf)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return 0, err
+	}
+	buf = buf[:n]
+
+	for i := 0; i+4 <= len(buf); i++ {
+		if buf[i] != 0xFF || buf[i+1]&0xE0 != 0xE0 {
+			continue
+		}
+		header, ok := parseMPEGHeader(buf[i : i+4])
+		if !ok {
+			continue
+		}
+
+		if frames := vbrFrameCount(buf[i:], header); frames > 0 {
+			seconds := float64(frames) * float64(header.samplesPerFrame) / float64(header.sampleRate)
+			return time.Duration(seconds * float64(time.Second)), nil
+		}
+
+		audioBytes := info.Size() - audioStart - int64(i)
+		seconds := float64(audioBytes*8) / float64(header.bitrate*1000)
+		return time.Duration(seconds * float64(time.Second)), nil
+	}
+
+	return 0, errNoAudioFrame
+}
+
+type mpegHeader struct {
+	mpeg1           bool
+	mono            bool
+	bitrate         int // kbps
+	sampleRate      int
+	samplesPerFrame int
+}
+
+func parseMPEGHeader(b []byte) (mpegHeader, bool) {
+	versionBits := (b[1] >> 3) & 0x03
+	layerBits := (b[1] >> 1) & 0x03
+	bitrateIndex := b[2] >> 4
+	sampleRateIndex := (b[2] >> 2) & 0x03
+
+	if versionBits == 1 || layerBits == 0 || bitrateIndex == 0 || bitrateIndex == 15 || sampleRateIndex == 3 {
+		return mpegHeader{}, false
+	}
+
+	h := mpegHeader{
+		mpeg1:      versionBits == 3,
+		mono:       b[3]>>6 == 3,
+		sampleRate: mpegSampleRates[versionBits][sampleRateIndex],
+	}
+
+	versionIndex := 1
+	if h.mpeg1 {
+		versionIndex = 0
+	}
+	// Layer bits: 1 = III, 2 = II, 3 = I
+	h.bitrate = mpegBitrates[versionIndex][layerBits-1][bitrateIndex]
+
+	switch {
+	case layerBits == 3:
+		h.samplesPerFrame = 384
+	case layerBits == 1 && !h.mpeg1:
+		h.samplesPerFrame = 576
+	default:
+		h.samplesPerFrame = 1152
+	}
+
+	return h, true
+}
+
+// vbrFrameCount reads the total frame count from a Xing/Info or VBRI header in the first frame
+func vbrFrameCount(frame []byte, h mpegHeader) int64 {
+	sideInfo := 32
+	switch {
+	case h.mpeg1 && h.mono:
+		sideInfo = 17
+	case !h.mpeg1 && h.mono:
+		sideInfo = 9
+	case !h.mpeg1:
+		sideInfo = 17
+	}
+
+	xing := 4 + sideInfo
+	if len(frame) >= xing+12 {
+		id := frame[xing : xing+4]
+		if bytes.Equal(id, []byte("Xing")) || bytes.Equal(id, []byte("Info")) {
+			// Frame count is present when bit 0 of the flags is set
+			if binary.BigEndian.Uint32(frame[xing+4:xing+8])&0x1 != 0 {
+				return int64(binary.BigEndian.Uint32(frame[xing+8 : xing+12]))
+			}
+		}
+	}
+
+	const vbri = 4 + 32
+	if len(frame) >= vbri+18 && bytes.Equal(frame[vbri:vbri+4], []byte("VBRI")) {
+		return int64(binary.BigEndian.Uint32(frame[vbri+14 : vbri+18]))
+	}
+
+	return 0
+}
+
+// id3v2Size returns the number of bytes taken by a leading ID3v2 tag, or 0 if there is none
+func id3v2Size(r io.ReadSeeker) (int64, error) {
+	header := make([]byte, 10)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return 0, err
+	}
+	if !bytes.Equal(header[:3], []byte("ID3")) {
+		return 0, nil
+	}
+
+	size := int64(header[6])<<21 | int64(header[7])<<14 | int64(header[8])<<7 | int64(header[9])
+	size += 10
+	if header[5]&0x10 != 0 {
+		// Footer present
+		size += 10
+	}
+	return size, nil
+}
+
+// mp4Duration reads the duration from the movie header (moov/mvhd) atom
+func mp4Duration(path string) (time.Duration, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	moov, moovSize, err := findAtom(f, 0, info.Size(), "moov")
+	if err != nil {
+		return 0, err
+	}
+	mvhd, _, err := findAtom(f, moov, moov+moovSize, "mvhd")
+	if err != nil {
+		return 0, err
+	}
+
+	header := make([]byte, 32)
+	if _, err := f.ReadAt(header, mvhd); err != nil {
+		return 0, fmt.Errorf("failed to read mvhd: %w", err)
+	}
+
+	var timescale uint32
+	var duration uint64
+	if header[0] == 1 {
+		timescale = binary.BigEndian.Uint32(header[20:24])
+		duration = binary.BigEndian.Uint64(header[24:32])
+	} else {
+		timescale = binary.BigEndian.Uint32(header[12:16])
+		duration = uint64(binary.BigEndian.Uint32(header[16:20]))
+	}
+	if timescale == 0 {
+		return 0, errors.New("invalid mvhd timescale")
+	}
+
+	return time.Duration(float64(duration) / float64(timescale) * float64(time.Second)), nil
+}
+
+// findAtom searches the atoms between start and end for name and returns the offset and size of its payload
+func findAtom(r io.ReaderAt, start, end int64, name string) (int64, int64, error) {
+	header := make([]byte, 16)
+	for offset := start; offset+8 <= end; {
+		if _, err := r.ReadAt(header[:8], offset); err != nil {
+			return 0, 0, err
+		}
+
+		size := int64(binary.BigEndian.Uint32(header[:4]))
+		headerSize := int64(8)
+		switch size {
+		case 0:
+			// Atom extends to the end of its parent
+			size = end - offset
+		case 1:
+			if _, err := r.ReadAt(header[8:16], offset+8); err != nil {
+				return 0, 0, err
+			}
+			size = int64(binary.BigEndian.Uint64(header[8:16]))
+			headerSize = 16
+		}
+		if size < headerSize {
+			return 0, 0, fmt.Errorf("invalid %q atom size", header[4:8])
+		}
+
+		if string(header[4:8]) == name {
+			return offset + headerSize, size - headerSize, nil
+		}
+		offset += size
+	}
+	return 0, 0, fmt.Errorf("%s atom not found", name)
+}
@@ -0,0 +1,107 @@
+package internal
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeCBRMP3 writes an untagged stream of 128 kbps, 44.1 kHz MPEG-1 Layer III frames
+func writeCBRMP3(t *testing.T, path string, frames int) {
+	t.Helper()
+	const frameSize = 417 // 144 * 128000 / 44100
+	frame := make([]byte, frameSize)
+	copy(frame, []byte{0xFF, 0xFB, 0x90, 0x00})
+
+	var data []byte
+	for range frames {
+		data = append(data, frame...)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("Failed to write mp3: %v", err)
+	}
+}
+
+// writeM4A writes a minimal ftyp + moov/mvhd structure
+func writeM4A(t *testing.T, path string, timescale, duration uint32) {
+	t.Helper()
+	atom := func(name string, payload []byte) []byte {
+		b := make([]byte, 8, 8+len(payload))
+		binary.BigEndian.PutUint32(b, uint32(8+len(payload)))
+		copy(b[4:], name)
+		return append(b, payload...)
+	}
+
+	mvhd := make([]byte, 100)
+	binary.BigEndian.PutUint32(mvhd[12:], timescale)
+	binary.BigEndian.PutUint32(mvhd[16:], duration)
+
+	data := atom("ftyp", []byte("M4A \x00\x00\x00\x00"))
+	data = append(data, atom("moov", atom("mvhd", mvhd))...)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("Failed to write m4a: %v", err)
+	}
+}
+
+func TestReadDuration(t *testing.T) {
+	tempDir := t.TempDir()
+
+	mp3 := filepath.Join(tempDir, "cbr.mp3")
+	writeCBRMP3(t, mp3, 1000) // 1000 * 1152 / 44100 ≈ 26.1s
+	got, err := ReadDuration(mp3)
+	if err != nil {
+		t.Fatalf("ReadDuration(mp3) failed: %v", err)
+	}
+	if got < 26*time.Second || got > 27*time.Second {
+		t.Errorf("Expected ~26s for the mp3, got %v", got)
+	}
+
+	m4a := filepath.Join(tempDir, "episode.m4a")
+	writeM4A(t, m4a, 1000, 90*60*1000)
+	got, err = ReadDuration(m4a)
+	if err != nil {
+		t.Fatalf("ReadDuration(m4a) failed: %v", err)
+	}
+	if got != 90*time.Minute {
+		t.Errorf("Expected 90m for the m4a, got %v", got)
+	}
+
+	if got, err := ReadDuration(filepath.Join(tempDir, "episode.ogg")); got != 0 || err != nil {
+		t.Errorf("Expected unsupported formats to report no duration, got %v, %v", got, err)
+	}
+}
+
+func TestExtractDurations(t *testing.T) {
+	tempDir := t.TempDir()
+	m4a := filepath.Join(tempDir, "episode.m4a")
+	writeM4A(t, m4a, 1, 600)
+
+	episodes := []PodcastEpisode{
+		{FilePath: m4a},
+		{FilePath: "file://" + m4a},
+		{FilePath: m4a, Duration: time.Minute},
+		{FilePath: filepath.Join(tempDir, "missing.mp3")},
+	}
+	ExtractDurations(episodes)
+
+	if episodes[0].Duration != 10*time.Minute || episodes[1].Duration != 10*time.Minute {
+		t.Errorf("Expected durations to be read from the file, got %v and %v", episodes[0].Duration, episodes[1].Duration)
+	}
+	if episodes[2].Duration != time.Minute {
+		t.Errorf("Expected known duration to be kept, got %v", episodes[2].Duration)
+	}
+	if episodes[3].Duration != 0 {
+		t.Errorf("Expected unreadable file to keep zero duration, got %v", episodes[3].Duration)
+	}
+}
+
+func TestFormatTotalDuration(t *testing.T) {
+	if got := FormatTotalDuration(12*time.Hour + 34*time.Minute + 59*time.Second); got != "12 h 34 m" {
+		t.Errorf("Expected \"12 h 34 m\", got %q", got)
+	}
+	if got := FormatTotalDuration(45 * time.Minute); got != "45 m" {
+		t.Errorf("Expected \"45 m\", got %q", got)
+	}
+}
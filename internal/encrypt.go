@@ -0,0 +1,536 @@
+package internal
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// DefaultEncryptedFolder is the encrypted folder on drives whose profile doesn't name one
+const DefaultEncryptedFolder = "podcasts.encrypted"
+
+// PassphraseEnv holds the passphrase of encrypted folders when the profile has no passphrase file
+const PassphraseEnv = "PODCASTS_SYNC_PASSPHRASE"
+
+// ErrWrongPassphrase is returned when a passphrase doesn't open an encrypted folder
+var ErrWrongPassphrase = errors.New("wrong passphrase for the encrypted folder")
+
+// EncryptSettings keep a drive's episodes in an encrypted folder on it, for drives that are shared or easily lost.
+// The drive is synced through a local mirror, like a WebDAV share, and only the mirror is readable.
+type EncryptSettings struct {
+	// Folder is the encrypted folder on the drive; the default is DefaultEncryptedFolder
+	Folder string `json:"folder,omitempty"`
+	// PassphraseFile holds the passphrase; without one it is read from PassphraseEnv
+	PassphraseFile string `json:"passphraseFile,omitempty"`
+}
+
+func (e *EncryptSettings) validate() error {
+	if e == nil {
+		return nil
+	}
+	if clean := path.Clean(filepath.ToSlash(e.Folder)); e.Folder != "" && (path.IsAbs(clean) || clean == "." || clean == ".." || strings.HasPrefix(clean, "../")) {
+		return fmt.Errorf("invalid encrypt folder %q: must be a folder on the drive", e.Folder)
+	}
+	return nil
+}
+
+func (e EncryptSettings) folder() string {
+	if e.Folder == "" {
+		return DefaultEncryptedFolder
+	}
+	return filepath.FromSlash(path.Clean(filepath.ToSlash(e.Folder)))
+}
+
+// Passphrase reads the passphrase from the passphrase file, or from PassphraseEnv without one
+func (e EncryptSettings) Passphrase() (string, error) {
+	if e.PassphraseFile == "" {
+		if passphrase := os.Getenv(PassphraseEnv); passphrase != "" {
+			return passphrase, nil
+		}
+		return "", fmt.Errorf("no passphrase for the encrypted folder: set passphraseFile or %s", PassphraseEnv)
+	}
+	data, err := os.ReadFile(e.PassphraseFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase: %w", err)
+	}
+	passphrase := strings.TrimRight(string(data), "\r\n")
+	if passphrase == "" {
+		return "", fmt.Errorf("passphrase file %s is empty", e.PassphraseFile)
+	}
+	return passphrase, nil
+}
+
+// EncryptedFolder returns the encrypted folder on the drive, or "" for drives that aren't encrypted
+func (d USBDrive) EncryptedFolder() string {
+	if d.Volume == "" || d.Profile.Encrypt == nil {
+		return ""
+	}
+	return filepath.Join(d.Volume, d.Profile.Encrypt.folder())
+}
+
+// encryptedDrive moves a mounted drive whose profile encrypts it to a mirror named after it.
+// It returns false when there's no mirror, so the drive is never written in the clear.
+func (dm *DriveManager) encryptedDrive(drive USBDrive) (USBDrive, bool) {
+	if dm.mirrors == "" {
+		return drive, false
+	}
+	mirror := filepath.Join(dm.mirrors, "encrypted", sanitizeName(drive.Name))
+	if err := os.MkdirAll(mirror, 0o755); err != nil {
+		return drive, false
+	}
+	drive.Volume = drive.MountPath
+	drive.MountPath = mirror
+	drive.Folder = ""
+	return drive, true
+}
+
+// containerFile is the key file of an encrypted folder, holding the salt and a check of the passphrase
+const containerFile = ".podcasts-sync-container.json"
+
+// encryptIterations is the PBKDF2 work for new encrypted folders; tests lower it
+var encryptIterations = 600_000
+
+type containerParams struct {
+	Version    int    `json:"version"`
+	Salt       []byte `json:"salt"`
+	Iterations int    `json:"iterations"`
+	Check      []byte `json:"check"`
+}
+
+// containerKeys are derived from the passphrase: names are encrypted with nameEnc and
+// authenticated with nameMAC, and each file's key comes from file and the file's salt
+type containerKeys struct {
+	nameEnc, nameMAC, file []byte
+}
+
+// openContainer unlocks the encrypted folder at dir, creating its key file when create is set and it has none
+func openContainer(dir, passphrase string, create bool) (*containerKeys, error) {
+	var params containerParams
+	data, err := os.ReadFile(filepath.Join(dir, containerFile))
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &params); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", containerFile, err)
+		}
+		if params.Version != 1 {
+			return nil, fmt.Errorf("unsupported encrypted folder version %d", params.Version)
+		}
+	case errors.Is(err, os.ErrNotExist) && create:
+		params = containerParams{Version: 1, Salt: make([]byte, 16), Iterations: encryptIterations}
+		rand.Read(params.Salt)
+	default:
+		return nil, fmt.Errorf("failed to open the encrypted folder: %w", err)
+	}
+
+	master, err := pbkdf2.Key(sha256.New, passphrase, params.Salt, params.Iterations, 32)
+	if err != nil {
+		return nil, err
+	}
+	mac := hmac.New(sha256.New, master)
+	mac.Write([]byte("podcasts-sync passphrase check"))
+	check := mac.Sum(nil)
+	if params.Check == nil {
+		params.Check = check
+		data, err := json.MarshalIndent(params, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create the encrypted folder: %w", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, containerFile), data, 0o644); err != nil {
+			return nil, fmt.Errorf("failed to create the encrypted folder: %w", err)
+		}
+	} else if !hmac.Equal(check, params.Check) {
+		return nil, ErrWrongPassphrase
+	}
+
+	keys := &containerKeys{}
+	for _, k := range []struct {
+		key  *[]byte
+		info string
+	}{{&keys.nameEnc, "names"}, {&keys.nameMAC, "name mac"}, {&keys.file, "files"}} {
+		if *k.key, err = hkdf.Key(sha256.New, master, nil, "podcasts-sync "+k.info, 32); err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
+
+// nameEncoding is case-insensitive, as FAT and exFAT drives are
+var nameEncoding = base32.HexEncoding.WithPadding(base32.NoPadding)
+
+// encryptName encrypts one path element deterministically, so the same name always encrypts
+// the same way: its MAC is both the IV and the check that it decrypted
+func (k *containerKeys) encryptName(name string) string {
+	mac := hmac.New(sha256.New, k.nameMAC)
+	mac.Write([]byte(name))
+	out := mac.Sum(nil)[:aes.BlockSize]
+	block, _ := aes.NewCipher(k.nameEnc)
+	out = append(out, name...)
+	cipher.NewCTR(block, out[:aes.BlockSize]).XORKeyStream(out[aes.BlockSize:], out[aes.BlockSize:])
+	return strings.ToLower(nameEncoding.EncodeToString(out))
+}
+
+func (k *containerKeys) decryptName(encrypted string) (string, bool) {
+	data, err := nameEncoding.DecodeString(strings.ToUpper(encrypted))
+	if err != nil || len(data) < aes.BlockSize {
+		return "", false
+	}
+	block, _ := aes.NewCipher(k.nameEnc)
+	name := make([]byte, len(data)-aes.BlockSize)
+	cipher.NewCTR(block, data[:aes.BlockSize]).XORKeyStream(name, data[aes.BlockSize:])
+	mac := hmac.New(sha256.New, k.nameMAC)
+	mac.Write(name)
+	if !hmac.Equal(mac.Sum(nil)[:aes.BlockSize], data[:aes.BlockSize]) {
+		return "", false
+	}
+	return string(name), true
+}
+
+func (k *containerKeys) encryptPath(rel string) (string, error) {
+	parts := strings.Split(rel, "/")
+	for i, part := range parts {
+		if parts[i] = k.encryptName(part); len(parts[i]) > maxNameLength {
+			return "", fmt.Errorf("name %q is too long for an encrypted folder", part)
+		}
+	}
+	return strings.Join(parts, "/"), nil
+}
+
+func (k *containerKeys) decryptPath(rel string) (string, bool) {
+	parts := strings.Split(rel, "/")
+	for i, part := range parts {
+		var ok bool
+		if parts[i], ok = k.decryptName(part); !ok {
+			return "", false
+		}
+	}
+	return strings.Join(parts, "/"), true
+}
+
+// Encrypted files are a magic number and a salt for the file's key, then the contents in
+// chunks sealed with AES-GCM. Each chunk's nonce is its number with the last chunk flagged,
+// so chunks can't be reordered, dropped or cut off without failing to open.
+const (
+	encryptedMagic  = "PSYNCENC"
+	fileSaltSize    = 16
+	encryptedHeader = len(encryptedMagic) + fileSaltSize
+	chunkSize       = 64 << 10
+	chunkOverhead   = 16
+)
+
+// encryptedSize returns the size of a file of size bytes once encrypted
+func encryptedSize(size int64) int64 {
+	chunks := max((size+chunkSize-1)/chunkSize, 1)
+	return int64(encryptedHeader) + size + chunks*chunkOverhead
+}
+
+// plainSize returns the size an encrypted file of size bytes decrypts to
+func plainSize(size int64) (int64, bool) {
+	size -= int64(encryptedHeader)
+	if size < chunkOverhead {
+		return 0, false
+	}
+	chunks := (size + chunkSize + chunkOverhead - 1) / (chunkSize + chunkOverhead)
+	return size - chunks*chunkOverhead, true
+}
+
+func (k *containerKeys) fileAEAD(salt []byte) (cipher.AEAD, error) {
+	key, err := hkdf.Key(sha256.New, k.file, salt, "podcasts-sync file", 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func chunkNonce(n uint64, last bool) []byte {
+	nonce := make([]byte, 12)
+	binary.BigEndian.PutUint64(nonce[3:11], n)
+	if last {
+		nonce[11] = 1
+	}
+	return nonce
+}
+
+// encryptReader reads size bytes of r encrypted
+type encryptReader struct {
+	r         io.Reader
+	aead      cipher.AEAD
+	remaining int64
+	chunk     uint64
+	buf, out  []byte
+	done      bool
+}
+
+func (k *containerKeys) encryptReader(r io.Reader, size int64) (*encryptReader, error) {
+	salt := make([]byte, fileSaltSize)
+	rand.Read(salt)
+	aead, err := k.fileAEAD(salt)
+	if err != nil {
+		return nil, err
+	}
+	out := append([]byte(encryptedMagic), salt...)
+	return &encryptReader{r: r, aead: aead, remaining: size, buf: make([]byte, chunkSize), out: out}, nil
+}
+
+func (e *encryptReader) Read(p []byte) (int, error) {
+	if len(e.out) == 0 {
+		if e.done {
+			return 0, io.EOF
+		}
+		n := min(e.remaining, chunkSize)
+		if _, err := io.ReadFull(e.r, e.buf[:n]); err != nil {
+			if errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+			return 0, err
+		}
+		e.remaining -= n
+		e.done = e.remaining == 0
+		e.out = e.aead.Seal(e.out[:0], chunkNonce(e.chunk, e.done), e.buf[:n], nil)
+		e.chunk++
+	}
+	n := copy(p, e.out)
+	e.out = e.out[n:]
+	return n, nil
+}
+
+// decrypt writes the contents of the encrypted file read from r to w
+func (k *containerKeys) decrypt(w io.Writer, r io.Reader) error {
+	header := make([]byte, encryptedHeader)
+	if _, err := io.ReadFull(r, header); err != nil || string(header[:len(encryptedMagic)]) != encryptedMagic {
+		return errors.New("not an encrypted podcasts-sync file")
+	}
+	aead, err := k.fileAEAD(header[len(encryptedMagic):])
+	if err != nil {
+		return err
+	}
+	br := bufio.NewReaderSize(r, chunkSize+chunkOverhead)
+	buf := make([]byte, chunkSize+chunkOverhead)
+	var plain []byte
+	for chunk := uint64(0); ; chunk++ {
+		n, err := io.ReadFull(br, buf)
+		if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+			return err
+		}
+		_, peekErr := br.Peek(1)
+		last := errors.Is(peekErr, io.EOF)
+		if plain, err = aead.Open(plain[:0], chunkNonce(chunk, last), buf[:n], nil); err != nil {
+			return errors.New("the file is damaged")
+		}
+		if _, err := w.Write(plain); err != nil {
+			return err
+		}
+		if last {
+			return nil
+		}
+	}
+}
+
+// folderDestination is a folder on a mounted drive, written as a destination so it can be wrapped
+type folderDestination struct {
+	dir string
+}
+
+func (f folderDestination) String() string { return f.dir }
+
+// Tuning writes one file at a time, which is fastest on USB sticks
+func (f folderDestination) Tuning() PushTuning { return PushTuning{Workers: 1, Attempts: 1} }
+
+func (f folderDestination) List() (map[string]int64, error) {
+	files, err := mirrorFiles(f.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]int64{}, nil
+	}
+	return files, err
+}
+
+func (f folderDestination) Put(rel string, r io.Reader, size int64) error {
+	dest := filepath.Join(f.dir, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return err
+	}
+	tmp := dest + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	n, err := io.Copy(file, r)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && n != size {
+		err = fmt.Errorf("wrote %d of %d bytes", n, size)
+	}
+	if err == nil {
+		err = os.Rename(tmp, dest)
+	}
+	if err != nil {
+		os.Remove(tmp)
+	}
+	return err
+}
+
+func (f folderDestination) Remove(rels []string) error {
+	var errs []error
+	for _, rel := range rels {
+		file := filepath.Join(f.dir, filepath.FromSlash(rel))
+		if err := os.Remove(file); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, err)
+			continue
+		}
+		// Removing a folder fails while it has anything left in it
+		for dir := filepath.Dir(file); dir != f.dir; dir = filepath.Dir(dir) {
+			if os.Remove(dir) != nil {
+				break
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// encryptedDestination wraps a destination, encrypting the names and contents of what is put in it.
+// Files it can't decrypt the names of, such as those written with another passphrase, are left alone.
+type encryptedDestination struct {
+	inner  Destination
+	unlock func() (*containerKeys, error)
+}
+
+func newEncryptedDestination(inner Destination, dir string, settings EncryptSettings) *encryptedDestination {
+	return &encryptedDestination{
+		inner: inner,
+		unlock: sync.OnceValues(func() (*containerKeys, error) {
+			passphrase, err := settings.Passphrase()
+			if err != nil {
+				return nil, err
+			}
+			return openContainer(dir, passphrase, true)
+		}),
+	}
+}
+
+func (e *encryptedDestination) String() string { return "encrypted " + e.inner.String() }
+
+func (e *encryptedDestination) Tuning() PushTuning { return e.inner.Tuning() }
+
+func (e *encryptedDestination) List() (map[string]int64, error) {
+	keys, err := e.unlock()
+	if err != nil {
+		return nil, err
+	}
+	files, err := e.inner.List()
+	if err != nil {
+		return nil, err
+	}
+	plain := make(map[string]int64, len(files))
+	for rel, size := range files {
+		name, ok := keys.decryptPath(rel)
+		if n, valid := plainSize(size); ok && valid {
+			plain[name] = n
+		}
+	}
+	return plain, nil
+}
+
+func (e *encryptedDestination) Put(rel string, r io.Reader, size int64) error {
+	keys, err := e.unlock()
+	if err != nil {
+		return err
+	}
+	name, err := keys.encryptPath(rel)
+	if err != nil {
+		return err
+	}
+	encrypted, err := keys.encryptReader(r, size)
+	if err != nil {
+		return err
+	}
+	return e.inner.Put(name, encrypted, encryptedSize(size))
+}
+
+func (e *encryptedDestination) Remove(rels []string) error {
+	keys, err := e.unlock()
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(rels))
+	for _, rel := range rels {
+		if name, err := keys.encryptPath(rel); err == nil {
+			names = append(names, name)
+		}
+	}
+	return e.inner.Remove(names)
+}
+
+// DecryptFolder writes the decrypted contents of the encrypted folder at dir below out and returns
+// how many files it wrote. Files that don't decrypt with the passphrase are skipped.
+func DecryptFolder(dir, passphrase, out string) (int, error) {
+	keys, err := openContainer(dir, passphrase, false)
+	if err != nil {
+		return 0, err
+	}
+	files, err := mirrorFiles(dir)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list %s: %w", dir, err)
+	}
+	var written int
+	for rel := range files {
+		name, ok := keys.decryptPath(rel)
+		if !ok {
+			continue
+		}
+		if err := decryptFile(keys, filepath.Join(dir, filepath.FromSlash(rel)), filepath.Join(out, filepath.FromSlash(name))); err != nil {
+			return written, fmt.Errorf("failed to decrypt %s: %w", name, err)
+		}
+		written++
+	}
+	return written, nil
+}
+
+func decryptFile(keys *containerKeys, src, dest string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return err
+	}
+	tmp := dest + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	err = keys.decrypt(file, in)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, dest)
+	}
+	if err != nil {
+		os.Remove(tmp)
+	}
+	return err
+}
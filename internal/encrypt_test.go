@@ -0,0 +1,172 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestEncryptedSize(t *testing.T) {
+	for _, size := range []int64{0, 1, chunkSize - 1, chunkSize, chunkSize + 1, 3*chunkSize + 7} {
+		if got, ok := plainSize(encryptedSize(size)); !ok || got != size {
+			t.Errorf("Expected %d bytes back from the encrypted size, got %d", size, got)
+		}
+	}
+}
+
+func TestPushMirror_Encrypted(t *testing.T) {
+	orig := encryptIterations
+	encryptIterations = 1000
+	t.Cleanup(func() { encryptIterations = orig })
+
+	passphraseFile := filepath.Join(t.TempDir(), "passphrase")
+	if err := os.WriteFile(passphraseFile, []byte("correct horse\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	volume := t.TempDir()
+	drive := USBDrive{Name: "Stick", Volume: volume, Profile: DriveProfile{Encrypt: &EncryptSettings{PassphraseFile: passphraseFile}}}
+	container := drive.EncryptedFolder()
+
+	mirror := t.TempDir()
+	writeDriveFiles(t, mirror, "Show A/One.mp3", "Show B/Three.mp3", "podcasts.m3u8", ".podcasts-sync.json")
+	long := bytes.Repeat([]byte("audio"), chunkSize/2)
+	if err := os.WriteFile(filepath.Join(mirror, "Show A", "Two.mp3"), long, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := pushMirror(drive.destination(), mirror, nil); err != nil {
+		t.Fatalf("pushMirror failed: %v", err)
+	}
+	onDrive, err := mirrorFiles(container)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(onDrive) != 4 {
+		t.Errorf("Expected 4 encrypted files on the drive, got %v", onDrive)
+	}
+	for rel := range onDrive {
+		data, _ := os.ReadFile(filepath.Join(container, filepath.FromSlash(rel)))
+		if strings.Contains(rel, "Show") || bytes.Contains(data, []byte("Show")) || bytes.Contains(data, []byte("audio")) {
+			t.Errorf("Expected names and contents to be encrypted, got %s", rel)
+		}
+	}
+
+	// An unchanged mirror has nothing to push, so the listed sizes match the mirror's
+	remote, err := drive.destination().List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	local, _ := mirrorFiles(mirror)
+	if plan := planPush(local, remote, loadPushed(mirror)); len(plan.put) > 0 || len(plan.remove) > 0 {
+		t.Errorf("Expected nothing to push again, got %+v", plan)
+	}
+
+	if err := os.Remove(filepath.Join(mirror, "Show B", "Three.mp3")); err != nil {
+		t.Fatal(err)
+	}
+	if err := pushMirror(drive.destination(), mirror, nil); err != nil {
+		t.Fatalf("pushMirror failed: %v", err)
+	}
+	if entries, _ := os.ReadDir(container); len(entries) != 3 {
+		t.Errorf("Expected the removed episode's folder to leave the drive, got %d entries", len(entries))
+	}
+
+	out := t.TempDir()
+	n, err := DecryptFolder(container, "correct horse", out)
+	if err != nil {
+		t.Fatalf("DecryptFolder failed: %v", err)
+	}
+	if n != 3 {
+		t.Errorf("Expected 3 files decrypted, got %d", n)
+	}
+	got, err := os.ReadFile(filepath.Join(out, "Show A", "Two.mp3"))
+	if err != nil || !bytes.Equal(got, long) {
+		t.Errorf("Expected the episode to decrypt to its contents, got %d bytes (%v)", len(got), err)
+	}
+	if got, _ := os.ReadFile(filepath.Join(out, "Show A", "One.mp3")); string(got) != "Show A/One.mp3" {
+		t.Errorf("Expected the small episode to decrypt, got %q", got)
+	}
+
+	if _, err := DecryptFolder(container, "wrong", t.TempDir()); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("Expected a wrong passphrase to be refused, got %v", err)
+	}
+}
+
+func TestDecrypt_DetectsTampering(t *testing.T) {
+	keys := &containerKeys{nameEnc: make([]byte, 32), nameMAC: make([]byte, 32), file: make([]byte, 32)}
+	plain := bytes.Repeat([]byte("x"), chunkSize+10)
+	r, err := keys.encryptReader(bytes.NewReader(plain), int64(len(plain)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var encrypted bytes.Buffer
+	if _, err := encrypted.ReadFrom(r); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := keys.decrypt(&out, bytes.NewReader(encrypted.Bytes())); err != nil || !bytes.Equal(out.Bytes(), plain) {
+		t.Fatalf("Expected the file to decrypt, got %v", err)
+	}
+	truncated := encrypted.Bytes()[:encryptedHeader+chunkSize+chunkOverhead]
+	if err := keys.decrypt(&bytes.Buffer{}, bytes.NewReader(truncated)); err == nil {
+		t.Error("Expected a file cut at a chunk boundary to fail")
+	}
+	flipped := slices.Clone(encrypted.Bytes())
+	flipped[len(flipped)-1] ^= 1
+	if err := keys.decrypt(&bytes.Buffer{}, bytes.NewReader(flipped)); err == nil {
+		t.Error("Expected a changed byte to fail")
+	}
+
+	if name, ok := keys.decryptName(keys.encryptName("Episode.mp3")); !ok || name != "Episode.mp3" {
+		t.Errorf("Expected the name to decrypt, got %q", name)
+	}
+	if _, ok := keys.decryptName("notanencryptedname"); ok {
+		t.Error("Expected a plain name not to decrypt")
+	}
+}
+
+func TestDetectDrives_Encrypted(t *testing.T) {
+	volumes := t.TempDir()
+	if err := os.Mkdir(filepath.Join(volumes, "Stick"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	dm := NewDriveManager(volumes, DirectoryTemplate{})
+	dm.SetProfiles(map[string]DriveProfile{"Stick": {Encrypt: &EncryptSettings{}}})
+
+	if drives, err := dm.DetectDrives(); err != nil || len(drives) != 0 {
+		t.Errorf("Expected no drive to be written in the clear without a mirror, got %v (%v)", drives, err)
+	}
+
+	mirrors := t.TempDir()
+	dm.SetMirrors(mirrors)
+	drives, err := dm.DetectDrives()
+	if err != nil || len(drives) != 1 {
+		t.Fatalf("Expected the encrypted drive, got %v (%v)", drives, err)
+	}
+	if drives[0].MountPath != filepath.Join(mirrors, "encrypted", "Stick") {
+		t.Errorf("Expected the drive to be synced through a mirror, got %s", drives[0].MountPath)
+	}
+	if want := filepath.Join(volumes, "Stick", DefaultEncryptedFolder); drives[0].EncryptedFolder() != want {
+		t.Errorf("Expected the encrypted folder at %s, got %s", want, drives[0].EncryptedFolder())
+	}
+}
+
+func TestLoadConfig_InvalidEncrypt(t *testing.T) {
+	for _, config := range []string{
+		`{"drives": {"Stick": {"encrypt": {"folder": "../outside"}}}}`,
+		`{"drives": {"iPod": {"ipod": true, "encrypt": {}}}}`,
+	} {
+		path := filepath.Join(t.TempDir(), "config.json")
+		if err := os.WriteFile(path, []byte(config), 0o644); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		if _, err := LoadConfig(path); err == nil {
+			t.Errorf("Expected an error for %s", config)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package internal
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// defaultSyncRate is the write speed assumed for a drive that was never measured or synced
+	defaultSyncRate = 20 * 1024 * 1024
+	// tagCost is what tagging a copied file adds to its file system's per-file overhead
+	tagCost = 40 * time.Millisecond
+	// estimatorRuns is how many of a drive's recent syncs the estimator is fitted to
+	estimatorRuns = 20
+)
+
+// fileOverhead is what creating and finalizing a file costs on each file system beyond writing its bytes.
+// FAT's directory updates are slow on USB sticks, and macOS adds an AppleDouble file next to each episode.
+var fileOverhead = map[string]time.Duration{
+	"msdos": 250 * time.Millisecond,
+	"exfat": 150 * time.Millisecond,
+}
+
+// defaultFileOverhead is the per-file overhead of other file systems
+const defaultFileOverhead = 60 * time.Millisecond
+
+// SyncTiming is how long a finished sync took next to what was predicted, recorded to refine later estimates
+type SyncTiming struct {
+	RunID      int64
+	Drive      string
+	FileSystem string
+	Files      int
+	Bytes      int64
+	Predicted  time.Duration
+	Actual     time.Duration
+	At         time.Time
+}
+
+// SyncEstimate is how long a sync is predicted to take
+type SyncEstimate struct {
+	Duration time.Duration
+	// Runs is how many past syncs of the drive the estimate learned from; 0 means it's a guess from the
+	// drive's measured speed, or a default one
+	Runs int
+}
+
+// String describes the estimate for the pre-sync summary, e.g. "about 4 minutes"
+func (e SyncEstimate) String() string {
+	d := e.Duration.Round(time.Minute)
+	switch {
+	case e.Duration < 45*time.Second:
+		return "under a minute"
+	case d <= time.Minute:
+		return "about a minute"
+	case d < time.Hour:
+		return fmt.Sprintf("about %d minutes", int(d.Minutes()))
+	}
+	hours, minutes := int(d.Hours()), int(d.Minutes())%60
+	text := "about 1 hour"
+	if hours > 1 {
+		text = fmt.Sprintf("about %d hours", hours)
+	}
+	if minutes > 0 {
+		text += fmt.Sprintf(" %d min", minutes)
+	}
+	return text
+}
+
+// syncModel predicts a sync as its bytes at a write rate plus a fixed cost for each file
+type syncModel struct {
+	rate    float64 // bytes per second
+	perFile time.Duration
+}
+
+func (m syncModel) predict(bytes int64, files int) time.Duration {
+	return time.Duration(float64(bytes)/m.rate*float64(time.Second)) + time.Duration(files)*m.perFile
+}
+
+// EstimateSync predicts how long copying files episodes totalling bytes to drive takes. It starts
+// from the drive's measured write speed and its file system's per-file overhead, then fits both to
+// the drive's recent syncs in the history, so estimates improve with every sync. Until the drive has
+// synced a few times, its per-file overhead is learned from other drives with the same file system.
+func EstimateSync(h *History, drive USBDrive, bytes int64, files int) (SyncEstimate, error) {
+	timings, err := h.Timings(drive.Name, estimatorRuns)
+	if err != nil {
+		return SyncEstimate{}, err
+	}
+	fs := fileSystemName(drive.MountPath)
+	prior := priorSyncModel(drive, fs)
+	if len(timings) < 3 && fs != "" {
+		others, err := h.timings("file_system", fs, estimatorRuns)
+		if err != nil {
+			return SyncEstimate{}, err
+		}
+		prior.perFile = fitSyncModel(prior, others).perFile
+	}
+	model := fitSyncModel(prior, timings)
+	return SyncEstimate{Duration: model.predict(bytes, files), Runs: len(timings)}, nil
+}
+
+// priorSyncModel is the estimate for a drive with file system fs before it has synced
+func priorSyncModel(drive USBDrive, fs string) syncModel {
+	perFile, ok := fileOverhead[fs]
+	if !ok {
+		perFile = defaultFileOverhead
+	}
+	model := syncModel{rate: defaultSyncRate, perFile: perFile + tagCost}
+	if drive.Profile.Speed.Write > 0 {
+		model.rate = drive.Profile.Speed.Write
+	}
+	return model
+}
+
+// fitSyncModel fits the rate and per-file cost to past syncs by least squares. Syncs that all copy
+// episodes of about the same size can't tell the two apart, so then only the rate is fitted and the
+// per-file cost is kept from prior.
+func fitSyncModel(prior syncModel, timings []SyncTiming) syncModel {
+	var bb, bf, ff, bt, ft, sumBytes, sumRest float64
+	for _, t := range timings {
+		// Work in MB and seconds so the sums stay well within float precision
+		b, f, s := float64(t.Bytes)/(1<<20), float64(t.Files), t.Actual.Seconds()
+		bb += b * b
+		bf += b * f
+		ff += f * f
+		bt += b * s
+		ft += f * s
+		sumBytes += b
+		sumRest += s - f*prior.perFile.Seconds()
+	}
+
+	if det := bb*ff - bf*bf; len(timings) >= 3 && det > 1e-3*bb*ff {
+		perByte := (bt*ff - ft*bf) / det
+		perFile := (ft*bb - bt*bf) / det
+		if perByte > 0 && perFile >= 0 {
+			return syncModel{rate: (1 << 20) / perByte, perFile: time.Duration(perFile * float64(time.Second))}
+		}
+	}
+	if sumBytes > 0 && sumRest > 0 {
+		return syncModel{rate: sumBytes * (1 << 20) / sumRest, perFile: prior.perFile}
+	}
+	return prior
+}
+
+// RecordTiming stores how long a sync took, stamping it with the current time if it has none
+func (h *History) RecordTiming(t SyncTiming) error {
+	if h == nil {
+		return nil
+	}
+
+	db, err := h.open()
+	if err != nil {
+		return err
+	}
+
+	if t.At.IsZero() {
+		t.At = time.Now()
+	}
+	_, err = db.Exec(
+		`INSERT OR REPLACE INTO timings (run_id, drive, file_system, files, bytes, predicted, actual, at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		t.RunID, t.Drive, t.FileSystem, t.Files, t.Bytes, int64(t.Predicted), int64(t.Actual), t.At.Unix(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record sync timing: %w", err)
+	}
+	return nil
+}
+
+// Timings returns up to limit of the named drive's most recent sync timings, newest first
+func (h *History) Timings(drive string, limit int) ([]SyncTiming, error) {
+	return h.timings("drive", drive, limit)
+}
+
+// timings returns up to limit of the most recent sync timings whose column is value
+func (h *History) timings(column, value string, limit int) ([]SyncTiming, error) {
+	if h == nil {
+		return nil, nil
+	}
+
+	db, err := h.open()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(
+		`SELECT run_id, drive, file_system, files, bytes, predicted, actual, at FROM timings WHERE `+column+` = ? ORDER BY at DESC, run_id DESC LIMIT ?`,
+		value, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sync timings: %w", err)
+	}
+	defer rows.Close()
+
+	var timings []SyncTiming
+	for rows.Next() {
+		var (
+			t                 SyncTiming
+			predicted, actual int64
+			at                int64
+		)
+		if err := rows.Scan(&t.RunID, &t.Drive, &t.FileSystem, &t.Files, &t.Bytes, &predicted, &actual, &at); err != nil {
+			return nil, fmt.Errorf("failed to read sync timings: %w", err)
+		}
+		t.Predicted = time.Duration(predicted)
+		t.Actual = time.Duration(actual)
+		t.At = time.Unix(at, 0)
+		timings = append(timings, t)
+	}
+	return timings, rows.Err()
+}
@@ -0,0 +1,115 @@
+package internal
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSyncEstimate_String(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{20 * time.Second, "under a minute"},
+		{70 * time.Second, "about a minute"},
+		{4*time.Minute + 10*time.Second, "about 4 minutes"},
+		{time.Hour + 20*time.Minute, "about 1 hour 20 min"},
+		{2 * time.Hour, "about 2 hours"},
+	}
+	for _, tt := range tests {
+		if got := (SyncEstimate{Duration: tt.d}).String(); got != tt.want {
+			t.Errorf("Expected %s to read %q, got %q", tt.d, tt.want, got)
+		}
+	}
+}
+
+// syncTimings returns timings of syncs taking 0.5s per file and 10 MB/s
+func syncTimings(sizes ...[2]int) []SyncTiming {
+	var timings []SyncTiming
+	for _, s := range sizes {
+		files, mb := s[0], s[1]
+		actual := time.Duration(files)*500*time.Millisecond + time.Duration(mb)*time.Second/10
+		timings = append(timings, SyncTiming{Files: files, Bytes: int64(mb) << 20, Actual: actual})
+	}
+	return timings
+}
+
+func TestFitSyncModel(t *testing.T) {
+	prior := syncModel{rate: 50 << 20, perFile: 100 * time.Millisecond}
+
+	fitted := fitSyncModel(prior, syncTimings([2]int{2, 100}, [2]int{20, 200}, [2]int{5, 500}))
+	if math.Abs(fitted.rate-10<<20) > 1<<10 || (fitted.perFile-500*time.Millisecond).Abs() > time.Millisecond {
+		t.Errorf("Expected 10 MB/s and 0.5s per file, got %.0f B/s and %s", fitted.rate, fitted.perFile)
+	}
+
+	// Syncs of same-sized episodes can't separate the per-file cost from the rate
+	fitted = fitSyncModel(prior, syncTimings([2]int{2, 100}, [2]int{4, 200}))
+	if fitted.perFile != prior.perFile {
+		t.Errorf("Expected the prior per-file cost to be kept, got %s", fitted.perFile)
+	}
+	if got := fitted.predict(300<<20, 6); (got - 33*time.Second).Abs() > time.Second {
+		t.Errorf("Expected the fitted rate to predict a like sync, got %s", got)
+	}
+
+	if got := fitSyncModel(prior, nil); got != prior {
+		t.Errorf("Expected the prior without timings, got %+v", got)
+	}
+}
+
+func TestEstimateSync_LearnsFromHistory(t *testing.T) {
+	h := NewHistory(filepath.Join(t.TempDir(), "history.db"))
+	defer h.Close()
+	drive := USBDrive{Name: "STICK", MountPath: t.TempDir(), Profile: DriveProfile{Speed: DriveSpeed{Write: 10 << 20, MeasuredAt: time.Now()}}}
+
+	estimate, err := EstimateSync(h, drive, 600<<20, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if estimate.Runs != 0 || estimate.Duration < time.Minute || estimate.Duration > time.Minute+time.Second {
+		t.Errorf("Expected about a minute from the measured speed, got %+v", estimate)
+	}
+
+	for i, timing := range syncTimings([2]int{2, 100}, [2]int{20, 200}, [2]int{5, 500}) {
+		timing.RunID, timing.Drive = int64(i+1), "STICK"
+		timing.At = time.Now().Add(time.Duration(i) * time.Minute)
+		if err := h.RecordTiming(timing); err != nil {
+			t.Fatal(err)
+		}
+	}
+	estimate, err = EstimateSync(h, drive, 600<<20, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 110 * time.Second; estimate.Runs != 3 || (estimate.Duration-want).Abs() > time.Second {
+		t.Errorf("Expected %s learned from 3 syncs, got %+v", want, estimate)
+	}
+}
+
+func TestPodcastSync_RecordsTiming(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHistory(filepath.Join(tempDir, "history.db"))
+	defer h.Close()
+	source := filepath.Join(tempDir, "New.mp3")
+	if err := os.WriteFile(source, []byte("audio"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	episodes := []PodcastEpisode{{ZTitle: "New", ShowName: "Show", FilePath: "file://" + source, Selected: true}}
+
+	ps := NewPodcastSync()
+	ps.SetHistory(h)
+	ch := make(chan FileOp, 100)
+	ps.StartSync(episodes, USBDrive{Name: "DRIVE", MountPath: filepath.Join(tempDir, "drive")}, ChanSink(ch))
+	for range ch {
+	}
+
+	timings, err := h.Timings("DRIVE", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(timings) != 1 || timings[0].Files != 1 || timings[0].Bytes != 5 || timings[0].Predicted <= 0 || timings[0].Actual <= 0 {
+		t.Errorf("Expected the sync's timing next to its prediction, got %+v", timings)
+	}
+}
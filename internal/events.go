@@ -0,0 +1,32 @@
+package internal
+
+import "time"
+
+// SyncEventType names a step in the life of an automatic sync
+type SyncEventType string
+
+const (
+	EventDriveConnected SyncEventType = "drive_connected"
+	EventSyncStarted    SyncEventType = "sync_started"
+	EventSyncFinished   SyncEventType = "sync_finished"
+	EventSyncFailed     SyncEventType = "sync_failed"
+)
+
+// SyncEvent is published to home automation as watch mode finds drives and syncs them
+type SyncEvent struct {
+	Event SyncEventType `json:"event"`
+	Drive string        `json:"drive"`
+	// UUID is the drive's volume UUID when its profile is keyed by it
+	UUID     string `json:"uuid,omitempty"`
+	Episodes int    `json:"episodes,omitempty"`
+	Bytes    int64  `json:"bytes,omitempty"`
+	// Titles lists the episodes a finished sync copied
+	Titles []string  `json:"titles,omitempty"`
+	Error  string    `json:"error,omitempty"`
+	Time   time.Time `json:"time"`
+}
+
+// EventPublisher sends sync events somewhere outside the app
+type EventPublisher interface {
+	Publish(event SyncEvent) error
+}
@@ -0,0 +1,37 @@
+package internal
+
+import (
+	"errors"
+	"io"
+	"os"
+)
+
+// errNoFastCopy is returned by cloneFile on platforms and filesystems without clones
+var errNoFastCopy = errors.New("fast copy not supported")
+
+// kernelCopyChunk bounds each kernel-side copy so progress and cancellation stay responsive
+const kernelCopyChunk = 8 * 1024 * 1024
+
+// copyKernel copies src to dst in chunks the kernel moves without passing them through the
+// process (copy_file_range or sendfile on Linux), advancing file after each chunk
+func copyKernel(dst, src *os.File, file *FileTransfer) error {
+	for {
+		if err := file.interrupted(); err != nil {
+			return err
+		}
+		// os.File.ReadFrom picks the kernel copy, which io.CopyN reaches through a LimitedReader
+		n, err := io.CopyN(dst, src, kernelCopyChunk)
+		file.Advance(n)
+		file.tm.pace(n)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		// Like copySynced, flush so progress tracks the drive rather than the page cache
+		if err := dst.Sync(); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,20 @@
+package internal
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// kernelCopy is false since macOS only sendfiles to sockets; copies use the buffered loop
+const kernelCopy = false
+
+// cloneFile makes dst a copy-on-write clone of src, which APFS allows within a single volume
+func cloneFile(src, dst string) error {
+	return unix.Clonefile(src, dst, unix.CLONE_NOFOLLOW)
+}
+
+// dropCache makes reads from f bypass the unified buffer cache
+func dropCache(f *os.File) {
+	_, _ = unix.FcntlInt(f.Fd(), unix.F_NOCACHE, 1)
+}
@@ -0,0 +1,13 @@
+//go:build !darwin && !linux
+
+package internal
+
+import "os"
+
+const kernelCopy = false
+
+func cloneFile(src, dst string) error {
+	return errNoFastCopy
+}
+
+func dropCache(f *os.File) {}
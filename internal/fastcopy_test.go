@@ -0,0 +1,82 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCopyKernel(t *testing.T) {
+	dir := t.TempDir()
+	// Larger than one chunk so progress is advanced more than once
+	data := bytes.Repeat([]byte("podcast!"), kernelCopyChunk/8+1000)
+	srcPath := filepath.Join(dir, "src.mp3")
+	if err := os.WriteFile(srcPath, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	src, err := os.Open(srcPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+	dst, err := os.Create(filepath.Join(dir, "dst.mp3"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dst.Close()
+
+	tm := NewTransferManager(int64(len(data)), 1, ChanSink(make(chan FileOp, 100)))
+	defer tm.Stop()
+	if err := copyKernel(dst, src, tm.BeginFile("src.mp3")); err != nil {
+		t.Fatalf("copyKernel() error = %v", err)
+	}
+
+	got, _ := os.ReadFile(dst.Name())
+	if !bytes.Equal(got, data) {
+		t.Errorf("copied %d bytes, want %d identical bytes", len(got), len(data))
+	}
+	if progress := tm.Snapshot().BytesTransferred; progress != int64(len(data)) {
+		t.Errorf("BytesTransferred = %d, want %d", progress, len(data))
+	}
+}
+
+func TestCopyKernel_Stopped(t *testing.T) {
+	dir := t.TempDir()
+	srcPath := filepath.Join(dir, "src.mp3")
+	if err := os.WriteFile(srcPath, []byte("audio"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	src, _ := os.Open(srcPath)
+	defer src.Close()
+	dst, _ := os.Create(filepath.Join(dir, "dst.mp3"))
+	defer dst.Close()
+
+	tm := NewTransferManager(5, 1, ChanSink(make(chan FileOp, 10)))
+	tm.Stop()
+	if err := copyKernel(dst, src, tm.BeginFile("src.mp3")); !errors.Is(err, ErrTransferStopped) {
+		t.Errorf("copyKernel() error = %v, want ErrTransferStopped", err)
+	}
+}
+
+func TestCloneFile_FallsBackWhereUnsupported(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.mp3")
+	if err := os.WriteFile(src, []byte("audio"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	dst := filepath.Join(dir, "dst.mp3")
+
+	err := cloneFile(src, dst)
+	if err != nil {
+		// Callers copy instead, so a failed clone must not leave a file behind
+		if _, statErr := os.Stat(dst); !os.IsNotExist(statErr) {
+			t.Errorf("failed clone left %s behind", dst)
+		}
+		return
+	}
+	if got, _ := os.ReadFile(dst); string(got) != "audio" {
+		t.Errorf("clone contents = %q, want %q", got, "audio")
+	}
+}
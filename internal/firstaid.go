@@ -0,0 +1,79 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// RepeatedIOErrors is how many I/O errors on a drive before First Aid is offered
+const RepeatedIOErrors = 2
+
+// DriveIOErrors counts the I/O errors syncs and deletes hit on a drive since First Aid last repaired it,
+// so flaky media is flagged in the drive selector
+type DriveIOErrors struct {
+	Count int       `json:"count"`
+	Last  time.Time `json:"last"`
+	// RepairedAt is when First Aid last finished on the drive, which resets the count
+	RepairedAt time.Time `json:"repairedAt,omitzero"`
+}
+
+// String describes the errors for the drive selector, e.g. "⚠ 3 I/O errors"
+func (e DriveIOErrors) String() string {
+	if e.Count == 1 {
+		return "⚠ 1 I/O error"
+	}
+	return fmt.Sprintf("⚠ %d I/O errors", e.Count)
+}
+
+// IsIOError reports whether err is the drive failing to read or write, or dropping off the bus,
+// rather than a full drive or a missing file
+func IsIOError(err error) bool {
+	return errors.Is(err, syscall.EIO) || errors.Is(err, syscall.ENXIO)
+}
+
+// RecordIOError counts an I/O error on the named drive and returns its updated count
+func (c *Config) RecordIOError(name string, at time.Time) DriveIOErrors {
+	if c.Drives == nil {
+		c.Drives = map[string]DriveProfile{}
+	}
+	profile := c.Drives[name]
+	profile.IOErrors.Count++
+	profile.IOErrors.Last = at
+	c.Drives[name] = profile
+	return profile.IOErrors
+}
+
+// ClearIOErrors resets the named drive's I/O error count once First Aid has repaired it
+func (c *Config) ClearIOErrors(name string, at time.Time) {
+	profile, ok := c.Drives[name]
+	if !ok {
+		return
+	}
+	profile.IOErrors = DriveIOErrors{RepairedAt: at}
+	c.Drives[name] = profile
+}
+
+// RunFirstAid checks and repairs the drive's file system with diskutil, as Disk Utility's First Aid
+// does, and returns diskutil's closing line. The volume is unmounted while it runs.
+func RunFirstAid(drive USBDrive) (string, error) {
+	volume := drive.MountPath
+	switch {
+	case drive.Volume != "":
+		volume = drive.Volume
+	case drive.destination() != nil:
+		return "", errors.New("First Aid only repairs mounted drives")
+	}
+	out, err := healthCommand("diskutil", "repairVolume", volume).CombinedOutput()
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	last := strings.TrimSpace(lines[len(lines)-1])
+	if err != nil {
+		if last == "" {
+			return "", err
+		}
+		return "", fmt.Errorf("%w: %s", err, last)
+	}
+	return last, nil
+}
@@ -0,0 +1,66 @@
+package internal
+
+import (
+	"fmt"
+	"io/fs"
+	"os/exec"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestIsIOError(t *testing.T) {
+	copyErr := fmt.Errorf("failed to copy episode: %w", &fs.PathError{Op: "write", Path: "/Volumes/STICK/a.mp3", Err: syscall.EIO})
+	if !IsIOError(copyErr) {
+		t.Error("Expected a wrapped EIO to be an I/O error")
+	}
+	if IsIOError(fmt.Errorf("failed to copy episode: %w", syscall.ENOSPC)) {
+		t.Error("Expected a full drive not to be an I/O error")
+	}
+}
+
+func TestRecordIOError(t *testing.T) {
+	cfg := &Config{}
+	first := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	cfg.RecordIOError("STICK", first)
+	errs := cfg.RecordIOError("STICK", first.Add(time.Minute))
+	if errs.Count != 2 || !errs.Last.Equal(first.Add(time.Minute)) {
+		t.Errorf("Expected 2 errors, the last a minute later, got %+v", errs)
+	}
+
+	drive := USBDrive{Name: "STICK", MountPath: "/Volumes/STICK", Profile: cfg.Drives["STICK"]}
+	if !strings.Contains(drive.Description(), "⚠ 2 I/O errors") {
+		t.Errorf("Expected the drive selector to flag the errors, got %q", drive.Description())
+	}
+
+	cfg.ClearIOErrors("STICK", first.Add(time.Hour))
+	if errs := cfg.Drives["STICK"].IOErrors; errs.Count != 0 || !errs.RepairedAt.Equal(first.Add(time.Hour)) {
+		t.Errorf("Expected First Aid to reset the count, got %+v", errs)
+	}
+}
+
+func TestRunFirstAid(t *testing.T) {
+	var ran string
+	orig := healthCommand
+	healthCommand = func(name string, args ...string) *exec.Cmd {
+		ran = name + " " + strings.Join(args, " ")
+		return exec.Command("printf", "%s", "Started file system repair on disk4s1 STICK\nFinished file system repair on disk4s1 STICK\n")
+	}
+	t.Cleanup(func() { healthCommand = orig })
+
+	result, err := RunFirstAid(USBDrive{Name: "STICK", MountPath: "/Volumes/STICK"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ran != "diskutil repairVolume /Volumes/STICK" {
+		t.Errorf("Expected diskutil to repair the volume, ran %s", ran)
+	}
+	if result != "Finished file system repair on disk4s1 STICK" {
+		t.Errorf("Expected diskutil's last line, got %q", result)
+	}
+
+	if _, err := RunFirstAid(USBDrive{Name: "Pixel", Serial: "abc"}); err == nil {
+		t.Error("Expected First Aid to refuse a drive without a volume")
+	}
+}
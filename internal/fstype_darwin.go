@@ -0,0 +1,24 @@
+package internal
+
+import (
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// fileSystemName returns the type of the file system holding path as macOS names it, e.g. "msdos",
+// "exfat" or "apfs", or "" if it can't be read
+func fileSystemName(path string) string {
+	var st unix.Statfs_t
+	if err := unix.Statfs(path, &st); err != nil {
+		return ""
+	}
+	name := make([]byte, 0, len(st.Fstypename))
+	for _, c := range st.Fstypename {
+		if c == 0 {
+			break
+		}
+		name = append(name, byte(c))
+	}
+	return strings.ToLower(string(name))
+}
@@ -0,0 +1,7 @@
+//go:build !darwin
+
+package internal
+
+func fileSystemName(path string) string {
+	return ""
+}
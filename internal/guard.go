@@ -0,0 +1,55 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ErrOutsidePodcastsFolder is returned instead of deleting or moving anything outside a drive's podcasts folder
+var ErrOutsidePodcastsFolder = errors.New("refusing to change files outside the drive's podcasts folder")
+
+// podcastsRoot returns the podcasts folder of drive, refusing a folder setting that leaves the
+// drive or makes the root of the filesystem the podcasts folder
+func podcastsRoot(drive USBDrive) (string, error) {
+	if !filepath.IsAbs(drive.MountPath) {
+		return "", fmt.Errorf("%w: drive %q is not mounted at an absolute path", ErrOutsidePodcastsFolder, drive.Name)
+	}
+	mount := filepath.Clean(drive.MountPath)
+	root := filepath.Join(mount, drive.Folder)
+	if root != mount && !within(mount, root) {
+		return "", fmt.Errorf("%w: folder %q leaves drive %q", ErrOutsidePodcastsFolder, drive.Folder, drive.Name)
+	}
+	if filepath.Dir(root) == root {
+		return "", fmt.Errorf("%w: the podcasts folder of drive %q is the filesystem root", ErrOutsidePodcastsFolder, drive.Name)
+	}
+	return root, nil
+}
+
+// checkInside returns ErrOutsidePodcastsFolder unless path lies below root once symlinks in both
+// are resolved. The last element of path isn't resolved: removing a symlink only removes the link.
+func checkInside(root, path string) error {
+	if root == "" || !filepath.IsAbs(path) {
+		return fmt.Errorf("%w: %s", ErrOutsidePodcastsFolder, path)
+	}
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return fmt.Errorf("failed to resolve podcasts folder: %w", err)
+	}
+	path = filepath.Clean(path)
+	realDir, err := filepath.EvalSymlinks(filepath.Dir(path))
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", path, err)
+	}
+	if !within(realRoot, filepath.Join(realDir, filepath.Base(path))) {
+		return fmt.Errorf("%w: %s", ErrOutsidePodcastsFolder, path)
+	}
+	return nil
+}
+
+// within reports whether path lies strictly below root, comparing the paths as given
+func within(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,129 @@
+package internal
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPodcastsRoot(t *testing.T) {
+	tests := []struct {
+		name  string
+		drive USBDrive
+		want  string
+	}{
+		{"podcasts folder", USBDrive{MountPath: "/Volumes/STICK", Folder: "podcasts"}, "/Volumes/STICK/podcasts"},
+		{"whole drive", USBDrive{MountPath: "/Volumes/STICK"}, "/Volumes/STICK"},
+		{"folder leaving the drive", USBDrive{MountPath: "/Volumes/STICK", Folder: "../Macintosh HD"}, ""},
+		{"relative mount", USBDrive{MountPath: "Volumes/STICK", Folder: "podcasts"}, ""},
+		{"filesystem root", USBDrive{MountPath: "/"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := podcastsRoot(tt.drive)
+			if tt.want == "" {
+				if !errors.Is(err, ErrOutsidePodcastsFolder) {
+					t.Errorf("Expected the folder to be refused, got %q, %v", got, err)
+				}
+				return
+			}
+			if err != nil || got != filepath.FromSlash(tt.want) {
+				t.Errorf("podcastsRoot = %q, %v; want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckInside(t *testing.T) {
+	base := t.TempDir()
+	root := filepath.Join(base, "podcasts")
+	outside := filepath.Join(base, "outside")
+	for _, dir := range []string{filepath.Join(root, "Show"), outside} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatalf("Failed to create %s: %v", dir, err)
+		}
+	}
+	if err := os.Symlink(outside, filepath.Join(root, "Escape")); err != nil {
+		t.Skipf("Symlinks unsupported: %v", err)
+	}
+	if err := os.Symlink(outside, filepath.Join(root, "Show", "link.mp3")); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		path string
+		ok   bool
+	}{
+		{"episode", filepath.Join(root, "Show", "episode.mp3"), true},
+		{"symlink itself", filepath.Join(root, "Show", "link.mp3"), true},
+		{"through a symlinked folder", filepath.Join(root, "Escape", "episode.mp3"), false},
+		{"dot dot", filepath.Join(root, "Show", "..", "..", "outside", "episode.mp3"), false},
+		{"the root itself", root, false},
+		{"relative", "Show/episode.mp3", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkInside(root, tt.path)
+			if tt.ok && err != nil {
+				t.Errorf("Expected %s to be allowed, got %v", tt.path, err)
+			}
+			if !tt.ok && !errors.Is(err, ErrOutsidePodcastsFolder) {
+				t.Errorf("Expected %s to be refused, got %v", tt.path, err)
+			}
+		})
+	}
+}
+
+func TestDeleteSelected_RefusesOutsidePodcastsFolder(t *testing.T) {
+	base := t.TempDir()
+	drive := USBDrive{MountPath: filepath.Join(base, "drive"), Folder: "podcasts"}
+	stray := filepath.Join(drive.MountPath, "notes.mp3")
+	if err := os.MkdirAll(filepath.Join(drive.MountPath, drive.Folder), 0o755); err != nil {
+		t.Fatalf("Failed to create podcasts folder: %v", err)
+	}
+	if err := os.WriteFile(stray, []byte("keep me"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	ps := NewPodcastSync()
+	ps.SetDrive(drive)
+	result := ps.DeleteSelected([]PodcastEpisode{{FilePath: stray, Selected: true}})
+	if !errors.Is(result.Error, ErrOutsidePodcastsFolder) {
+		t.Errorf("Expected the delete to be refused, got %v", result.Error)
+	}
+	if _, err := os.Stat(stray); err != nil {
+		t.Errorf("Expected the file outside the podcasts folder to survive: %v", err)
+	}
+
+	result = NewPodcastSync().DeleteSelected([]PodcastEpisode{{FilePath: stray, Selected: true}})
+	if !errors.Is(result.Error, ErrOutsidePodcastsFolder) {
+		t.Errorf("Expected deletes without a drive to be refused, got %v", result.Error)
+	}
+}
+
+func TestUndo_RefusesJournalLeavingPodcastsFolder(t *testing.T) {
+	base := t.TempDir()
+	podcastDir := filepath.Join(base, "podcasts")
+	victim := filepath.Join(base, "victim.mp3")
+	if err := os.MkdirAll(podcastDir, 0o755); err != nil {
+		t.Fatalf("Failed to create podcasts folder: %v", err)
+	}
+	if err := os.WriteFile(victim, []byte("keep me"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	run := newJournalRun(podcastDir)
+	run.Entries = append(run.Entries, JournalEntry{Op: JournalCopied, Path: "../victim.mp3"})
+	if err := saveJournalRun(podcastDir, UndoSync, run); err != nil {
+		t.Fatalf("saveJournalRun failed: %v", err)
+	}
+
+	_, err := NewPodcastSync().Undo(USBDrive{MountPath: podcastDir}, UndoSync)
+	if !errors.Is(err, ErrOutsidePodcastsFolder) {
+		t.Errorf("Expected the entry to be refused, got %v", err)
+	}
+	if _, err := os.Stat(victim); err != nil {
+		t.Errorf("Expected the file outside the podcasts folder to survive: %v", err)
+	}
+}
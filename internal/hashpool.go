@@ -0,0 +1,85 @@
+package internal
+
+import (
+	"os"
+	"runtime"
+	"sync"
+)
+
+// slowDriveRead is the measured read speed below which a drive is hashed one file at a time: USB 2
+// sticks and spinning disks lose more to seeking between files than they gain from overlapping reads
+const slowDriveRead = 40 * 1024 * 1024
+
+// HashProgress is how far hashFiles has got across all its files
+type HashProgress struct {
+	Files, TotalFiles int
+	Bytes, TotalBytes int64
+}
+
+// hashWorkers sizes the hash pool for files on a drive with the given measured speed. Unmeasured
+// drives get a few workers; fast ones as many as there are CPUs to spend on SHA-256, up to 8.
+func hashWorkers(speed DriveSpeed) int {
+	switch {
+	case speed.MeasuredAt.IsZero():
+		return min(runtime.NumCPU(), 4)
+	case speed.Read < slowDriveRead:
+		return 1
+	}
+	return libraryHashWorkers()
+}
+
+// libraryHashWorkers sizes the hash pool for files in the Mac's library, on its internal SSD
+func libraryHashWorkers() int {
+	return min(runtime.NumCPU(), 8)
+}
+
+// hashFiles returns the SHA-256 of each file in paths, hashing up to workers files at once.
+// Files that can't be hashed are left out of sums and their errors returned in errs.
+// progress, if not nil, is called after each file from one goroutine at a time.
+func hashFiles(paths []string, workers int, progress func(HashProgress)) (sums map[string]string, errs map[string]error) {
+	sums = make(map[string]string, len(paths))
+	errs = make(map[string]error)
+	var p HashProgress
+	p.TotalFiles = len(paths)
+	if progress != nil {
+		for _, path := range paths {
+			if info, err := os.Stat(path); err == nil {
+				p.TotalBytes += info.Size()
+			}
+		}
+	}
+
+	jobs := make(chan string)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for range max(min(workers, len(paths)), 1) {
+		wg.Go(func() {
+			for path := range jobs {
+				sum, err := getChecksum(path)
+				var size int64
+				if info, statErr := os.Stat(path); statErr == nil {
+					size = info.Size()
+				}
+
+				mu.Lock()
+				if err != nil {
+					errs[path] = err
+				} else {
+					sums[path] = sum
+				}
+				p.Files++
+				p.Bytes += size
+				if progress != nil {
+					progress(p)
+				}
+				mu.Unlock()
+			}
+		})
+	}
+	for _, path := range paths {
+		jobs <- path
+	}
+	close(jobs)
+	wg.Wait()
+	return sums, errs
+}
@@ -0,0 +1,56 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHashFiles(t *testing.T) {
+	dir := t.TempDir()
+	var paths []string
+	var total int64
+	for i := range 12 {
+		path := filepath.Join(dir, fmt.Sprintf("episode%d.mp3", i))
+		content := []byte(fmt.Sprintf("audio %d", i))
+		if err := os.WriteFile(path, content, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+		total += int64(len(content))
+	}
+	missing := filepath.Join(dir, "missing.mp3")
+
+	var last HashProgress
+	calls := 0
+	sums, errs := hashFiles(append(paths, missing), 4, func(p HashProgress) {
+		calls++
+		last = p
+	})
+	for _, path := range paths {
+		want, _ := getChecksum(path)
+		if sums[path] != want {
+			t.Errorf("Expected %s to hash to %s, got %s", filepath.Base(path), want, sums[path])
+		}
+	}
+	if _, ok := sums[missing]; ok || !os.IsNotExist(errs[missing]) {
+		t.Errorf("Expected the missing file's error instead of a sum, got %v", errs[missing])
+	}
+	if calls != 13 || last != (HashProgress{Files: 13, TotalFiles: 13, Bytes: total, TotalBytes: total}) {
+		t.Errorf("Expected progress after each of the 13 files ending at %d bytes, got %d calls ending at %+v", total, calls, last)
+	}
+}
+
+func TestHashWorkers(t *testing.T) {
+	if n := hashWorkers(DriveSpeed{Read: 20 * 1024 * 1024, MeasuredAt: time.Now()}); n != 1 {
+		t.Errorf("Expected a slow drive to be hashed one file at a time, got %d workers", n)
+	}
+	if n := hashWorkers(DriveSpeed{}); n < 1 || n > 4 {
+		t.Errorf("Expected a few workers for an unmeasured drive, got %d", n)
+	}
+	if fast := hashWorkers(DriveSpeed{Read: 400 * 1024 * 1024, MeasuredAt: time.Now()}); fast < hashWorkers(DriveSpeed{}) {
+		t.Errorf("Expected a fast drive to get at least as many workers as an unmeasured one, got %d", fast)
+	}
+}
@@ -0,0 +1,136 @@
+package internal
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// healthCommand runs diskutil, smartctl and fsck for drive health checks and First Aid; tests replace it
+var healthCommand = func(name string, args ...string) *exec.Cmd {
+	return exec.Command(name, args...)
+}
+
+// FileSystemState is whether a drive's file system was left clean, as reported by a quick fsck
+type FileSystemState string
+
+const (
+	FileSystemClean   FileSystemState = "clean"
+	FileSystemDirty   FileSystemState = "needs repair"
+	FileSystemUnknown FileSystemState = "unknown"
+)
+
+// DriveHealth is what the system reports about a drive's hardware, checked before trusting it with a long copy
+type DriveHealth struct {
+	Device     string
+	Media      string
+	Protocol   string
+	FileSystem string
+	// SMART is the drive's self-assessment: "Verified", "Failing", or "Not Supported" by most USB sticks
+	SMART           string
+	FileSystemState FileSystemState
+}
+
+// Warnings lists the reasons not to trust the drive with a long copy
+func (h DriveHealth) Warnings() []string {
+	var warnings []string
+	if h.SMART == "Failing" {
+		warnings = append(warnings, "SMART reports the drive is failing: copy its episodes elsewhere and replace it")
+	}
+	if h.FileSystemState == FileSystemDirty {
+		warnings = append(warnings, "The file system wasn't cleanly unmounted: repair it in Disk Utility before syncing")
+	}
+	return warnings
+}
+
+// CheckDriveHealth asks diskutil about the drive's device, then smartctl when diskutil can't read its
+// SMART status, and runs a quick fsck. Drives without a mounted volume, like Android devices and
+// WebDAV shares, have no health to check.
+func CheckDriveHealth(drive USBDrive) (DriveHealth, error) {
+	volume := drive.MountPath
+	switch {
+	case drive.Volume != "":
+		volume = drive.Volume
+	case drive.destination() != nil || drive.Profile.LocalFolder != "":
+		return DriveHealth{}, errors.New("only mounted drives have a health to check")
+	}
+
+	out, err := healthCommand("diskutil", "info", volume).Output()
+	if err != nil {
+		return DriveHealth{}, fmt.Errorf("failed to read drive info: %w", err)
+	}
+	info := parseDiskutilInfo(string(out))
+	health := DriveHealth{
+		Device:     info["Device Identifier"],
+		Media:      info["Device / Media Name"],
+		Protocol:   info["Protocol"],
+		FileSystem: info["File System Personality"],
+		SMART:      info["SMART Status"],
+	}
+	if health.SMART != "Verified" && health.SMART != "Failing" {
+		if smart, ok := smartctlHealth(info["Part of Whole"]); ok {
+			health.SMART = smart
+		}
+	}
+	health.FileSystemState = quickFsck(info["Type (Bundle)"], health.Device)
+	return health, nil
+}
+
+// parseDiskutilInfo reads the "Key: Value" lines of `diskutil info`
+func parseDiskutilInfo(out string) map[string]string {
+	info := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if ok {
+			info[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	return info
+}
+
+// smartctlHealth reads the overall SMART assessment of the whole disk from smartctl, which reaches
+// drives behind USB bridges that diskutil reports as not supported. It's optional, e.g. from Homebrew.
+func smartctlHealth(disk string) (string, bool) {
+	if disk == "" {
+		return "", false
+	}
+	out, _ := healthCommand("smartctl", "-H", "/dev/"+disk).Output()
+	for line := range strings.SplitSeq(string(out), "\n") {
+		_, result, ok := strings.Cut(line, "self-assessment test result:")
+		if !ok {
+			continue
+		}
+		if strings.TrimSpace(result) == "PASSED" {
+			return "Verified", true
+		}
+		return "Failing", true
+	}
+	return "", false
+}
+
+// quickFsck asks the file system's fsck whether the volume was cleanly unmounted, without checking
+// it in full. fsck exits 0 for clean and 3 for dirty; it needs to read the raw device, which not every
+// user may, and APFS keeps no such flag.
+func quickFsck(bundle, device string) FileSystemState {
+	switch bundle {
+	case "msdos", "exfat", "hfs":
+	default:
+		return FileSystemUnknown
+	}
+	if device == "" {
+		return FileSystemUnknown
+	}
+	err := healthCommand(filepath.Join("/sbin", "fsck_"+bundle), "-q", "/dev/"+device).Run()
+	var exit *exec.ExitError
+	switch {
+	case err == nil:
+		return FileSystemClean
+	case errors.As(err, &exit) && exit.ExitCode() == 3:
+		return FileSystemDirty
+	}
+	return FileSystemUnknown
+}
@@ -0,0 +1,98 @@
+package internal
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const diskutilInfo = `   Device Identifier:         disk4s1
+   Device Node:               /dev/disk4s1
+   Whole:                     No
+   Part of Whole:             disk4
+
+   Volume Name:               STICK
+   Mounted:                   Yes
+   Mount Point:               /Volumes/STICK
+
+   Partition Type:            DOS_FAT_32
+   File System Personality:   MS-DOS FAT32
+   Type (Bundle):             msdos
+
+   Protocol:                  USB
+   SMART Status:              Not Supported
+   Device / Media Name:       SanDisk Cruzer
+`
+
+// fakeHealthTools answers diskutil with diskutilInfo, smartctl with smartctl and fsck with fsckExit
+func fakeHealthTools(t *testing.T, smartctl string, fsckExit string) *[]string {
+	t.Helper()
+	var ran []string
+	orig := healthCommand
+	healthCommand = func(name string, args ...string) *exec.Cmd {
+		ran = append(ran, filepath.Base(name)+" "+strings.Join(args, " "))
+		switch {
+		case name == "diskutil":
+			return exec.Command("printf", "%s", diskutilInfo)
+		case name == "smartctl":
+			return exec.Command("printf", "%s", smartctl)
+		}
+		return exec.Command("sh", "-c", "exit "+fsckExit)
+	}
+	t.Cleanup(func() { healthCommand = orig })
+	return &ran
+}
+
+func TestCheckDriveHealth(t *testing.T) {
+	ran := fakeHealthTools(t, "SMART overall-health self-assessment test result: PASSED\n", "0")
+	health, err := CheckDriveHealth(USBDrive{Name: "STICK", MountPath: "/Volumes/STICK"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := DriveHealth{Device: "disk4s1", Media: "SanDisk Cruzer", Protocol: "USB", FileSystem: "MS-DOS FAT32", SMART: "Verified", FileSystemState: FileSystemClean}
+	if health != want {
+		t.Errorf("Expected %+v, got %+v", want, health)
+	}
+	if got := strings.Join(*ran, "; "); got != "diskutil info /Volumes/STICK; smartctl -H /dev/disk4; fsck_msdos -q /dev/disk4s1" {
+		t.Errorf("Expected the whole disk to be asked for SMART and the volume to be checked, ran %s", got)
+	}
+	if len(health.Warnings()) > 0 {
+		t.Errorf("Expected no warnings for a healthy drive, got %v", health.Warnings())
+	}
+}
+
+func TestCheckDriveHealth_Failing(t *testing.T) {
+	fakeHealthTools(t, "SMART overall-health self-assessment test result: FAILED!\n", "3")
+	health, err := CheckDriveHealth(USBDrive{Name: "STICK", MountPath: "/Volumes/STICK"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if health.SMART != "Failing" || health.FileSystemState != FileSystemDirty {
+		t.Errorf("Expected a failing, dirty drive, got %+v", health)
+	}
+	if len(health.Warnings()) != 2 {
+		t.Errorf("Expected a warning for each, got %v", health.Warnings())
+	}
+
+	// Without smartctl or access to the device, both stay unknown rather than alarming
+	fakeHealthTools(t, "", "8")
+	health, _ = CheckDriveHealth(USBDrive{Name: "STICK", MountPath: "/Volumes/STICK"})
+	if health.SMART != "Not Supported" || health.FileSystemState != FileSystemUnknown || len(health.Warnings()) > 0 {
+		t.Errorf("Expected unknown health without warnings, got %+v", health)
+	}
+}
+
+func TestCheckDriveHealth_Destination(t *testing.T) {
+	ran := fakeHealthTools(t, "", "0")
+	if _, err := CheckDriveHealth(USBDrive{Name: "Pixel", Serial: "abc"}); err == nil {
+		t.Error("Expected an Android device to have no health to check")
+	}
+	encrypted := USBDrive{Name: "STICK", MountPath: "/mirror", Volume: "/Volumes/STICK", Profile: DriveProfile{Encrypt: &EncryptSettings{}}}
+	if _, err := CheckDriveHealth(encrypted); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix((*ran)[0], "diskutil info /Volumes/STICK") {
+		t.Errorf("Expected an encrypted drive's volume to be checked, not its mirror, ran %v", *ran)
+	}
+}
@@ -0,0 +1,298 @@
+package internal
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// HistoryAction is the kind of event recorded in the history database
+type HistoryAction string
+
+const (
+	HistorySynced  HistoryAction = "synced"
+	HistoryFailed  HistoryAction = "failed"
+	HistoryRemoved HistoryAction = "removed"
+)
+
+// HistoryEntry is a single recorded sync or delete event for one episode
+type HistoryEntry struct {
+	RunID    int64
+	Action   HistoryAction
+	Title    string
+	ShowName string
+	Source   string
+	Drive    string
+	Error    string
+	At       time.Time
+}
+
+// Key identifies the episode an entry refers to, independent of where its file lives
+func (e HistoryEntry) Key() string {
+	return EpisodeKey(PodcastEpisode{ZTitle: e.Title, ShowName: e.ShowName})
+}
+
+// EpisodeKey identifies an episode by show and title so Mac and drive copies compare equal
+func EpisodeKey(episode PodcastEpisode) string {
+	return episode.ShowName + "\x00" + episode.ZTitle
+}
+
+// historyMigrations upgrades the database schema, tracked in PRAGMA user_version;
+// historyMigrations[i] upgrades version i to i+1
+var historyMigrations = []string{
+	// 0 → 1: databases from before versioning may already have these tables
+	`
+	CREATE TABLE IF NOT EXISTS history (
+		id     INTEGER PRIMARY KEY AUTOINCREMENT,
+		run_id INTEGER NOT NULL,
+		action TEXT NOT NULL,
+		title  TEXT NOT NULL,
+		show   TEXT NOT NULL,
+		source TEXT NOT NULL,
+		drive  TEXT NOT NULL,
+		error  TEXT NOT NULL,
+		at     INTEGER NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS favorites (
+		show  TEXT NOT NULL,
+		title TEXT NOT NULL,
+		at    INTEGER NOT NULL,
+		PRIMARY KEY (show, title)
+	)
+	`,
+	// 1 → 2: the last action of each kind, for repeating it
+	`
+	CREATE TABLE actions (
+		kind     TEXT PRIMARY KEY,
+		drive    TEXT NOT NULL,
+		shows    TEXT NOT NULL,
+		episodes INTEGER NOT NULL,
+		filter   TEXT NOT NULL,
+		at       INTEGER NOT NULL
+	)
+	`,
+	// 2 → 3: how long each finished sync took, for estimating the next
+	`
+	CREATE TABLE timings (
+		run_id      INTEGER PRIMARY KEY,
+		drive       TEXT NOT NULL,
+		file_system TEXT NOT NULL,
+		files       INTEGER NOT NULL,
+		bytes       INTEGER NOT NULL,
+		predicted   INTEGER NOT NULL,
+		actual      INTEGER NOT NULL,
+		at          INTEGER NOT NULL
+	);
+	CREATE INDEX timings_drive ON timings (drive, at)
+	`,
+}
+
+// History records sync activity in a local SQLite database.
+// The database is opened on first use; a nil *History silently records nothing.
+type History struct {
+	path    string
+	once    sync.Once
+	db      *sql.DB
+	openErr error
+}
+
+// DefaultHistoryPath returns the location of the history database next to the config file
+func DefaultHistoryPath() string {
+	return filepath.Join(filepath.Dir(DefaultConfigPath()), "history.db")
+}
+
+// NewHistory creates a History backed by the database at path
+func NewHistory(path string) *History {
+	return &History{path: path}
+}
+
+func (h *History) open() (*sql.DB, error) {
+	h.once.Do(func() {
+		if err := os.MkdirAll(filepath.Dir(h.path), 0o755); err != nil {
+			h.openErr = fmt.Errorf("failed to create history directory: %w", err)
+			return
+		}
+
+		db, err := sql.Open("sqlite", h.path)
+		if err != nil {
+			h.openErr = fmt.Errorf("failed to open history: %w", err)
+			return
+		}
+
+		if err := migrateHistory(db, h.path); err != nil {
+			db.Close()
+			h.openErr = err
+			return
+		}
+		h.db = db
+	})
+	return h.db, h.openErr
+}
+
+// migrateHistory brings the database up to the latest schema, refusing databases from newer releases
+func migrateHistory(db *sql.DB, path string) error {
+	var version int
+	if err := db.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		return fmt.Errorf("failed to read history schema: %w", err)
+	}
+
+	current := len(historyMigrations)
+	if version > current {
+		return newerSchemaError("history database", path, version, current)
+	}
+
+	for v := version; v < current; v++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return fmt.Errorf("failed to migrate history: %w", err)
+		}
+		if _, err := tx.Exec(historyMigrations[v]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to migrate history from schema %d: %w", v, err)
+		}
+		// PRAGMA doesn't accept bound parameters
+		if _, err := tx.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, v+1)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to migrate history from schema %d: %w", v, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to migrate history from schema %d: %w", v, err)
+		}
+	}
+	return nil
+}
+
+// Record stores entries, stamping any without a time with the current time
+func (h *History) Record(entries ...HistoryEntry) error {
+	if h == nil || len(entries) == 0 {
+		return nil
+	}
+
+	db, err := h.open()
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		if e.At.IsZero() {
+			e.At = time.Now()
+		}
+		_, err := db.Exec(
+			`INSERT INTO history (run_id, action, title, show, source, drive, error, at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			e.RunID, string(e.Action), e.Title, e.ShowName, e.Source, e.Drive, e.Error, e.At.Unix(),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to record history: %w", err)
+		}
+	}
+	return nil
+}
+
+// Since returns entries of the given action recorded at or after since, newest first
+func (h *History) Since(action HistoryAction, since time.Time) ([]HistoryEntry, error) {
+	return h.query(`WHERE action = ? AND at >= ? ORDER BY at DESC, id DESC`, string(action), since.Unix())
+}
+
+// LastRunFailures returns the failures recorded by the most recent sync run
+func (h *History) LastRunFailures() ([]HistoryEntry, error) {
+	return h.query(`
+		WHERE action = ? AND run_id = (
+			SELECT MAX(run_id) FROM history WHERE action IN (?, ?)
+		) ORDER BY id DESC`,
+		string(HistoryFailed), string(HistorySynced), string(HistoryFailed),
+	)
+}
+
+func (h *History) query(clause string, args ...any) ([]HistoryEntry, error) {
+	if h == nil {
+		return nil, nil
+	}
+
+	db, err := h.open()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(`SELECT run_id, action, title, show, source, drive, error, at FROM history `+clause, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query history: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []HistoryEntry
+	for rows.Next() {
+		var e HistoryEntry
+		var action string
+		var at int64
+		if err := rows.Scan(&e.RunID, &action, &e.Title, &e.ShowName, &e.Source, &e.Drive, &e.Error, &at); err != nil {
+			return nil, fmt.Errorf("failed to read history: %w", err)
+		}
+		e.Action = HistoryAction(action)
+		e.At = time.Unix(at, 0)
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// SetFavorite stars or unstars an episode. Favorites are keyed like EpisodeKey so
+// they apply to the Mac and drive copies alike.
+func (h *History) SetFavorite(episode PodcastEpisode, favorite bool) error {
+	if h == nil {
+		return nil
+	}
+
+	db, err := h.open()
+	if err != nil {
+		return err
+	}
+
+	if favorite {
+		_, err = db.Exec(`INSERT OR IGNORE INTO favorites (show, title, at) VALUES (?, ?, ?)`,
+			episode.ShowName, episode.ZTitle, time.Now().Unix())
+	} else {
+		_, err = db.Exec(`DELETE FROM favorites WHERE show = ? AND title = ?`, episode.ShowName, episode.ZTitle)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update favorite: %w", err)
+	}
+	return nil
+}
+
+// Favorites returns the EpisodeKey of every starred episode
+func (h *History) Favorites() (map[string]bool, error) {
+	favorites := map[string]bool{}
+	if h == nil {
+		return favorites, nil
+	}
+
+	db, err := h.open()
+	if err != nil {
+		return favorites, err
+	}
+
+	rows, err := db.Query(`SELECT show, title FROM favorites`)
+	if err != nil {
+		return favorites, fmt.Errorf("failed to query favorites: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var episode PodcastEpisode
+		if err := rows.Scan(&episode.ShowName, &episode.ZTitle); err != nil {
+			return favorites, fmt.Errorf("failed to read favorites: %w", err)
+		}
+		favorites[EpisodeKey(episode)] = true
+	}
+	return favorites, rows.Err()
+}
+
+// Close releases the database if it was opened
+func (h *History) Close() error {
+	if h == nil || h.db == nil {
+		return nil
+	}
+	return h.db.Close()
+}
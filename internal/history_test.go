@@ -0,0 +1,111 @@
+package internal
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHistory_RecordAndQuery(t *testing.T) {
+	h := NewHistory(filepath.Join(t.TempDir(), "history.db"))
+	defer h.Close()
+
+	old := time.Now().Add(-30 * 24 * time.Hour)
+	err := h.Record(
+		HistoryEntry{RunID: 1, Action: HistorySynced, Title: "Old", ShowName: "Show", At: old},
+		HistoryEntry{RunID: 1, Action: HistoryFailed, Title: "First failure", ShowName: "Show"},
+		HistoryEntry{RunID: 2, Action: HistorySynced, Title: "New", ShowName: "Show"},
+		HistoryEntry{RunID: 2, Action: HistoryFailed, Title: "Latest failure", ShowName: "Show", Error: "boom"},
+		HistoryEntry{Action: HistoryRemoved, Title: "Gone", ShowName: "Show"},
+	)
+	if err != nil {
+		t.Fatalf("Failed to record history: %v", err)
+	}
+
+	synced, err := h.Since(HistorySynced, time.Now().Add(-7*24*time.Hour))
+	if err != nil {
+		t.Fatalf("Failed to query history: %v", err)
+	}
+	if len(synced) != 1 || synced[0].Title != "New" {
+		t.Errorf("Expected only the recent sync, got %+v", synced)
+	}
+
+	failures, err := h.LastRunFailures()
+	if err != nil {
+		t.Fatalf("Failed to query failures: %v", err)
+	}
+	if len(failures) != 1 || failures[0].Title != "Latest failure" || failures[0].Error != "boom" {
+		t.Errorf("Expected only the latest run's failure, got %+v", failures)
+	}
+
+	removed, err := h.Since(HistoryRemoved, time.Time{})
+	if err != nil {
+		t.Fatalf("Failed to query removals: %v", err)
+	}
+	if len(removed) != 1 || removed[0].Key() != EpisodeKey(PodcastEpisode{ZTitle: "Gone", ShowName: "Show"}) {
+		t.Errorf("Unexpected removals: %+v", removed)
+	}
+}
+
+func TestHistory_NilIsNoop(t *testing.T) {
+	var h *History
+	if err := h.Record(HistoryEntry{Action: HistorySynced}); err != nil {
+		t.Errorf("Expected nil history to ignore records, got %v", err)
+	}
+	if entries, err := h.Since(HistorySynced, time.Time{}); err != nil || entries != nil {
+		t.Errorf("Expected nil history to return nothing, got %v, %v", entries, err)
+	}
+}
+
+func TestPodcastSync_RecordsHistory(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHistory(filepath.Join(tempDir, "history.db"))
+	defer h.Close()
+
+	audio := filepath.Join(tempDir, "episode.mp3")
+	if err := os.WriteFile(audio, []byte("audio"), 0o644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	ps := NewPodcastSync()
+	ps.SetHistory(h)
+	ps.SetDrive(USBDrive{MountPath: tempDir})
+	ps.DeleteSelected([]PodcastEpisode{{ZTitle: "Episode", ShowName: "Show", FilePath: audio, Selected: true}})
+	ps.record(HistoryFailed, PodcastEpisode{ZTitle: "Broken", ShowName: "Show"}, errors.New("read error"))
+
+	removed, err := h.Since(HistoryRemoved, time.Time{})
+	if err != nil || len(removed) != 1 || removed[0].Title != "Episode" {
+		t.Errorf("Expected removal to be recorded, got %+v, %v", removed, err)
+	}
+
+	failures, err := h.LastRunFailures()
+	if err != nil || len(failures) != 1 || failures[0].Error != "read error" {
+		t.Errorf("Expected failure to be recorded, got %+v, %v", failures, err)
+	}
+}
+
+func TestHistory_Favorites(t *testing.T) {
+	h := NewHistory(filepath.Join(t.TempDir(), "history.db"))
+	defer h.Close()
+
+	starred := PodcastEpisode{ZTitle: "Starred", ShowName: "Show"}
+	other := PodcastEpisode{ZTitle: "Other", ShowName: "Show"}
+	for _, episode := range []PodcastEpisode{starred, other, starred} {
+		if err := h.SetFavorite(episode, true); err != nil {
+			t.Fatalf("Failed to star %q: %v", episode.ZTitle, err)
+		}
+	}
+	if err := h.SetFavorite(other, false); err != nil {
+		t.Fatalf("Failed to unstar: %v", err)
+	}
+
+	favorites, err := h.Favorites()
+	if err != nil {
+		t.Fatalf("Failed to query favorites: %v", err)
+	}
+	if len(favorites) != 1 || !favorites[EpisodeKey(starred)] {
+		t.Errorf("Expected only %q to be starred, got %v", starred.ZTitle, favorites)
+	}
+}
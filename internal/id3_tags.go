@@ -121,7 +121,7 @@ func addID3TagsOnce(filePath string, episode PodcastEpisode) error {
 
 	// Set year from publish date
 	if !episode.Published.IsZero() {
-		tag.SetYear(episode.Published.Format("2006"))
+		tag.SetYear(episode.Published.UTC().Format("2006"))
 	}
 
 	// Set comment with publish date in readable format
@@ -130,7 +130,7 @@ func addID3TagsOnce(filePath string, episode PodcastEpisode) error {
 			Encoding:    id3v2.EncodingUTF8,
 			Language:    "eng",
 			Description: "Published",
-			Text:        episode.Published.Format("2006-01-02"),
+			Text:        episode.Published.UTC().Format(canonicalDateFormat),
 		}
 		tag.AddCommentFrame(comment)
 	}
@@ -0,0 +1,568 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// IPodPodcastsFolder is the podcasts folder of drives whose profile sets ipod, below the iPod's root
+const IPodPodcastsFolder = "iPod_Control/Music/Podcasts"
+
+var (
+	// ErrNotAnIPod is returned when a drive set up as an iPod has no iPod_Control/iTunes folder
+	ErrNotAnIPod = errors.New("no iPod_Control/iTunes folder found; is the iPod in disk mode?")
+	// ErrNoIPodDatabase is returned for iPods that were never set up by iTunes or Finder
+	ErrNoIPodDatabase = errors.New("the iPod has no iTunesDB yet; set it up once with iTunes or Finder")
+	// ErrSignedIPodDatabase is returned for iPods that only read a database signed for the device
+	ErrSignedIPodDatabase = errors.New("this iPod only accepts a signed iTunesDB (classic, nano 3G and later), which podcasts-sync can't write")
+)
+
+const (
+	// macEpochOffset is the number of seconds from the iPod's 1904 epoch to the Unix epoch
+	macEpochOffset = 2082844800
+	// itdbMediaPodcast is the mhit media type that files a track under Podcasts
+	itdbMediaPodcast = 0x04
+	// itdbGroupHeader marks an mhip that heads a show in the podcasts dataset
+	itdbGroupHeader = 0x100
+)
+
+// mhod types used by podcasts-sync
+const (
+	mhodTitle    = 1
+	mhodLocation = 2
+	mhodAlbum    = 3
+	mhodArtist   = 4
+	mhodGenre    = 5
+	mhodFiletype = 6
+	mhodPosition = 100
+	// Sorted track indexes of the master playlist, which go stale when tracks change
+	mhodLibraryIndex      = 52
+	mhodLibraryIndexJumps = 53
+)
+
+// itdb is a parsed iTunesDB. Tracks and playlists podcasts-sync doesn't manage are kept as raw chunks.
+type itdb struct {
+	header   []byte
+	tracks   []itdbTrack
+	sections []itdbSection
+}
+
+type itdbTrack struct {
+	id       uint32
+	location string
+	raw      []byte
+}
+
+// itdbSection is an mhsd. Track (1) and playlist (2, 3) datasets are rebuilt, others written back as read.
+type itdbSection struct {
+	kind      uint32
+	header    []byte
+	playlists []itdbPlaylist
+	raw       []byte
+}
+
+type itdbPlaylist struct {
+	header []byte
+	mhods  [][]byte
+	items  []itdbItem
+}
+
+type itdbItem struct {
+	trackID uint32
+	raw     []byte
+}
+
+func (p itdbPlaylist) master() bool  { return p.header[0x14] == 1 }
+func (p itdbPlaylist) podcast() bool { return binary.LittleEndian.Uint16(p.header[0x2A:]) == 1 }
+
+// driveFolder returns the podcasts folder below the mount path of a drive with profile
+func driveFolder(profile DriveProfile) string {
+	switch {
+	case profile.IPod:
+		return filepath.FromSlash(IPodPodcastsFolder)
+	case profile.Folder != "":
+		return filepath.FromSlash(profile.Folder)
+	}
+	return "podcasts"
+}
+
+// writeDriveIndexes rewrites what players read the drive's episodes from after a change:
+// the playlist, and on iPods the iTunesDB
+func writeDriveIndexes(podcastDir string, profile DriveProfile) error {
+	if err := WritePlaylist(podcastDir, profile); err != nil {
+		return err
+	}
+	if profile.IPod {
+		return WriteIPodDatabase(podcastDir)
+	}
+	return nil
+}
+
+// WriteIPodDatabase lists the episodes in podcastDir in the iTunesDB of the iPod holding it, so they
+// appear in its Podcasts menu. Tracks outside podcastDir and their playlists are kept; the previous
+// database is saved beside it as iTunesDB.bak.
+func WriteIPodDatabase(podcastDir string) error {
+	root, err := ipodRoot(podcastDir)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(root, "iPod_Control", "iTunes", "iTunesDB")
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return ErrNoIPodDatabase
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read iTunesDB: %w", err)
+	}
+	db, err := parseITunesDB(data)
+	if err != nil {
+		return err
+	}
+	if db.signed() {
+		return ErrSignedIPodDatabase
+	}
+
+	episodes, err := ipodEpisodes(podcastDir)
+	if err != nil {
+		return err
+	}
+	db.replacePodcasts(ipodLocation(root, podcastDir), root, episodes)
+
+	if err := os.WriteFile(path+".bak", data, 0o644); err != nil {
+		return fmt.Errorf("failed to back up iTunesDB: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, db.bytes(), 0o644); err != nil {
+		return fmt.Errorf("failed to write iTunesDB: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to write iTunesDB: %w", err)
+	}
+	return nil
+}
+
+// ipodRoot returns the root of the iPod holding dir: the nearest folder with iPod_Control/iTunes
+func ipodRoot(dir string) (string, error) {
+	for dir = filepath.Clean(dir); ; dir = filepath.Dir(dir) {
+		if info, err := os.Stat(filepath.Join(dir, "iPod_Control", "iTunes")); err == nil && info.IsDir() {
+			return dir, nil
+		}
+		if filepath.Dir(dir) == dir {
+			return "", ErrNotAnIPod
+		}
+	}
+}
+
+// ipodLocation is how the iTunesDB refers to path: relative to the iPod's root, separated by colons
+func ipodLocation(root, path string) string {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		rel = path
+	}
+	return ":" + strings.ReplaceAll(filepath.ToSlash(rel), "/", ":")
+}
+
+// ipodEpisodes returns the episodes in podcastDir, with each show's episodes together
+func ipodEpisodes(podcastDir string) ([]PodcastEpisode, error) {
+	episodes, err := namedEpisodes(podcastDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan iPod: %w", err)
+	}
+	// The podcasts dataset groups episodes by show, so each show's episodes have to be together
+	slices.SortStableFunc(episodes, func(a, b PodcastEpisode) int { return strings.Compare(a.ShowName, b.ShowName) })
+	return episodes, nil
+}
+
+// signed reports whether the database carries a hash the iPod checks against its FireWire ID
+func (db *itdb) signed() bool {
+	if len(db.header) >= 0x32 && binary.LittleEndian.Uint16(db.header[0x30:]) != 0 {
+		return true
+	}
+	return len(db.header) >= 0x6C && slices.ContainsFunc(db.header[0x58:0x6C], func(b byte) bool { return b != 0 })
+}
+
+// replacePodcasts swaps the tracks below the location prefix for episodes and rebuilds the playlists:
+// the master playlist lists every track, and a Podcasts playlist the episodes, grouped by show in the podcasts dataset
+func (db *itdb) replacePodcasts(prefix, root string, episodes []PodcastEpisode) {
+	var nextID uint32
+	kept := make(map[uint32]bool)
+	tracks := db.tracks[:0]
+	for _, t := range db.tracks {
+		nextID = max(nextID, t.id)
+		if strings.HasPrefix(strings.ToLower(t.location), strings.ToLower(prefix)+":") {
+			continue
+		}
+		kept[t.id] = true
+		tracks = append(tracks, t)
+	}
+
+	headerLen := 0xF4
+	if len(tracks) > 0 {
+		headerLen = max(headerLen, int(binary.LittleEndian.Uint32(tracks[0].raw[0x04:])))
+	}
+	podcastIDs := make([]uint32, len(episodes))
+	for i, e := range episodes {
+		nextID++
+		podcastIDs[i] = nextID
+		tracks = append(tracks, itdbTrack{id: nextID, location: ipodLocation(root, e.FilePath), raw: newITDBTrack(nextID, headerLen, ipodLocation(root, e.FilePath), e)})
+	}
+	db.tracks = tracks
+
+	now := macTime(time.Now())
+	for s := range db.sections {
+		section := &db.sections[s]
+		if section.kind != 2 && section.kind != 3 {
+			continue
+		}
+		playlists := section.playlists[:0]
+		for _, p := range section.playlists {
+			switch {
+			case p.master():
+				p.mhods = slices.DeleteFunc(p.mhods, func(m []byte) bool {
+					kind := binary.LittleEndian.Uint32(m[0x0C:])
+					return kind == mhodLibraryIndex || kind == mhodLibraryIndexJumps
+				})
+				p.items = p.items[:0]
+				for i, t := range db.tracks {
+					p.items = append(p.items, itdbItem{trackID: t.id, raw: newITDBItem(0, nextID+uint32(i)+1, t.id, 0, now, i, "")})
+				}
+			case p.podcast():
+				// Replaced by the playlist added below
+				continue
+			default:
+				p.items = slices.DeleteFunc(p.items, func(item itdbItem) bool { return !kept[item.trackID] })
+			}
+			playlists = append(playlists, p)
+		}
+		section.playlists = append(playlists, newPodcastsPlaylist(section.kind == 3, episodes, podcastIDs, nextID+uint32(len(db.tracks))+1, now))
+	}
+}
+
+// newPodcastsPlaylist lists the episodes, under a header item per show when grouped
+func newPodcastsPlaylist(grouped bool, episodes []PodcastEpisode, ids []uint32, nextItemID, now uint32) itdbPlaylist {
+	header := make([]byte, 0x6C)
+	copy(header, "mhyp")
+	binary.LittleEndian.PutUint32(header[0x04:], 0x6C)
+	binary.LittleEndian.PutUint32(header[0x18:], now)
+	binary.LittleEndian.PutUint64(header[0x1C:], stableID("podcasts-sync:Podcasts"))
+	binary.LittleEndian.PutUint16(header[0x28:], 1)
+	binary.LittleEndian.PutUint16(header[0x2A:], 1)
+	binary.LittleEndian.PutUint32(header[0x2C:], 1)
+	p := itdbPlaylist{header: header, mhods: [][]byte{newITDBString(mhodTitle, "Podcasts")}}
+
+	var group uint32
+	show := ""
+	for i, e := range episodes {
+		if grouped && (i == 0 || e.ShowName != show) {
+			show = e.ShowName
+			group = nextItemID
+			nextItemID++
+			p.items = append(p.items, itdbItem{raw: newITDBItem(itdbGroupHeader, group, 0, 0, now, -1, show)})
+		}
+		p.items = append(p.items, itdbItem{trackID: ids[i], raw: newITDBItem(0, nextItemID, ids[i], group, now, i, "")})
+		nextItemID++
+	}
+	return p
+}
+
+// newITDBTrack builds the mhit of a podcast episode at location
+func newITDBTrack(id uint32, headerLen int, location string, e PodcastEpisode) []byte {
+	h := make([]byte, headerLen)
+	le := binary.LittleEndian
+	copy(h, "mhit")
+	le.PutUint32(h[0x04:], uint32(headerLen))
+	le.PutUint32(h[0x10:], id)
+	le.PutUint32(h[0x14:], 1) // visible
+	filetype, marker := "MPEG audio file", uint32(0x4D503320)
+	if ext := strings.ToLower(filepath.Ext(location)); ext == ".m4a" || ext == ".aac" {
+		filetype, marker = "AAC audio file", 0x4D344120
+	} else {
+		h[0x1D] = 1 // type2 is set for MP3s
+	}
+	le.PutUint32(h[0x18:], marker)
+	le.PutUint32(h[0x20:], macTime(time.Now()))
+	le.PutUint32(h[0x24:], uint32(e.FileSize))
+	le.PutUint32(h[0x28:], uint32(e.Duration.Milliseconds()))
+	if !e.Published.IsZero() {
+		le.PutUint32(h[0x34:], uint32(e.Published.UTC().Year()))
+		le.PutUint32(h[0x8C:], macTime(e.Published))
+	}
+	le.PutUint16(h[0x3E:], 44100)
+	le.PutUint32(h[0x68:], macTime(time.Now()))
+	le.PutUint64(h[0x70:], stableID(location))
+	h[0xA4] = 2 // no artwork
+	h[0xA5] = 1 // skip when shuffling
+	h[0xA6] = 1 // remember playback position
+	h[0xA7] = 1
+	le.PutUint64(h[0xA8:], stableID(location))
+	h[0xB2] = 2 // show the unplayed bullet
+	le.PutUint32(h[0xD0:], itdbMediaPodcast)
+
+	mhods := [][]byte{
+		newITDBString(mhodTitle, e.ZTitle),
+		newITDBString(mhodLocation, location),
+		newITDBString(mhodAlbum, e.ShowName),
+		newITDBString(mhodArtist, e.ShowName),
+		newITDBString(mhodGenre, "Podcast"),
+		newITDBString(mhodFiletype, filetype),
+	}
+	le.PutUint32(h[0x0C:], uint32(len(mhods)))
+	track := append(h, bytes.Join(mhods, nil)...)
+	le.PutUint32(track[0x08:], uint32(len(track)))
+	return track
+}
+
+// newITDBItem builds an mhip; position < 0 gives it a title mhod instead of a position, for show headers
+func newITDBItem(flag uint16, id, trackID, group, now uint32, position int, title string) []byte {
+	h := make([]byte, 0x4C)
+	le := binary.LittleEndian
+	copy(h, "mhip")
+	le.PutUint32(h[0x04:], 0x4C)
+	le.PutUint32(h[0x0C:], 1)
+	le.PutUint16(h[0x10:], flag)
+	le.PutUint32(h[0x14:], id)
+	le.PutUint32(h[0x18:], trackID)
+	le.PutUint32(h[0x1C:], now)
+	le.PutUint32(h[0x20:], group)
+
+	var mhod []byte
+	if position < 0 {
+		mhod = newITDBString(mhodTitle, title)
+	} else {
+		mhod = make([]byte, 0x2C)
+		copy(mhod, "mhod")
+		le.PutUint32(mhod[0x04:], 0x18)
+		le.PutUint32(mhod[0x08:], 0x2C)
+		le.PutUint32(mhod[0x0C:], mhodPosition)
+		le.PutUint32(mhod[0x18:], uint32(position))
+	}
+	item := append(h, mhod...)
+	le.PutUint32(item[0x08:], uint32(len(item)))
+	return item
+}
+
+// newITDBString builds a string mhod, which holds UTF-16 text
+func newITDBString(kind uint32, s string) []byte {
+	text := utf16.Encode([]rune(s))
+	m := make([]byte, 0x28+2*len(text))
+	le := binary.LittleEndian
+	copy(m, "mhod")
+	le.PutUint32(m[0x04:], 0x18)
+	le.PutUint32(m[0x08:], uint32(len(m)))
+	le.PutUint32(m[0x0C:], kind)
+	le.PutUint32(m[0x18:], 1)
+	le.PutUint32(m[0x1C:], uint32(2*len(text)))
+	for i, c := range text {
+		le.PutUint16(m[0x28+2*i:], c)
+	}
+	return m
+}
+
+// itdbString reads the text of a string mhod
+func itdbString(m []byte) string {
+	if len(m) < 0x28 {
+		return ""
+	}
+	n := min(int(binary.LittleEndian.Uint32(m[0x1C:])), len(m)-0x28) / 2
+	text := make([]uint16, n)
+	for i := range text {
+		text[i] = binary.LittleEndian.Uint16(m[0x28+2*i:])
+	}
+	return string(utf16.Decode(text))
+}
+
+func macTime(t time.Time) uint32 {
+	return uint32(t.Unix() + macEpochOffset)
+}
+
+// stableID derives a persistent ID from s, so rewriting the database keeps the IDs of unchanged entries
+func stableID(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return h.Sum64()
+}
+
+// errCorruptITunesDB is wrapped by every parse failure
+var errCorruptITunesDB = errors.New("corrupt iTunesDB")
+
+// itdbChunk checks that data holds a chunk tagged tag at off and returns its header and total length.
+// List chunks (mhlt, mhlp) store a count where others store their total length, which is returned as 0.
+func itdbChunk(data []byte, off int, tag string) (int, int, error) {
+	if off < 0 || off+12 > len(data) || string(data[off:off+4]) != tag {
+		return 0, 0, fmt.Errorf("%w: expected %s at %d", errCorruptITunesDB, tag, off)
+	}
+	headerLen := int(binary.LittleEndian.Uint32(data[off+4:]))
+	total := int(binary.LittleEndian.Uint32(data[off+8:]))
+	if tag == "mhlt" || tag == "mhlp" {
+		total = 0
+	}
+	if headerLen < 12 || off+headerLen > len(data) || (tag != "mhlt" && tag != "mhlp" && (total < headerLen || off+total > len(data))) {
+		return 0, 0, fmt.Errorf("%w: bad %s length at %d", errCorruptITunesDB, tag, off)
+	}
+	return headerLen, total, nil
+}
+
+func parseITunesDB(data []byte) (*itdb, error) {
+	headerLen, _, err := itdbChunk(data, 0, "mhbd")
+	if err != nil || headerLen < 0x18 {
+		return nil, fmt.Errorf("%w: no database header", errCorruptITunesDB)
+	}
+	db := &itdb{header: data[:headerLen]}
+	off := headerLen
+	for range binary.LittleEndian.Uint32(data[0x14:]) {
+		sectionHeader, total, err := itdbChunk(data, off, "mhsd")
+		if err != nil || sectionHeader < 0x10 {
+			return nil, fmt.Errorf("%w: bad dataset at %d", errCorruptITunesDB, off)
+		}
+		section := itdbSection{kind: binary.LittleEndian.Uint32(data[off+0x0C:]), header: data[off : off+sectionHeader]}
+		body := data[off+sectionHeader : off+total]
+		switch section.kind {
+		case 1:
+			if db.tracks, err = parseITDBTracks(body); err != nil {
+				return nil, err
+			}
+		case 2, 3:
+			if section.playlists, err = parseITDBPlaylists(body); err != nil {
+				return nil, err
+			}
+		default:
+			section.raw = data[off : off+total]
+		}
+		db.sections = append(db.sections, section)
+		off += total
+	}
+	return db, nil
+}
+
+func parseITDBTracks(body []byte) ([]itdbTrack, error) {
+	off, _, err := itdbChunk(body, 0, "mhlt")
+	if err != nil {
+		return nil, err
+	}
+	var tracks []itdbTrack
+	for range binary.LittleEndian.Uint32(body[0x08:]) {
+		headerLen, total, err := itdbChunk(body, off, "mhit")
+		if err != nil || headerLen < 0x14 {
+			return nil, fmt.Errorf("%w: bad track at %d", errCorruptITunesDB, off)
+		}
+		raw := body[off : off+total]
+		track := itdbTrack{id: binary.LittleEndian.Uint32(raw[0x10:]), raw: raw}
+		for m := headerLen; m < total; {
+			_, mhodLen, err := itdbChunk(raw, m, "mhod")
+			if err != nil {
+				return nil, err
+			}
+			if binary.LittleEndian.Uint32(raw[m+0x0C:]) == mhodLocation {
+				track.location = itdbString(raw[m : m+mhodLen])
+			}
+			m += mhodLen
+		}
+		tracks = append(tracks, track)
+		off += total
+	}
+	return tracks, nil
+}
+
+func parseITDBPlaylists(body []byte) ([]itdbPlaylist, error) {
+	off, _, err := itdbChunk(body, 0, "mhlp")
+	if err != nil {
+		return nil, err
+	}
+	var playlists []itdbPlaylist
+	for range binary.LittleEndian.Uint32(body[0x08:]) {
+		headerLen, total, err := itdbChunk(body, off, "mhyp")
+		if err != nil || headerLen < 0x30 {
+			return nil, fmt.Errorf("%w: bad playlist at %d", errCorruptITunesDB, off)
+		}
+		raw := body[off : off+total]
+		p := itdbPlaylist{header: raw[:headerLen]}
+		for c := headerLen; c < total; {
+			tag := string(raw[c : c+4])
+			if tag != "mhod" && tag != "mhip" {
+				return nil, fmt.Errorf("%w: unexpected %q in playlist", errCorruptITunesDB, tag)
+			}
+			childHeader, childLen, err := itdbChunk(raw, c, tag)
+			if err != nil {
+				return nil, err
+			}
+			if tag == "mhod" {
+				p.mhods = append(p.mhods, raw[c:c+childLen])
+			} else {
+				if childHeader < 0x1C {
+					return nil, fmt.Errorf("%w: bad playlist item at %d", errCorruptITunesDB, c)
+				}
+				p.items = append(p.items, itdbItem{trackID: binary.LittleEndian.Uint32(raw[c+0x18:]), raw: raw[c : c+childLen]})
+			}
+			c += childLen
+		}
+		playlists = append(playlists, p)
+		off += total
+	}
+	return playlists, nil
+}
+
+// bytes serializes the database, fixing up the lengths and counts of every rebuilt chunk
+func (db *itdb) bytes() []byte {
+	le := binary.LittleEndian
+	var sections [][]byte
+	for _, s := range db.sections {
+		var body []byte
+		switch s.kind {
+		case 1:
+			body = itdbList("mhlt", len(db.tracks))
+			for _, t := range db.tracks {
+				body = append(body, t.raw...)
+			}
+		case 2, 3:
+			body = itdbList("mhlp", len(s.playlists))
+			for _, p := range s.playlists {
+				body = append(body, p.bytes()...)
+			}
+		default:
+			sections = append(sections, s.raw)
+			continue
+		}
+		section := append(slices.Clone(s.header), body...)
+		le.PutUint32(section[0x08:], uint32(len(section)))
+		sections = append(sections, section)
+	}
+
+	out := append(slices.Clone(db.header), bytes.Join(sections, nil)...)
+	le.PutUint32(out[0x08:], uint32(len(out)))
+	le.PutUint32(out[0x14:], uint32(len(db.sections)))
+	return out
+}
+
+func (p itdbPlaylist) bytes() []byte {
+	le := binary.LittleEndian
+	out := slices.Clone(p.header)
+	le.PutUint32(out[0x0C:], uint32(len(p.mhods)))
+	le.PutUint32(out[0x10:], uint32(len(p.items)))
+	for _, m := range p.mhods {
+		out = append(out, m...)
+	}
+	for _, item := range p.items {
+		out = append(out, item.raw...)
+	}
+	le.PutUint32(out[0x08:], uint32(len(out)))
+	return out
+}
+
+// itdbList builds the header of a list chunk holding count children
+func itdbList(tag string, count int) []byte {
+	h := make([]byte, 0x5C)
+	copy(h, tag)
+	binary.LittleEndian.PutUint32(h[0x04:], 0x5C)
+	binary.LittleEndian.PutUint32(h[0x08:], uint32(count))
+	return h
+}
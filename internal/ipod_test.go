@@ -0,0 +1,179 @@
+package internal
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// writeTestITunesDB writes a database with a music track, an episode from an earlier sync and
+// a playlist holding both, as iTunes would have left it
+func writeTestITunesDB(t *testing.T, root string, signed bool) {
+	t.Helper()
+	header := make([]byte, 0xBC)
+	copy(header, "mhbd")
+	binary.LittleEndian.PutUint32(header[0x04:], 0xBC)
+	if signed {
+		binary.LittleEndian.PutUint16(header[0x30:], 1)
+	}
+	music := PodcastEpisode{ZTitle: "Song", ShowName: "Band", FileSize: 10}
+	old := PodcastEpisode{ZTitle: "Gone", ShowName: "Old", FileSize: 10}
+	master := itdbPlaylist{header: make([]byte, 0x6C), mhods: [][]byte{newITDBString(mhodTitle, "iPod"), newITDBString(mhodLibraryIndex, "")}}
+	copy(master.header, "mhyp")
+	binary.LittleEndian.PutUint32(master.header[0x04:], 0x6C)
+	master.header[0x14] = 1
+	mix := itdbPlaylist{header: slices.Clone(master.header), mhods: [][]byte{newITDBString(mhodTitle, "Mix")}}
+	mix.header[0x14] = 0
+	for i, id := range []uint32{1, 2} {
+		item := itdbItem{trackID: id, raw: newITDBItem(0, 10+id, id, 0, 0, i, "")}
+		master.items = append(master.items, item)
+		mix.items = append(mix.items, item)
+	}
+	db := &itdb{
+		header: header,
+		tracks: []itdbTrack{
+			{id: 1, raw: newITDBTrack(1, 0xF4, ":iPod_Control:Music:F00:ABCD.mp3", music)},
+			{id: 2, raw: newITDBTrack(2, 0xF4, ":iPod_Control:Music:Podcasts:Old:Gone.mp3", old)},
+		},
+		sections: []itdbSection{
+			{kind: 1, header: itdbTestSection(1)},
+			{kind: 3, header: itdbTestSection(3), playlists: []itdbPlaylist{master, mix}},
+			{kind: 2, header: itdbTestSection(2), playlists: []itdbPlaylist{master, mix}},
+		},
+	}
+	dir := filepath.Join(root, "iPod_Control", "iTunes")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatalf("Failed to create %s: %v", dir, err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "iTunesDB"), db.bytes(), 0o644); err != nil {
+		t.Fatalf("Failed to write iTunesDB: %v", err)
+	}
+}
+
+func itdbTestSection(kind uint32) []byte {
+	h := make([]byte, 0x60)
+	copy(h, "mhsd")
+	binary.LittleEndian.PutUint32(h[0x04:], 0x60)
+	binary.LittleEndian.PutUint32(h[0x0C:], kind)
+	return h
+}
+
+func TestWriteIPodDatabase(t *testing.T) {
+	root := t.TempDir()
+	writeTestITunesDB(t, root, false)
+	podcastDir := filepath.Join(root, filepath.FromSlash(IPodPodcastsFolder))
+	writeDriveFiles(t, podcastDir, "Show A/One.mp3", "Show A/Two.mp3", "Show B/Three.m4a")
+	manifest, _ := LoadManifest(podcastDir)
+	manifest.Set(filepath.Join(podcastDir, "Show A", "One.mp3"), ManifestEntry{Show: "Show A", Title: "Episode One"})
+	manifest.Set(filepath.Join(podcastDir, "Show A", "Two.mp3"), ManifestEntry{Show: "Show A", Title: "Episode Two"})
+	if err := manifest.Save(); err != nil {
+		t.Fatalf("Failed to save manifest: %v", err)
+	}
+
+	if err := WriteIPodDatabase(podcastDir); err != nil {
+		t.Fatalf("WriteIPodDatabase failed: %v", err)
+	}
+	path := filepath.Join(root, "iPod_Control", "iTunes", "iTunesDB")
+	if _, err := os.Stat(path + ".bak"); err != nil {
+		t.Errorf("Expected the previous database to be backed up: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read iTunesDB: %v", err)
+	}
+	db, err := parseITunesDB(data)
+	if err != nil {
+		t.Fatalf("Failed to parse the written iTunesDB: %v", err)
+	}
+
+	var locations []string
+	var ids []uint32
+	for _, track := range db.tracks {
+		locations = append(locations, track.location)
+		ids = append(ids, track.id)
+		if track.id != 1 && binary.LittleEndian.Uint32(track.raw[0xD0:]) != itdbMediaPodcast {
+			t.Errorf("Expected %s to be filed under Podcasts", track.location)
+		}
+	}
+	want := []string{
+		":iPod_Control:Music:F00:ABCD.mp3",
+		":iPod_Control:Music:Podcasts:Show A:One.mp3",
+		":iPod_Control:Music:Podcasts:Show A:Two.mp3",
+		":iPod_Control:Music:Podcasts:Show B:Three.m4a",
+	}
+	if !slices.Equal(locations, want) {
+		t.Errorf("Expected the music track and then the episodes by show, got %q", locations)
+	}
+
+	for _, section := range db.sections[1:] {
+		if len(section.playlists) != 3 {
+			t.Fatalf("Expected the master, Mix and Podcasts playlists in dataset %d, got %d", section.kind, len(section.playlists))
+		}
+		master, mix, podcasts := section.playlists[0], section.playlists[1], section.playlists[2]
+		if got := itdbItemTracks(master); !slices.Equal(got, ids) {
+			t.Errorf("Expected the master playlist to list every track, got %v", got)
+		}
+		if len(master.mhods) != 1 {
+			t.Errorf("Expected the stale library index to be dropped, got %d mhods", len(master.mhods))
+		}
+		if got := itdbItemTracks(mix); !slices.Equal(got, []uint32{1}) {
+			t.Errorf("Expected Mix to keep only the music track, got %v", got)
+		}
+		if !podcasts.podcast() || itdbString(podcasts.mhods[0]) != "Podcasts" {
+			t.Errorf("Expected a Podcasts playlist, got %q", itdbString(podcasts.mhods[0]))
+		}
+		var shows []string
+		for _, item := range podcasts.items {
+			if binary.LittleEndian.Uint16(item.raw[0x10:]) == itdbGroupHeader {
+				shows = append(shows, itdbString(item.raw[0x4C:]))
+			}
+		}
+		wantShows := []string{"Show A", "Show B"}
+		if section.kind == 2 {
+			wantShows = nil
+		}
+		if !slices.Equal(shows, wantShows) {
+			t.Errorf("Expected show groups %q in dataset %d, got %q", wantShows, section.kind, shows)
+		}
+		if got := itdbItemTracks(podcasts); !slices.Equal(got, ids[1:]) {
+			t.Errorf("Expected the Podcasts playlist to list the episodes, got %v", got)
+		}
+	}
+}
+
+func itdbItemTracks(p itdbPlaylist) []uint32 {
+	var ids []uint32
+	for _, item := range p.items {
+		if item.trackID != 0 {
+			ids = append(ids, item.trackID)
+		}
+	}
+	return ids
+}
+
+func TestWriteIPodDatabase_Refused(t *testing.T) {
+	root := t.TempDir()
+	podcastDir := filepath.Join(root, filepath.FromSlash(IPodPodcastsFolder))
+	writeDriveFiles(t, podcastDir, "Show/One.mp3")
+	if err := WriteIPodDatabase(podcastDir); !errors.Is(err, ErrNotAnIPod) {
+		t.Errorf("Expected a drive without iPod_Control/iTunes to be refused, got %v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Join(root, "iPod_Control", "iTunes"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteIPodDatabase(podcastDir); !errors.Is(err, ErrNoIPodDatabase) {
+		t.Errorf("Expected an iPod without a database to be refused, got %v", err)
+	}
+
+	writeTestITunesDB(t, root, true)
+	if err := WriteIPodDatabase(podcastDir); !errors.Is(err, ErrSignedIPodDatabase) {
+		t.Errorf("Expected a signed database to be refused, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "iPod_Control", "iTunes", "iTunesDB.bak")); !os.IsNotExist(err) {
+		t.Errorf("Expected a refused database to be left alone, got %v", err)
+	}
+}
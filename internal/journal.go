@@ -0,0 +1,409 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	// JournalFile is the name of the undo journal kept in a drive's podcasts folder
+	JournalFile = ".podcasts-sync-journal.json"
+	// TrashFolder holds the files removed by the last cleanup until the next sync or cleanup
+	TrashFolder = ".podcasts-sync-trash"
+)
+
+// journalMigrations upgrades older journals; see migrateJSON
+var journalMigrations = []jsonMigration{
+	// 0 → 1: the schema version itself was introduced
+	func(map[string]json.RawMessage) error { return nil },
+}
+
+// ErrNothingToUndo is returned when the drive has no journaled run of the requested kind
+var ErrNothingToUndo = errors.New("nothing to undo")
+
+// UndoKind names the runs the journal can undo
+type UndoKind string
+
+const (
+	UndoSync    UndoKind = "sync"
+	UndoCleanup UndoKind = "cleanup"
+)
+
+// JournalOp is the kind of change a journal entry records
+type JournalOp string
+
+const (
+	JournalCopied  JournalOp = "copied"
+	JournalRenamed JournalOp = "renamed"
+	JournalRemoved JournalOp = "removed"
+)
+
+// JournalEntry records one change to an episode file. Companion files follow their episode and aren't journaled.
+type JournalEntry struct {
+	Op JournalOp `json:"op"`
+	// Path below the podcasts folder, as the change left it
+	Path string `json:"path"`
+	// For renames the previous path; for removals where the file waits in the trash
+	From string `json:"from,omitempty"`
+	// The manifest entry a removal dropped, put back on undo
+	Manifest *ManifestEntry `json:"manifest,omitempty"`
+	// For copies the file's size and modification time once the sync finished with it,
+	// so undo can tell a file changed since
+	Size    int64     `json:"size,omitempty"`
+	ModTime time.Time `json:"modTime,omitzero"`
+}
+
+// JournalRun is what one sync or cleanup did to a drive, in order. Recording on a nil *JournalRun does nothing.
+type JournalRun struct {
+	At      time.Time      `json:"at"`
+	Entries []JournalEntry `json:"entries"`
+
+	dir string
+	// Guards Entries while files are copied at once
+	mu sync.Mutex
+}
+
+// Count returns how many entries of op the run recorded
+func (r *JournalRun) Count(op JournalOp) int {
+	var n int
+	for _, e := range r.Entries {
+		if e.Op == op {
+			n++
+		}
+	}
+	return n
+}
+
+func newJournalRun(dir string) *JournalRun {
+	return &JournalRun{At: time.Now(), dir: dir}
+}
+
+// record adds an entry for the file now at path; from is the rename source or trash location
+func (r *JournalRun) record(op JournalOp, path, from string, entry *ManifestEntry) {
+	if r == nil {
+		return
+	}
+	e := JournalEntry{Op: op, Path: r.rel(path), Manifest: entry}
+	if from != "" {
+		e.From = r.rel(from)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Entries = append(r.Entries, e)
+}
+
+// stamp notes the size and modification time of each copied file as the run left it
+func (r *JournalRun) stamp() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, e := range r.Entries {
+		if e.Op != JournalCopied {
+			continue
+		}
+		if info, err := os.Stat(filepath.Join(r.dir, filepath.FromSlash(e.Path))); err == nil {
+			r.Entries[i].Size, r.Entries[i].ModTime = info.Size(), info.ModTime()
+		}
+	}
+}
+
+// changedSince reports whether the copied file at path no longer matches the stamp its entry recorded.
+// Entries from journals written before stamps were recorded match any file.
+func (e JournalEntry) changedSince(path string) bool {
+	if e.Size == 0 && e.ModTime.IsZero() {
+		return false
+	}
+	info, err := os.Stat(path)
+	return err != nil || info.Size() != e.Size || !info.ModTime().Equal(e.ModTime)
+}
+
+func (r *JournalRun) rel(path string) string {
+	rel, err := filepath.Rel(r.dir, path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// trash moves the file at path and its companions into the trash folder and records the removal
+func (r *JournalRun) trash(path string, entry *ManifestEntry) error {
+	trashed := filepath.Join(r.dir, TrashFolder, filepath.FromSlash(r.rel(path)))
+	if err := os.MkdirAll(filepath.Dir(trashed), 0o755); err != nil {
+		return err
+	}
+	if err := os.Rename(path, trashed); err != nil {
+		return err
+	}
+	moveCompanions(path, trashed)
+	r.record(JournalRemoved, path, trashed, entry)
+	return nil
+}
+
+// moveCompanions takes the companion files of the episode at from along to to
+func moveCompanions(from, to string) {
+	toCompanions := companionPaths(to)
+	for i, companion := range companionPaths(from) {
+		_ = os.Rename(companion, toCompanions[i])
+	}
+}
+
+// Journal holds the last sync and the last cleanup of a drive, so either can be undone
+type Journal struct {
+	Schema int                      `json:"schema"`
+	Last   map[UndoKind]*JournalRun `json:"last"`
+
+	dir string
+}
+
+// LoadJournal reads the journal in the podcasts folder dir. A missing journal yields an empty one.
+func LoadJournal(dir string) (*Journal, error) {
+	j := &Journal{Last: map[UndoKind]*JournalRun{}, dir: dir}
+	path := filepath.Join(dir, JournalFile)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return j, nil
+	}
+	if err != nil {
+		return j, fmt.Errorf("failed to read undo journal: %w", err)
+	}
+	data, err = migrateJSON(data, journalMigrations, "undo journal", path)
+	if err != nil {
+		return j, err
+	}
+	if err := json.Unmarshal(data, j); err != nil {
+		return j, fmt.Errorf("failed to parse undo journal %s: %w", path, err)
+	}
+	if j.Last == nil {
+		j.Last = map[UndoKind]*JournalRun{}
+	}
+	for _, run := range j.Last {
+		run.dir = dir
+	}
+	return j, nil
+}
+
+func (j *Journal) save() error {
+	path := filepath.Join(j.dir, JournalFile)
+	if len(j.Last) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to write undo journal: %w", err)
+		}
+		return nil
+	}
+
+	j.Schema = len(journalMigrations)
+	data, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode undo journal: %w", err)
+	}
+	// Written beside the journal and renamed over it, like the manifest
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write undo journal: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to write undo journal: %w", err)
+	}
+	return nil
+}
+
+// saveJournalRun stores run as the drive's last run of kind. A run that changed nothing keeps the
+// previous one, so undo always reverts the last run that did something.
+func saveJournalRun(dir string, kind UndoKind, run *JournalRun) error {
+	if run == nil || len(run.Entries) == 0 {
+		return nil
+	}
+	run.stamp()
+	j, err := LoadJournal(dir)
+	if err != nil {
+		return err
+	}
+	j.Last[kind] = run
+	return j.save()
+}
+
+// emptyTrash deletes the files kept for undoing the last cleanup in the podcasts folder dir
+func emptyTrash(dir string) error {
+	j, err := LoadJournal(dir)
+	if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(filepath.Join(dir, TrashFolder)); err != nil {
+		return fmt.Errorf("failed to empty trash: %w", err)
+	}
+	if _, ok := j.Last[UndoCleanup]; !ok {
+		return nil
+	}
+	delete(j.Last, UndoCleanup)
+	return j.save()
+}
+
+// Undo reverts the last run of kind on drive and drops it from the journal: a sync's copies are
+// removed and its renames reversed, a cleanup's files come back from the trash. Files changed
+// since are left alone, and entries pointing outside the podcasts folder are refused.
+// Returns the number of episode files undone.
+func (ps *PodcastSync) Undo(drive USBDrive, kind UndoKind) (int, error) {
+	podcastDir, err := podcastsRoot(drive)
+	if err != nil {
+		return 0, err
+	}
+	journal, err := LoadJournal(podcastDir)
+	if err != nil {
+		return 0, err
+	}
+	run := journal.Last[kind]
+	if run == nil {
+		return 0, ErrNothingToUndo
+	}
+	manifest, err := LoadManifest(podcastDir)
+	if err != nil {
+		return 0, err
+	}
+
+	ps.profile = drive.Profile
+	ps.driveName = drive.Name
+	ps.remote = drive.destination()
+	ps.runID = time.Now().UnixNano()
+
+	u := &undo{ps: ps, podcastDir: podcastDir, manifest: manifest, visitedDirs: map[string]bool{}}
+	var (
+		undone int
+		errs   []error
+	)
+	for i := len(run.Entries) - 1; i >= 0; i-- {
+		done, err := u.entry(run.Entries[i])
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if done {
+			undone++
+		}
+	}
+	return undone, u.finish(journal, kind, errs)
+}
+
+// undo reverts the entries of one journaled run in the podcasts folder
+type undo struct {
+	ps          *PodcastSync
+	podcastDir  string
+	manifest    *Manifest
+	visitedDirs map[string]bool
+}
+
+// entry reverts e, reporting whether its file was undone or left alone because it changed since
+func (u *undo) entry(e JournalEntry) (bool, error) {
+	path := filepath.Join(u.podcastDir, filepath.FromSlash(e.Path))
+	from := filepath.Join(u.podcastDir, filepath.FromSlash(e.From))
+	// The journal is a file on the drive like any other, so its paths aren't trusted
+	if !within(u.podcastDir, path) || (e.From != "" && !within(u.podcastDir, from)) {
+		return false, fmt.Errorf("%w: %s", ErrOutsidePodcastsFolder, e.Path)
+	}
+
+	switch e.Op {
+	case JournalCopied:
+		return u.copied(e, path)
+	case JournalRenamed:
+		return u.renamed(path, from)
+	case JournalRemoved:
+		return u.removed(e, path, from)
+	}
+	return false, nil
+}
+
+// copied removes a copy the sync added, unless it was replaced or rewritten since
+func (u *undo) copied(e JournalEntry, path string) (bool, error) {
+	if exists, _ := fileExists(path); !exists || e.changedSince(path) {
+		return false, nil
+	}
+	if err := checkInside(u.podcastDir, path); err != nil {
+		return false, err
+	}
+	if err := os.Remove(path); err != nil {
+		return false, err
+	}
+	for _, companion := range companionPaths(path) {
+		_ = os.Remove(companion)
+	}
+	if entry, ok := u.manifest.Entry(path); ok {
+		u.ps.record(HistoryRemoved, PodcastEpisode{ZTitle: entry.Title, ShowName: entry.Show, FilePath: path}, nil)
+	}
+	u.manifest.Remove(path)
+	for dir := filepath.Dir(path); within(u.podcastDir, dir); dir = filepath.Dir(dir) {
+		u.visitedDirs[dir] = true
+	}
+	return true, nil
+}
+
+// renamed moves a renamed file back, unless it is gone or its old name was taken since
+func (u *undo) renamed(path, from string) (bool, error) {
+	if exists, _ := fileExists(path); !exists {
+		return false, nil
+	}
+	if exists, _ := fileExists(from); exists {
+		return false, nil
+	}
+	if err := errors.Join(checkInside(u.podcastDir, path), checkInside(u.podcastDir, from)); err != nil {
+		return false, err
+	}
+	if err := os.Rename(path, from); err != nil {
+		return false, err
+	}
+	moveCompanions(path, from)
+	if entry, ok := u.manifest.Entry(path); ok {
+		u.manifest.Remove(path)
+		u.manifest.Set(from, entry)
+	}
+	return true, nil
+}
+
+// removed brings a removed file back from the trash
+func (u *undo) removed(e JournalEntry, path, from string) (bool, error) {
+	// An episode synced again since keeps its new copy
+	if exists, _ := fileExists(path); exists {
+		return false, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return false, err
+	}
+	if err := errors.Join(checkInside(u.podcastDir, path), checkInside(u.podcastDir, from)); err != nil {
+		return false, err
+	}
+	if err := os.Rename(from, path); err != nil {
+		return false, err
+	}
+	moveCompanions(from, path)
+	if e.Manifest != nil {
+		u.manifest.Set(path, *e.Manifest)
+	}
+	return true, nil
+}
+
+// finish saves the manifest and drive indexes and, unless anything failed, drops the run from the journal
+func (u *undo) finish(journal *Journal, kind UndoKind, errs []error) error {
+	if err := u.manifest.Save(); err != nil {
+		errs = append(errs, fmt.Errorf("failed to update drive manifest: %w", err))
+	}
+	if err := writeDriveIndexes(u.podcastDir, u.ps.profile); err != nil {
+		errs = append(errs, err)
+	}
+	u.ps.cleanupEmptyDirs(u.podcastDir, u.visitedDirs, &errs)
+	if err := u.ps.pushToDestination(u.podcastDir, nil); err != nil {
+		errs = append(errs, err)
+	}
+	if len(errs) > 0 {
+		// The run stays journaled so undo can be tried again
+		return errs[0]
+	}
+
+	if kind == UndoCleanup {
+		_ = os.RemoveAll(filepath.Join(u.podcastDir, TrashFolder))
+	}
+	delete(journal.Last, kind)
+	return journal.save()
+}
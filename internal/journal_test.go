@@ -0,0 +1,234 @@
+package internal
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUndo_Sync(t *testing.T) {
+	tempDir := t.TempDir()
+	drive := USBDrive{Name: "DRIVE", MountPath: filepath.Join(tempDir, "drive")}
+	var episodes []PodcastEpisode
+	for _, title := range []string{"One", "Two"} {
+		source := filepath.Join(tempDir, title+".mp3")
+		if err := os.WriteFile(source, []byte(title), 0o644); err != nil {
+			t.Fatalf("Failed to create source file: %v", err)
+		}
+		episodes = append(episodes, PodcastEpisode{ZTitle: title, ShowName: "Show", FilePath: "file://" + source, Selected: true})
+	}
+
+	ch := make(chan FileOp, 100)
+	NewPodcastSync().StartSync(episodes, drive, ChanSink(ch))
+	for range ch {
+	}
+	journal, err := LoadJournal(drive.MountPath)
+	if err != nil {
+		t.Fatalf("LoadJournal failed: %v", err)
+	}
+	if run := journal.Last[UndoSync]; run == nil || run.Count(JournalCopied) != 2 {
+		t.Fatalf("Expected the sync to journal 2 copies, got %+v", run)
+	}
+
+	undone, err := NewPodcastSync().Undo(drive, UndoSync)
+	if err != nil || undone != 2 {
+		t.Fatalf("Undo = %d, %v; want 2, nil", undone, err)
+	}
+	for _, episode := range episodes {
+		if _, err := os.Stat(filepath.Join(drive.MountPath, drive.Profile.EpisodePath(episode))); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be removed from the drive", episode.ZTitle)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(drive.MountPath, "Show")); !os.IsNotExist(err) {
+		t.Error("Expected the emptied show folder to be removed")
+	}
+	manifest, _ := LoadManifest(drive.MountPath)
+	if len(manifest.Entries) != 0 {
+		t.Errorf("Expected the manifest entries to be dropped, got %v", manifest.Entries)
+	}
+	if _, err := NewPodcastSync().Undo(drive, UndoSync); !errors.Is(err, ErrNothingToUndo) {
+		t.Errorf("Expected a second undo to have nothing to undo, got %v", err)
+	}
+}
+
+func TestUndo_SyncKeepsChangedFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	drive := USBDrive{Name: "DRIVE", MountPath: filepath.Join(tempDir, "drive")}
+	var episodes []PodcastEpisode
+	for _, title := range []string{"Kept", "Undone"} {
+		source := filepath.Join(tempDir, title+".mp3")
+		if err := os.WriteFile(source, []byte(title), 0o644); err != nil {
+			t.Fatalf("Failed to create source file: %v", err)
+		}
+		episodes = append(episodes, PodcastEpisode{ZTitle: title, ShowName: "Show", FilePath: "file://" + source, Selected: true})
+	}
+
+	ch := make(chan FileOp, 100)
+	NewPodcastSync().StartSync(episodes, drive, ChanSink(ch))
+	for range ch {
+	}
+	// Replaced by hand after the sync, so it is no longer the sync's copy
+	kept := filepath.Join(drive.MountPath, drive.Profile.EpisodePath(episodes[0]))
+	if err := os.WriteFile(kept, []byte("edited by hand"), 0o644); err != nil {
+		t.Fatalf("Failed to change %s: %v", kept, err)
+	}
+
+	undone, err := NewPodcastSync().Undo(drive, UndoSync)
+	if err != nil || undone != 1 {
+		t.Fatalf("Undo = %d, %v; want 1, nil", undone, err)
+	}
+	if _, err := os.Stat(kept); err != nil {
+		t.Errorf("Expected the changed file to be left alone, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(drive.MountPath, drive.Profile.EpisodePath(episodes[1]))); !os.IsNotExist(err) {
+		t.Error("Expected the unchanged copy to be removed")
+	}
+}
+
+func TestUndo_Cleanup(t *testing.T) {
+	podcastDir := t.TempDir()
+	drive := USBDrive{Name: "DRIVE", MountPath: podcastDir}
+	path := filepath.Join(podcastDir, "Show", "Episode.mp3")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("Failed to create show folder: %v", err)
+	}
+	for _, file := range []string{path, filepath.Join(podcastDir, "Show", "Episode.chapters.json")} {
+		if err := os.WriteFile(file, []byte("audio"), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", file, err)
+		}
+	}
+	manifest, _ := LoadManifest(podcastDir)
+	manifest.Set(path, ManifestEntry{Show: "Show", Title: "Episode", Size: 5})
+	if err := manifest.Save(); err != nil {
+		t.Fatalf("Failed to save manifest: %v", err)
+	}
+
+	ps := NewPodcastSync()
+	ps.SetDrive(drive)
+	result := ps.DeleteSelected([]PodcastEpisode{{ZTitle: "Episode", FilePath: path, Selected: true}})
+	if result.Error != nil {
+		t.Fatalf("DeleteSelected failed: %v", result.Error)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatal("Expected the episode to leave its folder")
+	}
+	if _, err := os.Stat(filepath.Join(podcastDir, TrashFolder, "Show", "Episode.mp3")); err != nil {
+		t.Fatalf("Expected the episode to wait in the trash: %v", err)
+	}
+	episodes, err := NewPodcastScanner(DirectoryTemplate{}).ScanDrive(drive, nil)
+	if err != nil || len(episodes) != 0 {
+		t.Errorf("Expected scans to skip the trash, got %v, %v", episodes, err)
+	}
+
+	undone, err := NewPodcastSync().Undo(drive, UndoCleanup)
+	if err != nil || undone != 1 {
+		t.Fatalf("Undo = %d, %v; want 1, nil", undone, err)
+	}
+	for _, file := range []string{path, filepath.Join(podcastDir, "Show", "Episode.chapters.json")} {
+		if _, err := os.Stat(file); err != nil {
+			t.Errorf("Expected %s to be restored: %v", filepath.Base(file), err)
+		}
+	}
+	manifest, _ = LoadManifest(podcastDir)
+	if entry, ok := manifest.Entry(path); !ok || entry.Title != "Episode" {
+		t.Errorf("Expected the manifest entry to be restored, got %+v", entry)
+	}
+	if _, err := os.Stat(filepath.Join(podcastDir, TrashFolder)); !os.IsNotExist(err) {
+		t.Error("Expected the trash to be removed")
+	}
+	if _, err := os.Stat(filepath.Join(podcastDir, JournalFile)); !os.IsNotExist(err) {
+		t.Error("Expected the emptied journal to be removed")
+	}
+}
+
+func TestUndo_CleanupKeepsPartialSidecar(t *testing.T) {
+	podcastDir := t.TempDir()
+	drive := USBDrive{Name: "DRIVE", MountPath: podcastDir}
+	partialPath := filepath.Join(podcastDir, "Show", "Episode.mp3"+partialSuffix)
+	if err := os.MkdirAll(filepath.Dir(partialPath), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(partialPath, []byte("aud"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := writePartialInfo(partialPath, PartialInfo{Source: "/library/episode.mp3", Size: 5}); err != nil {
+		t.Fatal(err)
+	}
+	// Only drives with a manifest keep what they remove in the trash
+	manifest, _ := LoadManifest(podcastDir)
+	manifest.Set(filepath.Join(podcastDir, "Show", "Other.mp3"), ManifestEntry{Show: "Show", Title: "Other"})
+	if err := manifest.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	ps := NewPodcastSync()
+	ps.SetDrive(drive)
+	if result := ps.DeleteSelected([]PodcastEpisode{{ZTitle: "Episode", FilePath: partialPath, Partial: true, Selected: true}}); result.Error != nil {
+		t.Fatalf("DeleteSelected failed: %v", result.Error)
+	}
+	if _, err := os.Stat(partialPath + ".json"); !os.IsNotExist(err) {
+		t.Error("Expected the sidecar to go to the trash with its partial file")
+	}
+
+	if _, err := NewPodcastSync().Undo(drive, UndoCleanup); err != nil {
+		t.Fatalf("Undo failed: %v", err)
+	}
+	if info, err := ReadPartialInfo(partialPath); err != nil || info.Size != 5 {
+		t.Errorf("Expected the sidecar to be restored with its partial file, got %+v, %v", info, err)
+	}
+}
+
+func TestUndo_Renames(t *testing.T) {
+	podcastDir := t.TempDir()
+	drive := USBDrive{MountPath: podcastDir}
+	path := filepath.Join(podcastDir, "Show", "A.mp3")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("Failed to create show folder: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("A"), 0o644); err != nil {
+		t.Fatalf("Failed to write episode: %v", err)
+	}
+
+	run := newJournalRun(podcastDir)
+	profile := DriveProfile{IndexPrefix: true, CustomOrder: []string{"Show/A.mp3"}}
+	if err := RenumberEpisodes(podcastDir, profile, nil, run); err != nil {
+		t.Fatalf("RenumberEpisodes failed: %v", err)
+	}
+	if err := saveJournalRun(podcastDir, UndoSync, run); err != nil {
+		t.Fatalf("saveJournalRun failed: %v", err)
+	}
+
+	if _, err := NewPodcastSync().Undo(drive, UndoSync); err != nil {
+		t.Fatalf("Undo failed: %v", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("Expected the episode to get its old name back: %v", err)
+	}
+}
+
+func TestEmptyTrash(t *testing.T) {
+	podcastDir := t.TempDir()
+	trashed := filepath.Join(podcastDir, TrashFolder, "Show", "Episode.mp3")
+	if err := os.MkdirAll(filepath.Dir(trashed), 0o755); err != nil {
+		t.Fatalf("Failed to create trash: %v", err)
+	}
+	if err := os.WriteFile(trashed, []byte("audio"), 0o644); err != nil {
+		t.Fatalf("Failed to write trashed episode: %v", err)
+	}
+	run := newJournalRun(podcastDir)
+	run.record(JournalRemoved, filepath.Join(podcastDir, "Show", "Episode.mp3"), trashed, nil)
+	if err := saveJournalRun(podcastDir, UndoCleanup, run); err != nil {
+		t.Fatalf("saveJournalRun failed: %v", err)
+	}
+
+	if err := emptyTrash(podcastDir); err != nil {
+		t.Fatalf("emptyTrash failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(podcastDir, TrashFolder)); !os.IsNotExist(err) {
+		t.Error("Expected the trash to be deleted")
+	}
+	if _, err := (&PodcastSync{}).Undo(USBDrive{MountPath: podcastDir}, UndoCleanup); !errors.Is(err, ErrNothingToUndo) {
+		t.Errorf("Expected the cleanup to no longer be undoable, got %v", err)
+	}
+}
@@ -0,0 +1,80 @@
+package internal
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+)
+
+// FolderLayout is how episodes are arranged in a drive's podcasts folder, set per drive profile
+type FolderLayout string
+
+const (
+	LayoutShow  FolderLayout = ""      // Show/episode
+	LayoutFlat  FolderLayout = "flat"  // Show - episode, all in the podcasts folder
+	LayoutYear  FolderLayout = "year"  // Show/2024/episode
+	LayoutGenre FolderLayout = "genre" // Genre/Show/episode
+)
+
+// flatEpisodeFormat names episodes in the flat layout, where the file name has to carry the show
+const flatEpisodeFormat = "{show} - {date} - {title}"
+
+// unknownGenre is the genre folder for shows the library has no category for
+const unknownGenre = "Other"
+
+func (l FolderLayout) validate() error {
+	switch l {
+	case LayoutShow, LayoutFlat, LayoutYear, LayoutGenre:
+		return nil
+	}
+	return fmt.Errorf("invalid layout %q: must be \"flat\", \"year\" or \"genre\"", l)
+}
+
+// depth is the number of path components an episode takes below the podcasts folder
+func (l FolderLayout) depth() int {
+	switch l {
+	case LayoutFlat:
+		return 1
+	case LayoutYear, LayoutGenre:
+		return 3
+	default:
+		return 2
+	}
+}
+
+// episodeFormat is the file name template of episodes in this layout
+func (l FolderLayout) episodeFormat() string {
+	if l == LayoutFlat {
+		return flatEpisodeFormat
+	}
+	return defaultDirTemplate.EpisodeFormat
+}
+
+// RelPath returns where episode is stored below a drive's podcasts folder with this layout's names
+func (l FolderLayout) RelPath(episode PodcastEpisode) string {
+	return DriveProfile{Layout: l}.RelPath(episode)
+}
+
+// RelPath returns where episode is stored below the drive's podcasts folder, named after the profile's
+// template, without the index prefix EpisodePath may add
+func (p DriveProfile) RelPath(episode PodcastEpisode) string {
+	t := p.directoryTemplate()
+	name := formatEpisodeNameAs(episode, t)
+	switch p.Layout {
+	case LayoutFlat:
+		return name
+	case LayoutYear:
+		return filepath.Join(t.showFolder(episode.ShowName), strconv.Itoa(episode.Published.UTC().Year()), name)
+	case LayoutGenre:
+		return filepath.Join(genreFolder(episode.Genre), t.showFolder(episode.ShowName), name)
+	default:
+		return filepath.Join(t.showFolder(episode.ShowName), name)
+	}
+}
+
+func genreFolder(genre string) string {
+	if genre = sanitizeName(genre); genre == "" {
+		return unknownGenre
+	}
+	return genre
+}
@@ -0,0 +1,139 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFolderLayout_RelPath(t *testing.T) {
+	episode := PodcastEpisode{
+		ZTitle:    "Episode Title",
+		ShowName:  "Podcast Show",
+		Genre:     "Science",
+		Published: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC),
+		FilePath:  "/path/to/file.mp3",
+	}
+	special := PodcastEpisode{
+		ZTitle:    "Episode: Test/File?",
+		ShowName:  "Show & Name",
+		Published: time.Date(2024, 3, 20, 0, 0, 0, 0, time.UTC),
+		FilePath:  "/path/to/file.mp3",
+	}
+	// New Year's Eve in New York is already New Year's Day in UTC
+	eastern := PodcastEpisode{
+		ZTitle:    "Countdown",
+		ShowName:  "Podcast Show",
+		Published: time.Date(2023, 12, 31, 20, 0, 0, 0, time.FixedZone("EST", -5*60*60)),
+		FilePath:  "/path/to/file.mp3",
+	}
+
+	tests := []struct {
+		name     string
+		layout   FolderLayout
+		episode  PodcastEpisode
+		expected string
+	}{
+		{"show", LayoutShow, episode, filepath.Join("Podcast Show", "2024-01-15 - Episode Title.mp3")},
+		{"special characters", LayoutShow, special, filepath.Join("Show and Name", "2024-03-20 - Episode- Test-File.mp3")},
+		{"flat", LayoutFlat, episode, "Podcast Show - 2024-01-15 - Episode Title.mp3"},
+		{"year", LayoutYear, episode, filepath.Join("Podcast Show", "2024", "2024-01-15 - Episode Title.mp3")},
+		{"genre", LayoutGenre, episode, filepath.Join("Science", "Podcast Show", "2024-01-15 - Episode Title.mp3")},
+		{"genre unknown", LayoutGenre, special, filepath.Join("Other", "Show and Name", "2024-03-20 - Episode- Test-File.mp3")},
+		{"UTC date", LayoutYear, eastern, filepath.Join("Podcast Show", "2024", "2024-01-01 - Countdown.mp3")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.layout.RelPath(tt.episode); got != tt.expected {
+				t.Errorf("RelPath() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestScanDrive_Layouts(t *testing.T) {
+	library := []PodcastEpisode{
+		{ZTitle: "First", ShowName: "Daily - News", Genre: "News", Published: time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC), FilePath: "file:///library/first.mp3", FileSize: 1000},
+		{ZTitle: "Second", ShowName: "Science Hour", Published: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), FilePath: "file:///library/second.mp3", FileSize: 1000},
+	}
+
+	for _, layout := range []FolderLayout{LayoutShow, LayoutFlat, LayoutYear, LayoutGenre} {
+		t.Run(string(layout), func(t *testing.T) {
+			drive := USBDrive{Name: "DRIVE", MountPath: t.TempDir(), Folder: "podcasts", Profile: DriveProfile{Layout: layout}}
+			for _, episode := range library {
+				path := filepath.Join(drive.MountPath, drive.Folder, layout.RelPath(episode))
+				if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+					t.Fatalf("Failed to create folder: %v", err)
+				}
+				if err := os.WriteFile(path, make([]byte, episode.FileSize), 0o644); err != nil {
+					t.Fatalf("Failed to write episode: %v", err)
+				}
+			}
+
+			// Equal sizes leave the path as the only way to tell the episodes apart
+			local := append([]PodcastEpisode(nil), library...)
+			bySize := map[int64][]*PodcastEpisode{1000: {&local[0], &local[1]}}
+			scanned, err := NewPodcastScanner(DirectoryTemplate{}).ScanDrive(drive, bySize)
+			if err != nil {
+				t.Fatalf("ScanDrive failed: %v", err)
+			}
+			if len(scanned) != 2 {
+				t.Fatalf("Expected 2 episodes, got %d", len(scanned))
+			}
+			for _, episode := range scanned {
+				want := library[0]
+				if episode.ZTitle != want.ZTitle {
+					want = library[1]
+				}
+				if episode.ZTitle != want.ZTitle || episode.ShowName != want.ShowName || !episode.Published.Equal(want.Published) {
+					t.Errorf("Expected %s/%s, got %+v", want.ShowName, want.ZTitle, episode)
+				}
+			}
+			if !local[0].OnDrive || !local[1].OnDrive {
+				t.Error("Expected both library episodes to be marked on the drive")
+			}
+		})
+	}
+}
+
+func TestLoadConfig_InvalidLayout(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"drives": {"CAR": {"layout": "decade"}}}`), 0o644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if _, err := LoadConfig(path); err == nil {
+		t.Error("Expected an error for an unknown layout")
+	}
+}
+
+func TestPodcastSync_DeleteSelected_NestedLayout(t *testing.T) {
+	podcastDir := filepath.Join(t.TempDir(), "podcasts")
+	episode := PodcastEpisode{ZTitle: "Old", ShowName: "Show", Published: time.Date(2022, 5, 1, 0, 0, 0, 0, time.UTC), FilePath: "file:///library/old.mp3"}
+	path := filepath.Join(podcastDir, LayoutYear.RelPath(episode))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("Failed to create folder: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("audio"), 0o644); err != nil {
+		t.Fatalf("Failed to write episode: %v", err)
+	}
+	manifest, _ := LoadManifest(podcastDir)
+	manifest.Set(path, ManifestEntry{Show: episode.ShowName, Title: episode.ZTitle})
+	if err := manifest.Save(); err != nil {
+		t.Fatalf("Failed to save manifest: %v", err)
+	}
+
+	episode.FilePath = path
+	episode.Selected = true
+	ps := NewPodcastSync()
+	ps.SetDrive(USBDrive{MountPath: filepath.Dir(podcastDir), Folder: "podcasts"})
+	if op := ps.DeleteSelected([]PodcastEpisode{episode}); op.Error != nil {
+		t.Fatalf("DeleteSelected failed: %v", op.Error)
+	}
+	if _, err := os.Stat(filepath.Join(podcastDir, "Show")); !os.IsNotExist(err) {
+		t.Errorf("Expected the emptied year and show folders to be removed, got %v", err)
+	}
+	if _, err := os.Stat(podcastDir); err != nil {
+		t.Errorf("Expected the podcasts folder to stay: %v", err)
+	}
+}
@@ -0,0 +1,113 @@
+package internal
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// Language is the UI language set in the config. The default is English with ISO dates.
+type Language string
+
+const (
+	LanguageDefault Language = ""
+	LanguageEnglish Language = "en"
+	LanguageGerman  Language = "de"
+	LanguageFrench  Language = "fr"
+	LanguageSpanish Language = "es"
+)
+
+// canonicalDateFormat is how dates appear in file names and tags whatever the language,
+// so drives synced under one language are matched under another
+const canonicalDateFormat = "2006-01-02"
+
+// dateFormats are the layouts FormatDate renders for each language
+var dateFormats = map[Language]string{
+	LanguageDefault: canonicalDateFormat,
+	LanguageEnglish: "Jan 2, 2006",
+	LanguageGerman:  "02.01.2006",
+	LanguageFrench:  "02/01/2006",
+	LanguageSpanish: "02/01/2006",
+}
+
+// catalogs translate UI strings, keyed by the English text. Strings missing from a catalog stay English.
+var catalogs = map[Language]map[string]string{
+	LanguageGerman: {
+		"Mac Podcasts":    "Mac-Podcasts",
+		"Drive Podcasts":  "Podcasts auf dem Laufwerk",
+		"USB Drives":      "USB-Laufwerke",
+		"Quick Lists":     "Schnelllisten",
+		"Search":          "Suche",
+		"not downloaded":  "nicht geladen",
+		"downloading…":    "wird geladen…",
+		"copy unfinished": "Kopie unvollständig",
+		"Are you sure you want to delete the selected file(s)?": "Die ausgewählten Dateien wirklich löschen?",
+		"Cancel sync?":          "Synchronisierung abbrechen?",
+		"Undo on %s":            "Rückgängig auf %s",
+		"Nothing to undo on %s": "Auf %s gibt es nichts rückgängig zu machen",
+	},
+	LanguageFrench: {
+		"Mac Podcasts":    "Podcasts du Mac",
+		"Drive Podcasts":  "Podcasts du disque",
+		"USB Drives":      "Disques USB",
+		"Quick Lists":     "Listes rapides",
+		"Search":          "Recherche",
+		"not downloaded":  "non téléchargé",
+		"downloading…":    "téléchargement…",
+		"copy unfinished": "copie inachevée",
+		"Are you sure you want to delete the selected file(s)?": "Supprimer les fichiers sélectionnés ?",
+		"Cancel sync?":          "Annuler la synchronisation ?",
+		"Undo on %s":            "Annuler sur %s",
+		"Nothing to undo on %s": "Rien à annuler sur %s",
+	},
+	LanguageSpanish: {
+		"Mac Podcasts":    "Podcasts del Mac",
+		"Drive Podcasts":  "Podcasts de la unidad",
+		"USB Drives":      "Unidades USB",
+		"Quick Lists":     "Listas rápidas",
+		"Search":          "Buscar",
+		"not downloaded":  "no descargado",
+		"downloading…":    "descargando…",
+		"copy unfinished": "copia sin terminar",
+		"Are you sure you want to delete the selected file(s)?": "¿Eliminar los archivos seleccionados?",
+		"Cancel sync?":          "¿Cancelar la sincronización?",
+		"Undo on %s":            "Deshacer en %s",
+		"Nothing to undo on %s": "Nada que deshacer en %s",
+	},
+}
+
+// language is the active UI language, set once at startup by SetLanguage
+var language atomic.Value
+
+func (l Language) validate() error {
+	if _, ok := dateFormats[l]; ok {
+		return nil
+	}
+	return fmt.Errorf("invalid language %q: must be \"en\", \"de\", \"fr\" or \"es\"", l)
+}
+
+// SetLanguage switches the UI strings and dates to lang. Unknown languages fall back to the default.
+func SetLanguage(lang Language) {
+	if lang.validate() != nil {
+		lang = LanguageDefault
+	}
+	language.Store(lang)
+}
+
+func currentLanguage() Language {
+	lang, _ := language.Load().(Language)
+	return lang
+}
+
+// T translates the English UI string msg into the active language
+func T(msg string) string {
+	if translated, ok := catalogs[currentLanguage()][msg]; ok {
+		return translated
+	}
+	return msg
+}
+
+// FormatDate renders t as a calendar date in the active language. File names keep canonicalDateFormat.
+func FormatDate(t time.Time) string {
+	return t.Format(dateFormats[currentLanguage()])
+}
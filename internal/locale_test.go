@@ -0,0 +1,58 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLocale(t *testing.T) {
+	t.Cleanup(func() { SetLanguage(LanguageDefault) })
+	published := time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		lang  Language
+		date  string
+		title string
+	}{
+		{LanguageDefault, "2024-03-05", "USB Drives"},
+		{LanguageEnglish, "Mar 5, 2024", "USB Drives"},
+		{LanguageGerman, "05.03.2024", "USB-Laufwerke"},
+		{LanguageFrench, "05/03/2024", "Disques USB"},
+		{"xx", "2024-03-05", "USB Drives"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.lang), func(t *testing.T) {
+			SetLanguage(tt.lang)
+			if got := FormatDate(published); got != tt.date {
+				t.Errorf("FormatDate = %q, want %q", got, tt.date)
+			}
+			if got := T("USB Drives"); got != tt.title {
+				t.Errorf("T = %q, want %q", got, tt.title)
+			}
+			if got := T("A string nobody translated"); got != "A string nobody translated" {
+				t.Errorf("Expected untranslated strings to stay English, got %q", got)
+			}
+		})
+	}
+
+	SetLanguage(LanguageGerman)
+	episode := PodcastEpisode{ZTitle: "Episode", ShowName: "Show", Published: published}
+	if got := episode.Description(); got != "Show • 05.03.2024" {
+		t.Errorf("Expected a localized description, got %q", got)
+	}
+	if got := formatEpisodeName(episode); got != "2024-03-05 - Episode" {
+		t.Errorf("Expected file names to keep ISO dates, got %q", got)
+	}
+}
+
+func TestLoadConfig_InvalidLanguage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"language": "klingon"}`), 0o644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if _, err := LoadConfig(path); err == nil {
+		t.Error("Expected an error for an unknown language")
+	}
+}
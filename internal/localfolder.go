@@ -0,0 +1,69 @@
+package internal
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// validLocalFolder checks that a profile syncing to a folder on this Mac names it by an absolute path
+func (p DriveProfile) validLocalFolder() error {
+	switch {
+	case p.LocalFolder == "":
+		return nil
+	case !filepath.IsAbs(p.LocalFolder):
+		return fmt.Errorf("invalid localFolder %q: must be an absolute path", p.LocalFolder)
+	case p.WebDAV != nil || p.Encrypt != nil || p.IPod:
+		return fmt.Errorf("invalid localFolder %q: can't be combined with webdav, encrypt or ipod", p.LocalFolder)
+	}
+	return nil
+}
+
+// localFolderDrives returns a drive for each profile with a local folder that exists, written
+// directly like a mounted drive
+func (dm *DriveManager) localFolderDrives() []USBDrive {
+	var drives []USBDrive
+	profiles := dm.profileSnapshot()
+	for _, name := range slices.Sorted(maps.Keys(profiles)) {
+		profile := profiles[name]
+		if profile.LocalFolder == "" {
+			continue
+		}
+		if info, err := os.Stat(profile.LocalFolder); err != nil || !info.IsDir() {
+			continue
+		}
+		drive := USBDrive{Name: name, MountPath: profile.LocalFolder, Folder: driveFolder(profile), Profile: profile}
+		drive.Capacity, drive.Free, _ = volumeSpace(profile.LocalFolder)
+		drives = append(drives, drive)
+	}
+	return drives
+}
+
+// AddLocalFolder adds a profile syncing to the folder at path, named after the folder, and returns
+// its name. A name already taken by another profile gets a number.
+func (c *Config) AddLocalFolder(path string) (string, error) {
+	if !filepath.IsAbs(path) {
+		return "", fmt.Errorf("invalid local folder %q: must be an absolute path", path)
+	}
+	path = filepath.Clean(path)
+	if c.Drives == nil {
+		c.Drives = map[string]DriveProfile{}
+	}
+	for name, profile := range c.Drives {
+		if profile.LocalFolder == path {
+			return name, nil
+		}
+	}
+	base := filepath.Base(path)
+	name := base
+	for i := 2; ; i++ {
+		if _, ok := c.Drives[name]; !ok {
+			break
+		}
+		name = fmt.Sprintf("%s %d", base, i)
+	}
+	c.Drives[name] = DriveProfile{LocalFolder: path}
+	return name, nil
+}
@@ -0,0 +1,344 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// ManifestFile is the name of the manifest kept in a drive's podcasts folder
+	ManifestFile     = ".podcasts-sync.json"
+	manifestLockFile = ".podcasts-sync.lock"
+	// A lock older than this is assumed to belong to a writer that crashed
+	manifestLockStale = 30 * time.Second
+)
+
+// manifestLockWait is how long Save waits for another writer to release the lock
+var manifestLockWait = 2 * time.Second
+
+// manifestMigrations upgrades older manifests; see migrateJSON
+var manifestMigrations = []jsonMigration{
+	// 0 → 1: the schema version itself was introduced
+	func(map[string]json.RawMessage) error { return nil },
+}
+
+// ErrManifestBusy is returned when another writer holds the manifest lock
+var ErrManifestBusy = errors.New("drive manifest is being written by another process")
+
+// ManifestEntry describes one episode file synced to the drive
+type ManifestEntry struct {
+	Show     string    `json:"show"`
+	Title    string    `json:"title"`
+	Size     int64     `json:"size"`
+	SyncedAt time.Time `json:"syncedAt"`
+	// Pinned episodes are kept by delete all and pruning
+	Pinned bool `json:"pinned,omitempty"`
+}
+
+// Manifest records the episodes synced to a drive, keyed by path relative to the podcasts folder.
+// Writers never overwrite each other: Save merges the entries changed through this value into
+// whatever is on disk, and every save bumps Version. Safe for concurrent use.
+type Manifest struct {
+	Schema  int                      `json:"schema"`
+	Version int64                    `json:"version"`
+	Writer  string                   `json:"writer,omitempty"`
+	Entries map[string]ManifestEntry `json:"entries"`
+
+	mu   sync.Mutex
+	dir  string
+	base int64 // Version on disk when last loaded or saved
+	// Entries set (non-nil) or removed (nil) since the last load or save
+	changed map[string]*ManifestEntry
+}
+
+// LoadManifest reads the manifest in dir. A missing manifest yields an empty one.
+func LoadManifest(dir string) (*Manifest, error) {
+	m := &Manifest{dir: dir, Entries: map[string]ManifestEntry{}, changed: map[string]*ManifestEntry{}}
+	disk, err := readManifest(dir)
+	if err != nil {
+		return m, err
+	}
+	m.Version, m.Writer, m.Entries, m.base = disk.Version, disk.Writer, disk.Entries, disk.Version
+	return m, nil
+}
+
+func readManifest(dir string) (*Manifest, error) {
+	m := &Manifest{Entries: map[string]ManifestEntry{}}
+	data, err := os.ReadFile(filepath.Join(dir, ManifestFile))
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return m, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	data, err = migrateJSON(data, manifestMigrations, "drive manifest", filepath.Join(dir, ManifestFile))
+	if err != nil {
+		return m, err
+	}
+	if err := json.Unmarshal(data, m); err != nil {
+		return m, fmt.Errorf("failed to parse manifest %s: %w", filepath.Join(dir, ManifestFile), err)
+	}
+	if m.Entries == nil {
+		m.Entries = map[string]ManifestEntry{}
+	}
+	return m, nil
+}
+
+// Set records the entry for the file at path, which must be inside the manifest's folder.
+// Setting or removing entries on a nil *Manifest does nothing.
+func (m *Manifest) Set(path string, entry ManifestEntry) {
+	if m == nil {
+		return
+	}
+	rel, ok := m.rel(path)
+	if !ok {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Entries[rel] = entry
+	m.changed[rel] = &entry
+}
+
+// Remove drops the entry for the file at path
+func (m *Manifest) Remove(path string) {
+	if m == nil {
+		return
+	}
+	rel, ok := m.rel(path)
+	if !ok {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.Entries, rel)
+	m.changed[rel] = nil
+}
+
+// Move re-keys the entry for the file at from to to. When from is a folder, the entries of
+// every file below it move along.
+func (m *Manifest) Move(from, to string) {
+	if m == nil {
+		return
+	}
+	fromRel, ok := m.rel(from)
+	if !ok {
+		return
+	}
+	toRel, ok := m.rel(to)
+	if !ok {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	moved := make(map[string]ManifestEntry)
+	for rel, entry := range m.Entries {
+		if rest, found := strings.CutPrefix(rel, fromRel); found && (rest == "" || strings.HasPrefix(rest, "/")) {
+			moved[rel] = entry
+		}
+	}
+	for rel, entry := range moved {
+		delete(m.Entries, rel)
+		m.changed[rel] = nil
+		newRel := toRel + strings.TrimPrefix(rel, fromRel)
+		m.Entries[newRel] = entry
+		m.changed[newRel] = &entry
+	}
+}
+
+// SetPinned pins or unpins episode in the manifest of drive. An episode copied to the drive by
+// other means gets a manifest entry so it can be pinned.
+func SetPinned(drive USBDrive, episode PodcastEpisode, pinned bool) error {
+	root, err := podcastsRoot(drive)
+	if err != nil {
+		return err
+	}
+	if err := checkInside(root, episode.FilePath); err != nil {
+		return err
+	}
+	manifest, err := LoadManifest(root)
+	if err != nil {
+		return err
+	}
+	entry, ok := manifest.Entry(episode.FilePath)
+	if !ok {
+		if !pinned {
+			return nil
+		}
+		entry = ManifestEntry{Show: episode.ShowName, Title: episode.ZTitle, Size: episode.FileSize}
+	}
+	entry.Pinned = pinned
+	manifest.Set(episode.FilePath, entry)
+	if err := manifest.Save(); err != nil {
+		return fmt.Errorf("failed to update drive manifest: %w", err)
+	}
+	return nil
+}
+
+// markPinned sets Pinned on the episodes the manifest in dir pins
+func markPinned(dir string, episodes []PodcastEpisode) {
+	manifest, err := LoadManifest(dir)
+	if err != nil {
+		return
+	}
+	for i := range episodes {
+		if entry, ok := manifest.Entry(episodes[i].FilePath); ok {
+			episodes[i].Pinned = entry.Pinned
+		}
+	}
+}
+
+// Entry returns the entry for the file at path
+func (m *Manifest) Entry(path string) (ManifestEntry, bool) {
+	if m == nil {
+		return ManifestEntry{}, false
+	}
+	rel, ok := m.rel(path)
+	if !ok {
+		return ManifestEntry{}, false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.Entries[rel]
+	return entry, ok
+}
+
+func (m *Manifest) rel(path string) (string, bool) {
+	rel, err := filepath.Rel(m.dir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// Save writes the manifest under an exclusive lock. If another writer saved since this
+// manifest was loaded, its entries are kept and only the entries changed here are applied on top.
+// Returns ErrManifestBusy if the lock stays held by a live writer.
+func (m *Manifest) Save() error {
+	if m == nil {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.changed) == 0 {
+		return nil
+	}
+
+	unlock, err := lockManifest(m.dir)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	disk, err := readManifest(m.dir)
+	if err != nil {
+		return err
+	}
+	if disk.Version != m.base {
+		// Someone else saved in between: merge per file instead of overwriting their entries
+		for rel, entry := range m.changed {
+			if entry == nil {
+				delete(disk.Entries, rel)
+			} else {
+				disk.Entries[rel] = *entry
+			}
+		}
+		m.Entries = disk.Entries
+	}
+
+	m.Schema = len(manifestMigrations)
+	m.Version = disk.Version + 1
+	m.Writer = writerID()
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+
+	// Write to a temp file and rename so readers never see a half-written manifest
+	path := filepath.Join(m.dir, ManifestFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	m.base = m.Version
+	m.changed = map[string]*ManifestEntry{}
+	return nil
+}
+
+// lockManifest takes the manifest lock in dir, breaking locks left behind by crashed writers
+func lockManifest(dir string) (func(), error) {
+	path := filepath.Join(dir, manifestLockFile)
+	deadline := time.Now().Add(manifestLockWait)
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err == nil {
+			_, _ = f.WriteString(writerID())
+			f.Close()
+			return func() { _ = os.Remove(path) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("failed to lock manifest: %w", err)
+		}
+
+		// FAT stores modification times in local time, so a lock written in another timezone
+		// can look hours old or hours in the future; either way it isn't a live writer's
+		info, statErr := os.Stat(path)
+		if statErr == nil && time.Since(info.ModTime()).Abs() > manifestLockStale {
+			_ = os.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			holder, _ := os.ReadFile(path)
+			return nil, fmt.Errorf("%w (%s)", ErrManifestBusy, strings.TrimSpace(string(holder)))
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// writerID identifies this process in the manifest and its lock
+func writerID() string {
+	host, _ := os.Hostname()
+	return fmt.Sprintf("podcasts-sync pid %d on %s", os.Getpid(), host)
+}
+
+// findManifestDir returns the closest directory above path that holds a manifest
+func findManifestDir(path string) (string, bool) {
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(filepath.Join(dir, ManifestFile)); err == nil {
+			return dir, true
+		}
+		if parent := filepath.Dir(dir); parent == dir {
+			return "", false
+		}
+	}
+}
+
+// namedEpisodes scans the episodes in podcastDir, dated by their file names and named after their
+// manifest entries where there are any, since file names lose characters drives can't store
+func namedEpisodes(podcastDir string) ([]PodcastEpisode, error) {
+	episodes, err := NewPodcastScanner(DirectoryTemplate{}).ScanDrive(USBDrive{MountPath: podcastDir}, nil)
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := LoadManifest(podcastDir)
+	if err != nil {
+		return nil, err
+	}
+	for i := range episodes {
+		if entry, ok := manifest.Entry(episodes[i].FilePath); ok {
+			episodes[i].ShowName, episodes[i].ZTitle = entry.Show, entry.Title
+		}
+	}
+	return episodes, nil
+}
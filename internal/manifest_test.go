@@ -0,0 +1,252 @@
+package internal
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestManifest_SaveAndLoad(t *testing.T) {
+	dir := t.TempDir()
+	m, err := LoadManifest(dir)
+	if err != nil {
+		t.Fatalf("Failed to load missing manifest: %v", err)
+	}
+
+	episodePath := filepath.Join(dir, "Show", "episode.mp3")
+	m.Set(episodePath, ManifestEntry{Show: "Show", Title: "Episode", Size: 42})
+	m.Set(filepath.Join(filepath.Dir(dir), "outside.mp3"), ManifestEntry{Title: "Outside"})
+	if err := m.Save(); err != nil {
+		t.Fatalf("Failed to save manifest: %v", err)
+	}
+
+	loaded, err := LoadManifest(dir)
+	if err != nil {
+		t.Fatalf("Failed to reload manifest: %v", err)
+	}
+	if loaded.Version != 1 || len(loaded.Entries) != 1 {
+		t.Errorf("Expected version 1 with one entry, got version %d and %v", loaded.Version, loaded.Entries)
+	}
+	if entry, ok := loaded.Entry(episodePath); !ok || entry.Size != 42 {
+		t.Errorf("Expected the episode entry to round trip, got %+v", entry)
+	}
+	if _, ok := loaded.Entries["Show/episode.mp3"]; !ok {
+		t.Errorf("Expected entries keyed by slash-separated relative path, got %v", loaded.Entries)
+	}
+}
+
+func TestManifest_ConcurrentWritersMerge(t *testing.T) {
+	dir := t.TempDir()
+	seed, _ := LoadManifest(dir)
+	seed.Set(filepath.Join(dir, "Show", "old.mp3"), ManifestEntry{Title: "Old"})
+	seed.Set(filepath.Join(dir, "Show", "kept.mp3"), ManifestEntry{Title: "Kept"})
+	if err := seed.Save(); err != nil {
+		t.Fatalf("Failed to seed manifest: %v", err)
+	}
+
+	// Both writers load the same version, like the TUI and a watch-mode daemon would
+	tui, _ := LoadManifest(dir)
+	daemon, _ := LoadManifest(dir)
+
+	daemon.Set(filepath.Join(dir, "Show", "daemon.mp3"), ManifestEntry{Title: "From daemon"})
+	if err := daemon.Save(); err != nil {
+		t.Fatalf("Daemon save failed: %v", err)
+	}
+
+	tui.Set(filepath.Join(dir, "Show", "tui.mp3"), ManifestEntry{Title: "From TUI"})
+	tui.Remove(filepath.Join(dir, "Show", "old.mp3"))
+	if err := tui.Save(); err != nil {
+		t.Fatalf("TUI save failed: %v", err)
+	}
+
+	merged, err := LoadManifest(dir)
+	if err != nil {
+		t.Fatalf("Failed to load merged manifest: %v", err)
+	}
+	if merged.Version != 3 {
+		t.Errorf("Expected every save to bump the version, got %d", merged.Version)
+	}
+	for _, rel := range []string{"Show/kept.mp3", "Show/daemon.mp3", "Show/tui.mp3"} {
+		if _, ok := merged.Entries[rel]; !ok {
+			t.Errorf("Expected %s to survive the merge, got %v", rel, merged.Entries)
+		}
+	}
+	if _, ok := merged.Entries["Show/old.mp3"]; ok {
+		t.Error("Expected the removal to be merged")
+	}
+}
+
+func TestManifest_Lock(t *testing.T) {
+	previous := manifestLockWait
+	manifestLockWait = 100 * time.Millisecond
+	t.Cleanup(func() { manifestLockWait = previous })
+
+	dir := t.TempDir()
+	lockPath := filepath.Join(dir, manifestLockFile)
+	if err := os.WriteFile(lockPath, []byte("podcasts-sync pid 1 on other"), 0o644); err != nil {
+		t.Fatalf("Failed to create lock: %v", err)
+	}
+
+	m, _ := LoadManifest(dir)
+	m.Set(filepath.Join(dir, "episode.mp3"), ManifestEntry{Title: "Episode"})
+	err := m.Save()
+	if !errors.Is(err, ErrManifestBusy) {
+		t.Fatalf("Expected ErrManifestBusy while another writer holds the lock, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "pid 1 on other") {
+		t.Errorf("Expected the error to name the other writer, got %q", err)
+	}
+
+	// A lock left behind by a crashed writer is broken
+	stale := time.Now().Add(-2 * manifestLockStale)
+	if err := os.Chtimes(lockPath, stale, stale); err != nil {
+		t.Fatalf("Failed to age lock: %v", err)
+	}
+	if err := m.Save(); err != nil {
+		t.Fatalf("Expected a stale lock to be broken, got %v", err)
+	}
+	if _, err := os.Stat(lockPath); !os.IsNotExist(err) {
+		t.Error("Expected the lock to be released after saving")
+	}
+
+	// FAT drives written in a timezone ahead of ours put the lock in the future
+	if err := os.WriteFile(lockPath, []byte("podcasts-sync pid 1 on other"), 0o644); err != nil {
+		t.Fatalf("Failed to create lock: %v", err)
+	}
+	future := time.Now().Add(3 * time.Hour)
+	if err := os.Chtimes(lockPath, future, future); err != nil {
+		t.Fatalf("Failed to date lock: %v", err)
+	}
+	m.Set(filepath.Join(dir, "other.mp3"), ManifestEntry{Title: "Other"})
+	if err := m.Save(); err != nil {
+		t.Fatalf("Expected a lock dated hours ahead to be broken, got %v", err)
+	}
+}
+
+func TestPodcastSync_TracksManifest(t *testing.T) {
+	dir := t.TempDir()
+	srcPath := filepath.Join(t.TempDir(), "source.mp3")
+	if err := os.WriteFile(srcPath, []byte("audio"), 0o644); err != nil {
+		t.Fatalf("Failed to create source: %v", err)
+	}
+	destPath := filepath.Join(dir, "Show", "episode.mp3")
+	_ = os.MkdirAll(filepath.Dir(destPath), 0o755)
+
+	ps := NewPodcastSync()
+	ps.tm = NewTransferManager(5, 1, ChanSink(make(chan FileOp, 10)))
+	t.Cleanup(ps.tm.Stop)
+	ps.manifest, _ = LoadManifest(dir)
+
+	episode := PodcastEpisode{ZTitle: "Episode", ShowName: "Show", FileSize: 5}
+	if err := ps.copyEpisode(episode, srcPath, destPath); err != nil {
+		t.Fatalf("copyEpisode failed: %v", err)
+	}
+	if err := ps.manifest.Save(); err != nil {
+		t.Fatalf("Failed to save manifest: %v", err)
+	}
+	if entry, ok := ps.manifest.Entry(destPath); !ok || entry.Title != "Episode" {
+		t.Fatalf("Expected the copied episode in the manifest, got %+v", entry)
+	}
+
+	ps.SetDrive(USBDrive{MountPath: dir})
+	result := ps.DeleteSelected([]PodcastEpisode{{ZTitle: "Episode", FilePath: destPath, Selected: true}})
+	if result.Error != nil {
+		t.Fatalf("DeleteSelected failed: %v", result.Error)
+	}
+	loaded, _ := LoadManifest(dir)
+	if _, ok := loaded.Entry(destPath); ok {
+		t.Error("Expected the deleted episode to be removed from the manifest")
+	}
+}
+
+func TestPodcastSync_RefusesUnreadableManifest(t *testing.T) {
+	root := t.TempDir()
+	drive := USBDrive{Name: "DRIVE", MountPath: root}
+	onDrive := filepath.Join(root, "Show", "Old.mp3")
+	source := filepath.Join(t.TempDir(), "New.mp3")
+	for _, path := range []string{onDrive, source} {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create folder: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("audio"), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(root, ManifestFile), []byte("{not json"), 0o644); err != nil {
+		t.Fatalf("Failed to write manifest: %v", err)
+	}
+
+	ch := make(chan FileOp, 100)
+	episodes := []PodcastEpisode{{ZTitle: "New", ShowName: "Show", FilePath: "file://" + source, Selected: true}}
+	if tm := NewPodcastSync().StartSync(episodes, drive, ChanSink(ch)); tm != nil {
+		t.Error("Expected no transfer to a drive whose manifest can't be read")
+	}
+	var failure error
+	for op := range ch {
+		if op.Error != nil {
+			failure = op.Error
+		}
+	}
+	if failure == nil || !strings.Contains(failure.Error(), "failed to parse drive manifest") {
+		t.Errorf("Expected the manifest error, got %v", failure)
+	}
+
+	ps := NewPodcastSync()
+	ps.SetDrive(drive)
+	result := ps.DeleteSelected([]PodcastEpisode{{ZTitle: "Old", FilePath: onDrive, Selected: true}})
+	if result.Error == nil || !strings.Contains(result.Error.Error(), "failed to parse drive manifest") {
+		t.Errorf("Expected the manifest error from delete, got %v", result.Error)
+	}
+	if _, err := os.Stat(onDrive); err != nil {
+		t.Errorf("Expected the episode left in place, got %v", err)
+	}
+}
+
+func TestSetPinned(t *testing.T) {
+	root := t.TempDir()
+	drive := USBDrive{MountPath: root}
+	tracked := filepath.Join(root, "Show", "Tracked.mp3")
+	byHand := filepath.Join(root, "Show", "By hand.mp3")
+	for _, path := range []string{tracked, byHand} {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create show folder: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("audio"), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+	manifest, _ := LoadManifest(root)
+	manifest.Set(tracked, ManifestEntry{Show: "Show", Title: "Tracked", Size: 5})
+	if err := manifest.Save(); err != nil {
+		t.Fatalf("Failed to save manifest: %v", err)
+	}
+
+	for _, path := range []string{tracked, byHand} {
+		if err := SetPinned(drive, PodcastEpisode{ZTitle: filepath.Base(path), ShowName: "Show", FilePath: path}, true); err != nil {
+			t.Fatalf("SetPinned failed: %v", err)
+		}
+	}
+	episodes, err := NewPodcastScanner(DirectoryTemplate{}).ScanDrive(drive, nil)
+	if err != nil || len(episodes) != 2 {
+		t.Fatalf("ScanDrive = %v, %v; want 2 episodes", episodes, err)
+	}
+	for _, e := range episodes {
+		if !e.Pinned || !(*Config)(nil).Retains(e) {
+			t.Errorf("Expected %s to be pinned and kept by bulk deletes", e.FilePath)
+		}
+	}
+
+	if err := SetPinned(drive, PodcastEpisode{FilePath: tracked}, false); err != nil {
+		t.Fatalf("SetPinned failed: %v", err)
+	}
+	manifest, _ = LoadManifest(root)
+	if entry, _ := manifest.Entry(tracked); entry.Pinned || entry.Title != "Tracked" {
+		t.Errorf("Expected unpinning to keep the rest of the entry, got %+v", entry)
+	}
+	if err := SetPinned(drive, PodcastEpisode{FilePath: filepath.Join(filepath.Dir(root), "elsewhere.mp3")}, true); !errors.Is(err, ErrOutsidePodcastsFolder) {
+		t.Errorf("Expected pinning outside the podcasts folder to be refused, got %v", err)
+	}
+}
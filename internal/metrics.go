@@ -0,0 +1,163 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Metrics counts the work of watch mode per drive and serves it in the Prometheus text format
+type Metrics struct {
+	mu       sync.Mutex
+	drives   map[string]*driveMetrics
+	mounted  map[string]string // mount path of each drive seen by the last check
+	freeFunc func(path string) (int64, error)
+}
+
+type driveMetrics struct {
+	syncs, failures       int64
+	bytesCopied, episodes int64
+	scanSeconds           float64
+	scans                 int64
+}
+
+// NewMetrics creates empty metrics
+func NewMetrics() *Metrics {
+	return &Metrics{drives: make(map[string]*driveMetrics), mounted: make(map[string]string), freeFunc: freeSpace}
+}
+
+func (m *Metrics) drive(name string) *driveMetrics {
+	d, ok := m.drives[name]
+	if !ok {
+		d = &driveMetrics{}
+		m.drives[name] = d
+	}
+	return d
+}
+
+// setMounted records the drives found by a check; their free space is read when metrics are scraped
+func (m *Metrics) setMounted(drives []USBDrive) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	clear(m.mounted)
+	for _, d := range drives {
+		m.mounted[d.Name] = d.MountPath
+	}
+}
+
+func (m *Metrics) syncStarted(drive string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.drive(drive).syncs++
+}
+
+func (m *Metrics) syncFailed(drive string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.drive(drive).failures++
+}
+
+func (m *Metrics) copied(drive string, progress TransferProgress) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	d := m.drive(drive)
+	d.bytesCopied += progress.BytesTransferred
+	d.episodes += int64(progress.FilesDone)
+}
+
+func (m *Metrics) scanned(drive string, took time.Duration) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	d := m.drive(drive)
+	d.scanSeconds += took.Seconds()
+	d.scans++
+}
+
+// WriteTo writes the metrics in the Prometheus text exposition format
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	drives := make(map[string]driveMetrics, len(m.drives))
+	for name, d := range m.drives {
+		drives[name] = *d
+	}
+	mounted := maps.Clone(m.mounted)
+	m.mu.Unlock()
+
+	var b strings.Builder
+	family := func(name, kind, help string, value func(d driveMetrics) string) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+		for _, drive := range slices.Sorted(maps.Keys(drives)) {
+			fmt.Fprintf(&b, "%s{drive=%s} %s\n", name, quoteLabel(drive), value(drives[drive]))
+		}
+	}
+	count := func(n int64) string { return fmt.Sprint(n) }
+	family("podcasts_sync_syncs_total", "counter", "Automatic syncs started.", func(d driveMetrics) string { return count(d.syncs) })
+	family("podcasts_sync_failures_total", "counter", "Automatic syncs that failed.", func(d driveMetrics) string { return count(d.failures) })
+	family("podcasts_sync_copied_bytes_total", "counter", "Bytes copied to the drive.", func(d driveMetrics) string { return count(d.bytesCopied) })
+	family("podcasts_sync_copied_episodes_total", "counter", "Episodes copied to the drive.", func(d driveMetrics) string { return count(d.episodes) })
+
+	b.WriteString("# HELP podcasts_sync_scan_duration_seconds Time spent scanning the drive before syncing.\n# TYPE podcasts_sync_scan_duration_seconds summary\n")
+	for _, drive := range slices.Sorted(maps.Keys(drives)) {
+		d := drives[drive]
+		fmt.Fprintf(&b, "podcasts_sync_scan_duration_seconds_sum{drive=%s} %g\n", quoteLabel(drive), d.scanSeconds)
+		fmt.Fprintf(&b, "podcasts_sync_scan_duration_seconds_count{drive=%s} %d\n", quoteLabel(drive), d.scans)
+	}
+
+	b.WriteString("# HELP podcasts_sync_drive_free_bytes Free space on each mounted drive.\n# TYPE podcasts_sync_drive_free_bytes gauge\n")
+	for _, drive := range slices.Sorted(maps.Keys(mounted)) {
+		// Drives that can't be read, e.g. ejected since the last check, are left out
+		if free, err := m.freeFunc(mounted[drive]); err == nil {
+			fmt.Fprintf(&b, "podcasts_sync_drive_free_bytes{drive=%s} %d\n", quoteLabel(drive), free)
+		}
+	}
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// quoteLabel quotes a label value, escaping as the exposition format requires
+func quoteLabel(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = m.WriteTo(w)
+}
+
+// StartMetricsServer serves metrics at /metrics on addr. The server runs until the process exits.
+func StartMetricsServer(addr string, metrics *Metrics) (string, error) {
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", metrics)
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return "", fmt.Errorf("failed to start metrics server: %w", err)
+	}
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	return listener.Addr().String(), nil
+}
@@ -0,0 +1,58 @@
+package internal
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	tempDir := t.TempDir()
+	volumes := filepath.Join(tempDir, "Volumes")
+	if err := os.MkdirAll(filepath.Join(volumes, `My "CAR"`), 0o755); err != nil {
+		t.Fatalf("Failed to create drive: %v", err)
+	}
+	source := filepath.Join(tempDir, "episode.mp3")
+	if err := os.WriteFile(source, make([]byte, 2048), 0o644); err != nil {
+		t.Fatalf("Failed to create source file: %v", err)
+	}
+	library := func() ([]PodcastEpisode, error) {
+		return []PodcastEpisode{{ZTitle: "Morning", ShowName: "Commute", FilePath: "file://" + source, FileSize: 2048}}, nil
+	}
+	cfg := &Config{Shows: map[string]ShowPolicy{"Commute": {Sync: SyncAlways}}}
+
+	metrics := NewMetrics()
+	metrics.freeFunc = func(string) (int64, error) { return 1 << 30, nil }
+	w := NewWatcher(cfg, NewDriveManager(volumes, DirectoryTemplate{}), nil, library, log.New(io.Discard, "", 0))
+	w.SetMetrics(metrics)
+	w.Check()
+
+	addr, err := StartMetricsServer("127.0.0.1:0", metrics)
+	if err != nil {
+		t.Fatalf("Failed to start metrics server: %v", err)
+	}
+	resp, err := http.Get("http://" + addr + "/metrics")
+	if err != nil {
+		t.Fatalf("Failed to fetch metrics: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+
+	for _, want := range []string{
+		"# TYPE podcasts_sync_syncs_total counter",
+		`podcasts_sync_syncs_total{drive="My \"CAR\""} 1`,
+		`podcasts_sync_failures_total{drive="My \"CAR\""} 0`,
+		`podcasts_sync_copied_bytes_total{drive="My \"CAR\""} 2048`,
+		`podcasts_sync_copied_episodes_total{drive="My \"CAR\""} 1`,
+		`podcasts_sync_scan_duration_seconds_count{drive="My \"CAR\""} 1`,
+		`podcasts_sync_drive_free_bytes{drive="My \"CAR\""} 1073741824`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("Expected metrics to contain %s, got:\n%s", want, body)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// ErrMountWatcherClosed is returned by Wait once the watcher is closed
+var ErrMountWatcherClosed = errors.New("mount watcher closed")
+
+// mountSettle gathers a burst of changes to the volumes folder into one, since a mount can create its
+// folder before the volume is readable
+const mountSettle = 500 * time.Millisecond
+
+// MountWatcher reports drives being mounted and unmounted, from changes to the folder they're mounted in
+type MountWatcher struct {
+	watcher *fsnotify.Watcher
+}
+
+// WatchMounts starts watching the folder the drive manager finds drives in
+func (dm *DriveManager) WatchMounts() (*MountWatcher, error) {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch mounts: %w", err)
+	}
+	if err := w.Add(dm.volumesPath); err != nil {
+		_ = w.Close()
+		return nil, fmt.Errorf("failed to watch %s: %w", dm.volumesPath, err)
+	}
+	return &MountWatcher{watcher: w}, nil
+}
+
+// Wait blocks until a drive is mounted or unmounted, and returns once the volumes folder settles
+func (mw *MountWatcher) Wait() error {
+	var settle <-chan time.Time
+	for {
+		select {
+		case event, ok := <-mw.watcher.Events:
+			if !ok {
+				return ErrMountWatcherClosed
+			}
+			if event.Has(fsnotify.Create) || event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename) {
+				settle = time.After(mountSettle)
+			}
+		case err, ok := <-mw.watcher.Errors:
+			if !ok {
+				return ErrMountWatcherClosed
+			}
+			return fmt.Errorf("failed to watch mounts: %w", err)
+		case <-settle:
+			return nil
+		}
+	}
+}
+
+// Close stops watching; a Wait in progress returns ErrMountWatcherClosed
+func (mw *MountWatcher) Close() error {
+	return mw.watcher.Close()
+}
@@ -0,0 +1,50 @@
+package internal
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMountWatcher_Wait(t *testing.T) {
+	volumes := t.TempDir()
+	mw, err := NewDriveManager(volumes, DirectoryTemplate{}).WatchMounts()
+	if err != nil {
+		t.Fatalf("WatchMounts() failed: %v", err)
+	}
+
+	waited := make(chan error, 1)
+	go func() { waited <- mw.Wait() }()
+	if err := os.Mkdir(filepath.Join(volumes, "CAR"), 0o755); err != nil {
+		t.Fatalf("Failed to mount: %v", err)
+	}
+	select {
+	case err := <-waited:
+		if err != nil {
+			t.Errorf("Wait() failed: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected Wait to return after a mount")
+	}
+
+	go func() { waited <- mw.Wait() }()
+	if err := mw.Close(); err != nil {
+		t.Fatalf("Close() failed: %v", err)
+	}
+	select {
+	case err := <-waited:
+		if !errors.Is(err, ErrMountWatcherClosed) {
+			t.Errorf("Expected ErrMountWatcherClosed, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected Wait to return once closed")
+	}
+}
+
+func TestDriveManager_WatchMounts_MissingFolder(t *testing.T) {
+	if _, err := NewDriveManager(filepath.Join(t.TempDir(), "Volumes"), DirectoryTemplate{}).WatchMounts(); err == nil {
+		t.Error("Expected an error watching a folder that doesn't exist")
+	}
+}
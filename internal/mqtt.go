@@ -0,0 +1,240 @@
+package internal
+
+import (
+	"bufio"
+	"cmp"
+	"crypto/tls"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// DefaultMQTTTopic is the topic events are published below when the config doesn't set one
+const DefaultMQTTTopic = "podcasts-sync"
+
+// mqttTimeout bounds connecting to the broker and publishing an event, so a broker that is down
+// delays a sync by seconds at most
+const mqttTimeout = 10 * time.Second
+
+// MQTTSettings point watch mode at an MQTT broker, e.g. the one of Home Assistant
+type MQTTSettings struct {
+	// Broker is the broker's URL: tcp://host:1883, or mqtts://host:8883 for TLS
+	Broker   string `json:"broker"`
+	Topic    string `json:"topic,omitempty"`
+	User     string `json:"user,omitempty"`
+	Password string `json:"password,omitempty"`
+	// Retain keeps the last event of each type on the broker for clients that connect later
+	Retain bool `json:"retain,omitempty"`
+}
+
+func (m *MQTTSettings) validate() error {
+	if m == nil {
+		return nil
+	}
+	u, err := url.Parse(m.Broker)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("invalid mqtt broker %q: must be a URL such as tcp://host:1883", m.Broker)
+	}
+	switch u.Scheme {
+	case "tcp", "mqtt", "mqtts", "ssl", "tls":
+	default:
+		return fmt.Errorf("invalid mqtt broker %q: the scheme must be tcp or mqtts", m.Broker)
+	}
+	if strings.ContainsAny(m.Topic, "+#") {
+		return fmt.Errorf("invalid mqtt topic %q: wildcards can't be published to", m.Topic)
+	}
+	return nil
+}
+
+// MQTTPublisher publishes each event as JSON to <topic>/<event>, e.g. podcasts-sync/sync_finished.
+// Events are rare, so each is sent on its own connection rather than keeping one alive.
+type MQTTPublisher struct {
+	settings MQTTSettings
+	packetID uint16
+}
+
+// NewMQTTPublisher creates a publisher for the broker in settings
+func NewMQTTPublisher(settings MQTTSettings) *MQTTPublisher {
+	return &MQTTPublisher{settings: settings}
+}
+
+func (p *MQTTPublisher) Publish(event SyncEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	topic := cmp.Or(strings.TrimSuffix(p.settings.Topic, "/"), DefaultMQTTTopic) + "/" + string(event.Event)
+	if err := p.send(topic, payload); err != nil {
+		return fmt.Errorf("failed to publish %s to %s: %w", event.Event, p.settings.Broker, err)
+	}
+	return nil
+}
+
+func (p *MQTTPublisher) dial() (net.Conn, error) {
+	u, err := url.Parse(p.settings.Broker)
+	if err != nil {
+		return nil, err
+	}
+	dialer := &net.Dialer{Timeout: mqttTimeout}
+	switch u.Scheme {
+	case "mqtts", "ssl", "tls":
+		host := u.Host
+		if u.Port() == "" {
+			host = net.JoinHostPort(u.Hostname(), "8883")
+		}
+		return tls.DialWithDialer(dialer, "tcp", host, &tls.Config{ServerName: u.Hostname()})
+	}
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), "1883")
+	}
+	return dialer.Dial("tcp", host)
+}
+
+// send connects, publishes payload at QoS 1 and waits for the broker to acknowledge it
+func (p *MQTTPublisher) send(topic string, payload []byte) error {
+	conn, err := p.dial()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(mqttTimeout))
+	r := bufio.NewReader(conn)
+
+	if _, err := conn.Write(mqttConnect(p.settings.User, p.settings.Password)); err != nil {
+		return err
+	}
+	kind, body, err := readMQTTPacket(r)
+	if err != nil {
+		return err
+	}
+	if kind != 0x20 || len(body) != 2 {
+		return fmt.Errorf("unexpected reply to connect: packet type %d", kind>>4)
+	}
+	if body[1] != 0 {
+		return mqttConnectError(body[1])
+	}
+
+	p.packetID++
+	if p.packetID == 0 {
+		p.packetID = 1
+	}
+	if _, err := conn.Write(mqttPublish(topic, payload, p.packetID, p.settings.Retain)); err != nil {
+		return err
+	}
+	kind, body, err = readMQTTPacket(r)
+	if err != nil {
+		return err
+	}
+	if kind != 0x40 || len(body) != 2 || binary.BigEndian.Uint16(body) != p.packetID {
+		return fmt.Errorf("unexpected reply to publish: packet type %d", kind>>4)
+	}
+
+	_, _ = conn.Write([]byte{0xe0, 0})
+	return nil
+}
+
+func mqttConnectError(code byte) error {
+	switch code {
+	case 1:
+		return errors.New("the broker refused protocol version 3.1.1")
+	case 2:
+		return errors.New("the broker refused the client id")
+	case 3:
+		return errors.New("the broker is unavailable")
+	case 4:
+		return errors.New("the broker refused the user name or password")
+	case 5:
+		return errors.New("not authorized by the broker")
+	}
+	return fmt.Errorf("the broker refused the connection (code %d)", code)
+}
+
+// mqttConnect builds an MQTT 3.1.1 CONNECT packet with a clean session
+func mqttConnect(user, password string) []byte {
+	var flags byte = 0x02
+	if user != "" {
+		flags |= 0x80
+		if password != "" {
+			flags |= 0x40
+		}
+	}
+	body := mqttString(nil, "MQTT")
+	body = append(body, 4, flags, 0, 60)
+	body = mqttString(body, fmt.Sprintf("podcasts-sync-%d", os.Getpid()))
+	if user != "" {
+		body = mqttString(body, user)
+		if password != "" {
+			body = mqttString(body, password)
+		}
+	}
+	return mqttPacket(0x10, body)
+}
+
+// mqttPublish builds a QoS 1 PUBLISH packet
+func mqttPublish(topic string, payload []byte, id uint16, retain bool) []byte {
+	var kind byte = 0x32
+	if retain {
+		kind |= 0x01
+	}
+	body := mqttString(nil, topic)
+	body = binary.BigEndian.AppendUint16(body, id)
+	return mqttPacket(kind, append(body, payload...))
+}
+
+func mqttString(b []byte, s string) []byte {
+	b = binary.BigEndian.AppendUint16(b, uint16(len(s)))
+	return append(b, s...)
+}
+
+// mqttPacket prefixes body with the fixed header: the packet type and the remaining length
+func mqttPacket(kind byte, body []byte) []byte {
+	packet := []byte{kind}
+	n := len(body)
+	for {
+		digit := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			digit |= 0x80
+		}
+		packet = append(packet, digit)
+		if n == 0 {
+			break
+		}
+	}
+	return append(packet, body...)
+}
+
+// readMQTTPacket reads a packet, returning its first byte and the rest after the remaining length
+func readMQTTPacket(r *bufio.Reader) (byte, []byte, error) {
+	kind, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	var n, shift int
+	for {
+		digit, err := r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		n |= int(digit&0x7f) << shift
+		if digit&0x80 == 0 {
+			break
+		}
+		if shift += 7; shift > 21 {
+			return 0, nil, errors.New("malformed packet length")
+		}
+	}
+	body := make([]byte, n)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, nil, err
+	}
+	return kind, body, nil
+}
@@ -0,0 +1,130 @@
+package internal
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+type mqttMessage struct {
+	topic  string
+	retain bool
+	event  SyncEvent
+}
+
+// fakeBroker accepts connections as an MQTT broker would and sends on the messages published to it
+func fakeBroker(t *testing.T) (string, <-chan mqttMessage) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	messages := make(chan mqttMessage, 16)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				for {
+					kind, body, err := readMQTTPacket(r)
+					if err != nil {
+						return
+					}
+					switch kind & 0xf0 {
+					case 0x10:
+						_, _ = conn.Write([]byte{0x20, 2, 0, 0})
+					case 0x30:
+						n := int(binary.BigEndian.Uint16(body))
+						topic, rest := string(body[2:2+n]), body[2+n:]
+						msg := mqttMessage{topic: topic, retain: kind&0x01 != 0}
+						_ = json.Unmarshal(rest[2:], &msg.event)
+						messages <- msg
+						_, _ = conn.Write(append([]byte{0x40, 2}, rest[:2]...))
+					case 0xe0:
+						return
+					}
+				}
+			}()
+		}
+	}()
+	return "tcp://" + listener.Addr().String(), messages
+}
+
+func TestMQTTPublisher_Watcher(t *testing.T) {
+	broker, messages := fakeBroker(t)
+
+	tempDir := t.TempDir()
+	volumes := filepath.Join(tempDir, "Volumes")
+	if err := os.MkdirAll(filepath.Join(volumes, "CAR"), 0o755); err != nil {
+		t.Fatalf("Failed to create drive: %v", err)
+	}
+	source := filepath.Join(tempDir, "episode.mp3")
+	if err := os.WriteFile(source, make([]byte, 2048), 0o644); err != nil {
+		t.Fatalf("Failed to create source file: %v", err)
+	}
+	library := func() ([]PodcastEpisode, error) {
+		return []PodcastEpisode{{ZTitle: "Morning", ShowName: "Commute", FilePath: "file://" + source, FileSize: 2048}}, nil
+	}
+	cfg := &Config{Shows: map[string]ShowPolicy{"Commute": {Sync: SyncAlways}}}
+	w := NewWatcher(cfg, NewDriveManager(volumes, DirectoryTemplate{}), nil, library, log.New(io.Discard, "", 0))
+	w.AddPublisher(NewMQTTPublisher(MQTTSettings{Broker: broker, Topic: "home/podcasts/", Retain: true}))
+	w.Check()
+	w.Check()
+
+	var topics []string
+	var finished SyncEvent
+	for len(messages) > 0 {
+		msg := <-messages
+		topics = append(topics, msg.topic)
+		if !msg.retain || msg.event.Drive != "CAR" {
+			t.Errorf("Expected a retained event for CAR, got %+v", msg)
+		}
+		if msg.event.Event == EventSyncFinished {
+			finished = msg.event
+		}
+	}
+	want := []string{"home/podcasts/drive_connected", "home/podcasts/sync_started", "home/podcasts/sync_finished"}
+	if !slices.Equal(topics, want) {
+		t.Fatalf("Expected %v, got %v", want, topics)
+	}
+	if finished.Episodes != 1 || finished.Bytes != 2048 || finished.Time.IsZero() {
+		t.Errorf("Expected the finished event to count the copy, got %+v", finished)
+	}
+}
+
+func TestMQTTPublisher_Unreachable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+	if err := NewMQTTPublisher(MQTTSettings{Broker: "tcp://" + addr}).Publish(SyncEvent{Event: EventSyncStarted}); err == nil {
+		t.Error("Expected an error when the broker is down")
+	}
+}
+
+func TestLoadConfig_InvalidMQTT(t *testing.T) {
+	for _, broker := range []string{"host:1883", "http://host", ""} {
+		path := filepath.Join(t.TempDir(), "config.json")
+		data, _ := json.Marshal(map[string]any{"mqtt": map[string]string{"broker": broker}})
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadConfig(path); err == nil {
+			t.Errorf("Expected broker %q to be refused", broker)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// NotifyWhen decides which unattended syncs send a notification
+type NotifyWhen string
+
+const (
+	NotifyFailure NotifyWhen = ""       // only failed syncs
+	NotifyAlways  NotifyWhen = "always" // every finished sync as well
+)
+
+// NotifySettings send a summary of a drive's unattended syncs, from watch mode and --repeat-last-sync,
+// to a webhook, by email, or both
+type NotifySettings struct {
+	On NotifyWhen `json:"on,omitempty"`
+	// Webhook receives the summary as a JSON POST
+	Webhook string         `json:"webhook,omitempty"`
+	Email   *EmailSettings `json:"email,omitempty"`
+}
+
+// EmailSettings send notifications through an SMTP server, which is asked for STARTTLS when it offers it
+type EmailSettings struct {
+	// SMTP is the server as host:port
+	SMTP     string   `json:"smtp"`
+	User     string   `json:"user,omitempty"`
+	Password string   `json:"password,omitempty"`
+	From     string   `json:"from"`
+	To       []string `json:"to"`
+}
+
+func (n *NotifySettings) validate() error {
+	if n == nil {
+		return nil
+	}
+	switch n.On {
+	case NotifyFailure, NotifyAlways:
+	default:
+		return fmt.Errorf("invalid notify on %q: must be \"always\" or empty for failures only", n.On)
+	}
+	if n.Webhook != "" {
+		u, err := url.Parse(n.Webhook)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid notify webhook %q: must be an http or https URL", n.Webhook)
+		}
+	}
+	if e := n.Email; e != nil {
+		if _, _, err := net.SplitHostPort(e.SMTP); err != nil {
+			return fmt.Errorf("invalid notify smtp %q: must be host:port", e.SMTP)
+		}
+		if _, err := mail.ParseAddress(e.From); err != nil {
+			return fmt.Errorf("invalid notify from %q: %w", e.From, err)
+		}
+		if len(e.To) == 0 {
+			return errors.New("invalid notify email: no recipients in to")
+		}
+		for _, to := range e.To {
+			if _, err := mail.ParseAddress(to); err != nil {
+				return fmt.Errorf("invalid notify to %q: %w", to, err)
+			}
+		}
+	}
+	return nil
+}
+
+// webhookClient gives up on webhooks that don't answer, so a notification never hangs watch mode
+var webhookClient = &http.Client{Timeout: 15 * time.Second}
+
+// sendMail is smtp.SendMail; tests replace it
+var sendMail = smtp.SendMail
+
+// Notifier sends the finished and failed syncs of each drive to the notifications of its profile
+type Notifier struct {
+	profiles map[string]DriveProfile
+}
+
+// NewNotifier notifies as set in the drive profiles
+func NewNotifier(profiles map[string]DriveProfile) *Notifier {
+	return &Notifier{profiles: profiles}
+}
+
+// Publish notifies about event if the drive's profile asks for it; other events are ignored
+func (n *Notifier) Publish(event SyncEvent) error {
+	profile, ok := n.profiles[event.UUID]
+	if !ok || event.UUID == "" {
+		profile = n.profiles[event.Drive]
+	}
+	settings := profile.Notify
+	if settings == nil {
+		return nil
+	}
+	switch {
+	case event.Event == EventSyncFailed:
+	case event.Event == EventSyncFinished && settings.On == NotifyAlways:
+	default:
+		return nil
+	}
+
+	var errs []error
+	if settings.Webhook != "" {
+		if err := postWebhook(settings.Webhook, event); err != nil {
+			errs = append(errs, fmt.Errorf("failed to notify %s: %w", settings.Webhook, err))
+		}
+	}
+	if settings.Email != nil {
+		if err := sendNotification(*settings.Email, event); err != nil {
+			errs = append(errs, fmt.Errorf("failed to email %s: %w", strings.Join(settings.Email.To, ", "), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func postWebhook(url string, event SyncEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	resp, err := webhookClient.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected response %s", resp.Status)
+	}
+	return nil
+}
+
+// notificationText returns the subject and plain text body summarizing event
+func notificationText(event SyncEvent) (string, string) {
+	var subject string
+	var body strings.Builder
+	if event.Event == EventSyncFailed {
+		subject = fmt.Sprintf("Sync to %s failed", event.Drive)
+		fmt.Fprintf(&body, "The sync to %s at %s failed:\n\n%s\n", event.Drive, event.Time.Format(time.DateTime), event.Error)
+	} else {
+		subject = fmt.Sprintf("Synced %d episode(s) to %s", event.Episodes, event.Drive)
+		fmt.Fprintf(&body, "Synced %d episode(s), %s, to %s at %s.\n", event.Episodes, FormatBytes(event.Bytes), event.Drive, event.Time.Format(time.DateTime))
+	}
+	if len(event.Titles) > 0 {
+		body.WriteString("\n")
+		for _, title := range event.Titles {
+			fmt.Fprintf(&body, "- %s\n", title)
+		}
+	}
+	return "podcasts-sync: " + subject, body.String()
+}
+
+func sendNotification(settings EmailSettings, event SyncEvent) error {
+	subject, text := notificationText(event)
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", settings.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(settings.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", event.Time.Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(text, "\n", "\r\n"))
+
+	host, _, _ := net.SplitHostPort(settings.SMTP)
+	var auth smtp.Auth
+	if settings.User != "" {
+		auth = smtp.PlainAuth("", settings.User, settings.Password, host)
+	}
+	from, _ := mail.ParseAddress(settings.From)
+	to := make([]string, 0, len(settings.To))
+	for _, addr := range settings.To {
+		parsed, _ := mail.ParseAddress(addr)
+		to = append(to, parsed.Address)
+	}
+	return sendMail(settings.SMTP, auth, from.Address, to, msg.Bytes())
+}
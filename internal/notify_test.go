@@ -0,0 +1,110 @@
+package internal
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNotifier(t *testing.T) {
+	var posted []SyncEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var e SyncEvent
+		if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+			t.Errorf("Failed to decode webhook: %v", err)
+		}
+		posted = append(posted, e)
+	}))
+	defer server.Close()
+
+	var mails []string
+	orig := sendMail
+	sendMail = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		if addr != "smtp.example.com:587" || from != "pi@example.com" || len(to) != 1 || to[0] != "me@example.com" {
+			t.Errorf("Unexpected envelope %s %s %v", addr, from, to)
+		}
+		mails = append(mails, string(msg))
+		return nil
+	}
+	t.Cleanup(func() { sendMail = orig })
+
+	notifier := NewNotifier(map[string]DriveProfile{
+		"CAR": {Notify: &NotifySettings{Webhook: server.URL}},
+		"GYM": {Notify: &NotifySettings{On: NotifyAlways, Email: &EmailSettings{
+			SMTP: "smtp.example.com:587", From: "Podcasts <pi@example.com>", To: []string{"Me <me@example.com>"},
+		}}},
+	})
+	at := time.Date(2024, 3, 5, 6, 0, 0, 0, time.UTC)
+	for _, e := range []SyncEvent{
+		{Event: EventSyncStarted, Drive: "CAR", Time: at},
+		{Event: EventSyncFinished, Drive: "CAR", Time: at},
+		{Event: EventSyncFailed, Drive: "CAR", Error: "disk full", Time: at},
+		{Event: EventSyncFinished, Drive: "GYM", Episodes: 1, Bytes: 2048, Titles: []string{"Commute: Morning"}, Time: at},
+		{Event: EventSyncFailed, Drive: "STICK", Time: at},
+	} {
+		if err := notifier.Publish(e); err != nil {
+			t.Fatalf("Publish failed: %v", err)
+		}
+	}
+
+	if len(posted) != 1 || posted[0].Event != EventSyncFailed || posted[0].Error != "disk full" {
+		t.Errorf("Expected only the failure to be posted, got %+v", posted)
+	}
+	if len(mails) != 1 {
+		t.Fatalf("Expected one email, got %d", len(mails))
+	}
+	for _, want := range []string{"Subject: podcasts-sync: Synced 1 episode(s) to GYM", "- Commute: Morning"} {
+		if !strings.Contains(mails[0], want) {
+			t.Errorf("Expected the email to contain %q, got:\n%s", want, mails[0])
+		}
+	}
+}
+
+func TestNotifier_WebhookError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+	notifier := NewNotifier(map[string]DriveProfile{"CAR": {Notify: &NotifySettings{Webhook: server.URL}}})
+	if err := notifier.Publish(SyncEvent{Event: EventSyncFailed, Drive: "CAR"}); err == nil {
+		t.Error("Expected an error when the webhook fails")
+	}
+}
+
+func TestNotifier_UUIDProfile(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+	const uuid = "0A1B2C3D-4E5F-6071-8293-A4B5C6D7E8F9"
+	notifier := NewNotifier(map[string]DriveProfile{uuid: {Notify: &NotifySettings{Webhook: server.URL}}, "NO NAME": {}})
+	if err := notifier.Publish(SyncEvent{Event: EventSyncFailed, Drive: "NO NAME", UUID: uuid}); err == nil {
+		t.Error("Expected the UUID-keyed profile's webhook to be called")
+	}
+	if err := notifier.Publish(SyncEvent{Event: EventSyncFailed, Drive: "NO NAME"}); err != nil {
+		t.Errorf("Expected no notification for the name-keyed profile, got %v", err)
+	}
+}
+
+func TestLoadConfig_InvalidNotify(t *testing.T) {
+	for _, notify := range []string{
+		`{"on": "sometimes"}`,
+		`{"webhook": "ftp://example.com"}`,
+		`{"email": {"smtp": "smtp.example.com", "from": "a@example.com", "to": ["b@example.com"]}}`,
+		`{"email": {"smtp": "smtp.example.com:25", "from": "a@example.com"}}`,
+	} {
+		path := filepath.Join(t.TempDir(), "config.json")
+		if err := os.WriteFile(path, []byte(`{"drives": {"CAR": {"notify": `+notify+`}}}`), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadConfig(path); err == nil {
+			t.Errorf("Expected %s to be refused", notify)
+		}
+	}
+}
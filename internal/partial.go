@@ -0,0 +1,157 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// partialSuffix marks an episode whose copy has not finished: "name.ext.part", as browsers and
+// download managers name theirs
+const partialSuffix = ".part"
+
+// partialInfoSuffix names the sidecar describing a partial file, "name.ext.part.json"
+const partialInfoSuffix = partialSuffix + ".json"
+
+// legacyPartialSuffix is what partial files were named before, resumed under the new name
+const legacyPartialSuffix = ".partial"
+
+// PartialInfo is written beside a partial file so whatever resumes it knows what the finished file
+// must be. SHA256 is added once a copy is left unfinished, and left out for the parts of split episodes.
+type PartialInfo struct {
+	Source string `json:"source"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256,omitempty"`
+}
+
+// writePartialInfo writes the sidecar for the partial file at partialPath
+func writePartialInfo(partialPath string, info PartialInfo) error {
+	data, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode partial file info: %w", err)
+	}
+	if err := os.WriteFile(partialPath+".json", data, 0o644); err != nil {
+		return fmt.Errorf("failed to write partial file info: %w", err)
+	}
+	return nil
+}
+
+// ReadPartialInfo reads the sidecar of the partial file at partialPath
+func ReadPartialInfo(partialPath string) (PartialInfo, error) {
+	var info PartialInfo
+	data, err := os.ReadFile(partialPath + ".json")
+	if err != nil {
+		return info, fmt.Errorf("failed to read partial file info: %w", err)
+	}
+	if err := json.Unmarshal(data, &info); err != nil {
+		return info, fmt.Errorf("failed to parse partial file info %s: %w", partialPath+".json", err)
+	}
+	return info, nil
+}
+
+// removePartial removes a partial file and its sidecar
+func removePartial(partialPath string) {
+	_ = os.Remove(partialPath)
+	_ = os.Remove(partialPath + ".json")
+}
+
+// isPartialFile reports whether name is a partial file or its sidecar
+func isPartialFile(name string) bool {
+	return strings.HasSuffix(name, partialSuffix) || strings.HasSuffix(name, partialInfoSuffix) ||
+		strings.HasSuffix(name, legacyPartialSuffix)
+}
+
+// adoptLegacyPartial renames a partial file kept under the old name, so it is resumed like the others
+func adoptLegacyPartial(partialPath string) {
+	legacy := strings.TrimSuffix(partialPath, partialSuffix) + legacyPartialSuffix
+	if exists, _ := fileExists(partialPath); exists {
+		return
+	}
+	if exists, _ := fileExists(legacy); exists {
+		_ = os.Rename(legacy, partialPath)
+	}
+}
+
+// preparePartial writes the sidecar for a copy of srcPath into partialPath. A kept partial file whose
+// sidecar describes another source, e.g. a download Podcasts.app replaced, is started over.
+func preparePartial(srcPath, partialPath string) error {
+	info, err := os.Stat(srcPath)
+	if err != nil {
+		return err
+	}
+	want := PartialInfo{Source: srcPath, Size: info.Size()}
+	if kept, err := ReadPartialInfo(partialPath); err == nil && (kept.Source != want.Source || kept.Size != want.Size) {
+		removePartial(partialPath)
+	}
+	return writePartialInfo(partialPath, want)
+}
+
+// keepPartial adds the hash of srcPath to the sidecar of a partial file left for resuming. Only copies
+// that stop short are hashed, so a finished copy never reads its source an extra time.
+func keepPartial(srcPath, partialPath string) error {
+	if exists, _ := fileExists(partialPath); !exists {
+		return nil
+	}
+	info, err := ReadPartialInfo(partialPath)
+	if err != nil {
+		return err
+	}
+	if info.SHA256, err = getChecksum(srcPath); err != nil {
+		return fmt.Errorf("failed to hash %s: %w", srcPath, err)
+	}
+	return writePartialInfo(partialPath, info)
+}
+
+// walkPartialFiles calls fn for every partial file of an audio file below root, skipping the trash,
+// symlinks and hidden files as walkAudioFiles does
+func walkPartialFiles(root string, fn func(path string, info os.FileInfo) error) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == TrashFolder {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || !strings.HasSuffix(path, partialSuffix) || !isAudioFile(strings.TrimSuffix(path, partialSuffix)) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		return fn(path, info)
+	})
+}
+
+// ScanPartials lists the unfinished copies on a drive as in-progress episodes, named after the
+// episode each will become, so they aren't taken for stray files. FilePath is the partial file.
+func (ps *PodcastScanner) ScanPartials(drive USBDrive) ([]PodcastEpisode, error) {
+	podcastDir := filepath.Join(drive.MountPath, drive.Folder)
+	if _, err := os.Stat(podcastDir); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	var episodes []PodcastEpisode
+	err := walkPartialFiles(podcastDir, func(path string, info os.FileInfo) error {
+		episode, err := parseEpisodeFromPath(strings.TrimSuffix(path, partialSuffix), drive.Profile.withTemplate(ps.template), drive.Profile.Layout)
+		if err != nil {
+			return err
+		}
+		episode.FilePath = path
+		episode.FileSize = info.Size()
+		episode.Downloaded = info.ModTime()
+		episode.Partial = true
+		episodes = append(episodes, episode)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan drive for partial files: %w", err)
+	}
+	return episodes, nil
+}
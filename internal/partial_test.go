@@ -0,0 +1,111 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPreparePartial(t *testing.T) {
+	dir := t.TempDir()
+	srcPath := filepath.Join(dir, "source.mp3")
+	if err := os.WriteFile(srcPath, []byte("the whole episode"), 0o644); err != nil {
+		t.Fatalf("Failed to create source: %v", err)
+	}
+	partialPath := filepath.Join(dir, "episode.mp3"+partialSuffix)
+	if err := os.WriteFile(partialPath, []byte("the whole"), 0o644); err != nil {
+		t.Fatalf("Failed to create partial file: %v", err)
+	}
+
+	// A partial file without a sidecar, e.g. from another tool, is kept for resuming
+	if err := preparePartial(srcPath, partialPath); err != nil {
+		t.Fatalf("preparePartial failed: %v", err)
+	}
+	if exists, _ := fileExists(partialPath); !exists {
+		t.Error("Expected the partial file to be kept")
+	}
+	info, err := ReadPartialInfo(partialPath)
+	if err != nil {
+		t.Fatalf("Expected a sidecar: %v", err)
+	}
+	if info.Source != srcPath || info.Size != int64(len("the whole episode")) || info.SHA256 != "" {
+		t.Errorf("Expected the sidecar to hold the source and size without hashing, got %+v", info)
+	}
+
+	// A copy left unfinished adds the hash, which doesn't make the next copy start over
+	if err := keepPartial(srcPath, partialPath); err != nil {
+		t.Fatalf("keepPartial failed: %v", err)
+	}
+	if info, _ := ReadPartialInfo(partialPath); len(info.SHA256) != 64 {
+		t.Errorf("Expected the kept partial file's sidecar to hold the hash, got %+v", info)
+	}
+	if err := preparePartial(srcPath, partialPath); err != nil {
+		t.Fatalf("preparePartial failed: %v", err)
+	}
+	if exists, _ := fileExists(partialPath); !exists {
+		t.Error("Expected the hashed partial file to be resumed")
+	}
+
+	// Once the source changes, the partial file belongs to another download and starts over
+	if err := os.WriteFile(srcPath, []byte("a re-downloaded episode"), 0o644); err != nil {
+		t.Fatalf("Failed to replace source: %v", err)
+	}
+	if err := preparePartial(srcPath, partialPath); err != nil {
+		t.Fatalf("preparePartial failed: %v", err)
+	}
+	if exists, _ := fileExists(partialPath); exists {
+		t.Error("Expected the partial file of the old source to be removed")
+	}
+	if info, _ := ReadPartialInfo(partialPath); info.Size != int64(len("a re-downloaded episode")) {
+		t.Errorf("Expected the sidecar to describe the new source, got %+v", info)
+	}
+}
+
+func TestAdoptLegacyPartial(t *testing.T) {
+	destPath := filepath.Join(t.TempDir(), "episode.mp3")
+	if err := os.WriteFile(destPath+legacyPartialSuffix, []byte("half"), 0o644); err != nil {
+		t.Fatalf("Failed to create legacy partial file: %v", err)
+	}
+
+	adoptLegacyPartial(destPath + partialSuffix)
+
+	if data, err := os.ReadFile(destPath + partialSuffix); err != nil || string(data) != "half" {
+		t.Errorf("Expected the legacy partial file under the new name, got %q, %v", data, err)
+	}
+	if exists, _ := fileExists(destPath + legacyPartialSuffix); exists {
+		t.Error("Expected the legacy name to be gone")
+	}
+}
+
+func TestScanPartials(t *testing.T) {
+	root := t.TempDir()
+	drive := USBDrive{MountPath: root, Folder: "podcasts"}
+	writeDriveFiles(t, filepath.Join(root, "podcasts"),
+		"Show/2024-06-01 - Finished.mp3",
+		"Show/2024-06-02 - Unfinished.mp3"+partialSuffix,
+		"Show/2024-06-02 - Unfinished.mp3"+partialInfoSuffix,
+		"Show/notes.txt"+partialSuffix,
+	)
+
+	partials, err := NewPodcastScanner(DirectoryTemplate{}).ScanPartials(drive)
+	if err != nil {
+		t.Fatalf("ScanPartials failed: %v", err)
+	}
+	if len(partials) != 1 {
+		t.Fatalf("Expected the one unfinished episode, got %+v", partials)
+	}
+	p := partials[0]
+	if !p.Partial || !strings.HasSuffix(p.ZTitle, "Unfinished") || p.ShowName != "Show" || filepath.Base(p.FilePath) != "2024-06-02 - Unfinished.mp3"+partialSuffix {
+		t.Errorf("Unexpected partial episode: %+v", p)
+	}
+
+	// Partial files never pass for episodes in a full scan
+	episodes, err := NewPodcastScanner(DirectoryTemplate{}).ScanDrive(drive, nil)
+	if err != nil {
+		t.Fatalf("ScanDrive failed: %v", err)
+	}
+	if len(episodes) != 1 || episodes[0].Partial {
+		t.Errorf("Expected only the finished episode, got %+v", episodes)
+	}
+}
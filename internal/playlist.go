@@ -0,0 +1,220 @@
+package internal
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// PlaylistFile is the playlist written to a drive's podcasts folder
+const PlaylistFile = "podcasts.m3u8"
+
+// indexPrefix matches the position RenumberEpisodes puts in front of an episode's file name
+var indexPrefix = regexp.MustCompile(`^\d{3,} - `)
+
+// PlaylistOrder decides the order episodes are listed in a drive's playlist
+type PlaylistOrder string
+
+const (
+	PlaylistNone       PlaylistOrder = ""           // no playlist is written
+	PlaylistOldest     PlaylistOrder = "oldest"     // oldest first, for serials
+	PlaylistNewest     PlaylistOrder = "newest"     // newest first, for news
+	PlaylistInterleave PlaylistOrder = "interleave" // one episode per show in turn, each show oldest first
+	PlaylistCustom     PlaylistOrder = "custom"     // the drive profile's saved order, then the rest oldest first
+)
+
+func (o PlaylistOrder) validate() error {
+	switch o {
+	case PlaylistNone, PlaylistOldest, PlaylistNewest, PlaylistInterleave, PlaylistCustom:
+		return nil
+	}
+	return fmt.Errorf("invalid playlist %q: must be \"oldest\", \"newest\", \"interleave\" or \"custom\"", o)
+}
+
+// OrderPlaylist returns episodes in the order the profile's playlist lists them.
+// relPath gives an episode's path below the podcasts folder, which the custom order is saved as.
+func OrderPlaylist(episodes []PodcastEpisode, profile DriveProfile, relPath func(PodcastEpisode) string) []PodcastEpisode {
+	ordered := slices.Clone(episodes)
+	oldestFirst := func(a, b PodcastEpisode) int {
+		return cmp.Or(a.Published.Compare(b.Published), cmp.Compare(relPath(a), relPath(b)))
+	}
+	slices.SortStableFunc(ordered, oldestFirst)
+
+	switch profile.Playlist {
+	case PlaylistNewest:
+		slices.Reverse(ordered)
+	case PlaylistInterleave:
+		ordered = interleaveShows(ordered)
+	case PlaylistCustom:
+		position := make(map[string]int, len(profile.CustomOrder))
+		for i, path := range profile.CustomOrder {
+			position[filepath.ToSlash(path)] = i
+		}
+		// Episodes outside the saved order keep their oldest-first order after it
+		slices.SortStableFunc(ordered, func(a, b PodcastEpisode) int {
+			pa, okA := position[filepath.ToSlash(relPath(a))]
+			pb, okB := position[filepath.ToSlash(relPath(b))]
+			switch {
+			case okA && okB:
+				return cmp.Compare(pa, pb)
+			case okA:
+				return -1
+			case okB:
+				return 1
+			}
+			return 0
+		})
+	}
+	return ordered
+}
+
+// interleaveShows takes one episode from each show in turn, keeping each show's own order.
+// Shows take turns in the order of their first episode.
+func interleaveShows(episodes []PodcastEpisode) []PodcastEpisode {
+	var shows []string
+	byShow := make(map[string][]PodcastEpisode)
+	for _, episode := range episodes {
+		if _, ok := byShow[episode.ShowName]; !ok {
+			shows = append(shows, episode.ShowName)
+		}
+		byShow[episode.ShowName] = append(byShow[episode.ShowName], episode)
+	}
+
+	interleaved := make([]PodcastEpisode, 0, len(episodes))
+	for round := 0; len(interleaved) < len(episodes); round++ {
+		for _, show := range shows {
+			if round < len(byShow[show]) {
+				interleaved = append(interleaved, byShow[show][round])
+			}
+		}
+	}
+	return interleaved
+}
+
+// WritePlaylist lists the episodes in podcastDir in an extended M3U playlist, ordered by the profile.
+// Paths are relative to the playlist so it keeps working wherever the drive is mounted.
+// Nothing is written when the profile has no playlist order.
+func WritePlaylist(podcastDir string, profile DriveProfile) error {
+	if profile.Playlist == PlaylistNone {
+		return nil
+	}
+
+	drive := USBDrive{MountPath: podcastDir, Profile: profile}
+	episodes, err := NewPodcastScanner(DirectoryTemplate{}).ScanDrive(drive, nil)
+	if err != nil {
+		return fmt.Errorf("failed to scan drive for playlist: %w", err)
+	}
+	relPath := func(episode PodcastEpisode) string {
+		rel, err := filepath.Rel(podcastDir, episode.FilePath)
+		if err != nil {
+			return episode.FilePath
+		}
+		return rel
+	}
+	// The custom order is saved without index prefixes or part numbers
+	orderPath := func(episode PodcastEpisode) string {
+		rel := wholePath(relPath(episode))
+		if profile.IndexPrefix {
+			rel = unnumbered(rel)
+		}
+		return rel
+	}
+
+	var b strings.Builder
+	fmt.Fprintln(&b, "#EXTM3U")
+	for _, episode := range OrderPlaylist(episodes, profile, orderPath) {
+		seconds := -1
+		if episode.Duration > 0 {
+			seconds = int(episode.Duration.Seconds())
+		}
+		fmt.Fprintf(&b, "#EXTINF:%d,%s - %s\n", seconds, episode.ShowName, episode.ZTitle)
+		fmt.Fprintln(&b, filepath.ToSlash(relPath(episode)))
+	}
+
+	path := filepath.Join(podcastDir, PlaylistFile)
+	data := []byte(b.String())
+	if profile.Stable && unchanged(path, data) {
+		return nil
+	}
+	// Written beside the playlist and renamed over it, so a player never reads half a playlist
+	tmp := path + ".tmp"
+	err = os.WriteFile(tmp, data, 0o644)
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to write playlist: %w", err)
+	}
+	return nil
+}
+
+// EpisodePath returns where episode is stored below the drive's podcasts folder.
+// With IndexPrefix set, episodes in the custom order are named after their position in it,
+// so players that only sort by file name follow the order too.
+func (p DriveProfile) EpisodePath(episode PodcastEpisode) string {
+	return p.numbered(p.RelPath(episode))
+}
+
+// numbered adds the index prefix rel is due in the custom order to its file name
+func (p DriveProfile) numbered(rel string) string {
+	if !p.IndexPrefix {
+		return rel
+	}
+	// Parts of a split episode share its place in the order
+	i := slices.Index(p.CustomOrder, filepath.ToSlash(wholePath(rel)))
+	if i < 0 {
+		return rel
+	}
+	return filepath.Join(filepath.Dir(rel), fmt.Sprintf("%03d - %s", i+1, filepath.Base(rel)))
+}
+
+// unnumbered strips the index prefix from the file name of rel
+func unnumbered(rel string) string {
+	return filepath.Join(filepath.Dir(rel), indexPrefix.ReplaceAllString(filepath.Base(rel), ""))
+}
+
+// RenumberEpisodes renames the episodes in podcastDir so their index prefixes follow the profile's
+// custom order, taking their companion files and manifest entries along.
+// Episodes that left the order lose their prefix. Nothing is renamed without IndexPrefix.
+// Renames are recorded in journal so they can be undone.
+func RenumberEpisodes(podcastDir string, profile DriveProfile, manifest *Manifest, journal *JournalRun) error {
+	if !profile.IndexPrefix {
+		return nil
+	}
+
+	renames := make(map[string]string)
+	err := walkAudioFiles(podcastDir, func(path string, _ os.FileInfo) error {
+		rel, err := filepath.Rel(podcastDir, path)
+		if err != nil {
+			return err
+		}
+		if want := profile.numbered(unnumbered(rel)); want != rel {
+			renames[path] = filepath.Join(podcastDir, want)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to scan drive for renumbering: %w", err)
+	}
+
+	for from, to := range renames {
+		if exists, _ := fileExists(to); exists {
+			continue
+		}
+		if err := os.Rename(from, to); err != nil {
+			return fmt.Errorf("failed to renumber episode: %w", err)
+		}
+		moveCompanions(from, to)
+		if entry, ok := manifest.Entry(from); ok {
+			manifest.Remove(from)
+			manifest.Set(to, entry)
+		}
+		journal.record(JournalRenamed, to, from, nil)
+	}
+	return nil
+}
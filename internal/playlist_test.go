@@ -0,0 +1,136 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOrderPlaylist(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+	episodes := []PodcastEpisode{
+		{ZTitle: "News 2", ShowName: "News", Published: day(4)},
+		{ZTitle: "Serial 1", ShowName: "Serial", Published: day(1)},
+		{ZTitle: "News 1", ShowName: "News", Published: day(3)},
+		{ZTitle: "Serial 2", ShowName: "Serial", Published: day(2)},
+		{ZTitle: "Serial 3", ShowName: "Serial", Published: day(5)},
+	}
+	relPath := func(e PodcastEpisode) string { return filepath.Join(e.ShowName, e.ZTitle+".mp3") }
+
+	tests := []struct {
+		name    string
+		profile DriveProfile
+		want    []string
+	}{
+		{"oldest", DriveProfile{Playlist: PlaylistOldest}, []string{"Serial 1", "Serial 2", "News 1", "News 2", "Serial 3"}},
+		{"newest", DriveProfile{Playlist: PlaylistNewest}, []string{"Serial 3", "News 2", "News 1", "Serial 2", "Serial 1"}},
+		{"interleave", DriveProfile{Playlist: PlaylistInterleave}, []string{"Serial 1", "News 1", "Serial 2", "News 2", "Serial 3"}},
+		{
+			"custom",
+			DriveProfile{Playlist: PlaylistCustom, CustomOrder: []string{"News/News 2.mp3", "Serial/Serial 3.mp3", "Gone/Deleted.mp3"}},
+			[]string{"News 2", "Serial 3", "Serial 1", "Serial 2", "News 1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, e := range OrderPlaylist(episodes, tt.profile, relPath) {
+				got = append(got, e.ZTitle)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("OrderPlaylist() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWritePlaylist(t *testing.T) {
+	podcastDir := t.TempDir()
+	profile := DriveProfile{Layout: LayoutYear, Playlist: PlaylistNewest}
+	for _, e := range []PodcastEpisode{
+		{ZTitle: "Older", ShowName: "Show", Published: time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC), FilePath: "/library/a.mp3"},
+		{ZTitle: "Newer", ShowName: "Show", Published: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), FilePath: "/library/b.mp3"},
+	} {
+		path := filepath.Join(podcastDir, profile.Layout.RelPath(e))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create folder: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("not really audio"), 0o644); err != nil {
+			t.Fatalf("Failed to write episode: %v", err)
+		}
+	}
+
+	if err := WritePlaylist(podcastDir, profile); err != nil {
+		t.Fatalf("WritePlaylist failed: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(podcastDir, PlaylistFile))
+	if err != nil {
+		t.Fatalf("Expected a playlist: %v", err)
+	}
+	want := strings.Join([]string{
+		"#EXTM3U",
+		"#EXTINF:-1,Show - 2024-06-01 - Newer",
+		"Show/2024/2024-06-01 - Newer.mp3",
+		"#EXTINF:-1,Show - 2023-06-01 - Older",
+		"Show/2023/2023-06-01 - Older.mp3",
+		"",
+	}, "\n")
+	if string(data) != want {
+		t.Errorf("Unexpected playlist:\n%s\nwant:\n%s", data, want)
+	}
+
+	// Drives without a playlist order are left alone
+	other := t.TempDir()
+	if err := WritePlaylist(other, DriveProfile{}); err != nil {
+		t.Fatalf("WritePlaylist failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(other, PlaylistFile)); !os.IsNotExist(err) {
+		t.Errorf("Expected no playlist without an order, got %v", err)
+	}
+}
+
+func TestRenumberEpisodes(t *testing.T) {
+	podcastDir := t.TempDir()
+	profile := DriveProfile{IndexPrefix: true, CustomOrder: []string{"Show/B.mp3", "Show/A.mp3"}}
+	for _, name := range []string{"001 - A.mp3", "001 - A.chapters.json", "B.mp3", "C.mp3", "003 - D.mp3"} {
+		path := filepath.Join(podcastDir, "Show", name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create folder: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	manifest, err := LoadManifest(podcastDir)
+	if err != nil {
+		t.Fatalf("LoadManifest failed: %v", err)
+	}
+	manifest.Set(filepath.Join(podcastDir, "Show", "001 - A.mp3"), ManifestEntry{Show: "Show", Title: "A"})
+
+	if err := RenumberEpisodes(podcastDir, profile, manifest, nil); err != nil {
+		t.Fatalf("RenumberEpisodes failed: %v", err)
+	}
+
+	entries, err := os.ReadDir(filepath.Join(podcastDir, "Show"))
+	if err != nil {
+		t.Fatalf("Failed to list show folder: %v", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	want := []string{"001 - B.mp3", "002 - A.chapters.json", "002 - A.mp3", "C.mp3", "D.mp3"}
+	if !slices.Equal(names, want) {
+		t.Errorf("Renumbered files = %v, want %v", names, want)
+	}
+	if _, ok := manifest.Entry(filepath.Join(podcastDir, "Show", "002 - A.mp3")); !ok {
+		t.Error("Expected the manifest entry to follow the renamed episode")
+	}
+	outside := PodcastEpisode{ZTitle: "Title", ShowName: "Other", FilePath: "/library/other.mp3"}
+	if got, want := profile.EpisodePath(outside), profile.Layout.RelPath(outside); got != want {
+		t.Errorf("Expected episodes outside the order to keep their name %s, got %s", want, got)
+	}
+}
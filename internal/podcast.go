@@ -3,6 +3,8 @@ package internal
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -15,16 +17,43 @@ import (
 // AppleEpochOffset is the difference between Apple's epoch (2001-01-01) and Unix epoch (1970-01-01)
 const AppleEpochOffset = 978307200
 
+// appleDate converts a Core Data timestamp, in seconds since Apple's epoch, to UTC.
+// Dates are kept in UTC throughout so file names don't shift a day with the local timezone or DST.
+func appleDate(seconds int64) time.Time {
+	return time.Unix(seconds+AppleEpochOffset, 0).UTC()
+}
+
 type PodcastEpisode struct {
-	ZTitle    string
-	ShowName  string
-	FilePath  string
-	Published time.Time
-	Selected  bool
-	FileSize  int64
-	OnDrive   bool
-	Duration  time.Duration
-	Progress  float64
+	ZTitle         string
+	ShowName       string
+	Genre          string
+	FilePath       string
+	Published      time.Time
+	Selected       bool
+	FileSize       int64
+	OnDrive        bool
+	Duration       time.Duration
+	Progress       float64
+	ShowNotes      string
+	TranscriptPath string
+	TransferState  FileState
+	// Played is set once Podcasts.app has played the episode
+	Played bool
+	// Downloaded is when the episode's download was last written, zero if it isn't downloaded; on a drive,
+	// when its file was copied there
+	Downloaded time.Time
+	// Missing is set when the database still lists the episode but Podcasts.app has deleted its download
+	Missing bool
+	// Downloading is set while Podcasts.app is still writing the episode's download
+	Downloading bool
+	// Partial is set for a drive episode whose copy hasn't finished, found by its partial file
+	Partial bool
+	// Favorite is set for episodes starred by the user, stored in the history database
+	Favorite bool
+	// Pinned is set for drive episodes the drive's manifest protects from bulk deletes
+	Pinned bool
+	// SameAudio is the hash of the episode's file when the library has the same audio under another show
+	SameAudio string
 }
 
 func (p PodcastEpisode) Title() string {
@@ -32,6 +61,19 @@ func (p PodcastEpisode) Title() string {
 	if p.OnDrive {
 		status = "✓ "
 	}
+	// While a sync is running its per-episode state takes precedence
+	if glyph := p.TransferState.Glyph(); glyph != "" {
+		status = glyph + " "
+	}
+	if p.Favorite {
+		status += "★ "
+	}
+	if p.Pinned {
+		status += "⚑ "
+	}
+	if p.SameAudio != "" {
+		status += "⧉ "
+	}
 	return status + p.ZTitle
 }
 
@@ -39,38 +81,100 @@ func (p PodcastEpisode) Description() string {
 	parts := []string{p.ShowName}
 
 	if !p.Published.IsZero() {
-		parts = append(parts, p.Published.Format("2006-01-02"))
+		parts = append(parts, FormatDate(p.Published.UTC()))
 	}
 
 	if p.Duration > 0 {
 		parts = append(parts, formatDuration(p.Duration))
 	}
 
+	if p.Missing {
+		parts = append(parts, T("not downloaded"))
+	}
+
+	if p.Downloading {
+		parts = append(parts, T("downloading…"))
+	}
+
+	if p.Partial {
+		parts = append(parts, T("copy unfinished"))
+	}
+
 	return strings.Join(parts, " • ")
 }
 
-func (p PodcastEpisode) FilterValue() string { return p.ZTitle }
+// FilterValue is what list filtering matches against: the show, the title and the publication date
+// written out, so "planet money jan" finds a January episode of Planet Money
+func (p PodcastEpisode) FilterValue() string {
+	value := strings.TrimSpace(p.ShowName + " " + p.ZTitle)
+	if !p.Published.IsZero() {
+		value += " " + p.Published.Format("January 2 2006 2006-01-02")
+	}
+	return value
+}
 
-// LoadMacPodcasts queries every podcast episodes from the local Apple Podcasts database
-func LoadMacPodcasts() ([]PodcastEpisode, error) {
-	dbPath := filepath.Join(
-		os.Getenv("HOME"),
-		"Library/Group Containers/243LU875E5.groups.com.apple.podcasts/Documents/MTLibrary.sqlite",
-	)
+// SourcePath returns the episode's file in the Mac's library, from its file:// URI
+func (p PodcastEpisode) SourcePath() (string, error) {
+	return convertFileURIToPath(p.FilePath)
+}
 
-	db, err := sql.Open("libsql", "file:"+dbPath)
+// Markdown summarizes the episode as a Markdown list, e.g. for a bug report. Empty paths are left out.
+func (p PodcastEpisode) Markdown(source, destination string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "**%s**\n\n- Show: %s\n", p.ZTitle, p.ShowName)
+	if !p.Published.IsZero() {
+		fmt.Fprintf(&b, "- Published: %s\n", FormatDate(p.Published.UTC()))
+	}
+	if p.Duration > 0 {
+		fmt.Fprintf(&b, "- Duration: %s\n", formatDuration(p.Duration))
+	}
+	if p.FileSize > 0 {
+		fmt.Fprintf(&b, "- Size: %s\n", FormatBytes(p.FileSize))
+	}
+	if source != "" {
+		fmt.Fprintf(&b, "- Source: `%s`\n", source)
+	}
+	if destination != "" {
+		fmt.Fprintf(&b, "- Destination: `%s`\n", destination)
+	}
+	return b.String()
+}
+
+// openLibrary opens the local Apple Podcasts database
+func openLibrary() (*sql.DB, error) {
+	dbPath := filepath.Join(podcastsContainerPath(), "Documents/MTLibrary.sqlite")
+	return sql.Open("libsql", "file:"+dbPath)
+}
+
+// LoadMacPodcasts queries every podcast episodes from the local Apple Podcasts database.
+// Shownotes are left out since they make up most of a large library; see lazyShowNotes.
+func LoadMacPodcasts() ([]PodcastEpisode, error) {
+	db, err := openLibrary()
 	if err != nil {
 		return nil, err
 	}
 	defer db.Close()
 
+	// Databases from before Podcasts.app had transcripts lack the column
+	transcripts, err := hasColumn(db, "ZMTEPISODE", "ZTRANSCRIPTIDENTIFIER")
+	if err != nil {
+		return nil, err
+	}
+	transcriptColumn := "NULL AS ZTRANSCRIPTIDENTIFIER"
+	if transcripts {
+		transcriptColumn = "e.ZTRANSCRIPTIDENTIFIER"
+	}
+
 	rows, err := db.Query(`
         SELECT 
             e.ZTITLE,
             p.ZTITLE,
             e.ZASSETURL,
             e.ZPUBDATE,
-			e.ZDURATION
+			e.ZDURATION,
+			` + transcriptColumn + `,
+			p.ZCATEGORY,
+			COALESCE(e.ZPLAYCOUNT, 0)
         FROM ZMTEPISODE e
         JOIN ZMTPODCAST p ON e.ZPODCASTUUID = p.ZUUID
         WHERE ZASSETURL IS NOT NULL
@@ -82,28 +186,187 @@ func LoadMacPodcasts() ([]PodcastEpisode, error) {
 	defer rows.Close()
 
 	var episodes []PodcastEpisode
+	// Every episode of a show shares one copy of its name and genre
+	names := make(map[string]string)
+	intern := func(s string) string {
+		if interned, ok := names[s]; ok {
+			return interned
+		}
+		names[s] = s
+		return s
+	}
 	for rows.Next() {
 		var e PodcastEpisode
 		var pubDate int64
 		var duration int64
-		err := rows.Scan(&e.ZTitle, &e.ShowName, &e.FilePath, &pubDate, &duration)
+		var transcriptID, genre sql.NullString
+		var playCount int64
+		err := rows.Scan(&e.ZTitle, &e.ShowName, &e.FilePath, &pubDate, &duration, &transcriptID, &genre, &playCount)
 		if err != nil {
 			return nil, err
 		}
 
-		e.Published = time.Unix((pubDate + AppleEpochOffset), 0)
+		e.ShowName = intern(e.ShowName)
+		e.Genre = intern(genre.String)
+		e.Published = appleDate(pubDate)
 		e.Duration = time.Duration(duration) * time.Second
+		e.TranscriptPath = resolveTranscriptPath(transcriptID.String)
+		e.Played = playCount > 0
 		episodes = append(episodes, e)
 	}
 
-	return episodes, nil
+	return episodes, rows.Err()
+}
+
+// hasColumn reports whether table in the Podcasts database has column, which older releases may lack
+func hasColumn(db *sql.DB, table, column string) (bool, error) {
+	var n int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`, table, column).Scan(&n); err != nil {
+		return false, fmt.Errorf("failed to read the columns of %s: %w", table, err)
+	}
+	return n > 0, nil
 }
 
-// LoadLocalPodcasts fills in the file size and checksum for each episode.
+// showNotesLoader reads shownotes keyed by asset URL; tests replace it to avoid the Podcasts database
+var showNotesLoader = loadShowNotes
+
+// lazyShowNotes returns the shownotes of episodes, keyed by FilePath, that were loaded without them.
+// Episodes that already carry shownotes, like the demo library, aren't looked up.
+func lazyShowNotes(episodes []PodcastEpisode) map[string]string {
+	var urls []string
+	for _, episode := range episodes {
+		if episode.ShowNotes == "" && strings.HasPrefix(episode.FilePath, "file://") {
+			urls = append(urls, episode.FilePath)
+		}
+	}
+	if len(urls) == 0 {
+		return nil
+	}
+	// Without the database the notes are simply unavailable, as if the episode had none
+	notes, _ := showNotesLoader(urls)
+	return notes
+}
+
+// loadShowNotes reads the shownotes of the episodes with the given asset URLs
+func loadShowNotes(urls []string) (map[string]string, error) {
+	db, err := openLibrary()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	query := `SELECT ZASSETURL, ZITEMDESCRIPTION FROM ZMTEPISODE WHERE ZASSETURL IS NOT NULL AND ZITEMDESCRIPTION IS NOT NULL`
+	var args []any
+	if len(urls) == 1 {
+		query += ` AND ZASSETURL = ?`
+		args = append(args, urls[0])
+	}
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	wanted := make(map[string]bool, len(urls))
+	for _, url := range urls {
+		wanted[url] = true
+	}
+	notes := make(map[string]string, len(urls))
+	for rows.Next() {
+		var url, description string
+		if err := rows.Scan(&url, &description); err != nil {
+			return nil, err
+		}
+		if wanted[url] {
+			notes[url] = description
+		}
+	}
+	return notes, rows.Err()
+}
+
+// ErrNotInDirectory is returned for episodes Podcasts.app has no link to, like those of private feeds
+var ErrNotInDirectory = errors.New("the episode isn't in the Apple Podcasts directory")
+
+// PodcastsAppURL returns a link that opens the episode in Podcasts.app, or its show when the
+// directory doesn't list the episode itself
+func PodcastsAppURL(episode PodcastEpisode) (string, error) {
+	db, err := openLibrary()
+	if err != nil {
+		return "", err
+	}
+	defer db.Close()
+
+	var track, collection sql.NullInt64
+	err = db.QueryRow(`
+		SELECT e.ZSTORETRACKID, p.ZSTORECOLLECTIONID
+		FROM ZMTEPISODE e
+		JOIN ZMTPODCAST p ON e.ZPODCASTUUID = p.ZUUID
+		WHERE e.ZASSETURL = ?
+	`, episode.FilePath).Scan(&track, &collection)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("%s isn't in the Podcasts library", episode.ZTitle)
+	}
+	if err != nil {
+		return "", err
+	}
+	if collection.Int64 == 0 {
+		return "", ErrNotInDirectory
+	}
+	url := fmt.Sprintf("podcasts://podcasts.apple.com/podcast/id%d", collection.Int64)
+	if track.Int64 != 0 {
+		url += fmt.Sprintf("?i=%d", track.Int64)
+	}
+	return url, nil
+}
+
+// MissingAssets returns the FilePaths of episodes whose downloaded file no longer exists,
+// e.g. because Podcasts.app removed it after the episode was played
+func MissingAssets(episodes []PodcastEpisode) map[string]bool {
+	missing := make(map[string]bool)
+	for _, episode := range episodes {
+		if sourceMissing(episode) {
+			missing[episode.FilePath] = true
+		}
+	}
+	return missing
+}
+
+// DownloadingAssets returns the FilePaths of episodes whose download Podcasts.app is still writing,
+// judged against the FileSize they were loaded with
+func DownloadingAssets(episodes []PodcastEpisode) map[string]bool {
+	downloading := make(map[string]bool)
+	for _, episode := range episodes {
+		filePath, err := convertFileURIToPath(episode.FilePath)
+		if err != nil {
+			continue
+		}
+		if info, err := os.Stat(filePath); err == nil && stillDownloading(episode.FileSize, info) {
+			downloading[episode.FilePath] = true
+		}
+	}
+	return downloading
+}
+
+// downloadQuiet is how long a download has to go unwritten before it is taken to be complete
+const downloadQuiet = 30 * time.Second
+
+// stillDownloading reports whether a library file last seen at known bytes is still growing: its size
+// changed since and it was written moments ago. A known size of 0 hasn't been seen yet.
+func stillDownloading(known int64, info os.FileInfo) bool {
+	return known > 0 && info.Size() != known && time.Since(info.ModTime()) < downloadQuiet
+}
+
+// LoadLocalPodcasts fills in the file size for each episode and marks audio found under several shows.
 // Continues processing all episodes even if some fail, setting FileSize to 0 for failed episodes.
 // Returns episodes with file sizes populated where possible, and nil error.
 func LoadLocalPodcasts(episodes []PodcastEpisode) ([]PodcastEpisode, error) {
+	return loadLocalPodcasts(episodes, nil)
+}
+
+// loadLocalPodcasts is LoadLocalPodcasts counting each episode looked up with prep
+func loadLocalPodcasts(episodes []PodcastEpisode, prep *prepareReporter) ([]PodcastEpisode, error) {
 	for i := range episodes {
+		prep.examined()
 		filePath, err := convertFileURIToPath(episodes[i].FilePath)
 		if err != nil {
 			// Unable to convert URI - skip this episode
@@ -111,14 +374,20 @@ func LoadLocalPodcasts(episodes []PodcastEpisode) ([]PodcastEpisode, error) {
 			continue
 		}
 
+		known := episodes[i].FileSize
 		fileInfo, err := os.Stat(filePath)
 		if err == nil {
 			episodes[i].FileSize = fileInfo.Size()
+			episodes[i].Downloaded = fileInfo.ModTime()
 		} else {
 			// File doesn't exist or can't be accessed - set size to 0
 			episodes[i].FileSize = 0
+			episodes[i].Downloaded = time.Time{}
 		}
+		episodes[i].Missing = os.IsNotExist(err)
+		episodes[i].Downloading = err == nil && stillDownloading(known, fileInfo)
 	}
+	MarkDuplicates(episodes)
 
 	return episodes, nil
 }
@@ -8,52 +8,62 @@ import (
 type PodcastMatcher struct {
 	podcastsBySize map[int64][]*PodcastEpisode
 	podcastsByPath map[string]*PodcastEpisode
+	layout         FolderLayout
+	// hashWorkers is how many files checksum matching hashes at once
+	hashWorkers int
 }
 
-// NewPodcastMatcher creates a new PodcastMatcher instance
-func NewPodcastMatcher(podcastsBySize map[int64][]*PodcastEpisode) *PodcastMatcher {
+// NewPodcastMatcher creates a new PodcastMatcher for a drive arranged in layout
+func NewPodcastMatcher(podcastsBySize map[int64][]*PodcastEpisode, layout FolderLayout) *PodcastMatcher {
+	return newProfileMatcher(podcastsBySize, DriveProfile{Layout: layout})
+}
+
+// newProfileMatcher creates a PodcastMatcher for a drive arranged and named as its profile sets
+func newProfileMatcher(podcastsBySize map[int64][]*PodcastEpisode, profile DriveProfile) *PodcastMatcher {
 	// Build path-based index from local episodes for fast path matching
 	pathIndex := make(map[string]*PodcastEpisode)
 
 	for _, episodes := range podcastsBySize {
 		for _, ep := range episodes {
 			// Create the expected drive path for this episode
-			expectedPath := buildExpectedDrivePath(ep)
-			pathIndex[expectedPath] = ep
+			pathIndex[profile.RelPath(*ep)] = ep
 		}
 	}
 
 	return &PodcastMatcher{
 		podcastsBySize: podcastsBySize,
 		podcastsByPath: pathIndex,
+		layout:         profile.Layout,
+		hashWorkers:    hashWorkers(DriveSpeed{}),
 	}
 }
 
-// buildExpectedDrivePath constructs the expected drive path from episode metadata
-func buildExpectedDrivePath(ep *PodcastEpisode) string {
-	// Use the same formatting logic as when copying files
-	showDir := sanitizeName(ep.ShowName)
-	filename := formatEpisodeName(*ep)
-	return filepath.Join(showDir, filename)
-}
-
-// canonicalizePathForMatching extracts the relative path from a full drive path
-func canonicalizePathForMatching(fullPath string) string {
-	// Extract the last two path components (show/episode)
+// canonicalizePathForMatching extracts the path below the podcasts folder from a full drive path,
+// which is its last depth components
+func canonicalizePathForMatching(fullPath string, depth int) string {
 	parts := strings.Split(filepath.ToSlash(fullPath), "/")
-	if len(parts) >= 2 {
-		return filepath.Join(parts[len(parts)-2], parts[len(parts)-1])
+	if len(parts) >= depth {
+		return filepath.Join(parts[len(parts)-depth:]...)
 	}
 	return filepath.Base(fullPath)
 }
 
 // matchByPath performs path-based lookup for drive files
 func (pm *PodcastMatcher) matchByPath(podcast *PodcastEpisode) bool {
-	drivePath := canonicalizePathForMatching(podcast.FilePath)
+	drivePath := canonicalizePathForMatching(podcast.FilePath, pm.layout.depth())
 	if match, found := pm.podcastsByPath[drivePath]; found {
 		updatePodcastMatch(podcast, match)
 		return true
 	}
+	// Each part of a split episode is listed as that part of the episode
+	if whole := wholePath(drivePath); whole != drivePath {
+		if match, found := pm.podcastsByPath[whole]; found {
+			suffix := strings.TrimSuffix(strings.TrimPrefix(drivePath, strings.TrimSuffix(whole, filepath.Ext(whole))), filepath.Ext(drivePath))
+			updatePodcastMatch(podcast, match)
+			podcast.ZTitle += suffix
+			return true
+		}
+	}
 	return false
 }
 
@@ -117,19 +127,29 @@ func (pm *PodcastMatcher) Match(podcast *PodcastEpisode) error {
 	return nil // No matches found
 }
 
-// Matches podcasts by comparing their checksums
+// Matches podcasts by comparing their checksums, hashing the drive file and its candidates at once
 func (pm *PodcastMatcher) matchByChecksum(podcast *PodcastEpisode, matches []*PodcastEpisode) error {
-	checksum, err := getChecksum(podcast.FilePath)
-	if err != nil {
-		return err
+	paths := []string{podcast.FilePath}
+	candidates := make([]string, len(matches))
+	for i, match := range matches {
+		// Library episodes are file URLs while drive episodes are plain paths
+		path := match.FilePath
+		if strings.HasPrefix(path, "file://") {
+			var err error
+			if path, err = convertFileURIToPath(path); err != nil {
+				continue
+			}
+		}
+		candidates[i] = path
+		paths = append(paths, path)
 	}
 
-	for _, match := range matches {
-		matchChecksum, err := getChecksum(match.FilePath)
-		if err != nil {
-			continue
-		}
-		if matchChecksum == checksum {
+	sums, errs := hashFiles(paths, pm.hashWorkers, nil)
+	if err := errs[podcast.FilePath]; err != nil {
+		return err
+	}
+	for i, match := range matches {
+		if sum, ok := sums[candidates[i]]; ok && candidates[i] != "" && sum == sums[podcast.FilePath] {
 			updatePodcastMatch(podcast, match)
 			return nil
 		}
@@ -1,75 +1,58 @@
 package internal
 
 import (
+	"bytes"
+	"fmt"
+	"math/rand/v2"
+	"net/url"
+	"os"
 	"path/filepath"
 	"testing"
 	"time"
 )
 
-func TestBuildExpectedDrivePath(t *testing.T) {
-	tests := []struct {
-		name     string
-		episode  *PodcastEpisode
-		expected string
-	}{
-		{
-			name: "basic episode",
-			episode: &PodcastEpisode{
-				ZTitle:    "Episode Title",
-				ShowName:  "Podcast Show",
-				Published: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC),
-				FilePath:  "/path/to/file.mp3",
-			},
-			expected: filepath.Join("Podcast Show", "2024-01-15 - Episode Title.mp3"),
-		},
-		{
-			name: "episode with special characters",
-			episode: &PodcastEpisode{
-				ZTitle:    "Episode: Test/File?",
-				ShowName:  "Show & Name",
-				Published: time.Date(2024, 3, 20, 0, 0, 0, 0, time.UTC),
-				FilePath:  "/path/to/file.mp3",
-			},
-			expected: filepath.Join("Show and Name", "2024-03-20 - Episode- Test-File.mp3"),
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := buildExpectedDrivePath(tt.episode)
-			if result != tt.expected {
-				t.Errorf("buildExpectedDrivePath() = %v, want %v", result, tt.expected)
-			}
-		})
-	}
-}
-
 func TestCanonicalizePathForMatching(t *testing.T) {
 	tests := []struct {
 		name     string
 		path     string
+		depth    int
 		expected string
 	}{
 		{
 			name:     "full path with multiple components",
 			path:     "/Volumes/Drive/Podcasts/Show Name/2024-01-15 - Episode.mp3",
+			depth:    2,
 			expected: filepath.Join("Show Name", "2024-01-15 - Episode.mp3"),
 		},
 		{
 			name:     "relative path with show and episode",
 			path:     "Show Name/2024-01-15 - Episode.mp3",
+			depth:    2,
 			expected: filepath.Join("Show Name", "2024-01-15 - Episode.mp3"),
 		},
 		{
 			name:     "single component path",
 			path:     "episode.mp3",
+			depth:    2,
 			expected: "episode.mp3",
 		},
+		{
+			name:     "year layout",
+			path:     "/Volumes/Drive/Podcasts/Show Name/2024/2024-01-15 - Episode.mp3",
+			depth:    3,
+			expected: filepath.Join("Show Name", "2024", "2024-01-15 - Episode.mp3"),
+		},
+		{
+			name:     "flat layout",
+			path:     "/Volumes/Drive/Podcasts/Show Name - 2024-01-15 - Episode.mp3",
+			depth:    1,
+			expected: "Show Name - 2024-01-15 - Episode.mp3",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := canonicalizePathForMatching(tt.path)
+			result := canonicalizePathForMatching(tt.path, tt.depth)
 			if result != tt.expected {
 				t.Errorf("canonicalizePathForMatching() = %v, want %v", result, tt.expected)
 			}
@@ -100,7 +83,7 @@ func TestMatchByPath(t *testing.T) {
 		2000: {localEpisode2},
 	}
 
-	matcher := NewPodcastMatcher(podcastsBySize)
+	matcher := NewPodcastMatcher(podcastsBySize, LayoutShow)
 
 	tests := []struct {
 		name          string
@@ -227,7 +210,7 @@ func TestMatch_CascadingStrategy(t *testing.T) {
 		1000: {localEpisode1, localEpisode2},
 	}
 
-	matcher := NewPodcastMatcher(podcastsBySize)
+	matcher := NewPodcastMatcher(podcastsBySize, LayoutShow)
 
 	tests := []struct {
 		name          string
@@ -295,7 +278,7 @@ func TestNewPodcastMatcher(t *testing.T) {
 		2000: {episode2},
 	}
 
-	matcher := NewPodcastMatcher(podcastsBySize)
+	matcher := NewPodcastMatcher(podcastsBySize, LayoutShow)
 
 	// Verify the matcher was created with correct data
 	if matcher.podcastsBySize == nil {
@@ -310,3 +293,208 @@ func TestNewPodcastMatcher(t *testing.T) {
 		t.Errorf("podcastsByPath length = %d, want 2", len(matcher.podcastsByPath))
 	}
 }
+
+// generatedDriveFile is a drive file from a generated layout along with the library
+// episodes it may legitimately be matched to; an empty accept set means it must stay unmatched
+type generatedDriveFile struct {
+	episode PodcastEpisode
+	layout  string
+	accept  map[string]bool // library titles
+}
+
+// generateMatcherCase writes a random library and drive layout to dir: size collisions,
+// duplicated audio under different titles, renamed and truncated copies, and unknown files
+func generateMatcherCase(t *testing.T, r *rand.Rand, dir string) ([]*PodcastEpisode, []generatedDriveFile) {
+	t.Helper()
+	write := func(path string, data []byte) {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	randomBytes := func(n int) []byte {
+		data := make([]byte, n)
+		for i := range data {
+			data[i] = byte(r.UintN(256))
+		}
+		return data
+	}
+
+	shows := []string{"The Daily Byte", "Long Walks", "History: Hour", "Kitchen & Science"}
+	collidingSizes := []int{512, 1024, 2048}
+	published := time.Date(2024, time.March, 28, 12, 0, 0, 0, time.UTC)
+
+	var library []*PodcastEpisode
+	contents := map[string][]byte{}
+	sizes := map[int64]int{}
+	for i := range 3 + r.IntN(10) {
+		ep := &PodcastEpisode{
+			ZTitle:    fmt.Sprintf("Episode %d", i),
+			ShowName:  shows[r.IntN(len(shows))],
+			Published: published.AddDate(0, 0, -i),
+			// Durations of distinct audio are far apart compared to the 2% tolerance
+			Duration: time.Duration(20+3*i) * time.Minute,
+		}
+		var data []byte
+		switch {
+		case i > 0 && r.IntN(6) == 0:
+			// The same audio published again under another title
+			original := library[r.IntN(len(library))]
+			data, ep.Duration = contents[original.ZTitle], original.Duration
+		case r.IntN(2) == 0:
+			data = randomBytes(collidingSizes[r.IntN(len(collidingSizes))])
+		default:
+			data = randomBytes(4096 + i*13)
+		}
+
+		path := filepath.Join(dir, "Library", fmt.Sprintf("episode-%02d.mp3", i))
+		write(path, data)
+		ep.FilePath = (&url.URL{Scheme: "file", Path: path}).String()
+		ep.FileSize = int64(len(data))
+		contents[ep.ZTitle] = data
+		sizes[ep.FileSize]++
+		library = append(library, ep)
+	}
+
+	// sameAudio lists the titles whose files are byte-identical to data
+	sameAudio := func(data []byte) map[string]bool {
+		titles := map[string]bool{}
+		for title, other := range contents {
+			if bytes.Equal(other, data) {
+				titles[title] = true
+			}
+		}
+		return titles
+	}
+	// unusedSize returns a size at most n that no library episode has
+	unusedSize := func(n int) int {
+		for n > 1 && sizes[int64(n)] > 0 {
+			n--
+		}
+		return n
+	}
+	driveDir := filepath.Join(dir, "Drive")
+	renamed := 0
+	renamedPath := func() string {
+		renamed++
+		return filepath.Join(driveDir, "Renamed", fmt.Sprintf("clip-%d.mp3", renamed))
+	}
+	// knownDuration is what duration extraction reports, which is sometimes nothing
+	knownDuration := func(d time.Duration) time.Duration {
+		if r.IntN(3) == 0 {
+			return 0
+		}
+		return d
+	}
+
+	var drive []generatedDriveFile
+	for _, ep := range library {
+		data := contents[ep.ZTitle]
+		var file generatedDriveFile
+		switch r.IntN(5) {
+		case 0:
+			continue // not on the drive
+		case 1:
+			file.layout = "exact"
+			file.episode.FilePath = filepath.Join(driveDir, LayoutShow.RelPath(*ep))
+			file.accept = map[string]bool{ep.ZTitle: true}
+		case 2:
+			file.layout = "renamed"
+			file.episode.FilePath = renamedPath()
+			file.episode.Duration = knownDuration(ep.Duration)
+			file.accept = sameAudio(data)
+		case 3:
+			file.layout = "truncated in place"
+			data = data[:unusedSize(len(data)-1-r.IntN(len(data)/2))]
+			file.episode.FilePath = filepath.Join(driveDir, LayoutShow.RelPath(*ep))
+			file.accept = map[string]bool{ep.ZTitle: true}
+		case 4:
+			file.layout = "truncated and renamed"
+			data = data[:unusedSize(len(data)-1-r.IntN(len(data)/2))]
+			file.episode.FilePath = renamedPath()
+			file.episode.Duration = knownDuration(ep.Duration)
+		}
+		write(file.episode.FilePath, data)
+		file.episode.FileSize = int64(len(data))
+		drive = append(drive, file)
+	}
+
+	for range r.IntN(4) {
+		file := generatedDriveFile{layout: "unknown", episode: PodcastEpisode{FilePath: renamedPath()}}
+		var data []byte
+		if r.IntN(2) == 0 {
+			// Collides in size with several library episodes, so it reaches the checksum fallback
+			for _, size := range collidingSizes {
+				if sizes[int64(size)] > 1 {
+					data = randomBytes(size)
+					file.episode.Duration = knownDuration(time.Minute)
+					break
+				}
+			}
+		}
+		if data == nil {
+			data = randomBytes(unusedSize(3000))
+		}
+		write(file.episode.FilePath, data)
+		file.episode.FileSize = int64(len(data))
+		drive = append(drive, file)
+	}
+	return library, drive
+}
+
+// TestMatch_Properties checks the cascade on generated libraries and drives: a file is only
+// ever matched to an episode it was copied from, and files that can't be identified stay unmatched
+func TestMatch_Properties(t *testing.T) {
+	for seed := range uint64(200) {
+		t.Run(fmt.Sprintf("seed=%d", seed), func(t *testing.T) {
+			r := rand.New(rand.NewPCG(seed, 0x9e3779b97f4a7c15))
+			library, drive := generateMatcherCase(t, r, t.TempDir())
+
+			bySize := map[int64][]*PodcastEpisode{}
+			byTitle := map[string]*PodcastEpisode{}
+			for _, ep := range library {
+				bySize[ep.FileSize] = append(bySize[ep.FileSize], ep)
+				byTitle[ep.ZTitle] = ep
+			}
+			matcher := NewPodcastMatcher(bySize, LayoutShow)
+
+			matched := map[string]bool{}
+			for _, file := range drive {
+				episode := file.episode
+				if err := matcher.Match(&episode); err != nil {
+					t.Fatalf("%s file %s: Match() error = %v", file.layout, file.episode.FilePath, err)
+				}
+
+				if len(file.accept) == 0 {
+					if episode.OnDrive {
+						t.Errorf("%s file %s matched %q, want no match", file.layout, file.episode.FilePath, episode.ZTitle)
+					}
+					continue
+				}
+				if !episode.OnDrive {
+					t.Errorf("%s file %s was not matched, want one of %v", file.layout, file.episode.FilePath, file.accept)
+					continue
+				}
+				if !file.accept[episode.ZTitle] {
+					t.Errorf("%s file %s matched %q, want one of %v", file.layout, file.episode.FilePath, episode.ZTitle, file.accept)
+					continue
+				}
+				source := byTitle[episode.ZTitle]
+				if episode.ShowName != source.ShowName || !episode.Published.Equal(source.Published) {
+					t.Errorf("%s file %s took metadata %q/%v, want %q/%v", file.layout, file.episode.FilePath,
+						episode.ShowName, episode.Published, source.ShowName, source.Published)
+				}
+				matched[episode.ZTitle] = true
+			}
+
+			// Only the library episodes something matched are marked as on the drive
+			for _, ep := range library {
+				if ep.OnDrive != matched[ep.ZTitle] {
+					t.Errorf("library episode %q OnDrive = %v, want %v", ep.ZTitle, ep.OnDrive, matched[ep.ZTitle])
+				}
+			}
+		})
+	}
+}
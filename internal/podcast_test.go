@@ -1,10 +1,14 @@
 package internal
 
 import (
+	"database/sql"
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
+	"unsafe"
 )
 
 func TestPodcastEpisode_Title(t *testing.T) {
@@ -29,6 +33,24 @@ func TestPodcastEpisode_Title(t *testing.T) {
 			},
 			expected: "✓ Test Episode",
 		},
+		{
+			name: "episode being transferred",
+			episode: PodcastEpisode{
+				ZTitle:        "Test Episode",
+				OnDrive:       true,
+				TransferState: FileFailed,
+			},
+			expected: "✗ Test Episode",
+		},
+		{
+			name: "starred episode on drive",
+			episode: PodcastEpisode{
+				ZTitle:   "Test Episode",
+				OnDrive:  true,
+				Favorite: true,
+			},
+			expected: "✓ ★ Test Episode",
+		},
 	}
 
 	for _, tt := range tests {
@@ -82,6 +104,14 @@ func TestPodcastEpisode_Description(t *testing.T) {
 			},
 			expected: "Test Show • 30:00",
 		},
+		{
+			name: "episode whose download was deleted",
+			episode: PodcastEpisode{
+				ShowName: "Test Show",
+				Missing:  true,
+			},
+			expected: "Test Show • not downloaded",
+		},
 	}
 
 	for _, tt := range tests {
@@ -105,6 +135,34 @@ func TestPodcastEpisode_FilterValue(t *testing.T) {
 	if got != expected {
 		t.Errorf("FilterValue() = %v, want %v", got, expected)
 	}
+
+	episode.ShowName = "Planet Money"
+	episode.Published = time.Date(2024, time.January, 5, 12, 0, 0, 0, time.UTC)
+	expected = "Planet Money Test Episode January 5 2024 2024-01-05"
+	if got := episode.FilterValue(); got != expected {
+		t.Errorf("FilterValue() = %v, want %v", got, expected)
+	}
+}
+
+func TestPodcastEpisode_Markdown(t *testing.T) {
+	episode := PodcastEpisode{
+		ZTitle:    "Episode Title",
+		ShowName:  "Podcast Show",
+		Published: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC),
+		Duration:  42 * time.Minute,
+		FileSize:  2048,
+		FilePath:  "file:///Library/Episode%20Title.mp3",
+	}
+	source, err := episode.SourcePath()
+	if err != nil || source != "/Library/Episode Title.mp3" {
+		t.Fatalf("SourcePath() = %q, %v", source, err)
+	}
+
+	want := "**Episode Title**\n\n- Show: Podcast Show\n- Published: 2024-01-15\n- Duration: 42:00\n- Size: 2.0 KB\n" +
+		"- Source: `/Library/Episode Title.mp3`\n"
+	if got := episode.Markdown(source, ""); got != want {
+		t.Errorf("Markdown() = %q, want %q", got, want)
+	}
 }
 
 func TestLoadLocalPodcasts(t *testing.T) {
@@ -146,6 +204,10 @@ func TestLoadLocalPodcasts(t *testing.T) {
 	if result[1].FileSize != 0 {
 		t.Errorf("Expected file size 0 for non-existent file, got %d", result[1].FileSize)
 	}
+
+	if time.Since(result[0].Downloaded) > time.Minute || !result[1].Downloaded.IsZero() {
+		t.Errorf("Expected the download time from the file, got %v and %v", result[0].Downloaded, result[1].Downloaded)
+	}
 }
 
 func TestConvertFileURIToPath(t *testing.T) {
@@ -237,3 +299,193 @@ func TestFormatDuration(t *testing.T) {
 		})
 	}
 }
+
+func TestMissingAssets(t *testing.T) {
+	tempDir := t.TempDir()
+	present := filepath.Join(tempDir, "present.mp3")
+	if err := os.WriteFile(present, []byte("audio"), 0o644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	gone := "file://" + filepath.Join(tempDir, "gone.mp3")
+
+	missing := MissingAssets([]PodcastEpisode{{FilePath: "file://" + present}, {FilePath: gone}})
+	if len(missing) != 1 || !missing[gone] {
+		t.Errorf("Expected only the deleted download to be missing, got %v", missing)
+	}
+
+	episodes, _ := LoadLocalPodcasts([]PodcastEpisode{{FilePath: gone}})
+	if !episodes[0].Missing {
+		t.Error("Expected LoadLocalPodcasts to mark the deleted download as missing")
+	}
+}
+
+func TestDownloadingAssets(t *testing.T) {
+	tempDir := t.TempDir()
+	growing := filepath.Join(tempDir, "growing.mp3")
+	finished := filepath.Join(tempDir, "finished.mp3")
+	for _, path := range []string{growing, finished} {
+		if err := os.WriteFile(path, make([]byte, 2000), 0o644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+	}
+	// A download that grew but hasn't been written for a while is complete
+	old := time.Now().Add(-time.Minute)
+	if err := os.Chtimes(finished, old, old); err != nil {
+		t.Fatalf("Failed to age file: %v", err)
+	}
+
+	episodes := []PodcastEpisode{
+		{FilePath: "file://" + growing, FileSize: 1000},
+		{FilePath: "file://" + finished, FileSize: 1000},
+		{FilePath: "file://" + growing},
+	}
+	downloading := DownloadingAssets(episodes)
+	if len(downloading) != 1 || !downloading["file://"+growing] {
+		t.Errorf("Expected only the growing download, got %v", downloading)
+	}
+
+	loaded, _ := LoadLocalPodcasts(episodes)
+	if !loaded[0].Downloading || loaded[1].Downloading || loaded[2].Downloading {
+		t.Errorf("Expected LoadLocalPodcasts to mark only the growing download, got %v, %v, %v", loaded[0].Downloading, loaded[1].Downloading, loaded[2].Downloading)
+	}
+	if !strings.Contains(loaded[0].Description(), "downloading…") {
+		t.Errorf("Expected a downloading badge, got %q", loaded[0].Description())
+	}
+}
+
+// writePodcastsLibrary creates a minimal Podcasts.app database under a temporary HOME
+func writePodcastsLibrary(t *testing.T, episodes [][4]string) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dir := filepath.Join(podcastsContainerPath(), "Documents")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := sql.Open("sqlite", filepath.Join(dir, "MTLibrary.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	statements := []string{
+		`CREATE TABLE ZMTPODCAST (ZUUID TEXT, ZTITLE TEXT, ZCATEGORY TEXT, ZSTORECOLLECTIONID INTEGER)`,
+		`CREATE TABLE ZMTEPISODE (ZTITLE TEXT, ZPODCASTUUID TEXT, ZASSETURL TEXT, ZPUBDATE INTEGER,
+			ZDURATION INTEGER, ZITEMDESCRIPTION TEXT, ZTRANSCRIPTIDENTIFIER TEXT, ZSTORETRACKID INTEGER, ZPLAYCOUNT INTEGER)`,
+	}
+	for _, statement := range statements {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatal(err)
+		}
+	}
+	shows := map[string]bool{}
+	for i, e := range episodes { // title, show, asset URL, description
+		if !shows[e[1]] {
+			shows[e[1]] = true
+			// Only the first show has a category and is in the directory, so the others cover NULLs
+			var category, collection any
+			if len(shows) == 1 {
+				category, collection = "News", 1000
+			}
+			if _, err := db.Exec(`INSERT INTO ZMTPODCAST VALUES (?, ?, ?, ?)`, e[1], e[1], category, collection); err != nil {
+				t.Fatal(err)
+			}
+		}
+		// The second episode has been played; the others are unplayed, with a play count of 0 or NULL
+		var plays any
+		switch i {
+		case 0:
+			plays = 0
+		case 1:
+			plays = 2
+		}
+		if _, err := db.Exec(`INSERT INTO ZMTEPISODE VALUES (?, ?, ?, ?, 1800, ?, NULL, ?, ?)`, e[0], e[1], e[2], 700000000-i, e[3], 2000+i, plays); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLoadMacPodcasts_WithoutTranscriptColumn(t *testing.T) {
+	writePodcastsLibrary(t, [][4]string{{"First", "Daily", "file:///library/1.mp3", ""}})
+	// Databases from before transcripts lack the column
+	db, err := sql.Open("sqlite", filepath.Join(podcastsContainerPath(), "Documents", "MTLibrary.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`ALTER TABLE ZMTEPISODE DROP COLUMN ZTRANSCRIPTIDENTIFIER`); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	episodes, err := LoadMacPodcasts()
+	if err != nil {
+		t.Fatalf("LoadMacPodcasts() error = %v", err)
+	}
+	if len(episodes) != 1 || episodes[0].TranscriptPath != "" {
+		t.Errorf("got %+v, want the episode without a transcript", episodes)
+	}
+}
+
+func TestLoadMacPodcasts_LeavesShowNotesForLater(t *testing.T) {
+	writePodcastsLibrary(t, [][4]string{
+		{"First", "Daily", "file:///library/1.mp3", "<p>First notes</p>"},
+		{"Second", "Daily", "file:///library/2.mp3", "<p>Second notes</p>"},
+		{"Third", "Weekly", "file:///library/3.mp3", ""},
+	})
+
+	episodes, err := LoadMacPodcasts()
+	if err != nil {
+		t.Fatalf("LoadMacPodcasts() error = %v", err)
+	}
+	if len(episodes) != 3 {
+		t.Fatalf("got %d episodes, want 3", len(episodes))
+	}
+	for _, e := range episodes {
+		if e.ShowNotes != "" {
+			t.Errorf("episode %q loaded with shownotes %q", e.ZTitle, e.ShowNotes)
+		}
+	}
+	if unsafe.StringData(episodes[0].ShowName) != unsafe.StringData(episodes[1].ShowName) {
+		t.Error("episodes of the same show should share one show name string")
+	}
+	if episodes[0].Genre != "News" || episodes[2].Genre != "" {
+		t.Errorf("got genres %q and %q, want the show's category and none", episodes[0].Genre, episodes[2].Genre)
+	}
+	if episodes[0].Played || !episodes[1].Played || episodes[2].Played {
+		t.Errorf("got played %v, %v and %v, want only the second played", episodes[0].Played, episodes[1].Played, episodes[2].Played)
+	}
+
+	notes := lazyShowNotes(episodes)
+	if notes["file:///library/1.mp3"] != "<p>First notes</p>" || notes["file:///library/2.mp3"] != "<p>Second notes</p>" {
+		t.Errorf("lazyShowNotes() = %v", notes)
+	}
+	single := lazyShowNotes(episodes[1:2])
+	if len(single) != 1 || single["file:///library/2.mp3"] != "<p>Second notes</p>" {
+		t.Errorf("lazyShowNotes() for one episode = %v", single)
+	}
+}
+
+func TestPodcastsAppURL(t *testing.T) {
+	writePodcastsLibrary(t, [][4]string{
+		{"First", "Daily", "file:///library/1.mp3", ""},
+		{"Second", "Weekly", "file:///library/2.mp3", ""},
+	})
+
+	url, err := PodcastsAppURL(PodcastEpisode{FilePath: "file:///library/1.mp3"})
+	if want := "podcasts://podcasts.apple.com/podcast/id1000?i=2000"; err != nil || url != want {
+		t.Errorf("PodcastsAppURL() = %q, %v, want %q", url, err, want)
+	}
+	if _, err := PodcastsAppURL(PodcastEpisode{FilePath: "file:///library/2.mp3"}); !errors.Is(err, ErrNotInDirectory) {
+		t.Errorf("PodcastsAppURL() for a show outside the directory = %v, want ErrNotInDirectory", err)
+	}
+	if _, err := PodcastsAppURL(PodcastEpisode{ZTitle: "Gone", FilePath: "file:///library/3.mp3"}); err == nil {
+		t.Error("PodcastsAppURL() for an episode not in the library should fail")
+	}
+}
+
+func TestAppleDate(t *testing.T) {
+	got := appleDate(0)
+	if want := time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC); !got.Equal(want) || got.Location() != time.UTC {
+		t.Errorf("appleDate(0) = %v, want %v in UTC", got, want)
+	}
+}
@@ -0,0 +1,105 @@
+package internal
+
+import (
+	"fmt"
+	"slices"
+)
+
+// SyncMode decides whether a show's episodes are picked automatically
+type SyncMode string
+
+const (
+	SyncManual SyncMode = ""       // episodes are only synced when selected by hand
+	SyncAlways SyncMode = "always" // new episodes are selected automatically
+	SyncNever  SyncMode = "never"  // episodes are never selected, even by sync all
+)
+
+// AutoSyncMode decides what the app syncs by itself when a drive is plugged in while it runs
+type AutoSyncMode string
+
+const (
+	AutoSyncOff      AutoSyncMode = ""         // the drive is only synced by hand
+	AutoSyncPolicies AutoSyncMode = "policies" // the new episodes of "always" shows, as watch mode does
+	AutoSyncUnsynced AutoSyncMode = "unsynced" // every episode not on the drive, except those of "never" shows
+)
+
+// ShowPolicy holds the sync rules for a single show, keyed by show name in Config.
+type ShowPolicy struct {
+	Sync SyncMode `json:"sync,omitempty"`
+	// Keep is the number of newest episodes to keep on the drive; 0 keeps everything
+	Keep int `json:"keep,omitempty"`
+}
+
+func (p ShowPolicy) validate(show string) error {
+	switch p.Sync {
+	case SyncManual, SyncAlways, SyncNever:
+	default:
+		return fmt.Errorf("invalid sync %q for show %q: must be \"always\" or \"never\"", p.Sync, show)
+	}
+	if p.Keep < 0 {
+		return fmt.Errorf("invalid keep %d for show %q: must not be negative", p.Keep, show)
+	}
+	return nil
+}
+
+// AutoSelect applies each show's sync mode to the library: exactly the episodes of "always" shows
+// that are downloaded but not on the drive are selected, and episodes of "never" shows are deselected.
+func AutoSelect(episodes []PodcastEpisode, cfg *Config) {
+	for i := range episodes {
+		switch cfg.PolicyFor(episodes[i].ShowName).Sync {
+		case SyncAlways:
+			episodes[i].Selected = !episodes[i].OnDrive && !episodes[i].Missing && !episodes[i].Downloading
+		case SyncNever:
+			episodes[i].Selected = false
+		}
+	}
+}
+
+// SelectAutoSync replaces the selection with the episodes mode syncs and returns how many it selected.
+// The episodes must be marked OnDrive for the drive being synced.
+func SelectAutoSync(episodes []PodcastEpisode, mode AutoSyncMode, cfg *Config) int {
+	for i := range episodes {
+		episodes[i].Selected = false
+	}
+	if mode == AutoSyncPolicies {
+		AutoSelect(episodes, cfg)
+	}
+	selected := 0
+	for i := range episodes {
+		if mode == AutoSyncUnsynced {
+			never := cfg.PolicyFor(episodes[i].ShowName).Sync == SyncNever
+			episodes[i].Selected = !episodes[i].OnDrive && !episodes[i].Missing && !episodes[i].Downloading && !never
+		}
+		if episodes[i].Selected {
+			selected++
+		}
+	}
+	return selected
+}
+
+// RetentionExcess returns the indexes, in ascending order, of drive episodes beyond their
+// show's keep limit of newest episodes. Episodes the config retains are neither counted nor returned.
+func RetentionExcess(episodes []PodcastEpisode, cfg *Config) []int {
+	byShow := map[string][]int{}
+	for i, episode := range episodes {
+		if cfg.PolicyFor(episode.ShowName).Keep == 0 || cfg.Retains(episode) {
+			continue
+		}
+		byShow[episode.ShowName] = append(byShow[episode.ShowName], i)
+	}
+
+	var excess []int
+	for show, indexes := range byShow {
+		keep := cfg.PolicyFor(show).Keep
+		if len(indexes) <= keep {
+			continue
+		}
+		// Newest first, so everything after the first keep entries is surplus
+		slices.SortStableFunc(indexes, func(a, b int) int {
+			return episodes[b].Published.Compare(episodes[a].Published)
+		})
+		excess = append(excess, indexes[keep:]...)
+	}
+	slices.Sort(excess)
+	return excess
+}
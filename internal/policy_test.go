@@ -0,0 +1,120 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestAutoSelect(t *testing.T) {
+	cfg := &Config{Shows: map[string]ShowPolicy{
+		"Daily":  {Sync: SyncAlways},
+		"Skip":   {Sync: SyncNever},
+		"Manual": {Keep: 3},
+	}}
+	episodes := []PodcastEpisode{
+		{ShowName: "Daily", ZTitle: "New"},
+		{ShowName: "Daily", ZTitle: "Already copied", OnDrive: true, Selected: true},
+		{ShowName: "Daily", ZTitle: "Deleted download", Missing: true},
+		{ShowName: "Skip", ZTitle: "Picked by hand", Selected: true},
+		{ShowName: "Manual", ZTitle: "Picked by hand", Selected: true},
+		{ShowName: "Manual", ZTitle: "Not picked"},
+	}
+
+	AutoSelect(episodes, cfg)
+
+	want := []bool{true, false, false, false, true, false}
+	for i, episode := range episodes {
+		if episode.Selected != want[i] {
+			t.Errorf("%s/%s: Selected = %v, want %v", episode.ShowName, episode.ZTitle, episode.Selected, want[i])
+		}
+	}
+}
+
+func TestSelectAutoSync(t *testing.T) {
+	cfg := &Config{Shows: map[string]ShowPolicy{
+		"Daily": {Sync: SyncAlways},
+		"Skip":  {Sync: SyncNever},
+	}}
+	library := []PodcastEpisode{
+		{ShowName: "Daily", ZTitle: "New"},
+		{ShowName: "Daily", ZTitle: "Already copied", OnDrive: true},
+		{ShowName: "Daily", ZTitle: "Deleted download", Missing: true},
+		{ShowName: "Skip", ZTitle: "Never synced"},
+		{ShowName: "Manual", ZTitle: "Picked by hand", Selected: true},
+		{ShowName: "Manual", ZTitle: "Not picked"},
+	}
+	for _, tt := range []struct {
+		mode AutoSyncMode
+		want []bool
+	}{
+		{AutoSyncOff, []bool{false, false, false, false, false, false}},
+		{AutoSyncPolicies, []bool{true, false, false, false, false, false}},
+		{AutoSyncUnsynced, []bool{true, false, false, false, true, true}},
+	} {
+		episodes := slices.Clone(library)
+		selected := SelectAutoSync(episodes, tt.mode, cfg)
+		for i, episode := range episodes {
+			if episode.Selected != tt.want[i] {
+				t.Errorf("%q: %s/%s: Selected = %v, want %v", tt.mode, episode.ShowName, episode.ZTitle, episode.Selected, tt.want[i])
+			}
+		}
+		if want := len(slices.DeleteFunc(slices.Clone(tt.want), func(b bool) bool { return !b })); selected != want {
+			t.Errorf("%q: SelectAutoSync() = %d, want %d", tt.mode, selected, want)
+		}
+	}
+}
+
+func TestRetentionExcess(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+	cfg := &Config{
+		KeepFavorites: true,
+		Shows:         map[string]ShowPolicy{"News": {Keep: 2}},
+	}
+	episodes := []PodcastEpisode{
+		{ShowName: "News", ZTitle: "Oldest", Published: day(1)},
+		{ShowName: "News", ZTitle: "Newest", Published: day(5)},
+		{ShowName: "Other", ZTitle: "Unlimited", Published: day(1)},
+		{ShowName: "News", ZTitle: "Starred", Published: day(2), Favorite: true},
+		{ShowName: "News", ZTitle: "Middle", Published: day(3)},
+		{ShowName: "News", ZTitle: "Second", Published: day(4)},
+	}
+
+	if got, want := RetentionExcess(episodes, cfg), []int{0, 4}; !slices.Equal(got, want) {
+		t.Errorf("RetentionExcess() = %v, want %v", got, want)
+	}
+	if got := RetentionExcess(episodes, nil); len(got) != 0 {
+		t.Errorf("Expected no excess without policies, got %v", got)
+	}
+}
+
+func TestConfig_SetPolicy(t *testing.T) {
+	cfg := &Config{}
+	cfg.SetPolicy("Show", ShowPolicy{Keep: 5})
+	if got := cfg.PolicyFor("Show"); got.Keep != 5 {
+		t.Errorf("Unexpected policy %+v", got)
+	}
+
+	cfg.SetPolicy("Show", ShowPolicy{})
+	if _, ok := cfg.Shows["Show"]; ok {
+		t.Error("Expected the zero policy to be dropped from the config")
+	}
+}
+
+func TestLoadConfig_InvalidShowPolicy(t *testing.T) {
+	for _, data := range []string{
+		`{"shows": {"Show": {"sync": "sometimes"}}}`,
+		`{"shows": {"Show": {"keep": -1}}}`,
+		`{"drives": {"CAR": {"autoSync": "always"}}}`,
+	} {
+		path := filepath.Join(t.TempDir(), "config.json")
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		if _, err := LoadConfig(path); err == nil {
+			t.Errorf("Expected an error loading %s", data)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+)
+
+// parsePowerSource reads the power source from the first line of `pmset -g batt`,
+// e.g. "Now drawing from 'AC Power'"
+func parsePowerSource(out string) (bool, error) {
+	first, _, _ := strings.Cut(out, "\n")
+	switch {
+	case strings.Contains(first, "'AC Power'"):
+		return true, nil
+	case strings.Contains(first, "'Battery Power'"):
+		return false, nil
+	}
+	return false, fmt.Errorf("unexpected pmset output %q", first)
+}
@@ -0,0 +1,31 @@
+package internal
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+)
+
+// holdWakeAssertion keeps the Mac from sleeping until release is called.
+// Releases are built without cgo, so rather than linking IOKit this runs caffeinate, which
+// holds the same IOKit power assertions (-i idle sleep, -s system sleep on AC power) on our
+// behalf. -w ties the assertion to this process, so it also ends if the app crashes.
+func holdWakeAssertion() (release func(), err error) {
+	cmd := exec.Command("/usr/bin/caffeinate", "-i", "-s", "-w", strconv.Itoa(os.Getpid()))
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	}, nil
+}
+
+// OnACPower reports whether the Mac is running on AC power, as reported by pmset
+func OnACPower() (bool, error) {
+	out, err := exec.Command("/usr/bin/pmset", "-g", "batt").Output()
+	if err != nil {
+		return false, err
+	}
+	return parsePowerSource(string(out))
+}
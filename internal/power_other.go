@@ -0,0 +1,12 @@
+//go:build !darwin
+
+package internal
+
+func holdWakeAssertion() (release func(), err error) {
+	return func() {}, nil
+}
+
+// OnACPower assumes AC power where the power source can't be read
+func OnACPower() (bool, error) {
+	return true, nil
+}
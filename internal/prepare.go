@@ -0,0 +1,71 @@
+package internal
+
+import (
+	"fmt"
+	"time"
+)
+
+// PrepareProgress is how far a sync is through examining the episodes before it knows what to copy:
+// first the library's files are looked up, then the selected episodes on the drive
+type PrepareProgress struct {
+	Examined, Total int
+}
+
+func (p PrepareProgress) String() string {
+	return fmt.Sprintf("examined %d of %d files", p.Examined, p.Total)
+}
+
+// prepareReportInterval is how long examining goes unreported, and then how often it is reported,
+// so quick syncs go straight to copying
+const prepareReportInterval = 100 * time.Millisecond
+
+// prepareReporter sends a sync's PrepareProgress to its sink, dropping updates the UI isn't
+// ready for. It leaves room in a ChanSink for the update with the totals, since callers like SyncAndWait
+// only read the channel once StartSync returns. A nil reporter reports nothing.
+type prepareReporter struct {
+	sink     ProgressSink
+	progress PrepareProgress
+	due      time.Time
+}
+
+func newPrepareReporter(sink ProgressSink, total int) *prepareReporter {
+	return &prepareReporter{sink: sink, progress: PrepareProgress{Total: total}, due: time.Now().Add(prepareReportInterval)}
+}
+
+// examined counts one more file examined
+func (r *prepareReporter) examined() {
+	if r == nil {
+		return
+	}
+	r.progress.Examined++
+	if now := time.Now(); now.After(r.due) && r.hasRoom() {
+		r.due = now.Add(prepareReportInterval)
+		progress := r.progress
+		r.sink.Send(FileOp{Preparing: &progress})
+	}
+}
+
+// hasRoom reports whether an update can be sent and still leave a slot for the totals
+func (r *prepareReporter) hasRoom() bool {
+	if ch, ok := r.sink.(ChanSink); ok {
+		return len(ch) < cap(ch)-1
+	}
+	return r.sink != nil
+}
+
+// skip takes n files that won't be examined after all out of the total
+func (r *prepareReporter) skip(n int) {
+	if r != nil {
+		r.progress.Total -= n
+	}
+}
+
+func countSelected(episodes []PodcastEpisode) int {
+	n := 0
+	for _, e := range episodes {
+		if e.Selected {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,44 @@
+package internal
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPrepareReporter(t *testing.T) {
+	ch := make(chan FileOp, 3)
+	r := newPrepareReporter(ChanSink(ch), 5)
+
+	// Quick preparations go unreported
+	r.examined()
+	if len(ch) != 0 {
+		t.Fatalf("Expected nothing reported before %v, got %d update(s)", prepareReportInterval, len(ch))
+	}
+
+	r.due = time.Now().Add(-time.Millisecond)
+	r.skip(1)
+	r.examined()
+	op := <-ch
+	if op.Preparing == nil || *op.Preparing != (PrepareProgress{Examined: 2, Total: 4}) {
+		t.Fatalf("Expected 2 of 4 examined, got %+v", op.Preparing)
+	}
+	if op.Complete || op.Error != nil {
+		t.Errorf("Expected a progress update, got %+v", op)
+	}
+	if got := op.Preparing.String(); got != "examined 2 of 4 files" {
+		t.Errorf("String() = %q", got)
+	}
+
+	// The last slot is left for the update with the totals
+	for range 3 {
+		r.due = time.Now().Add(-time.Millisecond)
+		r.examined()
+	}
+	if len(ch) != 2 {
+		t.Errorf("Expected 2 of 3 slots used, got %d", len(ch))
+	}
+
+	var none *prepareReporter
+	none.examined()
+	none.skip(1)
+}
@@ -0,0 +1,83 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// DestinationPreview is where a sync would write an episode, and how its name changed on the way
+type DestinationPreview struct {
+	Episode PodcastEpisode
+	// Path below the drive's podcasts folder; the first part's for split episodes
+	Path      string
+	Parts     int
+	Renamed   bool // characters the drive can't store were replaced
+	Truncated bool // a folder or file name was cut to the length limit
+	Unicode   bool // non-ASCII characters that simple players may not display
+	Collides  bool // another selected episode gets the same path and would be skipped as already synced
+	OnDrive   bool // already on the drive, so the sync skips it
+}
+
+// PreviewDestinations returns the destination of every selected episode for a sync to drive, in order.
+// Paths are compared case-insensitively, as FAT, exFAT and default APFS drives do.
+func PreviewDestinations(episodes []PodcastEpisode, drive USBDrive) []DestinationPreview {
+	profile := drive.Profile
+	podcastDir := filepath.Join(drive.MountPath, drive.Folder)
+
+	var previews []DestinationPreview
+	byPath := make(map[string][]int)
+	for _, episode := range episodes {
+		if !episode.Selected {
+			continue
+		}
+		preview := DestinationPreview{Episode: episode, Path: profile.EpisodePath(episode), Parts: profile.Split.Parts(episode)}
+		if preview.Parts > 1 {
+			preview.Path = partPath(preview.Path, 1, preview.Parts)
+		}
+		for _, name := range rawNames(episode, profile) {
+			name = strings.TrimSpace(name)
+			replaced := nameReplacer.Replace(name)
+			preview.Renamed = preview.Renamed || replaced != name
+			preview.Truncated = preview.Truncated || len(strings.TrimSpace(replaced)) > maxNameLength
+			preview.Unicode = preview.Unicode || strings.IndexFunc(name, func(r rune) bool { return r > unicode.MaxASCII }) >= 0
+		}
+		if _, err := os.Stat(filepath.Join(podcastDir, profile.syncedPath(episode))); err == nil {
+			preview.OnDrive = true
+		}
+
+		key := strings.ToLower(preview.Path)
+		byPath[key] = append(byPath[key], len(previews))
+		previews = append(previews, preview)
+	}
+
+	for _, indexes := range byPath {
+		if len(indexes) > 1 {
+			for _, i := range indexes {
+				previews[i].Collides = true
+			}
+		}
+	}
+	return previews
+}
+
+// rawNames returns the folder and file names the profile's layout and template build for episode,
+// before sanitizing
+func rawNames(episode PodcastEpisode, profile DriveProfile) []string {
+	t := profile.directoryTemplate()
+	name := t.EpisodeFormat
+	name = strings.ReplaceAll(name, "{title}", episode.ZTitle)
+	name = strings.ReplaceAll(name, "{date}", episode.Published.UTC().Format(t.DateFormat))
+	name = strings.ReplaceAll(name, "{show}", episode.ShowName)
+	show := strings.ReplaceAll(t.ShowNameFormat, "{show}", episode.ShowName)
+
+	switch profile.Layout {
+	case LayoutFlat:
+		return []string{name}
+	case LayoutGenre:
+		return []string{episode.Genre, show, name}
+	default:
+		return []string{show, name}
+	}
+}
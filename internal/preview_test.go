@@ -0,0 +1,70 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPreviewDestinations(t *testing.T) {
+	mountPath := t.TempDir()
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	episode := func(title string) PodcastEpisode {
+		return PodcastEpisode{ZTitle: title, ShowName: "Show", FilePath: "file:///library/" + title + ".mp3", Published: day, Selected: true}
+	}
+	synced := episode("Synced")
+	existing := filepath.Join(mountPath, LayoutShow.RelPath(synced))
+	if err := os.MkdirAll(filepath.Dir(existing), 0o755); err != nil {
+		t.Fatalf("Failed to create show folder: %v", err)
+	}
+	if err := os.WriteFile(existing, []byte("audio"), 0o644); err != nil {
+		t.Fatalf("Failed to write episode: %v", err)
+	}
+	long := episode("Long")
+	long.Duration = 3 * time.Hour
+	unselected := episode("Unselected")
+	unselected.Selected = false
+
+	previews := PreviewDestinations([]PodcastEpisode{
+		episode("Plain"),
+		episode("Q&A: Part 1"),
+		episode(strings.Repeat("x", 300)),
+		episode("Café"),
+		episode("Twins"),
+		episode("TWINS"),
+		synced,
+		long,
+		unselected,
+	}, USBDrive{MountPath: mountPath, Profile: DriveProfile{Split: SplitSettings{MaxMinutes: 60}}})
+
+	if len(previews) != 8 {
+		t.Fatalf("Expected a preview per selected episode, got %d", len(previews))
+	}
+	tests := []struct {
+		index int
+		check func(DestinationPreview) bool
+		want  string
+	}{
+		{0, func(p DestinationPreview) bool {
+			return p.Path == filepath.Join("Show", "2024-03-01 - Plain.mp3") && !p.Renamed && !p.Truncated && !p.Unicode && !p.Collides && !p.OnDrive
+		}, "an unchanged name"},
+		{1, func(p DestinationPreview) bool {
+			return p.Renamed && filepath.Base(p.Path) == "2024-03-01 - QandA- Part 1.mp3"
+		}, "replaced characters"},
+		{2, func(p DestinationPreview) bool { return p.Truncated }, "a truncated name"},
+		{3, func(p DestinationPreview) bool { return p.Unicode && !p.Renamed }, "non-ASCII characters"},
+		{4, func(p DestinationPreview) bool { return p.Collides }, "a case-insensitive collision"},
+		{5, func(p DestinationPreview) bool { return p.Collides }, "a case-insensitive collision"},
+		{6, func(p DestinationPreview) bool { return p.OnDrive }, "an episode already on the drive"},
+		{7, func(p DestinationPreview) bool {
+			return p.Parts == 3 && filepath.Base(p.Path) == "2024-03-01 - Long (Part 1 of 3).mp3"
+		}, "the first part of a split episode"},
+	}
+	for _, tt := range tests {
+		if !tt.check(previews[tt.index]) {
+			t.Errorf("Expected %s, got %+v", tt.want, previews[tt.index])
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package internal
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultStallThreshold is how long bytes must stop moving before a period counts as a stall
+const DefaultStallThreshold = 2 * time.Second
+
+// ProgressSample is one tick of transfer progress as recorded to disk
+type ProgressSample struct {
+	Elapsed time.Duration `json:"elapsed"`
+	Bytes   int64         `json:"bytes"`
+	Speed   float64       `json:"speed"`
+	File    string        `json:"file,omitempty"`
+}
+
+// ProgressRecorder appends progress samples of a single sync to a JSON lines file.
+// Safe for concurrent use.
+type ProgressRecorder struct {
+	mu    sync.Mutex
+	file  *os.File
+	w     *bufio.Writer
+	enc   *json.Encoder
+	start time.Time
+}
+
+// DefaultRecordingsDir returns the directory where progress recordings are written
+func DefaultRecordingsDir() string {
+	return filepath.Join(filepath.Dir(DefaultConfigPath()), "recordings")
+}
+
+// NewProgressRecorder creates a new timestamped recording file in dir
+func NewProgressRecorder(dir string) (*ProgressRecorder, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create recordings directory: %w", err)
+	}
+
+	now := time.Now()
+	path := filepath.Join(dir, "sync-"+now.Format("20060102-150405")+".jsonl")
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create recording: %w", err)
+	}
+
+	w := bufio.NewWriter(file)
+	return &ProgressRecorder{file: file, w: w, enc: json.NewEncoder(w), start: now}, nil
+}
+
+// Record appends a sample taken at now
+func (r *ProgressRecorder) Record(now time.Time, bytes int64, speed float64, file string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.enc == nil {
+		return
+	}
+	_ = r.enc.Encode(ProgressSample{Elapsed: now.Sub(r.start), Bytes: bytes, Speed: speed, File: file})
+}
+
+// Path returns the recording file location
+func (r *ProgressRecorder) Path() string {
+	return r.file.Name()
+}
+
+// Close flushes and closes the recording. Safe to call multiple times.
+func (r *ProgressRecorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.enc == nil {
+		return nil
+	}
+	r.enc = nil
+	if err := r.w.Flush(); err != nil {
+		r.file.Close()
+		return err
+	}
+	return r.file.Close()
+}
+
+// Stall is a period during which no bytes were transferred
+type Stall struct {
+	Start time.Duration
+	End   time.Duration
+	File  string
+}
+
+// RecordingAnalysis summarizes the throughput of a recorded sync
+type RecordingAnalysis struct {
+	Samples  int
+	Duration time.Duration
+	Bytes    int64
+	Average  float64
+	P50      float64
+	P90      float64
+	P99      float64
+	Min      float64
+	Max      float64
+	Stalls   []Stall
+}
+
+// AnalyzeRecording computes throughput percentiles and stall periods from a recording.
+// Percentiles use the instantaneous speed between consecutive samples rather than the smoothed speed.
+func AnalyzeRecording(r io.Reader, stallThreshold time.Duration) (RecordingAnalysis, error) {
+	var samples []ProgressSample
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var s ProgressSample
+		if err := json.Unmarshal([]byte(line), &s); err != nil {
+			return RecordingAnalysis{}, fmt.Errorf("invalid sample %q: %w", line, err)
+		}
+		samples = append(samples, s)
+	}
+	if err := scanner.Err(); err != nil {
+		return RecordingAnalysis{}, fmt.Errorf("failed to read recording: %w", err)
+	}
+
+	analysis := RecordingAnalysis{Samples: len(samples)}
+	if len(samples) < 2 {
+		return analysis, nil
+	}
+
+	first, last := samples[0], samples[len(samples)-1]
+	analysis.Duration = last.Elapsed - first.Elapsed
+	analysis.Bytes = last.Bytes - first.Bytes
+	if analysis.Duration > 0 {
+		analysis.Average = float64(analysis.Bytes) / analysis.Duration.Seconds()
+	}
+
+	speeds := make([]float64, 0, len(samples)-1)
+	var stall *Stall
+	for i := 1; i < len(samples); i++ {
+		prev, cur := samples[i-1], samples[i]
+		dt := (cur.Elapsed - prev.Elapsed).Seconds()
+		if dt > 0 {
+			speeds = append(speeds, math.Max(0, float64(cur.Bytes-prev.Bytes)/dt))
+		}
+
+		if cur.Bytes == prev.Bytes {
+			if stall == nil {
+				stall = &Stall{Start: prev.Elapsed, File: prev.File}
+			}
+			stall.End = cur.Elapsed
+			continue
+		}
+		if stall != nil && stall.End-stall.Start >= stallThreshold {
+			analysis.Stalls = append(analysis.Stalls, *stall)
+		}
+		stall = nil
+	}
+	if stall != nil && stall.End-stall.Start >= stallThreshold {
+		analysis.Stalls = append(analysis.Stalls, *stall)
+	}
+
+	if len(speeds) > 0 {
+		sort.Float64s(speeds)
+		analysis.Min = speeds[0]
+		analysis.Max = speeds[len(speeds)-1]
+		analysis.P50 = percentile(speeds, 50)
+		analysis.P90 = percentile(speeds, 90)
+		analysis.P99 = percentile(speeds, 99)
+	}
+
+	return analysis, nil
+}
+
+// percentile returns the nearest-rank percentile of sorted values
+func percentile(sorted []float64, p float64) float64 {
+	rank := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	rank = max(0, min(rank, len(sorted)-1))
+	return sorted[rank]
+}
+
+// LatestRecording returns the most recent recording in dir
+func LatestRecording(dir string) (string, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "sync-*.jsonl"))
+	if err != nil {
+		return "", err
+	}
+	if len(matches) == 0 {
+		return "", fmt.Errorf("no recordings found in %s", dir)
+	}
+	sort.Strings(matches)
+	return matches[len(matches)-1], nil
+}
@@ -0,0 +1,79 @@
+package internal
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProgressRecorder_RoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	rec, err := NewProgressRecorder(dir)
+	if err != nil {
+		t.Fatalf("Failed to create recorder: %v", err)
+	}
+
+	start := rec.start
+	rec.Record(start, 0, 0, "a.mp3")
+	rec.Record(start.Add(time.Second), 1024*1024, 1024*1024, "a.mp3")
+	if err := rec.Close(); err != nil {
+		t.Fatalf("Failed to close recorder: %v", err)
+	}
+	// Records after close are ignored rather than panicking
+	rec.Record(start.Add(2*time.Second), 0, 0, "")
+
+	latest, err := LatestRecording(dir)
+	if err != nil || latest != rec.Path() {
+		t.Fatalf("Expected latest recording %s, got %s (%v)", rec.Path(), latest, err)
+	}
+
+	file, err := os.Open(latest)
+	if err != nil {
+		t.Fatalf("Failed to open recording: %v", err)
+	}
+	defer file.Close()
+
+	a, err := AnalyzeRecording(file, DefaultStallThreshold)
+	if err != nil {
+		t.Fatalf("Failed to analyze: %v", err)
+	}
+	if a.Samples != 2 || a.Bytes != 1024*1024 {
+		t.Errorf("Unexpected analysis: %+v", a)
+	}
+}
+
+func TestAnalyzeRecording(t *testing.T) {
+	// 1 MB/s for 2s, a 3s stall on b.mp3, then 2 MB/s for 1s
+	recording := strings.Join([]string{
+		`{"elapsed":0,"bytes":0,"speed":0,"file":"a.mp3"}`,
+		`{"elapsed":1000000000,"bytes":1000000,"speed":0,"file":"a.mp3"}`,
+		`{"elapsed":2000000000,"bytes":2000000,"speed":0,"file":"b.mp3"}`,
+		`{"elapsed":3500000000,"bytes":2000000,"speed":0,"file":"b.mp3"}`,
+		`{"elapsed":5000000000,"bytes":2000000,"speed":0,"file":"b.mp3"}`,
+		`{"elapsed":6000000000,"bytes":4000000,"speed":0,"file":"b.mp3"}`,
+		``,
+	}, "\n")
+
+	a, err := AnalyzeRecording(strings.NewReader(recording), 2*time.Second)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if a.Duration != 6*time.Second || a.Bytes != 4000000 {
+		t.Errorf("Unexpected totals: duration %v, bytes %d", a.Duration, a.Bytes)
+	}
+	if a.Min != 0 || a.Max != 2000000 || a.P50 != 1000000 {
+		t.Errorf("Unexpected speeds: min %v, p50 %v, max %v", a.Min, a.P50, a.Max)
+	}
+	if len(a.Stalls) != 1 {
+		t.Fatalf("Expected 1 stall, got %d", len(a.Stalls))
+	}
+	if s := a.Stalls[0]; s.Start != 2*time.Second || s.End != 5*time.Second || s.File != "b.mp3" {
+		t.Errorf("Unexpected stall: %+v", s)
+	}
+
+	if _, err := AnalyzeRecording(strings.NewReader("not json"), time.Second); err == nil {
+		t.Error("Expected error for malformed recording")
+	}
+}
@@ -0,0 +1,140 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// ErrNameTaken is returned when renaming onto a file or folder that already exists
+var ErrNameTaken = errors.New("a file or folder with that name already exists")
+
+// Rename renames the episode file at path on drive to name, keeping its extension, or with show set
+// the show folder holding it. Companion files and manifest entries follow and the playlist and iTunesDB are rewritten.
+// Returns the old and new path below the podcasts folder, for updating the drive's custom order.
+func (ps *PodcastSync) Rename(drive USBDrive, path, name string, show bool) (string, string, error) {
+	root, err := podcastsRoot(drive)
+	if err != nil {
+		return "", "", err
+	}
+	name = sanitizeName(name)
+	if name == "" || name == "." || name == ".." {
+		return "", "", fmt.Errorf("invalid name %q", name)
+	}
+
+	from := filepath.Clean(path)
+	to := filepath.Join(filepath.Dir(from), name+filepath.Ext(from))
+	if show {
+		if from, err = showFolder(root, from, drive.Profile.Layout); err != nil {
+			return "", "", err
+		}
+		to = filepath.Join(filepath.Dir(from), name)
+	}
+	if to == from {
+		return "", "", nil
+	}
+	if err := errors.Join(checkInside(root, from), checkInside(root, to)); err != nil {
+		return "", "", err
+	}
+	// A name differing only in case is the same file on case-insensitive drives
+	if existing, err := os.Lstat(to); err == nil {
+		if current, err := os.Lstat(from); err != nil || !os.SameFile(existing, current) {
+			return "", "", fmt.Errorf("%w: %s", ErrNameTaken, filepath.Base(to))
+		}
+	}
+
+	if err := os.Rename(from, to); err != nil {
+		return "", "", fmt.Errorf("failed to rename %s: %w", filepath.Base(from), err)
+	}
+	if !show {
+		moveCompanions(from, to)
+	}
+
+	manifest, err := LoadManifest(root)
+	if err != nil {
+		return "", "", err
+	}
+	manifest.Move(from, to)
+	if err := manifest.Save(); err != nil {
+		return "", "", fmt.Errorf("failed to update drive manifest: %w", err)
+	}
+
+	fromRel, toRel := orderPath(root, from, show), orderPath(root, to, show)
+	profile := drive.Profile
+	profile.CustomOrder = retargetOrder(profile.CustomOrder, fromRel, toRel)
+	if err := writeDriveIndexes(root, profile); err != nil {
+		return fromRel, toRel, err
+	}
+	if dest := drive.destination(); dest != nil {
+		return fromRel, toRel, pushMirror(dest, root, nil)
+	}
+	return fromRel, toRel, nil
+}
+
+// showFolder returns the folder of the show the episode at path belongs to in layout
+func showFolder(root, path string, layout FolderLayout) (string, error) {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return "", err
+	}
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	// The show folder is the first component, below the genre folder in the genre layout
+	depth := 1
+	switch layout {
+	case LayoutFlat:
+		return "", errors.New("episodes in the flat layout have no show folder")
+	case LayoutGenre:
+		depth = 2
+	}
+	if len(parts) <= depth {
+		return "", fmt.Errorf("%s is not in a show folder", filepath.Base(path))
+	}
+	return filepath.Join(root, filepath.Join(parts[:depth]...)), nil
+}
+
+// orderPath is how the custom order refers to path: relative to the podcasts folder, and for
+// episode files without an index prefix
+func orderPath(root, path string, folder bool) string {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	if !folder {
+		rel = unnumbered(rel)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// retargetOrder points the entries of order for from, or for files below it, at to
+func retargetOrder(order []string, from, to string) []string {
+	if len(order) == 0 {
+		return order
+	}
+	retargeted := make([]string, len(order))
+	for i, entry := range order {
+		if rest, found := strings.CutPrefix(entry, from); found && (rest == "" || strings.HasPrefix(rest, "/")) {
+			entry = to + rest
+		}
+		retargeted[i] = entry
+	}
+	return retargeted
+}
+
+// RenameInOrder points the named drive's custom order at to wherever it listed from,
+// reporting whether anything changed
+func (c *Config) RenameInOrder(name, from, to string) bool {
+	profile, ok := c.Drives[name]
+	if !ok || from == "" {
+		return false
+	}
+	order := retargetOrder(profile.CustomOrder, from, to)
+	if slices.Equal(order, profile.CustomOrder) {
+		return false
+	}
+	profile.CustomOrder = order
+	c.Drives[name] = profile
+	return true
+}
@@ -0,0 +1,96 @@
+package internal
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func writeDriveFiles(t *testing.T, root string, files ...string) {
+	t.Helper()
+	for _, file := range files {
+		path := filepath.Join(root, filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create %s: %v", filepath.Dir(path), err)
+		}
+		if err := os.WriteFile(path, []byte(file), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", file, err)
+		}
+	}
+}
+
+func TestRename_Episode(t *testing.T) {
+	root := t.TempDir()
+	writeDriveFiles(t, root, "Show/Old.mp3", "Show/Old.chapters.json", "Show/Taken.mp3")
+	old := filepath.Join(root, "Show", "Old.mp3")
+	manifest, _ := LoadManifest(root)
+	manifest.Set(old, ManifestEntry{Show: "Show", Title: "Old"})
+	if err := manifest.Save(); err != nil {
+		t.Fatalf("Failed to save manifest: %v", err)
+	}
+	drive := USBDrive{MountPath: root, Profile: DriveProfile{Playlist: PlaylistCustom, CustomOrder: []string{"Show/Old.mp3"}}}
+
+	if _, _, err := NewPodcastSync().Rename(drive, old, "Taken", false); !errors.Is(err, ErrNameTaken) {
+		t.Fatalf("Expected renaming onto another episode to be refused, got %v", err)
+	}
+	from, to, err := NewPodcastSync().Rename(drive, old, "New: Part 1", false)
+	if err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+	if from != "Show/Old.mp3" || to != "Show/New- Part 1.mp3" {
+		t.Errorf("Rename = %q, %q; want the sanitized name", from, to)
+	}
+	for _, file := range []string{"New- Part 1.mp3", "New- Part 1.chapters.json"} {
+		if _, err := os.Stat(filepath.Join(root, "Show", file)); err != nil {
+			t.Errorf("Expected %s after the rename: %v", file, err)
+		}
+	}
+	manifest, _ = LoadManifest(root)
+	if entry, ok := manifest.Entry(filepath.Join(root, "Show", "New- Part 1.mp3")); !ok || entry.Title != "Old" {
+		t.Errorf("Expected the manifest entry to follow the file, got %+v", manifest.Entries)
+	}
+	playlist, _ := os.ReadFile(filepath.Join(root, PlaylistFile))
+	if lines := strings.Split(strings.TrimSpace(string(playlist)), "\n"); !slices.Contains(lines, "Show/New- Part 1.mp3") {
+		t.Errorf("Expected the playlist to list the new name, got:\n%s", playlist)
+	}
+}
+
+func TestRename_ShowFolder(t *testing.T) {
+	root := t.TempDir()
+	writeDriveFiles(t, root, "News/Other/2024/A.mp3", "News/Other/2024/B.mp3")
+	path := filepath.Join(root, "News", "Other", "2024", "A.mp3")
+	manifest, _ := LoadManifest(root)
+	manifest.Set(path, ManifestEntry{Show: "Other", Title: "A"})
+	if err := manifest.Save(); err != nil {
+		t.Fatalf("Failed to save manifest: %v", err)
+	}
+
+	flat := USBDrive{MountPath: root, Profile: DriveProfile{Layout: LayoutFlat}}
+	if _, _, err := NewPodcastSync().Rename(flat, path, "Daily", true); err == nil {
+		t.Error("Expected flat layouts to have no show folder to rename")
+	}
+
+	drive := USBDrive{MountPath: root, Profile: DriveProfile{Layout: LayoutGenre}}
+	from, to, err := NewPodcastSync().Rename(drive, path, "Daily", true)
+	if err != nil || from != "News/Other" || to != "News/Daily" {
+		t.Fatalf("Rename = %q, %q, %v; want News/Other, News/Daily", from, to, err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "News", "Daily", "2024", "B.mp3")); err != nil {
+		t.Errorf("Expected the whole folder to move: %v", err)
+	}
+	manifest, _ = LoadManifest(root)
+	if _, ok := manifest.Entry(filepath.Join(root, "News", "Daily", "2024", "A.mp3")); !ok {
+		t.Errorf("Expected the manifest entries below the folder to move, got %v", manifest.Entries)
+	}
+
+	cfg := &Config{Drives: map[string]DriveProfile{"STICK": {CustomOrder: []string{"News/Other/2024/A.mp3", "News/Otherwise/C.mp3"}}}}
+	if !cfg.RenameInOrder("STICK", from, to) {
+		t.Fatal("Expected the custom order to change")
+	}
+	if got := cfg.Drives["STICK"].CustomOrder; !slices.Equal(got, []string{"News/Daily/2024/A.mp3", "News/Otherwise/C.mp3"}) {
+		t.Errorf("Unexpected custom order %v", got)
+	}
+}
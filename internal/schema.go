@@ -0,0 +1,47 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// jsonMigration upgrades a decoded JSON document from one schema version to the next
+type jsonMigration func(doc map[string]json.RawMessage) error
+
+// migrateJSON upgrades data to the last schema in migrations, where migrations[i] upgrades
+// version i to i+1. A document without a "schema" key is version 0. Documents written by a
+// newer release are refused with guidance rather than silently losing fields.
+func migrateJSON(data []byte, migrations []jsonMigration, what, path string) ([]byte, error) {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse %s %s: %w", what, path, err)
+	}
+
+	var version int
+	if raw, ok := doc["schema"]; ok {
+		if err := json.Unmarshal(raw, &version); err != nil {
+			return nil, fmt.Errorf("invalid schema in %s %s: %w", what, path, err)
+		}
+	}
+
+	current := len(migrations)
+	if version > current {
+		return nil, newerSchemaError(what, path, version, current)
+	}
+	if version == current {
+		return data, nil
+	}
+
+	for v := version; v < current; v++ {
+		if err := migrations[v](doc); err != nil {
+			return nil, fmt.Errorf("failed to migrate %s %s from schema %d: %w", what, path, v, err)
+		}
+	}
+	doc["schema"] = json.RawMessage(fmt.Sprint(current))
+	return json.Marshal(doc)
+}
+
+func newerSchemaError(what, path string, version, current int) error {
+	return fmt.Errorf("%s %s uses schema %d but this version of podcasts-sync only understands schema %d: "+
+		"upgrade podcasts-sync, or move the file aside to start over", what, path, version, current)
+}
@@ -0,0 +1,146 @@
+package internal
+
+import (
+	"database/sql"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMigrateJSON(t *testing.T) {
+	migrations := []jsonMigration{
+		func(doc map[string]json.RawMessage) error { return nil },
+		// 1 → 2: rename "old" to "new"
+		func(doc map[string]json.RawMessage) error {
+			doc["new"] = doc["old"]
+			delete(doc, "old")
+			return nil
+		},
+	}
+
+	data, err := migrateJSON([]byte(`{"old": 7}`), migrations, "test file", "test.json")
+	if err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	var got struct {
+		Schema int
+		New    int
+		Old    int
+	}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Failed to decode migrated document: %v", err)
+	}
+	if got.Schema != 2 || got.New != 7 || got.Old != 0 {
+		t.Errorf("Expected every migration to run, got %+v", got)
+	}
+
+	current := []byte(`{"schema": 2, "new": 1}`)
+	if data, err := migrateJSON(current, migrations, "test file", "test.json"); err != nil || string(data) != string(current) {
+		t.Errorf("Expected current documents to pass through unchanged, got %s, %v", data, err)
+	}
+
+	_, err = migrateJSON([]byte(`{"schema": 3}`), migrations, "test file", "test.json")
+	if err == nil || !strings.Contains(err.Error(), "upgrade podcasts-sync") {
+		t.Errorf("Expected a newer schema to be refused with guidance, got %v", err)
+	}
+}
+
+func TestSchemaVersions_RefuseNewerFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	configPath := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(configPath, []byte(`{"schema": 99}`), 0o644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if _, err := LoadConfig(configPath); err == nil || !strings.Contains(err.Error(), "schema 99") {
+		t.Errorf("Expected a newer config to be refused, got %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, ManifestFile), []byte(`{"schema": 99, "entries": {}}`), 0o644); err != nil {
+		t.Fatalf("Failed to write manifest: %v", err)
+	}
+	if _, err := LoadManifest(dir); err == nil || !strings.Contains(err.Error(), "schema 99") {
+		t.Errorf("Expected a newer manifest to be refused, got %v", err)
+	}
+
+	dbPath := filepath.Join(dir, "history.db")
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	if _, err := db.Exec(`PRAGMA user_version = 99`); err != nil {
+		t.Fatalf("Failed to set user_version: %v", err)
+	}
+	db.Close()
+
+	h := NewHistory(dbPath)
+	defer h.Close()
+	if err := h.Record(HistoryEntry{Action: HistorySynced}); err == nil || !strings.Contains(err.Error(), "schema 99") {
+		t.Errorf("Expected a newer history database to be refused, got %v", err)
+	}
+}
+
+func TestSchemaVersions_MigrateUnversionedFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	configPath := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(configPath, []byte(`{"keepFavorites": true}`), 0o644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	cfg, err := LoadConfig(configPath)
+	if err != nil || !cfg.KeepFavorites || cfg.Schema != len(configMigrations) {
+		t.Errorf("Expected an unversioned config to migrate, got %+v, %v", cfg, err)
+	}
+
+	// A database created before versioning already has its tables and rows
+	dbPath := filepath.Join(dir, "history.db")
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	_, err = db.Exec(`
+		CREATE TABLE history (
+			id INTEGER PRIMARY KEY AUTOINCREMENT, run_id INTEGER NOT NULL, action TEXT NOT NULL,
+			title TEXT NOT NULL, show TEXT NOT NULL, source TEXT NOT NULL, drive TEXT NOT NULL,
+			error TEXT NOT NULL, at INTEGER NOT NULL
+		);
+		INSERT INTO history (run_id, action, title, show, source, drive, error, at)
+		VALUES (1, 'synced', 'Kept', 'Show', '', '', '', 0);
+	`)
+	if err != nil {
+		t.Fatalf("Failed to seed database: %v", err)
+	}
+	db.Close()
+
+	h := NewHistory(dbPath)
+	defer h.Close()
+	entries, err := h.Since(HistorySynced, time.Unix(0, 0))
+	if err != nil || len(entries) != 1 || entries[0].Title != "Kept" {
+		t.Errorf("Expected existing history to survive the migration, got %+v, %v", entries, err)
+	}
+	if err := h.SetFavorite(PodcastEpisode{ZTitle: "Kept", ShowName: "Show"}, true); err != nil {
+		t.Errorf("Expected the migration to add the favorites table, got %v", err)
+	}
+}
+
+func TestConfig_SaveRefusesAfterFailedLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	newer := []byte(`{"schema": 99, "futureSetting": true}`)
+	if err := os.WriteFile(path, newer, 0o644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err == nil {
+		t.Fatal("Expected the newer config to fail to load")
+	}
+	if err := cfg.Save(path); err == nil {
+		t.Error("Expected saving over a config that failed to load to be refused")
+	}
+	if data, _ := os.ReadFile(path); string(data) != string(newer) {
+		t.Errorf("Expected the newer config to be left alone, got %s", data)
+	}
+}
@@ -0,0 +1,145 @@
+package internal
+
+import (
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Relevance weights for each searchable field
+const (
+	titleMatchScore      = 10
+	titlePrefixBonus     = 5
+	showMatchScore       = 6
+	showNotesMatchScore  = 2
+	transcriptMatchScore = 1
+)
+
+var (
+	htmlTagPattern  = regexp.MustCompile(`<[^>]*>`)
+	transcriptCache sync.Map // TTML path -> lowercased transcript text
+)
+
+// SearchResult is an episode matched by SearchEpisodes with its relevance score
+type SearchResult struct {
+	Episode PodcastEpisode
+	Score   int
+}
+
+// SearchEpisodes ranks the episodes whose title, show name or, when includeContent is set,
+// shownotes and transcript contain every term of query. Results are ordered by score,
+// then by publish date with the newest first.
+func SearchEpisodes(episodes []PodcastEpisode, query string, includeContent bool) []SearchResult {
+	terms := strings.Fields(strings.ToLower(query))
+	if len(terms) == 0 {
+		return nil
+	}
+
+	// Shownotes are read for the duration of the search only, rather than kept with the library
+	var lazyNotes map[string]string
+	if includeContent {
+		lazyNotes = lazyShowNotes(episodes)
+	}
+
+	var results []SearchResult
+	for _, episode := range episodes {
+		notes := episode.ShowNotes
+		if notes == "" {
+			notes = lazyNotes[episode.FilePath]
+		}
+		if score := scoreEpisode(episode, notes, terms, includeContent); score > 0 {
+			results = append(results, SearchResult{Episode: episode, Score: score})
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Episode.Published.After(results[j].Episode.Published)
+	})
+
+	return results
+}
+
+// scoreEpisode returns 0 unless every term matches at least one field
+func scoreEpisode(episode PodcastEpisode, showNotes string, terms []string, includeContent bool) int {
+	title := strings.ToLower(episode.ZTitle)
+	show := strings.ToLower(episode.ShowName)
+
+	var notes, transcript string
+	if includeContent {
+		notes = strings.ToLower(stripHTML(showNotes))
+		transcript = transcriptText(episode.TranscriptPath)
+	}
+
+	total := 0
+	for _, term := range terms {
+		score := 0
+		if strings.Contains(title, term) {
+			score += titleMatchScore
+			if hasWordPrefix(title, term) {
+				score += titlePrefixBonus
+			}
+		}
+		if strings.Contains(show, term) {
+			score += showMatchScore
+		}
+		if strings.Contains(notes, term) {
+			score += showNotesMatchScore
+		}
+		if strings.Contains(transcript, term) {
+			score += transcriptMatchScore
+		}
+		if score == 0 {
+			return 0
+		}
+		total += score
+	}
+
+	return total
+}
+
+func hasWordPrefix(text, term string) bool {
+	for _, word := range strings.Fields(text) {
+		if strings.HasPrefix(word, term) {
+			return true
+		}
+	}
+	return false
+}
+
+func stripHTML(s string) string {
+	return htmlTagPattern.ReplaceAllString(s, " ")
+}
+
+// transcriptText returns the lowercased text of a TTML transcript, caching parsed files
+func transcriptText(path string) string {
+	if path == "" {
+		return ""
+	}
+	if cached, ok := transcriptCache.Load(path); ok {
+		return cached.(string)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	cues, err := parseTTML(file)
+	if err != nil {
+		return ""
+	}
+
+	parts := make([]string, len(cues))
+	for i, cue := range cues {
+		parts[i] = cue.Text
+	}
+	text := strings.ToLower(strings.Join(parts, " "))
+	transcriptCache.Store(path, text)
+	return text
+}
@@ -0,0 +1,96 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSearchEpisodes(t *testing.T) {
+	now := time.Now()
+	episodes := []PodcastEpisode{
+		{ZTitle: "The Money Episode", ShowName: "Planet Money", Published: now.Add(-48 * time.Hour)},
+		{ZTitle: "Interest rates", ShowName: "Planet Money", Published: now},
+		{ZTitle: "Space news", ShowName: "Science Weekly", ShowNotes: "<p>All about <b>money</b> in orbit</p>"},
+		{ZTitle: "Unrelated", ShowName: "Other"},
+	}
+
+	t.Run("empty query returns nothing", func(t *testing.T) {
+		if results := SearchEpisodes(episodes, "   ", false); len(results) != 0 {
+			t.Errorf("Expected no results, got %d", len(results))
+		}
+	})
+
+	t.Run("title matches rank above show matches", func(t *testing.T) {
+		results := SearchEpisodes(episodes, "money", false)
+		if len(results) != 2 {
+			t.Fatalf("Expected 2 results, got %d", len(results))
+		}
+		if results[0].Episode.ZTitle != "The Money Episode" {
+			t.Errorf("Expected title match first, got %q", results[0].Episode.ZTitle)
+		}
+	})
+
+	t.Run("all terms must match", func(t *testing.T) {
+		results := SearchEpisodes(episodes, "planet rates", false)
+		if len(results) != 1 || results[0].Episode.ZTitle != "Interest rates" {
+			t.Errorf("Expected only 'Interest rates', got %+v", results)
+		}
+	})
+
+	t.Run("content search includes shownotes without markup", func(t *testing.T) {
+		results := SearchEpisodes(episodes, "money", true)
+		if len(results) != 3 {
+			t.Fatalf("Expected 3 results, got %d", len(results))
+		}
+		if results[2].Episode.ZTitle != "Space news" {
+			t.Errorf("Expected shownotes match last, got %q", results[2].Episode.ZTitle)
+		}
+		if results := SearchEpisodes(episodes, "<b>", true); len(results) != 0 {
+			t.Errorf("Expected markup not to match, got %d results", len(results))
+		}
+	})
+
+	t.Run("content search includes transcripts", func(t *testing.T) {
+		ttmlPath := filepath.Join(t.TempDir(), "transcript.ttml")
+		if err := os.WriteFile(ttmlPath, []byte(testTTML), 0o644); err != nil {
+			t.Fatalf("Failed to write TTML: %v", err)
+		}
+		withTranscript := append(episodes, PodcastEpisode{ZTitle: "Talk", TranscriptPath: ttmlPath})
+
+		if results := SearchEpisodes(withTranscript, "hello", false); len(results) != 0 {
+			t.Errorf("Expected transcripts to be ignored without content search, got %d", len(results))
+		}
+		results := SearchEpisodes(withTranscript, "hello", true)
+		if len(results) != 1 || results[0].Episode.ZTitle != "Talk" {
+			t.Errorf("Expected transcript match, got %+v", results)
+		}
+	})
+}
+
+func TestSearchEpisodes_LoadsShowNotesLazily(t *testing.T) {
+	previous := showNotesLoader
+	t.Cleanup(func() { showNotesLoader = previous })
+	calls := 0
+	showNotesLoader = func(urls []string) (map[string]string, error) {
+		calls++
+		return map[string]string{"file:///library/1.mp3": "<p>A story about lighthouses</p>"}, nil
+	}
+
+	episodes := []PodcastEpisode{
+		{ZTitle: "Coastal Path", ShowName: "Long Walks", FilePath: "file:///library/1.mp3"},
+		{ZTitle: "Old Town", ShowName: "Long Walks", FilePath: "file:///library/2.mp3"},
+	}
+
+	if results := SearchEpisodes(episodes, "lighthouses", false); len(results) != 0 || calls != 0 {
+		t.Errorf("title search returned %d results after %d shownotes loads, want none", len(results), calls)
+	}
+	results := SearchEpisodes(episodes, "lighthouses", true)
+	if len(results) != 1 || results[0].Episode.ZTitle != "Coastal Path" {
+		t.Fatalf("content search = %+v, want Coastal Path", results)
+	}
+	if results[0].Episode.ShowNotes != "" {
+		t.Error("search results should not keep the lazily loaded shownotes")
+	}
+}
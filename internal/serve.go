@@ -0,0 +1,259 @@
+package internal
+
+import (
+	"cmp"
+	"encoding/xml"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// libraryRescan is how long the served episode list is reused before the folder is scanned again
+const libraryRescan = 30 * time.Second
+
+// LibraryServer serves the episodes in a podcasts folder to players on the network: a web page
+// with a player per episode, an RSS feed per show, and the audio files themselves.
+// Only scanned episodes are served, never other files in the folder.
+type LibraryServer struct {
+	podcastDir string
+	mux        *http.ServeMux
+
+	mu        sync.Mutex
+	scannedAt time.Time
+	episodes  []servedEpisode
+}
+
+type servedEpisode struct {
+	PodcastEpisode
+	// Rel is the slash-separated path below the podcasts folder
+	Rel string
+}
+
+// NewLibraryServer serves the episodes in podcastDir
+func NewLibraryServer(podcastDir string) *LibraryServer {
+	s := &LibraryServer{podcastDir: podcastDir, mux: http.NewServeMux()}
+	s.mux.HandleFunc("GET /{$}", s.serveIndex)
+	s.mux.HandleFunc("GET /feeds/{show}", s.serveFeed)
+	s.mux.HandleFunc("GET /files/{path...}", s.serveFile)
+	return s
+}
+
+func (s *LibraryServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// library returns the episodes in the folder, by show and newest first
+func (s *LibraryServer) library() ([]servedEpisode, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.episodes != nil && time.Since(s.scannedAt) < libraryRescan {
+		return s.episodes, nil
+	}
+
+	scanned, err := namedEpisodes(s.podcastDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", s.podcastDir, err)
+	}
+	episodes := make([]servedEpisode, 0, len(scanned))
+	for _, e := range scanned {
+		rel, err := filepath.Rel(s.podcastDir, e.FilePath)
+		if err != nil {
+			continue
+		}
+		// Episodes without a date in their name are dated by when they were written
+		if e.Published.IsZero() {
+			if info, err := os.Stat(e.FilePath); err == nil {
+				e.Published = info.ModTime()
+			}
+		}
+		episodes = append(episodes, servedEpisode{PodcastEpisode: e, Rel: filepath.ToSlash(rel)})
+	}
+	slices.SortStableFunc(episodes, func(a, b servedEpisode) int {
+		return cmp.Or(strings.Compare(a.ShowName, b.ShowName), b.Published.Compare(a.Published))
+	})
+	s.episodes, s.scannedAt = episodes, time.Now()
+	return episodes, nil
+}
+
+type servedShow struct {
+	Name     string
+	Feed     string
+	Episodes []servedEpisode
+}
+
+func groupShows(episodes []servedEpisode) []servedShow {
+	var shows []servedShow
+	for _, e := range episodes {
+		if len(shows) == 0 || shows[len(shows)-1].Name != e.ShowName {
+			shows = append(shows, servedShow{Name: e.ShowName, Feed: "/feeds/" + url.PathEscape(e.ShowName) + ".xml"})
+		}
+		shows[len(shows)-1].Episodes = append(shows[len(shows)-1].Episodes, e)
+	}
+	return shows
+}
+
+// FileURL is the path the file route serves the episode at
+func (e servedEpisode) FileURL() string {
+	return "/files/" + (&url.URL{Path: e.Rel}).EscapedPath()
+}
+
+var indexTemplate = template.Must(template.New("index").Funcs(template.FuncMap{
+	"date":     FormatDate,
+	"duration": formatDuration,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Podcasts</title>
+<style>
+body { font-family: sans-serif; max-width: 48em; margin: 1em auto; padding: 0 1em; }
+h2 { margin-top: 2em; }
+h2 a { font-size: 0.6em; font-weight: normal; margin-left: 1em; }
+li { margin: 1em 0; }
+audio { display: block; width: 100%; }
+small { color: #666; }
+</style>
+</head>
+<body>
+<h1>Podcasts</h1>
+{{range .}}
+<h2>{{.Name}}<a href="{{.Feed}}">RSS</a></h2>
+<ul>
+{{range .Episodes}}<li>{{.ZTitle}} <small>{{date .Published}}{{if .Duration}} · {{duration .Duration}}{{end}}</small>
+<audio controls preload="none" src="{{.FileURL}}"></audio></li>
+{{end}}</ul>
+{{else}}
+<p>No episodes yet.</p>
+{{end}}
+</body>
+</html>
+`))
+
+func (s *LibraryServer) serveIndex(w http.ResponseWriter, r *http.Request) {
+	episodes, err := s.library()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_ = indexTemplate.Execute(w, groupShows(episodes))
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	ITunes  string     `xml:"xmlns:itunes,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title       string    `xml:"title"`
+	Link        string    `xml:"link"`
+	Description string    `xml:"description"`
+	Items       []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title     string `xml:"title"`
+	GUID      rssGUID
+	PubDate   string       `xml:"pubDate"`
+	Enclosure rssEnclosure `xml:"enclosure"`
+	Duration  int          `xml:"itunes:duration,omitempty"`
+}
+
+type rssGUID struct {
+	XMLName   xml.Name `xml:"guid"`
+	Permalink bool     `xml:"isPermaLink,attr"`
+	Value     string   `xml:",chardata"`
+}
+
+type rssEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Length int64  `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+func (s *LibraryServer) serveFeed(w http.ResponseWriter, r *http.Request) {
+	show := strings.TrimSuffix(r.PathValue("show"), ".xml")
+	episodes, err := s.library()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	i := slices.IndexFunc(groupShows(episodes), func(g servedShow) bool { return g.Name == show })
+	if i < 0 {
+		http.NotFound(w, r)
+		return
+	}
+
+	// Players fetch enclosures themselves, so they need absolute URLs on the address they reached us at
+	base := "http://" + r.Host
+	feed := rssFeed{
+		Version: "2.0",
+		ITunes:  "http://www.itunes.com/dtds/podcast-1.0.dtd",
+		Channel: rssChannel{Title: show, Link: base + "/", Description: show + ", served by podcasts-sync"},
+	}
+	for _, e := range groupShows(episodes)[i].Episodes {
+		feed.Channel.Items = append(feed.Channel.Items, rssItem{
+			Title:     e.ZTitle,
+			GUID:      rssGUID{Value: e.Rel},
+			PubDate:   e.Published.UTC().Format(time.RFC1123Z),
+			Enclosure: rssEnclosure{URL: base + e.FileURL(), Length: e.FileSize, Type: audioType(e.Rel)},
+			Duration:  int(e.Duration.Seconds()),
+		})
+	}
+	w.Header().Set("Content-Type", "application/rss+xml; charset=utf-8")
+	_, _ = w.Write([]byte(xml.Header))
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	_ = enc.Encode(feed)
+}
+
+// serveFile serves an episode's audio, with range requests so players can seek
+func (s *LibraryServer) serveFile(w http.ResponseWriter, r *http.Request) {
+	rel := r.PathValue("path")
+	episodes, err := s.library()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	i := slices.IndexFunc(episodes, func(e servedEpisode) bool { return e.Rel == rel })
+	if i < 0 {
+		http.NotFound(w, r)
+		return
+	}
+	file, err := os.Open(episodes[i].FilePath)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", audioType(rel))
+	http.ServeContent(w, r, filepath.Base(rel), info.ModTime(), file)
+}
+
+// audioType returns the MIME type of an episode file by its extension
+func audioType(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".mp3":
+		return "audio/mpeg"
+	case ".m4a", ".aac":
+		return "audio/mp4"
+	case ".ogg", ".opus":
+		return "audio/ogg"
+	}
+	return "application/octet-stream"
+}
@@ -0,0 +1,79 @@
+package internal
+
+import (
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLibraryServer(t *testing.T) {
+	root := t.TempDir()
+	writeDriveFiles(t, root, "Show A/One.mp3", "Show A/Two.mp3", "Show B/Three.m4a")
+	// Episodes are dated by when they were written
+	older := time.Now().Add(-48 * time.Hour)
+	if err := os.Chtimes(filepath.Join(root, "Show A", "One.mp3"), older, older); err != nil {
+		t.Fatal(err)
+	}
+	manifest, _ := LoadManifest(root)
+	manifest.Set(filepath.Join(root, "Show A", "One.mp3"), ManifestEntry{Show: "Show A", Title: "One: The Pilot"})
+	if err := manifest.Save(); err != nil {
+		t.Fatalf("Failed to save manifest: %v", err)
+	}
+	server := httptest.NewServer(NewLibraryServer(root))
+	defer server.Close()
+
+	get := func(path string, header ...string) (*http.Response, string) {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, server.URL+path, nil)
+		if len(header) == 2 {
+			req.Header.Set(header[0], header[1])
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("GET %s failed: %v", path, err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp, string(body)
+	}
+
+	_, index := get("/")
+	for _, want := range []string{"One: The Pilot", "Two", "Three", `href="/feeds/Show%20A.xml"`, `src="/files/Show%20B/Three.m4a"`} {
+		if !strings.Contains(index, want) {
+			t.Errorf("Expected the index to contain %q", want)
+		}
+	}
+
+	resp, body := get("/feeds/Show%20A.xml")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected the feed of Show A, got %s", resp.Status)
+	}
+	var feed rssFeed
+	if err := xml.Unmarshal([]byte(body), &feed); err != nil {
+		t.Fatalf("Failed to parse feed: %v", err)
+	}
+	if len(feed.Channel.Items) != 2 || feed.Channel.Items[0].Title != "Two" || feed.Channel.Items[1].Title != "One: The Pilot" {
+		t.Fatalf("Expected Show A's episodes newest first, got %+v", feed.Channel.Items)
+	}
+	enclosure := feed.Channel.Items[1].Enclosure
+	if enclosure.URL != server.URL+"/files/Show%20A/One.mp3" || enclosure.Type != "audio/mpeg" {
+		t.Errorf("Expected an absolute MP3 enclosure, got %+v", enclosure)
+	}
+
+	resp, body = get("/files/Show%20A/One.mp3", "Range", "bytes=5-")
+	if resp.StatusCode != http.StatusPartialContent || body != "A/One.mp3" {
+		t.Errorf("Expected a range of the episode, got %s %q", resp.Status, body)
+	}
+
+	for _, path := range []string{"/files/.podcasts-sync.json", "/files/../etc/passwd", "/feeds/Nobody.xml"} {
+		if resp, _ := get(path); resp.StatusCode != http.StatusNotFound {
+			t.Errorf("Expected %s not to be served, got %s", path, resp.Status)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMeasureDriveSpeed(t *testing.T) {
+	previous := speedTestSize
+	speedTestSize = 4 * 1024 * 1024
+	t.Cleanup(func() { speedTestSize = previous })
+
+	drive := USBDrive{Name: "STICK", MountPath: t.TempDir()}
+	speed, err := MeasureDriveSpeed(drive)
+	if err != nil {
+		t.Fatalf("MeasureDriveSpeed() error = %v", err)
+	}
+	if speed.Write <= 0 || speed.Read <= 0 || speed.MeasuredAt.IsZero() {
+		t.Errorf("MeasureDriveSpeed() = %+v, want positive speeds and a timestamp", speed)
+	}
+	if _, err := os.Stat(filepath.Join(drive.MountPath, speedTestFile)); !os.IsNotExist(err) {
+		t.Error("speed test file was left on the drive")
+	}
+}
+
+func TestMeasureDriveSpeed_UnwritableDrive(t *testing.T) {
+	drive := USBDrive{Name: "GONE", MountPath: filepath.Join(t.TempDir(), "missing")}
+	if _, err := MeasureDriveSpeed(drive); err == nil {
+		t.Error("expected an error for a drive that can't be written")
+	}
+}
+
+func TestCopyBufferFor(t *testing.T) {
+	tests := []struct {
+		name  string
+		write float64
+		want  int
+	}{
+		{"unmeasured", 0, copyBufferSize},
+		{"slow drive", 200 * 1024, 64 * 1024},
+		{"typical stick", 20 * 1024 * 1024, 2 * 1024 * 1024},
+		{"fast SSD", 900 * 1024 * 1024, 4 * 1024 * 1024},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profile := DriveProfile{Speed: DriveSpeed{Write: tt.write}}
+			if got := copyBufferFor(profile); got != tt.want {
+				t.Errorf("copyBufferFor() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransferProgress_Remaining(t *testing.T) {
+	p := TransferProgress{TotalBytes: 100 << 20, BytesTransferred: 40 << 20}
+	if got := p.Remaining(0); got != 0 {
+		t.Errorf("Remaining() without any speed = %v, want 0", got)
+	}
+	if got := p.Remaining(10 << 20); got != 6*time.Second {
+		t.Errorf("Remaining() seeded with the drive speed = %v, want 6s", got)
+	}
+	p.Speed = 20 << 20
+	if got := p.Remaining(10 << 20); got != 3*time.Second {
+		t.Errorf("Remaining() with a measured speed = %v, want 3s", got)
+	}
+}
+
+func TestConfig_SetDriveSpeed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	cfg := &Config{Drives: map[string]DriveProfile{"STICK": {ExportChapters: true}}}
+	speed := DriveSpeed{Write: 1 << 20, Read: 2 << 20, MeasuredAt: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)}
+	cfg.SetDriveSpeed("STICK", speed)
+	cfg.SetDriveSpeed("CAR", speed)
+	if err := cfg.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stick := loaded.ProfileFor("STICK")
+	if !stick.ExportChapters || stick.Speed != speed {
+		t.Errorf("STICK profile = %+v, want chapters kept and speed %+v", stick, speed)
+	}
+	if loaded.ProfileFor("CAR").Speed != speed {
+		t.Errorf("CAR profile = %+v, want speed %+v", loaded.ProfileFor("CAR"), speed)
+	}
+}
@@ -0,0 +1,261 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// SplitSettings splits long episodes into parts, for drives formatted FAT32 (4 GB per file)
+// or players that choke on huge files. Zero limits leave episodes whole.
+type SplitSettings struct {
+	MaxSizeMB  int `json:"maxSizeMB,omitempty"`
+	MaxMinutes int `json:"maxMinutes,omitempty"`
+}
+
+func (s SplitSettings) validate() error {
+	if s.MaxSizeMB < 0 || s.MaxMinutes < 0 {
+		return fmt.Errorf("invalid split %+v: limits must not be negative", s)
+	}
+	return nil
+}
+
+// Parts returns how many parts episode is split into to stay within the limits; 1 keeps it whole.
+// Only MP3s are split, since their frames can be cut apart without re-encoding.
+func (s SplitSettings) Parts(episode PodcastEpisode) int {
+	if !strings.EqualFold(filepath.Ext(episode.FilePath), ".mp3") {
+		return 1
+	}
+	parts := 1
+	if s.MaxSizeMB > 0 {
+		parts = max(parts, int(math.Ceil(float64(episode.FileSize)/float64(int64(s.MaxSizeMB)<<20))))
+	}
+	if s.MaxMinutes > 0 {
+		parts = max(parts, int(math.Ceil(float64(episode.Duration)/float64(time.Duration(s.MaxMinutes)*time.Minute))))
+	}
+	return parts
+}
+
+// partSuffix matches the suffix partPath adds to the file names of a split episode
+var partSuffix = regexp.MustCompile(` \(Part \d+ of \d+\)$`)
+
+// partPath names part (counted from 1) of an episode stored whole at path, e.g. "Title (Part 1 of 3).mp3"
+func partPath(path string, part, parts int) string {
+	ext := filepath.Ext(path)
+	return fmt.Sprintf("%s (Part %d of %d)%s", strings.TrimSuffix(path, ext), part, parts, ext)
+}
+
+// wholePath returns the path of the whole episode a part's path belongs to; other paths are returned as is
+func wholePath(path string) string {
+	ext := filepath.Ext(path)
+	return partSuffix.ReplaceAllString(strings.TrimSuffix(path, ext), "") + ext
+}
+
+// syncedPath returns the path below the podcasts folder whose presence means episode was copied
+// completely: the episode itself, or its last part when the profile splits it
+func (p DriveProfile) syncedPath(episode PodcastEpisode) string {
+	path := p.EpisodePath(episode)
+	if parts := p.Split.Parts(episode); parts > 1 {
+		return partPath(path, parts, parts)
+	}
+	return path
+}
+
+// mp3Cuts returns parts+1 byte offsets that split the audio frames of the MP3 at path into parts of
+// about equal size, cutting only between frames. The ID3 tags and a Xing/Info/VBRI frame describing
+// the whole file are left out, since they would be wrong for every part.
+func mp3Cuts(path string, parts int) ([]int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	start, err := id3v2Size(f)
+	if err != nil {
+		return nil, err
+	}
+	end := info.Size()
+	if trailer := make([]byte, 3); end-start >= 128 {
+		if _, err := f.ReadAt(trailer, end-128); err == nil && bytes.Equal(trailer, []byte("TAG")) {
+			end -= 128 // ID3v1
+		}
+	}
+
+	first, err := nextMPEGFrame(f, start, end)
+	if err != nil {
+		return nil, err
+	}
+	frame := make([]byte, 4096)
+	n, _ := f.ReadAt(frame, first)
+	if header, ok := parseMPEGHeader(frame[:4]); ok && vbrFrameCount(frame[:n], header) > 0 {
+		first += int64(mpegFrameLength(frame[:4], header))
+	}
+
+	cuts := []int64{first}
+	for part := 1; part < parts; part++ {
+		cut, err := nextMPEGFrame(f, first+(end-first)*int64(part)/int64(parts), end)
+		if err != nil {
+			return nil, err
+		}
+		cuts = append(cuts, cut)
+	}
+	return append(cuts, end), nil
+}
+
+// nextMPEGFrame returns the offset of the first frame at or after from. A frame only counts when
+// another frame or the end of the audio follows it, so stray sync bytes inside audio data are skipped.
+func nextMPEGFrame(r io.ReaderAt, from, end int64) (int64, error) {
+	const maxFrame = 4096
+	buf := make([]byte, mp3ScanLimit+maxFrame)
+	n, err := r.ReadAt(buf, from)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return 0, err
+	}
+	buf = buf[:min(int64(n), end-from)]
+
+	for i := 0; i+4 <= len(buf) && i < mp3ScanLimit; i++ {
+		if buf[i] != 0xFF || buf[i+1]&0xE0 != 0xE0 {
+			continue
+		}
+		header, ok := parseMPEGHeader(buf[i : i+4])
+		if !ok {
+			continue
+		}
+		next := i + mpegFrameLength(buf[i:i+4], header)
+		if from+int64(next) == end {
+			return from + int64(i), nil
+		}
+		if next+4 <= len(buf) && buf[next] == 0xFF && buf[next+1]&0xE0 == 0xE0 {
+			if _, ok := parseMPEGHeader(buf[next : next+4]); ok {
+				return from + int64(i), nil
+			}
+		}
+	}
+	return 0, errNoAudioFrame
+}
+
+// mpegFrameLength returns the size in bytes of the frame starting with header b
+func mpegFrameLength(b []byte, h mpegHeader) int {
+	padding := int(b[2]>>1) & 0x01
+	if (b[1]>>1)&0x03 == 3 {
+		// Layer I counts in 4-byte slots
+		return (12*h.bitrate*1000/h.sampleRate + padding) * 4
+	}
+	return h.samplesPerFrame/8*h.bitrate*1000/h.sampleRate + padding
+}
+
+// copyParts copies the MP3 at srcPath into parts next to destPath, each a playable MP3 of its own.
+// A stall retry starts the episode over, since the transfer only tracks progress per episode.
+func (ps *PodcastSync) copyParts(episode PodcastEpisode, srcPath, destPath string, parts int) error {
+	cuts, err := mp3Cuts(srcPath, parts)
+	if err != nil {
+		return fmt.Errorf("failed to split %s: %w", filepath.Base(srcPath), err)
+	}
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	file := ps.tm.BeginFile(episode.ZTitle)
+	ps.tm.SetFileState(episode.FilePath, FileCopying)
+	for {
+		err := ps.writeParts(file, src, cuts, destPath)
+		if errors.Is(err, ErrFileAborted) {
+			switch file.takeAction() {
+			case FileSkip:
+				ps.skipEpisode(file, episode, partPath(destPath, 1, parts)+partialSuffix)
+				return nil
+			case FileRetry:
+				file.Restart(episode.ZTitle)
+				continue
+			}
+		}
+		if err != nil {
+			if ps.tm.IsStopped() {
+				return nil
+			}
+			return err
+		}
+		break
+	}
+
+	file.Complete(episode.FileSize)
+	ps.tm.SetFileState(episode.FilePath, FileDone)
+	ps.record(HistorySynced, episode, nil)
+	for part := 1; part <= parts; part++ {
+		path := partPath(destPath, part, parts)
+		partEpisode := episode
+		partEpisode.ZTitle = fmt.Sprintf("%s (Part %d of %d)", episode.ZTitle, part, parts)
+		ps.manifest.Set(path, ManifestEntry{
+			Show:     episode.ShowName,
+			Title:    partEpisode.ZTitle,
+			Size:     cuts[part] - cuts[part-1],
+			SyncedAt: time.Now(),
+		})
+		ps.journal.record(JournalCopied, path, "", nil)
+		// Companions describe the whole episode and go with the first part
+		ps.queueTagging(taggingJob{srcPath: srcPath, filePath: path, episode: partEpisode, laterPart: part > 1})
+	}
+	return nil
+}
+
+// writeParts writes each part through a partial file. On failure the parts written so far are removed.
+func (ps *PodcastSync) writeParts(file *FileTransfer, src *os.File, cuts []int64, destPath string) error {
+	parts := len(cuts) - 1
+	for part := 1; part <= parts; part++ {
+		path := partPath(destPath, part, parts)
+		if err := ps.writePart(file, src, cuts[part-1], cuts[part], path); err != nil {
+			for done := 1; done < part; done++ {
+				_ = os.Remove(partPath(destPath, done, parts))
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (ps *PodcastSync) writePart(file *FileTransfer, src *os.File, start, end int64, path string) error {
+	partialPath := path + partialSuffix
+	if err := writePartialInfo(partialPath, PartialInfo{Source: src.Name(), Size: end - start}); err != nil {
+		return err
+	}
+	dest, err := os.Create(partialPath)
+	if err != nil {
+		removePartial(partialPath)
+		return err
+	}
+	err = copySynced(dest, io.NewSectionReader(src, start, end-start), file, copyBufferFor(ps.profile))
+	if err == nil {
+		err = dest.Sync()
+	}
+	if closeErr := dest.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		if verifyErr := ps.verifyPart(src, start, end, partialPath); verifyErr != nil {
+			err = fmt.Errorf("failed to verify %s: %w", filepath.Base(path), verifyErr)
+		}
+	}
+	if err == nil {
+		err = os.Rename(partialPath, path)
+	}
+	if err != nil {
+		removePartial(partialPath)
+		return err
+	}
+	_ = os.Remove(partialPath + ".json")
+	return nil
+}
@@ -0,0 +1,103 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestSplitSettings_Parts(t *testing.T) {
+	long := PodcastEpisode{FilePath: "file:///library/long.mp3", FileSize: 300 << 20, Duration: 150 * time.Minute}
+	tests := []struct {
+		name     string
+		settings SplitSettings
+		episode  PodcastEpisode
+		want     int
+	}{
+		{"no limits", SplitSettings{}, long, 1},
+		{"by size", SplitSettings{MaxSizeMB: 100}, long, 3},
+		{"by length", SplitSettings{MaxMinutes: 60}, long, 3},
+		{"stricter limit wins", SplitSettings{MaxSizeMB: 250, MaxMinutes: 30}, long, 5},
+		{"within limits", SplitSettings{MaxSizeMB: 500, MaxMinutes: 180}, long, 1},
+		{"not an mp3", SplitSettings{MaxMinutes: 60}, PodcastEpisode{FilePath: "file:///library/long.m4a", Duration: 150 * time.Minute}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.settings.Parts(tt.episode); got != tt.want {
+				t.Errorf("Parts() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMP3Cuts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "episode.mp3")
+	writeCBRMP3(t, path, 100)
+
+	cuts, err := mp3Cuts(path, 3)
+	if err != nil {
+		t.Fatalf("mp3Cuts failed: %v", err)
+	}
+	// Each cut is the first frame boundary at or after a third of the audio
+	want := []int64{0, 34 * 417, 67 * 417, 100 * 417}
+	if !slices.Equal(cuts, want) {
+		t.Errorf("mp3Cuts() = %v, want %v", cuts, want)
+	}
+}
+
+func TestPodcastSync_StartSync_SplitsLongEpisodes(t *testing.T) {
+	tempDir := t.TempDir()
+	source := filepath.Join(tempDir, "long.mp3")
+	writeCBRMP3(t, source, 100)
+	episode := PodcastEpisode{
+		ZTitle: "Long", ShowName: "Show", FilePath: "file://" + source, Selected: true,
+		Published: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Duration: 3 * time.Hour,
+	}
+	drive := USBDrive{Name: "DRIVE", MountPath: filepath.Join(tempDir, "drive"), Profile: DriveProfile{Split: SplitSettings{MaxMinutes: 60}}}
+
+	sync := func() (files int) {
+		ch := make(chan FileOp, 100)
+		NewPodcastSync().StartSync([]PodcastEpisode{episode}, drive, ChanSink(ch))
+		for op := range ch {
+			if op.Error != nil {
+				t.Fatalf("Sync failed: %v", op.Error)
+			}
+			files = max(files, op.Progress.TotalFiles)
+		}
+		return files
+	}
+	if files := sync(); files != 1 {
+		t.Fatalf("Expected one episode to copy, got %d", files)
+	}
+
+	showDir := filepath.Join(drive.MountPath, "Show")
+	for part := 1; part <= 3; part++ {
+		path := partPath(filepath.Join(showDir, "2024-01-01 - Long.mp3"), part, 3)
+		if d, err := ReadDuration(path); err != nil || d <= 0 {
+			t.Errorf("Expected part %d to be a playable MP3, got %v (%v)", part, d, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(showDir, "2024-01-01 - Long.mp3")); !os.IsNotExist(err) {
+		t.Errorf("Expected no whole copy of a split episode, got %v", err)
+	}
+	if files := sync(); files != 0 {
+		t.Errorf("Expected a split episode to count as synced, got %d files to copy", files)
+	}
+
+	library := episode
+	scanned, err := NewPodcastScanner(DirectoryTemplate{}).ScanDrive(drive, map[int64][]*PodcastEpisode{0: {&library}})
+	if err != nil {
+		t.Fatalf("ScanDrive failed: %v", err)
+	}
+	var titles []string
+	for _, e := range scanned {
+		titles = append(titles, e.ZTitle)
+	}
+	slices.Sort(titles)
+	want := []string{"Long (Part 1 of 3)", "Long (Part 2 of 3)", "Long (Part 3 of 3)"}
+	if !slices.Equal(titles, want) || !library.OnDrive {
+		t.Errorf("Expected the parts to match the library episode, got %v", titles)
+	}
+}
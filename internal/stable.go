@@ -0,0 +1,35 @@
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+)
+
+// unchanged reports whether the file at path already holds exactly data
+func unchanged(path string, data []byte) bool {
+	existing, err := os.ReadFile(path)
+	return err == nil && bytes.Equal(existing, data)
+}
+
+// writeCompanion writes a file exported beside an episode. With stable output, a file that already
+// holds the same content is left untouched so replication doesn't transfer it again.
+func writeCompanion(path string, data []byte, stable bool) error {
+	if stable && unchanged(path, data) {
+		return nil
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// keepSourceTime gives a copied episode the modification time of its source, which tagging replaced,
+// so the copy looks the same to rsync however often it is synced
+func keepSourceTime(srcPath, destPath string) error {
+	info, err := os.Stat(srcPath)
+	if err != nil {
+		return fmt.Errorf("failed to read the time of %s: %w", srcPath, err)
+	}
+	if err := os.Chtimes(destPath, info.ModTime(), info.ModTime()); err != nil {
+		return fmt.Errorf("failed to set the time of %s: %w", destPath, err)
+	}
+	return nil
+}
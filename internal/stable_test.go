@@ -0,0 +1,84 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWriteCompanion_Stable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "episode.shownotes.html")
+	old := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := os.WriteFile(path, []byte("notes"), 0o644); err != nil {
+		t.Fatalf("Failed to write companion: %v", err)
+	}
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatalf("Failed to set time: %v", err)
+	}
+
+	if err := writeCompanion(path, []byte("notes"), true); err != nil {
+		t.Fatalf("writeCompanion failed: %v", err)
+	}
+	if info, _ := os.Stat(path); !info.ModTime().Equal(old) {
+		t.Errorf("Expected an unchanged companion to be left alone, modified at %v", info.ModTime())
+	}
+
+	if err := writeCompanion(path, []byte("new notes"), true); err != nil {
+		t.Fatalf("writeCompanion failed: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "new notes" {
+		t.Errorf("Expected a changed companion to be rewritten, got %q", data)
+	}
+}
+
+func TestWritePlaylist_Stable(t *testing.T) {
+	podcastDir := t.TempDir()
+	profile := DriveProfile{Playlist: PlaylistOldest, Stable: true}
+	episode := filepath.Join(podcastDir, "Show", "2024-06-01 - Episode.mp3")
+	if err := os.MkdirAll(filepath.Dir(episode), 0o755); err != nil {
+		t.Fatalf("Failed to create folder: %v", err)
+	}
+	if err := os.WriteFile(episode, []byte("not really audio"), 0o644); err != nil {
+		t.Fatalf("Failed to write episode: %v", err)
+	}
+	if err := WritePlaylist(podcastDir, profile); err != nil {
+		t.Fatalf("WritePlaylist failed: %v", err)
+	}
+
+	path := filepath.Join(podcastDir, PlaylistFile)
+	old := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatalf("Failed to set time: %v", err)
+	}
+	if err := WritePlaylist(podcastDir, profile); err != nil {
+		t.Fatalf("WritePlaylist failed: %v", err)
+	}
+	if info, _ := os.Stat(path); !info.ModTime().Equal(old) {
+		t.Errorf("Expected an unchanged playlist to be left alone, modified at %v", info.ModTime())
+	}
+}
+
+func TestKeepSourceTime(t *testing.T) {
+	dir := t.TempDir()
+	src, dest := filepath.Join(dir, "source.mp3"), filepath.Join(dir, "copy.mp3")
+	for _, path := range []string{src, dest} {
+		if err := os.WriteFile(path, []byte("audio"), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+	published := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	if err := os.Chtimes(src, published, published); err != nil {
+		t.Fatalf("Failed to set time: %v", err)
+	}
+
+	if err := keepSourceTime(src, dest); err != nil {
+		t.Fatalf("keepSourceTime failed: %v", err)
+	}
+	if info, _ := os.Stat(dest); !info.ModTime().Equal(published) {
+		t.Errorf("Expected the copy to take the source's time %v, got %v", published, info.ModTime())
+	}
+	if err := keepSourceTime(filepath.Join(dir, "missing.mp3"), dest); err == nil {
+		t.Error("Expected an error for a missing source")
+	}
+}
@@ -0,0 +1,180 @@
+package internal
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// volumeUUIDPattern matches the volume UUIDs profiles can be keyed by instead of a volume name, as
+// diskutil prints them
+var volumeUUIDPattern = regexp.MustCompile(`^[0-9A-Fa-f]{8}-[0-9A-Fa-f]{4}-[0-9A-Fa-f]{4}-[0-9A-Fa-f]{4}-[0-9A-Fa-f]{12}$`)
+
+// volumeUUID asks diskutil for the UUID of the volume mounted at mountPath; tests replace it
+var volumeUUID = func(mountPath string) (string, error) {
+	out, err := healthCommand("diskutil", "info", mountPath).Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to read volume UUID: %w", err)
+	}
+	return parseDiskutilInfo(string(out))["Volume UUID"], nil
+}
+
+// sharedNameSuffix matches the number macOS appends to the mount point of a volume whose name is
+// already mounted, as in "USB DRIVE 1"
+var sharedNameSuffix = regexp.MustCompile(`^(.+) [0-9]+$`)
+
+// volumeNames maps each mount point in /Volumes to its volume's name, dropping the number macOS adds
+// when another volume of that name is mounted
+func volumeNames(entries []string) map[string]string {
+	mounted := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		mounted[entry] = true
+	}
+	names := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		names[entry] = entry
+		if m := sharedNameSuffix.FindStringSubmatch(entry); m != nil && mounted[m[1]] {
+			names[entry] = m[1]
+		}
+	}
+	return names
+}
+
+// profileFor returns the profile of the drive mounted at mountPath, and the volume UUID it is keyed by.
+// A profile keyed by the volume's UUID wins over one keyed by its name, so drives that share a name,
+// like two sticks both called NO NAME, can be told apart. A drive whose name is shared is keyed by
+// its UUID even before it has a profile of its own, so settings saved for it don't apply to the other.
+// diskutil is only asked while some profile is keyed by a UUID or a name is shared, and once for each
+// time a drive is mounted.
+func (dm *DriveManager) profileFor(name, mountPath string, shared bool) (DriveProfile, string) {
+	dm.mu.Lock()
+	keyedByUUID := dm.keyedByUUID
+	uuid, cached := dm.uuids[mountPath]
+	dm.mu.Unlock()
+
+	if keyedByUUID || shared {
+		if !cached {
+			// A volume without a UUID, like some FAT sticks, is looked up by name. diskutil is asked
+			// outside the lock, so a slow answer doesn't hold up other detections.
+			uuid, _ = volumeUUID(mountPath)
+			uuid = strings.ToUpper(uuid)
+		}
+		dm.mu.Lock()
+		defer dm.mu.Unlock()
+		dm.uuids[mountPath] = uuid
+		if profile, ok := dm.profiles[uuid]; ok && uuid != "" {
+			return profile, uuid
+		}
+		if shared && uuid != "" {
+			return dm.profiles[name], uuid
+		}
+		return dm.profiles[name], ""
+	}
+	return dm.profile(name), ""
+}
+
+// profile returns the profile saved under key, the volume name or UUID
+func (dm *DriveManager) profile(key string) DriveProfile {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	return dm.profiles[key]
+}
+
+// profileSnapshot returns the profiles, for iterating without holding the lock
+func (dm *DriveManager) profileSnapshot() map[string]DriveProfile {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	return dm.profiles
+}
+
+// forgetUnmounted drops the cached UUIDs of volumes no longer mounted, so a different drive mounted
+// under the same name is looked up again
+func (dm *DriveManager) forgetUnmounted(mounted map[string]bool) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	for mountPath := range dm.uuids {
+		if !mounted[mountPath] {
+			delete(dm.uuids, mountPath)
+		}
+	}
+}
+
+// ProfileKey returns the key of the drive's profile in Config.Drives: its volume UUID when its profile
+// is keyed by it, otherwise its name
+func (d USBDrive) ProfileKey() string {
+	if d.UUID != "" {
+		return d.UUID
+	}
+	return d.Name
+}
+
+func (t *DirectoryTemplate) validate() error {
+	switch {
+	case t == nil:
+		return nil
+	case t.EpisodeFormat != "" && !strings.Contains(t.EpisodeFormat, "{title}"):
+		return fmt.Errorf("invalid template episodeFormat %q: must contain {title}", t.EpisodeFormat)
+	case t.ShowNameFormat != "" && !strings.Contains(t.ShowNameFormat, "{show}"):
+		return fmt.Errorf("invalid template showFormat %q: must contain {show}", t.ShowNameFormat)
+	case strings.ContainsAny(t.EpisodeFormat+t.ShowNameFormat+t.DateFormat, `/\`):
+		return fmt.Errorf("invalid template %+v: formats name a single folder or file, so can't contain slashes", *t)
+	}
+	return nil
+}
+
+// withTemplate returns base with the formats the profile's template sets replacing base's
+func (p DriveProfile) withTemplate(base DirectoryTemplate) DirectoryTemplate {
+	if p.Template == nil {
+		return base
+	}
+	if p.Template.ShowNameFormat != "" {
+		base.ShowNameFormat = p.Template.ShowNameFormat
+	}
+	if p.Template.EpisodeFormat != "" {
+		base.EpisodeFormat = p.Template.EpisodeFormat
+	}
+	if p.Template.DateFormat != "" {
+		base.DateFormat = p.Template.DateFormat
+	}
+	return base
+}
+
+// directoryTemplate returns the template the drive's folders and files are named after: the profile's
+// own, with the formats it leaves out taken from its layout
+func (p DriveProfile) directoryTemplate() DirectoryTemplate {
+	t := defaultDirTemplate
+	t.EpisodeFormat = p.Layout.episodeFormat()
+	return p.withTemplate(t)
+}
+
+// showFolder names the folder of show after the template's show format
+func (t DirectoryTemplate) showFolder(show string) string {
+	return sanitizeName(strings.ReplaceAll(t.ShowNameFormat, "{show}", show))
+}
+
+// showFromFolder reads the show name back from a folder named by showFolder
+func (t DirectoryTemplate) showFromFolder(folder string) string {
+	before, after, ok := strings.Cut(t.ShowNameFormat, "{show}")
+	if !ok {
+		return folder
+	}
+	before, after = sanitizeName(before), sanitizeName(after)
+	if len(folder) > len(before)+len(after) && strings.HasPrefix(folder, before) && strings.HasSuffix(folder, after) {
+		return strings.TrimSpace(folder[len(before) : len(folder)-len(after)])
+	}
+	return folder
+}
+
+// validFolder checks the profile's folder setting names a folder below the drive's root
+func (p DriveProfile) validFolder() error {
+	switch {
+	case p.Folder == "":
+		return nil
+	case !filepath.IsLocal(filepath.FromSlash(p.Folder)):
+		return fmt.Errorf("invalid folder %q: must be a folder below the drive's root", p.Folder)
+	case p.IPod:
+		return fmt.Errorf("invalid folder %q: iPods keep episodes in %s", p.Folder, IPodPodcastsFolder)
+	}
+	return nil
+}
@@ -0,0 +1,288 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDriveManager_DetectDrives_UUIDProfiles(t *testing.T) {
+	volumes := t.TempDir()
+	for _, name := range []string{"NO NAME", "WALKMAN"} {
+		if err := os.Mkdir(filepath.Join(volumes, name), 0o755); err != nil {
+			t.Fatalf("Failed to create volume: %v", err)
+		}
+	}
+	const carUUID = "0A1B2C3D-4E5F-6071-8293-A4B5C6D7E8F9"
+	lookups := 0
+	uuids := map[string]string{"NO NAME": carUUID}
+	original := volumeUUID
+	volumeUUID = func(mountPath string) (string, error) {
+		lookups++
+		return uuids[filepath.Base(mountPath)], nil
+	}
+	defer func() { volumeUUID = original }()
+
+	dm := NewDriveManager(volumes, DirectoryTemplate{})
+	dm.SetProfiles(map[string]DriveProfile{
+		"NO NAME": {Folder: "Other"},
+		carUUID:   {Folder: "Music/Podcasts", Layout: LayoutFlat},
+		"WALKMAN": {Folder: "PODCASTS"},
+	})
+	drives, err := dm.DetectDrives()
+	if err != nil {
+		t.Fatalf("DetectDrives() failed: %v", err)
+	}
+	if len(drives) != 2 {
+		t.Fatalf("Expected 2 drives, got %d", len(drives))
+	}
+	for _, drive := range drives {
+		switch drive.Name {
+		case "NO NAME":
+			if drive.UUID != carUUID || drive.ProfileKey() != carUUID || drive.Folder != filepath.FromSlash("Music/Podcasts") {
+				t.Errorf("Expected the UUID-keyed profile to win, got %+v", drive)
+			}
+		case "WALKMAN":
+			if drive.UUID != "" || drive.ProfileKey() != "WALKMAN" || drive.Folder != "PODCASTS" {
+				t.Errorf("Expected the name-keyed profile, got %+v", drive)
+			}
+		}
+	}
+
+	// UUIDs are looked up once per mount
+	if _, err := dm.DetectDrives(); err != nil {
+		t.Fatalf("DetectDrives() failed: %v", err)
+	}
+	if lookups != 2 {
+		t.Errorf("Expected 2 UUID lookups, got %d", lookups)
+	}
+
+	// A different stick mounted under the same name is looked up again
+	if err := os.Remove(filepath.Join(volumes, "NO NAME")); err != nil {
+		t.Fatalf("Failed to unmount: %v", err)
+	}
+	if _, err := dm.DetectDrives(); err != nil {
+		t.Fatalf("DetectDrives() failed: %v", err)
+	}
+	if err := os.Mkdir(filepath.Join(volumes, "NO NAME"), 0o755); err != nil {
+		t.Fatalf("Failed to mount: %v", err)
+	}
+	uuids["NO NAME"] = ""
+	drives, err = dm.DetectDrives()
+	if err != nil {
+		t.Fatalf("DetectDrives() failed: %v", err)
+	}
+	for _, drive := range drives {
+		if drive.Name == "NO NAME" && (drive.UUID != "" || drive.Folder != "Other") {
+			t.Errorf("Expected the name-keyed profile for a stick without the UUID, got %+v", drive)
+		}
+	}
+}
+
+func TestDriveManager_DetectDrives_NoUUIDLookups(t *testing.T) {
+	volumes := t.TempDir()
+	if err := os.Mkdir(filepath.Join(volumes, "CAR"), 0o755); err != nil {
+		t.Fatalf("Failed to create volume: %v", err)
+	}
+	original := volumeUUID
+	volumeUUID = func(string) (string, error) {
+		t.Error("Expected no UUID lookup while no profile is keyed by a UUID")
+		return "", nil
+	}
+	defer func() { volumeUUID = original }()
+
+	dm := NewDriveManager(volumes, DirectoryTemplate{})
+	dm.SetProfiles(map[string]DriveProfile{"CAR": {Layout: LayoutFlat}})
+	if _, err := dm.DetectDrives(); err != nil {
+		t.Fatalf("DetectDrives() failed: %v", err)
+	}
+}
+
+func TestDriveManager_DetectDrives_SharedNames(t *testing.T) {
+	volumes := t.TempDir()
+	for _, name := range []string{"USB DRIVE", "USB DRIVE 1", "WALKMAN 2"} {
+		if err := os.Mkdir(filepath.Join(volumes, name), 0o755); err != nil {
+			t.Fatalf("Failed to create volume: %v", err)
+		}
+	}
+	const (
+		redUUID  = "0A1B2C3D-4E5F-6071-8293-A4B5C6D7E8F9"
+		blueUUID = "1A1B2C3D-4E5F-6071-8293-A4B5C6D7E8F9"
+	)
+	uuids := map[string]string{"USB DRIVE": redUUID, "USB DRIVE 1": blueUUID, "WALKMAN 2": ""}
+	original := volumeUUID
+	volumeUUID = func(mountPath string) (string, error) {
+		return uuids[filepath.Base(mountPath)], nil
+	}
+	defer func() { volumeUUID = original }()
+
+	dm := NewDriveManager(volumes, DirectoryTemplate{})
+	dm.SetProfiles(map[string]DriveProfile{
+		"USB DRIVE": {Folder: "Shared"},
+		blueUUID:    {Folder: "Blue"},
+	})
+	drives, err := dm.DetectDrives()
+	if err != nil {
+		t.Fatalf("DetectDrives() failed: %v", err)
+	}
+	byUUID := map[string]USBDrive{}
+	for _, drive := range drives {
+		byUUID[drive.UUID] = drive
+	}
+	if red := byUUID[redUUID]; red.Name != "USB DRIVE" || !red.Shared || red.Folder != "Shared" {
+		t.Errorf("Expected the first stick keyed by its UUID with the name's profile, got %+v", red)
+	}
+	if blue := byUUID[blueUUID]; blue.Name != "USB DRIVE" || !blue.Shared || blue.Folder != "Blue" {
+		t.Errorf("Expected the second stick named after its volume with its own profile, got %+v", blue)
+	}
+	// A number is only dropped when the name without it is mounted too
+	if walkman := byUUID[""]; walkman.Name != "WALKMAN 2" || walkman.Shared {
+		t.Errorf("Expected a name ending in a number to be kept, got %+v", walkman)
+	}
+}
+
+func TestDriveManager_DetectDrives_Concurrent(t *testing.T) {
+	volumes := t.TempDir()
+	for _, name := range []string{"USB DRIVE", "USB DRIVE 1"} {
+		if err := os.Mkdir(filepath.Join(volumes, name), 0o755); err != nil {
+			t.Fatalf("Failed to create volume: %v", err)
+		}
+	}
+	original := volumeUUID
+	volumeUUID = func(mountPath string) (string, error) {
+		return "0A1B2C3D-4E5F-6071-8293-A4B5C6D7E8F" + strconv.Itoa(len(filepath.Base(mountPath))%10), nil
+	}
+	defer func() { volumeUUID = original }()
+
+	dm := NewDriveManager(volumes, DirectoryTemplate{})
+	profiles := map[string]DriveProfile{"USB DRIVE": {Folder: "Shared"}}
+	dm.SetProfiles(profiles)
+
+	// The poll, the mount watcher and a retry can all detect drives at once
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Go(func() {
+			for range 20 {
+				if _, err := dm.DetectDrives(); err != nil {
+					t.Errorf("DetectDrives() failed: %v", err)
+				}
+				if i == 0 {
+					dm.SetProfiles(profiles)
+				}
+			}
+		})
+	}
+	wg.Wait()
+}
+
+func TestUSBDrive_SharedTitle(t *testing.T) {
+	drive := USBDrive{Name: "USB DRIVE", Shared: true, Capacity: 16 << 30}
+	if got := drive.Title(); got != "USB DRIVE (16.0 GB, never synced)" {
+		t.Errorf("Expected the size and no sync, got %q", got)
+	}
+	drive.Profile.LastSync = time.Date(2024, time.March, 5, 9, 0, 0, 0, time.UTC)
+	if got := drive.Title(); got != "USB DRIVE (16.0 GB, synced Mar 5 2024)" {
+		t.Errorf("Expected the last sync, got %q", got)
+	}
+	if got := (USBDrive{Name: "CAR"}).Title(); got != "CAR" {
+		t.Errorf("Expected a lone drive to show its name only, got %q", got)
+	}
+
+	other := USBDrive{Name: "USB DRIVE", MountPath: "/Volumes/USB DRIVE", UUID: "A"}
+	swapped := other
+	swapped.UUID = "B"
+	if other.SameDrive(swapped) {
+		t.Error("Expected a different volume mounted at the same path to be another drive")
+	}
+}
+
+func TestDriveProfile_RelPath_Template(t *testing.T) {
+	episode := PodcastEpisode{
+		ZTitle:    "Episode Title",
+		ShowName:  "Podcast Show",
+		Published: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC),
+		FilePath:  "/path/to/file.mp3",
+	}
+
+	tests := []struct {
+		name     string
+		profile  DriveProfile
+		expected string
+	}{
+		{"layout defaults", DriveProfile{Template: &DirectoryTemplate{}}, filepath.Join("Podcast Show", "2024-01-15 - Episode Title.mp3")},
+		{"episode format", DriveProfile{Template: &DirectoryTemplate{EpisodeFormat: "{title}"}}, filepath.Join("Podcast Show", "Episode Title.mp3")},
+		{"show format", DriveProfile{Template: &DirectoryTemplate{ShowNameFormat: "Show - {show}"}}, filepath.Join("Show - Podcast Show", "2024-01-15 - Episode Title.mp3")},
+		{"date format", DriveProfile{Template: &DirectoryTemplate{DateFormat: "060102"}}, filepath.Join("Podcast Show", "240115 - Episode Title.mp3")},
+		{"flat", DriveProfile{Layout: LayoutFlat, Template: &DirectoryTemplate{EpisodeFormat: "{show} {title}"}}, "Podcast Show Episode Title.mp3"},
+		{"year", DriveProfile{Layout: LayoutYear, Template: &DirectoryTemplate{ShowNameFormat: "[{show}]"}}, filepath.Join("[Podcast Show]", "2024", "2024-01-15 - Episode Title.mp3")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.profile.RelPath(episode); got != tt.expected {
+				t.Errorf("RelPath() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestScanDrive_Template(t *testing.T) {
+	episode := PodcastEpisode{ZTitle: "First", ShowName: "Daily News", Published: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), FilePath: "file:///library/first.mp3", FileSize: 1000}
+	profile := DriveProfile{
+		Layout:   LayoutYear,
+		Folder:   "Music/Podcasts",
+		Template: &DirectoryTemplate{ShowNameFormat: "Show - {show}", EpisodeFormat: "{date} {title}", DateFormat: "20060102"},
+	}
+	drive := USBDrive{Name: "WALKMAN", MountPath: t.TempDir(), Folder: driveFolder(profile), Profile: profile}
+	path := filepath.Join(drive.MountPath, drive.Folder, profile.RelPath(episode))
+	if want := filepath.Join(drive.MountPath, "Music", "Podcasts", "Show - Daily News", "2024", "20240201 First.mp3"); path != want {
+		t.Fatalf("Expected the episode at %s, got %s", want, path)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("Failed to create folder: %v", err)
+	}
+	if err := os.WriteFile(path, make([]byte, episode.FileSize), 0o644); err != nil {
+		t.Fatalf("Failed to write episode: %v", err)
+	}
+
+	// Another episode of the same size leaves the path as the only way to tell them apart
+	other := PodcastEpisode{ZTitle: "Second", ShowName: "Daily News", Published: time.Date(2024, 2, 2, 0, 0, 0, 0, time.UTC), FilePath: "file:///library/second.mp3", FileSize: 1000}
+	local := []PodcastEpisode{other, episode}
+	bySize := map[int64][]*PodcastEpisode{1000: {&local[0], &local[1]}}
+	scanned, err := NewPodcastScanner(DirectoryTemplate{}).ScanDrive(drive, bySize)
+	if err != nil {
+		t.Fatalf("ScanDrive failed: %v", err)
+	}
+	if len(scanned) != 1 {
+		t.Fatalf("Expected 1 episode, got %d", len(scanned))
+	}
+	if got := scanned[0]; got.ZTitle != episode.ZTitle || got.ShowName != episode.ShowName || !got.Published.Equal(episode.Published) {
+		t.Errorf("Expected %s/%s matched by the template's names, got %+v", episode.ShowName, episode.ZTitle, got)
+	}
+	if local[0].OnDrive || !local[1].OnDrive {
+		t.Error("Expected only the copied episode to be marked on the drive")
+	}
+}
+
+func TestLoadConfig_InvalidTemplate(t *testing.T) {
+	for name, profile := range map[string]string{
+		"no title":      `{"template": {"episodeFormat": "{date}"}}`,
+		"no show":       `{"template": {"showFormat": "Podcasts"}}`,
+		"slash":         `{"template": {"episodeFormat": "{date}/{title}"}}`,
+		"outside drive": `{"folder": "../podcasts"}`,
+		"absolute":      `{"folder": "/podcasts"}`,
+		"iPod":          `{"ipod": true, "folder": "Podcasts"}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.json")
+			if err := os.WriteFile(path, []byte(`{"drives": {"CAR": `+profile+`}}`), 0o644); err != nil {
+				t.Fatalf("Failed to write config: %v", err)
+			}
+			if _, err := LoadConfig(path); err == nil {
+				t.Errorf("Expected an error for %s", profile)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package internal
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Transcript file suffixes written next to a synced episode
+const (
+	transcriptTextSuffix = ".txt"
+	transcriptSRTSuffix  = ".srt"
+)
+
+// transcriptCue is a single timed paragraph from a TTML transcript
+type transcriptCue struct {
+	Begin time.Duration
+	End   time.Duration
+	Text  string
+}
+
+// podcastsContainerPath returns the Apple Podcasts group container directory
+func podcastsContainerPath() string {
+	return filepath.Join(
+		os.Getenv("HOME"),
+		"Library/Group Containers/243LU875E5.groups.com.apple.podcasts",
+	)
+}
+
+// resolveTranscriptPath maps a ZTRANSCRIPTIDENTIFIER to the cached TTML file, if it has been downloaded.
+// Podcasts.app sometimes appends a suffix to the cached file name, so a prefix match is used as a fallback.
+func resolveTranscriptPath(identifier string) string {
+	if identifier == "" {
+		return ""
+	}
+
+	path := filepath.Join(podcastsContainerPath(), "Library/Cache/Assets/TTML", identifier)
+	if exists, _ := fileExists(path); exists {
+		return path
+	}
+
+	matches, err := filepath.Glob(path + "*")
+	if err != nil || len(matches) == 0 {
+		return ""
+	}
+	return matches[0]
+}
+
+// parseTTML extracts one cue per <p> element from a TTML document
+func parseTTML(r io.Reader) ([]transcriptCue, error) {
+	decoder := xml.NewDecoder(r)
+
+	var (
+		cues    []transcriptCue
+		current *transcriptCue
+		words   []string
+	)
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse transcript: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Local != "p" {
+				continue
+			}
+			current = &transcriptCue{}
+			words = nil
+			for _, attr := range t.Attr {
+				switch attr.Name.Local {
+				case "begin":
+					current.Begin = parseTTMLTime(attr.Value)
+				case "end":
+					current.End = parseTTMLTime(attr.Value)
+				}
+			}
+		case xml.CharData:
+			if current != nil {
+				words = append(words, strings.Fields(string(t))...)
+			}
+		case xml.EndElement:
+			if t.Name.Local == "p" && current != nil {
+				current.Text = strings.Join(words, " ")
+				if current.Text != "" {
+					cues = append(cues, *current)
+				}
+				current = nil
+			}
+		}
+	}
+
+	return cues, nil
+}
+
+// parseTTMLTime parses TTML offset ("12.5s", "12.5") and clock ("00:01:02.345") time expressions
+func parseTTMLTime(value string) time.Duration {
+	value = strings.TrimSuffix(strings.TrimSpace(value), "s")
+
+	var seconds float64
+	for _, part := range strings.Split(value, ":") {
+		n, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return 0
+		}
+		seconds = seconds*60 + n
+	}
+
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// formatSRTTime formats a duration as an SRT timestamp (HH:MM:SS,mmm)
+func formatSRTTime(d time.Duration) string {
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d,%03d", ms/3600000, (ms/60000)%60, (ms/1000)%60, ms%1000)
+}
+
+// writeTranscript converts the TTML transcript at ttmlPath into .txt and .srt files next to base
+func writeTranscript(ttmlPath, base string, stable bool) error {
+	file, err := os.Open(ttmlPath)
+	if err != nil {
+		return fmt.Errorf("failed to open transcript: %w", err)
+	}
+	defer file.Close()
+
+	cues, err := parseTTML(file)
+	if err != nil {
+		return err
+	}
+	if len(cues) == 0 {
+		return nil
+	}
+
+	var text, srt strings.Builder
+	for i, cue := range cues {
+		text.WriteString(cue.Text + "\n\n")
+		fmt.Fprintf(&srt, "%d\n%s --> %s\n%s\n\n", i+1, formatSRTTime(cue.Begin), formatSRTTime(cue.End), cue.Text)
+	}
+
+	if err := writeCompanion(base+transcriptTextSuffix, []byte(text.String()), stable); err != nil {
+		return fmt.Errorf("failed to write transcript: %w", err)
+	}
+	if err := writeCompanion(base+transcriptSRTSuffix, []byte(srt.String()), stable); err != nil {
+		return fmt.Errorf("failed to write subtitles: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testTTML = `<?xml version="1.0" encoding="UTF-8"?>
+<tt xmlns="http://www.w3.org/ns/ttml" xmlns:podcasts="http://podcasts.apple.com/transcript-ttml-internal">
+  <body>
+    <div>
+      <p begin="0.120" end="2.5">
+        <span podcasts:unit="sentence"><span podcasts:unit="word">Hello</span> <span podcasts:unit="word">there.</span></span>
+      </p>
+      <p begin="00:01:02.250" end="00:01:05">
+        <span podcasts:unit="sentence"><span podcasts:unit="word">Second</span> <span podcasts:unit="word">line.</span></span>
+      </p>
+    </div>
+  </body>
+</tt>`
+
+func TestParseTTML(t *testing.T) {
+	cues, err := parseTTML(strings.NewReader(testTTML))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(cues) != 2 {
+		t.Fatalf("Expected 2 cues, got %d", len(cues))
+	}
+	if cues[0].Text != "Hello there." {
+		t.Errorf("Expected first cue text 'Hello there.', got %q", cues[0].Text)
+	}
+	if cues[1].Begin != 62*time.Second+250*time.Millisecond {
+		t.Errorf("Expected second cue to begin at 1m2.25s, got %v", cues[1].Begin)
+	}
+}
+
+func TestParseTTMLTime(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected time.Duration
+	}{
+		{"1.5", 1500 * time.Millisecond},
+		{"12s", 12 * time.Second},
+		{"01:02:03.5", time.Hour + 2*time.Minute + 3500*time.Millisecond},
+		{"bogus", 0},
+	}
+
+	for _, tt := range tests {
+		if got := parseTTMLTime(tt.input); got != tt.expected {
+			t.Errorf("parseTTMLTime(%q) = %v, expected %v", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestFormatSRTTime(t *testing.T) {
+	got := formatSRTTime(time.Hour + 2*time.Minute + 3*time.Second + 45*time.Millisecond)
+	if got != "01:02:03,045" {
+		t.Errorf("Expected 01:02:03,045, got %s", got)
+	}
+}
+
+func TestExportCompanions_Transcript(t *testing.T) {
+	tempDir := t.TempDir()
+	ttmlPath := filepath.Join(tempDir, "transcript.ttml")
+	if err := os.WriteFile(ttmlPath, []byte(testTTML), 0o644); err != nil {
+		t.Fatalf("Failed to write TTML: %v", err)
+	}
+
+	destPath := filepath.Join(tempDir, "episode.m4a")
+	episode := PodcastEpisode{TranscriptPath: ttmlPath}
+	if err := ExportCompanions("", destPath, episode, DriveProfile{ExportTranscripts: true}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	srt, err := os.ReadFile(filepath.Join(tempDir, "episode.srt"))
+	if err != nil {
+		t.Fatalf("Expected srt file: %v", err)
+	}
+	if !strings.HasPrefix(string(srt), "1\n00:00:00,120 --> 00:00:02,500\nHello there.") {
+		t.Errorf("Unexpected srt content: %q", srt)
+	}
+
+	text, err := os.ReadFile(filepath.Join(tempDir, "episode.txt"))
+	if err != nil {
+		t.Fatalf("Expected txt file: %v", err)
+	}
+	if !strings.Contains(string(text), "Second line.") {
+		t.Errorf("Unexpected txt content: %q", text)
+	}
+}
@@ -3,12 +3,34 @@
 package internal
 
 import (
+	"errors"
 	"math"
+	"slices"
 	"sync"
 	"sync/atomic"
 	"time"
 )
 
+// ErrTransferStopped is returned by TransferManager.Write once the transfer has been cancelled,
+// aborting the copy in progress
+var ErrTransferStopped = errors.New("transfer stopped")
+
+// ErrFileAborted is returned by TransferManager.Write once AbortFile has been called,
+// ending the copy of the current file without stopping the transfer
+var ErrFileAborted = errors.New("file copy aborted")
+
+// FileAction is what happens to the file being copied after AbortFile
+type FileAction int32
+
+const (
+	FileContinue FileAction = iota // keep copying
+	FileRetry                      // start the copy again, resuming from the partial file
+	FileSkip                       // give up on the file and move on to the next
+)
+
+// DefaultStallTimeout is how long a transfer may go without writing a byte before it is reported as stalled
+const DefaultStallTimeout = 15 * time.Second
+
 // TransferProgress represents the current state of a file transfer operation.
 // All fields are safe to read, but writes should be coordinated through TransferManager.
 type TransferProgress struct {
@@ -17,22 +39,123 @@ type TransferProgress struct {
 	BytesTransferred int64
 	TotalBytes       int64
 	Speed            float64 // bytes per second
-	StartTime        time.Time
-	FilesDone        int
-	TotalFiles       int
+	// TimeRemaining is the time left at the smoothed speed, 0 until a speed is measured and once done
+	TimeRemaining time.Duration
+	StartTime     time.Time
+	FilesDone     int
+	TotalFiles    int
+	// Selected episodes left out of the totals because their source file no longer exists, or is
+	// still being downloaded
+	Missing []PodcastEpisode
+	// Selected episodes left out of the totals because they are already on the drive
+	Skipped []PodcastEpisode
+}
+
+// Remaining estimates the time left: TimeRemaining once the transfer has measured its speed, or at
+// fallback bytes per second before. Returns 0 when there is no estimate.
+func (p TransferProgress) Remaining(fallback float64) time.Duration {
+	if p.TimeRemaining > 0 {
+		return p.TimeRemaining
+	}
+	speed := p.Speed
+	if speed <= 0 {
+		speed = fallback
+	}
+	left := p.TotalBytes - p.BytesTransferred
+	if speed <= 0 || left <= 0 {
+		return 0
+	}
+	return time.Duration(float64(left) / speed * float64(time.Second))
+}
+
+// StallWatch tracks how long the bytes transferred have stayed the same
+type StallWatch struct {
+	bytes int64
+	since time.Time
+}
+
+// Observe records the bytes transferred at now and returns how long they have not changed
+func (w *StallWatch) Observe(bytes int64, now time.Time) time.Duration {
+	if w.since.IsZero() || bytes != w.bytes {
+		w.bytes, w.since = bytes, now
+		return 0
+	}
+	return now.Sub(w.since)
+}
+
+// Reset forgets the last observation, e.g. when the copy restarts
+func (w *StallWatch) Reset() {
+	*w = StallWatch{}
+}
+
+// FileState is the transfer status of a single episode within a sync
+type FileState int
+
+const (
+	FileNone FileState = iota
+	FileQueued
+	FileCopying
+	FileDone
+	FileFailed
+	FileSkipped
+	FileMissing
+)
+
+// Glyph returns the compact status marker shown next to an episode during a sync
+func (s FileState) Glyph() string {
+	switch s {
+	case FileQueued:
+		return "○"
+	case FileCopying:
+		return "◐"
+	case FileDone:
+		return "✓"
+	case FileFailed:
+		return "✗"
+	case FileSkipped:
+		return "≡"
+	case FileMissing:
+		return "⊘"
+	default:
+		return ""
+	}
 }
 
-// TransferManager coordinates file transfer progress tracking across multiple files.
+// TransferManager coordinates file transfer progress tracking across multiple files, including files
+// copied at the same time: each has a FileTransfer, whose bytes it adds up into one TransferProgress.
 // It maintains accurate byte counts and delegates UI updates to ProgressWriter.
 // Safe for concurrent use - all public methods are protected by mutex or atomic operations.
 type TransferManager struct {
-	totalBytes       int64
-	baseOffset       int64 // bytes completed from previous files
-	currentFileBytes int64 // bytes transferred in current file
-	progress         *TransferProgress
-	ch               chan<- FileOp
-	pw               *ProgressWriter
-	mu               sync.Mutex
+	totalBytes int64
+	baseOffset int64 // bytes completed from previous files
+	inFlight   int64 // bytes transferred of the files being copied
+	// Files being copied, in the order they started
+	active   []*FileTransfer
+	file     *FileTransfer // the file started with StartFile, for callers copying one file at a time
+	progress *TransferProgress
+	sink     ProgressSink
+	pw       *ProgressWriter
+	mu       sync.Mutex
+
+	// Per-episode states keyed by source FilePath, versioned so readers can skip unchanged snapshots
+	filesMu      sync.Mutex
+	files        map[string]FileState
+	filesVersion int64
+
+	// Bytes copied since the last background pause, and whether pausing is off, as for benchmarks
+	paced   atomic.Int64
+	unpaced bool
+}
+
+// FileTransfer tracks the copy of one file within a transfer. Bytes written to it count toward the
+// transfer's progress until the file is completed or dropped.
+type FileTransfer struct {
+	tm    *TransferManager
+	name  string
+	bytes int64 // guarded by tm.mu
+
+	// Pending FileAction, set by AbortFile
+	action atomic.Int32
 }
 
 // FileOp represents a file operation update sent through channels.
@@ -40,6 +163,8 @@ type FileOp struct {
 	Progress TransferProgress
 	Complete bool
 	Error    error
+	// Preparing is set on updates sent while the sync examines the episodes, before the first with its totals
+	Preparing *PrepareProgress
 }
 
 const (
@@ -59,6 +184,30 @@ const (
 	progressThresholdPercent = 0.001      // 0.1% progress change
 )
 
+// activeProgressWriters counts sender goroutines that have not exited yet
+var activeProgressWriters atomic.Int64
+
+// ActiveProgressWriters returns the number of ProgressWriter goroutines still running.
+// A non-zero value while no sync is in progress indicates a writer that was never stopped.
+func ActiveProgressWriters() int64 {
+	return activeProgressWriters.Load()
+}
+
+// liveProgressWriters holds the writers whose sender goroutine is running, so orphaned ones can be stopped
+var liveProgressWriters sync.Map
+
+// StopProgressWriters stops every ProgressWriter still running and returns how many there were.
+// Only meant for when no transfer is in progress, so any writer still running was never stopped.
+func StopProgressWriters() int {
+	var stopped int
+	liveProgressWriters.Range(func(pw, _ any) bool {
+		pw.(*ProgressWriter).Stop()
+		stopped++
+		return true
+	})
+	return stopped
+}
+
 // ProgressWriter handles asynchronous progress updates and speed calculations.
 // It runs a background goroutine that periodically sends progress updates through a channel.
 // Thread-safe: uses atomic operations for byte counting and mutexes for progress updates.
@@ -67,10 +216,12 @@ type ProgressWriter struct {
 	total                  int64
 	atomicBytesTransferred atomic.Int64
 	progress               *TransferProgress
-	ch                     chan<- FileOp
+	sink                   ProgressSink
 	startTime              time.Time
 	lastSent               time.Time
 	stopping               atomic.Bool
+	// Set under muProgress once the final update has been decided, after which totals can no longer grow
+	finished bool
 
 	// Mutex for protecting progress struct updates
 	muProgress sync.Mutex
@@ -87,6 +238,9 @@ type ProgressWriter struct {
 	minBytesThreshold    int64
 	minProgressThreshold float64
 
+	// Optional sink for raw samples, used for post-hoc performance analysis
+	recorder atomic.Pointer[ProgressRecorder]
+
 	wg       sync.WaitGroup
 	stopCh   chan struct{}
 	stopOnce sync.Once
@@ -96,8 +250,8 @@ type ProgressWriter struct {
 // It automatically starts a background ProgressWriter for UI updates.
 // totalBytes: total bytes to transfer across all files
 // totalFiles: total number of files to transfer
-// ch: channel for sending progress updates (caller owns, TransferManager will not close it)
-func NewTransferManager(totalBytes int64, totalFiles int, ch chan<- FileOp) *TransferManager {
+// sink: receives progress updates (caller owns, TransferManager will not close it)
+func NewTransferManager(totalBytes int64, totalFiles int, sink ProgressSink) *TransferManager {
 	progress := &TransferProgress{
 		TotalBytes: totalBytes,
 		TotalFiles: totalFiles,
@@ -107,90 +261,257 @@ func NewTransferManager(totalBytes int64, totalFiles int, ch chan<- FileOp) *Tra
 	tm := &TransferManager{
 		totalBytes: totalBytes,
 		progress:   progress,
-		ch:         ch,
+		sink:       sink,
+		files:      make(map[string]FileState),
 	}
 
-	tm.pw = NewProgressWriter(totalBytes, progress, ch)
+	tm.pw = NewProgressWriter(totalBytes, progress, sink)
 
 	return tm
 }
 
-// StartFile marks the beginning of a new file transfer.
-// Resets current file progress and updates the progress display.
-func (tm *TransferManager) StartFile(filename string) {
+// BeginFile starts tracking the copy of filename alongside any other files being copied, and shows it
+// as the current file.
+func (tm *TransferManager) BeginFile(filename string) *FileTransfer {
 	tm.mu.Lock()
 	defer tm.mu.Unlock()
+	f := &FileTransfer{tm: tm, name: filename}
+	tm.active = append(tm.active, f)
+	tm.updateProgress(filename)
+	return f
+}
 
-	tm.currentFileBytes = 0
+// StartFile marks the beginning of a new file transfer, for callers copying one file at a time.
+// Starting the same file again resets its progress, e.g. to retry it.
+func (tm *TransferManager) StartFile(filename string) {
+	tm.mu.Lock()
+	f := tm.file
+	tm.mu.Unlock()
+	if f != nil && f.Restart(filename) {
+		return
+	}
+	f = tm.BeginFile(filename)
+	tm.mu.Lock()
+	tm.file = f
+	tm.mu.Unlock()
+}
 
-	// Update progress struct safely (ProgressWriter also reads this)
+// current returns the file started with StartFile, starting an unnamed one if there is none
+func (tm *TransferManager) current() *FileTransfer {
+	tm.mu.Lock()
+	f := tm.file
+	tm.mu.Unlock()
+	if f == nil {
+		tm.StartFile("")
+		tm.mu.Lock()
+		f = tm.file
+		tm.mu.Unlock()
+	}
+	return f
+}
+
+// updateProgress publishes the bytes transferred, and the current file unless name is empty.
+// Callers must hold tm.mu.
+func (tm *TransferManager) updateProgress(name string) {
+	total := tm.baseOffset + tm.inFlight
 	if tm.pw != nil {
 		tm.pw.muProgress.Lock()
-		tm.progress.CurrentFile = filename
-		tm.progress.BytesTransferred = tm.baseOffset
-		tm.pw.muProgress.Unlock()
-	} else {
-		tm.progress.CurrentFile = filename
-		tm.progress.BytesTransferred = tm.baseOffset
+		defer tm.pw.muProgress.Unlock()
+		tm.pw.atomicBytesTransferred.Store(total)
+	}
+	if name != "" {
+		tm.progress.CurrentFile = name
 	}
+	tm.progress.BytesTransferred = total
+}
 
-	if tm.pw != nil {
-		tm.pw.atomicBytesTransferred.Store(tm.baseOffset)
+// finish stops counting f as in flight, showing the latest file still being copied as the current one.
+// Callers must hold tm.mu.
+func (tm *TransferManager) finish(f *FileTransfer) {
+	tm.inFlight -= f.bytes
+	f.bytes = 0
+	tm.active = slices.DeleteFunc(tm.active, func(a *FileTransfer) bool { return a == f })
+	if tm.file == f {
+		tm.file = nil
+	}
+}
+
+// latestName is the name of the file started last that is still being copied, or empty.
+// Callers must hold tm.mu.
+func (tm *TransferManager) latestName() string {
+	if len(tm.active) == 0 {
+		return ""
 	}
+	return tm.active[len(tm.active)-1].name
 }
 
-// CompleteFile marks a file transfer as complete and updates base offset.
+// CompleteFile marks the file started with StartFile as complete and updates base offset.
 func (tm *TransferManager) CompleteFile(fileSize int64) {
+	tm.current().Complete(fileSize)
+}
+
+// Restart resets the file's progress to start its copy again, as filename. Returns false if the file
+// was already completed or dropped.
+func (f *FileTransfer) Restart(filename string) bool {
+	tm := f.tm
 	tm.mu.Lock()
 	defer tm.mu.Unlock()
+	if !slices.Contains(tm.active, f) {
+		return false
+	}
+	tm.inFlight -= f.bytes
+	f.bytes = 0
+	f.name = filename
+	f.action.Store(int32(FileContinue))
+	tm.updateProgress(filename)
+	return true
+}
 
-	tm.baseOffset += fileSize
-	tm.currentFileBytes = 0
+// Complete marks the file as copied, counting fileSize bytes toward the transfer.
+func (f *FileTransfer) Complete(fileSize int64) {
+	tm := f.tm
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
 
-	// Update progress struct safely
+	tm.finish(f)
+	tm.baseOffset += fileSize
 	if tm.pw != nil {
 		tm.pw.muProgress.Lock()
 		tm.progress.FilesDone++
-		tm.progress.BytesTransferred = tm.baseOffset
 		tm.pw.muProgress.Unlock()
 	} else {
 		tm.progress.FilesDone++
-		tm.progress.BytesTransferred = tm.baseOffset
 	}
+	tm.updateProgress(tm.latestName())
+}
+
+// AddTotals grows the transfer by bytes and files queued after it started; negative bytes shrink it.
+// Returns false if the transfer has already finished or been stopped.
+func (tm *TransferManager) AddTotals(bytes int64, files int) bool {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
 
 	if tm.pw != nil {
-		tm.pw.atomicBytesTransferred.Store(tm.baseOffset)
+		tm.pw.muProgress.Lock()
+		defer tm.pw.muProgress.Unlock()
+		if tm.pw.finished || tm.pw.stopping.Load() {
+			return false
+		}
+		tm.pw.total += bytes
 	}
+
+	tm.totalBytes += bytes
+	tm.progress.TotalBytes += bytes
+	tm.progress.TotalFiles += files
+	return true
 }
 
-// Write implements io.Writer for tracking bytes transferred during file copy.
-// This method is called by io.Copy and similar functions.
-func (tm *TransferManager) Write(p []byte) (int, error) {
-	n := len(p)
+// SetSkipped records the episodes left out of the transfer because they are already on the drive
+func (tm *TransferManager) SetSkipped(skipped []PodcastEpisode) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	if tm.pw != nil {
+		tm.pw.muProgress.Lock()
+		defer tm.pw.muProgress.Unlock()
+	}
+	tm.progress.Skipped = skipped
+}
 
-	// Update our tracking
+// SetMissing records the episodes dropped from the transfer because their source file is gone
+func (tm *TransferManager) SetMissing(missing []PodcastEpisode) {
 	tm.mu.Lock()
-	tm.currentFileBytes += int64(n)
-	newTotal := tm.baseOffset + tm.currentFileBytes
+	defer tm.mu.Unlock()
+	if tm.pw != nil {
+		tm.pw.muProgress.Lock()
+		defer tm.pw.muProgress.Unlock()
+	}
+	tm.progress.Missing = missing
+	for _, episode := range missing {
+		tm.SetFileState(episode.FilePath, FileMissing)
+	}
+}
 
-	// Update progress struct safely
+// DropMissing takes an episode whose source went away after the transfer was planned out of the totals
+func (tm *TransferManager) DropMissing(episode PodcastEpisode, fileSize int64) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	tm.totalBytes -= fileSize
 	if tm.pw != nil {
 		tm.pw.muProgress.Lock()
-		tm.progress.BytesTransferred = newTotal
-		tm.pw.muProgress.Unlock()
-	} else {
-		tm.progress.BytesTransferred = newTotal
+		defer tm.pw.muProgress.Unlock()
+		tm.pw.total -= fileSize
 	}
-	tm.mu.Unlock()
+	tm.progress.TotalBytes -= fileSize
+	tm.progress.TotalFiles--
+	tm.progress.Missing = append(tm.progress.Missing, episode)
+	tm.SetFileState(episode.FilePath, FileMissing)
+}
 
-	// Update the atomic counter for the progress writer
+// Snapshot returns a copy of the current progress, safe to read while the transfer runs.
+func (tm *TransferManager) Snapshot() TransferProgress {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
 	if tm.pw != nil {
-		tm.pw.atomicBytesTransferred.Store(newTotal)
+		tm.pw.muProgress.Lock()
+		defer tm.pw.muProgress.Unlock()
 	}
+	return *tm.progress
+}
+
+// SetFileState records the transfer state of the episode identified by key.
+func (tm *TransferManager) SetFileState(key string, state FileState) {
+	tm.filesMu.Lock()
+	defer tm.filesMu.Unlock()
+	tm.files[key] = state
+	tm.filesVersion++
+}
+
+// FileStates returns a copy of the per-episode states and their version.
+// The version only changes when a state does, so callers can cheaply detect updates.
+func (tm *TransferManager) FileStates() (map[string]FileState, int64) {
+	tm.filesMu.Lock()
+	defer tm.filesMu.Unlock()
+	states := make(map[string]FileState, len(tm.files))
+	for k, v := range tm.files {
+		states[k] = v
+	}
+	return states, tm.filesVersion
+}
+
+// Write implements io.Writer for tracking bytes transferred during the copy of the file started with
+// StartFile. This method is called by io.Copy and similar functions.
+func (tm *TransferManager) Write(p []byte) (int, error) {
+	return tm.current().Write(p)
+}
+
+// Advance counts n bytes of the file started with StartFile as transferred without writing them,
+// e.g. when resuming a partial copy.
+func (tm *TransferManager) Advance(n int64) {
+	tm.current().Advance(n)
+}
 
+// Write implements io.Writer for tracking bytes transferred during the file's copy.
+func (f *FileTransfer) Write(p []byte) (int, error) {
+	if err := f.interrupted(); err != nil {
+		return 0, err
+	}
+	n := len(p)
+	f.Advance(int64(n))
+	f.tm.pace(int64(n))
 	return n, nil
 }
 
+// Advance counts n bytes of the file as transferred without writing them, e.g. when resuming a
+// partial copy.
+func (f *FileTransfer) Advance(n int64) {
+	tm := f.tm
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	f.bytes += n
+	tm.inFlight += n
+	tm.updateProgress("")
+}
+
 // Stop gracefully shuts down the progress writer.
 // Blocks until all background goroutines have exited.
 // Safe to call multiple times.
@@ -200,6 +521,75 @@ func (tm *TransferManager) Stop() {
 	}
 }
 
+// SetRecorder records every progress sample to r until the transfer stops.
+func (tm *TransferManager) SetRecorder(r *ProgressRecorder) {
+	if tm.pw != nil {
+		tm.pw.recorder.Store(r)
+	}
+}
+
+// AbortFile ends the copy of the files being copied at their next write, then retries or skips them.
+// A write blocked on an unresponsive drive still has to return before the action takes effect.
+func (tm *TransferManager) AbortFile(action FileAction) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	for _, f := range tm.active {
+		f.action.Store(int32(action))
+	}
+}
+
+// takeFileAction returns the pending action for the file started with StartFile and clears it
+func (tm *TransferManager) takeFileAction() FileAction {
+	return tm.current().takeAction()
+}
+
+// interrupted returns the error that ends the copy of the file started with StartFile, if any
+func (tm *TransferManager) interrupted() error {
+	return tm.current().interrupted()
+}
+
+// DropFile takes the file started with StartFile out of the totals, e.g. after it was skipped
+func (tm *TransferManager) DropFile(fileSize int64) {
+	tm.current().Drop(fileSize)
+}
+
+// takeAction returns the pending action for the file and clears it
+func (f *FileTransfer) takeAction() FileAction {
+	return FileAction(f.action.Swap(int32(FileContinue)))
+}
+
+// interrupted returns the error that ends the file's copy, if any
+func (f *FileTransfer) interrupted() error {
+	if f.tm.IsStopped() {
+		return ErrTransferStopped
+	}
+	if FileAction(f.action.Load()) != FileContinue {
+		return ErrFileAborted
+	}
+	return nil
+}
+
+// Drop takes the file out of the totals, e.g. after it was skipped
+func (f *FileTransfer) Drop(fileSize int64) {
+	tm := f.tm
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	tm.finish(f)
+	tm.totalBytes -= fileSize
+	if tm.pw != nil {
+		tm.pw.muProgress.Lock()
+		tm.pw.total -= fileSize
+		tm.progress.TotalBytes -= fileSize
+		tm.progress.TotalFiles--
+		tm.pw.muProgress.Unlock()
+	} else {
+		tm.progress.TotalBytes -= fileSize
+		tm.progress.TotalFiles--
+	}
+	tm.updateProgress(tm.latestName())
+}
+
 // IsStopped returns whether the transfer manager has been stopped.
 func (tm *TransferManager) IsStopped() bool {
 	if tm.pw != nil {
@@ -208,6 +598,47 @@ func (tm *TransferManager) IsStopped() bool {
 	return false
 }
 
+// ProgressSink receives the progress updates of a sync or benchmark
+type ProgressSink interface {
+	// Send delivers op without blocking, reporting false if it was dropped
+	Send(op FileOp) bool
+	// Close is called once the sync has ended, after its last update
+	Close()
+}
+
+// ChanSink delivers progress updates to a buffered channel, dropping those that find it
+// full; Close closes the channel
+type ChanSink chan<- FileOp
+
+func (ch ChanSink) Send(op FileOp) bool {
+	return safeSend(ch, op)
+}
+
+func (ch ChanSink) Close() {
+	safeClose(ch)
+}
+
+// ChannelStats counts progress sends to channels by outcome, so a frozen progress bar can be
+// correlated with a saturated or closed channel
+type ChannelStats struct {
+	Sent    int64 // delivered to the channel
+	Dropped int64 // discarded because the channel buffer was full
+	Closed  int64 // attempted on a channel that had already been closed
+}
+
+var channelStats struct {
+	sent, dropped, closed atomic.Int64
+}
+
+// GetChannelStats returns the progress send counters accumulated since startup
+func GetChannelStats() ChannelStats {
+	return ChannelStats{
+		Sent:    channelStats.sent.Load(),
+		Dropped: channelStats.dropped.Load(),
+		Closed:  channelStats.closed.Load(),
+	}
+}
+
 func safeClose(ch chan<- FileOp) {
 	if ch != nil {
 		defer func() {
@@ -219,25 +650,29 @@ func safeClose(ch chan<- FileOp) {
 	}
 }
 
-func safeSend(ch chan<- FileOp, msg FileOp) {
+func safeSend(ch chan<- FileOp, msg FileOp) (sent bool) {
 	defer func() {
 		if r := recover(); r != nil {
-			return
+			channelStats.closed.Add(1)
 		}
 	}()
-	if ch != nil {
-		select {
-		case ch <- msg:
-		default:
-			return
-		}
+	if ch == nil {
+		return false
+	}
+	select {
+	case ch <- msg:
+		channelStats.sent.Add(1)
+		return true
+	default:
+		channelStats.dropped.Add(1)
+		return false
 	}
 }
 
-// NewProgressWriter creates a new ProgressWriter that sends periodic updates through ch.
+// NewProgressWriter creates a new ProgressWriter that sends periodic updates to sink.
 // Starts a background goroutine for asynchronous updates.
 // The caller must call Stop() to clean up resources.
-func NewProgressWriter(total int64, progress *TransferProgress, ch chan<- FileOp) *ProgressWriter {
+func NewProgressWriter(total int64, progress *TransferProgress, sink ProgressSink) *ProgressWriter {
 	now := time.Now()
 
 	progress.TotalBytes = total
@@ -250,6 +685,7 @@ func NewProgressWriter(total int64, progress *TransferProgress, ch chan<- FileOp
 		progress.CurrentProgress = 0.0
 	}
 	progress.Speed = 0.0
+	progress.TimeRemaining = 0
 
 	// Calculate dynamic threshold based on total size for smooth updates
 	minBytesThreshold := int64(minBytesThresholdBase)
@@ -266,7 +702,7 @@ func NewProgressWriter(total int64, progress *TransferProgress, ch chan<- FileOp
 	pw := &ProgressWriter{
 		total:     total,
 		progress:  progress,
-		ch:        ch,
+		sink:      sink,
 		startTime: now,
 		lastSent:  now,
 
@@ -283,6 +719,8 @@ func NewProgressWriter(total int64, progress *TransferProgress, ch chan<- FileOp
 	}
 
 	pw.wg.Add(1)
+	activeProgressWriters.Add(1)
+	liveProgressWriters.Store(pw, struct{}{})
 	go pw.senderLoop()
 
 	pw.atomicBytesTransferred.Store(progress.BytesTransferred)
@@ -345,6 +783,8 @@ func (pw *ProgressWriter) shouldSendUpdate(currentBytes int64, currentProgress f
 // senderLoop runs in a background goroutine, periodically sending progress updates.
 func (pw *ProgressWriter) senderLoop() {
 	defer pw.wg.Done()
+	defer activeProgressWriters.Add(-1)
+	defer liveProgressWriters.Delete(pw)
 	ticker := time.NewTicker(defaultUpdateInterval)
 	defer ticker.Stop()
 
@@ -360,7 +800,10 @@ func (pw *ProgressWriter) senderLoop() {
 			}
 
 			actualBytes := pw.atomicBytesTransferred.Load()
+			pw.muProgress.Lock()
 			isComplete := pw.isTransferComplete(actualBytes)
+			pw.finished = isComplete
+			pw.muProgress.Unlock()
 
 			pw.performUpdateAndSend(actualBytes, isComplete)
 
@@ -422,37 +865,25 @@ func (pw *ProgressWriter) performUpdateAndSend(actualBytes int64, isFinalUpdate
 	pw.progress.Speed = pw.currentSmoothedSpeed
 	pw.muLastSample.Unlock()
 
+	// The countdown follows the smoothed speed, so it doesn't jump with every buffer written
+	pw.progress.TimeRemaining = 0
+	if left := pw.total - actualBytes; left > 0 && pw.progress.Speed > 0 && !isFinalUpdate {
+		pw.progress.TimeRemaining = time.Duration(float64(left) / pw.progress.Speed * float64(time.Second))
+	}
+
+	if rec := pw.recorder.Load(); rec != nil {
+		rec.Record(now, actualBytes, pw.progress.Speed, pw.progress.CurrentFile)
+	}
+
 	// Send update if needed
 	shouldSend := pw.shouldSendUpdate(actualBytes, pw.progress.CurrentProgress, isFinalUpdate)
 
-	if pw.ch != nil && shouldSend {
-		isComplete := pw.isTransferComplete(actualBytes)
+	if pw.sink != nil && shouldSend && !pw.stopping.Load() {
 		op := FileOp{
 			Progress: *pw.progress,
-			Complete: isComplete,
-			Error:    nil,
+			Complete: pw.isTransferComplete(actualBytes),
 		}
-
-		// Send with timeout to avoid blocking, with panic recovery
-		sendSuccessful := false
-		if !pw.stopping.Load() {
-			func() {
-				defer func() {
-					// Recover from panic if channel is closed
-					if r := recover(); r != nil {
-						return
-					}
-				}()
-				select {
-				case pw.ch <- op:
-					sendSuccessful = true
-				case <-time.After(defaultUpdateInterval):
-					// Timeout - don't block
-				}
-			}()
-		}
-
-		if sendSuccessful {
+		if pw.sink.Send(op) {
 			pw.lastSent = now
 		}
 	}
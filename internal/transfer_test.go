@@ -0,0 +1,170 @@
+package internal
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSafeSend_CountsOutcomes(t *testing.T) {
+	before := GetChannelStats()
+
+	ch := make(chan FileOp, 1)
+	safeSend(ch, FileOp{})
+	safeSend(ch, FileOp{}) // buffer full
+	close(ch)
+	safeSend(ch, FileOp{}) // closed
+
+	after := GetChannelStats()
+	if after.Sent-before.Sent != 1 {
+		t.Errorf("Expected 1 sent, got %d", after.Sent-before.Sent)
+	}
+	if after.Dropped-before.Dropped != 1 {
+		t.Errorf("Expected 1 dropped, got %d", after.Dropped-before.Dropped)
+	}
+	if after.Closed-before.Closed != 1 {
+		t.Errorf("Expected 1 closed, got %d", after.Closed-before.Closed)
+	}
+}
+
+func TestTransferManager_FileStates(t *testing.T) {
+	tm := NewTransferManager(0, 2, ChanSink(make(chan FileOp, 1)))
+	defer tm.Stop()
+
+	_, initial := tm.FileStates()
+	tm.SetFileState("a", FileQueued)
+	tm.SetFileState("a", FileDone)
+	tm.SetFileState("b", FileFailed)
+
+	states, version := tm.FileStates()
+	if version == initial {
+		t.Error("Expected version to change after state updates")
+	}
+	if states["a"] != FileDone || states["b"] != FileFailed {
+		t.Errorf("Unexpected states: %v", states)
+	}
+
+	// The snapshot is a copy
+	states["a"] = FileNone
+	if again, _ := tm.FileStates(); again["a"] != FileDone {
+		t.Error("Expected FileStates to return a copy")
+	}
+}
+
+func TestTransferManager_AbortFile(t *testing.T) {
+	tm := NewTransferManager(300, 2, ChanSink(make(chan FileOp, 1)))
+	defer tm.Stop()
+
+	tm.StartFile("first")
+	if _, err := tm.Write(make([]byte, 100)); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	tm.AbortFile(FileSkip)
+	if _, err := tm.Write(make([]byte, 100)); !errors.Is(err, ErrFileAborted) {
+		t.Fatalf("Expected ErrFileAborted, got %v", err)
+	}
+	if action := tm.takeFileAction(); action != FileSkip {
+		t.Errorf("Expected FileSkip, got %v", action)
+	}
+	if action := tm.takeFileAction(); action != FileContinue {
+		t.Errorf("Expected the action to be cleared once taken, got %v", action)
+	}
+
+	tm.DropFile(200)
+	progress := tm.Snapshot()
+	if progress.TotalBytes != 100 || progress.TotalFiles != 1 || progress.BytesTransferred != 0 {
+		t.Errorf("Expected the skipped file out of the totals, got %+v", progress)
+	}
+
+	// An abort left over from the previous file does not carry into the next one
+	tm.AbortFile(FileRetry)
+	tm.StartFile("second")
+	if _, err := tm.Write(make([]byte, 100)); err != nil {
+		t.Errorf("Expected the next file to copy, got %v", err)
+	}
+}
+
+func TestTransferManager_ConcurrentFiles(t *testing.T) {
+	tm := NewTransferManager(600, 3, ChanSink(make(chan FileOp, 10)))
+	defer tm.Stop()
+
+	first := tm.BeginFile("first")
+	second := tm.BeginFile("second")
+	if _, err := first.Write(make([]byte, 100)); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	second.Advance(50)
+	if p := tm.Snapshot(); p.BytesTransferred != 150 || p.CurrentFile != "second" {
+		t.Errorf("Expected both files' bytes added up with the latest as current, got %+v", p)
+	}
+
+	// Restarting a file only takes back its own bytes
+	second.Restart("second")
+	if p := tm.Snapshot(); p.BytesTransferred != 100 {
+		t.Errorf("Expected the restarted file's bytes taken back, got %d", p.BytesTransferred)
+	}
+
+	second.Complete(200)
+	if p := tm.Snapshot(); p.BytesTransferred != 300 || p.FilesDone != 1 || p.CurrentFile != "first" {
+		t.Errorf("Expected the completed file counted in full and the other current, got %+v", p)
+	}
+
+	third := tm.BeginFile("third")
+	tm.AbortFile(FileSkip)
+	for _, f := range []*FileTransfer{first, third} {
+		if _, err := f.Write(make([]byte, 1)); !errors.Is(err, ErrFileAborted) {
+			t.Errorf("Expected %s aborted, got %v", f.name, err)
+		}
+	}
+	first.Drop(200)
+	third.Drop(200)
+	if p := tm.Snapshot(); p.BytesTransferred != 200 || p.TotalBytes != 200 || p.TotalFiles != 1 {
+		t.Errorf("Expected the skipped files out of the totals, got %+v", p)
+	}
+}
+
+func TestStallWatch(t *testing.T) {
+	var w StallWatch
+	start := time.Now()
+
+	if got := w.Observe(0, start); got != 0 {
+		t.Errorf("Expected no stall on the first observation, got %v", got)
+	}
+	if got := w.Observe(0, start.Add(3*time.Second)); got != 3*time.Second {
+		t.Errorf("Expected a 3s stall, got %v", got)
+	}
+	if got := w.Observe(10, start.Add(4*time.Second)); got != 0 {
+		t.Errorf("Expected progress to end the stall, got %v", got)
+	}
+	if got := w.Observe(10, start.Add(5*time.Second)); got != time.Second {
+		t.Errorf("Expected the stall to count from the last progress, got %v", got)
+	}
+
+	w.Reset()
+	if got := w.Observe(10, start.Add(9*time.Second)); got != 0 {
+		t.Errorf("Expected Reset to start over, got %v", got)
+	}
+}
+
+func TestProgressWriter_TimeRemaining(t *testing.T) {
+	start := time.Now().Add(-2 * time.Second)
+	pw := &ProgressWriter{
+		total:          100 << 20,
+		progress:       &TransferProgress{TotalBytes: 100 << 20, StartTime: start},
+		lastSampleTime: start,
+	}
+
+	// 40 MB in 2s is 20 MB/s, leaving 3s for the other 60 MB
+	pw.performUpdateAndSend(40<<20, false)
+	if got := pw.progress.TimeRemaining; got < 3*time.Second || got > 3500*time.Millisecond {
+		t.Errorf("TimeRemaining = %v, want about 3s", got)
+	}
+	if got := pw.progress.Remaining(1); got != pw.progress.TimeRemaining {
+		t.Errorf("Remaining() = %v, want the measured %v", got, pw.progress.TimeRemaining)
+	}
+
+	pw.performUpdateAndSend(100<<20, true)
+	if got := pw.progress.TimeRemaining; got != 0 {
+		t.Errorf("TimeRemaining after the final update = %v, want 0", got)
+	}
+}
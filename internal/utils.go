@@ -4,6 +4,7 @@ import (
 	"crypto/sha256"
 	"fmt"
 	"io"
+	"io/fs"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -38,6 +39,16 @@ func formatDuration(duration time.Duration) string {
 	return fmt.Sprintf("%02d:%02d", minutes, seconds)
 }
 
+// FormatTotalDuration formats a summed duration for planning, e.g. "12 h 34 m"
+func FormatTotalDuration(duration time.Duration) string {
+	hours := int(duration.Hours())
+	minutes := int(duration.Minutes()) % 60
+	if hours > 0 {
+		return fmt.Sprintf("%d h %d m", hours, minutes)
+	}
+	return fmt.Sprintf("%d m", minutes)
+}
+
 func fileExists(path string) (bool, error) {
 	_, err := os.Stat(path)
 	if err == nil {
@@ -69,11 +80,31 @@ func isSystemHiddenFile(name string) bool {
 		}
 	}
 
-	// Also check for hidden temp files
-	return strings.HasPrefix(name, "._")
+	// Also check for AppleDouble files
+	return strings.HasPrefix(name, appleDoublePrefix)
+}
+
+// appleDoublePrefix starts the name of the AppleDouble file macOS writes beside a file on drives
+// without extended attributes (FAT, exFAT) to hold its metadata, e.g. "._Episode.mp3"
+const appleDoublePrefix = "._"
+
+// appleDoublePath returns where macOS keeps the AppleDouble file of the file at path
+func appleDoublePath(path string) string {
+	return filepath.Join(filepath.Dir(path), appleDoublePrefix+filepath.Base(path))
 }
 
-// cleanupSystemHiddenFiles removes system hidden files from a directory
+// isOrphanedAppleDouble reports whether name in dirPath is an AppleDouble file whose data file is gone
+func isOrphanedAppleDouble(dirPath, name string) bool {
+	data, ok := strings.CutPrefix(name, appleDoublePrefix)
+	if !ok || data == "" {
+		return false
+	}
+	exists, _ := fileExists(filepath.Join(dirPath, data))
+	return !exists
+}
+
+// cleanupSystemHiddenFiles removes system hidden files from a directory.
+// AppleDouble files are only removed once their data file is gone.
 func cleanupSystemHiddenFiles(dirPath string) {
 	entries, err := os.ReadDir(dirPath)
 	if err != nil {
@@ -81,10 +112,14 @@ func cleanupSystemHiddenFiles(dirPath string) {
 	}
 
 	for _, entry := range entries {
-		if isSystemHiddenFile(entry.Name()) {
-			filePath := filepath.Join(dirPath, entry.Name())
-			_ = os.Remove(filePath) // Best effort - ignore errors
+		name := entry.Name()
+		if !isSystemHiddenFile(name) {
+			continue
+		}
+		if strings.HasPrefix(name, appleDoublePrefix) && !isOrphanedAppleDouble(dirPath, name) {
+			continue
 		}
+		_ = os.Remove(filepath.Join(dirPath, name)) // Best effort - ignore errors
 	}
 }
 
@@ -119,46 +154,53 @@ func USBDrivesEqual(a, b []USBDrive) bool {
 	})
 
 	for i := range a {
-		if a[i].MountPath != b[i].MountPath {
+		if a[i].MountPath != b[i].MountPath || a[i].UUID != b[i].UUID {
 			return false
 		}
 	}
 	return true
 }
 
-func sanitizeName(name string) string {
-	// Replace invalid characters with safe alternatives
-	replacer := strings.NewReplacer(
-		"/", "-",
-		"\\", "-",
-		":", "-",
-		"*", "",
-		"?", "",
-		"\"", "'",
-		"<", "",
-		">", "",
-		"|", "-",
-		"&", "and",
-	)
+// nameReplacer replaces characters drives and players can't take in a file name with safe alternatives
+var nameReplacer = strings.NewReplacer(
+	"/", "-",
+	"\\", "-",
+	":", "-",
+	"*", "",
+	"?", "",
+	"\"", "'",
+	"<", "",
+	">", "",
+	"|", "-",
+	"&", "and",
+)
 
+// maxNameLength is the longest file name, in bytes, sanitizeName leaves
+const maxNameLength = 255
+
+func sanitizeName(name string) string {
 	// Remove or replace any other problematic characters
-	name = replacer.Replace(name)
+	name = nameReplacer.Replace(name)
 	name = strings.TrimSpace(name)
 
 	// Ensure name isn't too long for filesystem
-	if len(name) > 255 {
-		name = name[:255]
+	if len(name) > maxNameLength {
+		name = name[:maxNameLength]
 	}
 
 	return name
 }
 
 func formatEpisodeName(episode PodcastEpisode) string {
-	template := defaultDirTemplate
+	return formatEpisodeNameAs(episode, defaultDirTemplate)
+}
+
+// formatEpisodeNameAs names an episode's file after the template's episode format, e.g. "{date} - {title}"
+func formatEpisodeNameAs(episode PodcastEpisode, template DirectoryTemplate) string {
 	name := template.EpisodeFormat
 
 	name = strings.ReplaceAll(name, "{title}", episode.ZTitle)
-	name = strings.ReplaceAll(name, "{date}", episode.Published.Format(template.DateFormat))
+	name = strings.ReplaceAll(name, "{date}", episode.Published.UTC().Format(template.DateFormat))
 	name = strings.ReplaceAll(name, "{show}", episode.ShowName)
 
 	if template.SanitizeNames {
@@ -202,6 +244,7 @@ var audioExtensions = map[string]bool{
 // Check if a file is an audio file based on its extension
 func isAudioFile(path string) bool {
 	filename := filepath.Base(path)
+	// Hidden files include the AppleDouble files paired with episodes, which only hold metadata
 	if strings.HasPrefix(filename, ".") {
 		return false
 	}
@@ -209,6 +252,41 @@ func isAudioFile(path string) bool {
 	return audioExtensions[ext]
 }
 
+// walkAudioFiles calls fn for every audio file below root, skipping the trash. Symlinks are skipped,
+// so the walk never leaves root or loops through a link to one of its parents, and a file hardlinked
+// under several names is only visited under the first.
+func walkAudioFiles(root string, fn func(path string, info os.FileInfo) error) error {
+	seen := make(map[int64][]os.FileInfo)
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type()&fs.ModeSymlink != 0 {
+			return nil
+		}
+		if d.IsDir() {
+			if d.Name() == TrashFolder {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !isAudioFile(path) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		for _, other := range seen[info.Size()] {
+			if os.SameFile(other, info) {
+				return nil
+			}
+		}
+		seen[info.Size()] = append(seen[info.Size()], info)
+		return fn(path, info)
+	})
+}
+
 // Convert file URI to a file path manually
 func convertFileURIToPath(fileURI string) (string, error) {
 	parsedURL, err := url.Parse(fileURI)
@@ -225,14 +303,27 @@ func convertFileURIToPath(fileURI string) (string, error) {
 }
 
 // Parse episode metadata from a file path based on a template
-func parseEpisodeFromPath(path string, template DirectoryTemplate) (PodcastEpisode, error) {
+func parseEpisodeFromPath(path string, template DirectoryTemplate, layout FolderLayout) (PodcastEpisode, error) {
 	episode := PodcastEpisode{
 		FilePath: path,
 	}
 
-	// Extract show name from parent directory
+	// Extract show name (and genre) from the directories the layout puts the episode in
 	dir := filepath.Dir(path)
-	episode.ShowName = filepath.Base(dir)
+	switch layout {
+	case LayoutFlat:
+		// Unless a drive's template names files its own way
+		if template.EpisodeFormat == defaultDirTemplate.EpisodeFormat {
+			template.EpisodeFormat = flatEpisodeFormat
+		}
+	case LayoutYear:
+		episode.ShowName = template.showFromFolder(filepath.Base(filepath.Dir(dir)))
+	case LayoutGenre:
+		episode.ShowName = template.showFromFolder(filepath.Base(dir))
+		episode.Genre = filepath.Base(filepath.Dir(dir))
+	default:
+		episode.ShowName = template.showFromFolder(filepath.Base(dir))
+	}
 
 	// Get filename without extension
 	filename := filepath.Base(path)
@@ -249,7 +340,7 @@ func parseEpisodeFromPath(path string, template DirectoryTemplate) (PodcastEpiso
 	// Replace template placeholders with capture groups
 	pattern = strings.ReplaceAll(pattern, regexp.QuoteMeta("{date}"), fmt.Sprintf("(%s)", dateRegex))
 	pattern = strings.ReplaceAll(pattern, regexp.QuoteMeta("{title}"), `(.+)`)
-	pattern = strings.ReplaceAll(pattern, regexp.QuoteMeta("{show}"), `.+`)
+	pattern = strings.ReplaceAll(pattern, regexp.QuoteMeta("{show}"), `(.+?)`)
 
 	re, err := regexp.Compile(`^` + pattern + `$`)
 	if err != nil {
@@ -271,7 +362,7 @@ func parseEpisodeFromPath(path string, template DirectoryTemplate) (PodcastEpiso
 		pos := i + 1 // account for full match at index 0
 		switch pos {
 		case placeholderPos["date"]:
-			parsed, err := time.Parse(template.DateFormat, match)
+			parsed, err := time.ParseInLocation(template.DateFormat, match, time.UTC)
 			if err != nil {
 				return episode, fmt.Errorf("failed to parse date: %w", err)
 			}
@@ -281,6 +372,8 @@ func parseEpisodeFromPath(path string, template DirectoryTemplate) (PodcastEpiso
 			if template.SanitizeNames {
 				episode.ZTitle = strings.ReplaceAll(episode.ZTitle, "-", " ")
 			}
+		case placeholderPos["show"]:
+			episode.ShowName = match
 		}
 	}
 
@@ -333,18 +426,21 @@ func dateFormatToRegex(format string) string {
 	return regex
 }
 
-// Get positions of placeholders in template
+// Get the capture group of each placeholder in template, numbered in the order they appear
 func getPlaceholderPositions(template string) map[string]int {
-	positions := make(map[string]int)
-	placeholders := []string{"date", "title", "show"}
-
-	pos := 1 // Start at 1 since regex matches have full match at index 0
-	for _, placeholder := range placeholders {
+	var found []string
+	for _, placeholder := range []string{"date", "title", "show"} {
 		if strings.Contains(template, "{"+placeholder+"}") {
-			positions[placeholder] = pos
-			pos++
+			found = append(found, placeholder)
 		}
 	}
+	sort.Slice(found, func(i, j int) bool {
+		return strings.Index(template, "{"+found[i]+"}") < strings.Index(template, "{"+found[j]+"}")
+	})
 
+	positions := make(map[string]int, len(found))
+	for i, placeholder := range found {
+		positions[placeholder] = i + 1 // regex matches have the full match at index 0
+	}
 	return positions
 }
@@ -3,6 +3,7 @@ package internal
 import (
 	"os"
 	"path/filepath"
+	"slices"
 	"testing"
 )
 
@@ -156,3 +157,59 @@ func TestCleanupSystemHiddenFiles(t *testing.T) {
 		cleanupSystemHiddenFiles("/non/existent/path")
 	})
 }
+
+func TestWalkAudioFiles_SkipsLinks(t *testing.T) {
+	base := t.TempDir()
+	root := filepath.Join(base, "podcasts")
+	show := filepath.Join(root, "Show")
+	outside := filepath.Join(base, "outside.mp3")
+	if err := os.MkdirAll(show, 0o755); err != nil {
+		t.Fatalf("Failed to create show folder: %v", err)
+	}
+	for _, path := range []string{filepath.Join(show, "episode.mp3"), outside} {
+		if err := os.WriteFile(path, []byte("audio"), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+	// A link back up the tree would make a walk that follows links run forever
+	if err := os.Symlink(root, filepath.Join(show, "loop")); err != nil {
+		t.Skipf("Symlinks unsupported: %v", err)
+	}
+	if err := os.Symlink(outside, filepath.Join(show, "outside.mp3")); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+	if err := os.Link(filepath.Join(show, "episode.mp3"), filepath.Join(show, "second name.mp3")); err != nil {
+		t.Fatalf("Failed to create hardlink: %v", err)
+	}
+
+	var visited []string
+	err := walkAudioFiles(root, func(path string, _ os.FileInfo) error {
+		rel, _ := filepath.Rel(root, path)
+		visited = append(visited, rel)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walkAudioFiles failed: %v", err)
+	}
+	if want := []string{filepath.Join("Show", "episode.mp3")}; !slices.Equal(visited, want) {
+		t.Errorf("Visited %v, want %v", visited, want)
+	}
+}
+
+func TestCleanupSystemHiddenFiles_KeepsPairedAppleDouble(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"Episode.mp3", "._Episode.mp3", "._Gone.mp3"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), 0o644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+
+	cleanupSystemHiddenFiles(dir)
+
+	if _, err := os.Stat(filepath.Join(dir, "._Episode.mp3")); err != nil {
+		t.Errorf("Expected the AppleDouble file of a present episode to be kept: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "._Gone.mp3")); !os.IsNotExist(err) {
+		t.Error("Expected the orphaned AppleDouble file to be removed")
+	}
+}
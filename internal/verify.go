@@ -0,0 +1,172 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
+)
+
+// VerifyMode decides how much of each copied episode is read back from the drive and compared with the original
+type VerifyMode string
+
+const (
+	VerifyNone   VerifyMode = ""       // trust the copy
+	VerifyFull   VerifyMode = "full"   // re-read every file, which about doubles the time a sync takes
+	VerifySample VerifyMode = "sample" // re-read every Nth file and random blocks of the rest
+)
+
+const (
+	// DefaultVerifyEvery is how often a sampled verify re-reads a whole file
+	DefaultVerifyEvery = 10
+	// DefaultVerifyBlocks is how many random blocks a sampled verify compares in the other files
+	DefaultVerifyBlocks = 4
+	// verifyBlockSize is the size of each compared block
+	verifyBlockSize = 1 << 20
+)
+
+// ErrVerifyFailed is returned when a copy read back from the drive differs from its original
+var ErrVerifyFailed = errors.New("the copy on the drive doesn't match the original")
+
+// VerifySettings reads copied episodes back from the drive to catch bad writes on flaky media
+type VerifySettings struct {
+	Mode VerifyMode `json:"mode,omitempty"`
+	// Every is how often a sampled verify re-reads a whole file; the default is DefaultVerifyEvery
+	Every int `json:"every,omitempty"`
+	// Blocks is how many random 1 MB blocks are compared in the other files; the default is DefaultVerifyBlocks
+	Blocks int `json:"blocks,omitempty"`
+}
+
+func (v VerifySettings) validate() error {
+	switch v.Mode {
+	case VerifyNone, VerifyFull, VerifySample:
+	default:
+		return fmt.Errorf("invalid verify mode %q: must be \"full\" or \"sample\"", v.Mode)
+	}
+	if v.Every < 0 || v.Blocks < 0 {
+		return fmt.Errorf("invalid verify %+v: every and blocks must not be negative", v)
+	}
+	return nil
+}
+
+// full reports whether the nth copied file (counted from 1) is re-read whole
+func (v VerifySettings) full(n int) bool {
+	switch v.Mode {
+	case VerifyFull:
+		return true
+	case VerifySample:
+		every := v.Every
+		if every == 0 {
+			every = DefaultVerifyEvery
+		}
+		// The first file is verified whole, so even a short sync has one full check
+		return (n-1)%every == 0
+	}
+	return false
+}
+
+func (v VerifySettings) blocks() int {
+	if v.Blocks == 0 {
+		return DefaultVerifyBlocks
+	}
+	return v.Blocks
+}
+
+// verifyCopy checks the file copied to dstPath against srcPath after the copy has been synced to the
+// drive. The sizes always have to match; then the whole file is compared, or, for sampled files, the
+// last block and blocks at random offsets.
+func (ps *PodcastSync) verifyCopy(srcPath, dstPath string) error {
+	blocks, ok := ps.verifyBlocks()
+	if !ok {
+		return nil
+	}
+	return compareFiles(srcPath, dstPath, blocks)
+}
+
+// verifyPart checks a split part copied to dstPath against its range of src, as verifyCopy checks
+// whole files; each part counts as a copied file
+func (ps *PodcastSync) verifyPart(src io.ReaderAt, start, end int64, dstPath string) error {
+	blocks, ok := ps.verifyBlocks()
+	if !ok {
+		return nil
+	}
+	return compareSection(io.NewSectionReader(src, start, end-start), end-start, dstPath, blocks)
+}
+
+// verifyBlocks counts a copied file and returns how many random blocks of it to compare, negative
+// for all of it; ok is false when the profile doesn't verify copies
+func (ps *PodcastSync) verifyBlocks() (blocks int, ok bool) {
+	v := ps.profile.Verify
+	if v.Mode == VerifyNone {
+		return 0, false
+	}
+	if v.full(int(ps.copied.Add(1))) {
+		return -1, true
+	}
+	return v.blocks(), true
+}
+
+// compareFiles returns ErrVerifyFailed unless dstPath holds the same bytes as srcPath. A negative
+// number of blocks compares the files in full.
+func compareFiles(srcPath, dstPath string, blocks int) error {
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	srcInfo, err := src.Stat()
+	if err != nil {
+		return err
+	}
+	return compareSection(src, srcInfo.Size(), dstPath, blocks)
+}
+
+// compareSection returns ErrVerifyFailed unless dstPath holds the size bytes of src, compared as by compareFiles
+func compareSection(src io.ReaderAt, size int64, dstPath string, blocks int) error {
+	dst, err := os.Open(dstPath)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+	// Read what reached the drive, not what the page cache still holds of the write
+	dropCache(dst)
+
+	dstInfo, err := dst.Stat()
+	if err != nil {
+		return err
+	}
+	if dstInfo.Size() != size {
+		return fmt.Errorf("%w: %d of %d bytes", ErrVerifyFailed, dstInfo.Size(), size)
+	}
+
+	var offsets []int64
+	switch last := max(size-verifyBlockSize, 0); {
+	case blocks < 0 || last == 0:
+		for off := int64(0); off < size; off += verifyBlockSize {
+			offsets = append(offsets, off)
+		}
+	default:
+		offsets = append(offsets, last)
+		for range blocks {
+			offsets = append(offsets, rand.Int64N(last))
+		}
+	}
+
+	want := make([]byte, verifyBlockSize)
+	got := make([]byte, verifyBlockSize)
+	for _, off := range offsets {
+		n := min(verifyBlockSize, size-off)
+		if _, err := src.ReadAt(want[:n], off); err != nil && err != io.EOF {
+			return err
+		}
+		if _, err := dst.ReadAt(got[:n], off); err != nil && err != io.EOF {
+			return fmt.Errorf("failed to read back the copy: %w", err)
+		}
+		if !bytes.Equal(want[:n], got[:n]) {
+			return fmt.Errorf("%w at byte %d", ErrVerifyFailed, off)
+		}
+	}
+	return nil
+}
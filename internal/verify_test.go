@@ -0,0 +1,157 @@
+package internal
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestVerifySettings_Full(t *testing.T) {
+	sample := VerifySettings{Mode: VerifySample, Every: 3}
+	var full []int
+	for n := 1; n <= 7; n++ {
+		if sample.full(n) {
+			full = append(full, n)
+		}
+	}
+	if len(full) != 3 || full[0] != 1 || full[1] != 4 || full[2] != 7 {
+		t.Errorf("Expected every third file from the first to be verified in full, got %v", full)
+	}
+	if !(VerifySettings{Mode: VerifyFull}).full(2) || (VerifySettings{}).full(1) {
+		t.Error("Expected full mode to verify every file and no mode none")
+	}
+}
+
+func TestCompareFiles(t *testing.T) {
+	dir := t.TempDir()
+	content := make([]byte, 3*verifyBlockSize+1000)
+	for i := range content {
+		content[i] = byte(i % 251)
+	}
+	srcPath := filepath.Join(dir, "source.mp3")
+	dstPath := filepath.Join(dir, "copy.mp3")
+	if err := os.WriteFile(srcPath, content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	write := func(b []byte) {
+		t.Helper()
+		if err := os.WriteFile(dstPath, b, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(content)
+	for _, blocks := range []int{-1, 2} {
+		if err := compareFiles(srcPath, dstPath, blocks); err != nil {
+			t.Errorf("Expected an identical copy to verify with %d blocks, got %v", blocks, err)
+		}
+	}
+
+	write(content[:len(content)-1])
+	if err := compareFiles(srcPath, dstPath, 2); !errors.Is(err, ErrVerifyFailed) {
+		t.Errorf("Expected a truncated copy to fail, got %v", err)
+	}
+
+	corrupt := append([]byte(nil), content...)
+	corrupt[verifyBlockSize+5] ^= 0xff
+	write(corrupt)
+	if err := compareFiles(srcPath, dstPath, -1); !errors.Is(err, ErrVerifyFailed) {
+		t.Errorf("Expected a full verify to find the corrupt byte, got %v", err)
+	}
+
+	// Sampling always compares the last block, where an appended resume goes wrong
+	corrupt = append([]byte(nil), content...)
+	corrupt[len(corrupt)-10] ^= 0xff
+	write(corrupt)
+	if err := compareFiles(srcPath, dstPath, 0); !errors.Is(err, ErrVerifyFailed) {
+		t.Errorf("Expected a sampled verify to find the corrupt tail, got %v", err)
+	}
+}
+
+func TestPodcastSync_CopyEpisode_VerifyFails(t *testing.T) {
+	dir := t.TempDir()
+	content := make([]byte, 600*1024)
+	for i := range content {
+		content[i] = byte(i % 251)
+	}
+	srcPath := filepath.Join(dir, "source.mp3")
+	if err := os.WriteFile(srcPath, content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// A kept partial file whose bytes went bad on the drive is resumed without being reread
+	destPath := filepath.Join(dir, "Show", "episode.mp3")
+	_ = os.MkdirAll(filepath.Dir(destPath), 0o755)
+	if err := os.WriteFile(destPath+partialSuffix, make([]byte, 1000), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ps := NewPodcastSync()
+	ps.profile.Verify = VerifySettings{Mode: VerifySample}
+	ps.tm = NewTransferManager(int64(len(content)), 1, ChanSink(make(chan FileOp, 10)))
+	t.Cleanup(ps.tm.Stop)
+	episode := PodcastEpisode{ZTitle: "Episode", FileSize: int64(len(content))}
+
+	if err := ps.copyEpisode(episode, srcPath, destPath); !errors.Is(err, ErrVerifyFailed) {
+		t.Fatalf("Expected the copy to fail verification, got %v", err)
+	}
+	for _, path := range []string{destPath, destPath + partialSuffix} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("Expected the bad copy to be removed so the next sync starts over, got %v", err)
+		}
+	}
+
+	_ = os.MkdirAll(filepath.Dir(destPath), 0o755)
+	if err := ps.copyEpisode(episode, srcPath, destPath); err != nil {
+		t.Fatalf("Expected the fresh copy to verify, got %v", err)
+	}
+}
+
+func TestPodcastSync_VerifyPart(t *testing.T) {
+	dir := t.TempDir()
+	content := make([]byte, 3*verifyBlockSize)
+	for i := range content {
+		content[i] = byte(i % 251)
+	}
+	srcPath := filepath.Join(dir, "source.mp3")
+	if err := os.WriteFile(srcPath, content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	src, err := os.Open(srcPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+
+	ps := NewPodcastSync()
+	ps.profile.Verify = VerifySettings{Mode: VerifyFull}
+	ps.tm = NewTransferManager(int64(len(content)), 1, ChanSink(make(chan FileOp, 10)))
+	t.Cleanup(ps.tm.Stop)
+	start, end := int64(verifyBlockSize/2), int64(2*verifyBlockSize)
+	partPath := filepath.Join(dir, "episode (Part 2 of 3).mp3")
+	if err := ps.writePart(ps.tm.BeginFile("episode"), src, start, end, partPath); err != nil {
+		t.Fatalf("Expected the part to verify against its range of the original, got %v", err)
+	}
+
+	corrupt := append([]byte(nil), content[start:end]...)
+	corrupt[len(corrupt)/2] ^= 0xff
+	if err := os.WriteFile(partPath, corrupt, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ps.verifyPart(src, start, end, partPath); !errors.Is(err, ErrVerifyFailed) {
+		t.Errorf("Expected the corrupt part to fail verification, got %v", err)
+	}
+	if ps.copied.Load() != 2 {
+		t.Errorf("Expected each part to count as a copied file, got %d", ps.copied.Load())
+	}
+}
+
+func TestLoadConfig_InvalidVerify(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"drives": {"CAR": {"verify": {"mode": "sometimes"}}}}`), 0o644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if _, err := LoadConfig(path); err == nil {
+		t.Error("Expected an error for an unknown verify mode")
+	}
+}
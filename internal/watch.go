@@ -0,0 +1,315 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+)
+
+// WatchSettings configures the automatic syncs of watch mode
+type WatchSettings struct {
+	// Drives limits automatic syncs to these volume names; empty syncs every drive
+	Drives []string `json:"drives,omitempty"`
+	// Window limits automatic syncs to a time of day, e.g. "06:00-08:00"; empty allows any time
+	Window string `json:"window,omitempty"`
+	// RequireAC skips automatic syncs while the Mac runs on battery
+	RequireAC bool `json:"requireAC,omitempty"`
+}
+
+// SyncWindow is a daily time range as offsets from midnight. A window whose end is
+// before its start wraps past midnight; the zero value is the whole day.
+type SyncWindow struct {
+	Start, End time.Duration
+}
+
+// ParseSyncWindow parses a window such as "06:00-08:00" or "22:30-01:00"; an empty string is the whole day
+func ParseSyncWindow(s string) (SyncWindow, error) {
+	if s == "" {
+		return SyncWindow{}, nil
+	}
+	from, to, ok := strings.Cut(s, "-")
+	if !ok {
+		return SyncWindow{}, fmt.Errorf("invalid sync window %q: expected HH:MM-HH:MM", s)
+	}
+	var w SyncWindow
+	for _, part := range []struct {
+		text string
+		dst  *time.Duration
+	}{{from, &w.Start}, {to, &w.End}} {
+		t, err := time.Parse("15:04", strings.TrimSpace(part.text))
+		if err != nil {
+			return SyncWindow{}, fmt.Errorf("invalid sync window %q: expected HH:MM-HH:MM", s)
+		}
+		*part.dst = time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	}
+	return w, nil
+}
+
+// Contains reports whether t's time of day falls inside the window
+func (w SyncWindow) Contains(t time.Time) bool {
+	if w.Start == w.End {
+		return true
+	}
+	offset := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+	if w.Start < w.End {
+		return offset >= w.Start && offset < w.End
+	}
+	return offset >= w.Start || offset < w.End
+}
+
+// String formats the window as it is written in the config, e.g. "06:00-08:00"
+func (w SyncWindow) String() string {
+	format := func(d time.Duration) string {
+		return fmt.Sprintf("%02d:%02d", int(d.Hours()), int(d.Minutes())%60)
+	}
+	return format(w.Start) + "-" + format(w.End)
+}
+
+// Watcher syncs the episodes of "always" shows to drives as they are mounted,
+// within the schedule of the config's watch settings. Each drive is synced once per mount.
+type Watcher struct {
+	config   *Config
+	drives   *DriveManager
+	history  *History
+	library  func() ([]PodcastEpisode, error)
+	log      *log.Logger
+	metrics  *Metrics
+	publish  []EventPublisher
+	watchdog *Watchdog
+
+	now  func() time.Time
+	onAC func() (bool, error)
+
+	mounted map[string]bool   // drives found by the last check
+	synced  map[string]bool   // drives synced since they were mounted
+	skipped map[string]string // last reason logged for not syncing a drive
+}
+
+// NewWatcher creates a Watcher that loads episodes with library and logs to logger
+func NewWatcher(cfg *Config, drives *DriveManager, history *History, library func() ([]PodcastEpisode, error), logger *log.Logger) *Watcher {
+	return &Watcher{
+		config:  cfg,
+		drives:  drives,
+		history: history,
+		library: library,
+		log:     logger,
+		now:     time.Now,
+		onAC:    OnACPower,
+		mounted: make(map[string]bool),
+		synced:  make(map[string]bool),
+		skipped: make(map[string]string),
+	}
+}
+
+// SetMetrics counts syncs, copies, failures and scan times in metrics
+func (w *Watcher) SetMetrics(metrics *Metrics) {
+	w.metrics = metrics
+}
+
+// SetWatchdog checks for leaked goroutines with watchdog after every check for drives
+func (w *Watcher) SetWatchdog(watchdog *Watchdog) {
+	w.watchdog = watchdog
+}
+
+// AddPublisher sends the watcher's sync events to p as well
+func (w *Watcher) AddPublisher(p EventPublisher) {
+	w.publish = append(w.publish, p)
+}
+
+// event sends e to every publisher. Failures are logged, never holding up a sync.
+func (w *Watcher) event(e SyncEvent) {
+	e.Time = w.now()
+	for _, p := range w.publish {
+		if err := p.Publish(e); err != nil {
+			w.log.Print(err)
+		}
+	}
+}
+
+// Run checks for drives every interval until ctx is done. Returns ErrGoroutineLeak if the watchdog
+// finds goroutines it can't clean up, and nil once ctx is done.
+func (w *Watcher) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		w.Check()
+		if err := w.checkWatchdog(); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkWatchdog runs the watchdog, if any, logging the ProgressWriters it had to stop
+func (w *Watcher) checkWatchdog() error {
+	if w.watchdog == nil {
+		return nil
+	}
+	before := w.watchdog.Stats().StoppedWriters
+	stats, err := w.watchdog.Check()
+	if stopped := stats.StoppedWriters - before; stopped > 0 {
+		w.log.Printf("watchdog stopped %d orphaned progress writer(s)", stopped)
+	}
+	return err
+}
+
+// Check syncs every watched drive that is mounted, allowed by the schedule and not yet synced since it was mounted.
+// A drive skipped because of the schedule is synced by a later check once the schedule allows it.
+func (w *Watcher) Check() {
+	drives, err := w.drives.DetectDrives()
+	if err != nil {
+		w.log.Printf("failed to detect drives: %v", err)
+		return
+	}
+	w.metrics.setMounted(drives)
+
+	mounted := make(map[string]bool, len(drives))
+	for _, drive := range drives {
+		mounted[drive.Name] = true
+		if !w.mounted[drive.Name] {
+			w.event(SyncEvent{Event: EventDriveConnected, Drive: drive.Name, UUID: drive.UUID})
+		}
+		if w.synced[drive.Name] || !w.watches(drive.Name) {
+			continue
+		}
+		if reason := w.blocked(); reason != "" {
+			// Logged once per reason, since the check repeats every interval
+			if w.skipped[drive.Name] != reason {
+				w.log.Printf("skipped sync to %s: %s", drive.Name, reason)
+				w.skipped[drive.Name] = reason
+			}
+			continue
+		}
+		delete(w.skipped, drive.Name)
+		w.synced[drive.Name] = true
+		w.sync(drive)
+	}
+
+	w.mounted = mounted
+	// Forget unmounted drives so they are synced again when they come back
+	for name := range w.synced {
+		if !mounted[name] {
+			delete(w.synced, name)
+		}
+	}
+	for name := range w.skipped {
+		if !mounted[name] {
+			delete(w.skipped, name)
+		}
+	}
+}
+
+func (w *Watcher) watches(drive string) bool {
+	return len(w.config.Watch.Drives) == 0 || slices.Contains(w.config.Watch.Drives, drive)
+}
+
+// blocked returns why automatic syncs can't run right now, or "" if they can
+func (w *Watcher) blocked() string {
+	// The window was validated when the config was loaded
+	window, _ := ParseSyncWindow(w.config.Watch.Window)
+	if !window.Contains(w.now()) {
+		return "outside the sync window " + window.String()
+	}
+	if w.config.Watch.RequireAC {
+		ac, err := w.onAC()
+		if err != nil {
+			return fmt.Sprintf("could not read the power source: %v", err)
+		}
+		if !ac {
+			return "running on battery"
+		}
+	}
+	return ""
+}
+
+// sync copies the episodes the show policies select to drive and logs the outcome
+func (w *Watcher) sync(drive USBDrive) {
+	w.metrics.syncStarted(drive.Name)
+	w.event(SyncEvent{Event: EventSyncStarted, Drive: drive.Name, UUID: drive.UUID})
+	failed := func(err error) {
+		w.metrics.syncFailed(drive.Name)
+		w.event(SyncEvent{Event: EventSyncFailed, Drive: drive.Name, UUID: drive.UUID, Error: err.Error()})
+	}
+	episodes, err := w.library()
+	if err != nil {
+		w.log.Printf("failed to load the library for %s: %v", drive.Name, err)
+		failed(err)
+		return
+	}
+
+	started := time.Now()
+	err = MarkOnDrive(episodes, drive)
+	w.metrics.scanned(drive.Name, time.Since(started))
+	if err != nil {
+		w.log.Printf("failed to scan %s: %v", drive.Name, err)
+		failed(err)
+		return
+	}
+	AutoSelect(episodes, w.config)
+	if !slices.ContainsFunc(episodes, func(e PodcastEpisode) bool { return e.Selected }) {
+		w.log.Printf("%s is up to date", drive.Name)
+		w.event(SyncEvent{Event: EventSyncFinished, Drive: drive.Name, UUID: drive.UUID})
+		return
+	}
+
+	progress, err := SyncAndWait(episodes, drive, w.history)
+	w.metrics.copied(drive.Name, progress)
+	if err != nil {
+		w.log.Printf("sync to %s failed: %v", drive.Name, err)
+		failed(err)
+		return
+	}
+	w.log.Printf("synced %d episode(s), %s, to %s", progress.FilesDone, FormatBytes(progress.BytesTransferred), drive.Name)
+	w.event(SyncEvent{Event: EventSyncFinished, Drive: drive.Name, UUID: drive.UUID, Episodes: progress.FilesDone, Bytes: progress.BytesTransferred, Titles: SelectedTitles(episodes)})
+}
+
+// SelectedTitles returns "Show: Title" for each selected episode, for notifications
+func SelectedTitles(episodes []PodcastEpisode) []string {
+	var titles []string
+	for _, e := range episodes {
+		if e.Selected {
+			titles = append(titles, e.ShowName+": "+e.ZTitle)
+		}
+	}
+	return titles
+}
+
+// MarkOnDrive scans drive and marks the episodes already on it
+func MarkOnDrive(episodes []PodcastEpisode, drive USBDrive) error {
+	bySize := make(map[int64][]*PodcastEpisode)
+	for i := range episodes {
+		if episodes[i].FileSize > 0 {
+			bySize[episodes[i].FileSize] = append(bySize[episodes[i].FileSize], &episodes[i])
+		}
+	}
+	_, err := NewPodcastScanner(DirectoryTemplate{}).ScanDrive(drive, bySize)
+	return err
+}
+
+// SyncAndWait copies the selected episodes to drive without a UI and returns once the sync is done
+func SyncAndWait(episodes []PodcastEpisode, drive USBDrive, history *History) (TransferProgress, error) {
+	ps := NewPodcastSync()
+	ps.SetHistory(history)
+	ch := make(chan FileOp, 16)
+	ps.StartSync(episodes, drive, ChanSink(ch))
+
+	var progress TransferProgress
+	var syncErr error
+	for op := range ch {
+		if op.Error != nil {
+			syncErr = op.Error
+		}
+		if op.Complete {
+			progress = op.Progress
+		}
+	}
+	return progress, syncErr
+}
@@ -0,0 +1,122 @@
+package internal
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSyncWindow(t *testing.T) {
+	at := func(clock string) time.Time {
+		t, _ := time.Parse("15:04", clock)
+		return t
+	}
+	tests := []struct {
+		window string
+		in     []string
+		out    []string
+	}{
+		{"", []string{"00:00", "12:00", "23:59"}, nil},
+		{"06:00-08:00", []string{"06:00", "07:59"}, []string{"05:59", "08:00", "20:00"}},
+		{"22:30-01:00", []string{"22:30", "23:59", "00:30"}, []string{"01:00", "12:00", "22:29"}},
+	}
+	for _, tt := range tests {
+		w, err := ParseSyncWindow(tt.window)
+		if err != nil {
+			t.Fatalf("ParseSyncWindow(%q) failed: %v", tt.window, err)
+		}
+		for _, clock := range tt.in {
+			if !w.Contains(at(clock)) {
+				t.Errorf("Expected %q to contain %s", tt.window, clock)
+			}
+		}
+		for _, clock := range tt.out {
+			if w.Contains(at(clock)) {
+				t.Errorf("Expected %q not to contain %s", tt.window, clock)
+			}
+		}
+	}
+
+	if w, _ := ParseSyncWindow("6:00-8:30"); w.String() != "06:00-08:30" {
+		t.Errorf("Expected the window to round trip, got %s", w)
+	}
+	for _, invalid := range []string{"06:00", "6am-8am", "06:00-25:00"} {
+		if _, err := ParseSyncWindow(invalid); err == nil {
+			t.Errorf("Expected an error for %q", invalid)
+		}
+	}
+}
+
+func TestWatcher_Check(t *testing.T) {
+	tempDir := t.TempDir()
+	volumes := filepath.Join(tempDir, "Volumes")
+	if err := os.MkdirAll(filepath.Join(volumes, "CAR"), 0o755); err != nil {
+		t.Fatalf("Failed to create drive: %v", err)
+	}
+	source := filepath.Join(tempDir, "episode.mp3")
+	if err := os.WriteFile(source, make([]byte, 2048), 0o644); err != nil {
+		t.Fatalf("Failed to create source file: %v", err)
+	}
+	episode := PodcastEpisode{ZTitle: "Morning", ShowName: "Commute", FilePath: "file://" + source, FileSize: 2048}
+	library := func() ([]PodcastEpisode, error) {
+		return []PodcastEpisode{episode}, nil
+	}
+
+	cfg := &Config{
+		Shows: map[string]ShowPolicy{"Commute": {Sync: SyncAlways}},
+		Watch: WatchSettings{Window: "06:00-08:00", RequireAC: true},
+	}
+	var logs bytes.Buffer
+	w := NewWatcher(cfg, NewDriveManager(volumes, DirectoryTemplate{}), nil, library, log.New(&logs, "", 0))
+	clock, _ := time.Parse("15:04", "09:00")
+	w.now = func() time.Time { return clock }
+	ac := false
+	w.onAC = func() (bool, error) { return ac, nil }
+
+	w.Check()
+	w.Check()
+	if got := strings.Count(logs.String(), "skipped sync to CAR: outside the sync window 06:00-08:00"); got != 1 {
+		t.Errorf("Expected the skip to be logged once, got:\n%s", logs.String())
+	}
+
+	clock = clock.Add(-2 * time.Hour)
+	w.Check()
+	if !strings.Contains(logs.String(), "skipped sync to CAR: running on battery") {
+		t.Errorf("Expected a skip while on battery, got:\n%s", logs.String())
+	}
+
+	ac = true
+	w.Check()
+	dest := filepath.Join(volumes, "CAR", "podcasts", sanitizeName(episode.ShowName), formatEpisodeName(episode))
+	if _, err := os.Stat(dest); err != nil {
+		t.Fatalf("Expected the episode to be synced: %v\n%s", err, logs.String())
+	}
+	if !strings.Contains(logs.String(), "synced 1 episode(s)") {
+		t.Errorf("Expected the sync to be logged, got:\n%s", logs.String())
+	}
+
+	// Synced once per mount
+	logs.Reset()
+	w.Check()
+	if logs.Len() != 0 {
+		t.Errorf("Expected no second sync while the drive stays mounted, got:\n%s", logs.String())
+	}
+}
+
+func TestParsePowerSource(t *testing.T) {
+	ac, err := parsePowerSource("Now drawing from 'AC Power'\n -InternalBattery-0 (id=1)\t100%; charged;\n")
+	if err != nil || !ac {
+		t.Errorf("Expected AC power, got %v, %v", ac, err)
+	}
+	ac, err = parsePowerSource("Now drawing from 'Battery Power'\n")
+	if err != nil || ac {
+		t.Errorf("Expected battery power, got %v, %v", ac, err)
+	}
+	if _, err := parsePowerSource(""); err == nil {
+		t.Error("Expected an error for unexpected output")
+	}
+}
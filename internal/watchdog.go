@@ -0,0 +1,68 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// ErrGoroutineLeak is returned by a watchdog check once goroutines have grown past what it can clean up
+var ErrGoroutineLeak = errors.New("goroutines keep growing between syncs")
+
+// defaultGoroutineLimit is how many goroutines may be added to the first check's count before it is a leak
+const defaultGoroutineLimit = 200
+
+// Watchdog checks between the syncs of watch mode that nothing a sync started is still running, for a
+// process left running for weeks. Orphaned ProgressWriters are stopped; goroutines that grow past the
+// count of the first check by more than Limit are a leak it can't reach, and the engine has to restart.
+type Watchdog struct {
+	Limit      int
+	goroutines func() int
+
+	mu    sync.Mutex
+	stats WatchdogStats
+}
+
+// WatchdogStats are the watchdog's counts as of its last check, for the debug state
+type WatchdogStats struct {
+	Checks          int   `json:"checks"`
+	Goroutines      int   `json:"goroutines"`
+	Baseline        int   `json:"baseline"`
+	ProgressWriters int64 `json:"progressWriters"`
+	// Orphaned ProgressWriters stopped since the watchdog started
+	StoppedWriters int `json:"stoppedWriters"`
+}
+
+// NewWatchdog creates a watchdog with the default goroutine limit
+func NewWatchdog() *Watchdog {
+	return &Watchdog{Limit: defaultGoroutineLimit, goroutines: runtime.NumGoroutine}
+}
+
+// Check stops any ProgressWriter still running and compares the goroutines left with the first check.
+// Must only be called while no sync is running. Returns the counts and ErrGoroutineLeak past the limit.
+func (wd *Watchdog) Check() (WatchdogStats, error) {
+	wd.mu.Lock()
+	defer wd.mu.Unlock()
+
+	wd.stats.Checks++
+	wd.stats.ProgressWriters = ActiveProgressWriters()
+	if wd.stats.ProgressWriters > 0 {
+		wd.stats.StoppedWriters += StopProgressWriters()
+	}
+	wd.stats.Goroutines = wd.goroutines()
+	if wd.stats.Baseline == 0 {
+		wd.stats.Baseline = wd.stats.Goroutines
+	}
+	if wd.stats.Goroutines-wd.stats.Baseline > wd.Limit {
+		return wd.stats, fmt.Errorf("%w: %d running, %d after the first check", ErrGoroutineLeak, wd.stats.Goroutines, wd.stats.Baseline)
+	}
+	return wd.stats, nil
+}
+
+// Stats returns the counts as of the last check
+func (wd *Watchdog) Stats() WatchdogStats {
+	wd.mu.Lock()
+	defer wd.mu.Unlock()
+	return wd.stats
+}
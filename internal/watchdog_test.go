@@ -0,0 +1,52 @@
+package internal
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestWatchdog_StopsOrphanedProgressWriters(t *testing.T) {
+	// A transfer manager that was never stopped leaves its ProgressWriter running
+	NewTransferManager(100, 1, ChanSink(make(chan FileOp, 10)))
+	if ActiveProgressWriters() == 0 {
+		t.Fatal("Expected the orphaned writer running")
+	}
+
+	wd := NewWatchdog()
+	stats, err := wd.Check()
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	if stats.ProgressWriters == 0 || stats.StoppedWriters != int(stats.ProgressWriters) {
+		t.Errorf("Expected the orphaned writers stopped, got %+v", stats)
+	}
+	if n := ActiveProgressWriters(); n != 0 {
+		t.Errorf("Expected no writers left running, got %d", n)
+	}
+	if wd.Stats() != stats {
+		t.Errorf("Expected Stats to return the last check's counts, got %+v", wd.Stats())
+	}
+}
+
+func TestWatchdog_GoroutineLeak(t *testing.T) {
+	goroutines := 20
+	wd := NewWatchdog()
+	wd.Limit = 10
+	wd.goroutines = func() int { return goroutines }
+
+	if _, err := wd.Check(); err != nil {
+		t.Fatalf("Expected the first check to set the baseline, got %v", err)
+	}
+	goroutines = 30
+	if _, err := wd.Check(); err != nil {
+		t.Errorf("Expected growth within the limit allowed, got %v", err)
+	}
+	goroutines = 31
+	stats, err := wd.Check()
+	if !errors.Is(err, ErrGoroutineLeak) {
+		t.Errorf("Expected ErrGoroutineLeak, got %v", err)
+	}
+	if stats.Baseline != 20 || stats.Goroutines != 31 || stats.Checks != 3 {
+		t.Errorf("Unexpected counts %+v", stats)
+	}
+}
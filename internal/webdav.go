@@ -0,0 +1,298 @@
+package internal
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultWebDAVConcurrency is how many files are uploaded at once to shares that don't set concurrency
+const defaultWebDAVConcurrency = 4
+
+// WebDAVSettings point a drive profile at a WebDAV folder, such as a Nextcloud files URL
+type WebDAVSettings struct {
+	URL      string `json:"url"`
+	User     string `json:"user,omitempty"`
+	Password string `json:"password,omitempty"`
+	// Concurrency is the number of uploads at once; the default is defaultWebDAVConcurrency
+	Concurrency int `json:"concurrency,omitempty"`
+}
+
+func (w *WebDAVSettings) validate() error {
+	if w == nil {
+		return nil
+	}
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid webdav url %q: must be an http or https URL", w.URL)
+	}
+	if w.Concurrency < 0 {
+		return fmt.Errorf("invalid webdav concurrency %d: must not be negative", w.Concurrency)
+	}
+	return nil
+}
+
+// webDAVDrives returns a drive for each profile with a WebDAV share, synced through a mirror named after it
+func (dm *DriveManager) webDAVDrives() []USBDrive {
+	var drives []USBDrive
+	profiles := dm.profileSnapshot()
+	for _, name := range slices.Sorted(maps.Keys(profiles)) {
+		profile := profiles[name]
+		if profile.WebDAV == nil {
+			continue
+		}
+		mirror := filepath.Join(dm.mirrors, "webdav", sanitizeName(name))
+		if err := os.MkdirAll(mirror, 0o755); err != nil {
+			continue
+		}
+		drives = append(drives, USBDrive{Name: name, MountPath: mirror, Profile: profile})
+	}
+	return drives
+}
+
+// webDAVClient uploads without an overall timeout, since episodes are large, but gives up on silent servers
+var webDAVClient = &http.Client{
+	Transport: &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		ResponseHeaderTimeout: time.Minute,
+		TLSHandshakeTimeout:   15 * time.Second,
+		MaxIdleConnsPerHost:   defaultWebDAVConcurrency,
+	},
+}
+
+type webDAVDestination struct {
+	settings WebDAVSettings
+	base     *url.URL
+	// made remembers the folders created during this push
+	made sync.Map
+}
+
+func newWebDAVDestination(settings WebDAVSettings) *webDAVDestination {
+	base, err := url.Parse(settings.URL)
+	if err != nil {
+		base = &url.URL{}
+	}
+	base.Path = strings.TrimSuffix(base.Path, "/")
+	base.RawPath = ""
+	return &webDAVDestination{settings: settings, base: base}
+}
+
+func (w *webDAVDestination) String() string {
+	u := *w.base
+	u.User = nil
+	return u.String()
+}
+
+// Tuning uploads several files at once and retries each a few times, for connections that drop
+func (w *webDAVDestination) Tuning() PushTuning {
+	workers := w.settings.Concurrency
+	if workers == 0 {
+		workers = defaultWebDAVConcurrency
+	}
+	return PushTuning{Workers: workers, Attempts: 3}
+}
+
+// url returns the URL of rel below the share
+func (w *webDAVDestination) url(rel string) string {
+	u := *w.base
+	if rel != "" {
+		u.Path += "/" + rel
+	}
+	return u.String()
+}
+
+func (w *webDAVDestination) do(method, rel string, body io.Reader, size int64, header map[string]string) (*http.Response, error) {
+	req, err := http.NewRequest(method, w.url(rel), body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.ContentLength = size
+	}
+	if w.settings.User != "" {
+		req.SetBasicAuth(w.settings.User, w.settings.Password)
+	}
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	return webDAVClient.Do(req)
+}
+
+// webDAVStatusError reports an unexpected response
+func webDAVStatusError(method, rel string, resp *http.Response) error {
+	return fmt.Errorf("%s %s: %s", method, path.Join("/", rel), resp.Status)
+}
+
+type davMultistatus struct {
+	Responses []struct {
+		Href     string `xml:"href"`
+		Propstat []struct {
+			Prop struct {
+				Length       int64 `xml:"getcontentlength"`
+				ResourceType struct {
+					Collection *struct{} `xml:"collection"`
+				} `xml:"resourcetype"`
+			} `xml:"prop"`
+		} `xml:"propstat"`
+	} `xml:"response"`
+}
+
+const propfindBody = `<?xml version="1.0" encoding="utf-8"?>
+<d:propfind xmlns:d="DAV:"><d:prop><d:resourcetype/><d:getcontentlength/></d:prop></d:propfind>`
+
+// children lists the folder at rel: the size of each file and the paths of subfolders.
+// Depth 1 is used throughout, since servers like Nextcloud refuse infinite depth.
+func (w *webDAVDestination) children(rel string) (map[string]int64, []string, error) {
+	resp, err := w.do("PROPFIND", rel, strings.NewReader(propfindBody), int64(len(propfindBody)), map[string]string{"Depth": "1", "Content-Type": "application/xml"})
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil, os.ErrNotExist
+	}
+	if resp.StatusCode != http.StatusMultiStatus {
+		return nil, nil, webDAVStatusError("PROPFIND", rel, resp)
+	}
+	var status davMultistatus
+	if err := xml.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return nil, nil, fmt.Errorf("PROPFIND %s: %w", path.Join("/", rel), err)
+	}
+
+	files := make(map[string]int64)
+	var folders []string
+	for _, r := range status.Responses {
+		href, err := url.Parse(r.Href)
+		if err != nil {
+			continue
+		}
+		child, ok := strings.CutPrefix(strings.TrimSuffix(href.Path, "/"), w.base.Path+"/")
+		if !ok || child == rel || len(r.Propstat) == 0 {
+			continue
+		}
+		prop := r.Propstat[0].Prop
+		if prop.ResourceType.Collection != nil {
+			folders = append(folders, child)
+		} else {
+			files[child] = prop.Length
+		}
+	}
+	return files, folders, nil
+}
+
+func (w *webDAVDestination) List() (map[string]int64, error) {
+	all := make(map[string]int64)
+	for pending := []string{""}; len(pending) > 0; {
+		rel := pending[0]
+		pending = pending[1:]
+		files, folders, err := w.children(rel)
+		if errors.Is(err, os.ErrNotExist) && rel == "" {
+			return all, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", w, err)
+		}
+		for name, size := range files {
+			all[name] = size
+		}
+		pending = append(pending, folders...)
+	}
+	return all, nil
+}
+
+// mkdirs creates the share and each folder of rel's parents that this push hasn't made yet
+func (w *webDAVDestination) mkdirs(rel string) error {
+	dirs := []string{""}
+	if dir := path.Dir(rel); dir != "." {
+		parts := strings.Split(dir, "/")
+		for i := range parts {
+			dirs = append(dirs, strings.Join(parts[:i+1], "/"))
+		}
+	}
+	for _, dir := range dirs {
+		if _, done := w.made.Load(dir); done {
+			continue
+		}
+		resp, err := w.do("MKCOL", dir, nil, 0, nil)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		// 405 is an existing folder
+		if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusMethodNotAllowed {
+			return webDAVStatusError("MKCOL", dir, resp)
+		}
+		w.made.Store(dir, true)
+	}
+	return nil
+}
+
+func (w *webDAVDestination) Put(rel string, r io.Reader, size int64) error {
+	if err := w.mkdirs(rel); err != nil {
+		return err
+	}
+	resp, err := w.do(http.MethodPut, rel, r, size, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return webDAVStatusError("PUT", rel, resp)
+	}
+	return nil
+}
+
+func (w *webDAVDestination) Remove(rels []string) error {
+	var errs []error
+	folders := make(map[string]bool)
+	for _, rel := range rels {
+		if err := w.delete(rel); err != nil {
+			errs = append(errs, err)
+		}
+		for dir := path.Dir(rel); dir != "."; dir = path.Dir(dir) {
+			folders[dir] = true
+		}
+	}
+	// Deepest first, so a show folder empties before its parent is checked
+	dirs := slices.SortedFunc(maps.Keys(folders), func(a, b string) int { return strings.Count(b, "/") - strings.Count(a, "/") })
+	for _, dir := range dirs {
+		files, subfolders, err := w.children(dir)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if len(files) > 0 || len(subfolders) > 0 {
+			continue
+		}
+		if err := w.delete(dir); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (w *webDAVDestination) delete(rel string) error {
+	resp, err := w.do(http.MethodDelete, rel, nil, 0, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound && (resp.StatusCode < 200 || resp.StatusCode > 299) {
+		return webDAVStatusError("DELETE", rel, resp)
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeWebDAV is an in-memory WebDAV share below /dav. The first PUT of each file fails, to exercise retries.
+type fakeWebDAV struct {
+	mu      sync.Mutex
+	files   map[string][]byte
+	folders map[string]bool
+	failed  map[string]bool
+	// Paths whose DELETE is refused as locked
+	locked map[string]bool
+}
+
+func newFakeWebDAV(t *testing.T) (*fakeWebDAV, string) {
+	t.Helper()
+	dav := &fakeWebDAV{files: map[string][]byte{}, folders: map[string]bool{"/dav": true}, failed: map[string]bool{}}
+	server := httptest.NewServer(dav)
+	t.Cleanup(server.Close)
+	return dav, server.URL + "/dav/Podcasts"
+}
+
+func (d *fakeWebDAV) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if user, pass, _ := r.BasicAuth(); user != "me" || pass != "secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	p := strings.TrimSuffix(r.URL.Path, "/")
+	switch r.Method {
+	case "MKCOL":
+		if d.folders[p] {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		d.folders[p] = true
+		w.WriteHeader(http.StatusCreated)
+	case http.MethodPut:
+		data, _ := io.ReadAll(r.Body)
+		if !d.failed[p] {
+			d.failed[p] = true
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		d.files[p] = data
+		w.WriteHeader(http.StatusCreated)
+	case http.MethodDelete:
+		if d.locked[p] {
+			w.WriteHeader(http.StatusLocked)
+			return
+		}
+		delete(d.files, p)
+		delete(d.folders, p)
+		w.WriteHeader(http.StatusNoContent)
+	case "PROPFIND":
+		if !d.folders[p] {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusMultiStatus)
+		fmt.Fprint(w, `<?xml version="1.0"?><d:multistatus xmlns:d="DAV:">`)
+		fmt.Fprintf(w, `<d:response><d:href>%s/</d:href><d:propstat><d:prop><d:resourcetype><d:collection/></d:resourcetype></d:prop></d:propstat></d:response>`, (&url.URL{Path: p}).EscapedPath())
+		for folder := range d.folders {
+			if path.Dir(folder) == p {
+				fmt.Fprintf(w, `<d:response><d:href>%s/</d:href><d:propstat><d:prop><d:resourcetype><d:collection/></d:resourcetype></d:prop></d:propstat></d:response>`, (&url.URL{Path: folder}).EscapedPath())
+			}
+		}
+		for file, data := range d.files {
+			if path.Dir(file) == p {
+				fmt.Fprintf(w, `<d:response><d:href>%s</d:href><d:propstat><d:prop><d:resourcetype/><d:getcontentlength>%d</d:getcontentlength></d:prop></d:propstat></d:response>`, (&url.URL{Path: file}).EscapedPath(), len(data))
+			}
+		}
+		fmt.Fprint(w, `</d:multistatus>`)
+	}
+}
+
+func (d *fakeWebDAV) paths() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var paths []string
+	for p := range d.files {
+		paths = append(paths, p)
+	}
+	for p := range d.folders {
+		paths = append(paths, p+"/")
+	}
+	slices.Sort(paths)
+	return paths
+}
+
+func TestPushMirror_WebDAV(t *testing.T) {
+	orig := pushRetryDelay
+	pushRetryDelay = 0
+	t.Cleanup(func() { pushRetryDelay = orig })
+
+	dav, shareURL := newFakeWebDAV(t)
+	dest := newWebDAVDestination(WebDAVSettings{URL: shareURL, User: "me", Password: "secret", Concurrency: 2})
+	mirror := t.TempDir()
+	writeDriveFiles(t, mirror, "Show A/One #1.mp3", "Show A/Two.mp3", "Show B/Three.mp3", "podcasts.m3u8", ".podcasts-sync.json")
+
+	if err := pushMirror(dest, mirror, nil); err != nil {
+		t.Fatalf("pushMirror failed: %v", err)
+	}
+	want := []string{
+		"/dav/",
+		"/dav/Podcasts/",
+		"/dav/Podcasts/Show A/",
+		"/dav/Podcasts/Show A/One #1.mp3",
+		"/dav/Podcasts/Show A/Two.mp3",
+		"/dav/Podcasts/Show B/",
+		"/dav/Podcasts/Show B/Three.mp3",
+		"/dav/Podcasts/podcasts.m3u8",
+	}
+	if got := dav.paths(); !slices.Equal(got, want) {
+		t.Errorf("Expected the mirror on the share after a retry of each upload, got %q", got)
+	}
+
+	dest = newWebDAVDestination(WebDAVSettings{URL: shareURL, User: "me", Password: "secret"})
+	if err := os.Remove(filepath.Join(mirror, "Show B", "Three.mp3")); err != nil {
+		t.Fatal(err)
+	}
+	if err := pushMirror(dest, mirror, nil); err != nil {
+		t.Fatalf("pushMirror failed: %v", err)
+	}
+	if got := dav.paths(); slices.Contains(got, "/dav/Podcasts/Show B/Three.mp3") || slices.Contains(got, "/dav/Podcasts/Show B/") {
+		t.Errorf("Expected the removed episode and its emptied folder to leave the share, got %q", got)
+	}
+}
+
+func TestLoadConfig_InvalidWebDAV(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"drives": {"Cloud": {"webdav": {"url": "cloud.example.com/dav"}}}}`), 0o644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if _, err := LoadConfig(path); err == nil {
+		t.Error("Expected an error for a webdav url without a scheme")
+	}
+}
+
+func TestWebDAVRemove_ReportsFolderErrors(t *testing.T) {
+	dav, shareURL := newFakeWebDAV(t)
+	dav.folders["/dav/Podcasts"] = true
+	dav.folders["/dav/Podcasts/Show"] = true
+	dav.files["/dav/Podcasts/Show/One.mp3"] = []byte("audio")
+	dav.locked = map[string]bool{"/dav/Podcasts/Show": true}
+
+	dest := newWebDAVDestination(WebDAVSettings{URL: shareURL, User: "me", Password: "secret"})
+	err := dest.Remove([]string{"Show/One.mp3"})
+	if err == nil || !strings.Contains(err.Error(), "DELETE /Show: 423") {
+		t.Errorf("Expected the folder that couldn't be removed to be reported, got %v", err)
+	}
+	if got := dav.paths(); slices.Contains(got, "/dav/Podcasts/Show/One.mp3") {
+		t.Errorf("Expected the episode removed, got %q", got)
+	}
+}
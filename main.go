@@ -1,20 +1,98 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"os"
 
 	tea "github.com/charmbracelet/bubbletea"
 
+	"github.com/joncrangle/podcasts-sync/internal"
 	"github.com/joncrangle/podcasts-sync/tui"
 )
 
 var version = "dev"
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "analyze" {
+		if err := runAnalyze(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "analyze: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "bench" {
+		if err := runBench(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "bench: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "watch" {
+		err := runWatch(os.Args[2:])
+		if errors.Is(err, internal.ErrGoroutineLeak) {
+			// A fresh process is the only way to get rid of goroutines the watchdog can't stop
+			fmt.Fprintf(os.Stderr, "watch: %v, restarting\n", err)
+			err = restart()
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "watch: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "checksums" {
+		if err := runChecksums(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "checksums: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "verify" {
+		if err := runVerify(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "verify: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "sync" {
+		if err := runSync(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "sync: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "decrypt" {
+		if err := runDecrypt(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "decrypt: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "serve" {
+		if err := runServe(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "serve: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	showVersion := flag.Bool("version", false, "Show application version")
 	showVersionShort := flag.Bool("v", false, "Show application version (short)")
+	debugAddr := flag.String("debug-addr", "", "Serve pprof and a state dump on this address (e.g. 127.0.0.1:6060)")
+	recordProgress := flag.Bool("record-progress", false, "Record raw progress samples of each sync for `podcasts-sync analyze`")
+	demo := flag.Bool("demo", false, "Explore with a synthetic library and drive instead of Apple Podcasts and USB drives")
+	allowSleep := flag.Bool("allow-sleep", false, "Let the Mac sleep while a sync is running")
+	readOnly := flag.Bool("read-only", false, "Browse without syncing, deleting or changing anything")
+	background := flag.Bool("background", false, "Sync at background priority, pausing between chunks so the Mac stays responsive")
+	repeatLastSync := flag.Bool("repeat-last-sync", false, "Sync new episodes of the last sync's shows to the same drive, without the TUI")
 
 	flag.Parse()
 
@@ -23,9 +101,64 @@ func main() {
 		os.Exit(0)
 	}
 
-	initialModel := tui.InitialModel()
-	p := tea.NewProgram(initialModel, tea.WithAltScreen())
-	if _, err := p.Run(); err != nil {
+	if *background {
+		if err := internal.SetBackground(true); err != nil {
+			fmt.Fprintf(os.Stderr, "background: %v\n", err)
+		}
+	}
+
+	if *repeatLastSync {
+		if *readOnly {
+			fmt.Fprintln(os.Stderr, "repeat: --repeat-last-sync copies to the drive, which --read-only refuses")
+			os.Exit(1)
+		}
+		if err := runRepeatLastSync(); err != nil {
+			fmt.Fprintf(os.Stderr, "repeat: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	opts := tui.Options{AllowSleep: *allowSleep, ReadOnly: *readOnly}
+	if *recordProgress {
+		opts.RecordProgressDir = internal.DefaultRecordingsDir()
+	}
+	if *debugAddr != "" {
+		if _, err := internal.StartDebugServer(*debugAddr, tui.DebugState); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		opts.PublishDebugState = true
+	}
+
+	if *demo {
+		d, err := internal.NewDemo()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		opts.Demo = d
+	}
+
+	initialModel := tui.NewModel(opts)
+	_, err := tui.RunGuarded(initialModel, func(crash tui.Crash) {
+		if opts.Demo != nil {
+			_ = opts.Demo.Close()
+		}
+		fmt.Fprintf(os.Stderr, "podcasts-sync crashed: %v\n", crash.Value)
+		path, err := internal.WriteCrashReport(internal.DefaultCrashesDir(), version, crash.Value, crash.Stack, crash.Log)
+		if err != nil {
+			// Without a report the stack is the only clue left
+			fmt.Fprintf(os.Stderr, "%v\n\n%s", err, crash.Stack)
+		} else {
+			fmt.Fprintf(os.Stderr, "A crash report was written to %s\n", path)
+		}
+		os.Exit(2)
+	}, tea.WithAltScreen())
+	if opts.Demo != nil {
+		_ = opts.Demo.Close()
+	}
+	if err != nil {
 		fmt.Printf("Failed to start TUI application: %v\n", err)
 		os.Exit(1)
 	}
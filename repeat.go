@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/joncrangle/podcasts-sync/internal"
+)
+
+// runRepeatLastSync syncs the new episodes of the shows in the last sync to the same drive, without the TUI
+func runRepeatLastSync() error {
+	cfg, err := internal.LoadConfig(internal.DefaultConfigPath())
+	if err != nil {
+		return err
+	}
+	history := internal.NewHistory(internal.DefaultHistoryPath())
+	defer history.Close()
+
+	actions, err := history.LastActions()
+	if err != nil {
+		return err
+	}
+	last, ok := actions[internal.ActionSync]
+	if !ok {
+		return errors.New("no sync to repeat yet")
+	}
+
+	// Unattended runs report to the notifications of the last sync's drive
+	notifier := internal.NewNotifier(cfg.Drives)
+	notify := func(event internal.SyncEvent) {
+		event.Drive, event.Time = last.Drive, time.Now()
+		if err := notifier.Publish(event); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
+	progress, titles, err := repeatSync(cfg, history, last)
+	if err != nil {
+		notify(internal.SyncEvent{Event: internal.EventSyncFailed, Error: err.Error()})
+		return err
+	}
+	if len(titles) == 0 {
+		fmt.Printf("%s is up to date\n", last.Drive)
+		notify(internal.SyncEvent{Event: internal.EventSyncFinished})
+		return nil
+	}
+	fmt.Printf("synced %d episode(s), %s, to %s\n", progress.FilesDone, internal.FormatBytes(progress.BytesTransferred), last.Drive)
+	notify(internal.SyncEvent{Event: internal.EventSyncFinished, Episodes: progress.FilesDone, Bytes: progress.BytesTransferred, Titles: titles})
+	return nil
+}
+
+// repeatSync syncs to the drive of last and returns the titles synced, none when the drive is up to date
+func repeatSync(cfg *internal.Config, history *internal.History, last internal.Action) (internal.TransferProgress, []string, error) {
+	drives := internal.NewDriveManager("/Volumes", internal.DirectoryTemplate{})
+	drives.SetProfiles(cfg.Drives)
+	drives.SetMirrors(internal.DefaultMirrorsDir())
+	mounted, err := drives.DetectDrives()
+	if err != nil {
+		return internal.TransferProgress{}, nil, err
+	}
+	i := slices.IndexFunc(mounted, func(d internal.USBDrive) bool { return d.Name == last.Drive })
+	if i < 0 {
+		return internal.TransferProgress{}, nil, fmt.Errorf("drive %q of the last sync is not connected", last.Drive)
+	}
+	drive := mounted[i]
+
+	podcasts, err := internal.LoadMacPodcasts()
+	if err != nil {
+		return internal.TransferProgress{}, nil, err
+	}
+	episodes, err := internal.LoadLocalPodcasts(podcasts)
+	if err != nil {
+		return internal.TransferProgress{}, nil, err
+	}
+	if err := internal.MarkOnDrive(episodes, drive); err != nil {
+		return internal.TransferProgress{}, nil, fmt.Errorf("failed to scan %s: %w", drive.Name, err)
+	}
+	if internal.SelectRepeat(episodes, last, cfg) == 0 {
+		return internal.TransferProgress{}, nil, nil
+	}
+
+	titles := internal.SelectedTitles(episodes)
+	progress, err := internal.SyncAndWait(episodes, drive, history)
+	if err != nil {
+		return progress, nil, err
+	}
+	selected := slices.DeleteFunc(episodes, func(e internal.PodcastEpisode) bool { return !e.Selected })
+	if err := history.RecordAction(internal.NewAction(internal.ActionSync, drive.Name, selected)); err != nil {
+		return internal.TransferProgress{}, nil, err
+	}
+	return progress, titles, nil
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package main
+
+import "errors"
+
+func restart() error {
+	return errors.ErrUnsupported
+}
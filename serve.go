@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/joncrangle/podcasts-sync/internal"
+)
+
+// runServe serves the episodes on a drive, or in a folder, to players on the network until interrupted
+func runServe(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := fs.String("addr", ":8080", "Address to listen on")
+	driveName := fs.String("drive", "", "Drive whose podcasts folder to serve; the default is the only mounted drive")
+	dir := fs.String("dir", "", "Folder to serve instead of a drive, e.g. a copy of a drive's podcasts folder")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: podcasts-sync serve [--addr :8080] [--drive NAME | --dir PATH]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+
+	podcastDir := *dir
+	if podcastDir == "" {
+		var err error
+		if podcastDir, err = podcastsFolder(*driveName); err != nil {
+			return err
+		}
+	}
+	if info, err := os.Stat(podcastDir); err != nil || !info.IsDir() {
+		return fmt.Errorf("%s is not a folder", podcastDir)
+	}
+
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		return err
+	}
+	server := &http.Server{Handler: internal.NewLibraryServer(podcastDir), ReadHeaderTimeout: 5 * time.Second}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdown)
+	}()
+
+	log.New(os.Stderr, "", log.LstdFlags).Printf("serving %s on http://%s", podcastDir, listener.Addr())
+	if err := server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// podcastsFolder returns the podcasts folder of the named drive, or of the only mounted drive without a name
+func podcastsFolder(name string) (string, error) {
+	drive, err := connectedDrive(name)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(drive.MountPath, drive.Folder), nil
+}
+
+// connectedDrive returns the named drive, or the only mounted drive when name is empty
+func connectedDrive(name string) (internal.USBDrive, error) {
+	cfg, err := internal.LoadConfig(internal.DefaultConfigPath())
+	if err != nil {
+		return internal.USBDrive{}, err
+	}
+	drives := internal.NewDriveManager("/Volumes", internal.DirectoryTemplate{})
+	drives.SetProfiles(cfg.Drives)
+	drives.SetMirrors(internal.DefaultMirrorsDir())
+	mounted, err := drives.DetectDrives()
+	if err != nil {
+		return internal.USBDrive{}, err
+	}
+
+	if name == "" {
+		if len(mounted) != 1 {
+			return internal.USBDrive{}, fmt.Errorf("%d drives found; pick one with --drive or a folder with --dir", len(mounted))
+		}
+		return mounted[0], nil
+	}
+	i := slices.IndexFunc(mounted, func(d internal.USBDrive) bool { return d.Name == name })
+	if i < 0 {
+		return internal.USBDrive{}, fmt.Errorf("drive %q is not connected", name)
+	}
+	return mounted[i], nil
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/joncrangle/podcasts-sync/internal"
+)
+
+// showNames collects each --show flag
+type showNames []string
+
+func (s *showNames) String() string { return strings.Join(*s, ", ") }
+
+func (s *showNames) Set(name string) error {
+	*s = append(*s, name)
+	return nil
+}
+
+// runSync copies downloaded episodes to a drive without the TUI, for scripts and launchd jobs
+func runSync(args []string) error {
+	fs := flag.NewFlagSet("sync", flag.ContinueOnError)
+	driveName := fs.String("drive", "", "Drive to sync to; the default is the only mounted drive")
+	var shows showNames
+	fs.Var(&shows, "show", "Sync the new episodes of this show; repeat for several shows")
+	all := fs.Bool("all", false, "Sync the new episodes of every show whose policy isn't \"never\"")
+	readOnly := fs.Bool("read-only", false, "List what would be synced without copying anything")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: podcasts-sync sync [--drive NAME] [--read-only] (--show NAME... | --all)")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if (len(shows) == 0) == !*all {
+		fs.Usage()
+		return errors.New("pass either --show or --all")
+	}
+
+	cfg, err := internal.LoadConfig(internal.DefaultConfigPath())
+	if err != nil {
+		return err
+	}
+	history := internal.NewHistory(internal.DefaultHistoryPath())
+	defer history.Close()
+	drive, err := syncDrive(cfg, *driveName)
+	if err != nil {
+		return err
+	}
+	if *readOnly {
+		return dryRunSync(cfg, drive, shows)
+	}
+
+	// Scripted syncs are unattended too, so they report to the drive's notifications
+	notifier := internal.NewNotifier(cfg.Drives)
+	notify := func(event internal.SyncEvent) {
+		event.Drive, event.UUID, event.Time = drive.Name, drive.UUID, time.Now()
+		if err := notifier.Publish(event); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
+	progress, titles, err := headlessSync(cfg, history, drive, shows)
+	if err != nil {
+		notify(internal.SyncEvent{Event: internal.EventSyncFailed, Error: err.Error()})
+		return err
+	}
+	if len(titles) == 0 {
+		fmt.Printf("%s is up to date\n", drive.Name)
+		notify(internal.SyncEvent{Event: internal.EventSyncFinished})
+		return nil
+	}
+	for _, title := range titles {
+		fmt.Println(title)
+	}
+	fmt.Printf("synced %d episode(s), %s, to %s\n", progress.FilesDone, internal.FormatBytes(progress.BytesTransferred), drive.Name)
+	notify(internal.SyncEvent{Event: internal.EventSyncFinished, Episodes: progress.FilesDone, Bytes: progress.BytesTransferred, Titles: titles})
+	return nil
+}
+
+// syncDrive returns the named drive, or the only mounted drive without a name
+func syncDrive(cfg *internal.Config, name string) (internal.USBDrive, error) {
+	drives := internal.NewDriveManager("/Volumes", internal.DirectoryTemplate{})
+	drives.SetProfiles(cfg.Drives)
+	drives.SetMirrors(internal.DefaultMirrorsDir())
+	mounted, err := drives.DetectDrives()
+	if err != nil {
+		return internal.USBDrive{}, err
+	}
+	if name == "" {
+		if len(mounted) != 1 {
+			return internal.USBDrive{}, fmt.Errorf("%d drives found; pick one with --drive", len(mounted))
+		}
+		return mounted[0], nil
+	}
+	i := slices.IndexFunc(mounted, func(d internal.USBDrive) bool { return d.Name == name })
+	if i < 0 {
+		return internal.USBDrive{}, fmt.Errorf("drive %q is not connected", name)
+	}
+	return mounted[i], nil
+}
+
+// headlessSync syncs the new episodes of shows, or of every show without any, and returns the titles
+// synced, none when the drive is up to date
+func headlessSync(cfg *internal.Config, history *internal.History, drive internal.USBDrive, shows []string) (internal.TransferProgress, []string, error) {
+	episodes, err := selectForSync(cfg, drive, shows)
+	if err != nil || episodes == nil {
+		return internal.TransferProgress{}, nil, err
+	}
+
+	titles := internal.SelectedTitles(episodes)
+	progress, err := internal.SyncAndWait(episodes, drive, history)
+	if err != nil {
+		return progress, nil, err
+	}
+	selected := slices.DeleteFunc(episodes, func(e internal.PodcastEpisode) bool { return !e.Selected })
+	if err := history.RecordAction(internal.NewAction(internal.ActionSync, drive.Name, selected)); err != nil {
+		return internal.TransferProgress{}, nil, err
+	}
+	return progress, titles, nil
+}
+
+// selectForSync loads the library and selects the new episodes of shows, or of every show without
+// any; it returns nil when the drive is up to date
+func selectForSync(cfg *internal.Config, drive internal.USBDrive, shows []string) ([]internal.PodcastEpisode, error) {
+	podcasts, err := internal.LoadMacPodcasts()
+	if err != nil {
+		return nil, err
+	}
+	episodes, err := internal.LoadLocalPodcasts(podcasts)
+	if err != nil {
+		return nil, err
+	}
+	if err := internal.MarkOnDrive(episodes, drive); err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", drive.Name, err)
+	}
+	n, err := internal.SelectShows(episodes, shows, cfg)
+	if err != nil || n == 0 {
+		return nil, err
+	}
+	return episodes, nil
+}
+
+// dryRunSync prints what a sync would copy without touching the drive, the history or notifications
+func dryRunSync(cfg *internal.Config, drive internal.USBDrive, shows []string) error {
+	episodes, err := selectForSync(cfg, drive, shows)
+	if err != nil {
+		return err
+	}
+	if episodes == nil {
+		fmt.Printf("%s is up to date\n", drive.Name)
+		return nil
+	}
+	for _, title := range internal.SelectedTitles(episodes) {
+		fmt.Println(title)
+	}
+	var count int
+	var size int64
+	for _, e := range episodes {
+		if e.Selected {
+			count++
+			size += e.FileSize
+		}
+	}
+	fmt.Printf("would sync %d episode(s), %s, to %s\n", count, internal.FormatBytes(size), drive.Name)
+	return nil
+}
@@ -0,0 +1,65 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/joncrangle/podcasts-sync/internal"
+)
+
+// pluggedIn returns the first drive of a poll that wasn't there before and is set to sync by itself.
+// Drives already connected when the app starts are left for the user.
+func (m Model) pluggedIn(drives []internal.USBDrive) (internal.USBDrive, bool) {
+	if !m.drivesSeen || m.demo || m.readOnly {
+		return internal.USBDrive{}, false
+	}
+	for _, d := range drives {
+		known := slices.ContainsFunc(m.drives, d.SameDrive)
+		if !known && d.Profile.AutoSync != internal.AutoSyncOff {
+			return d, true
+		}
+	}
+	return internal.USBDrive{}, false
+}
+
+// beginAutoSync switches to a drive that was just plugged in and scans it; the sync starts once the
+// scan tells which episodes it already has
+func (m *Model) beginAutoSync(drive internal.USBDrive) (tea.Model, tea.Cmd) {
+	if m.state != normal {
+		m.logDebug(internal.Debug{DTitle: "Auto-sync", DDescription: fmt.Sprintf("skipped %s: busy", drive.Name), Level: internal.DebugWarn})
+		return m, nil
+	}
+	m.currentDrive = drive
+	m.drivePodcasts.SetItems(nil)
+	m.podcastsDrive = nil
+	m.loading.drivePodcasts = true
+	m.autoSyncDrive = drive.MountPath
+	return m, getDrivePodcasts(drive, m.podcasts)
+}
+
+// startAutoSync syncs the episodes the current drive's autoSync setting selects from the scanned
+// library, if the scan is of the drive that was plugged in
+func (m *Model) startAutoSync(episodes []internal.PodcastEpisode) tea.Cmd {
+	if m.autoSyncDrive == "" || m.autoSyncDrive != m.currentDrive.MountPath {
+		return nil
+	}
+	m.autoSyncDrive = ""
+	if m.state != normal {
+		return nil
+	}
+	episodes = slices.Clone(episodes)
+	if internal.SelectAutoSync(episodes, m.currentDrive.Profile.AutoSync, m.config) == 0 {
+		m.setInfo(fmt.Sprintf("%s is up to date", m.currentDrive.Name))
+		return nil
+	}
+	selected := selectedEpisodes(episodes)
+	m.logDebug(internal.Debug{DTitle: "Auto-sync", DDescription: fmt.Sprintf("%d episode(s) to %s", len(selected), m.currentDrive.Name)})
+	m.state = syncing
+	return tea.Batch(
+		m.syncManager.start(selected, m.currentDrive),
+		m.startProgress(),
+		m.remember(internal.NewAction(internal.ActionSync, m.currentDrive.Name, selected)),
+	)
+}
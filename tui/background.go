@@ -0,0 +1,24 @@
+package tui
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/joncrangle/podcasts-sync/internal"
+)
+
+// toggleBackground switches background priority, which a running sync picks up at once
+func (m *Model) toggleBackground() (tea.Model, tea.Cmd) {
+	on := !internal.Background()
+	if err := internal.SetBackground(on); err != nil {
+		m.errorMsg = fmt.Sprintf("Failed to change the process priority: %v", err)
+		return m, nil
+	}
+	if on {
+		m.statusMsg = "Background priority on: syncs pause between chunks to keep the Mac responsive"
+	} else {
+		m.statusMsg = "Background priority off: syncs run at full speed"
+	}
+	return m, nil
+}
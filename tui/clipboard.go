@@ -0,0 +1,104 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/aymanbagabas/go-osc52/v2"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/joncrangle/podcasts-sync/internal"
+)
+
+// copyToClipboard puts text on the clipboard through pbcopy on the Mac, or with an OSC 52 escape
+// sequence the terminal handles, which also works over SSH; tests replace it
+var copyToClipboard = func(text string) error {
+	if os.Getenv("SSH_TTY") == "" {
+		if pbcopy, err := exec.LookPath("pbcopy"); err == nil {
+			cmd := exec.Command(pbcopy)
+			cmd.Stdin = strings.NewReader(text)
+			return cmd.Run()
+		}
+	}
+	seq := osc52.New(text)
+	if os.Getenv("TMUX") != "" {
+		seq = seq.Tmux()
+	}
+	_, err := seq.WriteTo(os.Stderr)
+	return err
+}
+
+// episodeCopy is what of the focused episode to copy
+type episodeCopy int
+
+const (
+	copyDestination episodeCopy = iota
+	copySource
+	copySummary
+)
+
+// copyEpisode copies the destination path, source path or a Markdown summary of the focused episode
+func (m *Model) copyEpisode(what episodeCopy) (tea.Model, tea.Cmd) {
+	episode, ok := m.focusedEpisode()
+	if !ok {
+		return m, nil
+	}
+	source, destination := m.episodePaths(episode)
+
+	var text, name string
+	switch what {
+	case copyDestination:
+		text, name = destination, "the path on the drive"
+		if text == "" {
+			m.errorMsg = "Select a drive to copy where the episode goes"
+			return m, nil
+		}
+	case copySource:
+		text, name = source, "the path in the library"
+		if text == "" {
+			m.errorMsg = fmt.Sprintf("%s isn't in the library", episode.ZTitle)
+			return m, nil
+		}
+	case copySummary:
+		text, name = episode.Markdown(source, destination), "a summary"
+	}
+	if err := copyToClipboard(text); err != nil {
+		m.errorMsg = fmt.Sprintf("Failed to copy: %v", err)
+		return m, nil
+	}
+	m.setInfo(fmt.Sprintf("Copied %s of %s", name, episode.ZTitle))
+	return m, nil
+}
+
+// episodePaths returns where an episode is in the library and on the current drive, empty where it
+// isn't
+func (m Model) episodePaths(episode internal.PodcastEpisode) (source, destination string) {
+	drive := m.currentDrive
+	if m.focusIndex == 1 {
+		destination = episode.FilePath
+	} else if drive.Name != "" {
+		destination = filepath.Join(drive.MountPath, drive.Folder, drive.Profile.EpisodePath(episode))
+	}
+	if library, ok := m.libraryEpisode(episode); ok {
+		source, _ = library.SourcePath()
+	}
+	return source, destination
+}
+
+// libraryEpisode returns the library's copy of an episode in the focused list; a drive episode's is
+// found by show and title
+func (m Model) libraryEpisode(episode internal.PodcastEpisode) (internal.PodcastEpisode, bool) {
+	if m.focusIndex == 1 {
+		key := internal.EpisodeKey(episode)
+		for _, p := range m.podcasts {
+			if internal.EpisodeKey(p) == key {
+				return p, true
+			}
+		}
+		return internal.PodcastEpisode{}, false
+	}
+	return episode, strings.HasPrefix(episode.FilePath, "file://")
+}
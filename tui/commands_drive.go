@@ -19,22 +19,34 @@ type (
 		PodcastsDrive []internal.PodcastEpisode
 	}
 	ProgressTickMsg struct{}
-	FileOpMsg       struct {
+	SyncAppendedMsg struct {
+		Files int
+		Err   error
+	}
+	DriveSpeedMsg struct {
+		Drive     string
+		MountPath string
+		Speed     internal.DriveSpeed
+		Err       error
+	}
+	FileOpMsg struct {
 		Operation string // "sync" or "delete"
 		Msg       internal.FileOp
 	}
 	syncManager struct {
 		mu       sync.Mutex
-		msgChan  chan internal.FileOp
+		mailbox  *progressMailbox
 		tm       *internal.TransferManager
 		stopping atomic.Bool
 		syncer   *internal.PodcastSync
 	}
 )
 
-func newSyncManager() *syncManager {
+func newSyncManager(history *internal.History) *syncManager {
+	syncer := internal.NewPodcastSync()
+	syncer.SetHistory(history)
 	return &syncManager{
-		syncer: internal.NewPodcastSync(),
+		syncer: syncer,
 	}
 }
 
@@ -42,37 +54,23 @@ func (sm *syncManager) start(episodes []internal.PodcastEpisode, drive internal.
 	return func() tea.Msg {
 		sm.mu.Lock()
 		sm.stopping.Store(false)
-		// Larger buffer size to handle frequent progress updates smoothly
-		// With 16ms updates, we need more buffer capacity
-		sm.msgChan = make(chan internal.FileOp, 200)
-		ch := sm.msgChan
+		sm.mailbox = newProgressMailbox()
+		mb := sm.mailbox
 		sm.mu.Unlock()
 
 		go func() {
 			sm.mu.Lock()
-			sm.tm = sm.syncer.StartSync(episodes, drive, ch)
+			sm.tm = sm.syncer.StartSync(episodes, drive, mb)
 			sm.mu.Unlock()
 		}()
 
 		// Wait for first message with timeout to prevent hanging
 		select {
-		case msg, ok := <-ch:
-			if !ok {
-				return FileOpMsg{
-					Operation: "sync",
-					Msg:       internal.FileOp{Complete: true},
-				}
-			}
-			if msg.Error != nil {
-				return ErrMsg{msg.Error}
-			}
-			return FileOpMsg{
-				Operation: "sync",
-				Msg:       msg,
-			}
+		case <-mb.notify:
+			return mailboxMsg(mb)
 		case <-time.After(5 * time.Second):
 			// Timeout waiting for first message
-			return ErrMsg{fmt.Errorf("timeout waiting for sync to start")}
+			return ErrMsg{err: fmt.Errorf("timeout waiting for sync to start")}
 		}
 	}
 }
@@ -80,65 +78,91 @@ func (sm *syncManager) start(episodes []internal.PodcastEpisode, drive internal.
 func (sm *syncManager) wait() tea.Cmd {
 	return func() tea.Msg {
 		sm.mu.Lock()
-		if sm.msgChan == nil {
-			sm.mu.Unlock()
+		mb := sm.mailbox
+		sm.mu.Unlock()
+
+		if mb == nil {
 			return FileOpMsg{
 				Operation: "sync",
 				Msg:       internal.FileOp{Complete: true},
 			}
 		}
-		ch := sm.msgChan
-		sm.mu.Unlock()
 
-		// Non-blocking read with timeout to enable continuous UI updates
-		// This allows the progress bar to re-render even when no new messages arrive
+		// Wait briefly for fresh progress; the timeout keeps the progress bar animating
+		// even when no new update arrives
 		select {
-		case msg, ok := <-ch:
-			if !ok {
-				return FileOpMsg{
-					Operation: "sync",
-					Msg:       internal.FileOp{Complete: true},
-				}
-			}
-			if msg.Error != nil {
-				return ErrMsg{msg.Error}
-			}
-			return FileOpMsg{
-				Operation: "sync",
-				Msg:       msg,
-			}
+		case <-mb.notify:
+			return mailboxMsg(mb)
 		case <-time.After(50 * time.Millisecond):
-			// No message yet, return a tick to keep the update loop running
-			// This ensures continuous progress bar animation
 			return ProgressTickMsg{}
 		}
 	}
 }
 
-func (sm *syncManager) cancel() tea.Cmd {
+// appendEpisodes adds selected episodes to the running sync
+func (sm *syncManager) appendEpisodes(episodes []internal.PodcastEpisode) tea.Cmd {
+	return func() tea.Msg {
+		files, err := sm.syncer.Append(episodes)
+		return SyncAppendedMsg{Files: files, Err: err}
+	}
+}
+
+// abortFile retries or skips the file the running sync is copying
+func (sm *syncManager) abortFile(action internal.FileAction) tea.Cmd {
+	return func() tea.Msg {
+		sm.mu.Lock()
+		defer sm.mu.Unlock()
+		if sm.tm != nil {
+			sm.tm.AbortFile(action)
+		}
+		return nil
+	}
+}
+
+// fileStates returns the per-episode transfer states of the running sync.
+// ok is false while the sync is still starting and holds the lock.
+func (sm *syncManager) fileStates() (states map[string]internal.FileState, version int64, ok bool) {
+	if !sm.mu.TryLock() {
+		return nil, 0, false
+	}
+	tm := sm.tm
+	sm.mu.Unlock()
+
+	if tm == nil {
+		return nil, 0, false
+	}
+	states, version = tm.FileStates()
+	return states, version, true
+}
+
+// mailboxMsg converts the next mailbox update into a tea.Msg
+func mailboxMsg(mb *progressMailbox) tea.Msg {
+	op, ok := mb.take()
+	if !ok {
+		return ProgressTickMsg{}
+	}
+	if op.Error != nil {
+		return ErrMsg{err: op.Error}
+	}
+	return FileOpMsg{
+		Operation: "sync",
+		Msg:       op,
+	}
+}
+
+// cancel stops the running sync, handling the file being copied according to policy
+func (sm *syncManager) cancel(policy internal.PartialFilePolicy) tea.Cmd {
 	return func() tea.Msg {
 		sm.mu.Lock()
 		defer sm.mu.Unlock()
 
 		sm.stopping.Store(true)
+		sm.syncer.SetPartialPolicy(policy)
+		sm.mailbox = nil
 		if sm.tm != nil {
 			sm.tm.Stop()
 			sm.tm = nil
 		}
-		if sm.msgChan != nil {
-			// Capture channel before clearing it to avoid race
-			ch := sm.msgChan
-			sm.msgChan = nil
-			// Don't close immediately - let any pending messages drain
-			go func() {
-				time.Sleep(10 * time.Millisecond)
-				// Safe close - only this goroutine has access to ch
-				defer func() {
-					_ = recover() // Ignore panic from closing already-closed channel
-				}()
-				close(ch)
-			}()
-		}
 		return FileOpMsg{
 			Operation: "sync",
 			Msg:       internal.FileOp{Complete: true},
@@ -146,24 +170,23 @@ func (sm *syncManager) cancel() tea.Cmd {
 	}
 }
 
-var (
-	driveManager = internal.NewDriveManager("/Volumes", internal.DirectoryTemplate{})
-	scanner      = internal.NewPodcastScanner(internal.DirectoryTemplate{})
-)
+var scanner = internal.NewPodcastScanner(internal.DirectoryTemplate{})
 
-func pollDrivesCmd(milliseconds int) tea.Cmd {
+func pollDrivesCmd(interval time.Duration) tea.Cmd {
 	return func() tea.Msg {
-		time.Sleep(time.Duration(milliseconds) * time.Millisecond)
+		time.Sleep(interval)
 		return DrivesPollMsg{}
 	}
 }
 
-func getDrives() tea.Msg {
-	drives, err := driveManager.DetectDrives()
-	if err != nil {
-		return ErrMsg{err}
+func getDrives(driveManager *internal.DriveManager) tea.Cmd {
+	return func() tea.Msg {
+		drives, err := driveManager.DetectDrives()
+		if err != nil {
+			return ErrMsg{err: err, retry: retryDrives}
+		}
+		return DriveUpdatedMsg(drives)
 	}
-	return DriveUpdatedMsg(drives)
 }
 
 func getDrivePodcasts(drive internal.USBDrive, podcasts []internal.PodcastEpisode) tea.Cmd {
@@ -174,8 +197,13 @@ func getDrivePodcasts(drive internal.USBDrive, podcasts []internal.PodcastEpisod
 
 		podcastsDrive, err := scanner.ScanDrive(drive, podcastsBySize)
 		if err != nil {
-			return ErrMsg{err}
+			return ErrMsg{err: err, retry: retryDrivePodcasts}
+		}
+		partials, err := scanner.ScanPartials(drive)
+		if err != nil {
+			return ErrMsg{err: err, retry: retryDrivePodcasts}
 		}
+		podcastsDrive = append(podcastsDrive, partials...)
 
 		return DrivePodcastsMsg{
 			Podcasts:      updatedPodcasts,
@@ -197,12 +225,23 @@ func buildPodcastSizeMap(podcasts []internal.PodcastEpisode) map[int64][]*intern
 	return podcastsBySize
 }
 
-func deletePodcasts(episodes []internal.PodcastEpisode) tea.Cmd {
+func savePin(drive internal.USBDrive, episode internal.PodcastEpisode) tea.Cmd {
+	return func() tea.Msg {
+		if err := internal.SetPinned(drive, episode, episode.Pinned); err != nil {
+			return ErrMsg{err: fmt.Errorf("failed to pin %s: %w", episode.ZTitle, err)}
+		}
+		return nil
+	}
+}
+
+func deletePodcasts(episodes []internal.PodcastEpisode, drive internal.USBDrive, history *internal.History) tea.Cmd {
 	return func() tea.Msg {
 		syncer := internal.NewPodcastSync()
+		syncer.SetHistory(history)
+		syncer.SetDrive(drive)
 		msg := syncer.DeleteSelected(episodes)
 		if msg.Error != nil {
-			return ErrMsg{msg.Error}
+			return ErrMsg{err: msg.Error}
 		}
 		return FileOpMsg{
 			Operation: "delete",
@@ -1,33 +1,115 @@
 package tui
 
 import (
+	"slices"
+	"time"
+
 	tea "github.com/charmbracelet/bubbletea"
 
 	"github.com/joncrangle/podcasts-sync/internal"
 )
 
-type MacPodcastsMsg []internal.PodcastEpisode
+// reconcileInterval is how often the Mac library is checked for downloads Podcasts.app has deleted or
+// is still writing
+const reconcileInterval = time.Minute
+
+type (
+	MacPodcastsMsg   []internal.PodcastEpisode
+	ReconcileTickMsg struct{}
+	// MissingAssetsMsg holds the FilePaths of Mac episodes whose download no longer exists
+	MissingAssetsMsg map[string]bool
+	// DownloadingAssetsMsg holds the FilePaths of Mac episodes whose download is still being written
+	DownloadingAssetsMsg map[string]bool
+	// FavoritesMsg holds the EpisodeKey of every starred episode
+	FavoritesMsg     map[string]bool
+	SearchResultsMsg struct {
+		Query   string
+		Content bool
+		Results []internal.SearchResult
+	}
+)
 
 func getMacPodcasts() tea.Msg {
 	podcasts, err := internal.LoadMacPodcasts()
 	if err != nil {
-		return ErrMsg{err}
+		return ErrMsg{err: err, retry: retryLibrary}
 	}
 
 	podcasts, err = internal.LoadLocalPodcasts(podcasts)
 	if err != nil {
-		return ErrMsg{err}
+		return ErrMsg{err: err, retry: retryLibrary}
 	}
 
 	return MacPodcastsMsg(podcasts)
 }
 
+// getDemoPodcasts loads the synthetic library of a --demo session
+func getDemoPodcasts(demo *internal.Demo) tea.Cmd {
+	return func() tea.Msg {
+		podcasts, err := internal.LoadLocalPodcasts(slices.Clone(demo.Library))
+		if err != nil {
+			return ErrMsg{err: err, retry: retryLibrary}
+		}
+		return MacPodcastsMsg(podcasts)
+	}
+}
+
 func updateMacPodcasts(podcasts []internal.PodcastEpisode) tea.Cmd {
 	return func() tea.Msg {
 		podcasts, err := internal.LoadLocalPodcasts(podcasts)
 		if err != nil {
-			return ErrMsg{err}
+			return ErrMsg{err: err, retry: retryLibrary}
 		}
 		return MacPodcastsMsg(podcasts)
 	}
 }
+
+// runSearch ranks the library off the UI goroutine since content search may parse transcripts
+func runSearch(podcasts []internal.PodcastEpisode, query string, content bool) tea.Cmd {
+	return func() tea.Msg {
+		return SearchResultsMsg{
+			Query:   query,
+			Content: content,
+			Results: internal.SearchEpisodes(podcasts, query, content),
+		}
+	}
+}
+
+func pollReconcileCmd(interval time.Duration) tea.Cmd {
+	return tea.Tick(interval, func(time.Time) tea.Msg {
+		return ReconcileTickMsg{}
+	})
+}
+
+// reconcileMacPodcasts re-checks which episodes still have a downloaded file, and which are still
+// downloading
+func reconcileMacPodcasts(podcasts []internal.PodcastEpisode) tea.Cmd {
+	podcasts = slices.Clone(podcasts)
+	return tea.Batch(
+		func() tea.Msg {
+			return MissingAssetsMsg(internal.MissingAssets(podcasts))
+		},
+		func() tea.Msg {
+			return DownloadingAssetsMsg(internal.DownloadingAssets(podcasts))
+		},
+	)
+}
+
+func loadFavorites(history *internal.History) tea.Cmd {
+	return func() tea.Msg {
+		favorites, err := history.Favorites()
+		if err != nil {
+			return ErrMsg{err: err}
+		}
+		return FavoritesMsg(favorites)
+	}
+}
+
+func saveFavorite(history *internal.History, episode internal.PodcastEpisode, favorite bool) tea.Cmd {
+	return func() tea.Msg {
+		if err := history.SetFavorite(episode, favorite); err != nil {
+			return ErrMsg{err: err}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,109 @@
+package tui
+
+import (
+	"reflect"
+	runtimedebug "runtime/debug"
+	"sync"
+	"sync/atomic"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/joncrangle/podcasts-sync/internal"
+)
+
+// Crash is a panic caught in Init, Update, View or a command, with the debug log as of the last update
+type Crash struct {
+	Value any
+	Stack []byte
+	Log   []internal.Debug
+}
+
+// crashLog holds the debug entries as of the last update, for a crash in a command's goroutine
+var crashLog atomic.Pointer[[]internal.Debug]
+
+// guardedModel wraps a model so the commands it returns report their panics too
+type guardedModel struct {
+	tea.Model
+	crash func(any)
+}
+
+func (g guardedModel) Init() tea.Cmd {
+	return guardCmd(g.Model.Init(), g.crash)
+}
+
+func (g guardedModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	m, cmd := g.Model.Update(msg)
+	if l, ok := m.(interface{ recentLog() []internal.Debug }); ok {
+		log := l.recentLog()
+		crashLog.Store(&log)
+	}
+	return guardedModel{Model: m, crash: g.crash}, guardCmd(cmd, g.crash)
+}
+
+// cmdType is the element type of the messages batches and sequences are run as
+var cmdType = reflect.TypeFor[tea.Cmd]()
+
+// guardCmd wraps cmd to pass a panic to crash, including the commands of a batch or sequence it returns
+func guardCmd(cmd tea.Cmd, crash func(any)) tea.Cmd {
+	if cmd == nil {
+		return nil
+	}
+	return func() (msg tea.Msg) {
+		defer func() {
+			if r := recover(); r != nil {
+				crash(r)
+			}
+		}()
+		msg = cmd()
+		// tea.Sequence's message type isn't exported, so batches and sequences are both found by their shape
+		v := reflect.ValueOf(msg)
+		if v.Kind() != reflect.Slice || v.Type().Elem() != cmdType {
+			return msg
+		}
+		guarded := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := range v.Len() {
+			inner, _ := v.Index(i).Interface().(tea.Cmd)
+			guarded.Index(i).Set(reflect.ValueOf(guardCmd(inner, crash)))
+		}
+		return guarded.Interface()
+	}
+}
+
+// RunGuarded runs model like tea.NewProgram(model, opts...).Run, but a panic anywhere in the app restores
+// the terminal and is passed to crashed instead of leaving the terminal garbled. crashed is meant to
+// exit; if it returns, RunGuarded returns tea.ErrProgramPanic.
+func RunGuarded(model tea.Model, crashed func(Crash), opts ...tea.ProgramOption) (result tea.Model, err error) {
+	var (
+		p    *tea.Program
+		once sync.Once
+	)
+	crash := func(r any) {
+		stack := runtimedebug.Stack()
+		once.Do(func() {
+			_ = p.ReleaseTerminal()
+			var log []internal.Debug
+			if l := crashLog.Load(); l != nil {
+				log = *l
+			}
+			crashed(Crash{Value: r, Stack: stack, Log: log})
+		})
+	}
+	p = tea.NewProgram(guardedModel{Model: model, crash: crash}, append(opts, tea.WithoutCatchPanics())...)
+
+	defer func() {
+		if r := recover(); r != nil {
+			crash(r)
+			result, err = nil, tea.ErrProgramPanic
+		}
+	}()
+	result, err = p.Run()
+	if g, ok := result.(guardedModel); ok {
+		result = g.Model
+	}
+	return result, err
+}
+
+// recentLog returns the debug entries logged so far
+func (m Model) recentLog() []internal.Debug {
+	return m.debugMsgs
+}
@@ -1,6 +1,13 @@
 package tui
 
 import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
 
 	"github.com/joncrangle/podcasts-sync/internal"
@@ -10,12 +17,117 @@ type DebugMsg internal.Debug
 
 type ErrMsg struct {
 	err error
+	// retry is the operation to run again from the error banner, if it can be
+	retry retryOp
 }
 
-func addDebugMsg(title string, description string) tea.Cmd {
+func addDebugMsg(level internal.DebugLevel, title string, description string) tea.Cmd {
 	return func() tea.Msg {
-		return DebugMsg(internal.Debug{DTitle: title, DDescription: description})
+		return DebugMsg(internal.Debug{DTitle: title, DDescription: description, Level: level, Time: time.Now()})
+	}
+}
+
+// debugLevel is the level of an entry about an operation that ended with err
+func debugLevel(err error) internal.DebugLevel {
+	if err != nil {
+		return internal.DebugError
+	}
+	return internal.DebugInfo
+}
+
+// debugItem is an entry in the debug view, at index in the model's debugMsgs
+type debugItem struct {
+	internal.Debug
+	index  int
+	marked bool
+}
+
+func (d debugItem) Title() string {
+	mark := ""
+	if d.marked {
+		mark = "● "
+	}
+	return fmt.Sprintf("%s%s %s %s", mark, d.Time.Format(time.TimeOnly), strings.ToUpper(d.Level.String()), d.DTitle)
+}
+
+// logDebug adds an entry to the debug view
+func (m *Model) logDebug(d internal.Debug) {
+	if d.Time.IsZero() {
+		d.Time = time.Now()
+	}
+	m.debugMsgs = append(m.debugMsgs, d)
+	m.refreshDebugItems()
+}
+
+// refreshDebugItems lists the entries at or above the level shown
+func (m *Model) refreshDebugItems() {
+	var items []list.Item
+	for i, d := range m.debugMsgs {
+		if d.Level >= m.debugLevel {
+			items = append(items, debugItem{Debug: d, index: i, marked: m.debugMarked[i]})
+		}
+	}
+	m.debug.SetItems(items)
+}
+
+func (m *Model) handleDebugKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case msg.Type == tea.KeyCtrlC:
+		return m, tea.Quit
+	case key.Matches(msg, m.debugKeys.Up):
+		m.debug.CursorUp()
+	case key.Matches(msg, m.debugKeys.Down):
+		m.debug.CursorDown()
+	case key.Matches(msg, m.debugKeys.All):
+		m.showDebugLevel(internal.DebugInfo)
+	case key.Matches(msg, m.debugKeys.Warnings):
+		m.showDebugLevel(internal.DebugWarn)
+	case key.Matches(msg, m.debugKeys.Errors):
+		m.showDebugLevel(internal.DebugError)
+	case key.Matches(msg, m.debugKeys.Mark):
+		if item, ok := m.debug.SelectedItem().(debugItem); ok {
+			if m.debugMarked[item.index] {
+				delete(m.debugMarked, item.index)
+			} else {
+				m.debugMarked[item.index] = true
+			}
+			m.refreshDebugItems()
+			m.debug.CursorDown()
+		}
+	case key.Matches(msg, m.debugKeys.Copy):
+		return m, m.copyDebugEntries()
+	case key.Matches(msg, m.debugKeys.Close):
+		m.state = normal
 	}
+	return m, nil
+}
+
+// showDebugLevel hides the entries below level
+func (m *Model) showDebugLevel(level internal.DebugLevel) {
+	m.debugLevel = level
+	m.refreshDebugItems()
+	m.debug.Select(0)
+}
+
+// copyDebugEntries copies the marked entries, or the one under the cursor, as log lines
+func (m *Model) copyDebugEntries() tea.Cmd {
+	var lines []string
+	for i, d := range m.debugMsgs {
+		if m.debugMarked[i] {
+			lines = append(lines, d.String())
+		}
+	}
+	if len(lines) == 0 {
+		item, ok := m.debug.SelectedItem().(debugItem)
+		if !ok {
+			return nil
+		}
+		lines = append(lines, item.String())
+	}
+	if err := copyToClipboard(strings.Join(lines, "\n") + "\n"); err != nil {
+		return m.debug.NewStatusMessage(fmt.Sprintf("Failed to copy: %v", err))
+	}
+	return m.debug.NewStatusMessage(fmt.Sprintf("Copied %d entries", len(lines)))
 }
 
 func (e ErrMsg) Error() string {
@@ -24,3 +136,56 @@ func (e ErrMsg) Error() string {
 	}
 	return e.err.Error()
 }
+
+var (
+	// publishedState holds the last rendered model snapshot when the debug server is enabled
+	publishedState atomic.Pointer[map[string]any]
+	// publishedSync is read live so channel depth is visible even if rendering has stalled
+	publishedSync atomic.Pointer[syncManager]
+)
+
+// DebugState returns the most recently rendered model state together with the live
+// depth of the sync channel, for the --debug-addr state endpoint.
+func DebugState() map[string]any {
+	state := map[string]any{}
+	if snapshot := publishedState.Load(); snapshot != nil {
+		for k, v := range *snapshot {
+			state[k] = v
+		}
+	}
+
+	if sm := publishedSync.Load(); sm != nil {
+		// TryLock so a deadlocked sync manager shows up in the dump instead of hanging it
+		if sm.mu.TryLock() {
+			if sm.mailbox != nil {
+				state["coalescedUpdates"] = sm.mailbox.coalesced.Load()
+			}
+			sm.mu.Unlock()
+		} else {
+			state["syncManagerLocked"] = true
+		}
+		state["syncStopping"] = sm.stopping.Load()
+	}
+
+	return state
+}
+
+// publishDebugState snapshots the model for DebugState
+func (m Model) publishDebugState() {
+	snapshot := map[string]any{
+		"state":            m.state.String(),
+		"focusIndex":       m.focusIndex,
+		"macPodcasts":      len(m.podcasts),
+		"drivePodcasts":    len(m.podcastsDrive),
+		"drives":           len(m.drives),
+		"currentDrive":     m.currentDrive.Name,
+		"loadingMac":       m.loading.macPodcasts,
+		"loadingDrive":     m.loading.drivePodcasts,
+		"loadingDrives":    m.loading.drives,
+		"errorMsg":         m.errorMsg,
+		"infoMsg":          m.infoMsg,
+		"transferProgress": m.transferProgress,
+	}
+	publishedState.Store(&snapshot)
+	publishedSync.Store(m.syncManager)
+}
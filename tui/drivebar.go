@@ -0,0 +1,84 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/joncrangle/podcasts-sync/internal"
+)
+
+// maxDriveBarDrives is how many drives the drive bar numbers, one per quick-switch key
+const maxDriveBarDrives = 9
+
+// switchDrive makes drive the current drive and scans it
+func (m *Model) switchDrive(drive internal.USBDrive) (tea.Model, tea.Cmd) {
+	m.currentDrive = drive
+	m.loading.drivePodcasts = true
+	cmds := []tea.Cmd{tea.Sequence(m.loadLibrary, getDrivePodcasts(m.currentDrive, m.podcasts))}
+	if !m.demo {
+		// Warn before a failing drive is trusted with a long copy
+		cmds = append(cmds, checkDriveHealth(m.currentDrive, false))
+	}
+	return m, tea.Batch(cmds...)
+}
+
+// switchDriveNumber switches to the drive numbered number in the drive bar, if there is one
+func (m *Model) switchDriveNumber(number string) (tea.Model, tea.Cmd) {
+	n, err := strconv.Atoi(number)
+	if err != nil || n < 1 || n > len(m.drives) {
+		return m, nil
+	}
+	drive := m.drives[n-1]
+	if drive.MountPath == m.currentDrive.MountPath {
+		return m, nil
+	}
+	return m.switchDrive(drive)
+}
+
+// countDriveMatches records how many library episodes the scan of the current drive found on it
+func (m *Model) countDriveMatches() {
+	if m.driveMatches == nil {
+		m.driveMatches = make(map[string]int)
+	}
+	m.driveMatches[m.currentDrive.MountPath] = len(slices.DeleteFunc(slices.Clone(m.podcastsDrive), func(p internal.PodcastEpisode) bool {
+		return !p.OnDrive
+	}))
+}
+
+// forgetDriveMatches drops the counts of drives that are gone, as another drive may be mounted in their place
+func (m *Model) forgetDriveMatches() {
+	for mountPath := range m.driveMatches {
+		if !slices.ContainsFunc(m.drives, func(d internal.USBDrive) bool { return d.MountPath == mountPath }) {
+			delete(m.driveMatches, mountPath)
+		}
+	}
+}
+
+// formatDriveBar lists the connected drives with their quick-switch numbers, free space and the
+// library episodes found on them; empty unless several drives are connected
+func (m Model) formatDriveBar() string {
+	if len(m.drives) < 2 {
+		return ""
+	}
+	var entries []string
+	for i, d := range m.drives[:min(len(m.drives), maxDriveBarDrives)] {
+		entry := fmt.Sprintf("%d %s", i+1, d.Name)
+		if d.Capacity > 0 {
+			entry += " · " + internal.FormatBytes(d.Free) + " free"
+		}
+		if matches, ok := m.driveMatches[d.MountPath]; ok {
+			entry += fmt.Sprintf(" · %d matched", matches)
+		}
+		if d.MountPath == m.currentDrive.MountPath {
+			entries = append(entries, activeDriveTabStyle(entry))
+		} else {
+			entries = append(entries, driveTabStyle(entry))
+		}
+	}
+	return lipgloss.NewStyle().MaxWidth(max(m.width-8, 0)).Render(strings.Join(entries, "  "))
+}
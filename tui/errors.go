@@ -0,0 +1,84 @@
+package tui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/joncrangle/podcasts-sync/internal"
+)
+
+// retryOp is an operation that failed and can be run again from the error banner
+type retryOp int
+
+const (
+	retryNone retryOp = iota
+	// retryLibrary reloads the Podcasts database
+	retryLibrary
+	// retryDrives looks for drives again
+	retryDrives
+	// retryDrivePodcasts rescans the current drive
+	retryDrivePodcasts
+)
+
+var bannerHintStyle = lipgloss.NewStyle().Foreground(lipgloss.Color(Subtext0)).Render
+
+// setError shows err in the banner until it is dismissed, and keeps it in the debug view's history
+func (m *Model) setError(err string, retry retryOp) {
+	m.errorMsg = err
+	m.errorRetry = retry
+	// The failed load is over, so its list shouldn't look like it's still loading
+	switch retry {
+	case retryLibrary:
+		m.loading.macPodcasts = false
+	case retryDrives:
+		m.loading.drives = false
+	case retryDrivePodcasts:
+		m.loading.drivePodcasts = false
+	}
+	m.logDebug(internal.Debug{DTitle: "Error", DDescription: err, Level: internal.DebugError})
+}
+
+// setInfo shows a note in the banner in place of any earlier error, until it is dismissed
+func (m *Model) setInfo(info string) {
+	m.dismissError()
+	m.infoMsg = info
+}
+
+// dismissError hides the banner
+func (m *Model) dismissError() {
+	m.errorMsg = ""
+	m.errorRetry = retryNone
+	m.infoMsg = ""
+}
+
+// retryError dismisses the banner and runs the operation that failed again
+func (m *Model) retryError() (tea.Model, tea.Cmd) {
+	retry := m.errorRetry
+	m.dismissError()
+	switch retry {
+	case retryLibrary:
+		m.loading.macPodcasts = true
+		return m, m.loadLibrary
+	case retryDrives:
+		m.loading.drives = true
+		return m, getDrives(m.driveManager)
+	case retryDrivePodcasts:
+		m.loading.drivePodcasts = true
+		return m, getDrivePodcasts(m.currentDrive, m.podcasts)
+	}
+	return m, nil
+}
+
+// renderErrorBanner shows the message above the lists with the keys that act on it
+func (m Model) renderErrorBanner() string {
+	hint := "x dismiss"
+	if m.errorRetry != retryNone {
+		hint += " · r retry"
+	}
+	return errorStyle(m.errorMsg) + "  " + bannerHintStyle("("+hint+")")
+}
+
+// renderInfoBanner shows the note above the lists
+func (m Model) renderInfoBanner() string {
+	return infoStyle(m.infoMsg) + "  " + bannerHintStyle("(x dismiss)")
+}
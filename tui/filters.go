@@ -0,0 +1,296 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/joncrangle/podcasts-sync/internal"
+)
+
+const macListTitle = "Mac Podcasts"
+
+// episodeFilter narrows the Mac list to the episodes it matches
+type episodeFilter struct {
+	name  string
+	match func(internal.PodcastEpisode) bool
+}
+
+type quickList int
+
+const (
+	quickListSynced quickList = iota
+	quickListFailed
+	quickListRemoved
+	quickListFavorites
+)
+
+const quickListWindow = 7 * 24 * time.Hour
+
+// quickListItem is an entry in the quick list picker
+type quickListItem struct {
+	kind        quickList
+	title       string
+	description string
+}
+
+func (q quickListItem) Title() string { return q.title }
+
+func (q quickListItem) Description() string { return q.description }
+
+func (q quickListItem) FilterValue() string { return q.title }
+
+var quickListItems = []list.Item{
+	quickListItem{kind: quickListSynced, title: "Synced in the last 7 days", description: "Episodes copied to any drive this week"},
+	quickListItem{kind: quickListFailed, title: "Failed last sync", description: "Episodes that errored during the most recent sync"},
+	quickListItem{kind: quickListRemoved, title: "Removed from drive recently", description: "Episodes deleted from a drive this week"},
+	quickListItem{kind: quickListFavorites, title: "Favorites", description: "Episodes you have starred"},
+}
+
+// favoritesFilter matches starred episodes; favorites are already loaded so it needs no query
+var favoritesFilter = &episodeFilter{
+	name:  "Favorites",
+	match: func(p internal.PodcastEpisode) bool { return p.Favorite },
+}
+
+// recentDownloadWindow is how recently an episode must have been downloaded to pass recentDownloadsFilter
+const recentDownloadWindow = 7 * 24 * time.Hour
+
+// Filters toggled with a key; like the quick lists, escape shows the whole library again
+var (
+	unplayedFilter = &episodeFilter{
+		name:  "Unplayed",
+		match: func(p internal.PodcastEpisode) bool { return !p.Played },
+	}
+	notOnDriveFilter = &episodeFilter{
+		name:  "Not on drive",
+		match: func(p internal.PodcastEpisode) bool { return !p.OnDrive },
+	}
+	recentDownloadsFilter = &episodeFilter{
+		name: "Downloaded in the last 7 days",
+		match: func(p internal.PodcastEpisode) bool {
+			return !p.Downloaded.IsZero() && time.Since(p.Downloaded) < recentDownloadWindow
+		},
+	}
+)
+
+// toggleMacFilter narrows the Mac list with filter, or shows the whole library if filter is already applied
+func (m *Model) toggleMacFilter(filter *episodeFilter) (tea.Model, tea.Cmd) {
+	if m.macFilter == filter {
+		filter = nil
+	}
+	m.setMacFilter(filter)
+	m.focusIndex = 0
+	return m, nil
+}
+
+type QuickListMsg struct {
+	Name    string
+	Entries []internal.HistoryEntry
+}
+
+// loadQuickList queries the history database for the entries backing a quick list
+func loadQuickList(history *internal.History, item quickListItem) tea.Cmd {
+	return func() tea.Msg {
+		var (
+			entries []internal.HistoryEntry
+			err     error
+		)
+		since := time.Now().Add(-quickListWindow)
+
+		switch item.kind {
+		case quickListSynced:
+			entries, err = history.Since(internal.HistorySynced, since)
+		case quickListFailed:
+			entries, err = history.LastRunFailures()
+		case quickListRemoved:
+			entries, err = history.Since(internal.HistoryRemoved, since)
+		}
+		if err != nil {
+			return ErrMsg{err: err}
+		}
+
+		return QuickListMsg{Name: item.title, Entries: entries}
+	}
+}
+
+func (m *Model) handleQuickList(msg QuickListMsg) (tea.Model, tea.Cmd) {
+	episodeKeys := make(map[string]bool, len(msg.Entries))
+	for _, e := range msg.Entries {
+		episodeKeys[e.Key()] = true
+	}
+
+	m.setMacFilter(&episodeFilter{
+		name: msg.Name,
+		match: func(p internal.PodcastEpisode) bool {
+			return episodeKeys[internal.EpisodeKey(p)]
+		},
+	})
+	m.focusIndex = 0
+	return m, nil
+}
+
+// setMacFilter applies filter to the Mac list; nil shows the whole library
+func (m *Model) setMacFilter(filter *episodeFilter) {
+	m.macFilter = filter
+	m.refreshMacItems()
+	m.macPodcasts.Select(0)
+}
+
+// visiblePodcasts returns the episodes shown in the Mac list under the active filter
+func (m *Model) visiblePodcasts() []internal.PodcastEpisode {
+	if m.macFilter == nil && !m.hideMissing {
+		return m.podcasts
+	}
+
+	var visible []internal.PodcastEpisode
+	for _, p := range m.podcasts {
+		if m.isVisible(p) {
+			visible = append(visible, p)
+		}
+	}
+	return visible
+}
+
+// isVisible reports whether p passes the active filter and the not-downloaded toggle
+func (m *Model) isVisible(p internal.PodcastEpisode) bool {
+	if m.hideMissing && p.Missing {
+		return false
+	}
+	return m.macFilter == nil || m.macFilter.match(p)
+}
+
+// refreshMacItems rebuilds the Mac list items and title from m.podcasts
+func (m *Model) refreshMacItems() {
+	title := internal.T(macListTitle)
+	if m.macFilter != nil {
+		title += " · " + m.macFilter.name
+	}
+
+	missing := 0
+	for _, p := range m.podcasts {
+		if p.Missing {
+			missing++
+		}
+	}
+	if missing > 0 {
+		title += fmt.Sprintf(" · %d not downloaded", missing)
+		if m.hideMissing {
+			title += " (hidden)"
+		}
+	}
+
+	m.macPodcasts.Title = title + sortTitle(m.macSort)
+	visible := sortEpisodes(m.macSort, m.visiblePodcasts())
+	if m.groupShows {
+		items := m.showTreeItems(visible)
+		usePageNumbers(&m.macPodcasts, len(items)/max(1, m.macPodcasts.Paginator.PerPage))
+		setListItems(&m.macPodcasts, items)
+		return
+	}
+	setPodcastItems(&m.macPodcasts, visible)
+}
+
+// handleMissingAssets marks episodes whose downloads have disappeared since the library was loaded
+func (m *Model) handleMissingAssets(msg MissingAssetsMsg) (tea.Model, tea.Cmd) {
+	changed := false
+	for i := range m.podcasts {
+		missing := msg[m.podcasts[i].FilePath]
+		if m.podcasts[i].Missing == missing {
+			continue
+		}
+		m.podcasts[i].Missing = missing
+		if missing {
+			// A missing download can never sync
+			m.podcasts[i].Selected = false
+		}
+		changed = true
+	}
+	if changed {
+		m.refreshMacItems()
+	}
+	return m, nil
+}
+
+// handleDownloadingAssets marks episodes Podcasts.app is still downloading, which can't be selected
+// until the download is complete
+func (m *Model) handleDownloadingAssets(msg DownloadingAssetsMsg) (tea.Model, tea.Cmd) {
+	changed := false
+	for i := range m.podcasts {
+		downloading := msg[m.podcasts[i].FilePath]
+		if m.podcasts[i].Downloading == downloading {
+			continue
+		}
+		m.podcasts[i].Downloading = downloading
+		if downloading {
+			// A partial download must never sync
+			m.podcasts[i].Selected = false
+		}
+		changed = true
+	}
+	if changed {
+		m.refreshMacItems()
+	}
+	return m, nil
+}
+
+// applyFavorites marks starred episodes in both lists
+func (m *Model) applyFavorites() {
+	for i := range m.podcasts {
+		m.podcasts[i].Favorite = m.favorites[internal.EpisodeKey(m.podcasts[i])]
+	}
+	for i := range m.podcastsDrive {
+		m.podcastsDrive[i].Favorite = m.favorites[internal.EpisodeKey(m.podcastsDrive[i])]
+	}
+	m.refreshMacItems()
+	m.refreshDriveItems()
+}
+
+func (m *Model) handleFavorites(msg FavoritesMsg) (tea.Model, tea.Cmd) {
+	m.favorites = msg
+	m.applyFavorites()
+	return m, nil
+}
+
+// toggleFavorite stars or unstars the episode under the cursor of the focused list
+func (m *Model) toggleFavorite() (tea.Model, tea.Cmd) {
+	current := m.macPodcasts.SelectedItem()
+	if m.focusIndex == 1 {
+		current = m.drivePodcasts.SelectedItem()
+	}
+	episode, ok := current.(internal.PodcastEpisode)
+	if !ok {
+		return m, nil
+	}
+
+	if m.favorites == nil {
+		m.favorites = map[string]bool{}
+	}
+	key := internal.EpisodeKey(episode)
+	favorite := !m.favorites[key]
+	if favorite {
+		m.favorites[key] = true
+	} else {
+		delete(m.favorites, key)
+	}
+	m.applyFavorites()
+	return m, saveFavorite(m.history, episode, favorite)
+}
+
+// togglePin pins or unpins the drive episode under the cursor, keeping it from bulk deletes
+func (m *Model) togglePin() (tea.Model, tea.Cmd) {
+	episode, ok := m.drivePodcasts.SelectedItem().(internal.PodcastEpisode)
+	if !ok {
+		return m, nil
+	}
+	i := slices.IndexFunc(m.podcastsDrive, func(p internal.PodcastEpisode) bool { return p.FilePath == episode.FilePath })
+	if i < 0 {
+		return m, nil
+	}
+	m.podcastsDrive[i].Pinned = !episode.Pinned
+	m.refreshDriveItems()
+	return m, savePin(m.currentDrive, m.podcastsDrive[i])
+}
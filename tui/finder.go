@@ -0,0 +1,100 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/joncrangle/podcasts-sync/internal"
+)
+
+// openInFinder runs macOS's open command with args; tests replace it
+var openInFinder = func(args ...string) error {
+	if out, err := exec.Command("open", args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("open: %w: %s", err, out)
+	}
+	return nil
+}
+
+// podcastsAppURL looks up the Podcasts.app link of an episode; tests replace it
+var podcastsAppURL = internal.PodcastsAppURL
+
+// focusedEpisode returns the episode under the cursor of the focused list
+func (m Model) focusedEpisode() (internal.PodcastEpisode, bool) {
+	l := m.macPodcasts
+	if m.focusIndex == 1 {
+		l = m.drivePodcasts
+	}
+	episode, ok := l.SelectedItem().(internal.PodcastEpisode)
+	return episode, ok
+}
+
+// revealEpisode selects the focused episode's file in the Podcasts library in a Finder window
+func (m *Model) revealEpisode() (tea.Model, tea.Cmd) {
+	episode, ok := m.focusedEpisode()
+	if !ok {
+		return m, nil
+	}
+	source, _ := m.episodePaths(episode)
+	if source == "" {
+		m.errorMsg = fmt.Sprintf("%s isn't in the library", episode.ZTitle)
+		return m, nil
+	}
+	return m, runOpen("-R", source)
+}
+
+// openShowFolder opens the folder on the current drive that holds the focused episode's show
+func (m *Model) openShowFolder() (tea.Model, tea.Cmd) {
+	episode, ok := m.focusedEpisode()
+	if !ok {
+		return m, nil
+	}
+	_, destination := m.episodePaths(episode)
+	if destination == "" {
+		m.errorMsg = "Select a drive to open the show's folder"
+		return m, nil
+	}
+	folder := filepath.Dir(destination)
+	if _, err := os.Stat(folder); err != nil {
+		m.errorMsg = fmt.Sprintf("%s has no folder on %s yet", episode.ShowName, m.currentDrive.Name)
+		return m, nil
+	}
+	return m, runOpen(folder)
+}
+
+// runOpen opens args in the background, reporting a failure in the error banner
+func runOpen(args ...string) tea.Cmd {
+	return func() tea.Msg {
+		if err := openInFinder(args...); err != nil {
+			return ErrMsg{err: err}
+		}
+		return nil
+	}
+}
+
+// openInPodcastsApp shows the focused episode in Podcasts.app, for its shownotes, streaming or marking
+// it played
+func (m *Model) openInPodcastsApp() (tea.Model, tea.Cmd) {
+	episode, ok := m.focusedEpisode()
+	if !ok {
+		return m, nil
+	}
+	library, ok := m.libraryEpisode(episode)
+	if !ok {
+		m.errorMsg = fmt.Sprintf("%s isn't in the library", episode.ZTitle)
+		return m, nil
+	}
+	return m, func() tea.Msg {
+		url, err := podcastsAppURL(library)
+		if err == nil {
+			err = openInFinder(url)
+		}
+		if err != nil {
+			return ErrMsg{err: err}
+		}
+		return nil
+	}
+}
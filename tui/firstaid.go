@@ -0,0 +1,93 @@
+package tui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/joncrangle/podcasts-sync/internal"
+)
+
+// FirstAidMsg carries the outcome of running First Aid on a drive
+type FirstAidMsg struct {
+	Drive     string
+	MountPath string
+	Result    string
+	Err       error
+}
+
+// recordIOError counts an I/O error against the current drive so flaky media is flagged in the drive
+// selector, and offers First Aid once they repeat
+func (m *Model) recordIOError() tea.Cmd {
+	if m.demo || m.readOnly || m.currentDrive.Name == "" {
+		return nil
+	}
+	errs := m.config.RecordIOError(m.currentDrive.ProfileKey(), time.Now())
+	m.setIOErrors(m.currentDrive.ProfileKey(), errs)
+	if errs.Count >= internal.RepeatedIOErrors {
+		m.state = firstAid
+	}
+	return saveConfig(m.config, m.configPath)
+}
+
+// setIOErrors updates the I/O error count shown in the drive selector for the drives with the profile key
+func (m *Model) setIOErrors(key string, errs internal.DriveIOErrors) {
+	m.driveManager.SetProfiles(m.config.Drives)
+	for i := range m.drives {
+		if m.drives[i].ProfileKey() == key {
+			m.drives[i].Profile.IOErrors = errs
+		}
+	}
+	if m.currentDrive.ProfileKey() == key {
+		m.currentDrive.Profile.IOErrors = errs
+	}
+	m.driveSelector.SetItems(m.createDriveItems(m.drives))
+}
+
+func runFirstAid(drive internal.USBDrive) tea.Cmd {
+	return func() tea.Msg {
+		result, err := internal.RunFirstAid(drive)
+		return FirstAidMsg{Drive: drive.Name, MountPath: drive.MountPath, Result: result, Err: err}
+	}
+}
+
+func (m *Model) handleFirstAidKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case msg.Type == tea.KeyCtrlC:
+		return m, tea.Quit
+	case key.Matches(msg, m.firstAidKeys.Run):
+		m.state = normal
+		m.statusMsg = fmt.Sprintf("Running First Aid on %s...", m.currentDrive.Name)
+		return m, runFirstAid(m.currentDrive)
+	case key.Matches(msg, m.firstAidKeys.Close):
+		m.state = normal
+	}
+	return m, nil
+}
+
+// handleFirstAid clears a repaired drive's I/O errors, or explains how to repair it by hand
+func (m *Model) handleFirstAid(msg FirstAidMsg) (tea.Model, tea.Cmd) {
+	if msg.Err != nil {
+		m.statusMsg = ""
+		m.errorMsg = fmt.Sprintf("First Aid failed on %s: %v. Run First Aid on it in Disk Utility, or `diskutil repairVolume` in Terminal", msg.Drive, msg.Err)
+		return m, nil
+	}
+	key := m.profileKey(msg.Drive, msg.MountPath)
+	m.config.ClearIOErrors(key, time.Now())
+	m.setIOErrors(key, m.config.Drives[key].IOErrors)
+	m.statusMsg = fmt.Sprintf("First Aid finished on %s: %s", msg.Drive, msg.Result)
+	return m, saveConfig(m.config, m.configPath)
+}
+
+func (m Model) renderFirstAid() string {
+	d := m.currentDrive
+	text := fmt.Sprintf("%s keeps failing to read or write\n\n", d.Name)
+	text += previewCollisionStyle(fmt.Sprintf("%s since it was last repaired", d.Profile.IOErrors)) + "\n\n"
+	text += "First Aid checks the drive's file system and repairs it, as in Disk Utility.\n"
+	text += previewNoteStyle("The drive is unmounted while it runs. If errors continue, replace the drive.") + "\n\n"
+	help := m.createHelp(text, m.confirmHelp.View(m.firstAidKeys))
+	popup := popupStyle.Render(text + help)
+	return m.centerInWindow(popup)
+}
@@ -0,0 +1,101 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/joncrangle/podcasts-sync/internal"
+)
+
+// DriveHealthMsg carries the health of a drive. Show opens the details popup; otherwise only
+// warnings are reported, as when a drive is picked for syncing.
+type DriveHealthMsg struct {
+	Drive  internal.USBDrive
+	Health internal.DriveHealth
+	Err    error
+	Show   bool
+}
+
+// checkDriveHealth runs diskutil and fsck off the UI thread
+func checkDriveHealth(drive internal.USBDrive, show bool) tea.Cmd {
+	return func() tea.Msg {
+		health, err := internal.CheckDriveHealth(drive)
+		return DriveHealthMsg{Drive: drive, Health: health, Err: err, Show: show}
+	}
+}
+
+// showDriveDetails checks the health of the drive under the cursor in the drive selector
+func (m *Model) showDriveDetails() (tea.Model, tea.Cmd) {
+	drive, ok := m.driveSelector.SelectedItem().(internal.USBDrive)
+	if !ok {
+		return m, nil
+	}
+	return m, checkDriveHealth(drive, true)
+}
+
+func (m *Model) handleDriveHealth(msg DriveHealthMsg) (tea.Model, tea.Cmd) {
+	if !msg.Show {
+		if warnings := msg.Health.Warnings(); msg.Err == nil && len(warnings) > 0 && msg.Drive.SameDrive(m.currentDrive) {
+			m.errorMsg = fmt.Sprintf("%s: %s", msg.Drive.Name, warnings[0])
+			m.logDebug(internal.Debug{DTitle: "Drive health", DDescription: m.errorMsg, Level: internal.DebugWarn})
+		}
+		return m, nil
+	}
+	if m.state != driveSelection {
+		return m, nil
+	}
+	m.details = msg
+	m.state = driveDetails
+	return m, nil
+}
+
+func (m *Model) handleDriveDetailsKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case msg.Type == tea.KeyCtrlC:
+		return m, tea.Quit
+	case key.Matches(msg, m.detailsKeys.Close):
+		m.state = driveSelection
+	}
+	return m, nil
+}
+
+func (m Model) renderDriveDetails() string {
+	d := m.details
+	text := fmt.Sprintf("%s details\n\n", d.Drive.Name)
+	if d.Err != nil {
+		text += previewWarningStyle(d.Err.Error()) + "\n"
+	} else {
+		h := d.Health
+		rows := [][2]string{
+			{"Device", strings.TrimSpace(h.Device + " " + h.Media)},
+			{"Connection", h.Protocol},
+			{"File system", h.FileSystem},
+			{"SMART status", h.SMART},
+			{"Unmounted cleanly", map[internal.FileSystemState]string{
+				internal.FileSystemClean: "yes", internal.FileSystemDirty: "no",
+			}[h.FileSystemState]},
+		}
+		if !d.Drive.Profile.Speed.MeasuredAt.IsZero() {
+			rows = append(rows, [2]string{"Measured speed", d.Drive.Profile.Speed.String()})
+		}
+		for _, row := range rows {
+			if row[1] == "" {
+				row[1] = previewNoteStyle("unknown")
+			}
+			text += fmt.Sprintf("%-18s %s\n", row[0], row[1])
+		}
+		if warnings := h.Warnings(); len(warnings) > 0 {
+			text += "\n"
+			for _, w := range warnings {
+				text += previewCollisionStyle("⚠ "+w) + "\n"
+			}
+		}
+	}
+	text += "\n\n"
+	help := m.createHelp(text, m.confirmHelp.View(m.detailsKeys))
+	popup := popupStyle.Render(text + help)
+	return m.centerInWindow(popup)
+}
@@ -4,6 +4,8 @@ import (
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/lipgloss"
+
+	"github.com/joncrangle/podcasts-sync/internal"
 )
 
 func createHelp() help.Model {
@@ -32,15 +34,50 @@ type KeyMap struct {
 	Sync        key.Binding
 	SyncAll     key.Binding
 	Refresh     key.Binding
+	Dismiss     key.Binding
 	Delete      key.Binding
 	DeleteAll   key.Binding
 	Debug       key.Binding
 	Quit        key.Binding
 	Progress    key.Binding
+	Search      key.Binding
+	QuickLists  key.Binding
+	HideMissing key.Binding
+	Favorite    key.Binding
+	ShowPolicy  key.Binding
+	Prune       key.Binding
+	Benchmark   key.Binding
+	Queue       key.Binding
+	Preview     key.Binding
+	Undo        key.Binding
+	Repeat      key.Binding
+	Rename      key.Binding
+	RenameShow  key.Binding
+	Pin         key.Binding
+	Background  key.Binding
+	DriveInfo   key.Binding
+	CopyPath    key.Binding
+	CopySource  key.Binding
+	CopySummary key.Binding
+	Reveal      key.Binding
+	OpenFolder  key.Binding
+	OpenInApp   key.Binding
+	Unplayed    key.Binding
+	NotOnDrive  key.Binding
+	RecentlyNew key.Binding
+	SwitchDrive key.Binding
+	Filter      key.Binding
+	GroupShows  key.Binding
+	SelectShow  key.Binding
+	Sort        key.Binding
+	RangeSelect key.Binding
+	Invert      key.Binding
+	SelectNew   key.Binding
+	AddFolder   key.Binding
 }
 
 func (k KeyMap) ShortHelp() []key.Binding {
-	return []key.Binding{k.Up, k.Down, k.Tab, k.SelectDrive, k.Refresh, k.Quit}
+	return []key.Binding{k.Up, k.Down, k.Tab, k.SelectDrive, k.Search, k.QuickLists, k.Refresh, k.Quit}
 }
 
 func (k KeyMap) FullHelp() [][]key.Binding {
@@ -88,6 +125,10 @@ var keys = KeyMap{
 		key.WithKeys("r"),
 		key.WithHelp("r", "refresh"),
 	),
+	Dismiss: key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "dismiss error"),
+	),
 	Delete: key.NewBinding(
 		key.WithKeys("d"),
 		key.WithHelp("d", "delete selected"),
@@ -116,30 +157,171 @@ var keys = KeyMap{
 		key.WithKeys("p"),
 		key.WithHelp("p", "progress"),
 	),
+	Search: key.NewBinding(
+		key.WithKeys("ctrl+f"),
+		key.WithHelp("ctrl+f", "search"),
+	),
+	QuickLists: key.NewBinding(
+		key.WithKeys("H"),
+		key.WithHelp("H", "quick lists"),
+	),
+	HideMissing: key.NewBinding(
+		key.WithKeys("m"),
+		key.WithHelp("m", "hide not downloaded"),
+	),
+	Favorite: key.NewBinding(
+		key.WithKeys("*"),
+		key.WithHelp("*", "star"),
+	),
+	ShowPolicy: key.NewBinding(
+		key.WithKeys("o"),
+		key.WithHelp("o", "show policy"),
+	),
+	Prune: key.NewBinding(
+		key.WithKeys("K"),
+		key.WithHelp("K", "prune to keep limits"),
+	),
+	Benchmark: key.NewBinding(
+		key.WithKeys("b"),
+		key.WithHelp("b", "benchmark"),
+	),
+	Queue: key.NewBinding(
+		key.WithKeys("u"),
+		key.WithHelp("u", "build playlist"),
+	),
+	Preview: key.NewBinding(
+		key.WithKeys("P"),
+		key.WithHelp("P", "preview paths"),
+	),
+	Undo: key.NewBinding(
+		key.WithKeys("z"),
+		key.WithHelp("z", "undo"),
+	),
+	Repeat: key.NewBinding(
+		key.WithKeys("."),
+		key.WithHelp(".", "repeat last sync"),
+	),
+	Rename: key.NewBinding(
+		key.WithKeys("e"),
+		key.WithHelp("e", "rename"),
+	),
+	RenameShow: key.NewBinding(
+		key.WithKeys("E"),
+		key.WithHelp("E", "rename show folder"),
+	),
+	Pin: key.NewBinding(
+		key.WithKeys("!"),
+		key.WithHelp("!", "pin"),
+	),
+	Background: key.NewBinding(
+		key.WithKeys("N"),
+		key.WithHelp("N", "background priority"),
+	),
+	DriveInfo: key.NewBinding(
+		key.WithKeys("i"),
+		key.WithHelp("i", "details"),
+	),
+	CopyPath: key.NewBinding(
+		key.WithKeys("c"),
+		key.WithHelp("c", "copy drive path"),
+	),
+	CopySource: key.NewBinding(
+		key.WithKeys("C"),
+		key.WithHelp("C", "copy library path"),
+	),
+	CopySummary: key.NewBinding(
+		key.WithKeys("M"),
+		key.WithHelp("M", "copy summary"),
+	),
+	Reveal: key.NewBinding(
+		key.WithKeys("R"),
+		key.WithHelp("R", "reveal in Finder"),
+	),
+	OpenFolder: key.NewBinding(
+		key.WithKeys("O"),
+		key.WithHelp("O", "open show folder"),
+	),
+	OpenInApp: key.NewBinding(
+		key.WithKeys("a"),
+		key.WithHelp("a", "open in Podcasts"),
+	),
+	Unplayed: key.NewBinding(
+		key.WithKeys("U"),
+		key.WithHelp("U", "unplayed only"),
+	),
+	NotOnDrive: key.NewBinding(
+		key.WithKeys("V"),
+		key.WithHelp("V", "not on drive only"),
+	),
+	RecentlyNew: key.NewBinding(
+		key.WithKeys("W"),
+		key.WithHelp("W", "downloaded this week"),
+	),
+	SwitchDrive: key.NewBinding(
+		key.WithKeys("1", "2", "3", "4", "5", "6", "7", "8", "9"),
+		key.WithHelp("1-9", "switch drive"),
+	),
+	Filter: key.NewBinding(
+		key.WithKeys("/"),
+		key.WithHelp("/", "filter"),
+	),
+	GroupShows: key.NewBinding(
+		key.WithKeys("G"),
+		key.WithHelp("G", "group by show"),
+	),
+	SelectShow: key.NewBinding(
+		key.WithKeys("A"),
+		key.WithHelp("A", "select show"),
+	),
+	Sort: key.NewBinding(
+		key.WithKeys("t"),
+		key.WithHelp("t", "sort"),
+	),
+	RangeSelect: key.NewBinding(
+		key.WithKeys("v"),
+		key.WithHelp("v", "select range"),
+	),
+	Invert: key.NewBinding(
+		key.WithKeys("i"),
+		key.WithHelp("i", "invert selection"),
+	),
+	SelectNew: key.NewBinding(
+		key.WithKeys("g"),
+		key.WithHelp("g", "select unplayed"),
+	),
+	AddFolder: key.NewBinding(
+		key.WithKeys("F"),
+		key.WithHelp("F", "add folder"),
+	),
 }
 
 type MacHelpKeyMap struct{ KeyMap }
 
 func (k MacHelpKeyMap) ShortHelp() []key.Binding {
-	return []key.Binding{k.Space, k.Sync, k.SyncAll}
+	return []key.Binding{k.Space, k.Sync, k.Preview, k.SyncAll, k.Favorite, k.ShowPolicy, k.HideMissing, k.Background}
 }
 
 var macHelpKeys = MacHelpKeyMap{
 	KeyMap: KeyMap{
-		Up:      keys.Up,
-		Down:    keys.Down,
-		Tab:     keys.Tab,
-		Space:   keys.Space,
-		Sync:    keys.Sync,
-		SyncAll: keys.SyncAll,
-		Quit:    keys.Quit,
+		Up:          keys.Up,
+		Down:        keys.Down,
+		Tab:         keys.Tab,
+		Space:       keys.Space,
+		Sync:        keys.Sync,
+		Preview:     keys.Preview,
+		SyncAll:     keys.SyncAll,
+		Quit:        keys.Quit,
+		HideMissing: keys.HideMissing,
+		Favorite:    keys.Favorite,
+		ShowPolicy:  keys.ShowPolicy,
+		Background:  keys.Background,
 	},
 }
 
 type DriveHelpKeyMap struct{ KeyMap }
 
 func (k DriveHelpKeyMap) ShortHelp() []key.Binding {
-	return []key.Binding{k.Space, k.Delete, k.DeleteAll}
+	return []key.Binding{k.Space, k.Delete, k.DeleteAll, k.Prune, k.Queue, k.Favorite, k.Undo}
 }
 
 var driveHelpKeys = DriveHelpKeyMap{
@@ -150,6 +332,10 @@ var driveHelpKeys = DriveHelpKeyMap{
 		Space:     keys.Space,
 		Delete:    keys.Delete,
 		DeleteAll: keys.DeleteAll,
+		Favorite:  keys.Favorite,
+		Prune:     keys.Prune,
+		Queue:     keys.Queue,
+		Undo:      keys.Undo,
 		Quit:      keys.Quit,
 	},
 }
@@ -178,12 +364,60 @@ var confirmKeys = ConfirmKeyMap{
 	),
 }
 
+// CancelKeyMap confirms cancelling a running sync. Only the bindings matching
+// the configured partial file policy are enabled.
+type CancelKeyMap struct {
+	Delete key.Binding
+	Keep   key.Binding
+	Yes    key.Binding
+	No     key.Binding
+}
+
+func (k CancelKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Delete, k.Keep, k.Yes, k.No}
+}
+
+func (k CancelKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{}
+}
+
+func newCancelKeyMap(policy internal.PartialFilePolicy) CancelKeyMap {
+	k := CancelKeyMap{
+		Delete: key.NewBinding(
+			key.WithKeys("d"),
+			key.WithHelp("d", "cancel, delete partial file"),
+		),
+		Keep: key.NewBinding(
+			key.WithKeys("k"),
+			key.WithHelp("k", "cancel, keep partial file"),
+		),
+		Yes: key.NewBinding(
+			key.WithKeys("y", "enter"),
+			key.WithHelp("y/enter", "cancel sync"),
+		),
+		No: key.NewBinding(
+			key.WithKeys("n", "esc"),
+			key.WithHelp("n/esc", "keep syncing"),
+		),
+	}
+	ask := policy == internal.PartialAsk
+	k.Delete.SetEnabled(ask)
+	k.Keep.SetEnabled(ask)
+	k.Yes.SetEnabled(!ask)
+	return k
+}
+
 type TransferKeyMap struct {
-	Cancel key.Binding
+	Minimize   key.Binding
+	Append     key.Binding
+	Retry      key.Binding
+	Skip       key.Binding
+	Background key.Binding
+	Cancel     key.Binding
 }
 
 func (k TransferKeyMap) ShortHelp() []key.Binding {
-	return []key.Binding{k.Cancel}
+	return []key.Binding{k.Minimize, k.Append, k.Retry, k.Skip, k.Background, k.Cancel}
 }
 
 func (k TransferKeyMap) FullHelp() [][]key.Binding {
@@ -191,8 +425,362 @@ func (k TransferKeyMap) FullHelp() [][]key.Binding {
 }
 
 var transferKeys = TransferKeyMap{
+	Minimize: key.NewBinding(
+		key.WithKeys("v"),
+		key.WithHelp("v", "toggle library"),
+	),
+	Append: key.NewBinding(
+		key.WithKeys("s"),
+		key.WithHelp("s", "add selected"),
+	),
+	// Only enabled while the transfer is stalled
+	Retry: key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "retry file"),
+		key.WithDisabled(),
+	),
+	Skip: key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "skip file"),
+		key.WithDisabled(),
+	),
+	Background: keys.Background,
 	Cancel: key.NewBinding(
 		key.WithKeys("esc"),
 		key.WithHelp("esc", "cancel"),
 	),
 }
+
+type SearchKeyMap struct {
+	Up      key.Binding
+	Down    key.Binding
+	Jump    key.Binding
+	Content key.Binding
+	Close   key.Binding
+}
+
+func (k SearchKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Up, k.Down, k.Jump, k.Content, k.Close}
+}
+
+func (k SearchKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{}
+}
+
+var searchKeys = SearchKeyMap{
+	Up: key.NewBinding(
+		key.WithKeys("up", "ctrl+p"),
+		key.WithHelp("↑", "up"),
+	),
+	Down: key.NewBinding(
+		key.WithKeys("down", "ctrl+n"),
+		key.WithHelp("↓", "down"),
+	),
+	Jump: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "jump to episode"),
+	),
+	Content: key.NewBinding(
+		key.WithKeys("ctrl+t"),
+		key.WithHelp("ctrl+t", "toggle notes/transcripts"),
+	),
+	Close: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "close"),
+	),
+}
+
+type PolicyKeyMap struct {
+	Mode  key.Binding
+	More  key.Binding
+	Less  key.Binding
+	Save  key.Binding
+	Close key.Binding
+}
+
+func (k PolicyKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Mode, k.More, k.Less, k.Save, k.Close}
+}
+
+func (k PolicyKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{}
+}
+
+var policyKeys = PolicyKeyMap{
+	Mode: key.NewBinding(
+		key.WithKeys("a"),
+		key.WithHelp("a", "sync mode"),
+	),
+	More: key.NewBinding(
+		key.WithKeys("+", "="),
+		key.WithHelp("+", "keep more"),
+	),
+	Less: key.NewBinding(
+		key.WithKeys("-"),
+		key.WithHelp("-", "keep fewer"),
+	),
+	Save: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "save"),
+	),
+	Close: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "discard"),
+	),
+}
+
+type QueueKeyMap struct {
+	Up       key.Binding
+	Down     key.Binding
+	MoveUp   key.Binding
+	MoveDown key.Binding
+	Save     key.Binding
+	Close    key.Binding
+}
+
+func (k QueueKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Up, k.Down, k.MoveUp, k.MoveDown, k.Save, k.Close}
+}
+
+func (k QueueKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{}
+}
+
+var queueKeys = QueueKeyMap{
+	Up: key.NewBinding(
+		key.WithKeys("up", "k"),
+		key.WithHelp("↑/k", "up"),
+	),
+	Down: key.NewBinding(
+		key.WithKeys("down", "j"),
+		key.WithHelp("↓/j", "down"),
+	),
+	MoveUp: key.NewBinding(
+		key.WithKeys("shift+up", "K"),
+		key.WithHelp("K", "move up"),
+	),
+	MoveDown: key.NewBinding(
+		key.WithKeys("shift+down", "J"),
+		key.WithHelp("J", "move down"),
+	),
+	Save: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "save order"),
+	),
+	Close: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "discard"),
+	),
+}
+
+type PreviewKeyMap struct {
+	Up    key.Binding
+	Down  key.Binding
+	Sync  key.Binding
+	Close key.Binding
+}
+
+func (k PreviewKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Up, k.Down, k.Sync, k.Close}
+}
+
+func (k PreviewKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{}
+}
+
+var previewKeys = PreviewKeyMap{
+	Up: key.NewBinding(
+		key.WithKeys("up", "k"),
+		key.WithHelp("↑/k", "up"),
+	),
+	Down: key.NewBinding(
+		key.WithKeys("down", "j"),
+		key.WithHelp("↓/j", "down"),
+	),
+	Sync: key.NewBinding(
+		key.WithKeys("enter", "s"),
+		key.WithHelp("enter", "sync selected"),
+	),
+	Close: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "close"),
+	),
+}
+
+// UndoKeyMap offers undoing the last sync or cleanup; only the journaled ones are enabled
+type UndoKeyMap struct {
+	Sync    key.Binding
+	Cleanup key.Binding
+	Close   key.Binding
+}
+
+func (k UndoKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Sync, k.Cleanup, k.Close}
+}
+
+func (k UndoKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{}
+}
+
+var undoKeys = UndoKeyMap{
+	Sync: key.NewBinding(
+		key.WithKeys("s"),
+		key.WithHelp("s", "undo last sync"),
+	),
+	Cleanup: key.NewBinding(
+		key.WithKeys("c"),
+		key.WithHelp("c", "undo last cleanup"),
+	),
+	Close: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "close"),
+	),
+}
+
+// TypedConfirmKeyMap confirms or abandons a bulk delete once the drive's name is typed
+type TypedConfirmKeyMap struct {
+	Confirm key.Binding
+	Close   key.Binding
+}
+
+func (k TypedConfirmKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Confirm, k.Close}
+}
+
+func (k TypedConfirmKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{}
+}
+
+var typedConfirmKeys = TypedConfirmKeyMap{
+	Confirm: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "delete"),
+	),
+	Close: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "cancel"),
+	),
+}
+
+// RenameKeyMap saves or abandons the name typed in the rename popup
+type RenameKeyMap struct {
+	Save  key.Binding
+	Close key.Binding
+}
+
+func (k RenameKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Save, k.Close}
+}
+
+func (k RenameKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{}
+}
+
+var renameKeys = RenameKeyMap{
+	Save: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "rename"),
+	),
+	Close: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "cancel"),
+	),
+}
+
+// DetailsKeyMap closes the drive details popup
+type DetailsKeyMap struct {
+	Close key.Binding
+}
+
+func (k DetailsKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Close}
+}
+
+func (k DetailsKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{}
+}
+
+var detailsKeys = DetailsKeyMap{
+	Close: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "close"),
+	),
+}
+
+// DebugKeyMap filters the debug view by level and copies entries from it
+type DebugKeyMap struct {
+	Up       key.Binding
+	Down     key.Binding
+	All      key.Binding
+	Warnings key.Binding
+	Errors   key.Binding
+	Mark     key.Binding
+	Copy     key.Binding
+	Close    key.Binding
+}
+
+func (k DebugKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.All, k.Warnings, k.Errors, k.Mark, k.Copy, k.Close}
+}
+
+func (k DebugKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{}
+}
+
+var debugKeys = DebugKeyMap{
+	Up: key.NewBinding(
+		key.WithKeys("up", "k"),
+	),
+	Down: key.NewBinding(
+		key.WithKeys("down", "j"),
+	),
+	All: key.NewBinding(
+		key.WithKeys("1"),
+		key.WithHelp("1", "all"),
+	),
+	Warnings: key.NewBinding(
+		key.WithKeys("2"),
+		key.WithHelp("2", "warnings"),
+	),
+	Errors: key.NewBinding(
+		key.WithKeys("3"),
+		key.WithHelp("3", "errors"),
+	),
+	Mark: key.NewBinding(
+		key.WithKeys(" "),
+		key.WithHelp("space", "mark"),
+	),
+	Copy: key.NewBinding(
+		key.WithKeys("c"),
+		key.WithHelp("c", "copy"),
+	),
+	Close: key.NewBinding(
+		key.WithKeys("esc", "X"),
+		key.WithHelp("esc", "close"),
+	),
+}
+
+// FirstAidKeyMap runs First Aid on a drive after repeated I/O errors, or puts it off
+type FirstAidKeyMap struct {
+	Run   key.Binding
+	Close key.Binding
+}
+
+func (k FirstAidKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Run, k.Close}
+}
+
+func (k FirstAidKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{}
+}
+
+var firstAidKeys = FirstAidKeyMap{
+	Run: key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "run First Aid"),
+	),
+	Close: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "later"),
+	),
+}
@@ -5,6 +5,8 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+
+	"github.com/joncrangle/podcasts-sync/internal"
 )
 
 // Helper method to update window dimensions
@@ -14,7 +16,7 @@ func (m *Model) updateLayoutDimensions() tea.Cmd {
 	// Conservative estimate for non-list components
 	// Header (~3-5 lines) + Help (~2 lines) + AppStyle margins (~2 lines) + buffer
 	reservedHeight := 12
-	if m.errorMsg != "" {
+	if m.errorMsg != "" || m.infoMsg != "" {
 		reservedHeight += 2
 	}
 
@@ -36,13 +38,16 @@ func (m *Model) updateLayoutDimensions() tea.Cmd {
 
 	m.debug.SetSize(contentWidth, availableHeightForLists)
 	m.driveSelector.SetSize(40, 18)
+	m.quickLists.SetSize(56, 14)
 	m.driveSelector.Styles.TitleBar = m.driveSelector.Styles.TitleBar.
 		Width(40).
 		Align(lipgloss.Center)
 	m.progress.Width = m.listWidth
+	m.searchResults.SetSize(contentWidth*2/3, max(availableHeightForLists-4, 5))
+	m.searchInput.Width = contentWidth*2/3 - 4
 
 	if m.dbgEnabled {
-		return addDebugMsg("Layout Debug",
+		return addDebugMsg(internal.DebugInfo, "Layout Debug",
 			fmt.Sprintf("screen: %dx%d | reserved: %d | listHeight: %d",
 				m.width, m.height, reservedHeight, m.listHeight))
 	}
@@ -6,6 +6,9 @@ import (
 
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/paginator"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 
 	"github.com/joncrangle/podcasts-sync/internal"
@@ -30,8 +33,36 @@ var (
 				BorderForeground(lipgloss.Color(Pink))
 )
 
+// listKeyMap leaves the lists' keys to the model, except those of filtering, which the lists of
+// episodes handle themselves
 func listKeyMap() list.KeyMap {
-	return list.KeyMap{}
+	return list.KeyMap{
+		Filter:               keys.Filter,
+		ClearFilter:          key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "clear filter")),
+		CancelWhileFiltering: key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "cancel")),
+		AcceptWhileFiltering: key.NewBinding(key.WithKeys("enter", "tab", "up", "down"), key.WithHelp("enter", "apply filter")),
+	}
+}
+
+// setListItems shows items in l and reapplies its filter at once. SetItems filters in the background,
+// which would leave a filtered list empty until the result arrives and read items that
+// setPodcastItems may be reusing by then.
+func setListItems(l *list.Model, items []list.Item) {
+	if cmd := l.SetItems(items); cmd != nil {
+		*l, _ = l.Update(cmd())
+	}
+}
+
+// ownsFilterKey reports whether msg is for l's filter: any key while the filter is being typed,
+// the filter key to start one and escape to clear one that is applied
+func ownsFilterKey(l list.Model, msg tea.KeyMsg) bool {
+	switch l.FilterState() {
+	case list.Filtering:
+		return true
+	case list.FilterApplied:
+		return key.Matches(msg, l.KeyMap.ClearFilter, l.KeyMap.Filter)
+	}
+	return key.Matches(msg, l.KeyMap.Filter)
 }
 
 func newCustomDelegate() customDelegate {
@@ -91,12 +122,22 @@ func (d customDelegate) Render(w io.Writer, m list.Model, index int, listItem li
 		title = i.Title()
 		description = i.Description()
 
+	case showItem:
+		styleSet = d.getPodcastStyles(m, i.selected > 0 && i.selected == i.episodes, isFocused)
+		title = i.Title()
+		description = i.Description()
+
 	case internal.USBDrive:
 		styleSet = d.getDefaultStyles(m, isFocused)
 		title = i.Title()
 		description = i.Description()
 
-	case internal.Debug:
+	case debugItem:
+		styleSet = d.getDebugStyles(m, i.Level, isFocused)
+		title = i.Title()
+		description = i.Description()
+
+	case quickListItem:
 		styleSet = d.getDefaultStyles(m, isFocused)
 		title = i.Title()
 		description = i.Description()
@@ -136,18 +177,42 @@ func (d customDelegate) getDefaultStyles(m list.Model, isFocused bool) StyleSet
 	return createStyleSet(m, false, lipgloss.Color(Text), lipgloss.NormalBorder())
 }
 
+// getDebugStyles colors warnings and errors so they stand out among the other debug entries
+func (d customDelegate) getDebugStyles(m list.Model, level internal.DebugLevel, isFocused bool) StyleSet {
+	styles := d.getDefaultStyles(m, isFocused)
+	switch level {
+	case internal.DebugWarn:
+		styles.titleStyle = styles.titleStyle.Foreground(lipgloss.Color(Peach))
+	case internal.DebugError:
+		styles.titleStyle = styles.titleStyle.Foreground(lipgloss.Color(Red))
+	}
+	return styles
+}
+
 func (d customDelegate) renderContent(title, description string, styles StyleSet) string {
 	renderedTitle := styles.titleStyle.Render(title)
 	renderedDesc := styles.descriptionStyle.Render(description)
 	return lipgloss.JoinVertical(lipgloss.Left, renderedTitle, renderedDesc)
 }
 
+// usePageNumbers switches l to numbered pages when a row of dots for pages would not fit. The list
+// only makes that switch on a copy while rendering, so a long list would otherwise rebuild its
+// ever longer row of dots on every update and every frame.
+func usePageNumbers(l *list.Model, pages int) {
+	if pages >= l.Width() {
+		l.Paginator.Type = paginator.Arabic
+	} else {
+		l.Paginator.Type = paginator.Dots
+	}
+}
+
 func createList(title string, kind string) list.Model {
 	l := list.New([]list.Item{}, newCustomDelegate(), 0, 0)
 	l.Title = title
 	l.Help = createHelp()
 	l.KeyMap = listKeyMap()
-	l.SetFilteringEnabled(false)
+	// Only the lists of episodes can be filtered
+	l.SetFilteringEnabled(kind == "mac" || kind == "drive")
 
 	// Set list styles
 	l.Styles.NoItems = list.DefaultStyles().NoItems.
@@ -165,11 +230,55 @@ func createList(title string, kind string) list.Model {
 		l.SetStatusBarItemName("podcast", "podcasts")
 	case "drive":
 		l.SetStatusBarItemName("podcast", "podcasts")
+	case "quick":
+		l.SetStatusBarItemName("list", "lists")
+		l.AdditionalShortHelpKeys = func() []key.Binding {
+			return []key.Binding{keys.Enter, keys.Escape}
+		}
+	case "search":
+		l.SetStatusBarItemName("result", "results")
 	case "select":
 		l.SetStatusBarItemName("drive", "drives")
 		l.AdditionalShortHelpKeys = func() []key.Binding {
-			return []key.Binding{keys.Enter, keys.Escape, keys.Quit}
+			return []key.Binding{keys.Enter, keys.DriveInfo, keys.Benchmark, keys.AddFolder, keys.Escape, keys.Quit}
 		}
+	case "debug":
+		l.SetStatusBarItemName("entry", "entries")
+		l.AdditionalShortHelpKeys = debugKeys.ShortHelp
 	}
 	return l
 }
+
+func createQuickLists() list.Model {
+	l := createList(internal.T("Quick Lists"), "quick")
+	l.SetItems(quickListItems)
+	return l
+}
+
+func createSearchInput() textinput.Model {
+	ti := textinput.New()
+	ti.Placeholder = "Search titles and shows"
+	ti.Prompt = "🔍 "
+	ti.PromptStyle = lipgloss.NewStyle().Foreground(lipgloss.Color(Mauve))
+	ti.TextStyle = lipgloss.NewStyle().Foreground(lipgloss.Color(Text))
+	ti.CharLimit = 120
+	return ti
+}
+
+func createConfirmInput() textinput.Model {
+	ti := textinput.New()
+	ti.Prompt = "⚠ "
+	ti.PromptStyle = lipgloss.NewStyle().Foreground(lipgloss.Color(Red))
+	ti.TextStyle = lipgloss.NewStyle().Foreground(lipgloss.Color(Text))
+	ti.CharLimit = 255
+	return ti
+}
+
+func createRenameInput() textinput.Model {
+	ti := textinput.New()
+	ti.Prompt = "✎ "
+	ti.PromptStyle = lipgloss.NewStyle().Foreground(lipgloss.Color(Mauve))
+	ti.TextStyle = lipgloss.NewStyle().Foreground(lipgloss.Color(Text))
+	ti.CharLimit = 255
+	return ti
+}
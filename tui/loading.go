@@ -0,0 +1,61 @@
+package tui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type Loading struct {
+	macPodcasts   bool
+	drivePodcasts bool
+	drives        bool
+	// When each list started loading, for showing how long it's taking
+	macSince, driveSince time.Time
+}
+
+// trackLoading notes when each list started or stopped loading; it runs on every spinner tick, which
+// is as often as the overlays are redrawn
+func (m *Model) trackLoading(now time.Time) {
+	m.loading.macSince = loadingSince(m.loading.macPodcasts, m.loading.macSince, now)
+	m.loading.driveSince = loadingSince(m.loadingDrive(), m.loading.driveSince, now)
+}
+
+func loadingSince(loading bool, since, now time.Time) time.Time {
+	switch {
+	case !loading:
+		return time.Time{}
+	case since.IsZero():
+		return now
+	}
+	return since
+}
+
+// loadingDrive reports whether the drive list is waiting for its drive's episodes, or without a drive
+// for drives to be found
+func (m Model) loadingDrive() bool {
+	if m.currentDrive.Name == "" {
+		return m.loading.drives
+	}
+	return m.loading.drivePodcasts
+}
+
+// loadingLine shows the spinner with what is loading, and for how long once it takes a second
+func (m Model) loadingLine(what string, since time.Time) string {
+	line := m.transferSpinner.View() + " " + what + "..."
+	if elapsed := time.Since(since); !since.IsZero() && elapsed >= time.Second {
+		line += fmt.Sprintf(" %ds", int(elapsed.Seconds()))
+	}
+	return line
+}
+
+// loadingOverlay takes the place of an empty list's items while it loads, so a slow scan doesn't look
+// like an empty library or drive
+func loadingOverlay(l list.Model, listContent, line string) string {
+	title := l.Styles.TitleBar.Render(l.Styles.Title.Render(l.Title))
+	height := max(lipgloss.Height(listContent)-lipgloss.Height(title), 1)
+	body := lipgloss.Place(l.Width(), height, lipgloss.Center, lipgloss.Center, progressInfoStyle.Render(line))
+	return lipgloss.JoinVertical(lipgloss.Left, title, body)
+}
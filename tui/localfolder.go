@@ -0,0 +1,57 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// errNoFolderChosen is returned by chooseFolder when the picker is cancelled
+var errNoFolderChosen = errors.New("no folder chosen")
+
+// chooseFolder asks for a folder with macOS's folder picker; tests replace it
+var chooseFolder = func() (string, error) {
+	script := `POSIX path of (choose folder with prompt "Choose a folder to sync podcasts to")`
+	out, err := exec.Command("osascript", "-e", script).Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && strings.Contains(string(exitErr.Stderr), "-128") {
+		return "", errNoFolderChosen
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to choose a folder: %w", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+type LocalFolderMsg struct {
+	Path string
+	Err  error
+}
+
+// pickLocalFolder shows the folder picker to add a folder on this Mac as a destination
+func pickLocalFolder() tea.Cmd {
+	return func() tea.Msg {
+		path, err := chooseFolder()
+		return LocalFolderMsg{Path: path, Err: err}
+	}
+}
+
+// handleLocalFolder saves a profile for the chosen folder and looks for drives again, which lists it
+func (m *Model) handleLocalFolder(msg LocalFolderMsg) (tea.Model, tea.Cmd) {
+	if errors.Is(msg.Err, errNoFolderChosen) {
+		return m, nil
+	}
+	if msg.Err != nil {
+		return m.handleError(ErrMsg{err: msg.Err})
+	}
+	name, err := m.config.AddLocalFolder(msg.Path)
+	if err != nil {
+		return m.handleError(ErrMsg{err: err})
+	}
+	m.driveManager.SetProfiles(m.config.Drives)
+	m.setInfo(fmt.Sprintf("Added %s as the drive %s", msg.Path, name))
+	return m, tea.Batch(saveConfig(m.config, m.configPath), getDrives(m.driveManager))
+}
@@ -0,0 +1,68 @@
+package tui
+
+import (
+	"sync/atomic"
+
+	"github.com/joncrangle/podcasts-sync/internal"
+)
+
+// progressMailbox holds only the newest progress update so the UI always renders the
+// freshest state instead of working through a backlog of stale FileOps. Terminal
+// updates (errors and completion) are held in their own slot so coalescing never loses them.
+type progressMailbox struct {
+	latest    atomic.Pointer[internal.FileOp]
+	final     atomic.Pointer[internal.FileOp]
+	notify    chan struct{}
+	coalesced atomic.Int64
+}
+
+func newProgressMailbox() *progressMailbox {
+	return &progressMailbox{notify: make(chan struct{}, 1)}
+}
+
+// put stores op, replacing any progress update the UI has not picked up yet
+func (mb *progressMailbox) put(op internal.FileOp) {
+	if op.Complete || op.Error != nil {
+		// The first terminal update wins; later ones add nothing
+		mb.final.CompareAndSwap(nil, &op)
+	} else if mb.latest.Swap(&op) != nil {
+		mb.coalesced.Add(1)
+	}
+	mb.signal()
+}
+
+// take returns the next update to deliver. Pending progress is delivered before the
+// terminal update so the final frame of the bar is rendered.
+func (mb *progressMailbox) take() (internal.FileOp, bool) {
+	op := mb.latest.Swap(nil)
+	if op == nil {
+		op = mb.final.Swap(nil)
+	}
+	if op == nil {
+		return internal.FileOp{}, false
+	}
+
+	if mb.latest.Load() != nil || mb.final.Load() != nil {
+		mb.signal()
+	}
+	return *op, true
+}
+
+func (mb *progressMailbox) signal() {
+	select {
+	case mb.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Send lets the sync deliver updates straight into the mailbox; nothing is ever dropped
+func (mb *progressMailbox) Send(op internal.FileOp) bool {
+	mb.put(op)
+	return true
+}
+
+// Close is called from the sync's cleanup and posts a completion, so the UI always
+// learns the sync has ended
+func (mb *progressMailbox) Close() {
+	mb.put(internal.FileOp{Complete: true})
+}
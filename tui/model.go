@@ -3,11 +3,14 @@ package tui
 
 import (
 	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/progress"
 	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 
 	"github.com/joncrangle/podcasts-sync/internal"
@@ -22,12 +25,43 @@ const (
 	transferring // actively transferring files
 	confirm
 	debug
+	search
+	quickLists
+	cancelConfirm // asking whether to cancel a running transfer
+	showPolicy    // editing the sync policy of one show
+	queueBuilder  // ordering the drive's episodes for its playlist
+	pathPreview   // listing where the selection would be written
+	undoLog       // offering to undo the drive's last sync or cleanup
+	renaming      // typing a new name for a drive episode or show folder
+	typedConfirm  // typing the drive's name to confirm a bulk delete in safe mode
+	driveDetails  // showing the health of the drive under the cursor in the drive selector
+	firstAid      // offering First Aid after repeated I/O errors on the drive
 )
 
-type Loading struct {
-	macPodcasts   bool
-	drivePodcasts bool
-	drives        bool
+func (s state) String() string {
+	names := map[state]string{
+		normal:         "normal",
+		driveSelection: "driveSelection",
+		syncing:        "syncing",
+		transferring:   "transferring",
+		confirm:        "confirm",
+		debug:          "debug",
+		search:         "search",
+		quickLists:     "quickLists",
+		cancelConfirm:  "cancelConfirm",
+		showPolicy:     "showPolicy",
+		queueBuilder:   "queueBuilder",
+		pathPreview:    "pathPreview",
+		undoLog:        "undoLog",
+		renaming:       "renaming",
+		typedConfirm:   "typedConfirm",
+		driveDetails:   "driveDetails",
+		firstAid:       "firstAid",
+	}
+	if name, ok := names[s]; ok {
+		return name
+	}
+	return "unknown"
 }
 
 type Model struct {
@@ -40,13 +74,20 @@ type Model struct {
 	macPodcasts      list.Model
 	drivePodcasts    list.Model
 	driveSelector    list.Model
+	quickLists       list.Model
 	debug            list.Model
 	help             help.Model
 	confirmHelp      help.Model
 	transferHelp     help.Model
+	searchHelp       help.Model
 	keys             KeyMap
 	confirmKeys      ConfirmKeyMap
 	transferKeys     TransferKeyMap
+	cancelKeys       CancelKeyMap
+	searchKeys       SearchKeyMap
+	searchInput      textinput.Model
+	searchResults    list.Model
+	searchContent    bool
 	progress         progress.Model
 	transferSpinner  spinner.Model
 	syncManager      *syncManager
@@ -57,13 +98,149 @@ type Model struct {
 	debugMsgs        []internal.Debug
 	focusIndex       int // 0 = mac list, 1 = drive list
 	transferProgress internal.TransferProgress
-	statusMsg        string
-	errorMsg         string
-	dbgEnabled       bool
+	// Version of the per-episode transfer states last applied to the Mac list
+	transferStatesVersion int64
+	// Shows the library with a progress footer instead of the transfer popup
+	transferMinimized bool
+	// Where the transfer queue panel is scrolled to, once it has been scrolled by hand
+	transferQueueOffset   int
+	transferQueueScrolled bool
+	statusMsg             string
+	errorMsg              string
+	// A neutral note shown in the banner when there is no error, e.g. how many episodes were selected
+	infoMsg      string
+	errorRetry   retryOp
+	dbgEnabled   bool
+	config       *internal.Config
+	configPath   string
+	history      *internal.History
+	driveManager *internal.DriveManager
+	// Reports drives being mounted and unmounted once the first poll starts it; nil while drives are
+	// polled for
+	mounts        *internal.MountWatcher
+	mountsWatched bool
+	// Loads the Mac library, or the synthetic one in demo mode
+	loadLibrary tea.Cmd
+	demo        bool
+	readOnly    bool
+	macFilter   *episodeFilter
+	hideMissing bool
+	// Groups the Mac list under a node per show; expandedShows names the shows whose episodes are listed
+	groupShows    bool
+	expandedShows map[string]bool
+	// Orders of the Mac and drive lists, cycled with the sort key
+	macSort   sortMode
+	driveSort sortMode
+	// Episodes to sync once a drive is connected, selected while there was none
+	queuedSync []internal.PodcastEpisode
+	// Start of the range selection in progress, nil when there is none
+	selecting *selectionRange
+	// EpisodeKey of every starred episode
+	favorites map[string]bool
+	// Last sync, delete and quick list, kept in the history database for repeating
+	lastActions map[internal.ActionKind]internal.Action
+	// Show being edited in the policy panel and its unsaved policy
+	policyShow  string
+	policyDraft internal.ShowPolicy
+	policyKeys  PolicyKeyMap
+	// Episodes being ordered in the playlist builder and the one under the cursor
+	queue       []internal.PodcastEpisode
+	queueCursor int
+	queueKeys   QueueKeyMap
+	// Destinations of the selection, the first one shown and how long syncing them should take
+	preview         []internal.DestinationPreview
+	previewOffset   int
+	previewEstimate internal.SyncEstimate
+	previewConfirm  bool
+	previewKeys     PreviewKeyMap
+	// Undo journal of the current drive while the undo popup is open
+	journal  *internal.Journal
+	undoKeys UndoKeyMap
+	// Drive episode being renamed, and whether its show folder is renamed instead
+	renameInput  textinput.Model
+	renameTarget internal.PodcastEpisode
+	renameShow   bool
+	renameKeys   RenameKeyMap
+	// Drive name typed to confirm a bulk delete in safe mode
+	confirmInput     textinput.Model
+	typedConfirmKeys TypedConfirmKeyMap
+	publishState     bool
+	// Name of the drive whose speed is being measured
+	benchmarking string
+	// Health of the drive shown in the details popup
+	details     DriveHealthMsg
+	detailsKeys DetailsKeyMap
+	// Offered after repeated I/O errors on the current drive
+	firstAidKeys FirstAidKeyMap
+	// How long the running transfer has written nothing, once past the stall timeout
+	stalledFor time.Duration
+	stallWatch internal.StallWatch
+	// Eases the progress bar between updates; progressFrames tags the frames of the running sync
+	smoother       progressSmoother
+	progressFrames int
+	// How far the starting sync is through examining the episodes
+	preparing internal.PrepareProgress
+	// The lowest level the debug view shows, and the entries marked for copying by index in debugMsgs
+	debugKeys   DebugKeyMap
+	debugLevel  internal.DebugLevel
+	debugMarked map[int]bool
+	// Set once drives have been detected, so drives found later were plugged in while the app ran
+	drivesSeen bool
+	// Mount path of a drive that was plugged in and syncs by itself once it is scanned
+	autoSyncDrive string
+	// Library episodes found on each drive scanned so far, by mount path, for the header's drive bar
+	driveMatches map[string]int
+}
+
+// Options holds command line settings that change how the TUI behaves
+type Options struct {
+	// RecordProgressDir enables writing the raw progress samples of every sync to this directory
+	RecordProgressDir string
+	// PublishDebugState snapshots the model on every render for DebugState
+	PublishDebugState bool
+	// Demo replaces the Podcasts library, drives, config and history with a synthetic environment
+	Demo *internal.Demo
+	// AllowSleep lets the computer sleep during a sync
+	AllowSleep bool
+	// ReadOnly refuses everything that would change a drive, the config or the history database
+	ReadOnly bool
 }
 
 func InitialModel() Model {
+	return NewModel(Options{})
+}
+
+// NewModel creates the root model configured by opts
+func NewModel(opts Options) Model {
 	dbgEnabled := os.Getenv("DEBUG") == "true"
+
+	configPath := internal.DefaultConfigPath()
+	historyPath := internal.DefaultHistoryPath()
+	volumesPath := "/Volumes"
+	loadLibrary := tea.Cmd(getMacPodcasts)
+	if opts.Demo != nil {
+		configPath = filepath.Join(opts.Demo.Root, "config.json")
+		historyPath = filepath.Join(opts.Demo.Root, "history.db")
+		volumesPath = opts.Demo.VolumesPath
+		loadLibrary = getDemoPodcasts(opts.Demo)
+	}
+
+	errorMsg := ""
+	config, err := internal.LoadConfig(configPath)
+	if err != nil {
+		errorMsg = err.Error()
+	}
+	internal.SetLanguage(config.Language)
+	driveManager := internal.NewDriveManager(volumesPath, internal.DirectoryTemplate{})
+	driveManager.SetProfiles(config.Drives)
+	if opts.Demo == nil {
+		driveManager.SetMirrors(internal.DefaultMirrorsDir())
+	}
+	history := internal.NewHistory(historyPath)
+	syncManager := newSyncManager(history)
+	syncManager.syncer.SetRecordDir(opts.RecordProgressDir)
+	syncManager.syncer.SetAllowSleep(opts.AllowSleep)
+
 	return Model{
 		loading:          Loading{macPodcasts: true, drivePodcasts: true, drives: true},
 		state:            normal,
@@ -71,36 +248,65 @@ func InitialModel() Model {
 		height:           0,
 		listWidth:        0,
 		listHeight:       0,
-		macPodcasts:      createList("Mac Podcasts", "mac"),
-		drivePodcasts:    createList("Drive Podcasts", "drive"),
-		driveSelector:    createList("USB Drives", "select"),
-		debug:            createList("Debug", "select"),
+		macPodcasts:      createList(internal.T(macListTitle), "mac"),
+		drivePodcasts:    createList(internal.T("Drive Podcasts"), "drive"),
+		driveSelector:    createList(internal.T("USB Drives"), "select"),
+		quickLists:       createQuickLists(),
+		debug:            createList("Debug", "debug"),
 		help:             createHelp(),
 		confirmHelp:      createHelp(),
 		transferHelp:     createHelp(),
+		searchHelp:       createHelp(),
 		keys:             keys,
 		confirmKeys:      confirmKeys,
 		transferKeys:     transferKeys,
+		cancelKeys:       newCancelKeyMap(config.PartialFiles),
+		searchKeys:       searchKeys,
+		policyKeys:       policyKeys,
+		queueKeys:        queueKeys,
+		previewKeys:      newPreviewKeyMap(opts.ReadOnly),
+		undoKeys:         undoKeys,
+		renameInput:      createRenameInput(),
+		renameKeys:       renameKeys,
+		confirmInput:     createConfirmInput(),
+		typedConfirmKeys: typedConfirmKeys,
+		detailsKeys:      detailsKeys,
+		firstAidKeys:     firstAidKeys,
+		debugKeys:        debugKeys,
+		searchInput:      createSearchInput(),
+		searchResults:    createList(internal.T("Search"), "search"),
 		progress:         createProgress(),
 		transferSpinner:  createSpinner(),
-		syncManager:      newSyncManager(),
+		syncManager:      syncManager,
 		podcasts:         []internal.PodcastEpisode{},
 		podcastsDrive:    []internal.PodcastEpisode{},
 		currentDrive:     internal.USBDrive{},
 		drives:           []internal.USBDrive{},
 		debugMsgs:        []internal.Debug{},
+		debugMarked:      map[int]bool{},
 		focusIndex:       0,
 		transferProgress: internal.TransferProgress{},
 		statusMsg:        "",
-		errorMsg:         "",
+		errorMsg:         errorMsg,
 		dbgEnabled:       dbgEnabled,
+		config:           config,
+		configPath:       configPath,
+		history:          history,
+		driveManager:     driveManager,
+		loadLibrary:      loadLibrary,
+		demo:             opts.Demo != nil,
+		readOnly:         opts.ReadOnly,
+		publishState:     opts.PublishDebugState,
 	}
 }
 
 func (m Model) Init() tea.Cmd {
 	return tea.Batch(
-		getMacPodcasts,
+		m.loadLibrary,
 		pollDrivesCmd(0), // Check drives immediately
+		pollReconcileCmd(reconcileInterval),
+		loadFavorites(m.history),
+		loadActions(m.history),
 		m.transferSpinner.Tick,
 	)
 }
@@ -1,15 +1,43 @@
 package tui
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
 	"testing"
 	"time"
 
+	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/x/exp/teatest"
 
 	"github.com/joncrangle/podcasts-sync/internal"
 )
 
+// TestMain points HOME and the XDG folders at a temporary directory, so models built by the tests
+// neither read the developer's config nor create or migrate their history database
+func TestMain(m *testing.M) {
+	home, err := os.MkdirTemp("", "podcasts-sync-tui")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Setenv("HOME", home)
+	for _, name := range []string{"XDG_CONFIG_HOME", "XDG_CACHE_HOME"} {
+		os.Unsetenv(name)
+	}
+	code := m.Run()
+	os.RemoveAll(home)
+	os.Exit(code)
+}
+
 func TestInitialModel(t *testing.T) {
 	model := InitialModel()
 
@@ -106,6 +134,270 @@ func TestModelUpdate_DriveUpdate(t *testing.T) {
 	}
 }
 
+func TestModelUpdate_WatchesMounts(t *testing.T) {
+	model := InitialModel()
+	model.history = nil
+	model.driveManager = internal.NewDriveManager(t.TempDir(), internal.DirectoryTemplate{})
+	updated, cmd := model.Update(DrivesPollMsg{})
+	m := updated.(*Model)
+	if m.mounts == nil || cmd == nil {
+		t.Fatal("Expected the first poll to start watching mounts")
+	}
+	mounts := m.mounts
+	t.Cleanup(func() { _ = mounts.Close() })
+
+	// Later polls don't start another watcher
+	updated, _ = m.Update(DrivesPollMsg{})
+	if m = updated.(*Model); m.mounts != mounts {
+		t.Error("Expected the same watcher after another poll")
+	}
+
+	// A failed watcher leaves finding drives to polling
+	updated, _ = m.Update(MountsChangedMsg{err: errors.New("watch failed")})
+	if m = updated.(*Model); m.mounts != nil {
+		t.Error("Expected polling to take over from a failed watcher")
+	}
+}
+
+func TestDriveBar_SwitchesDrives(t *testing.T) {
+	model := InitialModel()
+	model.loading.macPodcasts = false
+	model.demo = true
+	model.history = nil
+	drives := DriveUpdatedMsg{
+		{Name: "CAR", MountPath: "/Volumes/CAR", Folder: "podcasts", Capacity: 32 << 30, Free: 12 << 30},
+		{Name: "GYM", MountPath: "/Volumes/GYM", Folder: "podcasts"},
+	}
+	updated, _ := model.Update(drives)
+	m := updated.(*Model)
+	updated, _ = m.Update(DrivePodcastsMsg{PodcastsDrive: []internal.PodcastEpisode{
+		{ZTitle: "Matched", OnDrive: true},
+		{ZTitle: "Unknown"},
+	}})
+	m = updated.(*Model)
+
+	bar := m.formatDriveBar()
+	for _, want := range []string{"1 CAR · 12.0 GB free · 1 matched", "2 GYM"} {
+		if !strings.Contains(bar, want) {
+			t.Errorf("Expected the drive bar to contain %q, got %q", want, bar)
+		}
+	}
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'2'}})
+	m = updated.(*Model)
+	if m.currentDrive.Name != "GYM" || !m.loading.drivePodcasts || cmd == nil {
+		t.Errorf("Expected 2 to switch to and scan GYM, got %q", m.currentDrive.Name)
+	}
+	updated, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'3'}})
+	if m = updated.(*Model); m.currentDrive.Name != "GYM" || cmd != nil {
+		t.Errorf("Expected a number without a drive ignored, got %q", m.currentDrive.Name)
+	}
+
+	// A drive that is ejected loses its count
+	updated, _ = m.Update(DriveUpdatedMsg{drives[1]})
+	if m = updated.(*Model); m.formatDriveBar() != "" || len(m.driveMatches) != 0 {
+		t.Errorf("Expected no drive bar or counts for a single drive, got %q and %v", m.formatDriveBar(), m.driveMatches)
+	}
+}
+
+// asModel returns the model Update returned, which handlers return as a pointer and the rest as a value
+func asModel(updated tea.Model) *Model {
+	if m, ok := updated.(*Model); ok {
+		return m
+	}
+	m := updated.(Model)
+	return &m
+}
+
+func TestModelUpdate_QueuedSyncWaitsForDrive(t *testing.T) {
+	model := InitialModel()
+	model.history = nil
+	updated, _ := model.Update(tea.WindowSizeMsg{Width: 160, Height: 50})
+	m := asModel(updated)
+	updated, _ = m.Update(MacPodcastsMsg{
+		{ZTitle: "Queued", ShowName: "Show", FilePath: "/test/queued.mp3", FileSize: 1 << 20, Selected: true},
+		{ZTitle: "Other", ShowName: "Show", FilePath: "/test/other.mp3"},
+	})
+	m = asModel(updated)
+	updated, _ = m.Update(DriveUpdatedMsg{})
+	m = asModel(updated)
+	if view := m.View(); !strings.Contains(view, "Waiting for a drive") {
+		t.Error("Expected the drive list to wait for a drive")
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'s'}})
+	m = asModel(updated)
+	if m.state != normal || len(m.queuedSync) != 1 || !strings.Contains(m.View(), "1 episode(s), 1.0 MB, will sync") {
+		t.Fatalf("Expected the sync queued until a drive appears, got state %v and %d queued", m.state, len(m.queuedSync))
+	}
+
+	updated, _ = m.Update(DriveUpdatedMsg{{Name: "CAR", MountPath: "/Volumes/CAR", Folder: "podcasts"}})
+	m = asModel(updated)
+	if m.state != normal {
+		t.Errorf("Expected the sync to wait for the drive's scan, got state %v", m.state)
+	}
+	updated, cmd := m.Update(DrivePodcastsMsg{})
+	if m = asModel(updated); m.state != syncing || len(m.queuedSync) != 0 || cmd == nil {
+		t.Errorf("Expected the queued sync to start once the drive was scanned, got state %v", m.state)
+	}
+}
+
+func TestModelUpdate_QueuedSyncCancels(t *testing.T) {
+	model := InitialModel()
+	model.history = nil
+	updated, _ := model.Update(MacPodcastsMsg{{ZTitle: "Queued", FilePath: "/test/queued.mp3", Selected: true}})
+	m := asModel(updated)
+	for range 2 {
+		updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'s'}})
+		m = asModel(updated)
+	}
+	if len(m.queuedSync) != 0 || !strings.Contains(m.infoMsg, "Cancelled") {
+		t.Errorf("Expected a second press to cancel the queued sync, got %q", m.infoMsg)
+	}
+}
+
+func TestModelUpdate_PreferredDrive(t *testing.T) {
+	model := InitialModel()
+	model.loading.macPodcasts = false
+	model.history = nil
+	model.config = &internal.Config{DrivePriority: []string{"CAR"}}
+	updated, _ := model.Update(DriveUpdatedMsg{
+		{Name: "GYM", MountPath: "/Volumes/GYM", Folder: "podcasts"},
+		{Name: "CAR", MountPath: "/Volumes/CAR", Folder: "podcasts"},
+	})
+	m := updated.(*Model)
+	if m.currentDrive.Name != "CAR" {
+		t.Errorf("Expected the preferred drive to be selected, got %q", m.currentDrive.Name)
+	}
+	if !strings.Contains(m.infoMsg, "Selected CAR") {
+		t.Errorf("Expected a note naming the chosen drive, got %q", m.infoMsg)
+	}
+}
+
+func TestModelUpdate_SharedDriveNames(t *testing.T) {
+	model := InitialModel()
+	model.loading.macPodcasts = false
+	model.history = nil
+	model.config = &internal.Config{Drives: map[string]internal.DriveProfile{"USB DRIVE": {Folder: "podcasts"}}}
+	red := internal.USBDrive{Name: "USB DRIVE", MountPath: "/Volumes/USB DRIVE", UUID: "RED", Shared: true, Capacity: 8 << 30}
+	blue := internal.USBDrive{Name: "USB DRIVE", MountPath: "/Volumes/USB DRIVE 1", UUID: "BLUE", Shared: true, Capacity: 16 << 30}
+	updated, _ := model.Update(DriveUpdatedMsg{red, blue})
+	m := updated.(*Model)
+
+	var titles []string
+	for _, item := range m.driveSelector.Items() {
+		titles = append(titles, item.(internal.USBDrive).Title())
+	}
+	if want := []string{"USB DRIVE (8.0 GB, never synced)", "USB DRIVE (16.0 GB, never synced)"}; !slices.Equal(titles, want) {
+		t.Errorf("Expected the selector to tell the drives apart, got %v", titles)
+	}
+
+	// A benchmark of the second stick is saved under its UUID, not the shared name
+	speed := internal.DriveSpeed{Write: 1 << 20}
+	updated, _ = m.Update(DriveSpeedMsg{Drive: "USB DRIVE", MountPath: blue.MountPath, Speed: speed})
+	m = updated.(*Model)
+	if m.config.Drives["BLUE"].Speed != speed || m.config.Drives["BLUE"].Folder != "podcasts" || m.config.Drives["USB DRIVE"].Speed != (internal.DriveSpeed{}) {
+		t.Errorf("Expected the speed saved for BLUE only, got %+v", m.config.Drives)
+	}
+
+	// Another stick mounted where the current one was is a different drive
+	green := red
+	green.UUID = "GREEN"
+	updated, _ = m.Update(DriveUpdatedMsg{green, blue})
+	if m = updated.(*Model); m.currentDrive.UUID != "GREEN" {
+		t.Errorf("Expected the swapped stick to replace the current drive, got %q", m.currentDrive.UUID)
+	}
+}
+
+func TestDriveSelector_AddLocalFolder(t *testing.T) {
+	folder := t.TempDir()
+	original := chooseFolder
+	chooseFolder = func() (string, error) { return folder, nil }
+	defer func() { chooseFolder = original }()
+
+	model := InitialModel()
+	model.history = nil
+	model.config = &internal.Config{}
+	model.state = driveSelection
+	updated, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'F'}})
+	m := asModel(updated)
+	if cmd == nil {
+		t.Fatal("Expected F to open the folder picker")
+	}
+	updated, cmd = m.Update(cmd())
+	m = asModel(updated)
+	name := filepath.Base(folder)
+	if m.config.Drives[name].LocalFolder != folder || cmd == nil {
+		t.Errorf("Expected a profile for the folder and a new look for drives, got %+v", m.config.Drives)
+	}
+
+	// Cancelling the picker changes nothing
+	chooseFolder = func() (string, error) { return "", errNoFolderChosen }
+	updated, _ = m.Update(pickLocalFolder()())
+	if m = asModel(updated); len(m.config.Drives) != 1 {
+		t.Errorf("Expected a cancelled picker to add nothing, got %+v", m.config.Drives)
+	}
+}
+
+func TestModelUpdate_DriveSpace(t *testing.T) {
+	model := InitialModel()
+	model.loading.macPodcasts = false
+	drive := internal.USBDrive{Name: "CAR", MountPath: "/Volumes/CAR", Folder: "podcasts", Capacity: 32 << 30, Free: 12 << 30}
+	updated, _ := model.Update(DriveUpdatedMsg{drive})
+	m := updated.(*Model)
+	if info := m.formatDriveInfo(); !strings.Contains(info, "(12.0 GB free)") {
+		t.Errorf("Expected the free space in the header, got %q", info)
+	}
+
+	// A poll that finds the same drive still picks up its new free space
+	drive.Free = 2 << 30
+	updated, _ = m.Update(DriveUpdatedMsg{drive})
+	m = updated.(*Model)
+	if m.currentDrive.Free != drive.Free {
+		t.Errorf("Expected the current drive's free space updated, got %d", m.currentDrive.Free)
+	}
+	if item := m.driveSelector.Items()[0].(internal.USBDrive); !strings.Contains(item.Description(), "2.0 GB free of 32.0 GB") {
+		t.Errorf("Expected the free space in the drive selector, got %q", item.Description())
+	}
+}
+
+func TestModelUpdate_AutoSyncPluggedInDrive(t *testing.T) {
+	model := InitialModel()
+	model.history = nil
+	model.loading.macPodcasts = false
+	m := &model
+	update := func(msg tea.Msg) tea.Cmd {
+		t.Helper()
+		updated, cmd := m.Update(msg)
+		m = updated.(*Model)
+		return cmd
+	}
+	car := internal.USBDrive{Name: "CAR", MountPath: "/Volumes/CAR", Folder: "podcasts", Profile: internal.DriveProfile{AutoSync: internal.AutoSyncUnsynced}}
+
+	// A drive connected when the app starts isn't synced by itself
+	update(DriveUpdatedMsg{car})
+	if m.autoSyncDrive != "" {
+		t.Fatal("Expected no auto-sync for a drive found at startup")
+	}
+	update(DriveUpdatedMsg{})
+
+	// Plugged in later, it is scanned and then synced
+	if cmd := update(DriveUpdatedMsg{car}); cmd == nil || m.autoSyncDrive != car.MountPath || m.currentDrive.Name != "CAR" {
+		t.Fatalf("Expected CAR scanned for an auto-sync, got drive %q pending %q", m.currentDrive.Name, m.autoSyncDrive)
+	}
+	library := []internal.PodcastEpisode{
+		{ZTitle: "Copied", ShowName: "Daily", FilePath: "file:///library/copied.mp3", OnDrive: true},
+		{ZTitle: "New", ShowName: "Daily", FilePath: "file:///library/new.mp3"},
+	}
+	update(DrivePodcastsMsg{Podcasts: library, PodcastsDrive: library[:1]})
+	if m.state != syncing || m.autoSyncDrive != "" {
+		t.Errorf("Expected the auto-sync started, got state %v pending %q", m.state, m.autoSyncDrive)
+	}
+	if library[1].Selected {
+		t.Error("Expected the scanned library left as it was")
+	}
+}
+
 func TestModelUpdate_ErrorHandling(t *testing.T) {
 	model := InitialModel()
 	model.state = transferring
@@ -260,3 +552,2055 @@ func TestKeyboardNavigation(t *testing.T) {
 	// Quit the test cleanly
 	tm.Send(tea.KeyMsg{Type: tea.KeyCtrlC})
 }
+
+func TestGlobalSearch_JumpsToEpisode(t *testing.T) {
+	model := InitialModel()
+	testPodcasts := []internal.PodcastEpisode{
+		{ZTitle: "Alpha", ShowName: "Show A", FilePath: "/test/alpha.mp3"},
+		{ZTitle: "Beta", ShowName: "Show B", FilePath: "/test/beta.mp3"},
+	}
+	updatedModel, _ := model.Update(MacPodcastsMsg(testPodcasts))
+	m := updatedModel.(*Model)
+
+	updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlF})
+	m = updatedModel.(*Model)
+	if m.state != search {
+		t.Fatalf("Expected search state, got %v", m.state)
+	}
+
+	// Typed keys go to the query rather than triggering global bindings
+	updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("beta")})
+	m = updatedModel.(*Model)
+	if m.searchInput.Value() != "beta" {
+		t.Fatalf("Expected query 'beta', got %q", m.searchInput.Value())
+	}
+
+	updatedModel, _ = m.Update(SearchResultsMsg{
+		Query:   "beta",
+		Results: internal.SearchEpisodes(m.podcasts, "beta", false),
+	})
+	m = updatedModel.(*Model)
+
+	updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updatedModel.(*Model)
+
+	if m.state != normal {
+		t.Errorf("Expected normal state after jump, got %v", m.state)
+	}
+	if m.macPodcasts.Index() != 1 {
+		t.Errorf("Expected Mac list cursor on 'Beta', got index %d", m.macPodcasts.Index())
+	}
+}
+
+func TestQuickList_FiltersMacList(t *testing.T) {
+	model := InitialModel()
+	testPodcasts := []internal.PodcastEpisode{
+		{ZTitle: "Kept", ShowName: "Show", FilePath: "/test/kept.mp3"},
+		{ZTitle: "Failed", ShowName: "Show", FilePath: "/test/failed.mp3"},
+	}
+	updatedModel, _ := model.Update(MacPodcastsMsg(testPodcasts))
+	m := updatedModel.(*Model)
+
+	updatedModel, _ = m.Update(QuickListMsg{
+		Name:    "Failed last sync",
+		Entries: []internal.HistoryEntry{{Action: internal.HistoryFailed, Title: "Failed", ShowName: "Show"}},
+	})
+	m = updatedModel.(*Model)
+
+	items := m.macPodcasts.Items()
+	if len(items) != 1 || items[0].(internal.PodcastEpisode).ZTitle != "Failed" {
+		t.Fatalf("Expected only the failed episode to be listed, got %v", items)
+	}
+
+	// Selection inside a quick list updates the underlying library
+	updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeySpace})
+	m = updatedModel.(*Model)
+	if !m.podcasts[1].Selected || m.podcasts[0].Selected {
+		t.Error("Expected only the failed episode to be selected")
+	}
+
+	updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyEscape})
+	m = updatedModel.(*Model)
+	if m.macFilter != nil || len(m.macPodcasts.Items()) != 2 {
+		t.Error("Expected escape to clear the quick list filter")
+	}
+}
+
+func TestMacFilterToggles(t *testing.T) {
+	model := InitialModel()
+	testPodcasts := []internal.PodcastEpisode{
+		{ZTitle: "New", ShowName: "Show", FilePath: "/test/new.mp3", Downloaded: time.Now().Add(-time.Hour)},
+		{ZTitle: "Played", ShowName: "Show", FilePath: "/test/played.mp3", Played: true, Downloaded: time.Now().Add(-30 * 24 * time.Hour)},
+		{ZTitle: "Synced", ShowName: "Show", FilePath: "/test/synced.mp3", OnDrive: true, Downloaded: time.Now().Add(-2 * time.Hour)},
+		{ZTitle: "Gone", ShowName: "Show", FilePath: "/test/gone.mp3", Missing: true},
+	}
+	updatedModel, _ := model.Update(MacPodcastsMsg(testPodcasts))
+	m := updatedModel.(*Model)
+
+	titles := func() []string {
+		var titles []string
+		for _, item := range m.macPodcasts.Items() {
+			titles = append(titles, item.(internal.PodcastEpisode).ZTitle)
+		}
+		return titles
+	}
+	for _, tt := range []struct {
+		key  rune
+		want []string
+	}{
+		{'U', []string{"New", "Synced", "Gone"}},
+		{'V', []string{"New", "Played", "Gone"}},
+		{'W', []string{"New", "Synced"}},
+	} {
+		updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{tt.key}})
+		m = updatedModel.(*Model)
+		if got := titles(); !slices.Equal(got, tt.want) {
+			t.Errorf("%c: expected %v, got %v", tt.key, tt.want, got)
+		}
+		if !strings.Contains(m.macPodcasts.Title, m.macFilter.name) {
+			t.Errorf("%c: expected the filter named in the title, got %q", tt.key, m.macPodcasts.Title)
+		}
+	}
+
+	// Pressing the active filter's key again shows the whole library
+	updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'W'}})
+	m = updatedModel.(*Model)
+	if m.macFilter != nil || len(m.macPodcasts.Items()) != 4 {
+		t.Errorf("Expected the filter toggled off, got %v", titles())
+	}
+}
+
+func TestMacList_GroupByShow(t *testing.T) {
+	model := InitialModel()
+	model.history = nil
+	testPodcasts := []internal.PodcastEpisode{
+		{ZTitle: "Eggs", ShowName: "Planet Money", FilePath: "/test/eggs.mp3", FileSize: 10},
+		{ZTitle: "Tides", ShowName: "Radiolab", FilePath: "/test/tides.mp3", FileSize: 20},
+		{ZTitle: "Tariffs", ShowName: "Planet Money", FilePath: "/test/tariffs.mp3", FileSize: 30},
+		{ZTitle: "Pending", ShowName: "Planet Money", FilePath: "/test/pending.mp3", Downloading: true},
+	}
+	updatedModel, _ := model.Update(MacPodcastsMsg(testPodcasts))
+	m := updatedModel.(*Model)
+	press := func(r rune) {
+		updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		m = updatedModel.(*Model)
+	}
+	titles := func() []string {
+		var titles []string
+		for _, item := range m.macPodcasts.Items() {
+			titles = append(titles, item.(interface{ Title() string }).Title())
+		}
+		return titles
+	}
+
+	press('G')
+	if got, want := titles(), []string{"▸ Planet Money", "▸ Radiolab"}; !slices.Equal(got, want) {
+		t.Errorf("Expected collapsed shows, got %v", got)
+	}
+
+	updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updatedModel.(*Model)
+	if got := titles(); len(got) != 5 || got[0] != "▾ Planet Money" || got[4] != "▸ Radiolab" {
+		t.Errorf("Expected Planet Money expanded, got %v", got)
+	}
+
+	// Selecting the show skips the episode still downloading
+	press('A')
+	selected := 0
+	for _, p := range m.podcasts {
+		if p.Selected {
+			selected++
+			if p.ShowName != "Planet Money" || p.Downloading {
+				t.Errorf("Expected only the show's downloaded episodes selected, got %q", p.ZTitle)
+			}
+		}
+	}
+	if node := m.macPodcasts.Items()[0].(showItem); selected != 2 || node.selected != 2 {
+		t.Errorf("Expected 2 episodes selected and counted, got %d and %d", selected, node.selected)
+	}
+	press('A')
+	if node := m.macPodcasts.Items()[0].(showItem); node.selected != 0 {
+		t.Errorf("Expected a second press to clear the show, got %d selected", node.selected)
+	}
+
+	press('G')
+	if got := titles(); len(got) != 4 {
+		t.Errorf("Expected the flat list back, got %v", got)
+	}
+}
+
+func TestLists_SortModes(t *testing.T) {
+	model := InitialModel()
+	model.history = nil
+	day := time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC)
+	testPodcasts := []internal.PodcastEpisode{
+		{ZTitle: "Short", ShowName: "Radiolab", FilePath: "/test/short.mp3", Published: day.AddDate(0, 0, 2), FileSize: 10, Duration: time.Minute},
+		{ZTitle: "Big", ShowName: "Planet Money", FilePath: "/test/big.mp3", Published: day.AddDate(0, 0, 1), FileSize: 90, Duration: 2 * time.Minute, Downloaded: day},
+		{ZTitle: "Long", ShowName: "Radiolab", FilePath: "/test/long.mp3", Published: day, FileSize: 30, Duration: time.Hour, Downloaded: day.AddDate(0, 0, 5)},
+	}
+	updatedModel, _ := model.Update(MacPodcastsMsg(testPodcasts))
+	m := updatedModel.(*Model)
+	titles := func(l list.Model) []string {
+		var titles []string
+		for _, item := range l.Items() {
+			titles = append(titles, item.(internal.PodcastEpisode).ZTitle)
+		}
+		return titles
+	}
+
+	for _, want := range []struct {
+		title  string
+		titles []string
+	}{
+		{"by show", []string{"Big", "Short", "Long"}},
+		{"largest first", []string{"Big", "Long", "Short"}},
+		{"longest first", []string{"Long", "Big", "Short"}},
+		{"recently downloaded", []string{"Long", "Big", "Short"}},
+		{"", []string{"Short", "Big", "Long"}},
+	} {
+		updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'t'}})
+		m = updatedModel.(*Model)
+		if got := titles(m.macPodcasts); !slices.Equal(got, want.titles) {
+			t.Errorf("%q: expected %v, got %v", want.title, want.titles, got)
+		}
+		if want.title != "" && !strings.Contains(m.macPodcasts.Title, want.title) {
+			t.Errorf("Expected the sort named in the title, got %q", m.macPodcasts.Title)
+		}
+	}
+
+	// The drive list has a sort of its own
+	updatedModel, _ = m.Update(DrivePodcastsMsg{PodcastsDrive: testPodcasts})
+	m = updatedModel.(*Model)
+	m.focusIndex = 1
+	for range 2 {
+		updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'t'}})
+		m = updatedModel.(*Model)
+	}
+	if got := titles(m.drivePodcasts); !slices.Equal(got, []string{"Big", "Long", "Short"}) || m.macSort != sortPublished {
+		t.Errorf("Expected only the drive list sorted largest first, got %v", got)
+	}
+}
+
+func TestLists_RangeSelection(t *testing.T) {
+	model := InitialModel()
+	model.history = nil
+	var testPodcasts []internal.PodcastEpisode
+	for i := range 6 {
+		testPodcasts = append(testPodcasts, internal.PodcastEpisode{
+			ZTitle:      fmt.Sprintf("Episode %d", i),
+			ShowName:    "Show",
+			FilePath:    fmt.Sprintf("/test/%d.mp3", i),
+			Downloading: i == 3,
+		})
+	}
+	updatedModel, _ := model.Update(MacPodcastsMsg(testPodcasts))
+	m := asModel(updatedModel)
+	press := func(msg tea.KeyMsg) {
+		updatedModel, _ = m.Update(msg)
+		m = asModel(updatedModel)
+	}
+	down := tea.KeyMsg{Type: tea.KeyDown}
+
+	press(down)
+	press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'v'}})
+	for range 3 {
+		press(down)
+	}
+	press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{' '}})
+
+	var selected []string
+	for _, p := range m.podcasts {
+		if p.Selected {
+			selected = append(selected, p.ZTitle)
+		}
+	}
+	if want := []string{"Episode 1", "Episode 2", "Episode 4"}; !slices.Equal(selected, want) {
+		t.Errorf("Expected the range without the download in progress, got %v", selected)
+	}
+	if m.selecting != nil {
+		t.Error("Expected the range selection to end")
+	}
+
+	// Space selects a single episode again once the range is done
+	press(down)
+	press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{' '}})
+	if !m.podcasts[5].Selected {
+		t.Error("Expected space to select the episode under the cursor")
+	}
+}
+
+func TestMacList_InvertAndSelectUnplayed(t *testing.T) {
+	model := InitialModel()
+	model.history = nil
+	updatedModel, _ := model.Update(MacPodcastsMsg{
+		{ZTitle: "Selected", FilePath: "/test/selected.mp3", Selected: true},
+		{ZTitle: "New", FilePath: "/test/new.mp3"},
+		{ZTitle: "Played", FilePath: "/test/played.mp3", Played: true},
+		{ZTitle: "Synced", FilePath: "/test/synced.mp3", OnDrive: true},
+		{ZTitle: "Pending", FilePath: "/test/pending.mp3", Downloading: true},
+	})
+	m := asModel(updatedModel)
+	press := func(r rune) {
+		updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		m = asModel(updatedModel)
+	}
+	selected := func() []string {
+		var titles []string
+		for _, item := range m.macPodcasts.Items() {
+			if p := item.(internal.PodcastEpisode); p.Selected {
+				titles = append(titles, p.ZTitle)
+			}
+		}
+		return titles
+	}
+
+	press('i')
+	if got, want := selected(), []string{"New", "Played", "Synced"}; !slices.Equal(got, want) {
+		t.Errorf("Expected the selection inverted without the download in progress, got %v", got)
+	}
+	press('i')
+	if got := selected(); !slices.Equal(got, []string{"Selected"}) {
+		t.Errorf("Expected a second invert to restore the selection, got %v", got)
+	}
+
+	press('g')
+	if got, want := selected(), []string{"Selected", "New"}; !slices.Equal(got, want) {
+		t.Errorf("Expected the unplayed episode not on the drive added, got %v", got)
+	}
+	if !strings.Contains(m.infoMsg, "1 unplayed") || m.errorMsg != "" {
+		t.Errorf("Expected a count of the added episodes as a note rather than an error, got %q, %q", m.infoMsg, m.errorMsg)
+	}
+	updatedModel, _ = m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = asModel(updatedModel)
+	if !strings.Contains(m.View(), "1 unplayed") {
+		t.Error("Expected the note in the banner")
+	}
+	press('x')
+	if m.infoMsg != "" {
+		t.Errorf("Expected x to dismiss the note, got %q", m.infoMsg)
+	}
+}
+
+func TestDriveList_SelectShow(t *testing.T) {
+	model := InitialModel()
+	model.history = nil
+	updatedModel, _ := model.Update(DrivePodcastsMsg{PodcastsDrive: []internal.PodcastEpisode{
+		{ZTitle: "A", ShowName: "Radiolab", FilePath: "/drive/a.mp3"},
+		{ZTitle: "B", ShowName: "Planet Money", FilePath: "/drive/b.mp3"},
+		{ZTitle: "C", ShowName: "Radiolab", FilePath: "/drive/c.mp3"},
+	}})
+	m := asModel(updatedModel)
+	m.focusIndex = 1
+	updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'A'}})
+	m = asModel(updatedModel)
+	if !m.podcastsDrive[0].Selected || m.podcastsDrive[1].Selected || !m.podcastsDrive[2].Selected {
+		t.Errorf("Expected the show's episodes selected on the drive, got %+v", m.podcastsDrive)
+	}
+}
+
+func TestMacList_FuzzyFilter(t *testing.T) {
+	model := InitialModel()
+	model.history = nil
+	jan := time.Date(2024, time.January, 12, 12, 0, 0, 0, time.UTC)
+	testPodcasts := []internal.PodcastEpisode{
+		{ZTitle: "The Price of Eggs", ShowName: "Planet Money", FilePath: "/test/eggs.mp3", Published: jan},
+		{ZTitle: "Tariffs", ShowName: "Planet Money", FilePath: "/test/tariffs.mp3", Published: jan.AddDate(0, 2, 0)},
+		{ZTitle: "Moon Landing", ShowName: "History Hour", FilePath: "/test/moon.mp3", Published: jan},
+	}
+	updated, _ := model.Update(MacPodcastsMsg(testPodcasts))
+	m := updated.(*Model)
+
+	// Keys taken by a list's filter return the model by value
+	// run returns cmd's message, skipping the cursor blinks that wait before sending theirs
+	run := func(cmd tea.Cmd) tea.Msg {
+		msgs := make(chan tea.Msg, 1)
+		go func() { msgs <- cmd() }()
+		select {
+		case msg := <-msgs:
+			return msg
+		case <-time.After(50 * time.Millisecond):
+			return nil
+		}
+	}
+	// send delivers a key and the filter results it leads to, as the program would
+	send := func(msg tea.KeyMsg) {
+		updated, cmd := m.Update(msg)
+		m = asModel(updated)
+		var results []tea.Msg
+		if cmd != nil {
+			results = append(results, run(cmd))
+		}
+		for len(results) > 0 {
+			result := results[0]
+			results = results[1:]
+			switch result := result.(type) {
+			case tea.BatchMsg:
+				for _, c := range result {
+					if c != nil {
+						results = append(results, run(c))
+					}
+				}
+			case list.FilterMatchesMsg:
+				updated, _ = m.Update(result)
+				m = asModel(updated)
+			}
+		}
+	}
+	send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'/'}})
+	for _, r := range "planet money jan" {
+		if r == ' ' {
+			send(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+			continue
+		}
+		send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	if m.state != normal || m.macPodcasts.FilterState() != list.Filtering {
+		t.Fatalf("Expected the typed filter to stay in the list, got state %v and filter %v", m.state, m.macPodcasts.FilterState())
+	}
+	send(tea.KeyMsg{Type: tea.KeyEnter})
+
+	visible := m.macPodcasts.VisibleItems()
+	if len(visible) != 1 || visible[0].(internal.PodcastEpisode).ZTitle != "The Price of Eggs" {
+		t.Fatalf("Expected only the January Planet Money episode, got %v", visible)
+	}
+
+	// Selecting in a filtered list selects the episode in the library and keeps the filter
+	send(tea.KeyMsg{Type: tea.KeySpace})
+	if !m.podcasts[0].Selected || m.podcasts[1].Selected {
+		t.Error("Expected only the filtered episode selected")
+	}
+	if visible := m.macPodcasts.VisibleItems(); len(visible) != 1 || !visible[0].(internal.PodcastEpisode).Selected {
+		t.Errorf("Expected the filtered list to show the selection, got %v", visible)
+	}
+
+	send(tea.KeyMsg{Type: tea.KeyEscape})
+	if m.macPodcasts.FilterState() != list.Unfiltered || len(m.macPodcasts.VisibleItems()) != 3 {
+		t.Errorf("Expected escape to clear the filter, got %v", m.macPodcasts.FilterState())
+	}
+}
+
+func TestDebugState_PublishedOnRender(t *testing.T) {
+	model := NewModel(Options{PublishDebugState: true})
+	model.state = driveSelection
+	model.View()
+
+	state := DebugState()
+	if state["state"] != "driveSelection" {
+		t.Errorf("Expected published state driveSelection, got %v", state["state"])
+	}
+	if _, locked := state["syncManagerLocked"]; locked {
+		t.Error("Expected idle sync manager not to be reported as locked")
+	}
+}
+
+func TestProgressMailbox_CoalescesProgressButKeepsFinal(t *testing.T) {
+	mb := newProgressMailbox()
+	for bytes := int64(1); bytes <= 3; bytes++ {
+		mb.Send(internal.FileOp{Progress: internal.TransferProgress{BytesTransferred: bytes}})
+	}
+	mb.Close()
+
+	op, ok := mb.take()
+	if !ok || op.Progress.BytesTransferred != 3 || op.Complete {
+		t.Fatalf("Expected newest progress first, got %+v", op)
+	}
+	if mb.coalesced.Load() != 2 {
+		t.Errorf("Expected 2 coalesced updates, got %d", mb.coalesced.Load())
+	}
+
+	// The remaining completion must still be signalled after progress was taken
+	select {
+	case <-mb.notify:
+	default:
+		t.Fatal("Expected notify to be re-armed for the pending completion")
+	}
+	op, ok = mb.take()
+	if !ok || !op.Complete {
+		t.Fatalf("Expected completion after progress, got %+v", op)
+	}
+	if _, ok := mb.take(); ok {
+		t.Error("Expected mailbox to be empty")
+	}
+}
+
+func TestSyncManager_DeliversCompletion(t *testing.T) {
+	tempDir := t.TempDir()
+	source := tempDir + "/source.mp3"
+	if err := os.WriteFile(source, make([]byte, 64*1024), 0o644); err != nil {
+		t.Fatalf("Failed to create source: %v", err)
+	}
+
+	sm := newSyncManager(nil)
+	episodes := []internal.PodcastEpisode{{
+		ZTitle:   "Episode",
+		ShowName: "Show",
+		FilePath: "file://" + source,
+		Selected: true,
+	}}
+	drive := internal.USBDrive{Name: "Test", MountPath: tempDir + "/drive", Folder: "podcasts"}
+
+	msg := sm.start(episodes, drive)()
+	for i := 0; i < 200; i++ {
+		if op, ok := msg.(FileOpMsg); ok && op.Msg.Complete {
+			return
+		}
+		if errMsg, ok := msg.(ErrMsg); ok {
+			t.Fatalf("Unexpected error: %v", errMsg)
+		}
+		msg = sm.wait()()
+	}
+	t.Fatal("Expected sync to report completion")
+}
+
+func TestTransferStates_ShownInMacList(t *testing.T) {
+	model := InitialModel()
+	testPodcasts := []internal.PodcastEpisode{
+		{ZTitle: "Copying", ShowName: "Show", FilePath: "/test/copying.mp3", Selected: true},
+		{ZTitle: "Idle", ShowName: "Show", FilePath: "/test/idle.mp3"},
+	}
+	updatedModel, _ := model.Update(MacPodcastsMsg(testPodcasts))
+	m := updatedModel.(*Model)
+
+	tm := internal.NewTransferManager(0, 1, internal.ChanSink(make(chan internal.FileOp, 1)))
+	defer tm.Stop()
+	tm.SetFileState("/test/copying.mp3", internal.FileCopying)
+	m.syncManager.tm = tm
+	m.state = transferring
+
+	updatedModel, _ = m.Update(ProgressTickMsg{})
+	ticked := updatedModel.(Model)
+	m = &ticked
+
+	items := m.macPodcasts.Items()
+	if got := items[0].(internal.PodcastEpisode).Title(); got != "◐ Copying" {
+		t.Errorf("Expected copying glyph, got %q", got)
+	}
+	if got := items[1].(internal.PodcastEpisode).Title(); got != "Idle" {
+		t.Errorf("Expected unselected episode without glyph, got %q", got)
+	}
+
+	updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("v")})
+	m = updatedModel.(*Model)
+	if !m.transferMinimized {
+		t.Error("Expected v to show the library during transfer")
+	}
+
+	m.resetTransferStates()
+	if got := m.macPodcasts.Items()[0].(internal.PodcastEpisode).Title(); got != "Copying" {
+		t.Errorf("Expected glyph to be cleared after sync, got %q", got)
+	}
+}
+
+func TestSyncKey_AppendsDuringTransfer(t *testing.T) {
+	model := InitialModel()
+	testPodcasts := []internal.PodcastEpisode{
+		{ZTitle: "Late", ShowName: "Show", FilePath: "/test/late.mp3", Selected: true},
+	}
+	updatedModel, _ := model.Update(MacPodcastsMsg(testPodcasts))
+	m := updatedModel.(*Model)
+	m.state = transferring
+
+	updatedModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s")})
+	m = updatedModel.(*Model)
+	if cmd == nil {
+		t.Fatal("Expected s to append the selection to the running sync")
+	}
+
+	// No sync is actually running, so the append is refused without leaving the transfer view
+	msg, ok := cmd().(SyncAppendedMsg)
+	if !ok || msg.Err == nil {
+		t.Fatalf("Expected append to fail without a running sync, got %v", msg)
+	}
+	updatedModel, _ = m.Update(msg)
+	m = updatedModel.(*Model)
+	if m.state != transferring || m.statusMsg == "" {
+		t.Errorf("Expected transfer to continue with a status message, got state %v, status %q", m.state, m.statusMsg)
+	}
+}
+
+func TestEscape_ConfirmsBeforeCancellingTransfer(t *testing.T) {
+	model := InitialModel()
+	model.config = &internal.Config{}
+	model.cancelKeys = newCancelKeyMap(internal.PartialAsk)
+	model.state = transferring
+
+	updatedModel, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEscape})
+	m := updatedModel.(*Model)
+	if m.state != cancelConfirm || cmd != nil {
+		t.Fatalf("Expected escape to ask for confirmation, got state %v", m.state)
+	}
+
+	// Progress keeps flowing while the question is open
+	updatedModel, cmd = m.Update(FileOpMsg{Operation: "sync", Msg: internal.FileOp{Progress: internal.TransferProgress{TotalFiles: 1}}})
+	m = updatedModel.(*Model)
+	if cmd == nil {
+		t.Error("Expected progress updates to continue during confirmation")
+	}
+
+	updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+	m = updatedModel.(*Model)
+	if m.state != transferring {
+		t.Fatalf("Expected n to resume the transfer view, got %v", m.state)
+	}
+
+	m.state = cancelConfirm
+	updatedModel, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("k")})
+	m = updatedModel.(*Model)
+	if m.state != normal || cmd == nil {
+		t.Errorf("Expected k to cancel the sync, got state %v", m.state)
+	}
+}
+
+func TestProgressSmoother_RunsOnBetweenUpdates(t *testing.T) {
+	start := time.Date(2024, 3, 1, 6, 0, 0, 0, time.UTC)
+	// 10% of the total per second, reported every 500ms
+	progressAt := func(percent float64) internal.TransferProgress {
+		return internal.TransferProgress{CurrentProgress: percent, TotalBytes: 100 << 20, Speed: 10 << 20}
+	}
+	var s progressSmoother
+	s.report(progressAt(0), start)
+	s.report(progressAt(0.05), start.Add(500*time.Millisecond))
+
+	shown := s.shown
+	for i := 1; i <= 15; i++ {
+		got := s.advance(start.Add(500*time.Millisecond + time.Duration(i)*time.Second/progressFPS))
+		if got < shown {
+			t.Fatalf("Expected the bar to only move forward, went from %v to %v", shown, got)
+		}
+		shown = got
+	}
+	if shown <= 0.05 || shown > 0.1 {
+		t.Errorf("Expected the bar to run on towards 10%% before the next update, got %v", shown)
+	}
+
+	// With no update for a long while the bar stops at one update interval past the last
+	for i := 16; i <= 120; i++ {
+		shown = s.advance(start.Add(500*time.Millisecond + time.Duration(i)*time.Second/progressFPS))
+	}
+	if shown > 0.1+1e-9 {
+		t.Errorf("Expected the bar to stop at 10%% while the copy stalls, got %v", shown)
+	}
+
+	// Appending episodes grows the total, so the bar moves back
+	s.report(progressAt(0.04), start.Add(5*time.Second))
+	if got := s.advance(start.Add(5*time.Second + 100*time.Millisecond)); got >= shown {
+		t.Errorf("Expected the bar to move back once the total grew, got %v", got)
+	}
+}
+
+func TestProgressSmoother_SameAtAnyFrameRate(t *testing.T) {
+	start := time.Date(2024, 3, 1, 6, 0, 0, 0, time.UTC)
+	var fast, slow progressSmoother
+	for _, s := range []*progressSmoother{&fast, &slow} {
+		s.report(internal.TransferProgress{TotalBytes: 100}, start)
+		s.report(internal.TransferProgress{CurrentProgress: 0.5, TotalBytes: 100}, start.Add(time.Millisecond))
+	}
+	for i := 1; i <= 60; i++ {
+		fast.advance(start.Add(time.Millisecond + time.Duration(i)*time.Second/60))
+	}
+	for i := 1; i <= 10; i++ {
+		slow.advance(start.Add(time.Millisecond + time.Duration(i)*time.Second/10))
+	}
+	if diff := fast.shown - slow.shown; diff > 0.001 || diff < -0.001 {
+		t.Errorf("Expected the same progress after a second at 60 and 10 fps, got %v and %v", fast.shown, slow.shown)
+	}
+}
+
+func TestSyncing_ShowsIndeterminateProgress(t *testing.T) {
+	model := InitialModel()
+	model.history = nil
+	model.width, model.height = 120, 40
+	model.currentDrive = internal.USBDrive{Name: "CAR"}
+	model.state = syncing
+	cmd := model.startProgress()
+	if cmd == nil {
+		t.Fatal("Expected the progress bar to be redrawn while the sync starts")
+	}
+
+	first := model.View()
+	if !strings.Contains(first, "Checking what to copy to CAR") {
+		t.Errorf("Expected the syncing popup, got:\n%s", first)
+	}
+	updatedModel, cmd := model.Update(ProgressFrameMsg{ID: model.progressFrames, At: time.Now()})
+	m := updatedModel.(*Model)
+	if cmd == nil || m.View() == first {
+		t.Error("Expected the indeterminate bar to move each frame")
+	}
+
+	// Frames of an earlier sync stop
+	if _, cmd := m.Update(ProgressFrameMsg{ID: m.progressFrames - 1, At: time.Now()}); cmd != nil {
+		t.Error("Expected a stale frame to stop redrawing")
+	}
+	m.state = normal
+	if _, cmd := m.Update(ProgressFrameMsg{ID: m.progressFrames, At: time.Now()}); cmd != nil {
+		t.Error("Expected frames to stop once the sync ended")
+	}
+}
+
+func TestSyncing_ShowsPreparingProgress(t *testing.T) {
+	model := InitialModel()
+	model.history = nil
+	model.width, model.height = 120, 40
+	model.currentDrive = internal.USBDrive{Name: "CAR"}
+	model.state = syncing
+	model.startProgress()
+
+	updatedModel, cmd := model.Update(FileOpMsg{Operation: "sync", Msg: internal.FileOp{Preparing: &internal.PrepareProgress{Examined: 120, Total: 480}}})
+	m := updatedModel.(*Model)
+	if m.state != syncing || cmd == nil {
+		t.Fatalf("Expected to keep waiting for the totals, got state %v", m.state)
+	}
+	if view := m.View(); !strings.Contains(view, "Preparing the sync to CAR: examined 120 of 480 files") || !strings.Contains(view, "25%") {
+		t.Errorf("Expected the preparing progress, got:\n%s", view)
+	}
+
+	// A sync cancelled while preparing ignores late updates
+	m.state = normal
+	if _, cmd := m.Update(FileOpMsg{Operation: "sync", Msg: internal.FileOp{Preparing: &internal.PrepareProgress{Examined: 121, Total: 480}}}); cmd != nil {
+		t.Error("Expected no more waiting once the sync was cancelled")
+	}
+}
+
+func TestLoadingOverlays(t *testing.T) {
+	model := InitialModel()
+	model.history = nil
+	updatedModel, _ := model.Update(tea.WindowSizeMsg{Width: 160, Height: 40})
+	m := updatedModel.(Model)
+
+	now := time.Now()
+	m.trackLoading(now.Add(-3 * time.Second))
+	view := m.View()
+	if !strings.Contains(view, "Loading the library... 3s") || !strings.Contains(view, "Looking for drives... 3s") {
+		t.Errorf("Expected both lists to show they're loading, got:\n%s", view)
+	}
+
+	// The start of a load is kept until it ends
+	m.trackLoading(now)
+	if !strings.Contains(m.View(), "Loading the library... 3s") {
+		t.Error("Expected the elapsed time to count from when loading started")
+	}
+
+	m.currentDrive = internal.USBDrive{Name: "CAR"}
+	m.loading.drives = false
+	updatedModel, _ = m.Update(MacPodcastsMsg{{ZTitle: "Episode", ShowName: "Show", FilePath: "file:///library/1.mp3"}})
+	m2 := updatedModel.(*Model)
+	m2.trackLoading(now)
+	view = m2.View()
+	if strings.Contains(view, "Loading the library") || !strings.Contains(view, "Scanning CAR...") {
+		t.Errorf("Expected only the drive list to be loading, got:\n%s", view)
+	}
+	if m2.loading.macSince != (time.Time{}) {
+		t.Error("Expected the library's loading time to be cleared once loaded")
+	}
+}
+
+func TestErrorBanner_RetryAndDismiss(t *testing.T) {
+	model := InitialModel()
+	model.history = nil
+	updatedModel, _ := model.Update(tea.WindowSizeMsg{Width: 160, Height: 40})
+	m := updatedModel.(Model)
+	m.currentDrive = internal.USBDrive{Name: "CAR"}
+	m.loading = Loading{drivePodcasts: true}
+
+	updatedModel, _ = m.Update(ErrMsg{err: errors.New("failed to scan CAR"), retry: retryDrivePodcasts})
+	m2 := updatedModel.(*Model)
+	if view := m2.View(); !strings.Contains(view, "failed to scan CAR") || !strings.Contains(view, "r retry") {
+		t.Errorf("Expected a banner offering to retry the scan, got:\n%s", view)
+	}
+	if m2.loading.drivePodcasts {
+		t.Error("Expected the failed scan to stop loading")
+	}
+	if items := m2.debug.Items(); len(items) != 1 || !strings.Contains(items[0].(debugItem).DDescription, "failed to scan CAR") {
+		t.Errorf("Expected the error in the debug history, got %v", items)
+	}
+
+	updatedModel, cmd := m2.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'r'}})
+	m2 = updatedModel.(*Model)
+	if cmd == nil || m2.errorMsg != "" || m2.errorRetry != retryNone || !m2.loading.drivePodcasts || m2.loading.macPodcasts {
+		t.Errorf("Expected r to rescan only the drive, got %q, loading %+v", m2.errorMsg, m2.loading)
+	}
+
+	// An error with nothing to retry can only be dismissed
+	updatedModel, _ = m2.Update(ErrMsg{err: errors.New("failed to save config")})
+	m2 = updatedModel.(*Model)
+	if view := m2.View(); !strings.Contains(view, "x dismiss") || strings.Contains(view, "r retry") {
+		t.Errorf("Expected a banner without retry, got:\n%s", view)
+	}
+	updatedModel, _ = m2.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'x'}})
+	m2 = updatedModel.(*Model)
+	if m2.errorMsg != "" || strings.Contains(m2.View(), "failed to save config") {
+		t.Error("Expected x to dismiss the banner")
+	}
+	if len(m2.debug.Items()) != 2 {
+		t.Errorf("Expected both errors kept in the debug history, got %d", len(m2.debug.Items()))
+	}
+}
+
+func TestDebugView_FilterAndCopy(t *testing.T) {
+	var copied string
+	original := copyToClipboard
+	copyToClipboard = func(text string) error {
+		copied = text
+		return nil
+	}
+	defer func() { copyToClipboard = original }()
+
+	model := InitialModel()
+	model.history = nil
+	m := &model
+	m.state = debug
+	at := time.Date(2024, 1, 15, 9, 30, 0, 0, time.Local)
+	m.logDebug(internal.Debug{DTitle: "Layout Debug", DDescription: "screen: 80x24", Time: at})
+	m.logDebug(internal.Debug{DTitle: "Drive health", DDescription: "CAR: failing", Level: internal.DebugWarn, Time: at})
+	m.logDebug(internal.Debug{DTitle: "Error", DDescription: "failed to scan CAR", Level: internal.DebugError, Time: at})
+
+	press := func(r rune) {
+		t.Helper()
+		updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		m = updated.(*Model)
+	}
+	press('2')
+	if n := len(m.debug.Items()); n != 2 {
+		t.Fatalf("Expected warnings and errors only, got %d entries", n)
+	}
+	press('3')
+	if n := len(m.debug.Items()); n != 1 {
+		t.Fatalf("Expected errors only, got %d entries", n)
+	}
+
+	// Without marks the entry under the cursor is copied
+	press('c')
+	if want := "09:30:00.000 [error] Error: failed to scan CAR\n"; copied != want {
+		t.Errorf("Expected %q copied, got %q", want, copied)
+	}
+
+	press('1')
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+	m = updated.(*Model)
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+	m = updated.(*Model)
+	if item := m.debug.Items()[0].(debugItem); !item.marked || !strings.HasPrefix(item.Title(), "● ") {
+		t.Errorf("Expected the first entry to be marked, got %q", item.Title())
+	}
+	press('c')
+	if lines := strings.Split(strings.TrimSuffix(copied, "\n"), "\n"); len(lines) != 2 || !strings.Contains(lines[1], "[warn] Drive health") {
+		t.Errorf("Expected both marked entries copied, got %q", copied)
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEscape})
+	if updated.(*Model).state != normal {
+		t.Error("Expected esc to close the debug view")
+	}
+}
+
+func TestCopyEpisode(t *testing.T) {
+	var copied string
+	original := copyToClipboard
+	copyToClipboard = func(text string) error {
+		copied = text
+		return nil
+	}
+	defer func() { copyToClipboard = original }()
+
+	model := InitialModel()
+	model.history = nil
+	m := &model
+	episode := internal.PodcastEpisode{ZTitle: "First", ShowName: "Daily News", FilePath: "file:///library/first.mp3"}
+	m.podcasts = []internal.PodcastEpisode{episode}
+	m.macPodcasts.SetItems([]list.Item{episode})
+	press := func(r rune) {
+		t.Helper()
+		updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		m = updated.(*Model)
+	}
+
+	// Without a drive there's nowhere the episode goes yet
+	press('c')
+	if copied != "" {
+		t.Errorf("Expected nothing copied without a drive, got %q", copied)
+	}
+	press('C')
+	if copied != "/library/first.mp3" {
+		t.Errorf("Expected the library path copied, got %q", copied)
+	}
+
+	m.currentDrive = internal.USBDrive{Name: "CAR", MountPath: "/Volumes/CAR"}
+	press('c')
+	if want := filepath.Join("/Volumes/CAR", m.currentDrive.Profile.EpisodePath(episode)); copied != want {
+		t.Errorf("Expected %q copied, got %q", want, copied)
+	}
+
+	// A drive episode copies where it is, and the library path of the episode it matches
+	onDrive := internal.PodcastEpisode{ZTitle: "First", ShowName: "Daily News", FilePath: "/Volumes/CAR/Daily News/first.mp3"}
+	m.drivePodcasts.SetItems([]list.Item{onDrive})
+	m.focusIndex = 1
+	press('c')
+	if copied != onDrive.FilePath {
+		t.Errorf("Expected the drive path copied, got %q", copied)
+	}
+	press('M')
+	if !strings.HasPrefix(copied, "**First**") || !strings.Contains(copied, "`/library/first.mp3`") || !strings.Contains(copied, "`"+onDrive.FilePath+"`") {
+		t.Errorf("Expected a summary with both paths, got %q", copied)
+	}
+}
+
+func TestRevealAndOpenShowFolder(t *testing.T) {
+	var opened []string
+	original := openInFinder
+	openInFinder = func(args ...string) error {
+		opened = args
+		return nil
+	}
+	defer func() { openInFinder = original }()
+
+	model := InitialModel()
+	model.history = nil
+	m := &model
+	episode := internal.PodcastEpisode{ZTitle: "First", ShowName: "Daily News", FilePath: "file:///library/first.mp3"}
+	m.podcasts = []internal.PodcastEpisode{episode}
+	m.macPodcasts.SetItems([]list.Item{episode})
+	press := func(r rune) {
+		t.Helper()
+		updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		m = updated.(*Model)
+		if cmd != nil {
+			cmd()
+		}
+	}
+
+	press('R')
+	if !slices.Equal(opened, []string{"-R", "/library/first.mp3"}) {
+		t.Errorf("Expected the library file revealed, got %v", opened)
+	}
+
+	// The show's folder has to exist on the drive to be opened
+	opened = nil
+	m.currentDrive = internal.USBDrive{Name: "CAR", MountPath: t.TempDir()}
+	press('O')
+	if opened != nil || !strings.Contains(m.errorMsg, "no folder on CAR") {
+		t.Errorf("Expected a note for a show not on the drive, got %v, %q", opened, m.errorMsg)
+	}
+	folder := filepath.Dir(filepath.Join(m.currentDrive.MountPath, m.currentDrive.Profile.EpisodePath(episode)))
+	if err := os.MkdirAll(folder, 0o755); err != nil {
+		t.Fatalf("Failed to create show folder: %v", err)
+	}
+	press('O')
+	if !slices.Equal(opened, []string{folder}) {
+		t.Errorf("Expected %s opened, got %v", folder, opened)
+	}
+}
+
+func TestOpenInPodcastsApp(t *testing.T) {
+	var opened []string
+	originalOpen, originalURL := openInFinder, podcastsAppURL
+	openInFinder = func(args ...string) error {
+		opened = args
+		return nil
+	}
+	podcastsAppURL = func(episode internal.PodcastEpisode) (string, error) {
+		return "podcasts://" + episode.FilePath, nil
+	}
+	defer func() { openInFinder, podcastsAppURL = originalOpen, originalURL }()
+
+	model := InitialModel()
+	model.history = nil
+	m := &model
+	episode := internal.PodcastEpisode{ZTitle: "First", ShowName: "Daily News", FilePath: "file:///library/first.mp3"}
+	m.podcasts = []internal.PodcastEpisode{episode}
+	// A drive episode opens the library episode it matches
+	m.drivePodcasts.SetItems([]list.Item{internal.PodcastEpisode{ZTitle: "First", ShowName: "Daily News", FilePath: "/Volumes/CAR/first.mp3"}})
+	m.focusIndex = 1
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'a'}})
+	if cmd == nil {
+		t.Fatal("Expected a command opening the episode")
+	}
+	if msg := cmd(); msg != nil {
+		t.Fatalf("Expected no error, got %v", msg)
+	}
+	if !slices.Equal(opened, []string{"podcasts://file:///library/first.mp3"}) {
+		t.Errorf("Expected the library episode's link opened, got %v", opened)
+	}
+}
+
+func TestStalledTransfer_OffersRetryAndSkip(t *testing.T) {
+	model := InitialModel()
+	model.config = &internal.Config{StallSeconds: 5}
+	sized, _ := model.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	resized := sized.(Model)
+	m := &resized
+	m.state = transferring
+	m.transferProgress = internal.TransferProgress{CurrentFile: "Slow", BytesTransferred: 100, TotalBytes: 1000, TotalFiles: 1}
+
+	tm := internal.NewTransferManager(1000, 1, internal.ChanSink(make(chan internal.FileOp, 1)))
+	defer tm.Stop()
+	tm.StartFile("Slow")
+	m.syncManager.tm = tm
+
+	start := time.Now()
+	m.checkStall(start)
+	m.checkStall(start.Add(4 * time.Second))
+	if m.stalledFor != 0 {
+		t.Fatalf("Expected no warning before the stall timeout, got %v", m.stalledFor)
+	}
+	if _, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")}); cmd != nil {
+		t.Error("Expected x to do nothing while bytes are moving")
+	}
+
+	m.checkStall(start.Add(6 * time.Second))
+	if m.stalledFor != 6*time.Second {
+		t.Fatalf("Expected a 6s stall, got %v", m.stalledFor)
+	}
+	view := m.View()
+	if !strings.Contains(view, "Nothing written for 6s") || !strings.Contains(view, "skip file") {
+		t.Errorf("Expected the stall warning with its options, got:\n%s", view)
+	}
+
+	updatedModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+	m = updatedModel.(*Model)
+	if cmd == nil {
+		t.Fatal("Expected x to skip the stalled file")
+	}
+	cmd()
+	if _, err := tm.Write([]byte("more")); !errors.Is(err, internal.ErrFileAborted) {
+		t.Errorf("Expected the copy of the stalled file to be aborted, got %v", err)
+	}
+	if m.stalledFor != 0 || !strings.Contains(m.statusMsg, "Skipping Slow") {
+		t.Errorf("Expected the warning to clear with a status message, got %v, %q", m.stalledFor, m.statusMsg)
+	}
+}
+
+func TestSyncStart_SummarizesMissingSources(t *testing.T) {
+	model := InitialModel()
+	model.state = syncing
+	missing := []internal.PodcastEpisode{{ZTitle: "Gone"}}
+
+	updatedModel, _ := model.Update(FileOpMsg{Operation: "sync", Msg: internal.FileOp{
+		Progress: internal.TransferProgress{TotalFiles: 1, Missing: missing},
+	}})
+	m := updatedModel.(*Model)
+	if m.state != transferring || !strings.Contains(m.statusMsg, "Gone") {
+		t.Errorf("Expected transfer to start with the missing episode listed, got state %v, status %q", m.state, m.statusMsg)
+	}
+
+	model.state = syncing
+	updatedModel, _ = model.Update(FileOpMsg{Operation: "sync", Msg: internal.FileOp{
+		Progress: internal.TransferProgress{Missing: missing},
+	}})
+	m = updatedModel.(*Model)
+	if m.state != normal || !strings.Contains(m.errorMsg, "1 episode(s) no longer downloaded") {
+		t.Errorf("Expected the missing episode to be reported when nothing is left to sync, got %q", m.errorMsg)
+	}
+}
+
+func TestSync_SummarizesEpisodesAlreadyOnDrive(t *testing.T) {
+	model := InitialModel()
+	model.state = syncing
+	skipped := []internal.PodcastEpisode{{ZTitle: "A"}, {ZTitle: "B"}, {ZTitle: "C"}, {ZTitle: "D"}}
+
+	updatedModel, _ := model.Update(FileOpMsg{Operation: "sync", Msg: internal.FileOp{
+		Progress: internal.TransferProgress{TotalFiles: 3, Skipped: skipped},
+	}})
+	m := updatedModel.(*Model)
+	if want := "3 to copy · 4 already on drive: A, B, C and 1 more"; m.state != transferring || m.statusMsg != want {
+		t.Errorf("Expected the transfer to start with %q, got state %v, status %q", want, m.state, m.statusMsg)
+	}
+
+	updatedModel, _ = m.Update(FileOpMsg{Operation: "sync", Msg: internal.FileOp{
+		Complete: true,
+		Progress: internal.TransferProgress{FilesDone: 3, TotalFiles: 3, Skipped: skipped},
+	}})
+	m = updatedModel.(*Model)
+	if want := "3 copied · 4 already on drive: A, B, C and 1 more"; m.state != normal || m.infoMsg != want {
+		t.Errorf("Expected the finished sync to report %q, got %q", want, m.infoMsg)
+	}
+
+	m.state = syncing
+	updatedModel, _ = m.Update(FileOpMsg{Operation: "sync", Msg: internal.FileOp{
+		Progress: internal.TransferProgress{Skipped: skipped[:1]},
+	}})
+	m = updatedModel.(*Model)
+	if want := "All 1 selected episode(s) are already on the drive: A"; m.state != normal || m.infoMsg != want {
+		t.Errorf("Expected nothing to copy to be explained, got %q", m.infoMsg)
+	}
+}
+
+func TestMissingAssets_MarksAndHidesEpisodes(t *testing.T) {
+	model := InitialModel()
+	testPodcasts := []internal.PodcastEpisode{
+		{ZTitle: "Downloaded", ShowName: "Show", FilePath: "/test/here.mp3"},
+		{ZTitle: "Deleted", ShowName: "Show", FilePath: "/test/gone.mp3", Selected: true},
+	}
+	updatedModel, _ := model.Update(MacPodcastsMsg(testPodcasts))
+	m := updatedModel.(*Model)
+
+	updatedModel, _ = m.Update(MissingAssetsMsg{"/test/gone.mp3": true})
+	m = updatedModel.(*Model)
+	if !m.podcasts[1].Missing || m.podcasts[1].Selected {
+		t.Error("Expected the deleted episode to be marked missing and deselected")
+	}
+	if !strings.Contains(m.macPodcasts.Title, "1 not downloaded") {
+		t.Errorf("Expected list title to count missing episodes, got %q", m.macPodcasts.Title)
+	}
+
+	updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("m")})
+	m = updatedModel.(*Model)
+	if items := m.macPodcasts.Items(); len(items) != 1 || items[0].(internal.PodcastEpisode).ZTitle != "Downloaded" {
+		t.Errorf("Expected m to hide the missing episode, got %v", items)
+	}
+}
+
+func TestDrivePartials_BlockSelection(t *testing.T) {
+	model := InitialModel()
+	model.history = nil
+	updatedModel, _ := model.Update(DrivePodcastsMsg{PodcastsDrive: []internal.PodcastEpisode{
+		{ZTitle: "Unfinished", ShowName: "Show", FilePath: "/Volumes/USB/podcasts/Show/Unfinished.mp3.part", Partial: true},
+	}})
+	m := asModel(updatedModel)
+	m.focusIndex = 1
+	if item := m.drivePodcasts.Items()[0].(internal.PodcastEpisode); !strings.Contains(item.Description(), "copy unfinished") {
+		t.Errorf("Expected an unfinished copy badge, got %q", item.Description())
+	}
+
+	updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+	m = asModel(updatedModel)
+	if m.podcastsDrive[0].Selected || !strings.Contains(m.errorMsg, "hasn't finished copying") {
+		t.Errorf("Expected the unfinished copy not to be selectable, got %q", m.errorMsg)
+	}
+}
+
+func TestDownloadingAssets_BlocksSelection(t *testing.T) {
+	model := InitialModel()
+	model.history = nil
+	testPodcasts := []internal.PodcastEpisode{
+		{ZTitle: "Downloading", ShowName: "Show", FilePath: "/test/partial.mp3", Selected: true},
+	}
+	updatedModel, _ := model.Update(MacPodcastsMsg(testPodcasts))
+	m := updatedModel.(*Model)
+
+	updatedModel, _ = m.Update(DownloadingAssetsMsg{"/test/partial.mp3": true})
+	m = updatedModel.(*Model)
+	if !m.podcasts[0].Downloading || m.podcasts[0].Selected {
+		t.Fatal("Expected the download to be marked and deselected")
+	}
+	if item := m.macPodcasts.Items()[0].(internal.PodcastEpisode); !strings.Contains(item.Description(), "downloading…") {
+		t.Errorf("Expected a downloading badge, got %q", item.Description())
+	}
+
+	updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+	m = updatedModel.(*Model)
+	if m.podcasts[0].Selected || !strings.Contains(m.errorMsg, "still downloading") {
+		t.Errorf("Expected the download not to be selectable, got %q", m.errorMsg)
+	}
+
+	// Once it is complete it can be selected again
+	updatedModel, _ = m.Update(DownloadingAssetsMsg{})
+	m = updatedModel.(*Model)
+	updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+	m = updatedModel.(*Model)
+	if !m.podcasts[0].Selected {
+		t.Error("Expected the finished download to be selectable")
+	}
+}
+
+func TestListSummary_TotalsSelection(t *testing.T) {
+	items := []list.Item{
+		internal.PodcastEpisode{ZTitle: "A", Duration: 2 * time.Hour, FileSize: 1024 * 1024, Selected: true},
+		internal.PodcastEpisode{ZTitle: "B", Duration: 45 * time.Minute, FileSize: 1024 * 1024, Selected: true},
+		internal.PodcastEpisode{ZTitle: "C", Duration: 10 * time.Hour},
+	}
+	if got := listSummary(items); !strings.Contains(got, "2 selected · 2 h 45 m · 2.0 MB") {
+		t.Errorf("Expected selection totals, got %q", got)
+	}
+
+	items[0] = internal.PodcastEpisode{ZTitle: "A", Duration: 2 * time.Hour}
+	items[1] = internal.PodcastEpisode{ZTitle: "B"}
+	if got := listSummary(items); !strings.Contains(got, "3 episodes · 12 h 0 m") {
+		t.Errorf("Expected list totals without a selection, got %q", got)
+	}
+}
+
+func TestHeader_ShowsSelectedListeningTime(t *testing.T) {
+	m := InitialModel()
+	m.width = 160
+	m.macPodcasts.SetItems([]list.Item{
+		internal.PodcastEpisode{ZTitle: "A", Duration: 5 * time.Hour, FileSize: 1024 * 1024, Selected: true},
+		internal.PodcastEpisode{ZTitle: "B", Duration: time.Hour},
+	})
+	m.drivePodcasts.SetItems([]list.Item{
+		internal.PodcastEpisode{ZTitle: "C", Duration: 3*time.Hour + 5*time.Minute, FileSize: 1024 * 1024, Selected: true},
+	})
+
+	if header := m.createHeader(); !strings.Contains(header, "Selected: 2 · 8 h 5 m · 2.0 MB") {
+		t.Errorf("Expected header to total the selection across both lists, got %q", header)
+	}
+
+	m.drivePodcasts.SetItems(nil)
+	m.macPodcasts.SetItems([]list.Item{internal.PodcastEpisode{ZTitle: "A", Duration: time.Hour}})
+	if header := m.createHeader(); strings.Contains(header, "Selected") {
+		t.Errorf("Expected no selection total without a selection, got %q", header)
+	}
+}
+
+func TestHeader_ProjectsFreeSpaceAfterSync(t *testing.T) {
+	m := InitialModel()
+	m.width = 200
+	m.currentDrive = internal.USBDrive{Name: "STICK", Capacity: 8 << 30, Free: 3 << 30}
+	m.podcasts = []internal.PodcastEpisode{
+		{ZTitle: "A", FileSize: 1 << 30, Selected: true},
+		{ZTitle: "B", FileSize: 2 << 30, Selected: true, OnDrive: true},
+		{ZTitle: "C", FileSize: 4 << 30},
+	}
+	setPodcastItems(&m.macPodcasts, m.podcasts)
+	if header := m.createHeader(); !strings.Contains(header, "2.0 GB free after sync") {
+		t.Errorf("Expected the free space left once the episodes not on the drive are copied, got %q", header)
+	}
+
+	m.podcasts[2].Selected = true
+	setPodcastItems(&m.macPodcasts, m.podcasts)
+	if header := m.createHeader(); !strings.Contains(header, "2.0 GB too much for STICK") {
+		t.Errorf("Expected a warning for a selection that won't fit, got %q", header)
+	}
+
+	m.currentDrive.Capacity = 0
+	if header := m.createHeader(); strings.Contains(header, "after sync") || strings.Contains(header, "too much") {
+		t.Errorf("Expected no projection while the drive's space is unknown, got %q", header)
+	}
+}
+
+func TestFavorites_StarFilterAndKeepOnDeleteAll(t *testing.T) {
+	model := InitialModel()
+	model.history = nil
+	model.config = &internal.Config{KeepFavorites: true}
+	testPodcasts := []internal.PodcastEpisode{
+		{ZTitle: "Keeper", ShowName: "Show", FilePath: "/test/keeper.mp3"},
+		{ZTitle: "Other", ShowName: "Show", FilePath: "/test/other.mp3"},
+	}
+	updatedModel, _ := model.Update(MacPodcastsMsg(testPodcasts))
+	m := updatedModel.(*Model)
+	updatedModel, _ = m.Update(DrivePodcastsMsg{PodcastsDrive: []internal.PodcastEpisode{
+		{ZTitle: "Keeper", ShowName: "Show", FilePath: "/drive/keeper.mp3"},
+		{ZTitle: "Other", ShowName: "Show", FilePath: "/drive/other.mp3"},
+	}})
+	m = updatedModel.(*Model)
+
+	updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("*")})
+	m = updatedModel.(*Model)
+	if !m.podcasts[0].Favorite || !m.podcastsDrive[0].Favorite || m.podcasts[1].Favorite {
+		t.Error("Expected starring to mark the Mac and drive copies of the episode")
+	}
+	if title := m.macPodcasts.Items()[0].(internal.PodcastEpisode).Title(); !strings.Contains(title, "★") {
+		t.Errorf("Expected the starred episode to show a star, got %q", title)
+	}
+
+	m.setMacFilter(favoritesFilter)
+	if items := m.macPodcasts.Items(); len(items) != 1 || items[0].(internal.PodcastEpisode).ZTitle != "Keeper" {
+		t.Errorf("Expected the favorites filter to show only starred episodes, got %v", items)
+	}
+
+	updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("D")})
+	m = updatedModel.(*Model)
+	if m.state != confirm || m.podcastsDrive[0].Selected || !m.podcastsDrive[1].Selected {
+		t.Errorf("Expected delete all to skip favorites, got state %v and %+v", m.state, m.podcastsDrive)
+	}
+
+	updatedModel, _ = m.Update(FavoritesMsg{})
+	m = updatedModel.(*Model)
+	if m.podcasts[0].Favorite || m.podcastsDrive[0].Favorite {
+		t.Error("Expected loaded favorites to replace the starred set")
+	}
+}
+
+func TestShowPolicy_EditAndPrune(t *testing.T) {
+	model := InitialModel()
+	model.config = &internal.Config{}
+	updatedModel, _ := model.Update(MacPodcastsMsg([]internal.PodcastEpisode{
+		{ZTitle: "New", ShowName: "News", FilePath: "/test/new.mp3"},
+	}))
+	m := updatedModel.(*Model)
+	updatedModel, _ = m.Update(DrivePodcastsMsg{PodcastsDrive: []internal.PodcastEpisode{
+		{ZTitle: "Old", ShowName: "News", FilePath: "/drive/old.mp3", Published: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{ZTitle: "Recent", ShowName: "News", FilePath: "/drive/recent.mp3", Published: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+	}})
+	m = updatedModel.(*Model)
+
+	for _, k := range []string{"o", "a", "+"} {
+		updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+		m = updatedModel.(*Model)
+	}
+	if m.state != showPolicy || !strings.Contains(m.renderShowPolicy(), "1 newest") {
+		t.Fatalf("Expected the policy panel to show the draft, got state %v", m.state)
+	}
+
+	updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updatedModel.(*Model)
+	policy := m.config.PolicyFor("News")
+	if m.state != normal || policy.Sync != internal.SyncAlways || policy.Keep != 1 {
+		t.Fatalf("Expected the policy to be saved, got state %v and %+v", m.state, policy)
+	}
+	if !m.podcasts[0].Selected {
+		t.Error("Expected an always-sync show's new episode to be auto-selected")
+	}
+
+	updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("K")})
+	m = updatedModel.(*Model)
+	if m.state != confirm || !m.podcastsDrive[0].Selected || m.podcastsDrive[1].Selected {
+		t.Errorf("Expected prune to select only the episode beyond the keep limit, got %+v", m.podcastsDrive)
+	}
+}
+
+func TestDemoMode_LoadsSyntheticLibraryAndDrive(t *testing.T) {
+	demo, err := internal.NewDemo()
+	if err != nil {
+		t.Fatalf("NewDemo failed: %v", err)
+	}
+	t.Cleanup(func() { _ = demo.Close() })
+
+	tm := teatest.NewTestModel(t, NewModel(Options{Demo: demo}), teatest.WithInitialTermSize(160, 45))
+	teatest.WaitFor(t, tm.Output(), func(out []byte) bool {
+		return bytes.Contains(out, []byte("DEMO MODE")) &&
+			bytes.Contains(out, []byte(internal.DemoDriveName)) &&
+			bytes.Contains(out, []byte("Compilers at Dawn"))
+	}, teatest.WithDuration(5*time.Second))
+
+	tm.Send(tea.KeyMsg{Type: tea.KeyCtrlC})
+	tm.WaitFinished(t, teatest.WithFinalTimeout(time.Second))
+}
+
+func TestSetPodcastItems_ReusesItemSlice(t *testing.T) {
+	l := createList("Mac Podcasts", "mac")
+	episodes := make([]internal.PodcastEpisode, 100)
+	for i := range episodes {
+		episodes[i] = internal.PodcastEpisode{ZTitle: fmt.Sprintf("Episode %d", i), ShowName: "Show"}
+	}
+
+	setPodcastItems(&l, episodes)
+	first := &l.Items()[0]
+
+	setPodcastItems(&l, episodes[:10])
+	items := l.Items()
+	if len(items) != 10 {
+		t.Fatalf("got %d items, want 10", len(items))
+	}
+	if &items[0] != first {
+		t.Error("refreshing with fewer episodes should reuse the item slice")
+	}
+	if tail := items[:cap(items)][10]; tail != nil {
+		t.Errorf("stale item %v kept alive past the end of the list", tail)
+	}
+	if ep := items[9].(internal.PodcastEpisode); ep.ZTitle != "Episode 9" {
+		t.Errorf("items[9] = %q, want Episode 9", ep.ZTitle)
+	}
+}
+
+// BenchmarkRefreshMacItems measures refreshing the Mac list of a 20k-episode library
+func BenchmarkRefreshMacItems(b *testing.B) {
+	m := InitialModel()
+	m.podcasts = make([]internal.PodcastEpisode, 20000)
+	for i := range m.podcasts {
+		m.podcasts[i] = internal.PodcastEpisode{ZTitle: fmt.Sprintf("Episode %d", i), ShowName: "Show", FilePath: fmt.Sprintf("file:///library/%d.mp3", i)}
+	}
+	b.ReportAllocs()
+	for b.Loop() {
+		m.refreshMacItems()
+	}
+}
+
+func TestDriveBenchmark_StoresSpeedInProfile(t *testing.T) {
+	model := InitialModel()
+	model.config = &internal.Config{}
+	model.loading.macPodcasts = false
+	updatedModel, _ := model.Update(DriveUpdatedMsg{
+		{Name: "STICK", MountPath: "/Volumes/STICK"},
+		{Name: "CAR", MountPath: "/Volumes/CAR"},
+	})
+	m := updatedModel.(*Model)
+	sized, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	resized := sized.(Model)
+	m = &resized
+	m.state = driveSelection
+
+	updatedModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("b")})
+	m = updatedModel.(*Model)
+	if cmd == nil || m.benchmarking == "" {
+		t.Fatal("Expected b to start measuring the drive under the cursor")
+	}
+	if !strings.Contains(m.View(), "Measuring "+m.benchmarking) {
+		t.Error("Expected the drive selector to show the running benchmark")
+	}
+	if _, again := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("b")}); again != nil {
+		t.Error("Expected no second benchmark while one is running")
+	}
+
+	speed := internal.DriveSpeed{Write: 21.4 * 1024 * 1024, Read: 38 * 1024 * 1024, MeasuredAt: time.Now()}
+	updatedModel, cmd = m.Update(DriveSpeedMsg{Drive: m.benchmarking, Speed: speed})
+	m = updatedModel.(*Model)
+	if cmd == nil {
+		t.Error("Expected the measured speed to be saved to the config")
+	}
+	if m.benchmarking != "" || m.config.ProfileFor(m.currentDrive.Name).Speed != speed {
+		t.Errorf("Expected the speed in the profile of %s, got %+v", m.currentDrive.Name, m.config.Drives)
+	}
+	if m.currentDrive.Profile.Speed != speed {
+		t.Error("Expected the current drive to seed ETAs with the measured speed")
+	}
+	if !strings.Contains(m.View(), "write 21.4 MB/s") {
+		t.Error("Expected the drive selector to show the measured speed")
+	}
+
+	updatedModel, _ = m.Update(DriveSpeedMsg{Drive: "CAR", Err: errors.New("read-only file system")})
+	m = updatedModel.(*Model)
+	if !strings.Contains(m.errorMsg, "failed to benchmark CAR") {
+		t.Errorf("Expected a benchmark error to be reported, got %q", m.errorMsg)
+	}
+}
+
+func TestQueueBuilder_ReorderAndSave(t *testing.T) {
+	model := InitialModel()
+	model.config = &internal.Config{}
+	model.currentDrive = internal.USBDrive{Name: "STICK", MountPath: "/Volumes/STICK"}
+	model.drives = []internal.USBDrive{model.currentDrive}
+	updatedModel, _ := model.Update(MacPodcastsMsg([]internal.PodcastEpisode{
+		{ZTitle: "Second", ShowName: "News", FilePath: "/test/second.mp3", Published: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{ZTitle: "Ignored", ShowName: "News", FilePath: "/test/ignored.mp3"},
+	}))
+	m := updatedModel.(*Model)
+	m.podcasts[0].Selected = true
+	updatedModel, _ = m.Update(DrivePodcastsMsg{PodcastsDrive: []internal.PodcastEpisode{
+		{ZTitle: "First", ShowName: "Serial", FilePath: "/drive/first.mp3", OnDrive: true, Published: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}})
+	m = updatedModel.(*Model)
+
+	updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("u")})
+	m = updatedModel.(*Model)
+	if m.state != queueBuilder || len(m.queue) != 2 || m.queue[0].ZTitle != "First" {
+		t.Fatalf("Expected the drive episode then the selection oldest first, got state %v and %+v", m.state, m.queue)
+	}
+	if view := m.renderQueueBuilder(); !strings.Contains(view, "News - Second (new)") {
+		t.Errorf("Expected episodes still to be synced to be marked, got:\n%s", view)
+	}
+
+	for _, k := range []string{"j", "K"} {
+		updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+		m = updatedModel.(*Model)
+	}
+	if m.queueCursor != 0 || m.queue[0].ZTitle != "Second" {
+		t.Fatalf("Expected the episode to move up with the cursor, got cursor %d and %+v", m.queueCursor, m.queue)
+	}
+
+	updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updatedModel.(*Model)
+	profile := m.config.ProfileFor("STICK")
+	want := []string{"News/2024-02-01 - Second.mp3", "Serial/2024-01-01 - First.mp3"}
+	if m.state != normal || profile.Playlist != internal.PlaylistCustom || !slices.Equal(profile.CustomOrder, want) {
+		t.Fatalf("Expected the order to be saved as the custom playlist, got state %v and %+v", m.state, profile)
+	}
+	if !slices.Equal(m.currentDrive.Profile.CustomOrder, want) || !slices.Equal(m.drives[0].Profile.CustomOrder, want) {
+		t.Error("Expected the current drive to sync with the saved order")
+	}
+}
+
+func TestPathPreview_ShowsDestinationsThenSyncs(t *testing.T) {
+	model := InitialModel()
+	model.history = nil
+	model.currentDrive = internal.USBDrive{Name: "STICK", MountPath: t.TempDir()}
+	updatedModel, _ := model.Update(MacPodcastsMsg([]internal.PodcastEpisode{
+		{ZTitle: "Q&A", ShowName: "News", FilePath: "/test/qa.mp3", Published: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+	}))
+	m := updatedModel.(*Model)
+	m.podcasts[0].Selected = true
+
+	updatedModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("P")})
+	m = updatedModel.(*Model)
+	if cmd == nil {
+		t.Fatal("Expected a command working out the destinations")
+	}
+	updatedModel, _ = m.Update(cmd())
+	m = updatedModel.(*Model)
+	view := m.renderPathPreview()
+	if m.state != pathPreview || !strings.Contains(view, "2024-02-01 - QandA.mp3") || !strings.Contains(view, "1 renamed") {
+		t.Fatalf("Expected the preview to list the renamed destination, got state %v:\n%s", m.state, view)
+	}
+	if !strings.Contains(view, "Syncing should take under a minute") {
+		t.Errorf("Expected the preview to estimate the sync, got:\n%s", view)
+	}
+
+	updatedModel, cmd = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updatedModel.(*Model)
+	if m.state != syncing || cmd == nil {
+		t.Errorf("Expected enter to start syncing the selection, got state %v", m.state)
+	}
+}
+
+func TestSync_ConfirmsBeforeCopying(t *testing.T) {
+	mount := t.TempDir()
+	synced := filepath.Join(mount, "podcasts", "News", "2024-02-01 - Synced.mp3")
+	if err := os.MkdirAll(filepath.Dir(synced), 0o755); err != nil {
+		t.Fatalf("Failed to create show folder: %v", err)
+	}
+	if err := os.WriteFile(synced, make([]byte, 1<<20), 0o644); err != nil {
+		t.Fatalf("Failed to write episode: %v", err)
+	}
+	model := InitialModel()
+	model.history = nil
+	model.currentDrive = internal.USBDrive{Name: "STICK", MountPath: mount, Folder: "podcasts"}
+	published := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	updatedModel, _ := model.Update(MacPodcastsMsg([]internal.PodcastEpisode{
+		{ZTitle: "New", ShowName: "News", FilePath: "/test/new.mp3", FileSize: 2 << 20, Published: published, Selected: true},
+		{ZTitle: "Synced", ShowName: "News", FilePath: "/test/synced.mp3", FileSize: 1 << 20, Published: published, Selected: true},
+	}))
+	m := asModel(updatedModel)
+
+	updatedModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'s'}})
+	m = asModel(updatedModel)
+	if m.state != normal || cmd == nil {
+		t.Fatalf("Expected s to work out the confirmation instead of syncing, got state %v", m.state)
+	}
+	updatedModel, _ = m.Update(cmd())
+	m = asModel(updatedModel)
+	view := m.renderPathPreview()
+	for _, want := range []string{"Sync 2 episode(s) to STICK?", "1 to copy (2.0 MB)", "1 skipped, already on drive (1.0 MB)", "skipped, on drive"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("Expected the confirmation to show %q, got:\n%s", want, view)
+		}
+	}
+
+	updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = asModel(updatedModel)
+	if m.state != normal || len(selectedEpisodes(m.podcasts)) != 2 {
+		t.Errorf("Expected esc to go back with the selection kept, got state %v", m.state)
+	}
+}
+
+func TestTransferQueue_FollowsCopyUntilScrolled(t *testing.T) {
+	model := InitialModel()
+	model.history = nil
+	var library []internal.PodcastEpisode
+	for i := range 12 {
+		state := internal.FileQueued
+		switch {
+		case i < 5:
+			state = internal.FileDone
+		case i == 5:
+			state = internal.FileCopying
+		}
+		library = append(library, internal.PodcastEpisode{
+			ZTitle: fmt.Sprintf("Episode %d", i), ShowName: "Show", FilePath: fmt.Sprintf("/test/%d.mp3", i), TransferState: state,
+		})
+	}
+	updatedModel, _ := model.Update(MacPodcastsMsg(library))
+	m := asModel(updatedModel)
+	m.state = transferring
+
+	view := m.renderTransferQueue(80)
+	if !strings.Contains(view, "5 done · 1 copying · 6 queued") || !strings.Contains(view, "5–12 of 12") {
+		t.Fatalf("Expected the queue to follow the copy, got:\n%s", view)
+	}
+
+	for range 3 {
+		updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyUp})
+		m = asModel(updatedModel)
+	}
+	if view := m.renderTransferQueue(80); !strings.Contains(view, "2–9 of 12") || !strings.Contains(view, "Episode 1") {
+		t.Errorf("Expected up to scroll the queue back, got:\n%s", view)
+	}
+	if m.macPodcasts.Index() != 0 {
+		t.Errorf("Expected the Mac list cursor to stay put, got %d", m.macPodcasts.Index())
+	}
+
+	m.resetTransferStates()
+	if m.transferQueueScrolled || m.transferQueueOffset != 0 {
+		t.Error("Expected the queue to follow the copy again in the next sync")
+	}
+}
+
+func TestSyncAll_ConfirmsBeforeCopying(t *testing.T) {
+	model := InitialModel()
+	model.history = nil
+	updatedModel, _ := model.Update(MacPodcastsMsg([]internal.PodcastEpisode{
+		{ZTitle: "One", ShowName: "News", FilePath: "/test/one.mp3", FileSize: 1 << 20},
+		{ZTitle: "Two", ShowName: "News", FilePath: "/test/two.mp3", FileSize: 1 << 20},
+	}))
+	m := asModel(updatedModel)
+
+	// Without a drive the sync waits for one instead of starting
+	updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'S'}})
+	m = asModel(updatedModel)
+	if m.state != normal || len(m.queuedSync) != 2 {
+		t.Fatalf("Expected sync all to be queued without a drive, got state %v and %d queued", m.state, len(m.queuedSync))
+	}
+	m.queuedSync = nil
+
+	m.currentDrive = internal.USBDrive{Name: "STICK", MountPath: t.TempDir(), Folder: "podcasts"}
+	updatedModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'S'}})
+	m = asModel(updatedModel)
+	if m.state != normal || cmd == nil {
+		t.Fatalf("Expected sync all to work out the confirmation instead of syncing, got state %v", m.state)
+	}
+	updatedModel, _ = m.Update(cmd())
+	m = asModel(updatedModel)
+	if m.state != pathPreview || !m.previewConfirm || !strings.Contains(m.renderPathPreview(), "Sync 2 episode(s) to STICK?") {
+		t.Errorf("Expected the confirmation for both episodes, got state %v", m.state)
+	}
+}
+
+func TestUndo_RestoresLastCleanup(t *testing.T) {
+	mount := t.TempDir()
+	path := filepath.Join(mount, "Show", "Episode.mp3")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("Failed to create show folder: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("audio"), 0o644); err != nil {
+		t.Fatalf("Failed to write episode: %v", err)
+	}
+	manifest, _ := internal.LoadManifest(mount)
+	manifest.Set(path, internal.ManifestEntry{Show: "Show", Title: "Episode"})
+	if err := manifest.Save(); err != nil {
+		t.Fatalf("Failed to save manifest: %v", err)
+	}
+	drive := internal.USBDrive{Name: "STICK", MountPath: mount}
+	syncer := internal.NewPodcastSync()
+	syncer.SetDrive(drive)
+	if op := syncer.DeleteSelected([]internal.PodcastEpisode{{FilePath: path, Selected: true}}); op.Error != nil {
+		t.Fatalf("DeleteSelected failed: %v", op.Error)
+	}
+
+	model := InitialModel()
+	model.currentDrive = drive
+	updatedModel, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("z")})
+	m := updatedModel.(*Model)
+	if cmd == nil {
+		t.Fatal("Expected a command loading the undo journal")
+	}
+	updatedModel, _ = m.Update(cmd())
+	m = updatedModel.(*Model)
+	if view := m.renderUndoLog(); m.state != undoLog || !strings.Contains(view, "removed 1 file(s)") {
+		t.Fatalf("Expected the undo popup to offer the cleanup, got state %v:\n%s", m.state, view)
+	}
+
+	updatedModel, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s")})
+	m = updatedModel.(*Model)
+	if m.state != undoLog || cmd != nil {
+		t.Fatalf("Expected undoing a sync that was never journaled to do nothing, got state %v", m.state)
+	}
+
+	updatedModel, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("c")})
+	m = updatedModel.(*Model)
+	if cmd == nil {
+		t.Fatal("Expected a command undoing the cleanup")
+	}
+	updatedModel, _ = m.Update(cmd())
+	m = updatedModel.(*Model)
+	if m.infoMsg != "Undid the last cleanup: 1 file(s)" {
+		t.Errorf("Expected a note about the undone cleanup, got %q", m.infoMsg)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("Expected the episode to be back on the drive: %v", err)
+	}
+}
+
+func TestRepeatLastSync_SelectsNewEpisodesOfSameShows(t *testing.T) {
+	model := InitialModel()
+	model.history = nil
+	model.currentDrive = internal.USBDrive{Name: "STICK", MountPath: t.TempDir()}
+	updatedModel, _ := model.Update(MacPodcastsMsg([]internal.PodcastEpisode{
+		{ZTitle: "New", ShowName: "Weekly", FilePath: "/test/new.mp3"},
+		{ZTitle: "Old", ShowName: "Weekly", FilePath: "/test/old.mp3", OnDrive: true},
+		{ZTitle: "Other", ShowName: "Daily", FilePath: "/test/other.mp3", Selected: true},
+	}))
+	m := updatedModel.(*Model)
+
+	updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(".")})
+	m = updatedModel.(*Model)
+	if m.infoMsg != "No sync to repeat yet" {
+		t.Fatalf("Expected a note that there is nothing to repeat, got %q", m.infoMsg)
+	}
+
+	updatedModel, _ = m.Update(ActionsMsg{internal.ActionSync: {Kind: internal.ActionSync, Drive: "OTHER", Shows: []string{"Weekly"}}})
+	m = updatedModel.(*Model)
+	updatedModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(".")})
+	m = updatedModel.(*Model)
+	if m.state != normal || cmd != nil || !strings.Contains(m.errorMsg, "OTHER") {
+		t.Fatalf("Expected repeating a sync to another drive to be refused, got state %v, %q", m.state, m.errorMsg)
+	}
+
+	m.lastActions[internal.ActionSync] = internal.Action{Kind: internal.ActionSync, Drive: "STICK", Shows: []string{"Weekly"}}
+	updatedModel, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(".")})
+	m = updatedModel.(*Model)
+	if m.state != syncing || cmd == nil {
+		t.Fatalf("Expected the repeat to start a sync, got state %v, %q", m.state, m.errorMsg)
+	}
+	var selected []string
+	for _, p := range m.podcasts {
+		if p.Selected {
+			selected = append(selected, p.ZTitle)
+		}
+	}
+	if !slices.Equal(selected, []string{"New"}) {
+		t.Errorf("Expected only the new episode of the repeated show to be selected, got %v", selected)
+	}
+	if last := m.lastActions[internal.ActionSync]; last.Episodes != 1 || !slices.Equal(last.Shows, []string{"Weekly"}) {
+		t.Errorf("Expected the repeat to become the last sync, got %+v", last)
+	}
+}
+
+func TestRename_RenamesDriveEpisode(t *testing.T) {
+	mount := t.TempDir()
+	path := filepath.Join(mount, "Show", "Old.mp3")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("Failed to create show folder: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("audio"), 0o644); err != nil {
+		t.Fatalf("Failed to write episode: %v", err)
+	}
+
+	model := InitialModel()
+	model.configPath = filepath.Join(t.TempDir(), "config.json")
+	model.currentDrive = internal.USBDrive{Name: "STICK", MountPath: mount}
+	model.focusIndex = 1
+	updatedModel, _ := model.Update(DrivePodcastsMsg{PodcastsDrive: []internal.PodcastEpisode{{ZTitle: "Old", ShowName: "Show", FilePath: path}}})
+	m := updatedModel.(*Model)
+
+	updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("e")})
+	m = updatedModel.(*Model)
+	if m.state != renaming || m.renameInput.Value() != "Old" {
+		t.Fatalf("Expected the rename popup prefilled with the file name, got state %v, %q", m.state, m.renameInput.Value())
+	}
+	m.renameInput.SetValue("New")
+	updatedModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updatedModel.(*Model)
+	if m.state != normal || cmd == nil {
+		t.Fatalf("Expected enter to rename, got state %v", m.state)
+	}
+	updatedModel, _ = m.Update(cmd())
+	m = updatedModel.(*Model)
+	if m.infoMsg != "Renamed Show/Old.mp3 to Show/New.mp3" {
+		t.Errorf("Expected a note about the rename, got %q", m.infoMsg)
+	}
+	if _, err := os.Stat(filepath.Join(mount, "Show", "New.mp3")); err != nil {
+		t.Errorf("Expected the episode to be renamed on the drive: %v", err)
+	}
+}
+
+func TestPin_KeepsEpisodeOnDeleteAll(t *testing.T) {
+	mount := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(mount, "Show"), 0o755); err != nil {
+		t.Fatalf("Failed to create show folder: %v", err)
+	}
+	model := InitialModel()
+	model.history = nil
+	model.config = &internal.Config{}
+	model.currentDrive = internal.USBDrive{Name: "STICK", MountPath: mount}
+	model.focusIndex = 1
+	updatedModel, _ := model.Update(DrivePodcastsMsg{PodcastsDrive: []internal.PodcastEpisode{
+		{ZTitle: "Reference", ShowName: "Show", FilePath: filepath.Join(mount, "Show", "reference.mp3")},
+		{ZTitle: "Other", ShowName: "Show", FilePath: filepath.Join(mount, "Show", "other.mp3")},
+	}})
+	m := updatedModel.(*Model)
+
+	updatedModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("!")})
+	m = updatedModel.(*Model)
+	if !m.podcastsDrive[0].Pinned || cmd == nil {
+		t.Fatal("Expected ! to pin the episode under the cursor")
+	}
+	if item := m.drivePodcasts.Items()[0].(internal.PodcastEpisode); !strings.HasPrefix(item.Title(), "⚑ ") {
+		t.Errorf("Expected the pin glyph in the drive list, got %q", item.Title())
+	}
+	if msg := cmd(); msg != nil {
+		t.Fatalf("Expected the pin to be saved, got %v", msg)
+	}
+	manifest, _ := internal.LoadManifest(mount)
+	if entry, ok := manifest.Entry(m.podcastsDrive[0].FilePath); !ok || !entry.Pinned {
+		t.Errorf("Expected the pin in the drive manifest, got %+v", manifest.Entries)
+	}
+
+	updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("D")})
+	m = updatedModel.(*Model)
+	if m.state != confirm || m.podcastsDrive[0].Selected || !m.podcastsDrive[1].Selected {
+		t.Errorf("Expected delete all to skip the pinned episode, got state %v and %+v", m.state, m.podcastsDrive)
+	}
+}
+
+func TestDeleteAll_LeavesPartialCopies(t *testing.T) {
+	model := NewModel(Options{})
+	model.history = nil
+	model.config = &internal.Config{}
+	model.currentDrive = internal.USBDrive{Name: "STICK", MountPath: t.TempDir()}
+	model.focusIndex = 1
+	updatedModel, _ := model.Update(DrivePodcastsMsg{PodcastsDrive: []internal.PodcastEpisode{
+		{ZTitle: "Done", ShowName: "Show", FilePath: "/drive/Show/done.mp3"},
+		{ZTitle: "Unfinished", ShowName: "Show", FilePath: "/drive/Show/unfinished.mp3.part", Partial: true},
+	}})
+	m := updatedModel.(*Model)
+
+	updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("D")})
+	m = updatedModel.(*Model)
+	if m.state != confirm || !m.podcastsDrive[0].Selected || m.podcastsDrive[1].Selected {
+		t.Errorf("Expected delete all to leave the unfinished copy for resuming, got state %v and %+v", m.state, m.podcastsDrive)
+	}
+}
+
+func TestSafeMode_TypedConfirmForDeleteAll(t *testing.T) {
+	mount := t.TempDir()
+	path := filepath.Join(mount, "Show", "episode.mp3")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("Failed to create show folder: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("audio"), 0o644); err != nil {
+		t.Fatalf("Failed to write episode: %v", err)
+	}
+	model := InitialModel()
+	model.history = nil
+	model.config = &internal.Config{SafeMode: true}
+	model.currentDrive = internal.USBDrive{Name: "STICK", MountPath: mount}
+	model.focusIndex = 1
+	updatedModel, _ := model.Update(DrivePodcastsMsg{PodcastsDrive: []internal.PodcastEpisode{{ZTitle: "Episode", ShowName: "Show", FilePath: path}}})
+	m := updatedModel.(*Model)
+
+	updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("D")})
+	m = updatedModel.(*Model)
+	if m.state != typedConfirm {
+		t.Fatalf("Expected delete all to ask for the drive's name, got state %v", m.state)
+	}
+
+	// y is typed rather than confirming, and enter waits for the name
+	for _, r := range "y" {
+		updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		m = updatedModel.(*Model)
+	}
+	updatedModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updatedModel.(*Model)
+	if m.state != typedConfirm || cmd != nil {
+		t.Fatalf("Expected enter to wait for the drive's name, got state %v", m.state)
+	}
+
+	m.confirmInput.SetValue("STICK")
+	updatedModel, cmd = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updatedModel.(*Model)
+	if m.state != normal || cmd == nil {
+		t.Fatalf("Expected the typed name to confirm the delete, got state %v", m.state)
+	}
+	if batch, ok := cmd().(tea.BatchMsg); ok {
+		for _, c := range batch {
+			if c != nil {
+				c()
+			}
+		}
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected the episode to be deleted, got %v", err)
+	}
+}
+
+func TestReadOnly_RefusesWrites(t *testing.T) {
+	mount := t.TempDir()
+	path := filepath.Join(mount, "Show", "episode.mp3")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("Failed to create show folder: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("audio"), 0o644); err != nil {
+		t.Fatalf("Failed to write episode: %v", err)
+	}
+	model := NewModel(Options{ReadOnly: true})
+	model.history = nil
+	model.config = &internal.Config{}
+	model.currentDrive = internal.USBDrive{Name: "STICK", MountPath: mount}
+	model.focusIndex = 1
+	updatedModel, _ := model.Update(DrivePodcastsMsg{PodcastsDrive: []internal.PodcastEpisode{{ZTitle: "Episode", ShowName: "Show", FilePath: path}}})
+	m := updatedModel.(*Model)
+
+	for _, k := range []string{"D", "s", "S", "K", "e", "!", "z", "*"} {
+		updatedModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+		m = updatedModel.(*Model)
+		if m.state != normal || cmd != nil {
+			t.Errorf("Expected %s to be refused, got state %v", k, m.state)
+		}
+	}
+	if !strings.Contains(m.errorMsg, "Read-only") {
+		t.Errorf("Expected a note about read-only mode, got %q", m.errorMsg)
+	}
+
+	// The path preview can be opened but not used to sync
+	m.podcasts = []internal.PodcastEpisode{{ZTitle: "New", ShowName: "Show", FilePath: "/library/new.mp3", Selected: true}}
+	updatedModel, _ = m.Update(PathPreviewMsg{Previews: []internal.DestinationPreview{{Episode: m.podcasts[0], Path: "Show/New.mp3"}}})
+	m = updatedModel.(*Model)
+	if m.state != pathPreview {
+		t.Fatalf("Expected the path preview to open, got state %v", m.state)
+	}
+	if view := m.renderPathPreview(); strings.Contains(view, "enter") {
+		t.Error("Expected the preview help to leave out syncing")
+	}
+	for _, k := range []tea.KeyMsg{{Type: tea.KeyEnter}, {Type: tea.KeyRunes, Runes: []rune("s")}} {
+		updatedModel, cmd := m.Update(k)
+		m = updatedModel.(*Model)
+		if m.state != pathPreview || cmd != nil {
+			t.Errorf("Expected %s in the preview to be refused, got state %v", k, m.state)
+		}
+	}
+	m.state = normal
+
+	// Browsing still works
+	updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("H")})
+	m = updatedModel.(*Model)
+	if m.state != quickLists {
+		t.Errorf("Expected quick lists to open, got state %v", m.state)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("Expected the episode to stay on the drive: %v", err)
+	}
+}
+
+func TestBackground_Toggle(t *testing.T) {
+	t.Cleanup(func() { _ = internal.SetBackground(false) })
+	model := NewModel(Options{})
+	model.history = nil
+	model.config = &internal.Config{}
+
+	updatedModel, _ := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("N")})
+	m := updatedModel.(*Model)
+	if !internal.Background() || !strings.Contains(m.statusMsg, "Background priority on") {
+		t.Errorf("Expected background priority on, got %q", m.statusMsg)
+	}
+	if !strings.Contains(m.formatDebugInfo(), "BACKGROUND") {
+		t.Error("Expected the header to show background priority")
+	}
+
+	updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("N")})
+	m = updatedModel.(*Model)
+	if internal.Background() || !strings.Contains(m.statusMsg, "off") {
+		t.Errorf("Expected background priority off, got %q", m.statusMsg)
+	}
+}
+
+func TestDriveDetails_ShowsHealthWarnings(t *testing.T) {
+	model := NewModel(Options{})
+	model.history = nil
+	model.config = &internal.Config{}
+	drive := internal.USBDrive{Name: "STICK", MountPath: t.TempDir()}
+	model.currentDrive = drive
+	model.state = driveSelection
+	failing := internal.DriveHealth{Device: "disk4s1", Protocol: "USB", SMART: "Failing", FileSystemState: internal.FileSystemDirty}
+
+	updatedModel, _ := model.Update(DriveHealthMsg{Drive: drive, Health: failing, Show: true})
+	m := updatedModel.(*Model)
+	if m.state != driveDetails {
+		t.Fatalf("Expected the details popup, got state %v", m.state)
+	}
+	m.width, m.height = 160, 40
+	view := m.renderDriveDetails()
+	for _, want := range []string{"Connection", "USB", "SMART reports the drive is failing", "wasn't cleanly unmounted"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("Expected %q in the details popup", want)
+		}
+	}
+
+	updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = updatedModel.(*Model)
+	if m.state != driveSelection {
+		t.Errorf("Expected esc to go back to the drive selector, got state %v", m.state)
+	}
+
+	// Picking a failing drive warns without opening the popup
+	m.state = normal
+	updatedModel, _ = m.Update(DriveHealthMsg{Drive: drive, Health: failing})
+	m = updatedModel.(*Model)
+	if m.state != normal || !strings.Contains(m.errorMsg, "failing") {
+		t.Errorf("Expected a warning for the picked drive, got state %v and %q", m.state, m.errorMsg)
+	}
+}
+
+func TestIOErrors_OfferFirstAid(t *testing.T) {
+	model := NewModel(Options{})
+	model.history = nil
+	model.config = &internal.Config{}
+	model.configPath = filepath.Join(t.TempDir(), "config.json")
+	drive := internal.USBDrive{Name: "STICK", MountPath: t.TempDir()}
+	model.currentDrive = drive
+	model.drives = []internal.USBDrive{drive}
+	ioErr := ErrMsg{err: fmt.Errorf("failed to copy episode: %w", &fs.PathError{Op: "write", Path: "a.mp3", Err: syscall.EIO})}
+
+	updatedModel, _ := model.Update(ioErr)
+	m := updatedModel.(*Model)
+	if m.state != normal || m.drives[0].Profile.IOErrors.Count != 1 {
+		t.Fatalf("Expected one I/O error to be recorded without a prompt, got state %v and %+v", m.state, m.drives[0].Profile.IOErrors)
+	}
+	if !strings.Contains(m.drives[0].Description(), "1 I/O error") {
+		t.Errorf("Expected the drive selector to flag the drive, got %q", m.drives[0].Description())
+	}
+
+	updatedModel, _ = m.Update(ioErr)
+	m = updatedModel.(*Model)
+	if m.state != firstAid {
+		t.Fatalf("Expected repeated I/O errors to offer First Aid, got state %v", m.state)
+	}
+	m.width, m.height = 160, 40
+	if view := m.renderFirstAid(); !strings.Contains(view, "2 I/O errors") {
+		t.Errorf("Expected the prompt to count the errors, got %q", view)
+	}
+	updatedModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+	m = updatedModel.(*Model)
+	if m.state != normal || cmd == nil {
+		t.Fatalf("Expected r to run First Aid, got state %v", m.state)
+	}
+
+	updatedModel, _ = m.Update(FirstAidMsg{Drive: "STICK", Result: "Finished file system repair"})
+	m = updatedModel.(*Model)
+	if n := m.config.Drives["STICK"].IOErrors.Count; n != 0 || m.currentDrive.Profile.IOErrors.Count != 0 {
+		t.Errorf("Expected First Aid to clear the drive's errors, got %d", n)
+	}
+
+	updatedModel, _ = m.Update(FirstAidMsg{Drive: "STICK", Err: errors.New("exit status 1")})
+	m = updatedModel.(*Model)
+	if !strings.Contains(m.errorMsg, "Disk Utility") {
+		t.Errorf("Expected instructions to repair the drive by hand, got %q", m.errorMsg)
+	}
+}
+
+// panicModel panics when it receives its own message
+type panicModel struct{}
+
+type panicMsg struct{}
+
+func (panicModel) Init() tea.Cmd {
+	return tea.Batch(nil, func() tea.Msg { return panicMsg{} })
+}
+
+func (m panicModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if _, ok := msg.(panicMsg); ok {
+		panic("boom")
+	}
+	return m, nil
+}
+
+func (panicModel) View() string { return "" }
+
+func TestRunGuarded_ReportsPanics(t *testing.T) {
+	var crashes []Crash
+	_, err := RunGuarded(panicModel{}, func(c Crash) { crashes = append(crashes, c) },
+		tea.WithInput(nil), tea.WithOutput(io.Discard))
+	if !errors.Is(err, tea.ErrProgramPanic) {
+		t.Errorf("Expected ErrProgramPanic, got %v", err)
+	}
+	if len(crashes) != 1 || crashes[0].Value != "boom" || len(crashes[0].Stack) == 0 {
+		t.Errorf("Expected the panic reported once with its stack, got %+v", crashes)
+	}
+}
+
+func TestGuardCmd_ReportsPanicsInBatches(t *testing.T) {
+	var caught any
+	cmd := guardCmd(tea.Batch(
+		func() tea.Msg { return nil },
+		func() tea.Msg { panic("in a batch") },
+	), func(r any) { caught = r })
+
+	batch, ok := cmd().(tea.BatchMsg)
+	if !ok || len(batch) != 2 {
+		t.Fatalf("Expected the batch passed through, got %#v", batch)
+	}
+	for _, inner := range batch {
+		inner()
+	}
+	if caught != "in a batch" {
+		t.Errorf("Expected the panic in the batch reported, got %v", caught)
+	}
+}
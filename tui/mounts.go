@@ -0,0 +1,64 @@
+package tui
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/joncrangle/podcasts-sync/internal"
+)
+
+const (
+	// drivePollInterval is how often drives are looked for when mounts can't be watched
+	drivePollInterval = 5 * time.Second
+	// drivePollBackstop is how often they're looked for while mounts are watched, which still finds
+	// adb devices, since they aren't mounted, and refreshes the drives' free space
+	drivePollBackstop = 30 * time.Second
+)
+
+// MountsChangedMsg reports that a drive was mounted or unmounted, or why mounts can't be watched anymore
+type MountsChangedMsg struct {
+	err error
+}
+
+// waitForMounts waits for the next drive to be mounted or unmounted
+func waitForMounts(mounts *internal.MountWatcher) tea.Cmd {
+	if mounts == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		return MountsChangedMsg{err: mounts.Wait()}
+	}
+}
+
+// drivePoll schedules the next look for drives, sparingly while mounts are watched
+func (m Model) drivePoll() tea.Cmd {
+	if m.mounts != nil {
+		return pollDrivesCmd(drivePollBackstop)
+	}
+	return pollDrivesCmd(drivePollInterval)
+}
+
+// handleDrivesPoll looks for drives. The first poll starts watching mounts, after which polls are rare.
+func (m *Model) handleDrivesPoll() (tea.Model, tea.Cmd) {
+	var watch tea.Cmd
+	if !m.mountsWatched {
+		m.mountsWatched = true
+		// Without mount notifications drives are polled for instead
+		m.mounts, _ = m.driveManager.WatchMounts()
+		watch = waitForMounts(m.mounts)
+	}
+	return m, tea.Batch(getDrives(m.driveManager), watch, m.drivePoll())
+}
+
+// handleMountsChanged looks for drives as soon as one is mounted or unmounted; if the watcher fails,
+// polling takes over from the next poll
+func (m *Model) handleMountsChanged(msg MountsChangedMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		m.logDebug(internal.Debug{DTitle: "Mounts", DDescription: "polling for drives: " + msg.err.Error(), Level: internal.DebugWarn})
+		_ = m.mounts.Close()
+		m.mounts = nil
+		return m, nil
+	}
+	return m, tea.Batch(getDrives(m.driveManager), waitForMounts(m.mounts))
+}
@@ -0,0 +1,123 @@
+package tui
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/joncrangle/podcasts-sync/internal"
+)
+
+// syncModes is the order the policy panel cycles through sync modes
+var syncModes = []internal.SyncMode{internal.SyncManual, internal.SyncAlways, internal.SyncNever}
+
+// openShowPolicy starts editing the policy of the show under the cursor of the focused list
+func (m *Model) openShowPolicy() (tea.Model, tea.Cmd) {
+	current := m.macPodcasts.SelectedItem()
+	if m.focusIndex == 1 {
+		current = m.drivePodcasts.SelectedItem()
+	}
+	episode, ok := current.(internal.PodcastEpisode)
+	if !ok || episode.ShowName == "" {
+		return m, nil
+	}
+
+	m.policyShow = episode.ShowName
+	m.policyDraft = m.config.PolicyFor(episode.ShowName)
+	m.state = showPolicy
+	return m, nil
+}
+
+func (m *Model) handleShowPolicyKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case msg.Type == tea.KeyCtrlC:
+		return m, tea.Quit
+	case key.Matches(msg, m.policyKeys.Mode):
+		m.policyDraft.Sync = next(syncModes, m.policyDraft.Sync)
+	case key.Matches(msg, m.policyKeys.More):
+		m.policyDraft.Keep++
+	case key.Matches(msg, m.policyKeys.Less):
+		m.policyDraft.Keep = max(0, m.policyDraft.Keep-1)
+	case key.Matches(msg, m.policyKeys.Save):
+		m.state = normal
+		m.config.SetPolicy(m.policyShow, m.policyDraft)
+		m.applyShowPolicies()
+		return m, saveConfig(m.config, m.configPath)
+	case key.Matches(msg, m.policyKeys.Close):
+		m.state = normal
+	}
+	return m, nil
+}
+
+// next returns the value after current in values, wrapping around; unknown values restart the cycle
+func next[T comparable](values []T, current T) T {
+	return values[(slices.Index(values, current)+1)%len(values)]
+}
+
+// applyShowPolicies re-runs auto-selection on the library after the policies or the library changed
+func (m *Model) applyShowPolicies() {
+	internal.AutoSelect(m.podcasts, m.config)
+	m.refreshMacItems()
+}
+
+// pruneToKeepLimits selects the drive episodes beyond their show's keep limit and asks to delete them
+func (m *Model) pruneToKeepLimits() (tea.Model, tea.Cmd) {
+	excess := internal.RetentionExcess(m.podcastsDrive, m.config)
+	if len(excess) == 0 {
+		return m, nil
+	}
+
+	for i := range m.podcastsDrive {
+		m.podcastsDrive[i].Selected = false
+	}
+	for _, i := range excess {
+		m.podcastsDrive[i].Selected = true
+	}
+	m.refreshDriveItems()
+	return m.confirmBulkDelete()
+}
+
+// saveConfig writes a snapshot of cfg so later edits can't race the write
+func saveConfig(cfg *internal.Config, path string) tea.Cmd {
+	snapshot := *cfg
+	snapshot.Shows = maps.Clone(cfg.Shows)
+	snapshot.Drives = maps.Clone(cfg.Drives)
+	return func() tea.Msg {
+		if err := snapshot.Save(path); err != nil {
+			return ErrMsg{err: fmt.Errorf("failed to save config: %w", err)}
+		}
+		return nil
+	}
+}
+
+func (m Model) renderShowPolicy() string {
+	var inLibrary, onDrive int
+	for _, p := range m.podcasts {
+		if p.ShowName == m.policyShow {
+			inLibrary++
+		}
+	}
+	for _, p := range m.podcastsDrive {
+		if p.ShowName == m.policyShow {
+			onDrive++
+		}
+	}
+
+	mode := "manual"
+	if m.policyDraft.Sync != internal.SyncManual {
+		mode = string(m.policyDraft.Sync)
+	}
+	keep := "all episodes"
+	if m.policyDraft.Keep > 0 {
+		keep = fmt.Sprintf("%d newest", m.policyDraft.Keep)
+	}
+
+	text := fmt.Sprintf("%s\n\n%d in library · %d on drive\n\nSync:  %s\nKeep:  %s\n\n\n",
+		m.policyShow, inLibrary, onDrive, mode, keep)
+	help := m.createHelp(text, m.confirmHelp.View(m.policyKeys))
+	popup := popupStyle.Render(text + help)
+	return m.centerInWindow(popup)
+}
@@ -0,0 +1,196 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/joncrangle/podcasts-sync/internal"
+)
+
+// PathPreviewMsg carries the destinations of the selected episodes and how long syncing them should take
+type PathPreviewMsg struct {
+	Previews []internal.DestinationPreview
+	// Estimate is zero when the history couldn't be read
+	Estimate internal.SyncEstimate
+	// Confirm is set when the preview was opened by s, to confirm the sync before it starts
+	Confirm bool
+}
+
+var (
+	previewCollisionStyle = lipgloss.NewStyle().Foreground(lipgloss.Color(Red)).Render
+	previewWarningStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color(Peach)).Render
+	previewNoteStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color(Overlay1)).Render
+)
+
+// previewDestinations works out the destinations off the UI thread, since it checks the drive for every
+// episode, and estimates the sync from the drive's past syncs in history
+func previewDestinations(episodes []internal.PodcastEpisode, drive internal.USBDrive, history *internal.History, confirm bool) tea.Cmd {
+	return func() tea.Msg {
+		previews := internal.PreviewDestinations(episodes, drive)
+		var bytes int64
+		var files int
+		for _, p := range previews {
+			if !p.OnDrive {
+				bytes += p.Episode.FileSize
+				files += max(p.Parts, 1)
+			}
+		}
+		estimate, _ := internal.EstimateSync(history, drive, bytes, files)
+		return PathPreviewMsg{Previews: previews, Estimate: estimate, Confirm: confirm}
+	}
+}
+
+func (m *Model) handlePathPreview(msg PathPreviewMsg) (tea.Model, tea.Cmd) {
+	if len(msg.Previews) == 0 || m.state != normal {
+		return m, nil
+	}
+	m.preview = msg.Previews
+	m.previewEstimate = msg.Estimate
+	m.previewConfirm = msg.Confirm
+	m.previewOffset = 0
+	m.state = pathPreview
+	return m, nil
+}
+
+func (m *Model) handlePathPreviewKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case msg.Type == tea.KeyCtrlC:
+		return m, tea.Quit
+	case key.Matches(msg, m.previewKeys.Up):
+		m.previewOffset = max(0, m.previewOffset-1)
+	case key.Matches(msg, m.previewKeys.Down):
+		m.previewOffset = min(max(0, len(m.preview)-m.previewRows()), m.previewOffset+1)
+	case key.Matches(msg, m.previewKeys.Sync):
+		m.state = normal
+		m.preview = nil
+		return m.syncSelected()
+	case key.Matches(msg, m.previewKeys.Close):
+		m.state = normal
+		m.preview = nil
+	}
+	return m, nil
+}
+
+// confirmSync lists what syncing the selection would copy and skip, and only syncs once that is
+// confirmed. Without a drive the sync is queued as before.
+func (m *Model) confirmSync() (tea.Model, tea.Cmd) {
+	if len(selectedEpisodes(m.podcasts)) == 0 || m.currentDrive.Name == "" {
+		return m.syncSelected()
+	}
+	return m, previewDestinations(m.podcasts, m.currentDrive, m.history, true)
+}
+
+// syncSelected starts syncing the selected library episodes to the current drive
+func (m *Model) syncSelected() (tea.Model, tea.Cmd) {
+	if m.readOnly {
+		m.errorMsg = readOnlyMsg
+		return m, nil
+	}
+	var selected []internal.PodcastEpisode
+	for _, p := range m.podcasts {
+		if p.Selected {
+			selected = append(selected, p)
+		}
+	}
+	if len(selected) == 0 {
+		return m, nil
+	}
+	if m.currentDrive.Name == "" {
+		return m.queueSyncForDrive(selected)
+	}
+	m.state = syncing
+	return m, tea.Batch(
+		m.syncManager.start(selected, m.currentDrive),
+		m.startProgress(),
+		m.remember(internal.NewAction(internal.ActionSync, m.currentDrive.Name, selected)),
+	)
+}
+
+// previewRows is how many destinations fit in the preview popup
+func (m Model) previewRows() int {
+	return max(5, m.height-16)
+}
+
+func (m Model) renderPathPreview() string {
+	var renamed, truncated, unicode, collisions, onDrive int
+	var copyBytes, skipBytes int64
+	for _, p := range m.preview {
+		renamed += btoi(p.Renamed)
+		truncated += btoi(p.Truncated)
+		unicode += btoi(p.Unicode)
+		collisions += btoi(p.Collides)
+		onDrive += btoi(p.OnDrive)
+		if p.OnDrive {
+			skipBytes += p.Episode.FileSize
+		} else {
+			copyBytes += p.Episode.FileSize
+		}
+	}
+	summary := fmt.Sprintf("%d to copy (%s) · %d skipped, already on drive (%s)\n%d collisions · %d truncated · %d renamed · %d non-ASCII",
+		len(m.preview)-onDrive, internal.FormatBytes(copyBytes), onDrive, internal.FormatBytes(skipBytes),
+		collisions, truncated, renamed, unicode)
+	if e := m.previewEstimate; e.Duration > 0 && onDrive < len(m.preview) {
+		basis := "a guess until the drive has synced"
+		switch {
+		case e.Runs == 1:
+			basis = "from 1 earlier sync"
+		case e.Runs > 1:
+			basis = fmt.Sprintf("from %d earlier syncs", e.Runs)
+		case m.currentDrive.Profile.Speed.Write > 0:
+			basis = "from the measured speed"
+		}
+		summary += "\n" + fmt.Sprintf("Syncing should take %s ", e) + previewNoteStyle("("+basis+")")
+	}
+
+	end := min(len(m.preview), m.previewOffset+m.previewRows())
+	lines := make([]string, 0, end-m.previewOffset)
+	for _, p := range m.preview[m.previewOffset:end] {
+		line := p.Path + previewNoteStyle("  "+internal.FormatBytes(p.Episode.FileSize))
+		var notes []string
+		if p.Parts > 1 {
+			notes = append(notes, fmt.Sprintf("%d parts", p.Parts))
+		}
+		if p.OnDrive {
+			notes = append(notes, "skipped, on drive")
+		}
+		if p.Renamed {
+			notes = append(notes, "renamed")
+		}
+		if p.Unicode {
+			notes = append(notes, "non-ASCII")
+		}
+		if len(notes) > 0 {
+			line += previewNoteStyle(" (" + strings.Join(notes, ", ") + ")")
+		}
+		switch {
+		case p.Collides:
+			line = previewCollisionStyle("collision  ") + line
+		case p.Truncated:
+			line = previewWarningStyle("truncated  ") + line
+		default:
+			line = "           " + line
+		}
+		lines = append(lines, line)
+	}
+
+	title := "Destinations on " + m.currentDrive.Name
+	if m.previewConfirm {
+		title = fmt.Sprintf("Sync %d episode(s) to %s?", len(m.preview), m.currentDrive.Name)
+	}
+	text := fmt.Sprintf("%s\n\n%s\n\n%s\n\n", title, summary,
+		lipgloss.NewStyle().Align(lipgloss.Left).Render(strings.Join(lines, "\n")))
+	help := m.createHelp(text, m.confirmHelp.View(m.previewKeys))
+	popup := popupStyle.Render(text + help)
+	return m.centerInWindow(popup)
+}
+
+func btoi(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -1,9 +1,16 @@
 package tui
 
 import (
+	"math"
+	"strings"
+	"time"
+
 	"github.com/charmbracelet/bubbles/progress"
 	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+
+	"github.com/joncrangle/podcasts-sync/internal"
 )
 
 var progressInfoStyle = lipgloss.NewStyle().
@@ -22,3 +29,129 @@ func createSpinner() spinner.Model {
 	s.Style = lipgloss.NewStyle().Foreground(lipgloss.Color(Mauve))
 	return s
 }
+
+// progressFPS is how often the progress bar is redrawn while a sync runs, however sparse its updates
+const progressFPS = 30
+
+const (
+	// maxLead caps how long the bar runs on at the reported speed after an update, so it doesn't run
+	// away from a copy that stalled
+	maxLead = time.Second
+	// easeTime is the time constant the bar closes the gap to where the copy should be by
+	easeTime = 150 * time.Millisecond
+	// holdBack is how far the bar may be ahead of the copy before it moves back, rather than waiting
+	// for the copy to catch up
+	holdBack = 0.02
+)
+
+// ProgressFrameMsg redraws the progress bar of the sync that started frame ID
+type ProgressFrameMsg struct {
+	ID int
+	At time.Time
+}
+
+func progressFrame(id int) tea.Cmd {
+	return tea.Tick(time.Second/progressFPS, func(t time.Time) tea.Msg {
+		return ProgressFrameMsg{ID: id, At: t}
+	})
+}
+
+// startProgress resets the progress bar for a new sync and starts redrawing it
+func (m *Model) startProgress() tea.Cmd {
+	m.smoother = progressSmoother{}
+	m.preparing = internal.PrepareProgress{}
+	m.progressFrames++
+	return progressFrame(m.progressFrames)
+}
+
+// handleProgressFrame advances the progress bar until the sync it was started for ends
+func (m *Model) handleProgressFrame(msg ProgressFrameMsg) (tea.Model, tea.Cmd) {
+	if msg.ID != m.progressFrames || (m.state != syncing && m.state != transferring && m.state != cancelConfirm) {
+		return m, nil
+	}
+	m.smoother.advance(msg.At)
+	return m, progressFrame(msg.ID)
+}
+
+// renderProgressBar shows how far the sync is, how far it is through examining the episodes before
+// that, or a sliding block until either is known
+func (m Model) renderProgressBar() string {
+	if m.state == syncing && m.preparing.Total > 0 {
+		return m.progress.ViewAs(float64(m.preparing.Examined) / float64(m.preparing.Total))
+	}
+	if m.state == syncing || m.transferProgress.TotalBytes <= 0 {
+		return indeterminateBar(m.progress, m.smoother.frames)
+	}
+	return m.progress.ViewAs(m.smoother.shown)
+}
+
+// progressSmoother moves the progress bar smoothly between sparse progress updates. Between updates it
+// runs on at the reported speed for about as long as updates take to arrive, and each frame it eases
+// towards that estimate by the time since the last frame, so the bar moves the same at any frame rate.
+type progressSmoother struct {
+	// target is the last reported progress, at the fraction per second rate
+	target, rate float64
+	at           time.Time
+	// interval is the smoothed time between updates
+	interval time.Duration
+	shown    float64
+	frame    time.Time
+	// frames counts the frames drawn, to animate the indeterminate bar
+	frames int
+}
+
+// report takes the progress of an update received at now
+func (s *progressSmoother) report(p internal.TransferProgress, now time.Time) {
+	s.rate = 0
+	if p.TotalBytes > 0 {
+		s.rate = p.Speed / float64(p.TotalBytes)
+	}
+	switch {
+	case s.at.IsZero():
+		s.shown, s.frame = p.CurrentProgress, now
+	case p.CurrentProgress == s.target:
+		return
+	case s.interval == 0:
+		s.interval = now.Sub(s.at)
+	default:
+		s.interval = (s.interval + now.Sub(s.at)) / 2
+	}
+	s.target, s.at = p.CurrentProgress, now
+}
+
+// advance draws a frame at now and returns the progress to show
+func (s *progressSmoother) advance(now time.Time) float64 {
+	s.frames++
+	if s.at.IsZero() {
+		return s.shown
+	}
+	lead := min(now.Sub(s.at), s.interval, maxLead)
+	estimate := min(s.target+s.rate*lead.Seconds(), 1)
+	// Running on can overshoot a little; wait for the copy instead of jumping back
+	if estimate < s.shown && s.target > s.shown-holdBack {
+		s.frame = now
+		return s.shown
+	}
+	if dt := now.Sub(s.frame); dt > 0 {
+		s.shown += (estimate - s.shown) * (1 - math.Exp(-dt.Seconds()/easeTime.Seconds()))
+		s.frame = now
+	}
+	return s.shown
+}
+
+// indeterminateBar renders a block sliding back and forth across a bar of width, for while the sync
+// works out what to copy
+func indeterminateBar(p progress.Model, frames int) string {
+	width := max(p.Width, 2)
+	block := max(width/5, 1)
+	span := width - block
+	pos := frames % (2 * span)
+	if pos > span {
+		pos = 2*span - pos
+	}
+	empty := lipgloss.NewStyle().Foreground(lipgloss.Color(p.EmptyColor))
+	full := lipgloss.NewStyle().Foreground(lipgloss.Color(Mauve))
+	return empty.Render(strings.Repeat(string(p.Empty), pos)) +
+		full.Render(strings.Repeat(string(p.Full), block)) +
+		empty.Render(strings.Repeat(string(p.Empty), span-pos))
+}
@@ -0,0 +1,128 @@
+package tui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/joncrangle/podcasts-sync/internal"
+)
+
+var queueCursorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color(Yellow)).Bold(true)
+
+// openQueueBuilder lists the episodes the drive holds after syncing the selection, in its saved order.
+// Episodes not yet in the order follow it oldest first.
+func (m *Model) openQueueBuilder() (tea.Model, tea.Cmd) {
+	if m.currentDrive.Name == "" {
+		return m, nil
+	}
+
+	profile := m.currentDrive.Profile
+	seen := make(map[string]bool)
+	var episodes []internal.PodcastEpisode
+	add := func(episode internal.PodcastEpisode) {
+		if path := profile.RelPath(episode); !seen[path] {
+			seen[path] = true
+			episodes = append(episodes, episode)
+		}
+	}
+	for _, episode := range m.podcastsDrive {
+		add(episode)
+	}
+	for _, episode := range m.podcasts {
+		if episode.Selected {
+			add(episode)
+		}
+	}
+	if len(episodes) == 0 {
+		return m, nil
+	}
+
+	profile.Playlist = internal.PlaylistCustom
+	m.queue = internal.OrderPlaylist(episodes, profile, profile.RelPath)
+	m.queueCursor = 0
+	m.state = queueBuilder
+	return m, nil
+}
+
+func (m *Model) handleQueueKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case msg.Type == tea.KeyCtrlC:
+		return m, tea.Quit
+	case key.Matches(msg, m.queueKeys.MoveUp):
+		if m.queueCursor > 0 {
+			m.queue[m.queueCursor-1], m.queue[m.queueCursor] = m.queue[m.queueCursor], m.queue[m.queueCursor-1]
+			m.queueCursor--
+		}
+	case key.Matches(msg, m.queueKeys.MoveDown):
+		if m.queueCursor < len(m.queue)-1 {
+			m.queue[m.queueCursor+1], m.queue[m.queueCursor] = m.queue[m.queueCursor], m.queue[m.queueCursor+1]
+			m.queueCursor++
+		}
+	case key.Matches(msg, m.queueKeys.Up):
+		m.queueCursor = max(0, m.queueCursor-1)
+	case key.Matches(msg, m.queueKeys.Down):
+		m.queueCursor = min(len(m.queue)-1, m.queueCursor+1)
+	case key.Matches(msg, m.queueKeys.Save):
+		return m.saveQueue()
+	case key.Matches(msg, m.queueKeys.Close):
+		m.state = normal
+		m.queue = nil
+	}
+	return m, nil
+}
+
+// saveQueue stores the built order as the drive's custom playlist order.
+// The next sync applies it to index prefixes and the playlist.
+func (m *Model) saveQueue() (tea.Model, tea.Cmd) {
+	key := m.config.KeyProfile(m.currentDrive)
+	order := make([]string, len(m.queue))
+	for i, episode := range m.queue {
+		order[i] = filepath.ToSlash(m.currentDrive.Profile.RelPath(episode))
+	}
+
+	m.config.SetCustomOrder(key, order)
+	m.driveManager.SetProfiles(m.config.Drives)
+	profile := m.config.ProfileFor(key)
+	for i := range m.drives {
+		if m.drives[i].ProfileKey() == key {
+			m.drives[i].Profile = profile
+		}
+	}
+	m.currentDrive.Profile = profile
+	m.state = normal
+	m.queue = nil
+	// The library view only has the error line for notes
+	m.setInfo(fmt.Sprintf("Saved the playlist order for %s; it applies on the next sync", m.currentDrive.Name))
+	return m, saveConfig(m.config, m.configPath)
+}
+
+func (m Model) renderQueueBuilder() string {
+	// Keep the cursor in view when the queue is taller than the window
+	rows := max(5, m.height-14)
+	start := min(max(0, m.queueCursor-rows/2), max(0, len(m.queue)-rows))
+	end := min(len(m.queue), start+rows)
+
+	lines := make([]string, 0, end-start)
+	for i := start; i < end; i++ {
+		episode := m.queue[i]
+		line := fmt.Sprintf("  %3d. %s - %s", i+1, episode.ShowName, episode.ZTitle)
+		if !episode.OnDrive {
+			line += " (new)"
+		}
+		if i == m.queueCursor {
+			line = queueCursorStyle.Render("›" + line[1:])
+		}
+		lines = append(lines, line)
+	}
+
+	text := fmt.Sprintf("Playlist for %s\n\n%s\n\n", m.currentDrive.Name,
+		lipgloss.NewStyle().Align(lipgloss.Left).Render(strings.Join(lines, "\n")))
+	help := m.createHelp(text, m.confirmHelp.View(m.queueKeys))
+	popup := popupStyle.Render(text + help)
+	return m.centerInWindow(popup)
+}
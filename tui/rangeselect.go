@@ -0,0 +1,74 @@
+package tui
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/joncrangle/podcasts-sync/internal"
+)
+
+// selectionRange is the start of a range selection: the list it was started in and the position of
+// its cursor there
+type selectionRange struct {
+	focus  int
+	anchor int
+}
+
+// toggleRangeSelection starts a range selection at the cursor of the focused list, or abandons the
+// one already started
+func (m *Model) toggleRangeSelection() (tea.Model, tea.Cmd) {
+	if m.selecting != nil {
+		m.selecting = nil
+		m.setInfo("Range selection cancelled")
+		return m, nil
+	}
+	l := m.focusedList()
+	if _, ok := l.SelectedItem().(internal.PodcastEpisode); !ok {
+		return m, nil
+	}
+	m.selecting = &selectionRange{focus: m.focusIndex, anchor: l.Index()}
+	m.setInfo("Range selection: move to the last episode and press space, or v to cancel")
+	return m, nil
+}
+
+// focusedList is the list of episodes that has the focus
+func (m *Model) focusedList() *list.Model {
+	if m.focusIndex == 1 {
+		return &m.drivePodcasts
+	}
+	return &m.macPodcasts
+}
+
+// selectRange selects every episode between the start of the range selection and the cursor,
+// both included, ending the range selection. Episodes that can't be synced are left out.
+func (m *Model) selectRange() (tea.Model, tea.Cmd) {
+	r := *m.selecting
+	m.selecting = nil
+	if r.focus != m.focusIndex {
+		m.setInfo("Range selection cancelled: it was started in the other list")
+		return m, nil
+	}
+
+	l, podcasts := &m.macPodcasts, m.podcasts
+	if m.focusIndex == 1 {
+		l, podcasts = &m.drivePodcasts, m.podcastsDrive
+	}
+	visible := l.VisibleItems()
+	from, to := min(r.anchor, l.Index()), max(r.anchor, l.Index())
+	inRange := map[string]bool{}
+	for _, item := range visible[from:min(to+1, len(visible))] {
+		if episode, ok := item.(internal.PodcastEpisode); ok && selectable(episode) {
+			inRange[episode.FilePath] = true
+		}
+	}
+	for i, p := range podcasts {
+		if inRange[p.FilePath] {
+			podcasts[i].Selected = true
+		}
+	}
+	m.refreshFocusedList()
+	m.setInfo(fmt.Sprintf("Selected %d episode(s)", len(inRange)))
+	return m, nil
+}
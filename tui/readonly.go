@@ -0,0 +1,50 @@
+package tui
+
+import (
+	"slices"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// readOnlyMsg is shown when a key that would write is refused
+const readOnlyMsg = "Read-only mode: syncs, deletes and other changes are disabled"
+
+// writeBindings returns the bindings of k that change a drive, the config or the history database
+func writeBindings(k *KeyMap) []*key.Binding {
+	return []*key.Binding{
+		&k.Sync, &k.SyncAll, &k.Delete, &k.DeleteAll, &k.Prune, &k.Repeat, &k.Undo,
+		&k.Rename, &k.RenameShow, &k.Pin, &k.Favorite, &k.ShowPolicy, &k.Queue, &k.Benchmark,
+		&k.AddFolder,
+	}
+}
+
+// withoutWrites returns k with the bindings that write disabled, so help leaves them out
+func withoutWrites(k KeyMap) KeyMap {
+	for _, b := range writeBindings(&k) {
+		b.SetEnabled(false)
+	}
+	return k
+}
+
+// refuseWrite blocks a key that would write while the app runs with --read-only.
+// Browsing, search, quick lists, path previews and the debug view keep working.
+func (m *Model) refuseWrite(msg tea.KeyMsg) (tea.Model, tea.Cmd, bool) {
+	if !m.readOnly {
+		return m, nil, false
+	}
+	k := keys
+	if !slices.ContainsFunc(writeBindings(&k), func(b *key.Binding) bool { return key.Matches(msg, *b) }) {
+		return m, nil, false
+	}
+	m.errorMsg = readOnlyMsg
+	return m, nil, true
+}
+
+// newPreviewKeyMap leaves syncing out of the path preview in read-only mode, where the
+// preview is only for looking
+func newPreviewKeyMap(readOnly bool) PreviewKeyMap {
+	k := previewKeys
+	k.Sync.SetEnabled(!readOnly)
+	return k
+}
@@ -0,0 +1,106 @@
+package tui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/joncrangle/podcasts-sync/internal"
+)
+
+// RenamedMsg reports a rename on the drive, with the old and new path below its podcasts folder
+type RenamedMsg struct {
+	From string
+	To   string
+	Err  error
+}
+
+func renameOnDrive(drive internal.USBDrive, path, name string, show bool) tea.Cmd {
+	return func() tea.Msg {
+		from, to, err := internal.NewPodcastSync().Rename(drive, path, name, show)
+		return RenamedMsg{From: from, To: to, Err: err}
+	}
+}
+
+// openRename offers to rename the drive episode under the cursor, or with show set its show folder
+func (m *Model) openRename(show bool) (tea.Model, tea.Cmd) {
+	episode, ok := m.drivePodcasts.SelectedItem().(internal.PodcastEpisode)
+	if !ok {
+		return m, nil
+	}
+	name := strings.TrimSuffix(filepath.Base(episode.FilePath), filepath.Ext(episode.FilePath))
+	if show {
+		name = episode.ShowName
+	}
+	m.renameTarget = episode
+	m.renameShow = show
+	m.renameInput.SetValue(name)
+	m.renameInput.CursorEnd()
+	m.state = renaming
+	return m, m.renameInput.Focus()
+}
+
+// handleRenameKey routes keys to the rename popup; anything else is typed into the name
+func (m *Model) handleRenameKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case msg.Type == tea.KeyCtrlC:
+		return m, tea.Quit
+	case key.Matches(msg, m.renameKeys.Close):
+		m.state = normal
+		m.renameInput.Blur()
+		return m, nil
+	case key.Matches(msg, m.renameKeys.Save):
+		m.state = normal
+		m.renameInput.Blur()
+		return m, renameOnDrive(m.currentDrive, m.renameTarget.FilePath, m.renameInput.Value(), m.renameShow)
+	}
+
+	var cmd tea.Cmd
+	m.renameInput, cmd = m.renameInput.Update(msg)
+	return m, cmd
+}
+
+func (m *Model) handleRenamed(msg RenamedMsg) (tea.Model, tea.Cmd) {
+	if msg.Err != nil {
+		m.errorMsg = fmt.Sprintf("Failed to rename: %v", msg.Err)
+	}
+	// The rename failed, or the name didn't change
+	if msg.From == "" {
+		return m, nil
+	}
+
+	var cmds []tea.Cmd
+	// The custom order names episodes by path, so it follows the rename
+	key := m.currentDrive.ProfileKey()
+	if m.config.RenameInOrder(key, msg.From, msg.To) {
+		m.driveManager.SetProfiles(m.config.Drives)
+		profile := m.config.ProfileFor(key)
+		for i := range m.drives {
+			if m.drives[i].ProfileKey() == key {
+				m.drives[i].Profile = profile
+			}
+		}
+		m.currentDrive.Profile = profile
+		cmds = append(cmds, saveConfig(m.config, m.configPath))
+	}
+	if msg.Err == nil {
+		m.setInfo(fmt.Sprintf("Renamed %s to %s", msg.From, msg.To))
+	}
+	m.loading.drivePodcasts = true
+	cmds = append(cmds, getDrivePodcasts(m.currentDrive, m.podcasts))
+	return m, tea.Batch(cmds...)
+}
+
+func (m Model) renderRename() string {
+	what := fmt.Sprintf("Rename %q on %s", filepath.Base(m.renameTarget.FilePath), m.currentDrive.Name)
+	if m.renameShow {
+		what = fmt.Sprintf("Rename the show folder of %q on %s", m.renameTarget.ShowName, m.currentDrive.Name)
+	}
+	text := what + "\n\n" + m.renameInput.View() + "\n\n\n"
+	help := m.createHelp(text, m.confirmHelp.View(m.renameKeys))
+	popup := popupStyle.Render(text + help)
+	return m.centerInWindow(popup)
+}
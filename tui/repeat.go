@@ -0,0 +1,100 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/joncrangle/podcasts-sync/internal"
+)
+
+// ActionsMsg carries the last action of each kind from the history database
+type ActionsMsg map[internal.ActionKind]internal.Action
+
+func loadActions(history *internal.History) tea.Cmd {
+	return func() tea.Msg {
+		actions, err := history.LastActions()
+		if err != nil {
+			return ErrMsg{err: err}
+		}
+		return ActionsMsg(actions)
+	}
+}
+
+func recordAction(history *internal.History, action internal.Action) tea.Cmd {
+	return func() tea.Msg {
+		if err := history.RecordAction(action); err != nil {
+			return ErrMsg{err: err}
+		}
+		return nil
+	}
+}
+
+func (m *Model) handleActions(msg ActionsMsg) (tea.Model, tea.Cmd) {
+	m.lastActions = msg
+	return m, nil
+}
+
+// remember makes action the last of its kind, in the model and the history database
+func (m *Model) remember(action internal.Action) tea.Cmd {
+	if m.lastActions == nil {
+		m.lastActions = map[internal.ActionKind]internal.Action{}
+	}
+	m.lastActions[action.Kind] = action
+	if m.readOnly {
+		return nil
+	}
+	return recordAction(m.history, action)
+}
+
+// selectedEpisodes returns the selected episodes of episodes
+func selectedEpisodes(episodes []internal.PodcastEpisode) []internal.PodcastEpisode {
+	var selected []internal.PodcastEpisode
+	for _, p := range episodes {
+		if p.Selected {
+			selected = append(selected, p)
+		}
+	}
+	return selected
+}
+
+// repeatLastSync replaces the selection with the new episodes of the shows in the last sync and syncs them
+func (m *Model) repeatLastSync() (tea.Model, tea.Cmd) {
+	last, ok := m.lastActions[internal.ActionSync]
+	if !ok {
+		m.setInfo("No sync to repeat yet")
+		return m, nil
+	}
+	if last.Drive != m.currentDrive.Name {
+		m.errorMsg = fmt.Sprintf("The last sync went to %s; select it with f to repeat it", last.Drive)
+		return m, nil
+	}
+
+	for i := range m.podcasts {
+		m.podcasts[i].Selected = false
+	}
+	if internal.SelectRepeat(m.podcasts, last, m.config) == 0 {
+		m.setInfo(fmt.Sprintf("No new episodes of the %d show(s) in the last sync", len(last.Shows)))
+		m.refreshMacItems()
+		return m, nil
+	}
+	m.refreshMacItems()
+	return m.syncSelected()
+}
+
+// selectLastQuickList puts the quick list cursor on the list applied last
+func (m *Model) selectLastQuickList() {
+	last, ok := m.lastActions[internal.ActionFilter]
+	if !ok {
+		return
+	}
+	i := slices.IndexFunc(m.quickLists.Items(), func(item list.Item) bool {
+		q, ok := item.(quickListItem)
+		return ok && q.title == last.Filter
+	})
+	if i >= 0 {
+		m.quickLists.Select(i)
+	}
+}
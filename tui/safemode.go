@@ -0,0 +1,63 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// confirmBulkDelete asks to confirm deleting the selected drive episodes of delete all or pruning.
+// In safe mode the drive's name has to be typed, so a stray keypress can't empty the drive.
+func (m *Model) confirmBulkDelete() (tea.Model, tea.Cmd) {
+	if !m.config.SafeMode {
+		m.state = confirm
+		return m, nil
+	}
+	m.confirmInput.SetValue("")
+	m.state = typedConfirm
+	return m, m.confirmInput.Focus()
+}
+
+// typedDriveName reports whether the drive's name has been typed into the confirmation
+func (m Model) typedDriveName() bool {
+	return strings.TrimSpace(m.confirmInput.Value()) == m.currentDrive.Name
+}
+
+// handleTypedConfirmKey routes keys to the safe mode confirmation; anything else is typed into the name
+func (m *Model) handleTypedConfirmKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case msg.Type == tea.KeyCtrlC:
+		return m, tea.Quit
+	case key.Matches(msg, m.typedConfirmKeys.Close):
+		m.state = normal
+		m.confirmInput.Blur()
+		return m, nil
+	case key.Matches(msg, m.typedConfirmKeys.Confirm):
+		if !m.typedDriveName() {
+			return m, nil
+		}
+		m.state = normal
+		m.confirmInput.Blur()
+		return m.handleDeletePodcasts()
+	}
+
+	var cmd tea.Cmd
+	m.confirmInput, cmd = m.confirmInput.Update(msg)
+	return m, cmd
+}
+
+func (m Model) renderTypedConfirm() string {
+	var count int
+	for _, p := range m.podcastsDrive {
+		if p.Selected {
+			count++
+		}
+	}
+	text := fmt.Sprintf("Delete %d episode(s) from %s?\nType the drive's name, %q, to confirm.", count, m.currentDrive.Name, m.currentDrive.Name)
+	text += "\n\n" + m.confirmInput.View() + "\n\n\n"
+	help := m.createHelp(text, m.confirmHelp.View(m.typedConfirmKeys))
+	popup := popupStyle.Render(text + help)
+	return m.centerInWindow(popup)
+}
@@ -0,0 +1,57 @@
+package tui
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/joncrangle/podcasts-sync/internal"
+)
+
+// selectable reports whether p can be selected at all: downloads that are missing or still in
+// progress can't sync, and unfinished copies on the drive are left to the next sync
+func selectable(p internal.PodcastEpisode) bool {
+	return !p.Downloading && !p.Missing && !p.Partial
+}
+
+// invertSelection selects the episodes of the focused list that aren't selected and clears those that
+// are, leaving out episodes hidden by the Mac list's filter
+func (m *Model) invertSelection() (tea.Model, tea.Cmd) {
+	podcasts, visible := m.podcasts, m.isVisible
+	if m.focusIndex == 1 {
+		podcasts, visible = m.podcastsDrive, func(internal.PodcastEpisode) bool { return true }
+	}
+	count := 0
+	for i, p := range podcasts {
+		if !visible(p) || (!p.Selected && !selectable(p)) {
+			continue
+		}
+		podcasts[i].Selected = !p.Selected
+		if podcasts[i].Selected {
+			count++
+		}
+	}
+	m.refreshFocusedList()
+	m.setInfo(fmt.Sprintf("Selected %d episode(s)", count))
+	return m, nil
+}
+
+// selectUnplayed adds every listed episode that hasn't been played and isn't on the current drive yet
+// to the Mac list's selection
+func (m *Model) selectUnplayed() (tea.Model, tea.Cmd) {
+	count := 0
+	for i, p := range m.podcasts {
+		if p.Played || p.OnDrive || p.Selected || !selectable(p) || !m.isVisible(p) {
+			continue
+		}
+		m.podcasts[i].Selected = true
+		count++
+	}
+	m.refreshMacItems()
+	if count == 0 {
+		m.setInfo("No unplayed episodes left to add")
+		return m, nil
+	}
+	m.setInfo(fmt.Sprintf("Selected %d unplayed episode(s) not on the drive", count))
+	return m, nil
+}
@@ -0,0 +1,143 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/joncrangle/podcasts-sync/internal"
+)
+
+// showItem is a show's node in the grouped Mac list, followed by its episodes while expanded
+type showItem struct {
+	name     string
+	episodes int
+	selected int
+	size     int64
+	expanded bool
+}
+
+func (s showItem) Title() string {
+	if s.expanded {
+		return "▾ " + s.name
+	}
+	return "▸ " + s.name
+}
+
+func (s showItem) Description() string {
+	desc := fmt.Sprintf("%d episodes · %s", s.episodes, internal.FormatBytes(s.size))
+	if s.selected > 0 {
+		desc += fmt.Sprintf(" · %d selected", s.selected)
+	}
+	return desc
+}
+
+func (s showItem) FilterValue() string { return s.name }
+
+// showTreeItems groups podcasts under a node per show, in order of show name, listing the episodes
+// of expanded shows after their node
+func (m *Model) showTreeItems(podcasts []internal.PodcastEpisode) []list.Item {
+	var names []string
+	byShow := map[string][]internal.PodcastEpisode{}
+	for _, p := range podcasts {
+		if _, ok := byShow[p.ShowName]; !ok {
+			names = append(names, p.ShowName)
+		}
+		byShow[p.ShowName] = append(byShow[p.ShowName], p)
+	}
+	slices.SortFunc(names, func(a, b string) int { return strings.Compare(strings.ToLower(a), strings.ToLower(b)) })
+
+	items := make([]list.Item, 0, len(names))
+	for _, name := range names {
+		node := showItem{name: name, expanded: m.expandedShows[name]}
+		for _, p := range byShow[name] {
+			node.episodes++
+			node.size += p.FileSize
+			if p.Selected {
+				node.selected++
+			}
+		}
+		items = append(items, node)
+		if node.expanded {
+			for _, p := range byShow[name] {
+				items = append(items, p)
+			}
+		}
+	}
+	return items
+}
+
+// toggleGroupShows switches the Mac list between the chronological list and the tree of shows
+func (m *Model) toggleGroupShows() (tea.Model, tea.Cmd) {
+	m.groupShows = !m.groupShows
+	m.refreshMacItems()
+	m.macPodcasts.Select(0)
+	m.focusIndex = 0
+	return m, nil
+}
+
+// toggleShowExpanded expands or collapses the show node under the Mac list cursor
+func (m *Model) toggleShowExpanded() (tea.Model, tea.Cmd) {
+	node, ok := m.macPodcasts.SelectedItem().(showItem)
+	if !ok {
+		return m, nil
+	}
+	if m.expandedShows == nil {
+		m.expandedShows = map[string]bool{}
+	}
+	if node.expanded {
+		delete(m.expandedShows, node.name)
+	} else {
+		m.expandedShows[node.name] = true
+	}
+	m.refreshMacItems()
+	return m, nil
+}
+
+// selectShow selects every visible episode of the show under the focused list's cursor, or clears
+// them all when they are already selected. Episodes still downloading are left out.
+func (m *Model) selectShow() (tea.Model, tea.Cmd) {
+	l, podcasts, visible := &m.macPodcasts, m.podcasts, m.isVisible
+	if m.focusIndex == 1 {
+		l, podcasts, visible = &m.drivePodcasts, m.podcastsDrive, func(internal.PodcastEpisode) bool { return true }
+	}
+	var show string
+	switch current := l.SelectedItem().(type) {
+	case showItem:
+		show = current.name
+	case internal.PodcastEpisode:
+		show = current.ShowName
+	default:
+		return m, nil
+	}
+
+	inShow := func(p internal.PodcastEpisode) bool {
+		return p.ShowName == show && selectable(p) && visible(p)
+	}
+	selected := true
+	for _, p := range podcasts {
+		if inShow(p) && !p.Selected {
+			selected = false
+			break
+		}
+	}
+	for i, p := range podcasts {
+		if inShow(p) {
+			podcasts[i].Selected = !selected
+		}
+	}
+	m.refreshFocusedList()
+	return m, nil
+}
+
+// refreshFocusedList rebuilds the items of the focused list after its episodes' selection changed
+func (m *Model) refreshFocusedList() {
+	if m.focusIndex == 1 {
+		m.refreshDriveItems()
+		return
+	}
+	m.refreshMacItems()
+}
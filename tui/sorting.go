@@ -0,0 +1,80 @@
+package tui
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/joncrangle/podcasts-sync/internal"
+)
+
+// sortMode orders the episodes of a list; the zero mode is the library's newest first
+type sortMode int
+
+const (
+	sortPublished sortMode = iota
+	sortShow
+	sortSize
+	sortDuration
+	sortDownloaded
+	sortModes
+)
+
+func (s sortMode) String() string {
+	return [...]string{"newest first", "by show", "largest first", "longest first", "recently downloaded"}[s]
+}
+
+// compare orders a before b under the mode. Ties keep the order the list was loaded in.
+func (s sortMode) compare(a, b internal.PodcastEpisode) int {
+	switch s {
+	case sortShow:
+		return cmp.Or(
+			strings.Compare(strings.ToLower(a.ShowName), strings.ToLower(b.ShowName)),
+			b.Published.Compare(a.Published),
+		)
+	case sortSize:
+		return cmp.Compare(b.FileSize, a.FileSize)
+	case sortDuration:
+		return cmp.Compare(b.Duration, a.Duration)
+	case sortDownloaded:
+		return b.Downloaded.Compare(a.Downloaded)
+	}
+	return b.Published.Compare(a.Published)
+}
+
+// sortEpisodes returns podcasts in the mode's order, leaving podcasts as they are
+func sortEpisodes(mode sortMode, podcasts []internal.PodcastEpisode) []internal.PodcastEpisode {
+	sorted := slices.Clone(podcasts)
+	slices.SortStableFunc(sorted, mode.compare)
+	return sorted
+}
+
+// sortTitle names a mode other than the default in a list's title
+func sortTitle(mode sortMode) string {
+	if mode == sortPublished {
+		return ""
+	}
+	return " · " + mode.String()
+}
+
+// cycleSort moves the focused list on to its next sort mode
+func (m *Model) cycleSort() (tea.Model, tea.Cmd) {
+	if m.focusIndex == 1 {
+		m.driveSort = (m.driveSort + 1) % sortModes
+		m.refreshDriveItems()
+		m.drivePodcasts.Select(0)
+		return m, nil
+	}
+	m.macSort = (m.macSort + 1) % sortModes
+	m.refreshMacItems()
+	m.macPodcasts.Select(0)
+	return m, nil
+}
+
+// refreshDriveItems rebuilds the drive list items and title from m.podcastsDrive
+func (m *Model) refreshDriveItems() {
+	m.drivePodcasts.Title = internal.T("Drive Podcasts") + sortTitle(m.driveSort)
+	setPodcastItems(&m.drivePodcasts, sortEpisodes(m.driveSort, m.podcastsDrive))
+}
@@ -0,0 +1,57 @@
+package tui
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/joncrangle/podcasts-sync/internal"
+)
+
+// benchmarkDrive measures the speed of the drive under the cursor of the drive selector
+func (m *Model) benchmarkDrive() (tea.Model, tea.Cmd) {
+	drive, ok := m.driveSelector.SelectedItem().(internal.USBDrive)
+	if !ok || m.benchmarking != "" {
+		return m, nil
+	}
+	m.benchmarking = drive.Name
+	return m, measureDriveSpeed(drive)
+}
+
+func measureDriveSpeed(drive internal.USBDrive) tea.Cmd {
+	return func() tea.Msg {
+		speed, err := internal.MeasureDriveSpeed(drive)
+		return DriveSpeedMsg{Drive: drive.Name, MountPath: drive.MountPath, Speed: speed, Err: err}
+	}
+}
+
+// handleDriveSpeed stores a measured speed in the drive's profile and shows it in the drive selector
+func (m *Model) handleDriveSpeed(msg DriveSpeedMsg) (tea.Model, tea.Cmd) {
+	m.benchmarking = ""
+	if msg.Err != nil {
+		return m.handleError(ErrMsg{err: fmt.Errorf("failed to benchmark %s: %w", msg.Drive, msg.Err)})
+	}
+
+	key := m.profileKey(msg.Drive, msg.MountPath)
+	m.config.SetDriveSpeed(key, msg.Speed)
+	m.driveManager.SetProfiles(m.config.Drives)
+	for i := range m.drives {
+		if m.drives[i].ProfileKey() == key {
+			m.drives[i].Profile.Speed = msg.Speed
+		}
+	}
+	if m.currentDrive.ProfileKey() == key {
+		m.currentDrive.Profile.Speed = msg.Speed
+	}
+	m.driveSelector.SetItems(m.createDriveItems(m.drives))
+	return m, saveConfig(m.config, m.configPath)
+}
+
+// formatRemaining shows an ETA, e.g. "~2m10s"
+func formatRemaining(d time.Duration) string {
+	if d <= 0 {
+		return "estimating..."
+	}
+	return "~" + max(d.Round(time.Second), time.Second).String()
+}
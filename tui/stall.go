@@ -0,0 +1,56 @@
+package tui
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/joncrangle/podcasts-sync/internal"
+)
+
+// checkStall flags the transfer once no byte has been written for longer than the configured timeout,
+// offering to retry or skip the file being copied
+func (m *Model) checkStall(now time.Time) {
+	stalled := m.stallWatch.Observe(m.transferProgress.BytesTransferred, now)
+	if m.state != transferring || stalled < m.config.StallTimeout() || m.transferProgress.CurrentProgress >= 1 {
+		stalled = 0
+	}
+	m.setStalled(stalled)
+}
+
+// resolveStall retries or skips the file the transfer stalled on
+func (m *Model) resolveStall(action internal.FileAction) (tea.Model, tea.Cmd) {
+	file := m.transferProgress.CurrentFile
+	m.clearStall()
+	if action == internal.FileSkip {
+		m.statusMsg = fmt.Sprintf("Skipping %s", file)
+	} else {
+		m.statusMsg = fmt.Sprintf("Retrying %s", file)
+	}
+	return m, m.syncManager.abortFile(action)
+}
+
+// clearStall forgets the stall history, e.g. when a transfer starts, ends or the stall is resolved
+func (m *Model) clearStall() {
+	m.stallWatch.Reset()
+	m.setStalled(0)
+}
+
+func (m *Model) setStalled(d time.Duration) {
+	m.stalledFor = d
+	m.transferKeys.Retry.SetEnabled(d > 0)
+	m.transferKeys.Skip.SetEnabled(d > 0)
+}
+
+// stallWarning explains a stalled transfer wrapped to width, or is empty while bytes are moving
+func (m Model) stallWarning(width int) string {
+	if m.stalledFor == 0 {
+		return ""
+	}
+	return lipgloss.NewStyle().Width(width).Foreground(lipgloss.Color(Red)).Render(fmt.Sprintf(
+		"Nothing written for %s. The drive or its USB connection may have stopped responding.",
+		m.stalledFor.Truncate(time.Second),
+	))
+}
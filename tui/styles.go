@@ -38,6 +38,7 @@ var (
 	appStyle = lipgloss.NewStyle().
 			Margin(1, 4)
 	errorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color(Red)).Render
+	infoStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color(Subtext1)).Render
 	driveStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color(Flamingo)).
 			Margin(1, 0).Padding(0, 2).
@@ -64,3 +65,9 @@ var (
 			BorderForeground(lipgloss.Color(Pink)).
 			Align(lipgloss.Center).Render
 )
+
+var (
+	// driveTabStyle and activeDriveTabStyle render the drives of the header's drive bar
+	driveTabStyle       = lipgloss.NewStyle().Foreground(lipgloss.Color(Subtext0)).Render
+	activeDriveTabStyle = lipgloss.NewStyle().Foreground(lipgloss.Color(Flamingo)).Bold(true).Render
+)
@@ -0,0 +1,101 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/joncrangle/podcasts-sync/internal"
+)
+
+// transferQueueRows is how many episodes the transfer queue panel shows at once
+const transferQueueRows = 8
+
+var transferStateStyles = map[internal.FileState]lipgloss.Style{
+	internal.FileQueued:  lipgloss.NewStyle().Foreground(lipgloss.Color(Overlay1)),
+	internal.FileCopying: lipgloss.NewStyle().Foreground(lipgloss.Color(Peach)),
+	internal.FileDone:    lipgloss.NewStyle().Foreground(lipgloss.Color(Green)),
+	internal.FileFailed:  lipgloss.NewStyle().Foreground(lipgloss.Color(Red)),
+	internal.FileSkipped: lipgloss.NewStyle().Foreground(lipgloss.Color(Overlay1)),
+	internal.FileMissing: lipgloss.NewStyle().Foreground(lipgloss.Color(Red)),
+}
+
+var transferStateNames = map[internal.FileState]string{
+	internal.FileQueued:  "queued",
+	internal.FileCopying: "copying",
+	internal.FileDone:    "done",
+	internal.FileFailed:  "failed",
+	internal.FileSkipped: "skipped",
+	internal.FileMissing: "missing",
+}
+
+// transferQueue is the episodes of the running sync, in library order
+func (m Model) transferQueue() []internal.PodcastEpisode {
+	var queue []internal.PodcastEpisode
+	for _, p := range m.podcasts {
+		if p.TransferState != internal.FileNone {
+			queue = append(queue, p)
+		}
+	}
+	return queue
+}
+
+// transferQueueStart is the first row the panel shows: the one scrolled to, or until the queue has
+// been scrolled, the row before the episode being copied
+func (m Model) transferQueueStart(queue []internal.PodcastEpisode) int {
+	start := m.transferQueueOffset
+	if !m.transferQueueScrolled {
+		start = 0
+		for i, p := range queue {
+			if p.TransferState == internal.FileCopying {
+				start = i - 1
+				break
+			}
+		}
+	}
+	return max(0, min(start, len(queue)-transferQueueRows))
+}
+
+// scrollTransferQueue moves the transfer queue panel by delta rows, which stops it following the copy
+func (m *Model) scrollTransferQueue(delta int) {
+	queue := m.transferQueue()
+	m.transferQueueOffset = m.transferQueueStart(queue) + delta
+	m.transferQueueScrolled = true
+	m.transferQueueOffset = max(0, min(m.transferQueueOffset, len(queue)-transferQueueRows))
+}
+
+// renderTransferQueue lists the state of each episode in the sync, width columns wide, under a count
+// of the episodes in each state
+func (m Model) renderTransferQueue(width int) string {
+	queue := m.transferQueue()
+	if len(queue) == 0 {
+		return ""
+	}
+
+	counts := map[internal.FileState]int{}
+	for _, p := range queue {
+		counts[p.TransferState]++
+	}
+	var summary []string
+	for _, state := range []internal.FileState{internal.FileDone, internal.FileCopying, internal.FileQueued, internal.FileFailed, internal.FileSkipped, internal.FileMissing} {
+		if counts[state] > 0 {
+			summary = append(summary, fmt.Sprintf("%d %s", counts[state], transferStateNames[state]))
+		}
+	}
+
+	start := m.transferQueueStart(queue)
+	end := min(len(queue), start+transferQueueRows)
+	lines := []string{progressInfoStyle.Render(strings.Join(summary, " · "))}
+	for _, p := range queue[start:end] {
+		style := transferStateStyles[p.TransferState]
+		state := style.Render(fmt.Sprintf("%s %-7s", p.TransferState.Glyph(), transferStateNames[p.TransferState]))
+		size := " " + internal.FormatBytes(p.FileSize)
+		title := lipgloss.NewStyle().MaxWidth(max(1, width-lipgloss.Width(state)-lipgloss.Width(size)-1)).Render(p.ZTitle)
+		lines = append(lines, state+" "+title+previewNoteStyle(size))
+	}
+	if len(queue) > transferQueueRows {
+		lines = append(lines, previewNoteStyle(fmt.Sprintf("%d–%d of %d · ↑/↓ to scroll", start+1, end, len(queue))))
+	}
+	return lipgloss.NewStyle().Width(width).Render(strings.Join(lines, "\n"))
+}
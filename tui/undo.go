@@ -0,0 +1,111 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/joncrangle/podcasts-sync/internal"
+)
+
+type (
+	// JournalMsg carries the undo journal of the current drive
+	JournalMsg struct {
+		Journal *internal.Journal
+		Err     error
+	}
+	// UndoneMsg reports how many files undoing the last sync or cleanup put back or removed
+	UndoneMsg struct {
+		Kind  internal.UndoKind
+		Files int
+		Err   error
+	}
+)
+
+// loadJournal reads the drive's undo journal off the UI thread
+func loadJournal(drive internal.USBDrive) tea.Cmd {
+	return func() tea.Msg {
+		journal, err := internal.LoadJournal(filepath.Join(drive.MountPath, drive.Folder))
+		return JournalMsg{Journal: journal, Err: err}
+	}
+}
+
+func undoRun(drive internal.USBDrive, kind internal.UndoKind, history *internal.History) tea.Cmd {
+	return func() tea.Msg {
+		syncer := internal.NewPodcastSync()
+		syncer.SetHistory(history)
+		files, err := syncer.Undo(drive, kind)
+		return UndoneMsg{Kind: kind, Files: files, Err: err}
+	}
+}
+
+func (m *Model) handleJournal(msg JournalMsg) (tea.Model, tea.Cmd) {
+	if m.state != normal {
+		return m, nil
+	}
+	if msg.Err != nil {
+		m.errorMsg = msg.Err.Error()
+		return m, nil
+	}
+	if len(msg.Journal.Last) == 0 {
+		m.setInfo(fmt.Sprintf(internal.T("Nothing to undo on %s"), m.currentDrive.Name))
+		return m, nil
+	}
+	m.journal = msg.Journal
+	m.undoKeys.Sync.SetEnabled(m.journal.Last[internal.UndoSync] != nil)
+	m.undoKeys.Cleanup.SetEnabled(m.journal.Last[internal.UndoCleanup] != nil)
+	m.state = undoLog
+	return m, nil
+}
+
+func (m *Model) handleUndoKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case msg.Type == tea.KeyCtrlC:
+		return m, tea.Quit
+	case key.Matches(msg, m.undoKeys.Sync):
+		m.state = normal
+		m.journal = nil
+		return m, undoRun(m.currentDrive, internal.UndoSync, m.history)
+	case key.Matches(msg, m.undoKeys.Cleanup):
+		m.state = normal
+		m.journal = nil
+		return m, undoRun(m.currentDrive, internal.UndoCleanup, m.history)
+	case key.Matches(msg, m.undoKeys.Close):
+		m.state = normal
+		m.journal = nil
+	}
+	return m, nil
+}
+
+func (m *Model) handleUndone(msg UndoneMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case errors.Is(msg.Err, internal.ErrNothingToUndo):
+		m.setInfo(fmt.Sprintf(internal.T("Nothing to undo on %s"), m.currentDrive.Name))
+		return m, nil
+	case msg.Err != nil:
+		m.errorMsg = fmt.Sprintf("Failed to undo the last %s after %d file(s): %v", msg.Kind, msg.Files, msg.Err)
+	default:
+		m.setInfo(fmt.Sprintf("Undid the last %s: %d file(s)", msg.Kind, msg.Files))
+	}
+	m.loading.drivePodcasts = true
+	return m, getDrivePodcasts(m.currentDrive, m.podcasts)
+}
+
+func (m Model) renderUndoLog() string {
+	text := fmt.Sprintf(internal.T("Undo on %s"), m.currentDrive.Name) + "\n\n"
+	if run := m.journal.Last[internal.UndoSync]; run != nil {
+		text += fmt.Sprintf("Last sync, %s: copied %d file(s), renamed %d\n",
+			run.At.Format("Jan 2 15:04"), run.Count(internal.JournalCopied), run.Count(internal.JournalRenamed))
+	}
+	if run := m.journal.Last[internal.UndoCleanup]; run != nil {
+		text += fmt.Sprintf("Last cleanup, %s: removed %d file(s), kept in the trash until the next sync\n",
+			run.At.Format("Jan 2 15:04"), run.Count(internal.JournalRemoved))
+	}
+	text += "\n\n"
+	help := m.createHelp(text, m.confirmHelp.View(m.undoKeys))
+	popup := popupStyle.Render(text + help)
+	return m.centerInWindow(popup)
+}
@@ -2,10 +2,12 @@ package tui
 
 import (
 	"fmt"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
-	"github.com/charmbracelet/bubbles/progress"
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 
@@ -13,6 +15,13 @@ import (
 )
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		if l := m.filterableList(); l != nil && ownsFilterKey(*l, msg) {
+			var cmd tea.Cmd
+			*l, cmd = l.Update(msg)
+			return m, cmd
+		}
+	}
 	if cmd := m.handleListUpdates(msg); cmd != nil {
 		return m, cmd
 	}
@@ -23,63 +32,151 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case DebugMsg:
 		return m.handleDebug(msg)
 	case ProgressTickMsg:
-		return m, tea.Batch(
-			m.progress.SetPercent(m.transferProgress.CurrentProgress),
-			m.syncManager.wait(),
-		)
+		m.applyTransferStates()
+		m.checkStall(time.Now())
+		return m, m.syncManager.wait()
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
 		return m, m.updateLayoutDimensions()
 	case DrivesPollMsg:
-		return m, tea.Batch(getDrives, pollDrivesCmd(5000))
+		return m.handleDrivesPoll()
+	case MountsChangedMsg:
+		return m.handleMountsChanged(msg)
 	case DriveUpdatedMsg:
 		return m.handleDriveUpdate(msg)
 	case DrivePodcastsMsg:
 		return m.handleDrivePodcasts(msg)
 	case MacPodcastsMsg:
 		return m.handleMacPodcasts(msg)
+	case ReconcileTickMsg:
+		return m, tea.Batch(reconcileMacPodcasts(m.podcasts), pollReconcileCmd(reconcileInterval))
+	case MissingAssetsMsg:
+		return m.handleMissingAssets(msg)
+	case DownloadingAssetsMsg:
+		return m.handleDownloadingAssets(msg)
+	case FavoritesMsg:
+		return m.handleFavorites(msg)
+	case ActionsMsg:
+		return m.handleActions(msg)
+	case SearchResultsMsg:
+		return m.handleSearchResults(msg)
+	case QuickListMsg:
+		return m.handleQuickList(msg)
 	case FileOpMsg:
 		return m.handleFileOp(msg)
+	case SyncAppendedMsg:
+		return m.handleSyncAppended(msg)
+	case DriveSpeedMsg:
+		return m.handleDriveSpeed(msg)
+	case LocalFolderMsg:
+		return m.handleLocalFolder(msg)
+	case DriveHealthMsg:
+		return m.handleDriveHealth(msg)
+	case FirstAidMsg:
+		return m.handleFirstAid(msg)
+	case PathPreviewMsg:
+		return m.handlePathPreview(msg)
+	case JournalMsg:
+		return m.handleJournal(msg)
+	case UndoneMsg:
+		return m.handleUndone(msg)
+	case RenamedMsg:
+		return m.handleRenamed(msg)
 	case tea.KeyMsg:
 		return m.handleKey(msg)
-	case progress.FrameMsg:
-		progressModel, cmd := m.progress.Update(msg)
-		m.progress = progressModel.(progress.Model)
-		return m, cmd
+	case ProgressFrameMsg:
+		return m.handleProgressFrame(msg)
 	case spinner.TickMsg:
 		var cmd tea.Cmd
 		m.transferSpinner, cmd = m.transferSpinner.Update(msg)
+		m.trackLoading(time.Now())
+		return m, cmd
+	}
+
+	// Keep the search cursor blinking while the search popup is open
+	if m.state == search {
+		var cmd tea.Cmd
+		m.searchInput, cmd = m.searchInput.Update(msg)
+		return m, cmd
+	}
+	if m.state == renaming {
+		var cmd tea.Cmd
+		m.renameInput, cmd = m.renameInput.Update(msg)
+		return m, cmd
+	}
+	if m.state == typedConfirm {
+		var cmd tea.Cmd
+		m.confirmInput, cmd = m.confirmInput.Update(msg)
 		return m, cmd
 	}
 	return m, nil
 }
 
 func (m *Model) handleError(msg ErrMsg) (tea.Model, tea.Cmd) {
+	if m.state == transferring || m.state == cancelConfirm {
+		// Keep the failed glyph visible until the next sync
+		m.applyTransferStates()
+		m.clearStall()
+	}
 	if m.state != normal {
 		m.state = normal
 	}
-	m.errorMsg = msg.Error()
+	m.setError(msg.Error(), msg.retry)
+	if internal.IsIOError(msg.err) {
+		return m, m.recordIOError()
+	}
 	return m, nil
 }
 
 func (m *Model) handleDebug(msg DebugMsg) (tea.Model, tea.Cmd) {
-	debug := internal.Debug(msg)
-	m.debugMsgs = append(m.debugMsgs, debug)
+	m.logDebug(internal.Debug(msg))
+	return m, nil
+}
 
-	items := make([]list.Item, len(m.debugMsgs))
-	for i, d := range m.debugMsgs {
-		items[i] = internal.Debug{
-			DTitle:       d.DTitle,
-			DDescription: d.DDescription,
-		}
+// filterableList returns the focused list of episodes when its filter can take keys
+func (m *Model) filterableList() *list.Model {
+	if m.state != normal {
+		return nil
 	}
-	m.debug.SetItems(items)
-	return m, nil
+	if m.focusIndex == 0 {
+		return &m.macPodcasts
+	}
+	return &m.drivePodcasts
+}
+
+// popupKeyHandler returns the handler that takes every key while the current popup is open,
+// ahead of the list bindings, if the state has one
+func (m *Model) popupKeyHandler() (func(tea.KeyMsg) (tea.Model, tea.Cmd), bool) {
+	handlers := map[state]func(tea.KeyMsg) (tea.Model, tea.Cmd){
+		search:        m.handleSearchKey,
+		cancelConfirm: m.handleCancelConfirmKey,
+		showPolicy:    m.handleShowPolicyKey,
+		queueBuilder:  m.handleQueueKey,
+		pathPreview:   m.handlePathPreviewKey,
+		undoLog:       m.handleUndoKey,
+		renaming:      m.handleRenameKey,
+		typedConfirm:  m.handleTypedConfirmKey,
+		driveDetails:  m.handleDriveDetailsKey,
+		firstAid:      m.handleFirstAidKey,
+		debug:         m.handleDebugKey,
+	}
+	handler, ok := handlers[m.state]
+	return handler, ok
+}
+
+// listsCovered reports whether the episode lists are hidden behind another view, so messages leave them be
+func (m *Model) listsCovered() bool {
+	switch m.state {
+	case transferring, syncing, driveSelection, quickLists:
+		return true
+	}
+	_, popup := m.popupKeyHandler()
+	return popup
 }
 
 func (m *Model) handleListUpdates(msg tea.Msg) tea.Cmd {
-	if m.state == transferring || m.state == syncing || m.state == driveSelection {
+	if m.listsCovered() {
 		return nil
 	}
 
@@ -99,10 +196,17 @@ func (m *Model) handleDriveUpdate(msg DriveUpdatedMsg) (tea.Model, tea.Cmd) {
 	}
 
 	if internal.USBDrivesEqual(m.drives, msg) {
+		// The first poll finding no drives is as good as news of them
+		m.drivesSeen = true
+		m.loading.drives = false
+		m.updateDriveSpace(msg)
 		return m, nil
 	}
 
+	plugged, autoSync := m.pluggedIn(msg)
 	m.drives = msg
+	m.forgetDriveMatches()
+	m.drivesSeen = true
 	m.driveSelector.SetItems(m.createDriveItems(msg))
 	m.loading.drives = false
 
@@ -113,30 +217,66 @@ func (m *Model) handleDriveUpdate(msg DriveUpdatedMsg) (tea.Model, tea.Cmd) {
 		m.podcastsDrive = nil
 		return m, nil
 	}
+	if autoSync {
+		return m.beginAutoSync(plugged)
+	}
 
-	// Set current drive to first drive if it's not set
+	// Set current drive to the preferred drive if it's not set
 	if m.currentDrive.Name == "" {
-		m.currentDrive = m.drives[0]
+		m.currentDrive = m.preferredDrive()
 		m.loading.drivePodcasts = true
-		return m, tea.Sequence(getMacPodcasts, getDrivePodcasts(m.currentDrive, m.podcasts))
+		return m, tea.Sequence(m.loadLibrary, getDrivePodcasts(m.currentDrive, m.podcasts))
 	}
 	// Handle drive state changes
 	found := false
 	for _, drive := range m.drives {
-		if drive.Name == m.currentDrive.Name && drive.MountPath == m.currentDrive.MountPath {
+		if drive.SameDrive(m.currentDrive) {
 			found = true
 			break
 		}
 	}
 	if !found {
-		m.currentDrive = m.drives[0]
+		m.currentDrive = m.preferredDrive()
 		m.drivePodcasts.SetItems(nil)
 		m.podcastsDrive = nil
 	}
+	m.updateDriveSpace(msg)
 
 	return m, nil
 }
 
+// preferredDrive picks the drive that comes first in the config's drive priority and, when it
+// chose between several connected drives, notes which one
+func (m *Model) preferredDrive() internal.USBDrive {
+	drive := m.drives[m.config.PreferredDrive(m.drives)]
+	if len(m.drives) > 1 && m.config != nil && len(m.config.DrivePriority) > 0 {
+		m.setInfo(fmt.Sprintf("Selected %s, the preferred of %d connected drives", drive.Name, len(m.drives)))
+	}
+	return drive
+}
+
+// updateDriveSpace takes the free space of each drive from a poll, which finds the same drives but
+// sees the space change as they're synced and cleaned up
+func (m *Model) updateDriveSpace(drives []internal.USBDrive) {
+	changed := false
+	for i, d := range m.drives {
+		for _, polled := range drives {
+			if polled.MountPath == d.MountPath && (polled.Capacity != d.Capacity || polled.Free != d.Free) {
+				m.drives[i].Capacity, m.drives[i].Free = polled.Capacity, polled.Free
+				changed = true
+			}
+		}
+	}
+	for _, d := range m.drives {
+		if d.MountPath == m.currentDrive.MountPath {
+			m.currentDrive.Capacity, m.currentDrive.Free = d.Capacity, d.Free
+		}
+	}
+	if changed {
+		m.driveSelector.SetItems(m.createDriveItems(m.drives))
+	}
+}
+
 func (m *Model) createDriveItems(drives []internal.USBDrive) []list.Item {
 	items := make([]list.Item, len(drives))
 	for i, d := range drives {
@@ -144,46 +284,108 @@ func (m *Model) createDriveItems(drives []internal.USBDrive) []list.Item {
 			Name:      d.Name,
 			MountPath: d.MountPath,
 			Folder:    d.Folder,
+			Serial:    d.Serial,
+			Volume:    d.Volume,
+			UUID:      d.UUID,
+			Shared:    d.Shared,
+			Profile:   d.Profile,
+			Capacity:  d.Capacity,
+			Free:      d.Free,
 		}
 	}
 	return items
 }
 
+// profileKey returns the key in the config of the profile of the drive mounted at mountPath, or of the
+// named drive when none is, its volume UUID when the profile is keyed by it
+func (m *Model) profileKey(name, mountPath string) string {
+	for _, d := range m.drives {
+		if d.MountPath == mountPath {
+			return m.config.KeyProfile(d)
+		}
+	}
+	for _, d := range m.drives {
+		if d.Name == name {
+			return m.config.KeyProfile(d)
+		}
+	}
+	return name
+}
+
+// recordLastSync saves when the current drive was synced, which tells it apart in the drive selector
+// from drives of the same name
+func (m *Model) recordLastSync(at time.Time) tea.Cmd {
+	key := m.config.KeyProfile(m.currentDrive)
+	m.config.SetLastSync(key, at)
+	m.driveManager.SetProfiles(m.config.Drives)
+	for i := range m.drives {
+		if m.drives[i].ProfileKey() == key {
+			m.drives[i].Profile.LastSync = at
+		}
+	}
+	m.currentDrive.Profile.LastSync = at
+	m.driveSelector.SetItems(m.createDriveItems(m.drives))
+	return saveConfig(m.config, m.configPath)
+}
+
 func (m *Model) handleDrivePodcasts(msg DrivePodcastsMsg) (tea.Model, tea.Cmd) {
 	m.podcastsDrive = msg.PodcastsDrive
-	m.drivePodcasts.SetItems(m.createPodcastItems(msg.PodcastsDrive))
+	m.countDriveMatches()
+	for i := range m.podcastsDrive {
+		m.podcastsDrive[i].Favorite = m.favorites[internal.EpisodeKey(m.podcastsDrive[i])]
+	}
+	m.refreshDriveItems()
 	m.loading.drivePodcasts = false
-	m.loading.macPodcasts = true
+	autoSync := tea.Batch(m.startAutoSync(msg.Podcasts), m.startQueuedSync())
 
 	if len(msg.PodcastsDrive) == 0 || len(msg.Podcasts) == 0 {
-		return m, nil
+		return m, autoSync
 	}
 
-	return m, updateMacPodcasts(msg.Podcasts)
+	m.loading.macPodcasts = true
+	return m, tea.Batch(autoSync, updateMacPodcasts(msg.Podcasts))
 }
 
 func (m *Model) handleMacPodcasts(msg MacPodcastsMsg) (tea.Model, tea.Cmd) {
 	m.podcasts = msg
-	m.macPodcasts.SetItems(m.createPodcastItems(msg))
+	for i := range m.podcasts {
+		m.podcasts[i].Favorite = m.favorites[internal.EpisodeKey(m.podcasts[i])]
+	}
+	m.applyShowPolicies()
 	m.loading.macPodcasts = false
 	return m, m.updateLayoutDimensions()
 }
 
-func (m *Model) createPodcastItems(podcasts []internal.PodcastEpisode) []list.Item {
-	items := make([]list.Item, len(podcasts))
+// setPodcastItems shows podcasts in l, reusing the list's item slice so refreshing a large
+// library doesn't reallocate it on every update. Safe because setListItems filters at once,
+// so nothing reads the old items in the background.
+func setPodcastItems(l *list.Model, podcasts []internal.PodcastEpisode) {
+	items := l.Items()
+	clear(items[min(len(podcasts), len(items)):])
+	items = slices.Grow(items[:0], len(podcasts))[:len(podcasts)]
 	for i, p := range podcasts {
 		items[i] = internal.PodcastEpisode{
-			ZTitle:    p.ZTitle,
-			ShowName:  p.ShowName,
-			FilePath:  p.FilePath,
-			Published: p.Published,
-			Selected:  p.Selected,
-			FileSize:  p.FileSize,
-			OnDrive:   p.OnDrive,
-			Duration:  p.Duration,
+			ZTitle:         p.ZTitle,
+			ShowName:       p.ShowName,
+			FilePath:       p.FilePath,
+			Published:      p.Published,
+			Selected:       p.Selected,
+			FileSize:       p.FileSize,
+			OnDrive:        p.OnDrive,
+			Duration:       p.Duration,
+			ShowNotes:      p.ShowNotes,
+			TranscriptPath: p.TranscriptPath,
+			TransferState:  p.TransferState,
+			Missing:        p.Missing,
+			Downloading:    p.Downloading,
+			Partial:        p.Partial,
+			Favorite:       p.Favorite,
+			Pinned:         p.Pinned,
+			SameAudio:      p.SameAudio,
 		}
 	}
-	return items
+	usePageNumbers(l, len(items)/max(1, l.Paginator.PerPage))
+	setListItems(l, items)
 }
 
 func (m *Model) handleFileOp(msg FileOpMsg) (tea.Model, tea.Cmd) {
@@ -201,6 +403,14 @@ func (m *Model) handleFileOp(msg FileOpMsg) (tea.Model, tea.Cmd) {
 }
 
 func (m *Model) handleSync(msg FileOpMsg) (tea.Model, tea.Cmd) {
+	// Updates while the sync examines the episodes come before the first with its totals
+	if prep := msg.Msg.Preparing; prep != nil {
+		if m.state != syncing {
+			return m, nil
+		}
+		m.preparing = *prep
+		return m, m.syncManager.wait()
+	}
 	// Handle first message - check if there are actually files to transfer
 	if m.state == syncing {
 		// First message received - check if we have files to transfer
@@ -208,46 +418,72 @@ func (m *Model) handleSync(msg FileOpMsg) (tea.Model, tea.Cmd) {
 			// No files to transfer - return to normal state with message
 			m.clearAllSelections()
 			m.state = normal
-			m.errorMsg = "All selected files already exist on drive"
+			m.setInfo("All selected files already exist on drive")
+			if skipped := msg.Msg.Progress.Skipped; len(skipped) > 0 {
+				m.setInfo(fmt.Sprintf("All %d selected episode(s) are already on the drive: %s", len(skipped), episodeTitles(skipped)))
+			}
+			if missing := msg.Msg.Progress.Missing; len(missing) > 0 {
+				m.errorMsg = missingSummary(missing)
+			}
 			m.loading.drivePodcasts = true
 			return m, getDrivePodcasts(m.currentDrive, m.podcasts)
 		}
 		// Files need transfer - transition to transferring state
 		m.state = transferring
+		m.statusMsg = ""
+		if skipped := msg.Msg.Progress.Skipped; len(skipped) > 0 {
+			m.statusMsg = syncSummary("to copy", msg.Msg.Progress.TotalFiles, skipped)
+		}
+		if missing := msg.Msg.Progress.Missing; len(missing) > 0 {
+			m.statusMsg = strings.TrimPrefix(m.statusMsg+" · "+missingSummary(missing), " · ")
+		}
 		m.transferProgress = msg.Msg.Progress
+		m.transferStatesVersion = 0
+		m.clearStall()
+		m.smoother.report(m.transferProgress, time.Now())
 		var cmds []tea.Cmd
-		cmds = append(cmds, m.progress.SetPercent(m.transferProgress.CurrentProgress), m.syncManager.wait())
+		cmds = append(cmds, m.syncManager.wait())
 		if m.dbgEnabled {
-			cmds = append(cmds, addDebugMsg("FileOpMsg", fmt.Sprintf("Operation: %s, Starting transfer of %d files", msg.Operation, msg.Msg.Progress.TotalFiles)))
+			cmds = append(cmds, addDebugMsg(internal.DebugInfo, "FileOpMsg", fmt.Sprintf("Operation: %s, Starting transfer of %d files", msg.Operation, msg.Msg.Progress.TotalFiles)))
 		}
 		return m, tea.Batch(cmds...)
 	}
 
-	if m.state != transferring {
+	// The transfer keeps running while the cancel confirmation is shown
+	if m.state != transferring && m.state != cancelConfirm {
 		return m, nil
 	}
 
 	if msg.Msg.Complete {
 		m.clearAllSelections()
+		m.resetTransferStates()
 		m.state = normal
-		m.progress.SetPercent(0)
+		// The library view only has the error line for notes
+		m.setInfo(syncSummary("copied", msg.Msg.Progress.FilesDone, msg.Msg.Progress.Skipped))
+		m.smoother = progressSmoother{}
 		m.transferProgress = internal.TransferProgress{}
 		m.loading.drivePodcasts = true
 		var cmds []tea.Cmd
 		cmds = append(cmds, getDrivePodcasts(m.currentDrive, m.podcasts))
+		if m.currentDrive.Shared && msg.Msg.Progress.FilesDone > 0 {
+			cmds = append(cmds, m.recordLastSync(time.Now()))
+		}
 		if m.dbgEnabled {
-			cmds = append(cmds, addDebugMsg("FileOpMsg", fmt.Sprintf("Operation: %s, Complete: %t, Error: %v", msg.Operation, msg.Msg.Complete, msg.Msg.Error)))
+			cmds = append(cmds, addDebugMsg(debugLevel(msg.Msg.Error), "FileOpMsg", fmt.Sprintf("Operation: %s, Complete: %t, Error: %v", msg.Operation, msg.Msg.Complete, msg.Msg.Error)))
 		}
 		return m, tea.Batch(cmds...)
 	}
 
 	m.transferProgress = msg.Msg.Progress
+	m.applyTransferStates()
+	m.checkStall(time.Now())
+	m.smoother.report(m.transferProgress, time.Now())
 
 	var cmds []tea.Cmd
-	cmds = append(cmds, m.progress.SetPercent(m.transferProgress.CurrentProgress), m.syncManager.wait())
+	cmds = append(cmds, m.syncManager.wait())
 
 	if m.dbgEnabled {
-		cmds = append(cmds, addDebugMsg("FileOpMsg", fmt.Sprintf("Operation: %s, BytesTransferred: %.1f, Error: %v", msg.Operation, float64(msg.Msg.Progress.BytesTransferred), msg.Msg.Error)))
+		cmds = append(cmds, addDebugMsg(debugLevel(msg.Msg.Error), "FileOpMsg", fmt.Sprintf("Operation: %s, BytesTransferred: %.1f, Error: %v", msg.Operation, float64(msg.Msg.Progress.BytesTransferred), msg.Msg.Error)))
 	}
 	return m, tea.Batch(cmds...)
 }
@@ -259,10 +495,16 @@ func (m *Model) handleDeletePodcasts() (tea.Model, tea.Cmd) {
 			selected = append(selected, p)
 		}
 	}
-	return m, deletePodcasts(selected)
+	return m, tea.Batch(
+		deletePodcasts(selected, m.currentDrive, m.history),
+		m.remember(internal.NewAction(internal.ActionDelete, m.currentDrive.Name, selected)),
+	)
 }
 
 func (m *Model) handlePodcastSelection() (tea.Model, tea.Cmd) {
+	if m.selecting != nil {
+		return m.selectRange()
+	}
 	var (
 		listToUpdate *list.Model
 		sourceList   *[]internal.PodcastEpisode
@@ -280,6 +522,14 @@ func (m *Model) handlePodcastSelection() (tea.Model, tea.Cmd) {
 	if listToUpdate != nil && sourceList != nil {
 		if selectedItem := listToUpdate.SelectedItem(); selectedItem != nil {
 			if episode, ok := selectedItem.(internal.PodcastEpisode); ok {
+				if episode.Downloading && !episode.Selected {
+					m.errorMsg = fmt.Sprintf("%s is still downloading", episode.ZTitle)
+					return m, nil
+				}
+				if episode.Partial && !episode.Selected {
+					m.errorMsg = fmt.Sprintf("%s hasn't finished copying; the next sync resumes it", episode.ZTitle)
+					return m, nil
+				}
 				episode.Selected = !episode.Selected
 				items := listToUpdate.Items()
 				for j, item := range items {
@@ -288,7 +538,7 @@ func (m *Model) handlePodcastSelection() (tea.Model, tea.Cmd) {
 						break
 					}
 				}
-				listToUpdate.SetItems(items)
+				setListItems(listToUpdate, items)
 
 				for k, podcast := range *sourceList {
 					if podcast.FilePath == episode.FilePath {
@@ -296,6 +546,10 @@ func (m *Model) handlePodcastSelection() (tea.Model, tea.Cmd) {
 						break
 					}
 				}
+				// The show's node counts its selected episodes
+				if m.groupShows && m.focusIndex == 0 {
+					m.refreshMacItems()
+				}
 			}
 		}
 	}
@@ -303,27 +557,87 @@ func (m *Model) handlePodcastSelection() (tea.Model, tea.Cmd) {
 }
 
 func (m *Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if handler, ok := m.popupKeyHandler(); ok {
+		return handler(msg)
+	}
+	if model, cmd, refused := m.refuseWrite(msg); refused {
+		return model, cmd
+	}
+
 	switch {
 	case key.Matches(msg, keys.Quit):
 		if m.state == transferring || m.state == syncing {
-			return m, tea.Sequence(m.syncManager.cancel(), tea.Quit)
+			// Quitting never prompts, so an unset policy falls back to deleting the partial file
+			policy := m.config.PartialFiles
+			if policy == internal.PartialAsk {
+				policy = internal.PartialDelete
+			}
+			return m, tea.Sequence(m.syncManager.cancel(policy), tea.Quit)
 		}
 		return m, tea.Quit
 	case key.Matches(msg, keys.Escape):
-		if m.state == transferring || m.state == syncing {
-			m.clearAllSelections()
-			m.state = normal
-			m.progress.SetPercent(0)
-			m.loading.drivePodcasts = true
-			return m, tea.Sequence(m.syncManager.cancel(), getDrivePodcasts(m.currentDrive, m.podcasts))
+		if m.state == transferring {
+			m.state = cancelConfirm
+			return m, nil
+		}
+		if m.state == syncing {
+			return m.cancelSync(internal.PartialDelete)
+		}
+		if m.state == normal && m.macFilter != nil {
+			m.setMacFilter(nil)
+			return m, nil
 		}
 		m.state = normal
 		return m, nil
+	case key.Matches(msg, m.transferKeys.Retry):
+		return m.resolveStall(internal.FileRetry)
+	case key.Matches(msg, m.transferKeys.Skip):
+		return m.resolveStall(internal.FileSkip)
+	case key.Matches(msg, keys.Background):
+		return m.toggleBackground()
+	case key.Matches(msg, transferKeys.Minimize) && m.state == transferring:
+		// Otherwise the key starts a range selection
+		m.transferMinimized = !m.transferMinimized
+		return m, nil
 	case key.Matches(msg, keys.SelectDrive):
 		if m.state != transferring && m.state != syncing {
 			m.state = driveSelection
 		}
 		return m, nil
+	case key.Matches(msg, keys.AddFolder):
+		if m.state == driveSelection {
+			return m, pickLocalFolder()
+		}
+		return m, nil
+	case key.Matches(msg, keys.Benchmark):
+		if m.state == driveSelection {
+			return m.benchmarkDrive()
+		}
+		return m, nil
+	case key.Matches(msg, keys.DriveInfo) && m.state == driveSelection:
+		// Otherwise the key inverts the selection
+		return m.showDriveDetails()
+	case key.Matches(msg, keys.QuickLists):
+		if m.state == normal {
+			m.state = quickLists
+			m.selectLastQuickList()
+		}
+		return m, nil
+	case key.Matches(msg, keys.HideMissing):
+		if m.state == normal {
+			m.hideMissing = !m.hideMissing
+			m.refreshMacItems()
+			m.macPodcasts.Select(0)
+		}
+		return m, nil
+	case key.Matches(msg, keys.Search):
+		if m.state == normal {
+			m.state = search
+			m.searchInput.SetValue("")
+			m.searchResults.SetItems(nil)
+			return m, m.searchInput.Focus()
+		}
+		return m, nil
 	case key.Matches(msg, keys.Debug):
 		if m.dbgEnabled && m.state != transferring && m.state != syncing {
 			m.state = debug
@@ -335,11 +649,15 @@ func (m *Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 	case key.Matches(msg, keys.Up):
+		if m.state == transferring && !m.transferMinimized {
+			m.scrollTransferQueue(-1)
+			return m, nil
+		}
 		if m.state == driveSelection {
 			m.driveSelector.CursorUp()
 		}
-		if m.state == debug {
-			m.debug.CursorUp()
+		if m.state == quickLists {
+			m.quickLists.CursorUp()
 		}
 		if m.focusIndex == 0 {
 			m.macPodcasts.CursorUp()
@@ -348,11 +666,15 @@ func (m *Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 	case key.Matches(msg, keys.Down):
+		if m.state == transferring && !m.transferMinimized {
+			m.scrollTransferQueue(1)
+			return m, nil
+		}
 		if m.state == driveSelection {
 			m.driveSelector.CursorDown()
 		}
-		if m.state == debug {
-			m.debug.CursorDown()
+		if m.state == quickLists {
+			m.quickLists.CursorDown()
 		}
 		if m.focusIndex == 0 {
 			m.macPodcasts.CursorDown()
@@ -374,55 +696,204 @@ func (m *Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, nil
 	case key.Matches(msg, keys.Enter):
 		if m.state == driveSelection && len(m.drives) > 0 {
-			m.currentDrive = m.driveSelector.SelectedItem().(internal.USBDrive)
-			m.loading.drivePodcasts = true
 			m.state = normal
-			return m, tea.Sequence(getMacPodcasts, getDrivePodcasts(m.currentDrive, m.podcasts))
+			return m.switchDrive(m.driveSelector.SelectedItem().(internal.USBDrive))
+		}
+		if m.state == quickLists {
+			m.state = normal
+			if item, ok := m.quickLists.SelectedItem().(quickListItem); ok {
+				remember := m.remember(internal.Action{Kind: internal.ActionFilter, Filter: item.title})
+				if item.kind == quickListFavorites {
+					m.setMacFilter(favoritesFilter)
+					m.focusIndex = 0
+					return m, remember
+				}
+				return m, tea.Batch(loadQuickList(m.history, item), remember)
+			}
+			return m, nil
 		}
 		if m.state == confirm {
 			return m.handleDeletePodcasts()
 		}
+		if m.state == normal && m.focusIndex == 0 {
+			return m.toggleShowExpanded()
+		}
 		return m, nil
 	case key.Matches(msg, confirmKeys.Yes):
 		if m.state == confirm {
 			return m.handleDeletePodcasts()
 		}
 		return m, nil
+	case key.Matches(msg, keys.CopyPath):
+		if m.state == normal {
+			return m.copyEpisode(copyDestination)
+		}
+		return m, nil
+	case key.Matches(msg, keys.CopySource):
+		if m.state == normal {
+			return m.copyEpisode(copySource)
+		}
+		return m, nil
+	case key.Matches(msg, keys.CopySummary):
+		if m.state == normal {
+			return m.copyEpisode(copySummary)
+		}
+		return m, nil
+	case key.Matches(msg, keys.Reveal):
+		if m.state == normal {
+			return m.revealEpisode()
+		}
+		return m, nil
+	case key.Matches(msg, keys.OpenFolder):
+		if m.state == normal {
+			return m.openShowFolder()
+		}
+		return m, nil
+	case key.Matches(msg, keys.OpenInApp):
+		if m.state == normal {
+			return m.openInPodcastsApp()
+		}
+		return m, nil
+	case key.Matches(msg, keys.Unplayed):
+		if m.state == normal {
+			return m.toggleMacFilter(unplayedFilter)
+		}
+		return m, nil
+	case key.Matches(msg, keys.NotOnDrive):
+		if m.state == normal {
+			return m.toggleMacFilter(notOnDriveFilter)
+		}
+		return m, nil
+	case key.Matches(msg, keys.RecentlyNew):
+		if m.state == normal {
+			return m.toggleMacFilter(recentDownloadsFilter)
+		}
+		return m, nil
+	case key.Matches(msg, keys.SwitchDrive):
+		if m.state == normal {
+			return m.switchDriveNumber(msg.String())
+		}
+		return m, nil
+	case key.Matches(msg, keys.GroupShows):
+		if m.state == normal {
+			return m.toggleGroupShows()
+		}
+		return m, nil
+	case key.Matches(msg, keys.SelectShow):
+		if m.state == normal {
+			return m.selectShow()
+		}
+		return m, nil
+	case key.Matches(msg, keys.Invert):
+		if m.state == normal {
+			return m.invertSelection()
+		}
+		return m, nil
+	case key.Matches(msg, keys.SelectNew):
+		if m.state == normal {
+			return m.selectUnplayed()
+		}
+		return m, nil
+	case key.Matches(msg, keys.RangeSelect):
+		if m.state == normal {
+			return m.toggleRangeSelection()
+		}
+		return m, nil
+	case key.Matches(msg, keys.Sort):
+		if m.state == normal {
+			return m.cycleSort()
+		}
+		return m, nil
+	case key.Matches(msg, keys.Dismiss):
+		m.dismissError()
+		return m, nil
 	case key.Matches(msg, keys.Refresh):
+		if m.errorRetry != retryNone {
+			return m.retryError()
+		}
 		m.loading.macPodcasts = true
 		m.loading.drivePodcasts = true
-		m.errorMsg = ""
-		return m, tea.Sequence(getMacPodcasts, getDrivePodcasts(m.currentDrive, m.podcasts))
+		m.dismissError()
+		return m, tea.Sequence(m.loadLibrary, getDrivePodcasts(m.currentDrive, m.podcasts))
 	case key.Matches(msg, keys.Space):
 		return m.handlePodcastSelection()
 	case key.Matches(msg, keys.Sync):
-		if m.state != transferring && m.state != syncing {
-			anySelected := false
-			for i := range m.podcasts {
-				if m.podcasts[i].Selected {
-					anySelected = true
-					break
+		if m.state == transferring {
+			var selected []internal.PodcastEpisode
+			for _, p := range m.podcasts {
+				if p.Selected && p.TransferState == internal.FileNone {
+					selected = append(selected, p)
 				}
 			}
-			if anySelected {
-				var selected []internal.PodcastEpisode
-				for _, p := range m.podcasts {
-					if p.Selected {
-						selected = append(selected, p)
-					}
-				}
-				m.state = syncing
-				return m, m.syncManager.start(selected, m.currentDrive)
+			if len(selected) > 0 {
+				return m, m.syncManager.appendEpisodes(selected)
 			}
+			return m, nil
+		}
+		if m.state != transferring && m.state != syncing {
+			return m.confirmSync()
+		}
+		return m, nil
+	case key.Matches(msg, keys.Preview):
+		if m.state == normal {
+			return m, previewDestinations(m.podcasts, m.currentDrive, m.history, false)
 		}
 		return m, nil
 	case key.Matches(msg, keys.SyncAll):
 		if m.state != transferring && m.state != syncing {
 			for i := range m.podcasts {
-				m.podcasts[i].Selected = true
+				never := m.config.PolicyFor(m.podcasts[i].ShowName).Sync == internal.SyncNever
+				if m.isVisible(m.podcasts[i]) && !m.podcasts[i].Missing && !m.podcasts[i].Downloading && !never {
+					m.podcasts[i].Selected = true
+				}
 			}
-			m.state = syncing
-			return m, m.syncManager.start(m.podcasts, m.currentDrive)
+			m.refreshMacItems()
+			return m.confirmSync()
+		}
+		return m, nil
+	case key.Matches(msg, keys.Favorite):
+		if m.state == normal {
+			return m.toggleFavorite()
+		}
+		return m, nil
+	case key.Matches(msg, keys.ShowPolicy):
+		if m.state == normal {
+			return m.openShowPolicy()
+		}
+		return m, nil
+	case key.Matches(msg, keys.Queue):
+		if m.state == normal {
+			return m.openQueueBuilder()
+		}
+		return m, nil
+	case key.Matches(msg, keys.Repeat):
+		if m.state == normal {
+			return m.repeatLastSync()
+		}
+		return m, nil
+	case key.Matches(msg, keys.Pin):
+		if m.state == normal && m.focusIndex == 1 {
+			return m.togglePin()
+		}
+		return m, nil
+	case key.Matches(msg, keys.Rename):
+		if m.state == normal && m.focusIndex == 1 {
+			return m.openRename(false)
+		}
+		return m, nil
+	case key.Matches(msg, keys.RenameShow):
+		if m.state == normal && m.focusIndex == 1 {
+			return m.openRename(true)
+		}
+		return m, nil
+	case key.Matches(msg, keys.Undo):
+		if m.state == normal {
+			return m, loadJournal(m.currentDrive)
+		}
+		return m, nil
+	case key.Matches(msg, keys.Prune):
+		if m.state == normal {
+			return m.pruneToKeepLimits()
 		}
 		return m, nil
 	case key.Matches(msg, keys.Delete):
@@ -441,15 +912,88 @@ func (m *Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		if len(m.podcastsDrive) == 0 {
 			return m, nil
 		}
+		anySelected := false
 		for i := range m.podcastsDrive {
-			m.podcastsDrive[i].Selected = true
+			// Favorites survive bulk deletes when the config asks to keep them, and unfinished
+			// copies are left for the next sync to resume
+			retained := m.config.Retains(m.podcastsDrive[i]) || !selectable(m.podcastsDrive[i])
+			m.podcastsDrive[i].Selected = !retained
+			anySelected = anySelected || !retained
+		}
+		if anySelected {
+			return m.confirmBulkDelete()
 		}
-		m.state = confirm
 		return m, nil
 	}
 	return m, nil
 }
 
+// handleSearchKey routes keys to the search popup; anything that isn't a search command is typed into the query
+func (m *Model) handleSearchKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case msg.Type == tea.KeyCtrlC:
+		return m, tea.Quit
+	case key.Matches(msg, searchKeys.Close):
+		m.state = normal
+		m.searchInput.Blur()
+		return m, nil
+	case key.Matches(msg, searchKeys.Up):
+		m.searchResults.CursorUp()
+		return m, nil
+	case key.Matches(msg, searchKeys.Down):
+		m.searchResults.CursorDown()
+		return m, nil
+	case key.Matches(msg, searchKeys.Jump):
+		return m.jumpToSearchResult()
+	case key.Matches(msg, searchKeys.Content):
+		m.searchContent = !m.searchContent
+		return m, runSearch(m.podcasts, m.searchInput.Value(), m.searchContent)
+	}
+
+	previous := m.searchInput.Value()
+	var cmd tea.Cmd
+	m.searchInput, cmd = m.searchInput.Update(msg)
+	if m.searchInput.Value() == previous {
+		return m, cmd
+	}
+	return m, tea.Batch(cmd, runSearch(m.podcasts, m.searchInput.Value(), m.searchContent))
+}
+
+func (m *Model) handleSearchResults(msg SearchResultsMsg) (tea.Model, tea.Cmd) {
+	// Drop results for a query the user has already typed past
+	if msg.Query != m.searchInput.Value() || msg.Content != m.searchContent {
+		return m, nil
+	}
+
+	items := make([]list.Item, len(msg.Results))
+	for i, r := range msg.Results {
+		items[i] = r.Episode
+	}
+	m.searchResults.SetItems(items)
+	m.searchResults.Select(0)
+	return m, nil
+}
+
+// jumpToSearchResult closes the search popup and moves the Mac list cursor to the chosen episode
+func (m *Model) jumpToSearchResult() (tea.Model, tea.Cmd) {
+	selected, ok := m.searchResults.SelectedItem().(internal.PodcastEpisode)
+	if !ok {
+		return m, nil
+	}
+
+	for i, item := range m.macPodcasts.Items() {
+		if ep, ok := item.(internal.PodcastEpisode); ok && ep.FilePath == selected.FilePath {
+			m.macPodcasts.Select(i)
+			break
+		}
+	}
+
+	m.focusIndex = 0
+	m.state = normal
+	m.searchInput.Blur()
+	return m, nil
+}
+
 // clearAllSelections clears the selected state for all episodes
 func (m *Model) clearAllSelections() {
 	for i := range m.podcasts {
@@ -462,4 +1006,118 @@ func (m *Model) clearAllSelections() {
 			items[i] = ep
 		}
 	}
+	// A filter shows copies of the items, which would still look selected
+	setListItems(&m.macPodcasts, items)
+	if m.groupShows {
+		m.refreshMacItems()
+	}
+}
+
+// missingSummary lists the selected episodes left out of a sync because Podcasts.app
+// removed their downloads after the library was loaded
+func missingSummary(missing []internal.PodcastEpisode) string {
+	downloading := slices.DeleteFunc(slices.Clone(missing), func(p internal.PodcastEpisode) bool { return !p.Downloading })
+	gone := slices.DeleteFunc(slices.Clone(missing), func(p internal.PodcastEpisode) bool { return p.Downloading })
+	var parts []string
+	if len(gone) > 0 {
+		parts = append(parts, fmt.Sprintf("Skipping %d episode(s) no longer downloaded: %s", len(gone), episodeTitles(gone)))
+	}
+	if len(downloading) > 0 {
+		parts = append(parts, fmt.Sprintf("Skipping %d episode(s) still downloading: %s", len(downloading), episodeTitles(downloading)))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// syncSummary counts what a sync copies and which selected episodes it skips as already on the
+// drive, e.g. "3 to copy · 12 already on drive: A, B, C and 9 more"
+func syncSummary(copied string, files int, skipped []internal.PodcastEpisode) string {
+	summary := fmt.Sprintf("%d %s", files, copied)
+	if len(skipped) > 0 {
+		summary += fmt.Sprintf(" · %d already on drive: %s", len(skipped), episodeTitles(skipped))
+	}
+	return summary
+}
+
+// episodeTitles names the first few episodes, e.g. "A, B, C and 9 more"
+func episodeTitles(episodes []internal.PodcastEpisode) string {
+	const maxTitles = 3
+	titles := make([]string, 0, maxTitles)
+	for _, episode := range episodes[:min(len(episodes), maxTitles)] {
+		titles = append(titles, episode.ZTitle)
+	}
+	summary := strings.Join(titles, ", ")
+	if len(episodes) > maxTitles {
+		summary += fmt.Sprintf(" and %d more", len(episodes)-maxTitles)
+	}
+	return summary
+}
+
+func (m *Model) handleCancelConfirmKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.cancelKeys.Delete):
+		return m.cancelSync(internal.PartialDelete)
+	case key.Matches(msg, m.cancelKeys.Keep):
+		return m.cancelSync(internal.PartialKeep)
+	case key.Matches(msg, m.cancelKeys.Yes):
+		return m.cancelSync(m.config.PartialFiles)
+	case key.Matches(msg, m.cancelKeys.No):
+		m.state = transferring
+	case key.Matches(msg, keys.Quit):
+		m.state = transferring
+		return m.handleKey(msg)
+	}
+	return m, nil
+}
+
+// cancelSync stops the running sync and returns to the library
+func (m *Model) cancelSync(policy internal.PartialFilePolicy) (tea.Model, tea.Cmd) {
+	m.clearAllSelections()
+	m.resetTransferStates()
+	m.state = normal
+	m.smoother = progressSmoother{}
+	m.loading.drivePodcasts = true
+	return m, tea.Sequence(m.syncManager.cancel(policy), getDrivePodcasts(m.currentDrive, m.podcasts))
+}
+
+func (m *Model) handleSyncAppended(msg SyncAppendedMsg) (tea.Model, tea.Cmd) {
+	if m.state != transferring {
+		return m, nil
+	}
+	switch {
+	case msg.Err != nil:
+		m.statusMsg = fmt.Sprintf("Could not add to sync: %v", msg.Err)
+	case msg.Files == 0:
+		m.statusMsg = "Selected episodes are already queued or on the drive"
+	default:
+		m.statusMsg = fmt.Sprintf("Added %d episode(s) to the sync", msg.Files)
+	}
+	m.applyTransferStates()
+	return m, nil
+}
+
+// applyTransferStates copies the per-episode states of the running sync onto the Mac list
+func (m *Model) applyTransferStates() {
+	states, version, ok := m.syncManager.fileStates()
+	if !ok || version == m.transferStatesVersion {
+		return
+	}
+	m.transferStatesVersion = version
+
+	for i := range m.podcasts {
+		m.podcasts[i].TransferState = states[m.podcasts[i].FilePath]
+	}
+	m.refreshMacItems()
+}
+
+// resetTransferStates removes the transfer glyphs once a sync has finished or been cancelled
+func (m *Model) resetTransferStates() {
+	m.transferStatesVersion = 0
+	m.transferMinimized = false
+	m.transferQueueOffset, m.transferQueueScrolled = 0, false
+	m.statusMsg = ""
+	m.clearStall()
+	for i := range m.podcasts {
+		m.podcasts[i].TransferState = internal.FileNone
+	}
+	m.refreshMacItems()
 }
@@ -4,13 +4,19 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
+	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/lipgloss"
 
 	"github.com/joncrangle/podcasts-sync/internal"
 )
 
 func (m Model) View() string {
+	if m.publishState {
+		m.publishDebugState()
+	}
+
 	if m.width == 0 {
 		return "Loading..."
 	}
@@ -19,9 +25,21 @@ func (m Model) View() string {
 	viewRenderers := map[state]func() string{
 		driveSelection: m.renderDriveSelection,
 		debug:          m.renderDebug,
+		syncing:        m.renderSyncing,
 		transferring:   m.renderTransfer,
 		confirm:        m.renderConfirm,
 		normal:         m.renderNormal,
+		search:         m.renderSearch,
+		quickLists:     m.renderQuickLists,
+		cancelConfirm:  m.renderCancelConfirm,
+		showPolicy:     m.renderShowPolicy,
+		queueBuilder:   m.renderQueueBuilder,
+		pathPreview:    m.renderPathPreview,
+		driveDetails:   m.renderDriveDetails,
+		firstAid:       m.renderFirstAid,
+		undoLog:        m.renderUndoLog,
+		renaming:       m.renderRename,
+		typedConfirm:   m.renderTypedConfirm,
 	}
 
 	if renderer, ok := viewRenderers[m.state]; ok {
@@ -31,33 +49,98 @@ func (m Model) View() string {
 }
 
 func (m Model) renderDriveSelection() string {
-	popup := popupStyle.Render(m.driveSelector.View())
+	content := m.driveSelector.View()
+	if m.benchmarking != "" {
+		content = lipgloss.JoinVertical(lipgloss.Left, content,
+			progressInfoStyle.Render(fmt.Sprintf("Measuring %s...", m.benchmarking)))
+	}
+	popup := popupStyle.Render(content)
+	return m.centerInWindow(popup)
+}
+
+func (m Model) renderQuickLists() string {
+	popup := popupStyle.Render(m.quickLists.View())
 	return m.centerInWindow(popup)
 }
 
 func (m Model) renderDebug() string {
-	popup := debugStyle(m.debug.View())
+	content := lipgloss.JoinVertical(lipgloss.Left, m.formatChannelStats(), m.debug.View())
+	popup := debugStyle(content)
 	return m.centerInWindow(popup)
 }
 
+// channelStats reads the process-wide progress send counters; tests pin it for stable snapshots
+var channelStats = internal.GetChannelStats
+
+// formatChannelStats summarizes progress sends that never reached the UI
+func (m Model) formatChannelStats() string {
+	stats := channelStats()
+	return progressInfoStyle.Render(fmt.Sprintf(
+		"Progress sends: %d sent · %d dropped (buffer full) · %d after close",
+		stats.Sent, stats.Dropped, stats.Closed,
+	))
+}
+
 func (m Model) renderTransfer() string {
+	if m.transferMinimized {
+		return m.renderLibrary(m.renderTransferFooter())
+	}
+
 	progressBar := m.renderProgressWithSpinner()
 	progressInfo := m.formatProgressInfo(progressBar)
 	help := m.createHelp(progressBar, m.transferHelp.View(m.transferKeys))
 
-	progress := lipgloss.JoinVertical(lipgloss.Left,
-		progressBar,
-		progressInfo,
-		help,
-	)
+	var status string
+	if m.statusMsg != "" {
+		status = progressInfoStyle.Render(m.statusMsg)
+	}
+	if warning := m.stallWarning(lipgloss.Width(progressBar)); warning != "" {
+		status = lipgloss.JoinVertical(lipgloss.Left, status, warning)
+	}
+
+	parts := []string{progressBar, progressInfo}
+	if queue := m.renderTransferQueue(lipgloss.Width(progressBar)); queue != "" {
+		parts = append(parts, queue)
+	}
+	progress := lipgloss.JoinVertical(lipgloss.Left, append(parts, status, help)...)
 
 	popup := popupStyle.Padding(3).Render(progress)
 	return m.centerInWindow(popup)
 }
 
+// renderSyncing shows the sync is starting while it works out which episodes need copying
+func (m Model) renderSyncing() string {
+	info := fmt.Sprintf("Checking what to copy to %s...", m.currentDrive.Name)
+	if m.preparing.Total > 0 {
+		info = fmt.Sprintf("Preparing the sync to %s: %s", m.currentDrive.Name, m.preparing)
+	}
+	content := lipgloss.JoinVertical(lipgloss.Left, m.renderProgressBar(), progressInfoStyle.Render(info))
+	return m.centerInWindow(popupStyle.Padding(3).Render(content))
+}
+
+// renderTransferFooter condenses the transfer popup into a footer below the lists
+func (m Model) renderTransferFooter() string {
+	progressBar := m.renderProgressWithSpinner()
+	info := progressInfoStyle.Render(fmt.Sprintf(
+		"%d/%d files · %.1f MB/s · %s",
+		m.transferProgress.FilesDone,
+		m.transferProgress.TotalFiles,
+		m.transferProgress.Speed/1024/1024,
+		m.transferProgress.CurrentFile,
+	))
+	if m.statusMsg != "" {
+		info = lipgloss.JoinVertical(lipgloss.Left, info, progressInfoStyle.Render(m.statusMsg))
+	}
+	if warning := m.stallWarning(m.width); warning != "" {
+		info = lipgloss.JoinVertical(lipgloss.Left, info, warning)
+	}
+	help := m.createHelp(m.width, m.transferHelp.View(m.transferKeys))
+	return lipgloss.JoinVertical(lipgloss.Left, progressBar, info, help)
+}
+
 func (m Model) renderProgressWithSpinner() string {
 	// Get the basic progress bar
-	progressBar := m.progress.View()
+	progressBar := m.renderProgressBar()
 
 	// Only show spinner during active transfer
 	if m.state == transferring && m.transferProgress.CurrentProgress < 1.0 {
@@ -74,31 +157,77 @@ func (m Model) formatProgressInfo(progressBar string) string {
 		"\nTransferring: %s\n"+
 			"Progress: %d/%d files\n"+
 			"Speed: %.1f MB/s\n"+
-			"Transferred: %s / %s\n",
+			"Transferred: %s / %s\n"+
+			"Remaining: %s\n",
 		m.transferProgress.CurrentFile,
 		m.transferProgress.FilesDone,
 		m.transferProgress.TotalFiles,
 		m.transferProgress.Speed/1024/1024,
 		internal.FormatBytes(m.transferProgress.BytesTransferred),
 		internal.FormatBytes(m.transferProgress.TotalBytes),
+		formatRemaining(m.transferProgress.Remaining(m.currentDrive.Profile.Speed.Write)),
 	))
 }
 
+func (m Model) renderSearch() string {
+	scope := "Searching titles and shows"
+	if m.searchContent {
+		scope = "Searching titles, shows, shownotes and transcripts"
+	}
+
+	results := m.searchResults.View()
+	help := m.createHelp(results, m.searchHelp.View(m.searchKeys))
+	content := lipgloss.JoinVertical(lipgloss.Left,
+		m.searchInput.View(),
+		progressInfoStyle.Render(scope),
+		results,
+		help,
+	)
+
+	popup := popupStyle.Render(content)
+	return m.centerInWindow(popup)
+}
+
 func (m Model) renderConfirm() string {
-	text := "Are you sure you want to delete the selected file(s)?\n\n\n"
+	text := internal.T("Are you sure you want to delete the selected file(s)?") + "\n\n\n"
 	help := m.createHelp(text, m.confirmHelp.View(m.confirmKeys))
 	popup := popupStyle.Render(text + help)
 	return m.centerInWindow(popup)
 }
 
+func (m Model) renderCancelConfirm() string {
+	text := internal.T("Cancel sync?") + "\n\n"
+	switch m.config.PartialFiles {
+	case internal.PartialKeep:
+		text += fmt.Sprintf("The partial copy of %q will be kept\nand resumed by the next sync.\n\n\n", m.transferProgress.CurrentFile)
+	case internal.PartialDelete:
+		text += fmt.Sprintf("The partial copy of %q will be deleted.\n\n\n", m.transferProgress.CurrentFile)
+	default:
+		text += fmt.Sprintf("Keep the partial copy of %q\nto resume it on the next sync, or delete it?\n\n\n", m.transferProgress.CurrentFile)
+	}
+	help := m.createHelp(text, m.confirmHelp.View(m.cancelKeys))
+	popup := popupStyle.Render(text + help)
+	return m.centerInWindow(popup)
+}
+
 func (m Model) renderNormal() string {
+	helpKeys := m.keys
+	if m.readOnly {
+		helpKeys = withoutWrites(helpKeys)
+	}
+	return m.renderLibrary(m.createHelp(m.width, m.help.View(helpKeys)))
+}
+
+// renderLibrary lays out the header and both lists above the given footer
+func (m Model) renderLibrary(help string) string {
 	// Create fixed-size components at their natural size
 	header := m.createHeader()
-	help := m.createHelp(m.width, m.help.View(m.keys))
 
 	var errorSection string
 	if m.errorMsg != "" {
-		errorSection = errorStyle(m.errorMsg)
+		errorSection = m.renderErrorBanner()
+	} else if m.infoMsg != "" {
+		errorSection = m.renderInfoBanner()
 	}
 
 	// Calculate space used by fixed components
@@ -148,11 +277,23 @@ func (m Model) renderNormal() string {
 }
 
 func (m Model) createHeader() string {
+	header := m.createTitleRow()
+	if bar := m.formatDriveBar(); bar != "" {
+		return lipgloss.JoinVertical(lipgloss.Left, header, bar)
+	}
+	return header
+}
+
+func (m Model) createTitleRow() string {
 	title := "🎵 Podcasts Sync 🎤"
 
 	// Try three-part layout if there's enough space
 	driveInfo := m.formatDriveInfo()
 	debug := m.formatDebugInfo()
+	if selection := m.formatSelectionInfo(); selection != "" {
+		// The selection total shares the right-hand slot with the debug marker
+		debug = strings.TrimSpace(selection + " " + debug)
+	}
 	titleRender := headingStyle(title)
 
 	// Calculate if we have enough space
@@ -184,6 +325,9 @@ func (m Model) formatDriveInfo() string {
 		info = fmt.Sprintf("Drive: %s > %s",
 			m.currentDrive.Name,
 			m.currentDrive.Folder)
+		if m.currentDrive.Capacity > 0 {
+			info += fmt.Sprintf(" (%s free)", internal.FormatBytes(m.currentDrive.Free))
+		}
 	}
 	return driveStyle(info)
 }
@@ -192,6 +336,15 @@ func (m Model) formatDebugInfo() string {
 	if os.Getenv("DEBUG") == "true" {
 		return debugTitleStyle("DEBUG MODE")
 	}
+	if m.demo {
+		return debugTitleStyle("DEMO MODE")
+	}
+	if m.readOnly {
+		return debugTitleStyle("READ-ONLY")
+	}
+	if internal.Background() {
+		return debugTitleStyle("BACKGROUND")
+	}
 	return ""
 }
 
@@ -205,6 +358,8 @@ func (m Model) createListsWithConstrainedHeight(availableHeight int) string {
 	m.macPodcasts.Styles.NoItems = m.macPodcasts.Styles.NoItems.Width(m.listWidth).Height(viewportHeight)
 	m.drivePodcasts.SetSize(m.listWidth, viewportHeight)
 	m.drivePodcasts.Styles.NoItems = m.drivePodcasts.Styles.NoItems.Width(m.listWidth).Height(viewportHeight)
+	usePageNumbers(&m.macPodcasts, m.macPodcasts.Paginator.TotalPages)
+	usePageNumbers(&m.drivePodcasts, m.drivePodcasts.Paginator.TotalPages)
 
 	macList := m.createMacList(availableHeight)
 	driveList := m.createDriveList(availableHeight)
@@ -218,10 +373,24 @@ func (m Model) createMacList(height int) string {
 	}
 
 	macListContent := m.macPodcasts.View()
-	help := m.createHelp(m.listWidth, m.macPodcasts.Help.View(macHelpKeys))
+	helpKeys := macHelpKeys
+	if m.readOnly {
+		helpKeys.KeyMap = withoutWrites(helpKeys.KeyMap)
+	}
+	help := m.createHelp(m.listWidth, m.macPodcasts.Help.View(helpKeys))
+	summary := listSummary(m.macPodcasts.Items())
+	if after := m.formatFreeAfterSync(); after != "" && summary != "" {
+		summary += progressInfoStyle.Render(" · ") + after
+	}
+	if m.loading.macPodcasts {
+		summary = progressInfoStyle.Render(m.loadingLine("Loading the library", m.loading.macSince))
+	}
 
 	// Check if list is empty - if so, no padding needed as the list handles its own height
 	if len(m.macPodcasts.Items()) == 0 {
+		if m.loading.macPodcasts {
+			macListContent = loadingOverlay(m.macPodcasts, macListContent, m.loadingLine("Loading the library", m.loading.macSince))
+		}
 		content := lipgloss.JoinVertical(lipgloss.Left, macListContent, help)
 		return style.Width(m.listWidth).Height(height).MarginRight(2).Render(content)
 	}
@@ -232,6 +401,11 @@ func (m Model) createMacList(height int) string {
 	padding := strings.Repeat("\n", paddingCount)
 
 	content := lipgloss.JoinVertical(lipgloss.Left, macListContent, padding, help)
+	if paddingCount > 0 {
+		// The summary takes the place of one line of padding when there is room for it
+		padding = strings.Repeat("\n", paddingCount-1)
+		content = lipgloss.JoinVertical(lipgloss.Left, macListContent, padding, summary, help)
+	}
 	return style.Width(m.listWidth).Height(height).MarginRight(2).Render(content)
 }
 
@@ -242,10 +416,28 @@ func (m Model) createDriveList(height int) string {
 	}
 
 	driveListContent := m.drivePodcasts.View()
-	help := m.createHelp(m.listWidth, m.drivePodcasts.Help.View(driveHelpKeys))
+	helpKeys := driveHelpKeys
+	if m.readOnly {
+		helpKeys.KeyMap = withoutWrites(helpKeys.KeyMap)
+	}
+	help := m.createHelp(m.listWidth, m.drivePodcasts.Help.View(helpKeys))
+	scanning := "Looking for drives"
+	if m.currentDrive.Name != "" {
+		scanning = "Scanning " + m.currentDrive.Name
+	}
+	summary := listSummary(m.drivePodcasts.Items())
+	if m.loadingDrive() {
+		summary = progressInfoStyle.Render(m.loadingLine(scanning, m.loading.driveSince))
+	}
 
 	// Check if list is empty - if so, no padding needed as the list handles its own height
 	if len(m.drivePodcasts.Items()) == 0 {
+		switch {
+		case m.loadingDrive():
+			driveListContent = loadingOverlay(m.drivePodcasts, driveListContent, m.loadingLine(scanning, m.loading.driveSince))
+		case m.currentDrive.Name == "":
+			driveListContent = loadingOverlay(m.drivePodcasts, driveListContent, m.waitingForDrive())
+		}
 		content := lipgloss.JoinVertical(lipgloss.Left, driveListContent, help)
 		return style.Width(m.listWidth).Height(height).MarginLeft(2).Render(content)
 	}
@@ -256,9 +448,101 @@ func (m Model) createDriveList(height int) string {
 	padding := strings.Repeat("\n", paddingCount)
 
 	content := lipgloss.JoinVertical(lipgloss.Left, driveListContent, padding, help)
+	if paddingCount > 0 {
+		// The summary takes the place of one line of padding when there is room for it
+		padding = strings.Repeat("\n", paddingCount-1)
+		content = lipgloss.JoinVertical(lipgloss.Left, driveListContent, padding, summary, help)
+	}
 	return style.Width(m.listWidth).Height(height).MarginLeft(2).Render(content)
 }
 
+// episodeTotals sums the count, duration and size of a set of episodes
+type episodeTotals struct {
+	count    int
+	duration time.Duration
+	size     int64
+}
+
+func (t *episodeTotals) add(episode internal.PodcastEpisode) {
+	t.count++
+	t.duration += episode.Duration
+	t.size += episode.FileSize
+}
+
+// totalItems sums all listed episodes and the selected ones separately
+func totalItems(items []list.Item) (all, selected episodeTotals) {
+	for _, item := range items {
+		episode, ok := item.(internal.PodcastEpisode)
+		if !ok {
+			continue
+		}
+		all.add(episode)
+		if episode.Selected {
+			selected.add(episode)
+		}
+	}
+	return all, selected
+}
+
+// listSummary totals the listed episodes, or only the selected ones when there is a selection,
+// e.g. "3 selected · 2 h 10 m · 180.0 MB"
+func listSummary(items []list.Item) string {
+	all, selected := totalItems(items)
+	if selected.count > 0 {
+		return progressInfoStyle.Render(fmt.Sprintf("%d selected · %s · %s",
+			selected.count, internal.FormatTotalDuration(selected.duration), internal.FormatBytes(selected.size)))
+	}
+	if all.count == 0 {
+		return ""
+	}
+	return progressInfoStyle.Render(fmt.Sprintf("%d episodes · %s · %s",
+		all.count, internal.FormatTotalDuration(all.duration), internal.FormatBytes(all.size)))
+}
+
+// formatSelectionInfo totals the selection across both lists for the header,
+// so it stays visible when the lists have no room for their own summaries
+func (m Model) formatSelectionInfo() string {
+	_, mac := totalItems(m.macPodcasts.Items())
+	_, drive := totalItems(m.drivePodcasts.Items())
+	count := mac.count + drive.count
+	if count == 0 {
+		return ""
+	}
+	info := progressInfoStyle.Render(fmt.Sprintf("Selected: %d · %s · %s", count,
+		internal.FormatTotalDuration(mac.duration+drive.duration), internal.FormatBytes(mac.size+drive.size)))
+	if after := m.formatFreeAfterSync(); after != "" {
+		info += progressInfoStyle.Render(" · ") + after
+	}
+	return info
+}
+
+// formatFreeAfterSync projects the current drive's free space once the selected library episodes
+// not on it yet are copied, warning when they won't fit. It is empty without a selection or while
+// the drive's space is unknown.
+func (m Model) formatFreeAfterSync() string {
+	if m.currentDrive.Capacity <= 0 {
+		return ""
+	}
+	var toCopy int64
+	selected := false
+	for _, p := range m.podcasts {
+		if p.Selected {
+			selected = true
+			if !p.OnDrive {
+				toCopy += p.FileSize
+			}
+		}
+	}
+	if !selected {
+		return ""
+	}
+	free := m.currentDrive.Free - toCopy
+	if free < 0 {
+		return errorStyle(fmt.Sprintf("%s too much for %s", internal.FormatBytes(-free), m.currentDrive.Name))
+	}
+	return progressInfoStyle.Render(fmt.Sprintf("%s free after sync", internal.FormatBytes(free)))
+}
+
 func (m Model) createHelp(width any, helpText string) string {
 	var w int
 	switch v := width.(type) {
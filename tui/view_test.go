@@ -0,0 +1,124 @@
+package tui
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/exp/teatest"
+
+	"github.com/joncrangle/podcasts-sync/internal"
+)
+
+// goldenSizes are the terminal sizes every view is snapshotted at
+var goldenSizes = []struct{ width, height int }{
+	{80, 24},
+	{120, 40},
+	{200, 60},
+}
+
+// goldenModel builds a model with fixed data so views render identically on every run.
+// Snapshots live in testdata/ and are regenerated with `go test ./tui -run TestViews_Golden -update`.
+func goldenModel(t *testing.T, width, height int) Model {
+	t.Helper()
+	t.Setenv("DEBUG", "")
+
+	m := InitialModel()
+	m.config = &internal.Config{}
+	m.errorMsg = ""
+	m.history = nil
+
+	day := func(d int) time.Time { return time.Date(2024, time.March, d, 12, 0, 0, 0, time.UTC) }
+	library := []internal.PodcastEpisode{
+		{ZTitle: "Compilers at Dawn", ShowName: "The Daily Byte", FilePath: "file:///library/1.mp3", Published: day(28), Duration: 25 * time.Minute, FileSize: 24 << 20, OnDrive: true},
+		{ZTitle: "Coastal Path", ShowName: "Long Walks", FilePath: "file:///library/2.mp3", Published: day(27), Duration: 72 * time.Minute, FileSize: 66 << 20, Selected: true},
+		{ZTitle: "The Printing Press", ShowName: "History Hour", FilePath: "file:///library/3.mp3", Published: day(26), Duration: 58 * time.Minute, FileSize: 53 << 20, Favorite: true},
+		{ZTitle: "Bread", ShowName: "Kitchen Science", FilePath: "file:///library/4.mp3", Published: day(25), Duration: 34 * time.Minute, Missing: true},
+	}
+	drive := []internal.PodcastEpisode{
+		{ZTitle: "Compilers at Dawn", ShowName: "The Daily Byte", FilePath: "/Volumes/DEMO STICK/podcasts/The Daily Byte/2024-03-28 - Compilers at Dawn.mp3", Published: day(28), Duration: 25 * time.Minute, FileSize: 24 << 20},
+	}
+
+	updated, _ := m.Update(MacPodcastsMsg(library))
+	m = *updated.(*Model)
+	updated, _ = m.Update(DrivePodcastsMsg{PodcastsDrive: drive})
+	m = *updated.(*Model)
+
+	speed := internal.DriveSpeed{Write: 21.4 * 1024 * 1024, Read: 38 * 1024 * 1024, MeasuredAt: day(1)}
+	m.currentDrive = internal.USBDrive{Name: internal.DemoDriveName, MountPath: "/Volumes/DEMO STICK", Folder: "podcasts", Profile: internal.DriveProfile{Speed: speed}}
+	m.drives = []internal.USBDrive{m.currentDrive, {Name: "CAR", MountPath: "/Volumes/CAR", Folder: "podcasts"}}
+	m.driveSelector.SetItems([]list.Item{m.drives[0], m.drives[1]})
+
+	updated, _ = m.Update(tea.WindowSizeMsg{Width: width, Height: height})
+	return updated.(Model)
+}
+
+func TestViews_Golden(t *testing.T) {
+	previous := channelStats
+	channelStats = func() internal.ChannelStats { return internal.ChannelStats{Sent: 120, Dropped: 3} }
+	t.Cleanup(func() { channelStats = previous })
+
+	transfer := func(m *Model) {
+		m.state = transferring
+		m.transferProgress = internal.TransferProgress{
+			CurrentFile:      "Coastal Path",
+			FilesDone:        1,
+			TotalFiles:       3,
+			Speed:            12.5 * 1024 * 1024,
+			BytesTransferred: 90 << 20,
+			TotalBytes:       143 << 20,
+			CurrentProgress:  0.63,
+		}
+		for i, state := range []internal.FileState{internal.FileDone, internal.FileCopying, internal.FileQueued} {
+			m.podcasts[i].TransferState = state
+		}
+	}
+
+	views := []struct {
+		name  string
+		setup func(m *Model)
+	}{
+		{"normal", func(m *Model) {}},
+		{"transfer", transfer},
+		{"transfer_minimized", func(m *Model) {
+			transfer(m)
+			m.transferMinimized = true
+		}},
+		{"confirm", func(m *Model) { m.state = confirm }},
+		{"cancel_confirm", func(m *Model) {
+			transfer(m)
+			m.state = cancelConfirm
+		}},
+		{"drive_selection", func(m *Model) { m.state = driveSelection }},
+		{"debug", func(m *Model) {
+			m.state = debug
+			at := time.Date(2024, 1, 15, 9, 30, 0, 0, time.Local)
+			m.logDebug(internal.Debug{DTitle: "Layout Debug", DDescription: "screen: 120x40 | reserved: 12 | listHeight: 28", Time: at})
+			m.logDebug(internal.Debug{DTitle: "FileOpMsg", DDescription: "Operation: sync, BytesTransferred: 1024.0, Error: <nil>", Time: at})
+			m.logDebug(internal.Debug{DTitle: "Error", DDescription: "failed to scan CAR", Level: internal.DebugError, Time: at.Add(time.Second)})
+		}},
+		{"search", func(m *Model) {
+			m.state = search
+			m.searchInput.SetValue("walk")
+			m.searchResults.SetItems([]list.Item{m.podcasts[1]})
+		}},
+		{"quick_lists", func(m *Model) { m.state = quickLists }},
+		{"show_policy", func(m *Model) {
+			m.state = showPolicy
+			m.policyShow = "Long Walks"
+			m.policyDraft = internal.ShowPolicy{Sync: internal.SyncAlways, Keep: 5}
+		}},
+	}
+
+	for _, view := range views {
+		for _, size := range goldenSizes {
+			t.Run(fmt.Sprintf("%s/%dx%d", view.name, size.width, size.height), func(t *testing.T) {
+				m := goldenModel(t, size.width, size.height)
+				view.setup(&m)
+				teatest.RequireEqualOutput(t, []byte(m.View()))
+			})
+		}
+	}
+}
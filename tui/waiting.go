@@ -0,0 +1,53 @@
+package tui
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/joncrangle/podcasts-sync/internal"
+)
+
+// queueSyncForDrive holds the selected episodes until a drive is connected, or lets go of them when
+// a sync is already waiting
+func (m *Model) queueSyncForDrive(selected []internal.PodcastEpisode) (tea.Model, tea.Cmd) {
+	if len(m.queuedSync) > 0 {
+		m.queuedSync = nil
+		m.setInfo("Cancelled the sync waiting for a drive")
+		return m, nil
+	}
+	m.queuedSync = selected
+	m.setInfo(fmt.Sprintf("%d episode(s) will sync as soon as a drive is connected", len(selected)))
+	return m, nil
+}
+
+// startQueuedSync syncs the episodes held while no drive was connected, once the drive picked when
+// one appeared has been scanned
+func (m *Model) startQueuedSync() tea.Cmd {
+	if len(m.queuedSync) == 0 || m.state != normal || m.currentDrive.Name == "" {
+		return nil
+	}
+	selected := m.queuedSync
+	m.queuedSync = nil
+	m.logDebug(internal.Debug{DTitle: "Queued sync", DDescription: fmt.Sprintf("%d episode(s) to %s", len(selected), m.currentDrive.Name)})
+	m.state = syncing
+	return tea.Batch(
+		m.syncManager.start(selected, m.currentDrive),
+		m.startProgress(),
+		m.remember(internal.NewAction(internal.ActionSync, m.currentDrive.Name, selected)),
+	)
+}
+
+// waitingForDrive is shown in place of the drive list while no drive is connected
+func (m Model) waitingForDrive() string {
+	text := "Waiting for a drive…\n\nPlug in a drive to see its episodes.\nMeanwhile, select episodes and press s to sync them when it is connected."
+	if len(m.queuedSync) > 0 {
+		var size int64
+		for _, p := range m.queuedSync {
+			size += p.FileSize
+		}
+		text = fmt.Sprintf("Waiting for a drive…\n\n%d episode(s), %s, will sync as soon as one is connected.\nPress s again to cancel.",
+			len(m.queuedSync), internal.FormatBytes(size))
+	}
+	return text
+}
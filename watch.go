@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/joncrangle/podcasts-sync/internal"
+)
+
+// runWatch syncs the episodes of "always" shows to drives as they are mounted, until interrupted
+func runWatch(args []string) error {
+	fs := flag.NewFlagSet("watch", flag.ContinueOnError)
+	interval := fs.Duration("interval", time.Minute, "How often to check for mounted drives")
+	metricsAddr := fs.String("metrics-addr", "", "Serve Prometheus metrics at /metrics on this address (e.g. :9090)")
+	background := fs.Bool("background", false, "Sync at background priority, pausing between chunks so the Mac stays responsive")
+	debugAddr := fs.String("debug-addr", "", "Serve pprof and a state dump, including the watchdog's counts, on this address (e.g. 127.0.0.1:6060)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: podcasts-sync watch [--interval 1m] [--metrics-addr :9090] [--background] [--debug-addr 127.0.0.1:6060]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if *interval <= 0 {
+		return errors.New("--interval must be positive")
+	}
+
+	cfg, err := internal.LoadConfig(internal.DefaultConfigPath())
+	if err != nil {
+		return err
+	}
+	history := internal.NewHistory(internal.DefaultHistoryPath())
+	defer history.Close()
+	drives := internal.NewDriveManager("/Volumes", internal.DirectoryTemplate{})
+	drives.SetProfiles(cfg.Drives)
+	drives.SetMirrors(internal.DefaultMirrorsDir())
+
+	library := func() ([]internal.PodcastEpisode, error) {
+		podcasts, err := internal.LoadMacPodcasts()
+		if err != nil {
+			return nil, err
+		}
+		return internal.LoadLocalPodcasts(podcasts)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	logger := log.New(os.Stderr, "", log.LstdFlags)
+	if *background {
+		if err := internal.SetBackground(true); err != nil {
+			logger.Printf("failed to lower the priority: %v", err)
+		}
+	}
+	watcher := internal.NewWatcher(cfg, drives, history, library, logger)
+	if *metricsAddr != "" {
+		metrics := internal.NewMetrics()
+		addr, err := internal.StartMetricsServer(*metricsAddr, metrics)
+		if err != nil {
+			return err
+		}
+		watcher.SetMetrics(metrics)
+		logger.Printf("serving metrics on http://%s/metrics", addr)
+	}
+	watchdog := internal.NewWatchdog()
+	watcher.SetWatchdog(watchdog)
+	if *debugAddr != "" {
+		addr, err := internal.StartDebugServer(*debugAddr, func() map[string]any {
+			return map[string]any{"watchdog": watchdog.Stats()}
+		})
+		if err != nil {
+			return err
+		}
+		logger.Printf("serving debug state on http://%s/debug/state", addr)
+	}
+	watcher.AddPublisher(internal.NewNotifier(cfg.Drives))
+	if cfg.MQTT != nil {
+		watcher.AddPublisher(internal.NewMQTTPublisher(*cfg.MQTT))
+		logger.Printf("publishing events to %s", cfg.MQTT.Broker)
+	}
+	logger.Printf("watching for drives every %s", *interval)
+	return watcher.Run(ctx, *interval)
+}